@@ -0,0 +1,113 @@
+package artifacts
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	execCommand = exec.Command
+	lookPath    = exec.LookPath
+)
+
+// Uploader uploads the artifacts of the builds
+type Uploader interface {
+	// Upload uploads the files in dir and returns the URLs of them
+	Upload(dir string) ([]string, error)
+}
+
+type s3 struct {
+	bucket   string
+	prefix   string
+	endpoint string
+}
+
+// NewS3 creates the Uploader into the S3 compatible storage at dest like s3://bucket/prefix.
+// The files are uploaded by the AWS CLI, which resolves the credentials in the standard way.
+// The endpoint is set for the storage other than AWS like MinIO.
+func NewS3(dest, endpoint string) (Uploader, error) {
+	u, err := url.Parse(dest)
+	if err != nil || u.Scheme != "s3" || u.Host == "" {
+		return nil, fmt.Errorf("invalid artifacts destination %s: must be like s3://bucket/prefix", dest)
+	}
+
+	if endpoint != "" {
+		e, err := url.Parse(endpoint)
+		if err != nil || (e.Scheme != "http" && e.Scheme != "https") || e.Host == "" {
+			return nil, fmt.Errorf("invalid artifacts endpoint %s: must be an absolute http(s) URL", endpoint)
+		}
+	}
+
+	return &s3{
+		bucket:   u.Host,
+		prefix:   strings.Trim(u.Path, "/"),
+		endpoint: strings.TrimRight(endpoint, "/"),
+	}, nil
+}
+
+// uri returns the S3 URI of the key under the prefix
+func (s *s3) uri(key string) string {
+	return fmt.Sprintf("s3://%s/%s", s.bucket, path.Join(s.prefix, key))
+}
+
+// url returns the URL of the uploaded object, which is path-style for the custom endpoint
+func (s *s3) url(key string) string {
+	if s.endpoint == "" {
+		return s.uri(key)
+	}
+	return fmt.Sprintf("%s/%s/%s", s.endpoint, s.bucket, path.Join(s.prefix, key))
+}
+
+func (s *s3) Upload(dir string) ([]string, error) {
+	if _, err := lookPath("aws"); err != nil {
+		return nil, fmt.Errorf("aws command is required to upload artifacts: %v", err)
+	}
+
+	keys := make([]string, 0)
+	err := filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		keys = append(keys, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list artifacts: %v", err)
+	}
+
+	args := []string{"s3", "cp", "--recursive", "--only-show-errors"}
+	if s.endpoint != "" {
+		args = append(args, "--endpoint-url", s.endpoint)
+	}
+	dest := s.uri("")
+	if !strings.HasSuffix(dest, "/") {
+		dest += "/"
+	}
+	args = append(args, dir, dest)
+
+	cmd := execCommand("aws", args...)
+	stderr := bytes.NewBuffer(nil)
+	cmd.Stderr = stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("failed to upload artifacts to %s: %v: %s", s.uri(""), err, strings.TrimSpace(stderr.String()))
+	}
+
+	urls := make([]string, 0, len(keys))
+	for _, k := range keys {
+		urls = append(urls, s.url(k))
+	}
+	return urls, nil
+}
@@ -0,0 +1,127 @@
+package artifacts
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeExecCommand struct {
+	id      string
+	execCmd func(command string, args ...string) *exec.Cmd
+	command string
+}
+
+func newFakeExecCommand(id string) *fakeExecCommand {
+	c := &fakeExecCommand{}
+	c.id = id
+	c.execCmd = func(name string, args ...string) *exec.Cmd {
+		c.command = fmt.Sprintf("%s %s", name, strings.Join(args, " "))
+		cs := []string{"-test.run=TestHelperProcess", "--", name}
+		cs = append(cs, args...)
+		cmd := exec.Command(os.Args[0], cs...)
+		cmd.Env = []string{"GO_WANT_HELPER_PROCESS=1", fmt.Sprintf("GO_TEST_MODE=%s", id)}
+		return cmd
+	}
+	return c
+}
+
+func TestNewS3(t *testing.T) {
+	testCase := []struct {
+		name        string
+		dest        string
+		endpoint    string
+		expect      Uploader
+		expectError error
+	}{
+		{"success", "s3://bucket/prefix/", "", &s3{bucket: "bucket", prefix: "prefix"}, nil},
+		{"success without prefix", "s3://bucket", "", &s3{bucket: "bucket"}, nil},
+		{"success with endpoint", "s3://bucket/a/b", "http://localhost:9000/", &s3{bucket: "bucket", prefix: "a/b", endpoint: "http://localhost:9000"}, nil},
+		{"failure by scheme", "https://bucket/prefix", "", nil, fmt.Errorf("invalid artifacts destination https://bucket/prefix: must be like s3://bucket/prefix")},
+		{"failure by no bucket", "s3:///prefix", "", nil, fmt.Errorf("invalid artifacts destination s3:///prefix: must be like s3://bucket/prefix")},
+		{"failure by endpoint", "s3://bucket", "localhost:9000", nil, fmt.Errorf("invalid artifacts endpoint localhost:9000: must be an absolute http(s) URL")},
+	}
+
+	for _, tt := range testCase {
+		t.Run(tt.name, func(t *testing.T) {
+			actual, err := NewS3(tt.dest, tt.endpoint)
+
+			assert.Equal(t, tt.expect, actual)
+			assert.Equal(t, tt.expectError, err)
+		})
+	}
+}
+
+func TestUpload(t *testing.T) {
+	defer func() {
+		execCommand = exec.Command
+		lookPath = exec.LookPath
+	}()
+
+	dir, err := ioutil.TempDir("", "artifacts")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	assert.Nil(t, os.MkdirAll(filepath.Join(dir, "test"), 0777))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "builds.log"), []byte("log"), 0666))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "test", "report.html"), []byte("report"), 0666))
+
+	testCase := []struct {
+		name          string
+		id            string
+		uploader      *s3
+		lookPathError error
+		expectCommand string
+		expectURLs    []string
+		expectError   error
+	}{
+		{"success", "SUCCESS_UPLOAD", &s3{bucket: "bucket", prefix: "prefix"}, nil,
+			fmt.Sprintf("aws s3 cp --recursive --only-show-errors %s s3://bucket/prefix/", dir),
+			[]string{"s3://bucket/prefix/builds.log", "s3://bucket/prefix/test/report.html"}, nil},
+		{"success with endpoint", "SUCCESS_UPLOAD", &s3{bucket: "bucket", endpoint: "http://localhost:9000"}, nil,
+			fmt.Sprintf("aws s3 cp --recursive --only-show-errors --endpoint-url http://localhost:9000 %s s3://bucket/", dir),
+			[]string{"http://localhost:9000/bucket/builds.log", "http://localhost:9000/bucket/test/report.html"}, nil},
+		{"failure by upload", "FAIL_UPLOAD", &s3{bucket: "bucket", prefix: "prefix"}, nil,
+			fmt.Sprintf("aws s3 cp --recursive --only-show-errors %s s3://bucket/prefix/", dir),
+			nil, fmt.Errorf("failed to upload artifacts to s3://bucket/prefix: exit status 1: upload failed: Unable to locate credentials")},
+		{"failure by no aws command", "SUCCESS_UPLOAD", &s3{bucket: "bucket", prefix: "prefix"}, errors.New("not found"),
+			"", nil, fmt.Errorf("aws command is required to upload artifacts: not found")},
+	}
+
+	for _, tt := range testCase {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newFakeExecCommand(tt.id)
+			execCommand = c.execCmd
+			lookPath = func(file string) (string, error) {
+				return "/usr/bin/aws", tt.lookPathError
+			}
+
+			urls, err := tt.uploader.Upload(dir)
+
+			assert.Equal(t, tt.expectError, err)
+			assert.Equal(t, tt.expectURLs, urls)
+			assert.Equal(t, tt.expectCommand, c.command)
+		})
+	}
+}
+
+func TestHelperProcess(t *testing.T) {
+	if os.Getenv("GO_WANT_HELPER_PROCESS") != "1" {
+		return
+	}
+	defer os.Exit(0)
+
+	switch os.Getenv("GO_TEST_MODE") {
+	case "SUCCESS_UPLOAD":
+		os.Exit(0)
+	case "FAIL_UPLOAD":
+		fmt.Fprintln(os.Stderr, "upload failed: Unable to locate credentials")
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,104 @@
+package build
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/screwdriver-cd/sd-local/launch"
+)
+
+const (
+	// ArtifactsJobToken is replaced with the job name in Options.ArtifactsPath
+	ArtifactsJobToken = "{job}"
+	// ArtifactsTimeToken is replaced with the time the build started in Options.ArtifactsPath
+	ArtifactsTimeToken = "{time}"
+	// artifactsTimeFormat is the format of ArtifactsTimeToken, which sorts the runs by the name
+	artifactsTimeFormat = "20060102150405"
+)
+
+var artifactsTokenPattern = regexp.MustCompile(`\{[^{}/]*\}`)
+
+// ValidateArtifactsDir returns an error if the artifacts directory has a token other than {job} and {time}
+func ValidateArtifactsDir(dir string) error {
+	for _, token := range artifactsTokenPattern.FindAllString(dir, -1) {
+		if token != ArtifactsJobToken && token != ArtifactsTimeToken {
+			return fmt.Errorf("invalid token %s in artifacts-dir: must be %s or %s", token, ArtifactsJobToken, ArtifactsTimeToken)
+		}
+	}
+	return nil
+}
+
+// artifactsJobName returns the name {job} is replaced with, which joins the names of the jobs with - for multiple jobs
+// whose artifacts are in the subdirectories of the job names
+func artifactsJobName(jobNames []string) string {
+	return launch.ContainerName(jobNames...)
+}
+
+// expandArtifactsDir replaces the tokens of the artifacts directory with the job name and the time the build started
+func expandArtifactsDir(dir, jobName string, t time.Time) string {
+	return strings.NewReplacer(ArtifactsJobToken, jobName, ArtifactsTimeToken, t.Format(artifactsTimeFormat)).Replace(dir)
+}
+
+// ArtifactsRoot returns the artifacts directory before the tokens, which has the artifacts of all the builds
+func ArtifactsRoot(dir string) string {
+	for artifactsTokenPattern.MatchString(dir) {
+		dir = filepath.Dir(dir)
+	}
+	return dir
+}
+
+// pruneArtifacts removes the oldest directories of the runs made by {time} of the artifacts directory beyond `keep`,
+// and returns the removed directories. The runs of the other jobs are kept if the directories have {job}.
+func pruneArtifacts(dir, jobName string, keep int) ([]string, error) {
+	// the directories of the runs are the first path element with {time}
+	run := filepath.Clean(dir)
+	for strings.Contains(filepath.Dir(run), ArtifactsTimeToken) {
+		run = filepath.Dir(run)
+	}
+	parent := strings.Replace(filepath.Dir(run), ArtifactsJobToken, jobName, -1)
+
+	var pattern strings.Builder
+	pattern.WriteString("^")
+	for i, part := range strings.Split(filepath.Base(run), ArtifactsTimeToken) {
+		if i > 0 {
+			pattern.WriteString(`(\d{14})`)
+		}
+		pattern.WriteString(regexp.QuoteMeta(strings.Replace(part, ArtifactsJobToken, jobName, -1)))
+	}
+	pattern.WriteString("$")
+	runPattern := regexp.MustCompile(pattern.String())
+
+	infos, err := ioutil.ReadDir(parent)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the artifacts in %s: %v", parent, err)
+	}
+	var runs []string
+	for _, info := range infos {
+		if info.IsDir() && runPattern.MatchString(info.Name()) {
+			runs = append(runs, info.Name())
+		}
+	}
+	if len(runs) <= keep {
+		return nil, nil
+	}
+
+	// the runs are sorted by the time, which is the first {time} of the name
+	sort.Slice(runs, func(i, j int) bool {
+		return runPattern.FindStringSubmatch(runs[i])[1] > runPattern.FindStringSubmatch(runs[j])[1]
+	})
+	removed := make([]string, 0, len(runs)-keep)
+	for _, name := range runs[keep:] {
+		path := filepath.Join(parent, name)
+		if err := os.RemoveAll(path); err != nil {
+			return removed, fmt.Errorf("failed to remove the artifacts %s: %v", path, err)
+		}
+		removed = append(removed, path)
+	}
+	return removed, nil
+}
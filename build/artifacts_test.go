@@ -0,0 +1,89 @@
+package build
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateArtifactsDir(t *testing.T) {
+	assert.Nil(t, ValidateArtifactsDir("sd-artifacts"))
+	assert.Nil(t, ValidateArtifactsDir("sd-artifacts/{job}/{time}"))
+	assert.Equal(t, "invalid token {date} in artifacts-dir: must be {job} or {time}", ValidateArtifactsDir("sd-artifacts/{date}").Error())
+}
+
+func TestExpandArtifactsDir(t *testing.T) {
+	start := time.Date(2021, 1, 2, 15, 4, 5, 0, time.UTC)
+	assert.Equal(t, "sd-artifacts", expandArtifactsDir("sd-artifacts", "main", start))
+	assert.Equal(t, "sd-artifacts/main/20210102150405", expandArtifactsDir("sd-artifacts/{job}/{time}", "main", start))
+	assert.Equal(t, "PR-1-main", artifactsJobName([]string{"PR-1:main"}))
+	assert.Equal(t, "test-lint", artifactsJobName([]string{"test", "lint"}))
+}
+
+func TestArtifactsRoot(t *testing.T) {
+	assert.Equal(t, "/src/sd-artifacts", ArtifactsRoot("/src/sd-artifacts"))
+	assert.Equal(t, "/src/sd-artifacts", ArtifactsRoot("/src/sd-artifacts/{job}/{time}"))
+	assert.Equal(t, "/src", ArtifactsRoot("/src/sd-artifacts-{time}"))
+}
+
+func TestPruneArtifacts(t *testing.T) {
+	testCases := map[string]struct {
+		template string
+		dirs     []string
+		expected []string
+	}{
+		"prune the runs of the job": {
+			template: "{job}/{time}",
+			dirs:     []string{"main/20210101000000", "main/20210103000000", "main/20210102000000", "main/latest", "test/20200101000000"},
+			expected: []string{"main/20210102000000", "main/20210103000000", "main/latest", "test/20200101000000"},
+		},
+		"prune the runs in the name with the job": {
+			template: "run-{time}-{job}/logs",
+			dirs:     []string{"run-20210101000000-main", "run-20210102000000-main", "run-20210103000000-main", "run-20200101000000-test"},
+			expected: []string{"run-20200101000000-test", "run-20210102000000-main", "run-20210103000000-main"},
+		},
+		"keep the runs within the number": {
+			template: "{time}",
+			dirs:     []string{"20210101000000", "20210102000000"},
+			expected: []string{"20210101000000", "20210102000000"},
+		},
+	}
+
+	for name, tt := range testCases {
+		t.Run(name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "sd-artifacts")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(dir)
+			for _, d := range tt.dirs {
+				if err := os.MkdirAll(filepath.Join(dir, d), 0777); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			_, err = pruneArtifacts(filepath.Join(dir, tt.template), "main", 2)
+			assert.Nil(t, err)
+
+			var actual []string
+			err = filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+				if err != nil {
+					return err
+				}
+				rel, _ := filepath.Rel(dir, p)
+				for _, d := range tt.dirs {
+					if rel == d {
+						actual = append(actual, rel)
+					}
+				}
+				return nil
+			})
+			assert.Nil(t, err)
+			assert.Equal(t, tt.expected, actual)
+		})
+	}
+}
@@ -0,0 +1,700 @@
+// Package build runs the jobs of screwdriver.yaml locally like `sd-local build`.
+// It is used by the CLI and can be embedded into the other Go programs like the test harnesses.
+package build
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/screwdriver-cd/sd-local/artifacts"
+	"github.com/screwdriver-cd/sd-local/buildlog"
+	"github.com/screwdriver-cd/sd-local/config"
+	"github.com/screwdriver-cd/sd-local/launch"
+	"github.com/screwdriver-cd/sd-local/pipeline"
+	"github.com/screwdriver-cd/sd-local/screwdriver"
+	"github.com/sirupsen/logrus"
+)
+
+// ErrSkipJob is returned by Options.Prepare to skip the job without running it
+var ErrSkipJob = errors.New("skip the job")
+
+// Options is the options of the builds
+type Options struct {
+	// JobNames is the names of the jobs to run. The jobs run after the jobs they require among them.
+	JobNames []string
+	// PipelineFile is the path to screwdriver.yaml. screwdriver.yaml in the source directory is used if it is empty.
+	PipelineFile string
+	// PipelineID is the ID of the pipeline in Screwdriver.cd to run the jobs of as the API has them instead of PipelineFile
+	PipelineID int
+	// Jobs is the jobs of screwdriver.yaml. They are validated by the API with PipelineFile if it is nil.
+	Jobs map[string]screwdriver.Job
+	// API is the client of the API whose JWT is initialized. It is created with the entry if it is nil.
+	API screwdriver.API
+	// HTTPClient is the client to create the API with. The client with the proxies and the CA bundle of the entry is used if it is nil.
+	HTTPClient *http.Client
+	// UserAgent is the User-Agent of the requests to the API
+	UserAgent string
+	// ArtifactsPath is the host side directory of the artifacts. sd-artifacts in the working directory is used if it is empty.
+	// Every job has its own subdirectory if multiple jobs run. ArtifactsJobToken in it is replaced with the job name,
+	// which joins the names with - for multiple jobs, and ArtifactsTimeToken with the time the Runner is created.
+	ArtifactsPath string
+	// ArtifactsKeep is the number of the runs made by ArtifactsTimeToken of ArtifactsPath to keep.
+	// The oldest runs beyond it are removed after the successful builds. All the runs are kept if it is zero.
+	ArtifactsKeep int
+	// NoLocalArtifacts collects the artifacts into a temporary directory, which is removed when Run returns, only to upload them by Uploader
+	NoLocalArtifacts bool
+	// Uploader uploads the artifacts after the builds even if they failed. The URLs of the uploaded artifacts are in Result.
+	Uploader artifacts.Uploader
+	// MaxParallel is the number of the jobs run at once, 1 if it is zero
+	MaxParallel int
+	// Timeout stops the builds which don't finish within it, and Run fails with the error matched by ErrTimeout.
+	// The builds don't time out if it is zero.
+	Timeout time.Duration
+	// Resume resumes the jobs from the first step which did not succeed by the state of the previous build in their artifacts directories.
+	// The jobs start fresh if the job definition or the source code changed since then.
+	Resume bool
+	// State persists the state of the builds into the artifacts directories to resume them, apart from the interactive mode whose steps are run by the user
+	State bool
+	// Hooks are the commands run on the host when the build of each job finishes, whose output is written into Output
+	Hooks Hooks
+	// Output is where the logs of the builds are written into, which are prefixed with the job name if multiple jobs run.
+	// They are discarded if it is nil.
+	Output io.Writer
+	// Color prints the boundaries of the steps in color
+	Color bool
+	// LogFormat is the format of the logs written into Output, plain, json or tap. It is plain if it is empty.
+	// The logs of json have the job name and the tests of tap are prefixed with it if multiple jobs run instead of the lines.
+	LogFormat string
+	// Quiet writes only the logs of the failed steps and the results of the builds into Output in plain,
+	// holding the last buildlog.QuietLines lines of each step until it finishes
+	Quiet bool
+	// Summary is where the durations of the steps are written into after the builds in SummaryFormat. It is not written if it is nil.
+	Summary io.Writer
+	// SummaryFormat is the format of Summary, the table if it is empty or SummaryJSON
+	SummaryFormat string
+	// SortSummary sorts the steps in Summary by the duration
+	SortSummary bool
+	// Report is the path to write the JSON report of the builds into after them. It is not written if it is empty.
+	Report string
+	// Mask replaces the secrets in the errors written into Report. They are written as they are if it is nil.
+	Mask func(string) string
+	// Events is where the events of the builds like step-started and log-line are written into as newline-delimited JSON
+	// by buildlog.EventWriter, apart from the logs written into Output. They are not written if it is nil.
+	Events io.Writer
+	// Launch is the option of the launchers of the jobs like the source directory and the environment variables.
+	// Job, JobName, Entry, JWT and ArtifactsPath are set for each job. The working directory is used if SrcPath is empty,
+	// and the runtime of the entry is used if Runtime is empty.
+	Launch launch.Option
+	// Prepare is called before the job runs to change the option of its launcher. The job is skipped if it returns ErrSkipJob.
+	Prepare func(option *launch.Option) error
+	// Finish is called after every job Prepare is called for, with the launcher which is nil if the job did not run
+	Finish func(option launch.Option, launcher launch.Launcher, result JobResult)
+	// NewLauncher, NewLogger and MkdirAll create the launchers, the loggers and the artifacts directories.
+	// launch.New, buildlog.New and os.MkdirAll are used if they are nil.
+	NewLauncher func(option launch.Option) launch.Launcher
+	NewLogger   func(filepath string, writer io.Writer, done chan<- struct{}, color bool) (buildlog.Logger, error)
+	MkdirAll    func(path string, perm os.FileMode) error
+	// RunHook runs the command of Hooks and SourceRevision returns the revision of the source code to detect its changes for Resume.
+	// RunHookCommand and GitRevision are used if they are nil.
+	RunHook        func(command string, env []string, out io.Writer) error
+	SourceRevision func(srcPath string) (string, error)
+}
+
+// JobResult is the result of the build of a job
+type JobResult struct {
+	Name          string
+	ArtifactsPath string
+	// Skipped is true if the job is skipped by Options.Prepare
+	Skipped bool
+	// Steps is the timings of the executed steps
+	Steps []buildlog.StepTiming
+	Err   error
+}
+
+// Result is the result of the builds
+type Result struct {
+	// Jobs is the results of the jobs which started in the order of the jobs to run.
+	// The jobs skipped because the jobs they require failed are not included.
+	Jobs []JobResult
+	// URLs is the URLs of the artifacts uploaded by Options.Uploader
+	URLs []string
+}
+
+// Timings returns the timings of the steps of the jobs by the job name
+func (r Result) Timings() map[string][]buildlog.StepTiming {
+	timings := make(map[string][]buildlog.StepTiming, len(r.Jobs))
+	for _, j := range r.Jobs {
+		timings[j.Name] = j.Steps
+	}
+	return timings
+}
+
+// Runner runs the builds of the jobs
+type Runner struct {
+	entry             *config.Entry
+	opts              Options
+	api               screwdriver.API
+	jobs              map[string]screwdriver.Job
+	graph             *pipeline.Graph
+	artifactsTemplate string
+	artifactsPath     string
+	mutex             sync.Mutex
+	launchers         []launch.Launcher
+	killed            bool
+	events            *buildlog.EventWriter
+	// states and results are the states to persist and the results of the jobs which Options.Prepare is called for
+	states  map[string]State
+	results map[string]JobResult
+}
+
+// New creates the Runner of the jobs with the entry of the config.
+// The jobs are validated by the API unless they are given by Options.Jobs.
+func New(entry *config.Entry, opts Options) (*Runner, error) {
+	return NewContext(context.Background(), entry, opts)
+}
+
+// NewContext is New whose requests to the API are cancelled when ctx is done.
+// The API given by Options.API is requested with ctx only if it implements screwdriver.ContextAPI.
+func NewContext(ctx context.Context, entry *config.Entry, opts Options) (*Runner, error) {
+	if entry == nil {
+		return nil, errors.New("entry must not be nil")
+	}
+	if len(opts.JobNames) == 0 {
+		return nil, errors.New("no job to run")
+	}
+	if opts.MaxParallel == 0 {
+		opts.MaxParallel = 1
+	}
+	if opts.MaxParallel < 0 {
+		return nil, fmt.Errorf("max-parallel must be a positive integer: %d", opts.MaxParallel)
+	}
+
+	if opts.Timeout < 0 {
+		return nil, fmt.Errorf("timeout must not be negative: %s", opts.Timeout)
+	}
+	if opts.ArtifactsKeep < 0 {
+		return nil, fmt.Errorf("artifacts-keep must be a positive integer: %d", opts.ArtifactsKeep)
+	}
+	if err := ValidateArtifactsDir(opts.ArtifactsPath); err != nil {
+		return nil, err
+	}
+	if opts.ArtifactsKeep > 0 && !strings.Contains(opts.ArtifactsPath, ArtifactsTimeToken) {
+		return nil, fmt.Errorf("can't keep the runs without %s in the artifacts directory, which makes the directory of each run", ArtifactsTimeToken)
+	}
+	if opts.NoLocalArtifacts && opts.Uploader == nil {
+		return nil, errors.New("can't collect the artifacts only to upload them without the uploader, the artifacts would be lost")
+	}
+	if opts.SummaryFormat != "" && opts.SummaryFormat != SummaryJSON {
+		return nil, fmt.Errorf("invalid summary format %s: only %s is supported", opts.SummaryFormat, SummaryJSON)
+	}
+
+	if opts.LogFormat == "" {
+		opts.LogFormat = buildlog.FormatPlain
+	}
+	if err := buildlog.ValidateFormat(opts.LogFormat); err != nil {
+		return nil, err
+	}
+
+	resolved, err := entry.Resolve()
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.Launch.SrcPath == "" {
+		opts.Launch.SrcPath, err = os.Getwd()
+		if err != nil {
+			return nil, err
+		}
+	}
+	if opts.Launch.Runtime == "" {
+		opts.Launch.Runtime = resolved.Runtime
+	}
+	if opts.ArtifactsPath == "" {
+		opts.ArtifactsPath = launch.ArtifactsDir
+	}
+	opts.ArtifactsPath, err = filepath.Abs(opts.ArtifactsPath)
+	if err != nil {
+		return nil, err
+	}
+	if opts.PipelineFile == "" {
+		opts.PipelineFile = filepath.Join(opts.Launch.SrcPath, "screwdriver.yaml")
+	}
+	if opts.Output == nil {
+		opts.Output = ioutil.Discard
+	}
+	if opts.NewLauncher == nil {
+		opts.NewLauncher = launch.New
+	}
+	if opts.NewLogger == nil {
+		opts.NewLogger = buildlog.New
+	}
+	if opts.MkdirAll == nil {
+		opts.MkdirAll = os.MkdirAll
+	}
+	if opts.RunHook == nil {
+		opts.RunHook = RunHookCommand
+	}
+	if opts.SourceRevision == nil {
+		opts.SourceRevision = GitRevision
+	}
+	if opts.Mask == nil {
+		opts.Mask = func(s string) string { return s }
+	}
+
+	r := &Runner{entry: resolved, opts: opts, api: opts.API, jobs: opts.Jobs}
+	// the tokens are expanded when the Runner is created, so a Runner is created for every run to keep the artifacts of it
+	r.artifactsTemplate = opts.ArtifactsPath
+	r.artifactsPath = expandArtifactsDir(opts.ArtifactsPath, artifactsJobName(opts.JobNames), time.Now())
+	if opts.Events != nil {
+		r.events = buildlog.NewEventWriter(opts.Events)
+	}
+
+	if r.api == nil {
+		httpClient := opts.HTTPClient
+		if httpClient == nil {
+			httpClient, err = screwdriver.NewHTTPClient(screwdriver.HTTPClientOption{
+				HTTPProxy:  resolved.HTTPProxy,
+				HTTPSProxy: resolved.HTTPSProxy,
+				CABundle:   resolved.CABundle,
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+		r.api = screwdriver.New(resolved.APIURL, resolved.Token, opts.UserAgent, httpClient)
+		if err := r.api.(screwdriver.ContextAPI).InitJWTContext(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	if r.jobs == nil && opts.PipelineID != 0 {
+		api, ok := r.api.(screwdriver.PipelineAPI)
+		if !ok {
+			return nil, errors.New("the API does not support getting the jobs of the pipeline")
+		}
+		r.jobs, err = api.PipelineJobs(ctx, opts.PipelineID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if r.jobs == nil {
+		if api, ok := r.api.(screwdriver.ContextAPI); ok {
+			r.jobs, err = api.JobsContext(ctx, opts.PipelineFile)
+		} else {
+			r.jobs, err = r.api.Jobs(opts.PipelineFile)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	r.graph, err = pipeline.New(r.jobs, opts.JobNames)
+	if err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// Names returns the names of the jobs to run in the given order
+func (r *Runner) Names() []string {
+	return r.graph.Names()
+}
+
+// ArtifactsPath returns the artifacts directory of the job
+func (r *Runner) ArtifactsPath(jobName string) string {
+	if len(r.Names()) > 1 {
+		return filepath.Join(r.artifactsPath, jobName)
+	}
+	return r.artifactsPath
+}
+
+// LaunchOption returns the option of the launcher of the job before it is changed by Options.Prepare
+func (r *Runner) LaunchOption(jobName string) launch.Option {
+	option := r.opts.Launch
+	option.Job = r.jobs[jobName]
+	option.JobName = jobName
+	option.Entry = *r.entry
+	option.JWT = r.api.JWT()
+	option.ArtifactsPath = r.ArtifactsPath(jobName)
+	return option
+}
+
+// Run runs the jobs and waits for them to finish. The running builds are killed if ctx is done or they time out,
+// and the jobs which have not started yet don't run. The artifacts are uploaded, and the summary and the report are written
+// after the builds even if they failed, and the old runs of the artifacts are removed after the successful builds.
+func (r *Runner) Run(ctx context.Context) (Result, error) {
+	if r.opts.NoLocalArtifacts {
+		// the artifacts are collected into the temporary directory only to upload them
+		dir, err := ioutil.TempDir("", "sd-local-artifacts")
+		if err != nil {
+			return Result{}, err
+		}
+		defer os.RemoveAll(dir)
+		r.artifactsPath = dir
+	}
+
+	r.mutex.Lock()
+	r.states = make(map[string]State)
+	r.results = make(map[string]JobResult)
+	r.mutex.Unlock()
+
+	result, err := r.runJobs(ctx)
+
+	// the artifacts are uploaded even if the build failed to investigate it
+	if r.opts.Uploader != nil {
+		logrus.Info("Uploading artifacts...")
+		var uploadErr error
+		result.URLs, uploadErr = r.opts.Uploader.Upload(r.artifactsPath)
+		err = firstError(err, uploadErr)
+	}
+
+	names := r.Names()
+	timings := result.Timings()
+	if r.opts.Summary != nil {
+		err = firstError(err, writeSummary(r.opts.Summary, names, timings, r.opts.SummaryFormat, r.opts.SortSummary))
+	}
+
+	if r.opts.Report != "" {
+		r.mutex.Lock()
+		report := newBuildReport(names, r.jobs, r.results, timings, err, result.URLs, !r.opts.NoLocalArtifacts, r.opts.Mask)
+		r.mutex.Unlock()
+		err = firstError(err, writeReport(r.opts.Report, report))
+	}
+
+	// the artifacts of the failed build are kept to investigate it
+	if r.opts.ArtifactsKeep > 0 && err == nil {
+		removed, pruneErr := pruneArtifacts(r.artifactsTemplate, artifactsJobName(r.opts.JobNames), r.opts.ArtifactsKeep)
+		if pruneErr != nil {
+			logrus.Warn(pruneErr)
+		}
+		for _, p := range removed {
+			logrus.Infof("Removed the old artifacts %s", p)
+		}
+	}
+
+	return result, err
+}
+
+// firstError returns err if it is not nil, warning the other error, which is returned otherwise
+func firstError(err, other error) error {
+	if other == nil {
+		return err
+	}
+	if err != nil {
+		logrus.Warn(other)
+		return err
+	}
+	return other
+}
+
+// runJobs runs the jobs and waits for them to finish, which cleans up the builds when it returns
+func (r *Runner) runJobs(ctx context.Context) (Result, error) {
+	defer r.Clean()
+
+	runCtx := ctx
+	if r.opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithTimeout(ctx, r.opts.Timeout)
+		defer cancel()
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-runCtx.Done():
+			if ctx.Err() == nil {
+				logrus.Warnf("build timed out after %s, stopping the build...", formatTimeout(r.opts.Timeout))
+			}
+			r.Kill(syscall.SIGTERM)
+		case <-done:
+		}
+	}()
+
+	names := r.Names()
+	results := make(map[string]JobResult, len(names))
+	var mutex sync.Mutex
+	runJob := func(jobName string, writer io.Writer) error {
+		result := r.runJob(runCtx, jobName, writer)
+		mutex.Lock()
+		results[jobName] = result
+		mutex.Unlock()
+		return result.Err
+	}
+
+	var tap *buildlog.TAP
+	if r.opts.LogFormat == buildlog.FormatTAP {
+		var err error
+		tap, err = buildlog.NewTAP(r.opts.Output)
+		if err != nil {
+			return Result{}, err
+		}
+		defer tap.Close()
+	}
+	outputMutex := &sync.Mutex{}
+	writer := func(jobName string) io.Writer {
+		switch r.opts.LogFormat {
+		case buildlog.FormatJSON:
+			return buildlog.NewJSONFormatter(r.opts.Output, jobName, outputMutex)
+		case buildlog.FormatTAP:
+			if len(names) == 1 {
+				return tap.Formatter("")
+			}
+			return tap.Formatter(jobName)
+		}
+		var w io.Writer = r.opts.Output
+		if len(names) != 1 {
+			w = buildlog.NewPrefixWriter(r.opts.Output, fmt.Sprintf("[%s] ", jobName), outputMutex)
+		}
+		if r.opts.Quiet {
+			return buildlog.NewQuietFormatter(w, buildlog.QuietLines, r.opts.Color)
+		}
+		return w
+	}
+
+	var err error
+	if len(names) == 1 {
+		err = runJob(names[0], writer(names[0]))
+	} else {
+		err = r.graph.Run(r.opts.MaxParallel, func(jobName string) error {
+			return runJob(jobName, writer(jobName))
+		})
+	}
+
+	result := Result{Jobs: make([]JobResult, 0, len(results))}
+	for _, name := range names {
+		if jr, ok := results[name]; ok {
+			result.Jobs = append(result.Jobs, jr)
+		}
+	}
+
+	if ctx.Err() != nil {
+		return result, ctx.Err()
+	}
+	if runCtx.Err() != nil {
+		return result, &timeoutError{timeout: r.opts.Timeout}
+	}
+	return result, err
+}
+
+func (r *Runner) runJob(ctx context.Context, jobName string, writer io.Writer) (result JobResult) {
+	option := r.LaunchOption(jobName)
+	result = JobResult{Name: jobName, ArtifactsPath: option.ArtifactsPath}
+
+	r.mutex.Lock()
+	killed := r.killed
+	r.mutex.Unlock()
+	if err := ctx.Err(); err != nil || killed {
+		result.Err = errors.New("the build was stopped before it started")
+		return result
+	}
+
+	var launcher launch.Launcher
+	err := r.prepare(&option)
+	if err == ErrSkipJob {
+		result.Skipped = true
+		err = nil
+	}
+	if err != nil || result.Skipped {
+		result.Err = err
+		r.finish(option, launcher, result)
+		return result
+	}
+	defer func() { r.finish(option, launcher, result) }()
+
+	if err := r.opts.MkdirAll(option.ArtifactsPath, 0777); err != nil {
+		result.Err = err
+		return result
+	}
+
+	if option.NoFailFast {
+		if err := launch.RemoveFailedSteps(option.ArtifactsPath); err != nil {
+			result.Err = err
+			return result
+		}
+	}
+
+	if r.events != nil {
+		writer = buildlog.WithEvents(writer, r.events.Formatter(jobName))
+	}
+	loggerDone := make(chan struct{})
+	logger, err := r.opts.NewLogger(filepath.Join(option.ArtifactsPath, launch.LogFile), writer, loggerDone, r.opts.Color)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	go logger.Run()
+
+	launcher = r.opts.NewLauncher(option)
+	r.mutex.Lock()
+	r.launchers = append(r.launchers, launcher)
+	killed = r.killed
+	r.mutex.Unlock()
+	// the launcher created while the builds are killed is not run
+	var total time.Duration
+	if killed {
+		result.Err = errors.New("the build was stopped before it started")
+	} else {
+		logrus.Infof("Prepare to start build of %s...", jobName)
+		r.writeEvent(func(events *buildlog.EventWriter) error { return events.BuildStarted(jobName, option.ContainerName) })
+		start := time.Now()
+		result.Err = launcher.Run()
+		total = time.Since(start)
+	}
+
+	// wait for the logger to print the rest of the logs and the result of the build
+	logger.Stop(result.Err)
+	<-loggerDone
+	result.Steps = logger.Timings()
+	if option.NoFailFast && result.Err != nil {
+		result.Steps, result.Err = failedSteps(option.ArtifactsPath, result.Steps, result.Err)
+	}
+
+	if !killed {
+		r.writeEvent(func(events *buildlog.EventWriter) error {
+			return events.BuildFinished(jobName, result.Steps, total, result.Err)
+		})
+	}
+
+	return result
+}
+
+// failedSteps marks the steps which failed without fail fast as failed, and returns the error listing them.
+// The build failed by CheckFailedStepsStep if they are found, otherwise by the step which fails the build as usual.
+func failedSteps(artifactsPath string, steps []buildlog.StepTiming, err error) ([]buildlog.StepTiming, error) {
+	failed, readErr := launch.FailedSteps(artifactsPath)
+	if readErr != nil {
+		logrus.Warnf("failed to read the failed steps: %v", readErr)
+	}
+	if len(failed) == 0 {
+		return steps, err
+	}
+
+	names := make(map[string]bool, len(failed))
+	for _, name := range failed {
+		names[name] = true
+	}
+	marked := make([]buildlog.StepTiming, len(steps))
+	for i, s := range steps {
+		if names[s.Name] {
+			s.Status = buildlog.StepFailed
+		}
+		marked[i] = s
+	}
+	return marked, fmt.Errorf("failed steps: %s", strings.Join(failed, ", "))
+}
+
+// writeEvent writes the event by the EventWriter of Options.Events, which only warns on the failure not to fail the build
+func (r *Runner) writeEvent(write func(events *buildlog.EventWriter) error) {
+	if r.events == nil {
+		return
+	}
+	if err := write(r.events); err != nil {
+		logrus.Warnf("failed to write the event: %v", err)
+	}
+}
+
+// prepare resumes the job from the state of the previous build by Options.Resume, and calls Options.Prepare
+func (r *Runner) prepare(option *launch.Option) (err error) {
+	jobName := option.JobName
+	persist := r.opts.State && !option.InteractiveMode
+	if persist || r.opts.Resume {
+		// the state of the build is persisted with the artifacts to resume the build from the failed step
+		fingerprint, fingerprintErr := buildFingerprint(option.Job, option.SrcPath, r.opts.SourceRevision)
+		var state *State
+		if r.opts.Resume {
+			state = resumableState(jobName, option.ArtifactsPath, fingerprint, fingerprintErr)
+		}
+		previousSteps := []string{}
+		if state != nil {
+			steps := remainingSteps(option.Job, state.SucceededSteps)
+			if len(steps) == 0 {
+				logrus.Infof("The build of %s already succeeded, skipping it", jobName)
+				return ErrSkipJob
+			}
+			logrus.Infof("Resuming the build of %s from the step %s...", jobName, steps[0])
+			option.Job, err = option.Job.SelectSteps(steps)
+			if err != nil {
+				return err
+			}
+			previousSteps = state.SucceededSteps
+			option.ResumeContainer = state.Container
+		}
+		if persist {
+			r.mutex.Lock()
+			r.states[jobName] = State{Job: jobName, Fingerprint: fingerprint, SucceededSteps: previousSteps}
+			r.mutex.Unlock()
+		}
+	}
+
+	if r.opts.Prepare != nil {
+		return r.opts.Prepare(option)
+	}
+	return nil
+}
+
+// finish calls Options.Finish, and persists the state of the build and runs the hooks after it
+func (r *Runner) finish(option launch.Option, launcher launch.Launcher, result JobResult) {
+	jobName := option.JobName
+	r.mutex.Lock()
+	r.results[jobName] = result
+	state, persist := r.states[jobName]
+	r.mutex.Unlock()
+
+	if r.opts.Finish != nil {
+		r.opts.Finish(option, launcher, result)
+	}
+
+	if persist && launcher != nil {
+		state.Image = option.Job.Image
+		state.SucceededSteps = succeededSteps(r.jobs[jobName], state.SucceededSteps, result.Steps)
+		if k, ok := launcher.(containerKeeper); ok {
+			state.Container = k.KeptContainer()
+		}
+		if err := WriteState(option.ArtifactsPath, state); err != nil {
+			logrus.Warn(err)
+		}
+	}
+
+	// the hooks run after the state and the meta are written for them to read
+	if !r.opts.Hooks.empty() && !result.Skipped {
+		r.opts.Hooks.run(r.opts.RunHook, r.opts.Output, jobName, option.ArtifactsPath, result.Steps, result.Err)
+	}
+}
+
+// Kill kills the running builds with the signal. The jobs which have not started yet don't run.
+func (r *Runner) Kill(sig os.Signal) {
+	r.mutex.Lock()
+	r.killed = true
+	launchers := make([]launch.Launcher, len(r.launchers))
+	copy(launchers, r.launchers)
+	r.mutex.Unlock()
+
+	for _, l := range launchers {
+		l.Kill(sig)
+	}
+}
+
+// Clean cleans up the resources of the builds like the volumes. It is called when Run returns.
+func (r *Runner) Clean() {
+	r.mutex.Lock()
+	launchers := r.launchers
+	r.launchers = nil
+	r.mutex.Unlock()
+
+	for _, l := range launchers {
+		l.Clean()
+	}
+}
@@ -0,0 +1,677 @@
+package build
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/screwdriver-cd/sd-local/buildlog"
+	"github.com/screwdriver-cd/sd-local/config"
+	"github.com/screwdriver-cd/sd-local/launch"
+	"github.com/screwdriver-cd/sd-local/screwdriver"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockAPI struct {
+	jobs map[string]screwdriver.Job
+}
+
+func (m mockAPI) Job(jobName, filePath string) (screwdriver.Job, error) { return m.jobs[jobName], nil }
+
+func (m mockAPI) Jobs(filePath string) (map[string]screwdriver.Job, error) { return m.jobs, nil }
+
+func (m mockAPI) JWT() string { return "jwt" }
+
+func (m mockAPI) InitJWT() error { return nil }
+
+type mockContextAPI struct {
+	mockAPI
+}
+
+func (m mockContextAPI) JobContext(ctx context.Context, jobName, filePath string) (screwdriver.Job, error) {
+	return m.jobs[jobName], ctx.Err()
+}
+
+func (m mockContextAPI) JobsContext(ctx context.Context, filePath string) (map[string]screwdriver.Job, error) {
+	return m.jobs, ctx.Err()
+}
+
+func (m mockContextAPI) InitJWTContext(ctx context.Context) error { return ctx.Err() }
+
+func (m mockContextAPI) PipelineJobs(ctx context.Context, pipelineID int) (map[string]screwdriver.Job, error) {
+	return map[string]screwdriver.Job{"main": {Image: "golang"}}, nil
+}
+
+type mockLauncher struct {
+	run    func() error
+	killed chan os.Signal
+}
+
+func (m *mockLauncher) Run() error { return m.run() }
+
+func (m *mockLauncher) Kill(sig os.Signal) { m.killed <- sig }
+
+func (m *mockLauncher) Clean() {}
+
+type mockUploader struct {
+	dir  *string
+	urls []string
+	err  error
+}
+
+func (m mockUploader) Upload(dir string) ([]string, error) {
+	*m.dir = dir
+	return m.urls, m.err
+}
+
+type mockLogger struct {
+	writer  io.Writer
+	done    chan<- struct{}
+	timings []buildlog.StepTiming
+}
+
+func (m mockLogger) Run() {}
+
+func (m mockLogger) Stop(err error) {
+	_, _ = io.WriteString(m.writer, "done\n")
+	close(m.done)
+}
+
+func (m mockLogger) Timings() []buildlog.StepTiming { return m.timings }
+
+func newMockLogger(filepath string, writer io.Writer, done chan<- struct{}, color bool) (buildlog.Logger, error) {
+	return mockLogger{writer: writer, done: done, timings: []buildlog.StepTiming{
+		{Name: "test", Duration: time.Second, Status: buildlog.StepSucceeded},
+	}}, nil
+}
+
+func testEntry() *config.Entry {
+	return &config.Entry{APIURL: "http://api", Runtime: config.RuntimeDocker}
+}
+
+func testJobs() map[string]screwdriver.Job {
+	return map[string]screwdriver.Job{
+		"test":    {Image: "node:14"},
+		"lint":    {Image: "node:14"},
+		"publish": {Image: "node:14", Requires: []string{"test", "lint"}},
+	}
+}
+
+func testOptions(jobNames ...string) Options {
+	return Options{
+		JobNames:      jobNames,
+		API:           mockAPI{jobs: testJobs()},
+		ArtifactsPath: "/tmp/sd-artifacts",
+		NewLogger:     newMockLogger,
+		MkdirAll:      func(path string, perm os.FileMode) error { return nil },
+		Launch:        launch.Option{SrcPath: "/src"},
+	}
+}
+
+func TestNew(t *testing.T) {
+	testCases := map[string]struct {
+		entry    *config.Entry
+		opts     Options
+		expected string
+	}{
+		"no entry":           {entry: nil, opts: testOptions("test"), expected: "entry must not be nil"},
+		"no job":             {entry: testEntry(), opts: testOptions(), expected: "no job to run"},
+		"unknown job":        {entry: testEntry(), opts: testOptions("deploy"), expected: "not found 'deploy' in parsed screwdriver.yaml"},
+		"negative maximum":   {entry: testEntry(), opts: func() Options { o := testOptions("test"); o.MaxParallel = -1; return o }(), expected: "max-parallel must be a positive integer: -1"},
+		"unknown log format": {entry: testEntry(), opts: func() Options { o := testOptions("test"); o.LogFormat = "xml"; return o }(), expected: "invalid log format xml: must be one of plain, json or tap"},
+		"negative timeout":   {entry: testEntry(), opts: func() Options { o := testOptions("test"); o.Timeout = -time.Minute; return o }(), expected: "timeout must not be negative: -1m0s"},
+		"unknown token":      {entry: testEntry(), opts: func() Options { o := testOptions("test"); o.ArtifactsPath = "/tmp/{date}"; return o }(), expected: "invalid token {date} in artifacts-dir: must be {job} or {time}"},
+		"keep without time":  {entry: testEntry(), opts: func() Options { o := testOptions("test"); o.ArtifactsKeep = 1; return o }(), expected: "can't keep the runs without {time} in the artifacts directory, which makes the directory of each run"},
+		"no uploader":        {entry: testEntry(), opts: func() Options { o := testOptions("test"); o.NoLocalArtifacts = true; return o }(), expected: "can't collect the artifacts only to upload them without the uploader, the artifacts would be lost"},
+		"unknown summary":    {entry: testEntry(), opts: func() Options { o := testOptions("test"); o.SummaryFormat = "yaml"; return o }(), expected: "invalid summary format yaml: only json is supported"},
+	}
+
+	for name, tt := range testCases {
+		t.Run(name, func(t *testing.T) {
+			_, err := New(tt.entry, tt.opts)
+			assert.Equal(t, tt.expected, err.Error())
+		})
+	}
+
+	t.Run("cancelled context", func(t *testing.T) {
+		opts := testOptions("test")
+		opts.API = mockContextAPI{mockAPI{jobs: testJobs()}}
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := NewContext(ctx, testEntry(), opts)
+		assert.Equal(t, context.Canceled, err)
+
+		_, err = NewContext(context.Background(), testEntry(), opts)
+		assert.Nil(t, err)
+	})
+
+	t.Run("jobs of the pipeline", func(t *testing.T) {
+		opts := testOptions("main")
+		opts.PipelineID = 123
+		opts.API = mockContextAPI{mockAPI{jobs: testJobs()}}
+		r, err := New(testEntry(), opts)
+		assert.Nil(t, err)
+		assert.Equal(t, "golang", r.LaunchOption("main").Job.Image)
+
+		opts.API = mockAPI{jobs: testJobs()}
+		_, err = New(testEntry(), opts)
+		assert.Equal(t, "the API does not support getting the jobs of the pipeline", err.Error())
+	})
+
+	t.Run("default options", func(t *testing.T) {
+		opts := testOptions("test")
+		opts.ArtifactsPath = ""
+		opts.Launch = launch.Option{}
+		r, err := New(testEntry(), opts)
+		assert.Nil(t, err)
+
+		cwd, err := os.Getwd()
+		if err != nil {
+			t.Fatal(err)
+		}
+		option := r.LaunchOption("test")
+		assert.Equal(t, cwd, option.SrcPath)
+		assert.Equal(t, filepath.Join(cwd, launch.ArtifactsDir), option.ArtifactsPath)
+		assert.Equal(t, config.RuntimeDocker, option.Runtime)
+		assert.Equal(t, "jwt", option.JWT)
+		assert.Equal(t, "http://api", option.Entry.APIURL)
+		assert.Equal(t, []string{"test"}, r.Names())
+	})
+}
+
+func TestRun(t *testing.T) {
+	t.Run("run a job", func(t *testing.T) {
+		var option launch.Option
+		opts := testOptions("test")
+		out := bytes.NewBuffer(nil)
+		opts.Output = out
+		opts.NewLauncher = func(o launch.Option) launch.Launcher {
+			option = o
+			return &mockLauncher{run: func() error { return nil }}
+		}
+
+		r, err := New(testEntry(), opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		result, err := r.Run(context.Background())
+		assert.Nil(t, err)
+		assert.Equal(t, Result{Jobs: []JobResult{{
+			Name:          "test",
+			ArtifactsPath: "/tmp/sd-artifacts",
+			Steps:         []buildlog.StepTiming{{Name: "test", Duration: time.Second, Status: buildlog.StepSucceeded}},
+		}}}, result)
+		assert.Equal(t, "done\n", out.String())
+		assert.Equal(t, "test", option.JobName)
+		assert.Equal(t, "node:14", option.Job.Image)
+		assert.Equal(t, "/src", option.SrcPath)
+		assert.Equal(t, "/tmp/sd-artifacts", option.ArtifactsPath)
+	})
+
+	t.Run("run a job with the events", func(t *testing.T) {
+		opts := testOptions("test")
+		out := bytes.NewBuffer(nil)
+		events := bytes.NewBuffer(nil)
+		opts.Output = out
+		opts.Events = events
+		opts.Launch.ContainerName = "sdlocal-test"
+		opts.NewLauncher = func(o launch.Option) launch.Launcher {
+			return &mockLauncher{run: func() error { return errors.New("exit status 1") }}
+		}
+
+		r, err := New(testEntry(), opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = r.Run(context.Background())
+		assert.Equal(t, "exit status 1", err.Error())
+		assert.Equal(t, "done\n", out.String())
+
+		lines := strings.Split(strings.TrimSuffix(events.String(), "\n"), "\n")
+		assert.Equal(t, 2, len(lines))
+		assert.Contains(t, lines[0], `"type":"build-started"`)
+		assert.Contains(t, lines[0], `"job":"test","container":"sdlocal-test"`)
+		assert.Contains(t, lines[1], `"type":"build-finished"`)
+		assert.Contains(t, lines[1], `"job":"test","message":"exit status 1","status":"failed"`)
+	})
+
+	t.Run("run a job without fail fast", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "sd-artifacts")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+		failedStepsPath := filepath.Join(dir, launch.FailedStepsFile)
+		if err := ioutil.WriteFile(failedStepsPath, []byte("previous\n"), 0666); err != nil {
+			t.Fatal(err)
+		}
+
+		opts := testOptions("test")
+		opts.ArtifactsPath = dir
+		opts.Launch.NoFailFast = true
+		opts.NewLogger = func(filepath string, writer io.Writer, done chan<- struct{}, color bool) (buildlog.Logger, error) {
+			return mockLogger{writer: writer, done: done, timings: []buildlog.StepTiming{
+				{Name: "lint", Duration: time.Second, Status: buildlog.StepSucceeded},
+				{Name: "test", Duration: time.Second, Status: buildlog.StepSucceeded},
+				{Name: "build", Duration: time.Second, Status: buildlog.StepSucceeded},
+				{Name: launch.CheckFailedStepsStep, Duration: 0, Status: buildlog.StepFailed},
+			}}, nil
+		}
+		opts.NewLauncher = func(o launch.Option) launch.Launcher {
+			return &mockLauncher{run: func() error {
+				_, err := os.Stat(failedStepsPath)
+				assert.True(t, os.IsNotExist(err))
+				if err := ioutil.WriteFile(failedStepsPath, []byte("lint\ntest\n"), 0666); err != nil {
+					t.Fatal(err)
+				}
+				return errors.New("exit status 1")
+			}}
+		}
+
+		r, err := New(testEntry(), opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		result, err := r.Run(context.Background())
+		assert.Equal(t, "failed steps: lint, test", err.Error())
+		assert.Equal(t, []buildlog.StepTiming{
+			{Name: "lint", Duration: time.Second, Status: buildlog.StepFailed},
+			{Name: "test", Duration: time.Second, Status: buildlog.StepFailed},
+			{Name: "build", Duration: time.Second, Status: buildlog.StepSucceeded},
+			{Name: launch.CheckFailedStepsStep, Duration: 0, Status: buildlog.StepFailed},
+		}, result.Jobs[0].Steps)
+	})
+
+	t.Run("run multiple jobs", func(t *testing.T) {
+		opts := testOptions("publish", "test", "lint")
+		out := bytes.NewBuffer(nil)
+		opts.Output = out
+		var mutex sync.Mutex
+		var order []string
+		opts.NewLauncher = func(o launch.Option) launch.Launcher {
+			return &mockLauncher{run: func() error {
+				mutex.Lock()
+				defer mutex.Unlock()
+				order = append(order, o.JobName)
+				if o.JobName == "lint" {
+					return errors.New("lint failed")
+				}
+				return nil
+			}}
+		}
+
+		r, err := New(testEntry(), opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		result, err := r.Run(context.Background())
+		assert.Equal(t, "failed to run jobs:\n  * publish: skipped because `lint` failed\n  * lint: lint failed", err.Error())
+		assert.Equal(t, 2, len(result.Jobs))
+		assert.Equal(t, "test", result.Jobs[0].Name)
+		assert.Equal(t, "/tmp/sd-artifacts/test", result.Jobs[0].ArtifactsPath)
+		assert.Nil(t, result.Jobs[0].Err)
+		assert.Equal(t, "lint", result.Jobs[1].Name)
+		assert.Equal(t, "lint failed", result.Jobs[1].Err.Error())
+		assert.ElementsMatch(t, []string{"test", "lint"}, order)
+		assert.Contains(t, out.String(), "[test] done\n")
+		assert.Contains(t, out.String(), "[lint] done\n")
+		assert.Equal(t, 1, len(result.Timings()["test"]))
+	})
+
+	t.Run("run multiple jobs with the log format", func(t *testing.T) {
+		testCases := []struct {
+			format   string
+			expected []string
+		}{
+			{format: buildlog.FormatJSON, expected: []string{`"job":"test"`, `"job":"lint"`, `"message":"done"`}},
+			{format: buildlog.FormatTAP, expected: []string{"TAP version 13\n", "# [test] done\n", "# [lint] done\n", "1..0\n"}},
+		}
+
+		for _, tt := range testCases {
+			t.Run(tt.format, func(t *testing.T) {
+				opts := testOptions("test", "lint")
+				out := bytes.NewBuffer(nil)
+				opts.Output = out
+				opts.LogFormat = tt.format
+				opts.NewLogger = func(filepath string, writer io.Writer, done chan<- struct{}, color bool) (buildlog.Logger, error) {
+					_, ok := writer.(buildlog.Formatter)
+					assert.True(t, ok)
+					return newMockLogger(filepath, writer, done, color)
+				}
+				opts.NewLauncher = func(o launch.Option) launch.Launcher {
+					return &mockLauncher{run: func() error { return nil }}
+				}
+
+				r, err := New(testEntry(), opts)
+				if err != nil {
+					t.Fatal(err)
+				}
+				_, err = r.Run(context.Background())
+				assert.Nil(t, err)
+				for _, e := range tt.expected {
+					assert.Contains(t, out.String(), e)
+				}
+				assert.NotContains(t, out.String(), "[test] {")
+			})
+		}
+	})
+
+	t.Run("run the job quietly", func(t *testing.T) {
+		opts := testOptions("test")
+		opts.Output = bytes.NewBuffer(nil)
+		opts.Quiet = true
+		formatted := false
+		opts.NewLogger = func(filepath string, writer io.Writer, done chan<- struct{}, color bool) (buildlog.Logger, error) {
+			_, formatted = writer.(buildlog.Formatter)
+			return newMockLogger(filepath, writer, done, color)
+		}
+		opts.NewLauncher = func(o launch.Option) launch.Launcher {
+			return &mockLauncher{run: func() error { return nil }}
+		}
+
+		r, err := New(testEntry(), opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = r.Run(context.Background())
+		assert.Nil(t, err)
+		assert.True(t, formatted)
+	})
+
+	t.Run("prepare and finish the jobs", func(t *testing.T) {
+		opts := testOptions("test", "lint")
+		launched := map[string]launch.Option{}
+		opts.NewLauncher = func(o launch.Option) launch.Launcher {
+			launched[o.JobName] = o
+			return &mockLauncher{run: func() error { return nil }}
+		}
+		opts.Prepare = func(o *launch.Option) error {
+			if o.JobName == "lint" {
+				return ErrSkipJob
+			}
+			o.ContainerName = "sdlocal-" + o.JobName
+			return nil
+		}
+		finished := map[string]bool{}
+		opts.Finish = func(o launch.Option, l launch.Launcher, result JobResult) {
+			finished[o.JobName] = l != nil
+		}
+
+		r, err := New(testEntry(), opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		result, err := r.Run(context.Background())
+		assert.Nil(t, err)
+		assert.Equal(t, map[string]bool{"test": true, "lint": false}, finished)
+		assert.Equal(t, "sdlocal-test", launched["test"].ContainerName)
+		assert.NotContains(t, launched, "lint")
+		assert.True(t, result.Jobs[1].Skipped)
+	})
+
+	t.Run("kill the builds when the context is done", func(t *testing.T) {
+		opts := testOptions("test")
+		killed := make(chan os.Signal, 1)
+		opts.NewLauncher = func(o launch.Option) launch.Launcher {
+			l := &mockLauncher{killed: killed}
+			l.run = func() error {
+				<-time.After(100 * time.Millisecond)
+				select {
+				case <-killed:
+					return errors.New("killed")
+				case <-time.After(3 * time.Second):
+					return nil
+				}
+			}
+			return l
+		}
+
+		r, err := New(testEntry(), opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		result, err := r.Run(ctx)
+		assert.Equal(t, context.DeadlineExceeded, err)
+		assert.Equal(t, "killed", result.Jobs[0].Err.Error())
+	})
+
+	t.Run("don't run the jobs after killed", func(t *testing.T) {
+		opts := testOptions("test")
+		launched := false
+		opts.NewLauncher = func(o launch.Option) launch.Launcher {
+			launched = true
+			return &mockLauncher{run: func() error { return nil }}
+		}
+
+		r, err := New(testEntry(), opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r.Kill(os.Interrupt)
+		result, err := r.Run(context.Background())
+		assert.Equal(t, "the build was stopped before it started", err.Error())
+		assert.Equal(t, "the build was stopped before it started", result.Jobs[0].Err.Error())
+		assert.False(t, launched)
+	})
+
+	t.Run("stop the builds by the timeout", func(t *testing.T) {
+		opts := testOptions("test")
+		opts.Timeout = 10 * time.Millisecond
+		killed := make(chan os.Signal, 1)
+		opts.NewLauncher = func(o launch.Option) launch.Launcher {
+			l := &mockLauncher{killed: killed}
+			l.run = func() error {
+				<-killed
+				return nil
+			}
+			return l
+		}
+
+		r, err := New(testEntry(), opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = r.Run(context.Background())
+		assert.Equal(t, "build timed out after 10ms", err.Error())
+		assert.True(t, errors.Is(err, ErrTimeout))
+	})
+
+	t.Run("resume the job by the state", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "sd-artifacts")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+
+		jobs := map[string]screwdriver.Job{"test": resumeJob}
+		var option launch.Option
+		run := func(resume bool, revision string) (Result, error) {
+			opts := testOptions("test")
+			opts.API = mockAPI{jobs: jobs}
+			opts.ArtifactsPath = dir
+			opts.Resume = resume
+			opts.State = true
+			opts.SourceRevision = func(srcPath string) (string, error) { return revision, nil }
+			opts.NewLogger = func(filepath string, writer io.Writer, done chan<- struct{}, color bool) (buildlog.Logger, error) {
+				return mockLogger{writer: writer, done: done, timings: []buildlog.StepTiming{
+					{Name: "install", Duration: time.Second, Status: buildlog.StepSucceeded},
+					{Name: "test", Duration: time.Second, Status: buildlog.StepFailed},
+				}}, nil
+			}
+			opts.NewLauncher = func(o launch.Option) launch.Launcher {
+				option = o
+				return &mockLauncher{run: func() error { return errors.New("exit status 1") }}
+			}
+			r, err := New(testEntry(), opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return r.Run(context.Background())
+		}
+
+		_, err = run(false, "rev")
+		assert.Equal(t, "exit status 1", err.Error())
+		state, err := ReadState(dir)
+		assert.Nil(t, err)
+		assert.Equal(t, "test", state.Job)
+		assert.Equal(t, "node:12", state.Image)
+		assert.Equal(t, []string{"install"}, state.SucceededSteps)
+
+		_, _ = run(true, "rev")
+		assert.Equal(t, []string{"test", "publish", "teardown-clean"}, stepNames(option.Job))
+
+		// the build starts fresh if the source code changed
+		_, _ = run(true, "changed")
+		assert.Equal(t, []string{"install", "test", "publish", "teardown-clean"}, stepNames(option.Job))
+
+		// the build which already succeeded is skipped
+		state.SucceededSteps = []string{"install", "test", "publish"}
+		state.Fingerprint, _ = buildFingerprint(resumeJob, "/src", func(string) (string, error) { return "rev", nil })
+		assert.Nil(t, WriteState(dir, *state))
+		result, err := run(true, "rev")
+		assert.Nil(t, err)
+		assert.True(t, result.Jobs[0].Skipped)
+	})
+
+	t.Run("run the hooks", func(t *testing.T) {
+		opts := testOptions("test", "lint")
+		opts.Hooks = Hooks{OnComplete: "notify", OnFailure: "alert"}
+		var mutex sync.Mutex
+		commands := map[string][]string{}
+		opts.RunHook = func(command string, env []string, out io.Writer) error {
+			mutex.Lock()
+			defer mutex.Unlock()
+			commands[env[0]] = append(commands[env[0]], command)
+			return nil
+		}
+		opts.NewLauncher = func(o launch.Option) launch.Launcher {
+			return &mockLauncher{run: func() error {
+				if o.JobName == "lint" {
+					return errors.New("lint failed")
+				}
+				return nil
+			}}
+		}
+
+		r, err := New(testEntry(), opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _ = r.Run(context.Background())
+		assert.Equal(t, map[string][]string{
+			"SD_LOCAL_JOB=test": {"notify"},
+			"SD_LOCAL_JOB=lint": {"alert", "notify"},
+		}, commands)
+	})
+
+	t.Run("upload the artifacts and write the summary and the report", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "report")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+
+		opts := testOptions("test")
+		var uploaded string
+		opts.Uploader = mockUploader{dir: &uploaded, urls: []string{"s3://bucket/builds.log"}}
+		summary := bytes.NewBuffer(nil)
+		opts.Summary = summary
+		opts.Report = filepath.Join(dir, "report.json")
+		opts.Mask = strings.NewReplacer("secret", buildlog.Mask).Replace
+		opts.NewLauncher = func(o launch.Option) launch.Launcher {
+			return &mockLauncher{run: func() error { return errors.New("failed with secret") }}
+		}
+
+		r, err := New(testEntry(), opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		result, err := r.Run(context.Background())
+		assert.Equal(t, "failed with secret", err.Error())
+		assert.Equal(t, "/tmp/sd-artifacts", uploaded)
+		assert.Equal(t, []string{"s3://bucket/builds.log"}, result.URLs)
+		assert.Equal(t, "JOB   STEP   PHASE  STATUS     DURATION\ntest  test          succeeded  1s\ntest  TOTAL                    1s\n", summary.String())
+
+		b, err := ioutil.ReadFile(opts.Report)
+		assert.Nil(t, err)
+		assert.Contains(t, string(b), `"error": "failed with ****"`)
+		assert.Contains(t, string(b), `"uploadedArtifacts": [`)
+	})
+
+	t.Run("upload the artifacts collected into the temporary directory", func(t *testing.T) {
+		opts := testOptions("test")
+		opts.NoLocalArtifacts = true
+		var uploaded, collected string
+		opts.Uploader = mockUploader{dir: &uploaded, err: errors.New("failed to upload")}
+		opts.NewLauncher = func(o launch.Option) launch.Launcher {
+			collected = o.ArtifactsPath
+			return &mockLauncher{run: func() error { return nil }}
+		}
+
+		r, err := New(testEntry(), opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = r.Run(context.Background())
+		assert.Equal(t, "failed to upload", err.Error())
+		assert.Equal(t, collected, uploaded)
+		assert.NotEqual(t, "/tmp/sd-artifacts", uploaded)
+		_, err = os.Stat(uploaded)
+		assert.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("remove the old runs of the artifacts", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "sd-artifacts")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+		for _, d := range []string{"test/20200101000000", "test/20200102000000"} {
+			if err := os.MkdirAll(filepath.Join(dir, d), 0777); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		opts := testOptions("test")
+		opts.ArtifactsPath = filepath.Join(dir, ArtifactsJobToken, ArtifactsTimeToken)
+		opts.ArtifactsKeep = 2
+		opts.MkdirAll = os.MkdirAll
+		opts.NewLauncher = func(o launch.Option) launch.Launcher {
+			return &mockLauncher{run: func() error { return nil }}
+		}
+
+		r, err := New(testEntry(), opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		artifactsPath := r.ArtifactsPath("test")
+		assert.Equal(t, filepath.Join(dir, "test"), filepath.Dir(artifactsPath))
+		_, err = r.Run(context.Background())
+		assert.Nil(t, err)
+
+		infos, _ := ioutil.ReadDir(filepath.Join(dir, "test"))
+		runs := make([]string, 0, len(infos))
+		for _, info := range infos {
+			runs = append(runs, filepath.Join(dir, "test", info.Name()))
+		}
+		assert.Equal(t, []string{filepath.Join(dir, "test", "20200102000000"), artifactsPath}, runs)
+	})
+}
+
+func stepNames(job screwdriver.Job) []string {
+	names := make([]string, 0, len(job.Steps))
+	for _, s := range job.Steps {
+		names = append(names, s.Name)
+	}
+	return names
+}
@@ -0,0 +1,87 @@
+package build
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/screwdriver-cd/sd-local/buildlog"
+	"github.com/sirupsen/logrus"
+)
+
+// The status of the build given to the hooks
+const (
+	hookStatusSuccess = "success"
+	hookStatusFailure = "failure"
+)
+
+// Hooks are the commands run on the host when the build of a job finishes
+type Hooks struct {
+	// OnComplete runs after the build whether it succeeded or failed, after OnSuccess or OnFailure
+	OnComplete string
+	// OnSuccess runs after the build which succeeded
+	OnSuccess string
+	// OnFailure runs after the build which failed
+	OnFailure string
+}
+
+func (h Hooks) empty() bool {
+	return h.OnComplete == "" && h.OnSuccess == "" && h.OnFailure == ""
+}
+
+// hookEnv returns the environment variables describing the result of the build of the job for the hooks
+func hookEnv(jobName, artifactsPath string, steps []buildlog.StepTiming, err error) []string {
+	status := hookStatusSuccess
+	if err != nil {
+		status = hookStatusFailure
+	}
+	var total time.Duration
+	failedStep := ""
+	for _, s := range steps {
+		total += s.Duration
+		if s.Status == buildlog.StepFailed && failedStep == "" {
+			failedStep = s.Name
+		}
+	}
+
+	env := []string{
+		"SD_LOCAL_JOB=" + jobName,
+		"SD_LOCAL_STATUS=" + status,
+		fmt.Sprintf("SD_LOCAL_DURATION=%d", int64(total.Seconds())),
+		"SD_LOCAL_ARTIFACTS_DIR=" + artifactsPath,
+	}
+	if err != nil {
+		env = append(env, "SD_LOCAL_ERROR="+err.Error(), "SD_LOCAL_FAILED_STEP="+failedStep)
+	}
+	return env
+}
+
+// run runs the hooks of the result by runHook, the hook of the status first and then OnComplete.
+// The failures of the hooks are only warned not to change the result of the build.
+func (h Hooks) run(runHook func(command string, env []string, out io.Writer) error, out io.Writer, jobName, artifactsPath string, steps []buildlog.StepTiming, err error) {
+	commands := []string{h.OnSuccess, h.OnComplete}
+	if err != nil {
+		commands[0] = h.OnFailure
+	}
+
+	env := hookEnv(jobName, artifactsPath, steps, err)
+	for _, c := range commands {
+		if c == "" {
+			continue
+		}
+		if hookErr := runHook(c, env, out); hookErr != nil {
+			logrus.Warnf("failed to run the hook `%s` of %s: %v", c, jobName, hookErr)
+		}
+	}
+}
+
+// RunHookCommand runs the command by sh on the host with the environment added, whose output is written into out
+func RunHookCommand(command string, env []string, out io.Writer) error {
+	c := exec.Command("sh", "-c", command)
+	c.Env = append(os.Environ(), env...)
+	c.Stdout = out
+	c.Stderr = out
+	return c.Run()
+}
@@ -0,0 +1,64 @@
+package build
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/screwdriver-cd/sd-local/buildlog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHookEnv(t *testing.T) {
+	steps := []buildlog.StepTiming{
+		{Name: "install", Duration: 10 * time.Second, Status: buildlog.StepSucceeded},
+		{Name: "test", Duration: 5500 * time.Millisecond, Status: buildlog.StepFailed},
+	}
+
+	assert.Equal(t, []string{
+		"SD_LOCAL_JOB=main",
+		"SD_LOCAL_STATUS=success",
+		"SD_LOCAL_DURATION=10",
+		"SD_LOCAL_ARTIFACTS_DIR=/src/sd-artifacts",
+	}, hookEnv("main", "/src/sd-artifacts", steps[:1], nil))
+
+	assert.Equal(t, []string{
+		"SD_LOCAL_JOB=main",
+		"SD_LOCAL_STATUS=failure",
+		"SD_LOCAL_DURATION=15",
+		"SD_LOCAL_ARTIFACTS_DIR=/src/sd-artifacts",
+		"SD_LOCAL_ERROR=exit status 1",
+		"SD_LOCAL_FAILED_STEP=test",
+	}, hookEnv("main", "/src/sd-artifacts", steps, errors.New("exit status 1")))
+}
+
+func TestHooks(t *testing.T) {
+	var commands []string
+	runHook := func(command string, env []string, out io.Writer) error {
+		commands = append(commands, command)
+		return errors.New("exit status 1")
+	}
+
+	hooks := Hooks{OnComplete: "notify", OnSuccess: "celebrate", OnFailure: "alert"}
+	hooks.run(runHook, bytes.NewBuffer(nil), "main", "/src/sd-artifacts", nil, nil)
+	assert.Equal(t, []string{"celebrate", "notify"}, commands)
+
+	commands = nil
+	hooks.run(runHook, bytes.NewBuffer(nil), "main", "/src/sd-artifacts", nil, errors.New("exit status 1"))
+	assert.Equal(t, []string{"alert", "notify"}, commands)
+
+	commands = nil
+	Hooks{OnSuccess: "celebrate"}.run(runHook, bytes.NewBuffer(nil), "main", "/src/sd-artifacts", nil, errors.New("exit status 1"))
+	assert.Nil(t, commands)
+}
+
+func TestRunHookCommand(t *testing.T) {
+	out := bytes.NewBuffer(nil)
+	err := RunHookCommand(`echo "$SD_LOCAL_JOB $SD_LOCAL_STATUS"`, []string{"SD_LOCAL_JOB=main", "SD_LOCAL_STATUS=success"}, out)
+	assert.Nil(t, err)
+	assert.Equal(t, "main success\n", out.String())
+
+	assert.Equal(t, "exit status 3", RunHookCommand("exit 3", nil, out).Error())
+}
@@ -0,0 +1,130 @@
+package build
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/screwdriver-cd/sd-local/buildlog"
+	"github.com/screwdriver-cd/sd-local/screwdriver"
+)
+
+// reportSchemaVersion is the version of the schema of the build report.
+// It is incremented only on incompatible changes, so that the parsers of the report keep working on additions.
+const reportSchemaVersion = 1
+
+// The status of the builds in the report
+const (
+	reportSucceeded = "succeeded"
+	reportFailed    = "failed"
+	reportSkipped   = "skipped"
+)
+
+// buildReport is the machine-readable result of the build
+type buildReport struct {
+	SchemaVersion     int         `json:"schemaVersion"`
+	Status            string      `json:"status"`
+	Error             string      `json:"error,omitempty"`
+	Jobs              []jobReport `json:"jobs"`
+	UploadedArtifacts []string    `json:"uploadedArtifacts,omitempty"`
+}
+
+type jobReport struct {
+	Name    string       `json:"name"`
+	Status  string       `json:"status"`
+	Error   string       `json:"error,omitempty"`
+	Image   string       `json:"image"`
+	Seconds float64      `json:"seconds"`
+	Steps   []stepReport `json:"steps"`
+	// FailedPhase is the phase of the failed step, setup or user, which is empty if no step failed
+	FailedPhase  string   `json:"failedPhase,omitempty"`
+	ArtifactsDir string   `json:"artifactsDir,omitempty"`
+	Artifacts    []string `json:"artifacts,omitempty"`
+}
+
+type stepReport struct {
+	Name    string  `json:"name"`
+	Phase   string  `json:"phase,omitempty"`
+	Status  string  `json:"status"`
+	Seconds float64 `json:"seconds"`
+}
+
+// listArtifacts returns the paths of the files in the artifacts directory
+func listArtifacts(dir string) []string {
+	paths := make([]string, 0)
+	_ = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	return paths
+}
+
+// newBuildReport creates the report of the jobs in the order of `names`.
+// The errors are masked by `mask` not to write the secrets into the report.
+// The artifacts are listed only if they are kept in the local directory.
+func newBuildReport(names []string, jobs map[string]screwdriver.Job, results map[string]JobResult, timings map[string][]buildlog.StepTiming,
+	err error, uploaded []string, localArtifacts bool, mask func(string) string) buildReport {
+	report := buildReport{
+		SchemaVersion:     reportSchemaVersion,
+		Status:            reportSucceeded,
+		Jobs:              make([]jobReport, 0, len(names)),
+		UploadedArtifacts: uploaded,
+	}
+	if err != nil {
+		report.Status = reportFailed
+		report.Error = mask(err.Error())
+	}
+
+	for _, name := range names {
+		jr := jobReport{
+			Name:   name,
+			Status: reportSkipped,
+			Image:  jobs[name].Image,
+			Steps:  make([]stepReport, 0, len(timings[name])),
+		}
+
+		var total time.Duration
+		for _, t := range timings[name] {
+			jr.Steps = append(jr.Steps, stepReport{Name: t.Name, Phase: t.Phase, Status: t.Status, Seconds: t.Duration.Seconds()})
+			total += t.Duration
+			if t.Status == buildlog.StepFailed {
+				jr.FailedPhase = t.Phase
+			}
+		}
+		jr.Seconds = total.Seconds()
+
+		if result, ok := results[name]; ok {
+			jr.Status = reportSucceeded
+			if result.Err != nil {
+				jr.Status = reportFailed
+				jr.Error = mask(result.Err.Error())
+			}
+
+			if localArtifacts {
+				jr.ArtifactsDir = result.ArtifactsPath
+				jr.Artifacts = listArtifacts(result.ArtifactsPath)
+			}
+		}
+
+		report.Jobs = append(report.Jobs, jr)
+	}
+
+	return report
+}
+
+// writeReport writes the report into the file at path
+func writeReport(path string, report buildReport) error {
+	b, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(path, append(b, '\n'), 0666); err != nil {
+		return fmt.Errorf("failed to write report %s: %v", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package build
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/screwdriver-cd/sd-local/buildlog"
+	"github.com/screwdriver-cd/sd-local/screwdriver"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewBuildReport(t *testing.T) {
+	dir, err := ioutil.TempDir("", "artifacts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	testArtifacts := filepath.Join(dir, "test")
+	_ = os.MkdirAll(testArtifacts, 0777)
+	_ = ioutil.WriteFile(filepath.Join(testArtifacts, "builds.log"), []byte(""), 0666)
+
+	jobs := map[string]screwdriver.Job{
+		"test":    {Image: "node:12"},
+		"lint":    {Image: "node:14"},
+		"publish": {Image: "node:16"},
+	}
+	timings := map[string][]buildlog.StepTiming{
+		"test": {
+			{Name: "install", Duration: 2 * time.Second, Status: buildlog.StepSucceeded, Phase: "user"},
+			{Name: "test", Duration: time.Second, Status: buildlog.StepFailed, Phase: "user"},
+		},
+	}
+	mask := strings.NewReplacer("secret", buildlog.Mask).Replace
+
+	t.Run("succeeded", func(t *testing.T) {
+		results := map[string]JobResult{
+			"test": {Name: "test", ArtifactsPath: testArtifacts},
+		}
+		report := newBuildReport([]string{"test"}, jobs, results, timings, nil, []string{"s3://bucket/builds.log"}, true, mask)
+		assert.Equal(t, buildReport{
+			SchemaVersion: reportSchemaVersion,
+			Status:        reportSucceeded,
+			Jobs: []jobReport{
+				{
+					Name:    "test",
+					Status:  reportSucceeded,
+					Image:   "node:12",
+					Seconds: 3,
+					Steps: []stepReport{
+						{Name: "install", Phase: "user", Status: buildlog.StepSucceeded, Seconds: 2},
+						{Name: "test", Phase: "user", Status: buildlog.StepFailed, Seconds: 1},
+					},
+					FailedPhase:  "user",
+					ArtifactsDir: testArtifacts,
+					Artifacts:    []string{filepath.Join(testArtifacts, "builds.log")},
+				},
+			},
+			UploadedArtifacts: []string{"s3://bucket/builds.log"},
+		}, report)
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		results := map[string]JobResult{
+			"test": {Name: "test", ArtifactsPath: testArtifacts, Err: errors.New("failed with secret")},
+			"lint": {Name: "lint", ArtifactsPath: filepath.Join(dir, "lint"), Err: errors.New("the build was stopped before it started")},
+		}
+		report := newBuildReport([]string{"test", "lint", "publish"}, jobs, results, timings, errors.New("failed with secret"), nil, false, mask)
+		assert.Equal(t, reportFailed, report.Status)
+		assert.Equal(t, "failed with ****", report.Error)
+
+		assert.Equal(t, reportFailed, report.Jobs[0].Status)
+		assert.Equal(t, "failed with ****", report.Jobs[0].Error)
+		assert.Equal(t, "user", report.Jobs[0].FailedPhase)
+		assert.Empty(t, report.Jobs[0].ArtifactsDir)
+		assert.Nil(t, report.Jobs[0].Artifacts)
+
+		assert.Equal(t, reportFailed, report.Jobs[1].Status)
+		assert.Equal(t, []stepReport{}, report.Jobs[1].Steps)
+
+		assert.Equal(t, reportSkipped, report.Jobs[2].Status)
+		assert.Equal(t, "node:16", report.Jobs[2].Image)
+	})
+}
+
+func TestWriteReport(t *testing.T) {
+	dir, err := ioutil.TempDir("", "report")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "report.json")
+	err = writeReport(path, buildReport{SchemaVersion: reportSchemaVersion, Status: reportSkipped, Jobs: []jobReport{}})
+	assert.Nil(t, err)
+	b, _ := ioutil.ReadFile(path)
+	assert.Equal(t, "{\n  \"schemaVersion\": 1,\n  \"status\": \"skipped\",\n  \"jobs\": []\n}\n", string(b))
+
+	err = writeReport(filepath.Join(dir, "missing", "report.json"), buildReport{})
+	assert.Contains(t, err.Error(), "failed to write report")
+}
@@ -0,0 +1,162 @@
+package build
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/screwdriver-cd/sd-local/buildlog"
+	"github.com/screwdriver-cd/sd-local/screwdriver"
+	"github.com/sirupsen/logrus"
+)
+
+// StateFile is the file in the artifacts directory to persist the state of the build to resume it
+const StateFile = "sd-local-state.json"
+
+// containerKeeper is the launcher which keeps the build container of the failed build
+type containerKeeper interface {
+	KeptContainer() string
+}
+
+// State is the state of the build to resume it from the first step which did not succeed
+type State struct {
+	Job            string   `json:"job"`
+	Image          string   `json:"image"`
+	Fingerprint    string   `json:"fingerprint"`
+	SucceededSteps []string `json:"succeededSteps"`
+	// Container is the build container kept by the failed build
+	Container string `json:"container,omitempty"`
+}
+
+// GitRevision returns the commit and the uncommitted changes of the git repository at srcPath.
+// The files ignored by git like the installed dependencies are not included.
+func GitRevision(srcPath string) (string, error) {
+	var revision []byte
+	for _, args := range [][]string{
+		{"rev-parse", "HEAD"},
+		{"diff", "HEAD"},
+		{"ls-files", "--others", "--exclude-standard"},
+	} {
+		out, err := exec.Command("git", append([]string{"-C", srcPath}, args...)...).Output()
+		if err != nil {
+			return "", fmt.Errorf("failed to get the revision of the source code: %v", err)
+		}
+		revision = append(revision, out...)
+	}
+	return string(revision), nil
+}
+
+// buildFingerprint returns the hash of the job definition and the source code to detect the changes since the previous build.
+// The error is returned with the fingerprint of only the job if the revision of the source code is not available.
+func buildFingerprint(job screwdriver.Job, srcPath string, sourceRevision func(srcPath string) (string, error)) (string, error) {
+	h := sha256.New()
+	b, err := json.Marshal(job)
+	if err != nil {
+		return "", err
+	}
+	h.Write(b)
+
+	revision, err := sourceRevision(srcPath)
+	h.Write([]byte(revision))
+	return hex.EncodeToString(h.Sum(nil)), err
+}
+
+// ReadState reads the state of the previous build in the artifacts directory. It returns nil if there is no state.
+func ReadState(artifactsPath string) (*State, error) {
+	b, err := ioutil.ReadFile(filepath.Join(artifactsPath, StateFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the state of the previous build: %v", err)
+	}
+
+	var state State
+	if err := json.Unmarshal(b, &state); err != nil {
+		return nil, fmt.Errorf("failed to parse the state of the previous build: %v", err)
+	}
+	return &state, nil
+}
+
+// WriteState writes the state of the build into the artifacts directory
+func WriteState(artifactsPath string, state State) error {
+	b, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(filepath.Join(artifactsPath, StateFile), append(b, '\n'), 0666); err != nil {
+		return fmt.Errorf("failed to write the state of the build: %v", err)
+	}
+	return nil
+}
+
+// succeededSteps returns the steps of the job which succeeded in the previous build or in this build.
+// The teardown steps are not included because they run in every build.
+func succeededSteps(job screwdriver.Job, previous []string, timings []buildlog.StepTiming) []string {
+	succeeded := make(map[string]bool, len(previous)+len(timings))
+	for _, name := range previous {
+		succeeded[name] = true
+	}
+	for _, t := range timings {
+		if t.Status == buildlog.StepSucceeded {
+			succeeded[t.Name] = true
+		}
+	}
+
+	steps := make([]string, 0, len(succeeded))
+	for _, s := range job.Steps {
+		if !s.IsTeardown() && succeeded[s.Name] {
+			steps = append(steps, s.Name)
+		}
+	}
+	return steps
+}
+
+// remainingSteps returns the steps of the job from the first step which did not succeed, and the teardown steps
+func remainingSteps(job screwdriver.Job, succeeded []string) []string {
+	done := make(map[string]bool, len(succeeded))
+	for _, name := range succeeded {
+		done[name] = true
+	}
+
+	steps := make([]string, 0, len(job.Steps))
+	resumed := false
+	for _, s := range job.Steps {
+		if s.IsTeardown() {
+			continue
+		}
+		if !resumed && done[s.Name] {
+			continue
+		}
+		resumed = true
+		steps = append(steps, s.Name)
+	}
+	return steps
+}
+
+// resumableState returns the state of the previous build of the job if the build can be resumed with it.
+// It returns nil to start the build fresh if there is no state or the job or the source code changed.
+func resumableState(jobName, artifactsPath, fingerprint string, fingerprintErr error) *State {
+	state, err := ReadState(artifactsPath)
+	if err != nil {
+		logrus.Warnf("%v, starting the build of %s fresh", err, jobName)
+		return nil
+	}
+	if state == nil {
+		logrus.Warnf("There is no state of the previous build of %s, starting the build fresh", jobName)
+		return nil
+	}
+	if fingerprintErr != nil {
+		logrus.Warnf("The changes of the source code can't be detected: %v", fingerprintErr)
+	}
+	if state.Fingerprint != fingerprint {
+		logrus.Warnf("The job definition or the source code of %s changed since the previous build, starting the build fresh", jobName)
+		return nil
+	}
+	return state
+}
@@ -0,0 +1,175 @@
+package build
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/screwdriver-cd/sd-local/buildlog"
+	"github.com/screwdriver-cd/sd-local/screwdriver"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+var resumeJob = screwdriver.Job{
+	Image: "node:12",
+	Steps: []screwdriver.Step{
+		{Name: "install", Command: "npm install"},
+		{Name: "test", Command: "npm test"},
+		{Name: "publish", Command: "npm publish"},
+		{Name: "teardown-clean", Command: "rm -rf node_modules"},
+	},
+}
+
+func TestGitRevision(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir, err := ioutil.TempDir("", "src")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	git := func(args ...string) {
+		c := exec.Command("git", append([]string{"-C", dir, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		if out, err := c.CombinedOutput(); err != nil {
+			t.Fatalf("%v: %s", err, out)
+		}
+	}
+
+	_, err = GitRevision(dir)
+	assert.Contains(t, err.Error(), "failed to get the revision of the source code")
+
+	git("init")
+	_ = ioutil.WriteFile(filepath.Join(dir, "main.js"), []byte("v1"), 0666)
+	_ = ioutil.WriteFile(filepath.Join(dir, ".gitignore"), []byte("node_modules\n"), 0666)
+	git("add", ".")
+	git("commit", "-m", "init")
+	committed, err := GitRevision(dir)
+	assert.Nil(t, err)
+
+	_ = os.MkdirAll(filepath.Join(dir, "node_modules"), 0777)
+	_ = ioutil.WriteFile(filepath.Join(dir, "node_modules", "dep.js"), []byte(""), 0666)
+	revision, _ := GitRevision(dir)
+	assert.Equal(t, committed, revision, "the ignored files must not change the revision")
+
+	_ = ioutil.WriteFile(filepath.Join(dir, "main.js"), []byte("v2"), 0666)
+	modified, _ := GitRevision(dir)
+	assert.NotEqual(t, committed, modified)
+
+	_ = ioutil.WriteFile(filepath.Join(dir, "new.js"), []byte(""), 0666)
+	added, _ := GitRevision(dir)
+	assert.NotEqual(t, modified, added)
+}
+
+func TestBuildFingerprint(t *testing.T) {
+	revision := "rev1"
+	sourceRevision := func(srcPath string) (string, error) { return revision, nil }
+	fingerprint, err := buildFingerprint(resumeJob, "src", sourceRevision)
+	assert.Nil(t, err)
+
+	same, _ := buildFingerprint(resumeJob, "src", sourceRevision)
+	assert.Equal(t, fingerprint, same)
+
+	changedJob := resumeJob
+	changedJob.Image = "node:14"
+	changed, _ := buildFingerprint(changedJob, "src", sourceRevision)
+	assert.NotEqual(t, fingerprint, changed)
+
+	revision = "rev2"
+	changed, _ = buildFingerprint(resumeJob, "src", sourceRevision)
+	assert.NotEqual(t, fingerprint, changed)
+
+	_, err = buildFingerprint(resumeJob, "src", func(srcPath string) (string, error) { return "", errors.New("not a git repository") })
+	assert.EqualError(t, err, "not a git repository")
+}
+
+func TestState(t *testing.T) {
+	dir, err := ioutil.TempDir("", "artifacts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	state, err := ReadState(dir)
+	assert.Nil(t, err)
+	assert.Nil(t, state)
+
+	expected := State{Job: "main", Image: "node:12", Fingerprint: "abc", SucceededSteps: []string{"install"}, Container: "cid"}
+	assert.Nil(t, WriteState(dir, expected))
+	state, err = ReadState(dir)
+	assert.Nil(t, err)
+	assert.Equal(t, &expected, state)
+
+	_ = ioutil.WriteFile(filepath.Join(dir, StateFile), []byte("{"), 0666)
+	_, err = ReadState(dir)
+	assert.Contains(t, err.Error(), "failed to parse the state of the previous build")
+
+	err = WriteState(filepath.Join(dir, "missing"), expected)
+	assert.Contains(t, err.Error(), "failed to write the state of the build")
+}
+
+func TestSucceededSteps(t *testing.T) {
+	timings := []buildlog.StepTiming{
+		{Name: "sd-setup-launcher", Duration: time.Second, Status: buildlog.StepSucceeded},
+		{Name: "test", Duration: time.Second, Status: buildlog.StepSucceeded},
+		{Name: "publish", Duration: time.Second, Status: buildlog.StepFailed},
+		{Name: "teardown-clean", Duration: time.Second, Status: buildlog.StepFinished},
+	}
+	assert.Equal(t, []string{"install", "test"}, succeededSteps(resumeJob, []string{"install"}, timings))
+	assert.Equal(t, []string{}, succeededSteps(resumeJob, nil, nil))
+}
+
+func TestRemainingSteps(t *testing.T) {
+	testCases := map[string]struct {
+		succeeded []string
+		expected  []string
+	}{
+		"nothing succeeded":    {succeeded: nil, expected: []string{"install", "test", "publish"}},
+		"failed at the middle": {succeeded: []string{"install"}, expected: []string{"test", "publish"}},
+		"failed at the first":  {succeeded: []string{"test"}, expected: []string{"install", "test", "publish"}},
+		"all succeeded":        {succeeded: []string{"install", "test", "publish"}, expected: []string{}},
+	}
+
+	for name, tt := range testCases {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, remainingSteps(resumeJob, tt.succeeded))
+		})
+	}
+}
+
+func TestResumableState(t *testing.T) {
+	dir, err := ioutil.TempDir("", "artifacts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	logBuf := bytes.NewBuffer(nil)
+	logrus.SetOutput(logBuf)
+	defer logrus.SetOutput(os.Stderr)
+
+	assert.Nil(t, resumableState("main", dir, "abc", nil))
+	assert.Contains(t, logBuf.String(), "There is no state of the previous build of main")
+
+	state := State{Job: "main", Fingerprint: "abc", SucceededSteps: []string{"install"}}
+	_ = WriteState(dir, state)
+
+	assert.Equal(t, &state, resumableState("main", dir, "abc", nil))
+
+	logBuf.Reset()
+	assert.Equal(t, &state, resumableState("main", dir, "abc", errors.New("not a git repository")))
+	assert.Contains(t, logBuf.String(), "The changes of the source code can't be detected: not a git repository")
+
+	logBuf.Reset()
+	assert.Nil(t, resumableState("main", dir, "def", nil))
+	assert.Contains(t, logBuf.String(), "The job definition or the source code of main changed since the previous build")
+}
@@ -0,0 +1,84 @@
+package build
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/screwdriver-cd/sd-local/buildlog"
+)
+
+// SummaryJSON is Options.SummaryFormat to write the summary in JSON instead of the table
+const SummaryJSON = "json"
+
+// jobTimings is the timing summary of the steps of a job
+type jobTimings struct {
+	Job          string        `json:"job"`
+	Steps        []stepTiming  `json:"steps"`
+	Total        time.Duration `json:"-"`
+	TotalSeconds float64       `json:"totalSeconds"`
+	// FailedPhase is the phase of the failed step, setup or user, which is empty if no step failed
+	FailedPhase string `json:"failedPhase,omitempty"`
+}
+
+type stepTiming struct {
+	Name     string        `json:"name"`
+	Phase    string        `json:"phase,omitempty"`
+	Status   string        `json:"status,omitempty"`
+	Duration time.Duration `json:"-"`
+	Seconds  float64       `json:"seconds"`
+}
+
+// writeSummary writes the durations of the executed steps of the jobs in the order of `names` in the table or in JSON by the format
+func writeSummary(w io.Writer, names []string, timings map[string][]buildlog.StepTiming, format string, sortTime bool) error {
+	summary := make([]jobTimings, 0, len(names))
+	for _, name := range names {
+		if len(timings[name]) == 0 {
+			continue
+		}
+
+		jt := jobTimings{Job: name}
+		for _, t := range timings[name] {
+			jt.Steps = append(jt.Steps, stepTiming{Name: t.Name, Phase: t.Phase, Status: t.Status, Duration: t.Duration, Seconds: t.Duration.Seconds()})
+			jt.Total += t.Duration
+			if t.Status == buildlog.StepFailed {
+				jt.FailedPhase = t.Phase
+			}
+		}
+		jt.TotalSeconds = jt.Total.Seconds()
+		if sortTime {
+			sort.SliceStable(jt.Steps, func(i, j int) bool {
+				return jt.Steps[i].Duration > jt.Steps[j].Duration
+			})
+		}
+		summary = append(summary, jt)
+	}
+
+	if len(summary) == 0 {
+		return nil
+	}
+
+	if format == SummaryJSON {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(summary)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "JOB\tSTEP\tPHASE\tSTATUS\tDURATION")
+	for _, jt := range summary {
+		for _, step := range jt.Steps {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", jt.Job, step.Name, step.Phase, step.Status, step.Duration)
+		}
+		// the total shows the phase the job failed in
+		status := ""
+		if jt.FailedPhase != "" {
+			status = buildlog.StepFailed
+		}
+		fmt.Fprintf(tw, "%s\tTOTAL\t%s\t%s\t%s\n", jt.Job, jt.FailedPhase, status, jt.Total)
+	}
+	return tw.Flush()
+}
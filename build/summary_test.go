@@ -0,0 +1,115 @@
+package build
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/screwdriver-cd/sd-local/buildlog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteSummary(t *testing.T) {
+	timings := map[string][]buildlog.StepTiming{
+		"test": {
+			{Name: "install", Duration: 1500 * time.Millisecond, Status: buildlog.StepSucceeded, Phase: "user"},
+			{Name: "test", Duration: 3 * time.Second, Status: buildlog.StepFailed, Phase: "user"},
+			{Name: "teardown-report", Duration: 0, Status: buildlog.StepFinished, Phase: "teardown"},
+		},
+		"lint": {
+			{Name: "lint", Duration: 500 * time.Millisecond, Status: buildlog.StepSucceeded, Phase: "user"},
+		},
+	}
+
+	cases := map[string]struct {
+		names    []string
+		format   string
+		sortTime bool
+		expect   string
+	}{
+		"table": {
+			names: []string{"test", "lint"},
+			expect: "JOB   STEP             PHASE     STATUS     DURATION\n" +
+				"test  install          user      succeeded  1.5s\n" +
+				"test  test             user      failed     3s\n" +
+				"test  teardown-report  teardown  finished   0s\n" +
+				"test  TOTAL            user      failed     4.5s\n" +
+				"lint  lint             user      succeeded  500ms\n" +
+				"lint  TOTAL                                 500ms\n",
+		},
+		"table sorted by duration": {
+			names:    []string{"test"},
+			sortTime: true,
+			expect: "JOB   STEP             PHASE     STATUS     DURATION\n" +
+				"test  test             user      failed     3s\n" +
+				"test  install          user      succeeded  1.5s\n" +
+				"test  teardown-report  teardown  finished   0s\n" +
+				"test  TOTAL            user      failed     4.5s\n",
+		},
+		"json": {
+			names:  []string{"lint"},
+			format: SummaryJSON,
+			expect: `[
+  {
+    "job": "lint",
+    "steps": [
+      {
+        "name": "lint",
+        "phase": "user",
+        "status": "succeeded",
+        "seconds": 0.5
+      }
+    ],
+    "totalSeconds": 0.5
+  }
+]
+`,
+		},
+		"json with the failed phase": {
+			names:  []string{"test"},
+			format: SummaryJSON,
+			expect: `[
+  {
+    "job": "test",
+    "steps": [
+      {
+        "name": "install",
+        "phase": "user",
+        "status": "succeeded",
+        "seconds": 1.5
+      },
+      {
+        "name": "test",
+        "phase": "user",
+        "status": "failed",
+        "seconds": 3
+      },
+      {
+        "name": "teardown-report",
+        "phase": "teardown",
+        "status": "finished",
+        "seconds": 0
+      }
+    ],
+    "totalSeconds": 4.5,
+    "failedPhase": "user"
+  }
+]
+`,
+		},
+		"no steps": {
+			names:  []string{"publish"},
+			expect: "",
+		},
+	}
+
+	for name, test := range cases {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			buf := bytes.NewBuffer(nil)
+			err := writeSummary(buf, test.names, timings, test.format, test.sortTime)
+			assert.Nil(t, err)
+			assert.Equal(t, test.expect, buf.String())
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package build
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrTimeout is matched by errors.Is with the error of Run whose builds were stopped by Options.Timeout
+var ErrTimeout = errors.New("build timed out")
+
+// timeoutError is the error of the builds which timed out, whose message has the timeout
+type timeoutError struct {
+	timeout time.Duration
+}
+
+func (e *timeoutError) Error() string {
+	return fmt.Sprintf("build timed out after %s", formatTimeout(e.timeout))
+}
+
+func (e *timeoutError) Is(target error) bool {
+	return target == ErrTimeout
+}
+
+// formatTimeout formats the timeout without the redundant zero units, like 30m instead of 30m0s
+func formatTimeout(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
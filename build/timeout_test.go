@@ -0,0 +1,36 @@
+package build
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimeoutError(t *testing.T) {
+	err := &timeoutError{timeout: 30 * time.Minute}
+	assert.EqualError(t, err, "build timed out after 30m")
+	assert.True(t, errors.Is(fmt.Errorf("failed: %w", err), ErrTimeout))
+}
+
+func TestFormatTimeout(t *testing.T) {
+	cases := map[string]struct {
+		timeout time.Duration
+		expect  string
+	}{
+		"minutes":             {timeout: 30 * time.Minute, expect: "30m"},
+		"hours":               {timeout: 2 * time.Hour, expect: "2h"},
+		"hours and minutes":   {timeout: 90 * time.Minute, expect: "1h30m"},
+		"minutes and seconds": {timeout: 90 * time.Second, expect: "1m30s"},
+		"milliseconds":        {timeout: 10 * time.Millisecond, expect: "10ms"},
+	}
+
+	for name, test := range cases {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.expect, formatTimeout(test.timeout))
+		})
+	}
+}
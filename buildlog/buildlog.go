@@ -22,7 +22,10 @@ const (
 // Logger outputs logs
 type Logger interface {
 	Run()
-	Stop()
+	// Stop stops the logger after the build finished with err
+	Stop(err error)
+	// Timings returns the durations of the executed steps. It must be called after the logger is done.
+	Timings() []StepTiming
 }
 
 type log struct {
@@ -32,6 +35,24 @@ type log struct {
 	cancel         context.CancelFunc
 	done           chan<- struct{}
 	currentLineNum int
+	// showSteps prints the boundaries of the steps, which are colored if color is true
+	showSteps    bool
+	color        bool
+	step         string
+	stepStart    int64
+	buildStart   int64
+	lastTime     int64
+	lastUserStep string
+	err          error
+	timings      []StepTiming
+	// stepEnds is the times the steps of timings finished at
+	stepEnds []int64
+	// holding is true after the user step followed by the teardown steps or the end of the build,
+	// whose results are written by the Formatter after the result of the build is known
+	holding  bool
+	heldFrom int
+	// events is the Formatter of the events given by WithEvents, which is written along with writer
+	events Formatter
 }
 
 type logLine struct {
@@ -46,10 +67,16 @@ type parseError struct{}
 func (e *parseError) Error() string { return "Parse Error" }
 
 // New creates new Logger interface.
-func New(filepath string, writer io.Writer, done chan<- struct{}) (Logger, error) {
+// The boundaries of the steps are printed in color if `color` is true.
+func New(filepath string, writer io.Writer, done chan<- struct{}, color bool) (Logger, error) {
 	log := log{
-		writer: writer,
-		done:   done,
+		writer:    writer,
+		done:      done,
+		showSteps: true,
+		color:     color,
+	}
+	if w, ok := writer.(*eventsWriter); ok {
+		log.writer, log.events = w.Writer, w.events
 	}
 
 	var err error
@@ -63,10 +90,15 @@ func New(filepath string, writer io.Writer, done chan<- struct{}) (Logger, error
 	return &log, nil
 }
 
-func (l *log) Stop() {
+func (l *log) Stop(err error) {
+	l.err = err
 	l.cancel()
 }
 
+func (l *log) Timings() []StepTiming {
+	return l.timings
+}
+
 func (l *log) Run() {
 	reader := bufio.NewReader(l.file)
 	buildDone := false
@@ -94,6 +126,9 @@ func (l *log) Run() {
 		}
 
 		if buildDone && readDone {
+			if l.showSteps {
+				l.finishSteps()
+			}
 			close(l.done)
 			break
 		}
@@ -125,22 +160,35 @@ func (l *log) output(reader *bufio.Reader) (bool, error) {
 		return false, fmt.Errorf("failed to read logfile: %w", err)
 	}
 
-	parsedLog, err := parse(line)
+	ll, err := parse(line)
 	if err != nil {
 		logrus.Warnf("\x1b[33mParsed error. If you want to check see %s:%d \x1b[0m", rowBuildLogPath, l.currentLineNum)
 		return false, &parseError{}
 	}
 
-	fmt.Fprintf(l.writer, "%s\r\n", parsedLog)
+	if l.showSteps && ll.StepName != l.step {
+		l.switchStep(ll.StepName, ll.Time)
+	}
+	l.lastTime = ll.Time
+
+	if l.events != nil {
+		if err := l.events.WriteLine(ll.StepName, ll.Time, ll.Message); err != nil {
+			return false, err
+		}
+	}
+	if f, ok := l.writer.(Formatter); ok {
+		return false, f.WriteLine(ll.StepName, ll.Time, ll.Message)
+	}
+	fmt.Fprintf(l.writer, "%s: %s\r\n", ll.StepName, ll.Message)
 	return false, nil
 }
 
-func parse(rawLog []byte) (string, error) {
+func parse(rawLog []byte) (*logLine, error) {
 	ll := &logLine{}
 	err := json.Unmarshal(rawLog, ll)
 	if err != nil {
-		return "", fmt.Errorf("failed to parse raw log: %w", err)
+		return nil, fmt.Errorf("failed to parse raw log: %w", err)
 	}
 
-	return fmt.Sprintf("%s: %s", ll.StepName, ll.Message), nil
+	return ll, nil
 }
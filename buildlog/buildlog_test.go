@@ -70,7 +70,7 @@ func TestRun(t *testing.T) {
 		go l.Run()
 
 		time.Sleep(intervalTime * time.Millisecond)
-		l.Stop()
+		l.Stop(nil)
 		timeout := time.After(5 * time.Second)
 
 		select {
@@ -115,7 +115,7 @@ func TestRun(t *testing.T) {
 		go l.Run()
 
 		time.Sleep(intervalTime * time.Millisecond)
-		l.Stop()
+		l.Stop(nil)
 
 		timeout := time.After(5 * time.Second)
 
@@ -163,7 +163,7 @@ func TestRun(t *testing.T) {
 		go l.Run()
 
 		time.Sleep(intervalTime * time.Millisecond)
-		l.Stop()
+		l.Stop(nil)
 
 		timeout := time.After(5 * time.Second)
 
@@ -187,7 +187,7 @@ func TestStop(t *testing.T) {
 	}
 
 	timeout := time.After(5 * time.Second)
-	l.Stop()
+	l.Stop(nil)
 
 	select {
 	case v := <-l.ctx.Done():
@@ -208,7 +208,7 @@ func TestNew(t *testing.T) {
 		writer := bytes.NewBuffer(nil)
 
 		loggerDone := make(chan struct{})
-		logger, err := New(tmpFile.Name(), writer, loggerDone)
+		logger, err := New(tmpFile.Name(), writer, loggerDone, true)
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -225,21 +225,24 @@ func TestNew(t *testing.T) {
 
 		assert.Equal(t, tmpFile.Name(), file.Name())
 		assert.Equal(t, writer, log.writer)
+		assert.True(t, log.showSteps)
+		assert.True(t, log.color)
 	})
 
 	t.Run("failure", func(t *testing.T) {
 		writer := bytes.NewBuffer(nil)
 
 		loggerDone := make(chan struct{})
-		logger, err := New("/", writer, loggerDone)
+		logger, err := New("/", writer, loggerDone, false)
 		if err == nil {
 			t.Fatal("failure err is nil")
 		}
 
 		expected := &log{
-			writer: writer,
-			file:   (*os.File)(nil),
-			done:   loggerDone,
+			writer:    writer,
+			file:      (*os.File)(nil),
+			done:      loggerDone,
+			showSteps: true,
 		}
 
 		msg := err.Error()
@@ -0,0 +1,136 @@
+package buildlog
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/screwdriver-cd/sd-local/screwdriver"
+)
+
+// The types of the events of the builds
+const (
+	EventBuildStarted  = "build-started"
+	EventStepStarted   = "step-started"
+	EventLogLine       = "log-line"
+	EventStepFinished  = "step-finished"
+	EventBuildFinished = "build-finished"
+)
+
+// Event is the event of the build of a job, which is written as a line of JSON
+type Event struct {
+	Type     string `json:"type"`
+	Time     string `json:"time"`
+	Job      string `json:"job"`
+	Step     string `json:"step,omitempty"`
+	Phase    string `json:"phase,omitempty"`
+	Message  string `json:"message,omitempty"`
+	Status   string `json:"status,omitempty"`
+	Duration string `json:"duration,omitempty"`
+	// Container is the name of the build container of build-started
+	Container string `json:"container,omitempty"`
+}
+
+// EventWriter writes the events of the builds as newline-delimited JSON for the programs like UIs watching the builds.
+// The events of the jobs running in parallel never interleave their lines.
+type EventWriter struct {
+	writer io.Writer
+	mutex  sync.Mutex
+}
+
+// NewEventWriter returns the EventWriter writing into `writer`
+func NewEventWriter(writer io.Writer) *EventWriter {
+	return &EventWriter{writer: writer}
+}
+
+// Write writes the event as a line
+func (w *EventWriter) Write(event Event) error {
+	b, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	_, err = w.writer.Write(append(b, '\n'))
+	return err
+}
+
+func now() int64 {
+	return time.Now().UnixNano() / int64(time.Millisecond)
+}
+
+// BuildStarted writes build-started of the job run in the container
+func (w *EventWriter) BuildStarted(jobName, container string) error {
+	return w.Write(Event{Type: EventBuildStarted, Time: formatTime(now()), Job: jobName, Container: container})
+}
+
+// BuildFinished writes build-finished of the job, which failed at the failed step of `steps` if err is not nil
+func (w *EventWriter) BuildFinished(jobName string, steps []StepTiming, total time.Duration, err error) error {
+	event := Event{Type: EventBuildFinished, Time: formatTime(now()), Job: jobName, Status: StepSucceeded, Duration: total.String()}
+	if err != nil {
+		event.Status, event.Message = StepFailed, err.Error()
+		for _, s := range steps {
+			if s.Status == StepFailed {
+				event.Step, event.Phase = s.Name, s.Phase
+			}
+		}
+	}
+	return w.Write(event)
+}
+
+// Formatter returns the Formatter of the job writing step-started, log-line and step-finished,
+// which is given to New with the writer of the logs by WithEvents.
+func (w *EventWriter) Formatter(jobName string) Formatter {
+	return &eventFormatter{events: w, job: jobName}
+}
+
+type eventFormatter struct {
+	events *EventWriter
+	job    string
+	step   string
+}
+
+func (f *eventFormatter) Write(p []byte) (int, error) {
+	return len(p), nil
+}
+
+// WriteLine writes step-started before the first line of the step, which starts the step in the logs
+func (f *eventFormatter) WriteLine(step string, t int64, message string) error {
+	if step != f.step {
+		f.step = step
+		if err := f.events.Write(Event{Type: EventStepStarted, Time: formatTime(t), Job: f.job, Step: step, Phase: screwdriver.StepPhase(step)}); err != nil {
+			return err
+		}
+	}
+	return f.events.Write(Event{Type: EventLogLine, Time: formatTime(t), Job: f.job, Step: step, Message: message})
+}
+
+func (f *eventFormatter) WriteStep(timing StepTiming, t int64) error {
+	return f.events.Write(Event{
+		Type:     EventStepFinished,
+		Time:     formatTime(t),
+		Job:      f.job,
+		Step:     timing.Name,
+		Phase:    timing.Phase,
+		Status:   timing.Status,
+		Duration: timing.Duration.String(),
+	})
+}
+
+// WriteBuild writes nothing because build-finished is written by BuildFinished, as the build can finish without the logs
+func (f *eventFormatter) WriteBuild(failedStep string, total time.Duration, err error, t int64) error {
+	return nil
+}
+
+type eventsWriter struct {
+	io.Writer
+	events Formatter
+}
+
+// WithEvents returns the writer given to New, whose logger writes the logs into `writer`
+// and the lines and the steps into `events` at the same time.
+func WithEvents(writer io.Writer, events Formatter) io.Writer {
+	return &eventsWriter{Writer: writer, events: events}
+}
@@ -0,0 +1,100 @@
+package buildlog
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithEvents(t *testing.T) {
+	inputs := strings.Join([]string{
+		`{"t": 1581662020000, "m": "installed", "n": 0, "s": "install"}`,
+		`{"t": 1581662021500, "m": "not ok", "n": 1, "s": "test"}`,
+		`{"t": 1581662022250, "m": "reported", "n": 2, "s": "teardown-report"}`,
+	}, "\n") + "\n"
+
+	output := bytes.NewBuffer(nil)
+	events := bytes.NewBuffer(nil)
+	l := log{showSteps: true, err: fmt.Errorf("exit status 1")}
+	if w, ok := WithEvents(output, NewEventWriter(events).Formatter("main")).(*eventsWriter); ok {
+		l.writer, l.events = w.Writer, w.events
+	}
+
+	reader := bufio.NewReader(strings.NewReader(inputs))
+	for {
+		readDone, err := l.output(reader)
+		assert.Nil(t, err)
+		if readDone {
+			break
+		}
+	}
+	l.finishSteps()
+
+	assert.Equal(t, strings.Join([]string{
+		"==> install",
+		"install: installed",
+		"<== install succeeded (1.5s)",
+		"==> test",
+		"test: not ok",
+		"<== test finished (750ms)",
+		"==> teardown-report",
+		"teardown-report: reported",
+		"<== teardown-report finished (0s)",
+		"Build failed at test in the user phase (2.25s)",
+	}, "\r\n")+"\r\n", output.String())
+
+	assert.Equal(t, strings.Join([]string{
+		`{"type":"step-started","time":"2020-02-14T06:33:40Z","job":"main","step":"install","phase":"user"}`,
+		`{"type":"log-line","time":"2020-02-14T06:33:40Z","job":"main","step":"install","message":"installed"}`,
+		`{"type":"step-finished","time":"2020-02-14T06:33:41.5Z","job":"main","step":"install","phase":"user","status":"succeeded","duration":"1.5s"}`,
+		`{"type":"step-started","time":"2020-02-14T06:33:41.5Z","job":"main","step":"test","phase":"user"}`,
+		`{"type":"log-line","time":"2020-02-14T06:33:41.5Z","job":"main","step":"test","message":"not ok"}`,
+		`{"type":"step-started","time":"2020-02-14T06:33:42.25Z","job":"main","step":"teardown-report","phase":"teardown"}`,
+		`{"type":"log-line","time":"2020-02-14T06:33:42.25Z","job":"main","step":"teardown-report","message":"reported"}`,
+		`{"type":"step-finished","time":"2020-02-14T06:33:42.25Z","job":"main","step":"test","phase":"user","status":"failed","duration":"750ms"}`,
+		`{"type":"step-finished","time":"2020-02-14T06:33:42.25Z","job":"main","step":"teardown-report","phase":"teardown","status":"finished","duration":"0s"}`,
+	}, "\n")+"\n", events.String())
+}
+
+func TestEventWriter(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	w := NewEventWriter(buf)
+
+	if err := w.BuildStarted("main", "sdlocal-main"); err != nil {
+		t.Fatal(err)
+	}
+	steps := []StepTiming{
+		{Name: "install", Duration: time.Second, Status: StepSucceeded, Phase: "user"},
+		{Name: "test", Duration: time.Second, Status: StepFailed, Phase: "user"},
+	}
+	if err := w.BuildFinished("main", steps, 2*time.Second, fmt.Errorf("exit status 1")); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.BuildFinished("lint", nil, time.Second, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	var events []Event
+	for _, line := range strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n") {
+		var e Event
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			t.Fatal(err)
+		}
+		_, err := time.Parse(time.RFC3339Nano, e.Time)
+		assert.Nil(t, err)
+		e.Time = ""
+		events = append(events, e)
+	}
+
+	assert.Equal(t, []Event{
+		{Type: EventBuildStarted, Job: "main", Container: "sdlocal-main"},
+		{Type: EventBuildFinished, Job: "main", Step: "test", Phase: "user", Message: "exit status 1", Status: StepFailed, Duration: "2s"},
+		{Type: EventBuildFinished, Job: "lint", Status: StepSucceeded, Duration: "1s"},
+	}, events)
+}
@@ -0,0 +1,61 @@
+package buildlog
+
+import (
+	"io"
+	"os"
+	"regexp"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ansiPattern matches the ANSI escape sequences like colors
+var ansiPattern = regexp.MustCompile(`\x1b\[[0-9;?]*[ -/]*[@-~]`)
+
+type ansiStripWriter struct {
+	writer io.Writer
+}
+
+// NewANSIStripWriter returns a writer which removes the ANSI escape sequences before writing.
+// The logger writes a line at once, so a sequence is never split between writes.
+func NewANSIStripWriter(writer io.Writer) io.Writer {
+	return &ansiStripWriter{writer: writer}
+}
+
+func (w *ansiStripWriter) Write(p []byte) (int, error) {
+	_, err := w.writer.Write(ansiPattern.ReplaceAll(p, nil))
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// OpenFile opens the file to write the build logs into. The file is truncated unless `append` is true.
+func OpenFile(path string, append bool) (*os.File, error) {
+	flag := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if append {
+		flag = os.O_WRONLY | os.O_CREATE | os.O_APPEND
+	}
+	return os.OpenFile(path, flag, 0666)
+}
+
+type hook struct {
+	writer io.Writer
+}
+
+// NewHook returns the logrus hook which writes the messages of sd-local into `writer` as well as the terminal
+func NewHook(writer io.Writer) logrus.Hook {
+	return &hook{writer: writer}
+}
+
+func (h *hook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (h *hook) Fire(entry *logrus.Entry) error {
+	line, err := entry.String()
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(h.writer, line)
+	return err
+}
@@ -0,0 +1,70 @@
+package buildlog
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestANSIStripWriter(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	w := NewANSIStripWriter(buf)
+
+	n, err := fmt.Fprintf(w, "%s\r\n", "\x1b[33mtest: \x1b[1;31mfailed\x1b[0m")
+	assert.Nil(t, err)
+	assert.Equal(t, 30, n)
+	assert.Equal(t, "test: failed\r\n", buf.String())
+}
+
+func TestOpenFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "buildlog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "build.log")
+
+	cases := []struct {
+		name   string
+		append bool
+		expect string
+	}{
+		{"truncate", false, "second\n"},
+		{"append", true, "first\nsecond\n"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := ioutil.WriteFile(path, []byte("first\n"), 0666)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			f, err := OpenFile(path, c.append)
+			assert.Nil(t, err)
+			fmt.Fprint(f, "second\n")
+			f.Close()
+
+			b, _ := ioutil.ReadFile(path)
+			assert.Equal(t, c.expect, string(b))
+		})
+	}
+}
+
+func TestHook(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	logger := logrus.New()
+	logger.SetOutput(ioutil.Discard)
+	logger.SetFormatter(&logrus.TextFormatter{DisableTimestamp: true})
+	logger.AddHook(NewHook(buf))
+
+	logger.Info("Pulling docker image")
+
+	assert.Equal(t, "level=info msg=\"Pulling docker image\"\n", buf.String())
+}
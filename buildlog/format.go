@@ -0,0 +1,197 @@
+package buildlog
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/screwdriver-cd/sd-local/screwdriver"
+)
+
+// The formats of the build logs
+const (
+	FormatPlain = "plain"
+	FormatJSON  = "json"
+	// FormatTAP is the Test Anything Protocol whose tests are the steps
+	FormatTAP = "tap"
+)
+
+// The streams of the objects of the JSON format
+const (
+	// streamOutput is the output of the steps, which is stdout and stderr merged by the launcher
+	streamOutput = "output"
+	// streamStatus is the results of the steps and the build
+	streamStatus = "status"
+)
+
+// ValidateFormat validates the format of the build logs
+func ValidateFormat(format string) error {
+	switch format {
+	case FormatPlain, FormatJSON, FormatTAP:
+		return nil
+	}
+	return fmt.Errorf("invalid log format %s: must be one of %s, %s or %s", format, FormatPlain, FormatJSON, FormatTAP)
+}
+
+// Formatter is the writer of the build logs in a format other than plain.
+// The logger writes the lines and the results of the steps through it if the writer given to New implements it.
+type Formatter interface {
+	io.Writer
+	// WriteLine writes the line of the output of the step at t in milliseconds
+	WriteLine(step string, t int64, message string) error
+	// WriteStep writes the result of the step finished at t
+	WriteStep(timing StepTiming, t int64) error
+	// WriteBuild writes the result of the build finished at t, which failed at failedStep if err is not nil
+	WriteBuild(failedStep string, total time.Duration, err error, t int64) error
+}
+
+type jsonLine struct {
+	Job      string `json:"job,omitempty"`
+	Step     string `json:"step,omitempty"`
+	Time     string `json:"time"`
+	Stream   string `json:"stream"`
+	Message  string `json:"message,omitempty"`
+	Status   string `json:"status,omitempty"`
+	Phase    string `json:"phase,omitempty"`
+	Duration string `json:"duration,omitempty"`
+}
+
+type jsonFormatter struct {
+	writer io.Writer
+	job    string
+	mutex  *sync.Mutex
+}
+
+// NewJSONFormatter returns the Formatter which writes an object per line of the logs and per result of the steps.
+// The objects have the job name unless it is empty, and the formatters sharing `mutex` never interleave their lines.
+func NewJSONFormatter(writer io.Writer, jobName string, mutex *sync.Mutex) Formatter {
+	return &jsonFormatter{writer: writer, job: jobName, mutex: mutex}
+}
+
+func formatTime(t int64) string {
+	return time.Unix(0, t*int64(time.Millisecond)).UTC().Format(time.RFC3339Nano)
+}
+
+func (f *jsonFormatter) write(line jsonLine) error {
+	line.Job = f.job
+	b, err := json.Marshal(line)
+	if err != nil {
+		return err
+	}
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	_, err = f.writer.Write(append(b, '\n'))
+	return err
+}
+
+func (f *jsonFormatter) Write(p []byte) (int, error) {
+	if err := f.WriteLine("", time.Now().UnixNano()/int64(time.Millisecond), strings.TrimRight(string(p), "\r\n")); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (f *jsonFormatter) WriteLine(step string, t int64, message string) error {
+	return f.write(jsonLine{Step: step, Time: formatTime(t), Stream: streamOutput, Message: message})
+}
+
+func (f *jsonFormatter) WriteStep(timing StepTiming, t int64) error {
+	return f.write(jsonLine{
+		Step:     timing.Name,
+		Time:     formatTime(t),
+		Stream:   streamStatus,
+		Status:   timing.Status,
+		Phase:    timing.Phase,
+		Duration: timing.Duration.String(),
+	})
+}
+
+func (f *jsonFormatter) WriteBuild(failedStep string, total time.Duration, err error, t int64) error {
+	line := jsonLine{Time: formatTime(t), Stream: streamStatus, Status: StepSucceeded, Duration: total.String()}
+	if err != nil {
+		line.Step, line.Status, line.Phase = failedStep, StepFailed, screwdriver.StepPhase(failedStep)
+		line.Message = err.Error()
+	}
+	return f.write(line)
+}
+
+// TAP is the stream of the Test Anything Protocol shared by the jobs, whose tests are the steps of them.
+// The output of the steps is written as the diagnostics.
+type TAP struct {
+	writer io.Writer
+	mutex  sync.Mutex
+	count  int
+}
+
+// NewTAP writes the version of TAP and returns the stream. Close must be called to write the plan after all the builds.
+func NewTAP(writer io.Writer) (*TAP, error) {
+	if _, err := fmt.Fprint(writer, "TAP version 13\n"); err != nil {
+		return nil, err
+	}
+	return &TAP{writer: writer}, nil
+}
+
+// Close writes the plan of the tests, which is written at the end because the steps to run are known only after the builds
+func (t *TAP) Close() error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	_, err := fmt.Fprintf(t.writer, "1..%d\n", t.count)
+	return err
+}
+
+// Formatter returns the Formatter of the job, whose tests are prefixed with the job name unless it is empty
+func (t *TAP) Formatter(jobName string) Formatter {
+	return &tapFormatter{tap: t, job: jobName}
+}
+
+type tapFormatter struct {
+	tap *TAP
+	job string
+}
+
+func (f *tapFormatter) name(s string) string {
+	if f.job == "" {
+		return s
+	}
+	return fmt.Sprintf("[%s] %s", f.job, s)
+}
+
+func (f *tapFormatter) Write(p []byte) (int, error) {
+	if err := f.WriteLine("", 0, strings.TrimRight(string(p), "\r\n")); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (f *tapFormatter) WriteLine(step string, t int64, message string) error {
+	if step != "" {
+		message = step + ": " + message
+	}
+	f.tap.mutex.Lock()
+	defer f.tap.mutex.Unlock()
+	_, err := fmt.Fprintf(f.tap.writer, "# %s\n", f.name(message))
+	return err
+}
+
+func (f *tapFormatter) WriteStep(timing StepTiming, t int64) error {
+	result := "ok"
+	if timing.Status == StepFailed {
+		result = "not ok"
+	}
+	f.tap.mutex.Lock()
+	defer f.tap.mutex.Unlock()
+	f.tap.count++
+	_, err := fmt.Fprintf(f.tap.writer, "%s %d - %s (%s)\n", result, f.tap.count, f.name(timing.Name), timing.Duration)
+	return err
+}
+
+func (f *tapFormatter) WriteBuild(failedStep string, total time.Duration, err error, t int64) error {
+	if err != nil {
+		return f.WriteLine("", t, fmt.Sprintf("Build failed at %s in the %s phase (%s)", failedStep, screwdriver.StepPhase(failedStep), total))
+	}
+	return f.WriteLine("", t, fmt.Sprintf("Build succeeded (%s)", total))
+}
@@ -0,0 +1,110 @@
+package buildlog
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// runLog outputs the raw logs by the logger writing into the writer and finishes the build with err
+func runLog(t *testing.T, writer Formatter, inputs string, err error) {
+	l := log{
+		writer:    writer,
+		showSteps: true,
+		err:       err,
+	}
+
+	reader := bufio.NewReader(strings.NewReader(inputs))
+	for {
+		readDone, err := l.output(reader)
+		assert.Nil(t, err)
+		if readDone {
+			break
+		}
+	}
+	l.finishSteps()
+}
+
+func TestValidateFormat(t *testing.T) {
+	for _, format := range []string{FormatPlain, FormatJSON, FormatTAP} {
+		assert.Nil(t, ValidateFormat(format))
+	}
+	assert.Equal(t, "invalid log format xml: must be one of plain, json or tap", ValidateFormat("xml").Error())
+}
+
+func TestJSONFormatter(t *testing.T) {
+	inputs := strings.Join([]string{
+		`{"t": 1581662020000, "m": "installed", "n": 0, "s": "install"}`,
+		`{"t": 1581662021500, "m": "not ok", "n": 1, "s": "test"}`,
+		`{"t": 1581662022250, "m": "reported", "n": 2, "s": "teardown-report"}`,
+	}, "\n") + "\n"
+
+	writer := bytes.NewBuffer(nil)
+	runLog(t, NewJSONFormatter(writer, "main", &sync.Mutex{}), inputs, fmt.Errorf("exit status 1"))
+
+	assert.Equal(t, strings.Join([]string{
+		`{"job":"main","step":"install","time":"2020-02-14T06:33:40Z","stream":"output","message":"installed"}`,
+		`{"job":"main","step":"install","time":"2020-02-14T06:33:41.5Z","stream":"status","status":"succeeded","phase":"user","duration":"1.5s"}`,
+		`{"job":"main","step":"test","time":"2020-02-14T06:33:41.5Z","stream":"output","message":"not ok"}`,
+		`{"job":"main","step":"teardown-report","time":"2020-02-14T06:33:42.25Z","stream":"output","message":"reported"}`,
+		`{"job":"main","step":"test","time":"2020-02-14T06:33:42.25Z","stream":"status","status":"failed","phase":"user","duration":"750ms"}`,
+		`{"job":"main","step":"teardown-report","time":"2020-02-14T06:33:42.25Z","stream":"status","status":"finished","phase":"teardown","duration":"0s"}`,
+		`{"job":"main","step":"test","time":"2020-02-14T06:33:42.25Z","stream":"status","message":"exit status 1","status":"failed","phase":"user","duration":"2.25s"}`,
+	}, "\n")+"\n", writer.String())
+}
+
+func TestTAP(t *testing.T) {
+	inputs := strings.Join([]string{
+		`{"t": 1581662020000, "m": "installed", "n": 0, "s": "install"}`,
+		`{"t": 1581662021500, "m": "ok", "n": 1, "s": "test"}`,
+	}, "\n") + "\n"
+
+	cases := []struct {
+		name   string
+		jobs   []string
+		err    error
+		expect string
+	}{
+		{
+			name: "success",
+			jobs: []string{""},
+			expect: "TAP version 13\n# install: installed\nok 1 - install (1.5s)\n# test: ok\nok 2 - test (0s)\n" +
+				"# Build succeeded (1.5s)\n1..2\n",
+		},
+		{
+			name: "failure",
+			jobs: []string{""},
+			err:  fmt.Errorf("exit status 1"),
+			expect: "TAP version 13\n# install: installed\nok 1 - install (1.5s)\n# test: ok\nnot ok 2 - test (0s)\n" +
+				"# Build failed at test in the user phase (1.5s)\n1..2\n",
+		},
+		{
+			name: "multiple jobs",
+			jobs: []string{"main", "lint"},
+			expect: "TAP version 13\n# [main] install: installed\nok 1 - [main] install (1.5s)\n# [main] test: ok\nok 2 - [main] test (0s)\n" +
+				"# [main] Build succeeded (1.5s)\n" +
+				"# [lint] install: installed\nok 3 - [lint] install (1.5s)\n# [lint] test: ok\nok 4 - [lint] test (0s)\n" +
+				"# [lint] Build succeeded (1.5s)\n1..4\n",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			writer := bytes.NewBuffer(nil)
+			tap, err := NewTAP(writer)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, job := range c.jobs {
+				runLog(t, tap.Formatter(job), inputs, c.err)
+			}
+			assert.Nil(t, tap.Close())
+			assert.Equal(t, c.expect, writer.String())
+		})
+	}
+}
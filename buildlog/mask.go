@@ -0,0 +1,68 @@
+package buildlog
+
+import (
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Mask replaces the secrets in the logs
+const Mask = "****"
+
+type maskWriter struct {
+	writer   io.Writer
+	replacer *strings.Replacer
+}
+
+// NewMasker returns the replacer of the secrets with the mask
+func NewMasker(secrets []string) *strings.Replacer {
+	// the longer secrets are replaced first not to leave the rest of them which contain the shorter ones
+	sorted := make([]string, 0, len(secrets))
+	for _, s := range secrets {
+		if s != "" {
+			sorted = append(sorted, s)
+		}
+	}
+	sort.Slice(sorted, func(i, j int) bool { return len(sorted[i]) > len(sorted[j]) })
+
+	oldnew := make([]string, 0, len(sorted)*2)
+	for _, s := range sorted {
+		oldnew = append(oldnew, s, Mask)
+	}
+	return strings.NewReplacer(oldnew...)
+}
+
+// NewMaskWriter returns a writer which replaces the secrets with the mask before writing.
+// The logger writes a line at once, so a secret without newlines is never split between writes.
+func NewMaskWriter(writer io.Writer, secrets []string) io.Writer {
+	return &maskWriter{writer: writer, replacer: NewMasker(secrets)}
+}
+
+func (w *maskWriter) Write(p []byte) (int, error) {
+	_, err := io.WriteString(w.writer, w.replacer.Replace(string(p)))
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+type maskFormatter struct {
+	formatter logrus.Formatter
+	replacer  *strings.Replacer
+}
+
+// NewMaskFormatter returns a logrus formatter which replaces the secrets with the mask in the formatted entries.
+// The hooks format the entries with the formatter as well, so the secrets are masked in all the outputs of the logger.
+func NewMaskFormatter(formatter logrus.Formatter, secrets []string) logrus.Formatter {
+	return &maskFormatter{formatter: formatter, replacer: NewMasker(secrets)}
+}
+
+func (f *maskFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	b, err := f.formatter.Format(entry)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(f.replacer.Replace(string(b))), nil
+}
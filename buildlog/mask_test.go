@@ -0,0 +1,50 @@
+package buildlog
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMaskWriter(t *testing.T) {
+	testCase := []struct {
+		name    string
+		secrets []string
+		input   string
+		expect  string
+	}{
+		{"no secrets", nil, "test: password\r\n", "test: password\r\n"},
+		{"secrets", []string{"password", "token"}, "test: password and token\r\n", "test: **** and ****\r\n"},
+		{"secret containing another", []string{"pass", "password"}, "test: password\r\n", "test: ****\r\n"},
+		{"empty secret", []string{""}, "test: password\r\n", "test: password\r\n"},
+	}
+
+	for _, tt := range testCase {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := bytes.NewBuffer(nil)
+			w := NewMaskWriter(buf, tt.secrets)
+
+			n, err := fmt.Fprint(w, tt.input)
+			assert.Nil(t, err)
+			assert.Equal(t, len(tt.input), n)
+			assert.Equal(t, tt.expect, buf.String())
+		})
+	}
+}
+
+func TestMaskFormatter(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	hookBuf := bytes.NewBuffer(nil)
+	logger := logrus.New()
+	logger.SetOutput(buf)
+	logger.SetFormatter(NewMaskFormatter(&logrus.TextFormatter{DisableTimestamp: true}, []string{"aBcD1234"}))
+	logger.AddHook(NewHook(hookBuf))
+
+	logger.Errorf("failed to send request: Get https://api.screwdriver.cd/v4/auth/token?api_token=%s", "aBcD1234")
+	expect := "level=error msg=\"failed to send request: Get https://api.screwdriver.cd/v4/auth/token?api_token=****\"\n"
+	assert.Equal(t, expect, buf.String())
+	assert.Equal(t, expect, hookBuf.String())
+}
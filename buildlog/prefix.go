@@ -0,0 +1,34 @@
+package buildlog
+
+import (
+	"io"
+	"sync"
+)
+
+type prefixWriter struct {
+	writer io.Writer
+	prefix []byte
+	mutex  *sync.Mutex
+}
+
+// NewPrefixWriter returns a writer which prefixes every write with `prefix`.
+// The logger writes a line at once, so every line of the logs of the job is prefixed.
+// Writers sharing `mutex` never interleave their lines.
+func NewPrefixWriter(writer io.Writer, prefix string, mutex *sync.Mutex) io.Writer {
+	return &prefixWriter{
+		writer: writer,
+		prefix: []byte(prefix),
+		mutex:  mutex,
+	}
+}
+
+func (w *prefixWriter) Write(p []byte) (int, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	_, err := w.writer.Write(append(append([]byte{}, w.prefix...), p...))
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
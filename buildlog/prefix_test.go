@@ -0,0 +1,24 @@
+package buildlog
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrefixWriter(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	mutex := &sync.Mutex{}
+	main := NewPrefixWriter(buf, "[main] ", mutex)
+	lint := NewPrefixWriter(buf, "[lint] ", mutex)
+
+	n, err := fmt.Fprintf(main, "%s\r\n", "test: ok")
+	assert.Nil(t, err)
+	assert.Equal(t, 10, n)
+	fmt.Fprintf(lint, "%s\r\n", "lint: ok")
+
+	assert.Equal(t, "[main] test: ok\r\n[lint] lint: ok\r\n", buf.String())
+}
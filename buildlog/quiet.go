@@ -0,0 +1,112 @@
+package buildlog
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/screwdriver-cd/sd-local/screwdriver"
+)
+
+// QuietLines is the number of the last lines of each step kept by the quiet Formatter, beyond which the oldest lines are dropped
+const QuietLines = 1000
+
+// lineRing keeps the last lines up to its capacity
+type lineRing struct {
+	lines   []string
+	next    int
+	dropped int
+}
+
+func newLineRing(size int) *lineRing {
+	return &lineRing{lines: make([]string, 0, size)}
+}
+
+func (r *lineRing) add(line string) {
+	if len(r.lines) < cap(r.lines) {
+		r.lines = append(r.lines, line)
+		return
+	}
+	r.lines[r.next] = line
+	r.next = (r.next + 1) % len(r.lines)
+	r.dropped++
+}
+
+// all returns the kept lines from the oldest
+func (r *lineRing) all() []string {
+	return append(append([]string{}, r.lines[r.next:]...), r.lines[:r.next]...)
+}
+
+type quietFormatter struct {
+	writer io.Writer
+	size   int
+	color  bool
+	steps  map[string]*lineRing
+}
+
+// NewQuietFormatter returns the Formatter which writes only the logs of the failed steps and the result of the build in plain.
+// The logs of each step are held until the step finishes up to the last `lines` lines, and the number of the dropped lines is written with them.
+func NewQuietFormatter(writer io.Writer, lines int, color bool) Formatter {
+	if lines < 1 {
+		lines = 1
+	}
+	return &quietFormatter{writer: writer, size: lines, color: color, steps: map[string]*lineRing{}}
+}
+
+func (f *quietFormatter) colorize(color, s string) string {
+	if !f.color {
+		return s
+	}
+	return color + s + colorReset
+}
+
+// Write writes the messages other than the logs of the steps as they are
+func (f *quietFormatter) Write(p []byte) (int, error) {
+	return f.writer.Write(p)
+}
+
+func (f *quietFormatter) WriteLine(step string, t int64, message string) error {
+	r, ok := f.steps[step]
+	if !ok {
+		r = newLineRing(f.size)
+		f.steps[step] = r
+	}
+	r.add(fmt.Sprintf("%s: %s", step, message))
+	return nil
+}
+
+func (f *quietFormatter) WriteStep(timing StepTiming, t int64) error {
+	r := f.steps[timing.Name]
+	delete(f.steps, timing.Name)
+	if timing.Status != StepFailed {
+		return nil
+	}
+
+	if _, err := fmt.Fprintf(f.writer, "%s\r\n", f.colorize(colorCyan, fmt.Sprintf("==> %s", timing.Name))); err != nil {
+		return err
+	}
+	if r != nil {
+		if r.dropped != 0 {
+			if _, err := fmt.Fprintf(f.writer, "... %d lines truncated, see the build logs in the artifacts for all the lines\r\n", r.dropped); err != nil {
+				return err
+			}
+		}
+		for _, line := range r.all() {
+			if _, err := fmt.Fprintf(f.writer, "%s\r\n", line); err != nil {
+				return err
+			}
+		}
+	}
+	_, err := fmt.Fprintf(f.writer, "%s\r\n", f.colorize(colorRed, fmt.Sprintf("<== %s failed (%s)", timing.Name, timing.Duration)))
+	return err
+}
+
+func (f *quietFormatter) WriteBuild(failedStep string, total time.Duration, err error, t int64) error {
+	if err != nil {
+		_, err = fmt.Fprintf(f.writer, "%s\r\n", f.colorize(colorRed, fmt.Sprintf("Build failed at %s in the %s phase (%s)",
+			failedStep, screwdriver.StepPhase(failedStep), total)))
+		return err
+	}
+	_, err = fmt.Fprintf(f.writer, "%s\r\n", f.colorize(colorGreen, fmt.Sprintf("Build succeeded (%s)", total)))
+	return err
+}
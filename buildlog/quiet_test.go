@@ -0,0 +1,70 @@
+package buildlog
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuietFormatter(t *testing.T) {
+	inputs := strings.Join([]string{
+		`{"t": 1581662020000, "m": "added 120 packages", "n": 0, "s": "install"}`,
+		`{"t": 1581662021500, "m": "1 passing", "n": 1, "s": "test"}`,
+		`{"t": 1581662021600, "m": "2 failing", "n": 2, "s": "test"}`,
+		`{"t": 1581662021700, "m": "3 failing", "n": 3, "s": "test"}`,
+		`{"t": 1581662022000, "m": "uploaded", "n": 4, "s": "teardown-upload"}`,
+	}, "\n") + "\n"
+
+	cases := []struct {
+		name   string
+		lines  int
+		err    error
+		expect string
+	}{
+		{
+			name:   "success",
+			lines:  QuietLines,
+			expect: "Build succeeded (2s)\r\n",
+		},
+		{
+			name:  "failure",
+			lines: QuietLines,
+			err:   fmt.Errorf("exit status 1"),
+			expect: "==> test\r\ntest: 1 passing\r\ntest: 2 failing\r\ntest: 3 failing\r\n<== test failed (500ms)\r\n" +
+				"Build failed at test in the user phase (2s)\r\n",
+		},
+		{
+			name:  "failure with the truncated lines",
+			lines: 2,
+			err:   fmt.Errorf("exit status 1"),
+			expect: "==> test\r\n... 1 lines truncated, see the build logs in the artifacts for all the lines\r\ntest: 2 failing\r\ntest: 3 failing\r\n" +
+				"<== test failed (500ms)\r\nBuild failed at test in the user phase (2s)\r\n",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			writer := bytes.NewBuffer(nil)
+			runLog(t, NewQuietFormatter(writer, c.lines, false), inputs, c.err)
+			assert.Equal(t, c.expect, writer.String())
+		})
+	}
+}
+
+func TestLineRing(t *testing.T) {
+	r := newLineRing(3)
+	for _, line := range []string{"a", "b"} {
+		r.add(line)
+	}
+	assert.Equal(t, []string{"a", "b"}, r.all())
+	assert.Equal(t, 0, r.dropped)
+
+	for _, line := range []string{"c", "d", "e", "f", "g"} {
+		r.add(line)
+	}
+	assert.Equal(t, []string{"e", "f", "g"}, r.all())
+	assert.Equal(t, 4, r.dropped)
+}
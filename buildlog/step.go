@@ -0,0 +1,144 @@
+package buildlog
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/screwdriver-cd/sd-local/screwdriver"
+)
+
+const (
+	colorReset = "\x1b[0m"
+	colorRed   = "\x1b[31m"
+	colorGreen = "\x1b[32m"
+	colorCyan  = "\x1b[1;36m"
+)
+
+// The status of the steps
+const (
+	StepSucceeded = "succeeded"
+	StepFailed    = "failed"
+	// StepFinished is the status of the teardown steps, which run regardless of the result of the build
+	StepFinished = "finished"
+)
+
+// StepTiming is the duration and the status of the step measured with the timestamps of the build logs
+type StepTiming struct {
+	Name     string
+	Duration time.Duration
+	Status   string
+	// Phase is the phase of the build the step runs in, setup, user or teardown
+	Phase string
+}
+
+// isTeardownStep returns true for the user-defined and the launcher's teardown steps, which run even if the build failed
+func isTeardownStep(name string) bool {
+	return screwdriver.StepPhase(name) == screwdriver.PhaseTeardown
+}
+
+func (l *log) colorize(color, s string) string {
+	if !l.color {
+		return s
+	}
+	return color + s + colorReset
+}
+
+func elapsed(from, to int64) time.Duration {
+	return time.Duration(to-from) * time.Millisecond
+}
+
+// switchStep prints the boundary between the current step and the next step which starts at `t`
+func (l *log) switchStep(next string, t int64) {
+	if l.step != "" {
+		status := StepSucceeded
+		if isTeardownStep(l.step) {
+			status = StepFinished
+		}
+		l.timings = append(l.timings, StepTiming{Name: l.step, Duration: elapsed(l.stepStart, t), Status: status, Phase: screwdriver.StepPhase(l.step)})
+		l.stepEnds = append(l.stepEnds, t)
+
+		l.holdStep(next)
+		if l.events != nil {
+			l.writeStep(l.events)
+		}
+		if f, ok := l.writer.(Formatter); ok {
+			l.writeStep(f)
+		} else if !isTeardownStep(l.step) && !isTeardownStep(next) {
+			// the step succeeded if the build went on to the next step, but the teardown steps run even after failures
+			fmt.Fprintf(l.writer, "%s\r\n", l.colorize(colorGreen, fmt.Sprintf("<== %s succeeded (%s)", l.step, elapsed(l.stepStart, t))))
+		} else {
+			fmt.Fprintf(l.writer, "<== %s finished (%s)\r\n", l.step, elapsed(l.stepStart, t))
+		}
+	} else {
+		l.buildStart = t
+	}
+
+	if _, ok := l.writer.(Formatter); !ok && next != "" {
+		fmt.Fprintf(l.writer, "%s\r\n", l.colorize(colorCyan, fmt.Sprintf("==> %s", next)))
+	}
+	if next != "" && !isTeardownStep(next) {
+		l.lastUserStep = next
+	}
+	l.step = next
+	l.stepStart = t
+}
+
+// holdStep holds the results from the user step followed by the teardown steps or the end of the build,
+// which are written by the Formatters after the status of the step is known by the result of the build.
+func (l *log) holdStep(next string) {
+	if !l.holding && !isTeardownStep(l.step) && (next == "" || isTeardownStep(next)) {
+		l.holding = true
+		l.heldFrom = len(l.timings) - 1
+	}
+}
+
+// writeStep writes the result of the step which has just finished by the Formatter unless it is held
+func (l *log) writeStep(f Formatter) {
+	if l.holding {
+		return
+	}
+	i := len(l.timings) - 1
+	f.WriteStep(l.timings[i], l.stepEnds[i])
+}
+
+// writeHeldSteps writes the results of the held steps by the Formatter
+func (l *log) writeHeldSteps(f Formatter) {
+	for i := l.heldFrom; l.holding && i < len(l.timings); i++ {
+		f.WriteStep(l.timings[i], l.stepEnds[i])
+	}
+}
+
+// finishSteps prints the end of the last step and the result of the build
+func (l *log) finishSteps() {
+	if l.step == "" {
+		return
+	}
+	end := l.lastTime
+	l.switchStep("", end)
+
+	total := elapsed(l.buildStart, end)
+	if l.err != nil {
+		for i := range l.timings {
+			if l.timings[i].Name == l.lastUserStep {
+				l.timings[i].Status = StepFailed
+			}
+		}
+	}
+
+	if l.events != nil {
+		l.writeHeldSteps(l.events)
+	}
+	if f, ok := l.writer.(Formatter); ok {
+		l.writeHeldSteps(f)
+		f.WriteBuild(l.lastUserStep, total, l.err, end)
+		return
+	}
+
+	if l.err != nil {
+		// the teardown steps ran after the failure, so the build failed in the phase of the last step before them
+		fmt.Fprintf(l.writer, "%s\r\n", l.colorize(colorRed, fmt.Sprintf("Build failed at %s in the %s phase (%s)",
+			l.lastUserStep, screwdriver.StepPhase(l.lastUserStep), total)))
+		return
+	}
+	fmt.Fprintf(l.writer, "%s\r\n", l.colorize(colorGreen, fmt.Sprintf("Build succeeded (%s)", total)))
+}
@@ -0,0 +1,117 @@
+package buildlog
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSteps(t *testing.T) {
+	inputs := strings.Join([]string{
+		`{"t": 1581662020000, "m": "installed", "n": 0, "s": "install"}`,
+		`{"t": 1581662021500, "m": "ok", "n": 1, "s": "test"}`,
+		`{"t": 1581662022000, "m": "done", "n": 2, "s": "test"}`,
+		`{"t": 1581662022250, "m": "reported", "n": 3, "s": "teardown-report"}`,
+	}, "\n") + "\n"
+
+	cases := []struct {
+		name       string
+		color      bool
+		err        error
+		expect     string
+		testStatus string
+	}{
+		{
+			name: "success",
+			expect: "==> install\r\ninstall: installed\r\n" +
+				"<== install succeeded (1.5s)\r\n==> test\r\ntest: ok\r\ntest: done\r\n" +
+				"<== test finished (750ms)\r\n==> teardown-report\r\nteardown-report: reported\r\n" +
+				"<== teardown-report finished (0s)\r\nBuild succeeded (2.25s)\r\n",
+			testStatus: StepSucceeded,
+		},
+		{
+			name: "failure",
+			err:  fmt.Errorf("exit status 1"),
+			expect: "==> install\r\ninstall: installed\r\n" +
+				"<== install succeeded (1.5s)\r\n==> test\r\ntest: ok\r\ntest: done\r\n" +
+				"<== test finished (750ms)\r\n==> teardown-report\r\nteardown-report: reported\r\n" +
+				"<== teardown-report finished (0s)\r\nBuild failed at test in the user phase (2.25s)\r\n",
+			testStatus: StepFailed,
+		},
+		{
+			name:  "success with color",
+			color: true,
+			expect: "\x1b[1;36m==> install\x1b[0m\r\ninstall: installed\r\n" +
+				"\x1b[32m<== install succeeded (1.5s)\x1b[0m\r\n\x1b[1;36m==> test\x1b[0m\r\ntest: ok\r\ntest: done\r\n" +
+				"<== test finished (750ms)\r\n\x1b[1;36m==> teardown-report\x1b[0m\r\nteardown-report: reported\r\n" +
+				"<== teardown-report finished (0s)\r\n\x1b[32mBuild succeeded (2.25s)\x1b[0m\r\n",
+			testStatus: StepSucceeded,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			writer := bytes.NewBuffer(nil)
+			l := log{
+				writer:    writer,
+				showSteps: true,
+				color:     c.color,
+				err:       c.err,
+			}
+
+			reader := bufio.NewReader(strings.NewReader(inputs))
+			for {
+				readDone, err := l.output(reader)
+				assert.Nil(t, err)
+				if readDone {
+					break
+				}
+			}
+			l.finishSteps()
+
+			assert.Equal(t, c.expect, writer.String())
+			assert.Equal(t, []StepTiming{
+				{Name: "install", Duration: 1500 * time.Millisecond, Status: StepSucceeded, Phase: "user"},
+				{Name: "test", Duration: 750 * time.Millisecond, Status: c.testStatus, Phase: "user"},
+				{Name: "teardown-report", Duration: 0, Status: StepFinished, Phase: "teardown"},
+			}, l.Timings())
+		})
+	}
+}
+
+func TestStepsFailedInSetup(t *testing.T) {
+	inputs := strings.Join([]string{
+		`{"t": 1581662020000, "m": "cloning", "n": 0, "s": "sd-setup-scm"}`,
+		`{"t": 1581662021000, "m": "cleaned", "n": 1, "s": "sd-teardown-artifacts"}`,
+	}, "\n") + "\n"
+
+	writer := bytes.NewBuffer(nil)
+	l := log{
+		writer:    writer,
+		showSteps: true,
+		err:       fmt.Errorf("exit status 1"),
+	}
+
+	reader := bufio.NewReader(strings.NewReader(inputs))
+	for {
+		readDone, err := l.output(reader)
+		assert.Nil(t, err)
+		if readDone {
+			break
+		}
+	}
+	l.finishSteps()
+
+	assert.Equal(t, "==> sd-setup-scm\r\nsd-setup-scm: cloning\r\n<== sd-setup-scm finished (1s)\r\n"+
+		"==> sd-teardown-artifacts\r\nsd-teardown-artifacts: cleaned\r\n<== sd-teardown-artifacts finished (0s)\r\n"+
+		"Build failed at sd-setup-scm in the setup phase (1s)\r\n", writer.String())
+	assert.Equal(t, []StepTiming{
+		{Name: "sd-setup-scm", Duration: time.Second, Status: StepFailed, Phase: "setup"},
+		{Name: "sd-teardown-artifacts", Duration: 0, Status: StepFinished, Phase: "teardown"},
+	}, l.Timings())
+}
@@ -2,40 +2,58 @@ package cmd
 
 import (
 	"bufio"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"runtime"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
-	"github.com/joho/godotenv"
 	"github.com/mitchellh/go-homedir"
+	"github.com/screwdriver-cd/sd-local/artifacts"
+	"github.com/screwdriver-cd/sd-local/build"
 	"github.com/screwdriver-cd/sd-local/buildlog"
 	"github.com/screwdriver-cd/sd-local/config"
+	"github.com/screwdriver-cd/sd-local/ignore"
 	"github.com/screwdriver-cd/sd-local/launch"
+	"github.com/screwdriver-cd/sd-local/retry"
 	"github.com/screwdriver-cd/sd-local/scm"
 	"github.com/screwdriver-cd/sd-local/screwdriver"
+	"github.com/screwdriver-cd/sd-local/sdcmd"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	"golang.org/x/term"
 )
 
 var (
-	configNew       = config.New
-	apiNew          = screwdriver.New
-	buildLogNew     = buildlog.New
-	launchNew       = launch.New
-	artifactsDir    = launch.ArtifactsDir
-	memory          = ""
-	scmNew          = scm.New
-	osMkdirAll      = os.MkdirAll
-	useSudo         = false
-	usePrivileged   = false
-	interactiveMode = false
-	loggerDone      chan struct{}
+	configNew          = config.New
+	configRead         = config.Read
+	apiNew             = screwdriver.New
+	buildLogNew        = buildlog.New
+	launchNew          = launch.New
+	artifactsDir       = launch.ArtifactsDir
+	memory             = ""
+	scmNew             = scm.New
+	uploaderNew        = artifacts.NewS3
+	osMkdirAll         = os.MkdirAll
+	useSudo            = false
+	usePrivileged      = false
+	interactiveMode    = false
+	maxParallel        = 1
+	isTerminal         = term.IsTerminal
+	expandYAMLFile     = expandYAML
+	expandTemplateFile = expandTemplate
+	extractStepEnvFile = extractStepEnv
+	launcherTags       = launch.LauncherTags
+	checkBuildRuntime  = launch.CheckRuntimeWith
+	runHook            = build.RunHookCommand
+	sourceRevision     = build.GitRevision
 )
 
 func mergeEnvFromFile(optionEnv *map[string]string, envFilePath string) error {
@@ -44,7 +62,7 @@ func mergeEnvFromFile(optionEnv *map[string]string, envFilePath string) error {
 		return err
 	}
 
-	env, err := godotenv.Read(absEnvFilePath)
+	env, err := readDotenv(absEnvFilePath)
 	if err != nil {
 		return fmt.Errorf("failed to read env file in `%s`: %v", absEnvFilePath, err)
 	}
@@ -57,6 +75,15 @@ func mergeEnvFromFile(optionEnv *map[string]string, envFilePath string) error {
 	return nil
 }
 
+// readSecretsFile reads the secrets from the file in the '.env' format. The values are never shown in the errors.
+func readSecretsFile(secretsFilePath string) (launch.EnvVar, error) {
+	secrets, err := readDotenv(secretsFilePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read secrets file %s: %v", secretsFilePath, err)
+	}
+	return secrets, nil
+}
+
 func generateUserAgent(uuid string) string {
 	// User-Agent format sample
 	// "User-Agent": "sd-local/<sd-local version> (darwin or linux; <UUID>)"
@@ -65,66 +92,475 @@ func generateUserAgent(uuid string) string {
 	return ua
 }
 
+// buildError returns the error of the builds with the exit code. The errors after the builds which succeeded,
+// like the failures to upload the artifacts, exit with ExitUsage.
+func buildError(result build.Result, err error) error {
+	if errors.Is(err, build.ErrTimeout) {
+		return withExitCode(err, ExitBuildFailure)
+	}
+	for _, job := range result.Jobs {
+		if job.Err != nil {
+			return withExitCode(err, buildExitCode(result))
+		}
+	}
+	return err
+}
+
+// buildExitCode returns the exit code of the failed builds, which is ExitRuntime if any job failed by the container runtime,
+// the code of the error of the job which failed to prepare its build like ExitAPI, or ExitBuildFailure for the failed steps
+func buildExitCode(result build.Result) int {
+	code := ExitBuildFailure
+	for _, job := range result.Jobs {
+		if errors.Is(job.Err, launch.ErrRuntime) {
+			return ExitRuntime
+		}
+		var e *exitError
+		if errors.As(job.Err, &e) {
+			code = e.code
+		}
+	}
+	return code
+}
+
+// pipelineJobs returns the jobs of the pipeline registered in Screwdriver.cd
+func pipelineJobs(api screwdriver.API, pipelineID int) (map[string]screwdriver.Job, error) {
+	p, ok := api.(screwdriver.PipelineAPI)
+	if !ok {
+		return nil, errors.New("the API does not support getting the jobs of the pipeline")
+	}
+	logrus.Infof("Getting the jobs of pipeline %d from the API...", pipelineID)
+	return p.PipelineJobs(context.Background(), pipelineID)
+}
+
+// fetchCommands fetches the binaries of the commands of sd-cmd into the cache with the fetcher
+func fetchCommands(api screwdriver.API, fetcher sdcmd.Fetcher, refs []sdcmd.Ref) error {
+	c, ok := api.(screwdriver.CommandAPI)
+	if !ok {
+		return errors.New("the API does not support getting the commands of sd-cmd")
+	}
+	fetcher.API = c
+	for _, ref := range refs {
+		if err := fetcher.Fetch(context.Background(), ref); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// supportsMount returns true if the host paths can be mounted into the build container with the runtime
+func supportsMount(runtime string) bool {
+	return runtime != config.RuntimeKubernetes
+}
+
+// supportsUser returns true if the runtime runs the build container as the specified user, which k8s leaves to the security context of the cluster
+func supportsUser(runtime string) bool {
+	return runtime != config.RuntimeKubernetes
+}
+
+// supportsLauncherArchive returns true if the runtime loads the launcher image on the host, which k8s pulls on the nodes
+func supportsLauncherArchive(runtime string) bool {
+	return runtime != config.RuntimeKubernetes
+}
+
+// buildContainerName returns the name of the build container of the job like sdlocal-main-20210102150405.
+// The name specified by --container-name is suffixed with the job name if multiple jobs run to be unique.
+func buildContainerName(name, jobName string, multiple bool) string {
+	if name == "" {
+		return launch.ContainerName("sdlocal", jobName, time.Now().Format("20060102150405"))
+	}
+	if multiple {
+		return launch.ContainerName(name, jobName)
+	}
+	return name
+}
+
+const outputJSON = "json"
+
 func newBuildCmd() *cobra.Command {
 	var srcURL string
+	var srcDir string
+	var pipelineFile string
+	var pipelineID int
+	var noIgnore bool
+	var noExpand bool
+	var printIgnored bool
 	var optionEnv map[string]string
 	var envFilePath string
-	var optionMeta string
+	var secretsFilePath string
+	var artifactsS3 string
+	var artifactsS3Endpoint string
+	var noLocalArtifacts bool
+	var artifactsKeep int
+	var hooks build.Hooks
+	var report string
+	var optionMeta []string
 	var metaFilePath string
+	var metaOutPath string
 	var socketPath string
 	var localVolumes []string
+	var mountSpecs []string
+	var cacheDir string
+	var runtimeName string
+	var platform string
+	var pullPolicy string
+	var offline bool
+	var dockerHost string
+	var configEntry string
+	var launcherProfile string
+	var launcherArchive string
+	var containerName string
+	var image string
+	var timeout time.Duration
+	var retries int
+	var retryBackoff time.Duration
+	var stepNames []string
+	var noTeardown bool
+	var failFast bool
+	var resume bool
+	var dryRun bool
+	var watch bool
+	var logFilePath string
+	var logAppend bool
+	var quiet bool
+	var noColor bool
+	var output string
+	var sortTime bool
+	var printExpanded bool
+	var matrixFilter map[string]string
+	var changedSince string
+	var logFormat string
+	var checkLauncherVersion bool
+	var strict bool
+	var workdir string
+	var jsonEventsPath string
+	var buildUser string
+	var shell string
+	var dumpEnvFile string
+	var dumpEnvUnmasked bool
 
 	buildCmd := &cobra.Command{
-		Use:   "build [job name]",
-		Short: "Run screwdriver build.",
-		Long:  `Run screwdriver build of the specified job name.`,
+		Annotations: map[string]string{usesTokenAnnotation: ""},
+		Use:         "build [job name...]",
+		Short:       "Run screwdriver build.",
+		Long: `Run screwdriver build of the specified job names.
+The jobs which do not require each other run in parallel up to --max-parallel,
+and the logs of each job are prefixed with the job name.
+The jobs with the matrix run as all the combinations of it named like main[NODE_VERSION=12], which can be narrowed down by --matrix.
+The jobs of screwdriver.yaml in another path like a service of a monorepo are specified like services/a/screwdriver.yaml::test.
+The cpu and memory of the build container are limited by the annotations screwdriver.cd/cpu and screwdriver.cd/ram of the job,
+and screwdriver.cd/cpu/<step name> and screwdriver.cd/ram/<step name> for the steps.
+All steps run in the same build container whose limits can't be changed, so the maximum of the annotations is used.
+The steps run in the directory of the annotation screwdriver.cd/workdir of the job if it is set, which is overridden by --workdir.
+The steps run in the shell of the annotation screwdriver.cd/shell or USER_SHELL_BIN of the job if it is set, which is overridden by --shell.
+Ctrl-C stops the build and removes the build container before exiting with 130, and the second Ctrl-C exits immediately.`,
 		Args: func(cmd *cobra.Command, args []string) error {
-			err := cobra.ExactArgs(1)(cmd, args)
+			err := cobra.MinimumNArgs(1)(cmd, args)
 
 			if err != nil {
 				return err
 			}
 
-			if optionMeta != "" && metaFilePath != "" {
+			if len(stepNames) != 0 && len(args) > 1 {
+				return errors.New("can't select steps of multiple jobs, please specify only one job")
+			}
+
+			if interactiveMode && len(args) > 1 {
+				return errors.New("can't run multiple jobs in interactive mode, please specify only one job")
+			}
+
+			if maxParallel < 1 {
+				return fmt.Errorf("max-parallel must be a positive integer: %d", maxParallel)
+			}
+
+			if srcURL != "" && srcDir != "" {
+				return errors.New("can't pass the both options `src-url` and `src-dir`, please specify only one of them")
+			}
+
+			if len(optionMeta) != 0 && metaFilePath != "" {
 				return errors.New("can't pass the both options `meta` and `meta-file`, please specify only one of them")
 			}
 
+			if err := config.ValidateRuntime(runtimeName); err != nil {
+				return err
+			}
+
+			if platform != "" {
+				if err := launch.ValidatePlatform(platform); err != nil {
+					return err
+				}
+			}
+
+			if err := launch.ValidatePullPolicy(pullPolicy); err != nil {
+				return err
+			}
+
+			if containerName != "" {
+				if err := launch.ValidateContainerName(containerName); err != nil {
+					return err
+				}
+			}
+
+			if workdir != "" {
+				if err := launch.ValidateWorkdir(workdir); err != nil {
+					return err
+				}
+			}
+
+			if dumpEnvUnmasked && dumpEnvFile == "" {
+				return errors.New("can't pass the option `dump-env-unmasked` without `dump-env`")
+			}
+
+			if shell != "" {
+				if err := launch.ValidateShell(shell); err != nil {
+					return err
+				}
+			}
+
+			if buildUser != "" {
+				if err := launch.ValidateUser(buildUser); err != nil {
+					return err
+				}
+			}
+
+			if err := config.ValidateDockerHost(dockerHost); err != nil {
+				return err
+			}
+
+			if offline && pullPolicy != launch.PullNever && cmd.Flags().Changed("pull") {
+				return fmt.Errorf("can't pull the launcher image by `pull %s` offline", pullPolicy)
+			}
+
+			if pipelineID < 0 {
+				return fmt.Errorf("pipeline-id must be a positive integer: %d", pipelineID)
+			}
+
+			if offline && pipelineID != 0 {
+				return errors.New("can't get the jobs of the pipeline by `pipeline-id` offline")
+			}
+
+			if printExpanded && offline {
+				return errors.New("can't expand the templates by `print-expanded` offline")
+			}
+
+			if printExpanded && pipelineID != 0 {
+				return errors.New("can't pass the both options `print-expanded` and `pipeline-id`, the jobs of the pipeline are expanded by the API")
+			}
+
+			// --strict fails on the checks which only warn without it
+			checkLauncherVersion = checkLauncherVersion || strict
+			if offline && checkLauncherVersion {
+				return errors.New("can't check the launcher version by `check-launcher-version` offline")
+			}
+
+			if offline && artifactsS3 != "" {
+				return errors.New("can't upload the artifacts by `artifacts-s3` offline")
+			}
+
+			if metaOutPath != "" && len(args) > 1 {
+				return errors.New("can't write the meta of multiple jobs, please specify only one job with `meta-out`")
+			}
+
+			if metaOutPath != "" && resume {
+				return errors.New("can't pass the both options `resume` and `meta-out`, the meta of the previous build is not kept")
+			}
+
+			if dryRun && resume {
+				return errors.New("can't pass the both options `dry-run` and `resume`")
+			}
+
+			if resume && len(stepNames) != 0 {
+				return errors.New("can't pass the both options `resume` and `step`, the steps to run are decided by the previous build")
+			}
+
+			if resume && interactiveMode {
+				return errors.New("can't resume the build in interactive mode")
+			}
+
+			if watch && interactiveMode {
+				return errors.New("can't watch the source code in interactive mode")
+			}
+
+			if watch && dryRun {
+				return errors.New("can't pass the both options `watch` and `dry-run`")
+			}
+
+			if err := build.ValidateArtifactsDir(artifactsDir); err != nil {
+				return err
+			}
+
+			if artifactsKeep < 0 {
+				return fmt.Errorf("artifacts-keep must be a positive integer: %d", artifactsKeep)
+			}
+
+			if artifactsKeep > 0 && !strings.Contains(artifactsDir, build.ArtifactsTimeToken) {
+				return fmt.Errorf("can't keep the runs by `artifacts-keep` without %s in `artifacts-dir`, which makes the directory of each run", build.ArtifactsTimeToken)
+			}
+
+			if artifactsKeep > 0 && noLocalArtifacts {
+				return errors.New("can't pass the both options `artifacts-keep` and `no-local-artifacts`, the artifacts are not kept locally")
+			}
+
+			if resume && strings.Contains(artifactsDir, build.ArtifactsTimeToken) {
+				return fmt.Errorf("can't resume the build with %s in `artifacts-dir`, the state of the previous build is in another directory", build.ArtifactsTimeToken)
+			}
+
+			if resume && noLocalArtifacts {
+				return errors.New("can't pass the both options `resume` and `no-local-artifacts`, the state of the build is kept with the artifacts")
+			}
+
+			if noLocalArtifacts && artifactsS3 == "" {
+				return errors.New("can't pass the option `no-local-artifacts` without `artifacts-s3`, the artifacts would be lost")
+			}
+
+			if err := buildlog.ValidateFormat(logFormat); err != nil {
+				return err
+			}
+
+			if interactiveMode && logFormat != buildlog.FormatPlain {
+				return fmt.Errorf("can't print the build logs in %s in interactive mode", logFormat)
+			}
+
+			if output != "" && output != outputJSON {
+				return fmt.Errorf("invalid output format %s: only %s is supported", output, outputJSON)
+			}
+
+			if timeout < 0 {
+				return fmt.Errorf("timeout must not be negative: %s", timeout)
+			}
+
+			if retries < 0 {
+				return fmt.Errorf("retries must not be negative: %d", retries)
+			}
+
+			if retryBackoff < 0 {
+				return fmt.Errorf("retry-backoff must not be negative: %s", retryBackoff)
+			}
+
+			if report != "" {
+				if _, err := parseReport(report); err != nil {
+					return err
+				}
+			}
+
 			return nil
 		},
-		RunE: func(cmd *cobra.Command, args []string) error {
-			var err error
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
 			cmd.SilenceUsage = true
 
-			if envFilePath != "" {
-				err = mergeEnvFromFile(&optionEnv, envFilePath)
+			pipelineFile, args, err = parseTargets(args, pipelineFile)
+			if err != nil {
+				return err
+			}
+
+			if pipelineID != 0 && pipelineFile != "" {
+				return errors.New("can't pass the both options `pipeline-id` and `file`, the jobs are got from the pipeline")
+			}
+
+			if useSudo && !dryRun {
+				if err := sudoValidate(); err != nil {
+					return fmt.Errorf("failed to authenticate with sudo: %v", err)
+				}
+			}
+
+			// the logs of the failed steps are shown in quiet mode of plain, and nothing is shown in the other formats and interactive mode
+			var stdout io.Writer = os.Stdout
+			if quiet && (logFormat != buildlog.FormatPlain || interactiveMode) {
+				stdout = ioutil.Discard
+			}
+			color := !noColor && isTerminal(int(os.Stdout.Fd()))
+
+			secrets := launch.EnvVar{}
+			if secretsFilePath != "" {
+				secrets, err = readSecretsFile(secretsFilePath)
 				if err != nil {
 					return err
 				}
 			}
+			secretValues := make([]string, 0, len(secrets))
+			for _, v := range secrets {
+				secretValues = append(secretValues, v)
+			}
+			masker := buildlog.NewMasker(secretValues)
+			if len(secretValues) != 0 {
+				// the secrets are masked in the build logs, the messages of sd-local, the report and the error
+				stdout = buildlog.NewMaskWriter(stdout, secretValues)
 
-			metaJSON := []byte("{}")
-			if optionMeta != "" {
-				metaJSON = []byte(optionMeta)
-			} else if metaFilePath != "" {
-				absMetaFilePath, err := filepath.Abs(metaFilePath)
+				logger := logrus.StandardLogger()
+				out := logger.Out
+				logger.SetOutput(buildlog.NewMaskWriter(out, secretValues))
+				defer logger.SetOutput(out)
+
+				defer func() {
+					if err != nil {
+						err = errors.New(masker.Replace(err.Error()))
+					}
+				}()
+			}
 
+			if logFilePath != "" {
+				logFile, err := buildlog.OpenFile(logFilePath, logAppend)
 				if err != nil {
-					return err
+					return fmt.Errorf("failed to open log file %s: %v", logFilePath, err)
 				}
+				defer logFile.Close()
+
+				// the messages of sd-local are written into the log file as well as the build logs
+				fileWriter := buildlog.NewMaskWriter(buildlog.NewANSIStripWriter(logFile), secretValues)
+				stdout = io.MultiWriter(stdout, fileWriter)
 
-				metaJSON, err = ioutil.ReadFile(absMetaFilePath)
+				logger := logrus.StandardLogger()
+				hooks := logrus.LevelHooks{}
+				for level, h := range logger.Hooks {
+					hooks[level] = append(hooks[level], h...)
+				}
+				logger.AddHook(buildlog.NewHook(fileWriter))
+				defer logger.ReplaceHooks(hooks)
+			}
 
+			var events io.Writer
+			if jsonEventsPath != "" {
+				eventsFile, err := buildlog.OpenFile(jsonEventsPath, false)
 				if err != nil {
-					return fmt.Errorf("failed to read meta-file %s: %v", metaFilePath, err)
+					return fmt.Errorf("failed to open json events file %s: %v", jsonEventsPath, err)
+				}
+				defer eventsFile.Close()
+				events = buildlog.NewMaskWriter(eventsFile, secretValues)
+			}
+
+			if envFilePath != "" {
+				err = mergeEnvFromFile(&optionEnv, envFilePath)
+				if err != nil {
+					return err
 				}
 			}
 
 			var meta launch.Meta
+			if len(optionMeta) != 0 {
+				meta, err = parseMeta(optionMeta)
+				if err != nil {
+					return err
+				}
+			} else {
+				metaJSON := []byte("{}")
+				if metaFilePath != "" {
+					absMetaFilePath, err := filepath.Abs(metaFilePath)
 
-			err = json.Unmarshal(metaJSON, &meta)
+					if err != nil {
+						return err
+					}
 
-			if err != nil {
-				return fmt.Errorf("failed to parse meta %s, meta must be formated with JSON: %v", string(metaJSON), err)
+					metaJSON, err = ioutil.ReadFile(absMetaFilePath)
+
+					if err != nil {
+						return fmt.Errorf("failed to read meta-file %s: %v", metaFilePath, err)
+					}
+				}
+
+				err = json.Unmarshal(metaJSON, &meta)
+
+				if err != nil {
+					return fmt.Errorf("failed to parse meta %s, meta must be formated with JSON: %v", string(metaJSON), err)
+				}
 			}
 
 			cwd, err := os.Getwd()
@@ -140,6 +576,32 @@ func newBuildCmd() *cobra.Command {
 			sdlocalDir := filepath.Join(configBaseDir, ".sdlocal")
 			srcPath := cwd
 
+			// the local path in --src-url is used as the source directory without cloning
+			if srcURL != "" && isLocalDir(srcURL) {
+				srcDir, srcURL = srcURL, ""
+			}
+
+			if offline && srcURL != "" {
+				return errors.New("can't pull the source code by `src-url` offline, please specify the local directory")
+			}
+
+			if watch && srcURL != "" {
+				return errors.New("can't watch the source code pulled by `src-url`, please specify the local directory")
+			}
+
+			// the working tree of --src-dir may have the build outputs ignored by git as well
+			ignoreFiles := []string{sdIgnoreFile}
+			if srcDir != "" {
+				srcPath, err = filepath.Abs(srcDir)
+				if err != nil {
+					return err
+				}
+				if !isLocalDir(srcPath) {
+					return fmt.Errorf("source directory %s is not a directory", srcDir)
+				}
+				ignoreFiles = []string{".gitignore", sdIgnoreFile}
+			}
+
 			if srcURL != "" {
 				logrus.Infof("Pulling the source code from %s...", srcURL)
 
@@ -149,7 +611,7 @@ func newBuildCmd() *cobra.Command {
 				}
 				s, ok := scm.(Cleaner)
 				if ok {
-					cleaners = append(cleaners, s)
+					addCleaner(s)
 				}
 
 				err = scm.Pull()
@@ -158,17 +620,138 @@ func newBuildCmd() *cobra.Command {
 				}
 				srcPath = scm.LocalPath()
 			}
+			if err := launch.ValidateHostPath(srcPath); err != nil {
+				return err
+			}
 
-			configPath := filepath.Join(sdlocalDir, "config")
+			// --file is resolved from the working directory, not from the source directory
+			sdYAMLPath := filepath.Join(srcPath, "screwdriver.yaml")
+			if pipelineFile != "" {
+				sdYAMLPath, err = filepath.Abs(pipelineFile)
+				if err != nil {
+					return err
+				}
+				if _, err := os.Stat(sdYAMLPath); err != nil {
+					if os.IsNotExist(err) {
+						return fmt.Errorf("pipeline file %s does not exist", pipelineFile)
+					}
+					return err
+				}
+			}
+
+			var ignoredPaths []string
+			if !noIgnore {
+				ignoredPaths, err = findIgnoredPaths(srcPath, ignoreFiles...)
+				if err != nil {
+					return err
+				}
+			}
+			if printIgnored {
+				for _, p := range ignoredPaths {
+					logrus.Infof("Ignored %s", p)
+				}
+			}
+
+			// the changes of the ignored paths, which are not copied into the build, don't trigger the rerun
+			var watchMatcher *ignore.Matcher
+			if watch && !noIgnore {
+				watchMatcher, err = ignore.Load(srcPath, ignoreFiles...)
+				if err != nil {
+					return err
+				}
+			}
+
+			configPath, err := configFilePath()
+			if err != nil {
+				return err
+			}
 			config, err := configNew(configPath)
 			if err != nil {
 				return err
 			}
+			defer config.Close()
+
+			// --config-entry selects the config only for this build without changing the current one
+			entryName, entry, err := config.RunEntry(configEntry)
+			if err != nil {
+				return err
+			}
 
-			entry, err := config.Entry(config.Current)
+			err = entry.Validate()
 			if err != nil {
+				return fmt.Errorf("config `%s` is not ready to build: %v\nplease set them with `sd-local config set`", entryName, err)
+			}
+			// --launcher-profile selects the launcher of the build from the launchers of the config
+			launcher, err := entry.LauncherProfile(launcherProfile)
+			if err != nil {
+				return fmt.Errorf("config `%s` has no launcher to build with: %v", entryName, err)
+			}
+			logrus.Debugf("Using config `%s` with API %s, store %s and launcher %s:%s",
+				entryName, entry.APIURL, entry.StoreURL, launcher.Image, launcher.Version)
+
+			if runtimeName == "" {
+				runtimeName = entry.Runtime
+			}
+
+			if metaOutPath != "" && !supportsMetaOut(runtimeName) {
+				return fmt.Errorf("runtime %s does not support `meta-out`", runtimeName)
+			}
+
+			if offline && !supportsOffline(runtimeName) {
+				return fmt.Errorf("runtime %s does not support `offline`", runtimeName)
+			}
+
+			if launcherArchive != "" && !supportsLauncherArchive(runtimeName) {
+				return fmt.Errorf("runtime %s does not support `launcher-archive`", runtimeName)
+			}
+
+			if err := launch.CheckHost(runtimeName); err != nil {
 				return err
 			}
+			if interactiveMode {
+				if err := launch.CheckInteractive(); err != nil {
+					return err
+				}
+			}
+
+			if buildUser != "" && !supportsUser(runtimeName) {
+				return fmt.Errorf("runtime %s does not support `user`", runtimeName)
+			}
+			if !cmd.Flags().Changed("user") {
+				buildUser = launch.DefaultUser(runtimeName)
+			}
+
+			mounts := make([]launch.Mount, 0, len(mountSpecs))
+			for _, spec := range mountSpecs {
+				m, err := launch.ParseMount(spec)
+				if err != nil {
+					return err
+				}
+				mounts = append(mounts, m)
+			}
+			if len(mounts) != 0 && !supportsMount(runtimeName) {
+				return fmt.Errorf("runtime %s does not support `mount`", runtimeName)
+			}
+
+			if cacheDir != "" {
+				if !supportsMount(runtimeName) {
+					return fmt.Errorf("runtime %s does not support `cache-dir`", runtimeName)
+				}
+				cacheDir, err = prepareCacheDir(cacheDir)
+				if err != nil {
+					return err
+				}
+				if err := launch.ValidateHostPath(cacheDir); err != nil {
+					return err
+				}
+			}
+
+			if timeout == 0 {
+				timeout, err = entry.BuildTimeout()
+				if err != nil {
+					return fmt.Errorf("config `%s` has %v", entryName, err)
+				}
+			}
 
 			uuidStr := entry.UUID
 			if uuidStr == "" {
@@ -185,10 +768,8 @@ func newBuildCmd() *cobra.Command {
 				if input == "y" || input == "Y" || input == "yes" || input == "Yes" {
 					uuidStr = uuid.NewString()
 				}
-				err = entry.Set("uuid", uuidStr)
-				if err != nil {
-					return err
-				}
+				// set it directly because the survey answer is recorded even in locked configs
+				entry.UUID = uuidStr
 				err = config.Save()
 				if err != nil {
 					return err
@@ -200,74 +781,382 @@ func newBuildCmd() *cobra.Command {
 					fmt.Println("UUID key is not set.")
 				}
 			}
+			// the config file is not locked during the build for the other processes of sd-local
+			config.Close()
 
 			ua := generateUserAgent(uuidStr)
-			api := apiNew(entry.APIURL, entry.Token, ua)
-
-			err = api.InitJWT()
+			// the launcher profile is set before resolving the entry to expand its archive
+			buildEntry := entry.Clone()
+			buildEntry.Launcher = launcher
+			if launcherArchive != "" {
+				buildEntry.Launcher.Archive = launcherArchive
+			}
+			// --docker-host is expanded in the same way as the config
+			if dockerHost != "" {
+				buildEntry.DockerHost = dockerHost
+			}
+			resolved, err := buildEntry.Resolve()
 			if err != nil {
 				return err
 			}
-
-			jobName := args[0]
-
-			sdYAMLPath := filepath.Join(srcPath, "screwdriver.yaml")
-			job, err := api.Job(jobName, sdYAMLPath)
+			// the runtime is checked before the build not to fail with the low-level error of the socket in the middle of it
+			if !dryRun {
+				if err := checkBuildRuntime(runtimeName, useSudo, resolved.DockerHost); err != nil {
+					return withExitCode(err, ExitRuntime)
+				}
+			}
+			retryPolicy := retry.Policy{Retries: retries, Backoff: retryBackoff}
+			httpClient, err := screwdriver.NewHTTPClient(screwdriver.HTTPClientOption{
+				HTTPProxy:  resolved.HTTPProxy,
+				HTTPSProxy: resolved.HTTPSProxy,
+				CABundle:   resolved.CABundle,
+				Retry:      retryPolicy,
+			})
 			if err != nil {
 				return err
 			}
+			api := apiNew(entry.APIURL, entry.Token, ua, httpClient)
 
-			artifactsPath, err := filepath.Abs(artifactsDir)
-			if err != nil {
+			// the build runs without JWT offline, so the store is not available in it
+			if !offline {
+				err = api.InitJWT()
+				if err != nil {
+					return withExitCode(err, ExitAPI)
+				}
+			}
+
+			// the jobs of the pipeline are parsed by the API, so the local screwdriver.yaml is not used
+			if !noExpand && pipelineID == 0 {
+				expandedPath, err := expandYAMLFile(sdYAMLPath, optionEnv)
+				if err != nil {
+					return err
+				}
+				if expandedPath != sdYAMLPath {
+					defer os.Remove(expandedPath)
+					sdYAMLPath = expandedPath
+				}
+			}
+			// the templates are merged by the API, so the jobs are cached by the screwdriver.yaml before merging them
+			jobsYAMLPath := sdYAMLPath
+			if !offline && pipelineID == 0 {
+				expandedPath, err := expandTemplateFile(api, sdYAMLPath)
+				if err != nil {
+					return withExitCode(err, ExitAPI)
+				}
+				if expandedPath != sdYAMLPath {
+					defer os.Remove(expandedPath)
+					jobsYAMLPath = expandedPath
+				}
+			}
+			if printExpanded {
+				content, err := ioutil.ReadFile(jobsYAMLPath)
+				if err != nil {
+					return err
+				}
+				_, err = cmd.OutOrStdout().Write(content)
 				return err
 			}
 
-			err = osMkdirAll(artifactsPath, 0777)
+			// the typo of the launcher version is found before pulling the image, which fails late with a confusing error
+			if checkLauncherVersion {
+				tags, err := launcherTags(httpClient, launcher.Image)
+				if err != nil {
+					logrus.Warnf("Can't check the launcher version: %v", err)
+				} else if err := launch.CheckLauncherVersion(launcher, tags); err != nil {
+					if strict {
+						return err
+					}
+					logrus.Warn(err)
+				}
+			}
+
+			// the environment of the steps is set to the jobs parsed by the API, whose validator doesn't accept it
+			stepEnvs := screwdriver.StepEnvironments{}
+			if !offline && pipelineID == 0 {
+				extractedPath, envs, err := extractStepEnvFile(jobsYAMLPath)
+				if err != nil {
+					return err
+				}
+				if extractedPath != jobsYAMLPath {
+					defer os.Remove(extractedPath)
+					jobsYAMLPath = extractedPath
+				}
+				stepEnvs = envs
+			}
+
+			var jobs map[string]screwdriver.Job
+			if pipelineID != 0 {
+				jobs, err = pipelineJobs(api, pipelineID)
+				if err != nil {
+					return withExitCode(err, ExitAPI)
+				}
+			} else if offline {
+				jobs, err = cachedJobs(jobsCacheDir(sdlocalDir), sdYAMLPath)
+				if err != nil {
+					return err
+				}
+			} else {
+				jobs, err = api.Jobs(jobsYAMLPath)
+				if err != nil {
+					return withExitCode(err, ExitAPI)
+				}
+				stepEnvs.Apply(jobs)
+				if err := cacheJobs(jobsCacheDir(sdlocalDir), sdYAMLPath, jobs); err != nil {
+					logrus.Debugf("Failed to cache the jobs of screwdriver.yaml: %v", err)
+				}
+			}
+
+			// the jobs with the matrix run as all the combinations of it unless they are narrowed down by --matrix
+			args, err = matrixJobNames(jobs, args, matrixFilter)
 			if err != nil {
 				return err
 			}
 
-			loggerDone = make(chan struct{})
-			logger, err := buildLogNew(filepath.Join(artifactsPath, launch.LogFile), os.Stdout, loggerDone)
+			// the jobs whose sourcePaths are not touched are skipped like the builds triggered by the commits in Screwdriver.cd
+			if changedSince != "" {
+				files, err := changedFiles(srcPath, changedSince)
+				if err != nil {
+					return err
+				}
+				args = changedJobNames(jobs, args, files, changedSince)
+				if len(args) == 0 {
+					logrus.Infof("No job to run, all the jobs are skipped by the changes since %s", changedSince)
+					return nil
+				}
+			}
+
+			if interactiveMode && len(args) > 1 {
+				return errors.New("can't run multiple combinations of the matrix in interactive mode, please select one with `matrix`")
+			}
+			if metaOutPath != "" && len(args) > 1 {
+				return errors.New("can't write the meta of multiple combinations of the matrix, please select one with `matrix`")
+			}
+
+			if pipelineFile != "" {
+				if err := findJobs(jobs, args, pipelineFile); err != nil {
+					return err
+				}
+			}
+
+			// the tokens of the artifacts directory are expanded for every build, which is run repeatedly in watch mode
+			artifactsTemplate, err := filepath.Abs(artifactsDir)
 			if err != nil {
 				return err
 			}
-			go logger.Run()
-
-			option := launch.Option{
-				Job:             job,
-				Entry:           *entry,
-				JobName:         jobName,
-				JWT:             api.JWT(),
-				ArtifactsPath:   artifactsPath,
-				Memory:          memory,
-				SrcPath:         srcPath,
-				OptionEnv:       optionEnv,
-				Meta:            meta,
-				UseSudo:         useSudo,
-				UsePrivileged:   usePrivileged,
-				InteractiveMode: interactiveMode,
-				SocketPath:      socketPath,
-				FlagVerbose:     flagVerbose,
-				LocalVolumes:    localVolumes,
-			}
-
-			launch := launchNew(option)
-			l, ok := launch.(Cleaner)
-			if ok {
-				cleaners = append(cleaners, l)
-			}
-
-			logrus.Info("Prepare to start build...")
-			err = launch.Run()
+			if err := launch.ValidateHostPath(artifactsTemplate); err != nil {
+				return err
+			}
+			// the commands of sd-cmd are fetched on the host to use the cache, and sd-cmd looks them up in the mounted directory
+			var commandsDir string
+			jobsToRun := make([]screwdriver.Job, 0, len(args))
+			for _, jobName := range args {
+				jobsToRun = append(jobsToRun, jobs[jobName])
+			}
+			if refs := sdcmd.Find(jobsToRun...); len(refs) != 0 {
+				if !supportsMount(runtimeName) {
+					logrus.Debugf("The commands of sd-cmd are fetched in the build container with runtime %s", runtimeName)
+				} else {
+					commandsDir = filepath.Join(sdlocalDir, "cache", "commands")
+					if err := osMkdirAll(commandsDir, 0755); err != nil {
+						return err
+					}
+					if !dryRun {
+						fetcher := sdcmd.Fetcher{
+							StoreURL:   entry.StoreURL,
+							JWT:        api.JWT(),
+							HTTPClient: httpClient,
+							CacheDir:   commandsDir,
+							Offline:    offline,
+						}
+						if err := fetchCommands(api, fetcher, refs); err != nil {
+							return withExitCode(err, ExitAPI)
+						}
+					}
+				}
+			}
+
+			// the environment is dumped when the launcher is created so that it is written even if the build fails
+			newLauncher := launchNew
+			if dumpEnvFile != "" {
+				newLauncher = func(option launch.Option) launch.Launcher {
+					l := launchNew(option)
+					if err := dumpEnv(l, dumpEnvFile, option.JobName, masker.Replace, dumpEnvUnmasked); err != nil {
+						logrus.Warn(err)
+					}
+					return l
+				}
+			}
+
+			var uploader artifacts.Uploader
+			if artifactsS3 != "" {
+				uploader, err = uploaderNew(artifactsS3, artifactsS3Endpoint)
+				if err != nil {
+					return err
+				}
+			}
+
+			// the results of the steps are in the logs of the other formats, which are not mixed with the summary
+			var summary io.Writer
+			if !interactiveMode && (logFormat == buildlog.FormatPlain || output != "") {
+				summary = cmd.OutOrStdout()
+			}
+			reportPath := ""
+			if report != "" {
+				reportPath, _ = parseReport(report)
+			}
+
+			// the runner is created for every build in watch mode to expand the tokens of the artifacts directory for it
+			newRunner := func(prepare func(*launch.Option) error, finish func(launch.Option, launch.Launcher, build.JobResult)) (*build.Runner, error) {
+				return build.New(resolved, build.Options{
+					JobNames:         args,
+					Jobs:             jobs,
+					API:              api,
+					ArtifactsPath:    artifactsTemplate,
+					ArtifactsKeep:    artifactsKeep,
+					NoLocalArtifacts: noLocalArtifacts,
+					Uploader:         uploader,
+					MaxParallel:      maxParallel,
+					Timeout:          timeout,
+					Resume:           resume,
+					State:            true,
+					Hooks:            hooks,
+					Output:           stdout,
+					Color:            color,
+					LogFormat:        logFormat,
+					Quiet:            quiet && !interactiveMode,
+					Summary:          summary,
+					SummaryFormat:    output,
+					SortSummary:      sortTime,
+					Report:           reportPath,
+					Mask:             masker.Replace,
+					Events:           events,
+					Launch: launch.Option{
+						Memory:          memory,
+						SrcPath:         srcPath,
+						OptionEnv:       optionEnv,
+						Meta:            meta,
+						UseSudo:         useSudo,
+						UsePrivileged:   usePrivileged,
+						InteractiveMode: interactiveMode,
+						SocketPath:      socketPath,
+						FlagVerbose:     flagVerbose,
+						LocalVolumes:    localVolumes,
+						Runtime:         runtimeName,
+						NoTeardown:      noTeardown,
+						NoFailFast:      !failFast,
+						Platform:        platform,
+						Secrets:         secrets,
+						IgnoredPaths:    ignoredPaths,
+						Retry:           retryPolicy,
+						PullPolicy:      pullPolicy,
+						Offline:         offline,
+						EntryName:       entryName,
+						Mounts:          mounts,
+						CacheDir:        cacheDir,
+						CommandsDir:     commandsDir,
+						Workdir:         workdir,
+						User:            buildUser,
+						Shell:           shell,
+					},
+					Prepare:        prepare,
+					Finish:         finish,
+					NewLauncher:    newLauncher,
+					NewLogger:      buildLogNew,
+					MkdirAll:       osMkdirAll,
+					RunHook:        runHook,
+					SourceRevision: sourceRevision,
+				})
+			}
+			runner, err := newRunner(nil, nil)
 			if err != nil {
 				return err
 			}
+			names := runner.Names()
 
-			logger.Stop()
-			<-loggerDone
+			// the image is overridden only for the jobs to run because the others are not used
+			if image != "" {
+				for _, jobName := range args {
+					job := jobs[jobName]
+					logrus.Infof("The image of %s is overridden with %s instead of %s", jobName, image, job.Image)
+					job.Image = image
+					jobs[jobName] = job
+				}
+			}
 
-			return nil
+			// the steps are selected for all the combinations of the matrix of the job
+			if len(stepNames) != 0 {
+				for _, jobName := range args {
+					jobs[jobName], err = jobs[jobName].SelectSteps(stepNames)
+					if err != nil {
+						return err
+					}
+				}
+			}
+
+			if dumpEnvFile != "" && len(names) > 1 && !strings.Contains(dumpEnvFile, build.ArtifactsJobToken) {
+				return fmt.Errorf("dump-env must have %s to dump the environment of multiple jobs", build.ArtifactsJobToken)
+			}
+			if dryRun {
+				for i, jobName := range names {
+					option := runner.LaunchOption(jobName)
+					option.ContainerName = buildContainerName(containerName, jobName, len(names) > 1)
+					l, ok := newLauncher(option).(planner)
+					if !ok {
+						return fmt.Errorf("runtime %s does not support dry run", runtimeName)
+					}
+					if i > 0 {
+						fmt.Fprintln(cmd.OutOrStdout())
+					}
+					printPlan(cmd.OutOrStdout(), jobName, runtimeName, l.Plan(), masker.Replace)
+				}
+				return nil
+			}
+
+			runBuild := func() error {
+				prepare := func(option *launch.Option) (err error) {
+					option.ContainerName = buildContainerName(containerName, option.JobName, len(names) > 1)
+
+					// the meta is written by the launcher into the directory mounted from the host
+					if metaOutPath != "" {
+						option.MetaPath, err = newMetaDir()
+						if err != nil {
+							return fmt.Errorf("failed to create the meta directory: %v", err)
+						}
+					}
+					return nil
+				}
+
+				finish := func(option launch.Option, l launch.Launcher, result build.JobResult) {
+					if option.MetaPath == "" {
+						return
+					}
+					defer os.RemoveAll(option.MetaPath)
+					if l != nil {
+						if metaErr := writeMetaOut(option.MetaPath, metaOutPath); metaErr != nil {
+							logrus.Warn(metaErr)
+						}
+					}
+				}
+
+				runner, err := newRunner(prepare, finish)
+				if err != nil {
+					return err
+				}
+				addCleaner(runner)
+
+				result, err := runner.Run(context.Background())
+				// the runner cleans up itself when it finishes
+				removeCleaner(runner)
+				for _, u := range result.URLs {
+					logrus.Infof("Uploaded %s", u)
+				}
+				return buildError(result, err)
+			}
+
+			if !watch {
+				return runBuild()
+			}
+			return watchSource(cmd.OutOrStdout(), srcPath, build.ArtifactsRoot(artifactsTemplate), watchMatcher, runBuild, nil)
 		},
 	}
 
@@ -275,42 +1164,125 @@ func newBuildCmd() *cobra.Command {
 		&artifactsDir,
 		"artifacts-dir",
 		launch.ArtifactsDir,
-		"Path to the host side directory which is mounted into $SD_ARTIFACTS_DIR.")
+		`Path to the host side directory which is mounted into $SD_ARTIFACTS_DIR. {job} is replaced with the job name, which joins the names with - for multiple jobs,
+and {time} is replaced with the time the build started like 20060102150405 to keep the artifacts of every run like sd-artifacts/{job}/{time}.`)
+
+	buildCmd.Flags().IntVar(
+		&artifactsKeep,
+		"artifacts-keep",
+		0,
+		"Number of the runs made by {time} of --artifacts-dir to keep. The oldest runs beyond it are removed after a successful build. All the runs are kept if it is 0.")
+
+	buildCmd.Flags().StringVar(
+		&artifactsS3,
+		"artifacts-s3",
+		"",
+		"Destination like s3://bucket/prefix to upload the artifacts to after the build. They are uploaded by the AWS CLI with its credentials.")
+
+	buildCmd.Flags().StringVar(
+		&artifactsS3Endpoint,
+		"artifacts-s3-endpoint",
+		"",
+		"Endpoint URL of the S3 compatible storage like MinIO to upload the artifacts to.")
+
+	buildCmd.Flags().BoolVar(
+		&noLocalArtifacts,
+		"no-local-artifacts",
+		false,
+		"Do not keep the artifacts in --artifacts-dir when they are uploaded by --artifacts-s3.")
+
+	buildCmd.Flags().StringVar(
+		&report,
+		"report",
+		"",
+		"Write the result of the build like the status of the steps and the artifacts into the file in the format, like json=<path>. It is written even if the build fails.")
 
 	buildCmd.Flags().StringVarP(
 		&memory,
 		"memory",
 		"m",
 		"",
-		"Memory limit for build container, which take a positive integer, followed by a suffix of b, k, m, g.")
+		"Memory limit for build container, which take a positive integer, followed by a suffix of b, k, m, g. It caps the memory of the annotations.")
 
 	buildCmd.Flags().StringVar(
 		&srcURL,
 		"src-url",
 		"",
-		`Specify the source url to build.
+		`Specify the source url to build. The local directory is used like --src-dir.
 ex) git@github.com:<org>/<repo>.git[#<branch>]
     https://github.com/<org>/<repo>.git[#<branch>]`)
 
-	buildCmd.Flags().StringToStringVarP(
-		&optionEnv,
+	buildCmd.Flags().StringVar(
+		&srcDir,
+		"src-dir",
+		"",
+		`Path to the local source directory to build, which is mounted into the build container without cloning.
+The paths matched by .gitignore and .sdignore in it are not mounted.`)
+
+	buildCmd.Flags().StringVarP(
+		&pipelineFile,
+		"file",
+		"f",
+		"",
+		"Path to the screwdriver.yaml to run the jobs in like ci/screwdriver.yaml, which is relative to the working directory. screwdriver.yaml in the source directory is used if it is not specified.")
+
+	buildCmd.Flags().IntVar(
+		&pipelineID,
+		"pipeline-id",
+		0,
+		"ID of the pipeline in Screwdriver.cd to run the jobs of as the API has them instead of screwdriver.yaml. The source code is still taken from the working directory, --src-dir or --src-url.")
+
+	buildCmd.Flags().BoolVar(
+		&noIgnore,
+		"no-ignore",
+		false,
+		"Mount all the files of the source code including the paths matched by .sdignore, and .gitignore of --src-dir.")
+
+	buildCmd.Flags().BoolVar(
+		&printIgnored,
+		"print-ignored",
+		false,
+		"Print the paths of the source code which are not mounted because they are matched by the ignore files.")
+
+	buildCmd.Flags().VarP(
+		newEnvValue(&optionEnv),
 		"env",
 		"e",
+		`Set key and value relationship which is set as environment variables of Build Container. (<key>=<value>)
+The value of <key>=@<path> is read from the file like a PEM or JSON, and <key> alone passes the variable of the host through if it is set.`,
+	)
+
+	buildCmd.Flags().StringToStringVar(
+		&matrixFilter,
+		"matrix",
 		map[string]string{},
-		"Set key and value relationship which is set as environment variables of Build Container. (<key>=<value>)",
+		"Run only the combinations of the matrix of the jobs whose environment variables match like NODE_VERSION=12. All the combinations run by default.",
 	)
 
+	buildCmd.Flags().BoolVar(
+		&noExpand,
+		"no-expand",
+		false,
+		`Use the variables like ${VAR} and $VAR in screwdriver.yaml as they are.
+They are expanded with the environment variables of --env and sd-local except in the steps and the environment by default, and ${VAR:-default} can be used for the undefined ones.`)
+
 	buildCmd.Flags().StringVar(
 		&envFilePath,
 		"env-file",
 		"",
-		"Path to config file of environment variables. '.env' format file can be used.")
+		"Path to the file of environment variables in '.env' format, which can have comments, quoted values and export prefixes. --env takes precedence over it.")
 
 	buildCmd.Flags().StringVar(
+		&secretsFilePath,
+		"secrets-file",
+		"",
+		"Path to the file of secrets in '.env' format. They are set as environment variables of Build Container and masked in the logs.")
+
+	buildCmd.Flags().StringArrayVar(
 		&optionMeta,
 		"meta",
-		"",
-		"Metadata to pass into the build environment, which is represented with JSON format",
+		nil,
+		"Metadata to pass into the build environment like key=value, which can be specified multiple times. The nested keys are separated by dots like foo.bar=baz, and a JSON object is accepted as well.",
 	)
 
 	buildCmd.Flags().StringVar(
@@ -319,11 +1291,17 @@ ex) git@github.com:<org>/<repo>.git[#<branch>]
 		"",
 		"Path to the meta file. meta file is represented with JSON format.")
 
+	buildCmd.Flags().StringVar(
+		&metaOutPath,
+		"meta-out",
+		"",
+		"Path to the file to write the meta of the build into in JSON format after the build. It is written even if the build fails.")
+
 	buildCmd.Flags().BoolVar(
 		&useSudo,
 		"sudo",
 		false,
-		"Use sudo command for container runtime.")
+		"Use sudo command for container runtime. The password is asked once before the build, and the owner of the artifacts is changed back to the user after the build.")
 
 	buildCmd.Flags().BoolVar(
 		&usePrivileged,
@@ -331,6 +1309,13 @@ ex) git@github.com:<org>/<repo>.git[#<branch>]
 		false,
 		"Use privileged mode for container runtime.")
 
+	buildCmd.Flags().StringVar(
+		&buildUser,
+		"user",
+		"",
+		`User of the build container in the form of uid[:gid], which owns the artifacts and the files written into the source directory.
+The user of sd-local is used with Docker on Linux, and the user of the image otherwise. Specify root for the images which require root.`)
+
 	buildCmd.Flags().BoolVarP(
 		&interactiveMode,
 		"interactive",
@@ -351,5 +1336,268 @@ ex) git@github.com:<org>/<repo>.git[#<branch>]
 		[]string{},
 		"Volumes to mount into build container.")
 
+	buildCmd.Flags().StringArrayVar(
+		&mountSpecs,
+		"mount",
+		[]string{},
+		`Bind mount the host path into the build container like ~/.m2:/root/.m2:ro. It can be specified multiple times.
+The host path must exist, and the paths mounted by sd-local like the source code can't be mounted. It is not supported by k8s.`)
+
+	buildCmd.Flags().StringVar(
+		&cacheDir,
+		"cache-dir",
+		"",
+		`Path to the build cache like `+defaultCacheDir+`, which persists across the builds and the jobs. It is mounted into /sd/cache set to $SD_LOCAL_CACHE_DIR.
+Point the caches of the tools at it like GOMODCACHE=$SD_LOCAL_CACHE_DIR/go/mod. It is cleaned by sd-local cache clean. It is not supported by k8s.`)
+
+	buildCmd.Flags().IntVar(
+		&maxParallel,
+		"max-parallel",
+		1,
+		"Maximum number of jobs to run in parallel.")
+
+	buildCmd.Flags().StringVar(
+		&dockerHost,
+		"docker-host",
+		"",
+		"Address of the daemon of docker or podman like tcp://host:2376 or a path of a unix socket. docker-host of the config, or DOCKER_HOST or CONTAINER_HOST is used if it is not specified.")
+
+	buildCmd.Flags().StringVar(
+		&configEntry,
+		"config-entry",
+		"",
+		"Name of the config to run the build with instead of the current config, which is not changed by it.")
+
+	buildCmd.Flags().StringVar(
+		&launcherArchive,
+		"launcher-archive",
+		"",
+		"Path to the tarball made by docker save to load the launcher image from instead of pulling it, which has to have the launcher image of the config. launcher-archive of the config is used if it is not specified. It is not supported by k8s.")
+
+	buildCmd.Flags().StringVar(
+		&launcherProfile,
+		"launcher-profile",
+		"",
+		"Name of the launcher profile in launchers of the config to run the build with. The launcher of the config is used if it is not specified.")
+
+	buildCmd.Flags().StringVar(
+		&workdir,
+		"workdir",
+		"",
+		`Absolute path of the working directory of the steps in the build container, which is created if it does not exist.
+It overrides the annotation screwdriver.cd/workdir of the job, and the source directory of the launcher is used if neither is specified.`)
+
+	buildCmd.Flags().StringVar(
+		&dumpEnvFile,
+		"dump-env",
+		"",
+		`Path to the file to write the environment variables of the build container into in '.env' format before the build, except the ones of the steps.
+The secrets and SD_TOKEN are masked unless --dump-env-unmasked is passed, and {job} is replaced with the job name, which is required for multiple jobs.`)
+
+	buildCmd.Flags().BoolVar(
+		&dumpEnvUnmasked,
+		"dump-env-unmasked",
+		false,
+		"Write the values of the secrets and SD_TOKEN into the file of --dump-env as they are. Be careful not to share the file.")
+
+	buildCmd.Flags().StringVar(
+		&shell,
+		"shell",
+		"",
+		`Absolute path of the shell in the build image the steps run in like /bin/bash, which fails the build if it is not found in the image.
+It overrides the annotation screwdriver.cd/shell and USER_SHELL_BIN of the job, and /bin/sh is used if none of them is specified.`)
+
+	buildCmd.Flags().StringVar(
+		&containerName,
+		"container-name",
+		"",
+		`Name of the build container, which is suffixed with the job name for multiple jobs. sdlocal-<job>-<timestamp> is used if it is not specified.
+The build container is labeled with sdlocal.job and sdlocal.entry to be listed like docker ps --filter label=sdlocal.job. It is not supported by k8s.`)
+
+	buildCmd.Flags().StringVar(
+		&image,
+		"image",
+		"",
+		"Image to run the jobs with instead of the image in screwdriver.yaml like node:20. It can be used with --platform to try the other images.")
+
+	buildCmd.Flags().StringVar(
+		&platform,
+		"platform",
+		"",
+		"Platform of the images like linux/arm64. The architecture of the host is used if it is not specified.")
+
+	buildCmd.Flags().StringVar(
+		&pullPolicy,
+		"pull",
+		launch.PullMissing,
+		"Policy to pull the launcher image, always, missing or never. The local image is used without contacting the registry unless it is always, and the image pinned to a digest is used only if the local one has the digest. It is ignored by k8s.")
+
+	buildCmd.Flags().BoolVar(
+		&offline,
+		"offline",
+		false,
+		"Run the build without the network. The jobs parsed by the API in the previous builds and the local images are used, and it fails if they are not available. It is not supported by k8s.")
+
+	buildCmd.Flags().BoolVar(
+		&checkLauncherVersion,
+		"check-launcher-version",
+		false,
+		"Warn if the launcher version is not a tag of the launcher image in the registry, which is accessed anonymously. latest and stable are always accepted.")
+
+	buildCmd.Flags().BoolVar(
+		&strict,
+		"strict",
+		false,
+		"Fail the build instead of warning when the launcher version is not a tag of the launcher image. The launcher version is checked as --check-launcher-version.")
+
+	buildCmd.Flags().StringVar(
+		&runtimeName,
+		"runtime",
+		"",
+		"Runtime to run the build, docker, podman or k8s. The runtime of the config or docker is used if it is not specified.")
+
+	buildCmd.Flags().StringVar(
+		&hooks.OnComplete,
+		"on-complete",
+		"",
+		`Command to run in the shell of the host when the build of each job finishes like a notification, with the result in the environment variables
+SD_LOCAL_JOB, SD_LOCAL_STATUS of success or failure, SD_LOCAL_DURATION in seconds and SD_LOCAL_ARTIFACTS_DIR, and SD_LOCAL_ERROR and SD_LOCAL_FAILED_STEP on failure.`)
+
+	buildCmd.Flags().StringVar(
+		&hooks.OnSuccess,
+		"on-success",
+		"",
+		"Command to run like --on-complete only when the build of each job succeeds, which runs before --on-complete.")
+
+	buildCmd.Flags().StringVar(
+		&hooks.OnFailure,
+		"on-failure",
+		"",
+		"Command to run like --on-complete only when the build of each job fails, which runs before --on-complete.")
+
+	buildCmd.Flags().StringVar(
+		&logFilePath,
+		"log-file",
+		"",
+		"Path to the file to write the build logs into as well as the terminal. ANSI escape sequences are removed in the file.")
+
+	buildCmd.Flags().BoolVar(
+		&logAppend,
+		"log-append",
+		false,
+		"Append the build logs to the log file instead of truncating it.")
+
+	buildCmd.Flags().StringVar(
+		&jsonEventsPath,
+		"json-events",
+		"",
+		`Path to the file like /dev/fd/3 to write the events of the builds into as newline-delimited JSON apart from the build logs,
+build-started, step-started, log-line, step-finished and build-finished with the time, the job and the step.`)
+
+	buildCmd.Flags().StringVar(
+		&logFormat,
+		"log-format",
+		buildlog.FormatPlain,
+		`Format of the build logs, plain, json or tap. json prints an object with the job, the step, the time and the stream per line of the logs and per result of the steps,
+and tap prints the steps as the tests of the Test Anything Protocol with the logs as the diagnostics. The summary of the steps is printed only in plain unless --output is passed.`)
+
+	buildCmd.Flags().StringVarP(
+		&output,
+		"output",
+		"o",
+		"",
+		"Output format of the timing summary of the steps printed at the end of the build. Only 'json' is supported.")
+
+	buildCmd.Flags().BoolVar(
+		&sortTime,
+		"sort-time",
+		false,
+		"Sort the steps by duration in the timing summary.")
+
+	buildCmd.Flags().BoolVar(
+		&noColor,
+		"no-color",
+		false,
+		"Disable the colors of the build logs. They are disabled if the output is not a terminal as well.")
+
+	buildCmd.Flags().BoolVarP(
+		&quiet,
+		"quiet",
+		"q",
+		false,
+		`Show only the logs of the failed steps and the summary on the terminal. The last 1000 lines of each step are kept until it finishes.
+The build logs are not shown at all in the formats other than plain, and all the lines are in the build logs of the artifacts.`)
+
+	buildCmd.Flags().BoolVar(
+		&noTeardown,
+		"no-teardown",
+		false,
+		`Skip the teardown steps and keep the build container if the build fails for debugging.
+The kept container and volumes must be removed by yourself.`)
+
+	buildCmd.Flags().BoolVar(
+		&failFast,
+		"fail-fast",
+		true,
+		`Fail the build at the first failed step. With --fail-fast=false, the next steps run after a step failed,
+and the build fails after all the user steps with the failed steps listed. The teardown steps run regardless of it.`)
+
+	buildCmd.Flags().BoolVar(
+		&printExpanded,
+		"print-expanded",
+		false,
+		"Print screwdriver.yaml whose variables are expanded and whose job templates are merged into the jobs without running the build.")
+
+	buildCmd.Flags().BoolVar(
+		&dryRun,
+		"dry-run",
+		false,
+		"Print the plan of the build like the steps, the image, the environment variables and the mounts without running it.")
+
+	buildCmd.Flags().BoolVar(
+		&watch,
+		"watch",
+		false,
+		`Rerun the build whenever the files in the source directory change until it is interrupted.
+The changes of the paths ignored by .sdignore do not trigger it, and the running build is cancelled by a new change.`)
+
+	buildCmd.Flags().BoolVar(
+		&resume,
+		"resume",
+		false,
+		`Resume the failed build from the first step which did not succeed with the artifacts of the previous build.
+The build container kept by --no-teardown is re-used. The build starts fresh if the job or the source code changed.`)
+
+	buildCmd.Flags().StringArrayVar(
+		&stepNames,
+		"step",
+		[]string{},
+		"Run only the specified step of the job. It can be specified multiple times to run the steps in the order of the job.")
+
+	buildCmd.Flags().DurationVar(
+		&timeout,
+		"timeout",
+		0,
+		"Abort the build if it does not finish within the duration like 30m. The timeout of the config is used if it is not specified.")
+
+	buildCmd.Flags().IntVar(
+		&retries,
+		"retries",
+		0,
+		"Number of the retries of the requests to the API and the pulls of the images failed by the transient errors like the network timeouts, 5xx and rate limits.")
+
+	buildCmd.Flags().DurationVar(
+		&retryBackoff,
+		"retry-backoff",
+		retry.DefaultBackoff,
+		"Wait before the first retry of --retries, which doubles on every retry.")
+
+	buildCmd.Flags().StringVar(
+		&changedSince,
+		"changed-since",
+		"",
+		`Run only the jobs whose sourcePaths have the files changed since the git ref like origin/main, including the uncommitted and untracked files.
+The jobs without sourcePaths always run, and the skipped jobs are printed.`)
+
 	return buildCmd
 }
@@ -2,18 +2,101 @@ package cmd
 
 import (
 	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"io/ioutil"
+	"net/http"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
 	"testing"
+	"time"
 
+	"github.com/mitchellh/go-homedir"
+	"github.com/screwdriver-cd/sd-local/artifacts"
+	"github.com/screwdriver-cd/sd-local/build"
+	"github.com/screwdriver-cd/sd-local/buildlog"
 	"github.com/screwdriver-cd/sd-local/config"
 	"github.com/screwdriver-cd/sd-local/launch"
+	"github.com/screwdriver-cd/sd-local/retry"
+	"github.com/screwdriver-cd/sd-local/screwdriver"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 )
 
+// hangLaunch is the launcher whose build hangs until it is killed
+type hangLaunch struct {
+	stop   chan struct{}
+	signal os.Signal
+}
+
+func (h *hangLaunch) Run() error {
+	<-h.stop
+	return nil
+}
+
+func (h *hangLaunch) Kill(sig os.Signal) {
+	h.signal = sig
+	close(h.stop)
+}
+
+func (h *hangLaunch) Clean() {}
+
+// failLaunch is the launcher whose build fails with err
+type failLaunch struct {
+	err error
+}
+
+func (f failLaunch) Run() error { return f.err }
+
+func (f failLaunch) Kill(os.Signal) {}
+
+func (f failLaunch) Clean() {}
+
+// mockUploader records the directory of the uploaded artifacts
+type mockUploader struct {
+	dir  *string
+	urls []string
+	err  error
+}
+
+func (m mockUploader) Upload(dir string) ([]string, error) {
+	*m.dir = dir
+	return m.urls, m.err
+}
+
+// testReport is the report of --report read by the tests
+type testReport struct {
+	SchemaVersion int    `json:"schemaVersion"`
+	Status        string `json:"status"`
+	Jobs          []struct {
+		Name    string           `json:"name"`
+		Status  string           `json:"status"`
+		Error   string           `json:"error"`
+		Seconds float64          `json:"seconds"`
+		Steps   []testStepReport `json:"steps"`
+	} `json:"jobs"`
+}
+
+type testStepReport struct {
+	Name    string  `json:"name"`
+	Status  string  `json:"status"`
+	Seconds float64 `json:"seconds"`
+}
+
+// planLaunch is the launcher which tells the plan of the build
+type planLaunch struct {
+	mockLaunch
+	plan launch.Plan
+}
+
+func (p planLaunch) Plan() launch.Plan { return p.plan }
+
 func TestBuildCmd(t *testing.T) {
 	t.Run("Success build cmd", func(t *testing.T) {
 		root := newBuildCmd()
@@ -57,6 +140,71 @@ func TestBuildCmd(t *testing.T) {
 		assert.Nil(t, err)
 	})
 
+	t.Run("Success build cmd with --artifacts-dir of the tokens and --artifacts-keep", func(t *testing.T) {
+		defFunc := osMkdirAll
+		osMkdirAll = os.MkdirAll
+		defer func() {
+			osMkdirAll = defFunc
+		}()
+
+		dir, err := ioutil.TempDir("", "example")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+
+		old := filepath.Join(dir, "test", "20200101000000")
+		if err := os.MkdirAll(old, 0777); err != nil {
+			t.Fatal(err)
+		}
+
+		root := newBuildCmd()
+		root.SetArgs([]string{"test", "--artifacts-dir", filepath.Join(dir, "{job}", "{time}"), "--artifacts-keep", "1"})
+		root.SetOut(bytes.NewBuffer(nil))
+		err = root.Execute()
+		assert.Nil(t, err)
+
+		runs, err := filepath.Glob(filepath.Join(dir, "test", "*"))
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(runs))
+		assert.Regexp(t, `/test/\d{14}$`, runs[0])
+		assert.NotEqual(t, old, runs[0])
+	})
+
+	t.Run("Failed build cmd with invalid --artifacts-dir and --artifacts-keep", func(t *testing.T) {
+		testCases := map[string]struct {
+			args      []string
+			expectErr string
+		}{
+			"unknown token": {
+				args:      []string{"test", "--artifacts-dir", "sd-artifacts/{date}"},
+				expectErr: "invalid token {date} in artifacts-dir: must be {job} or {time}",
+			},
+			"negative keep": {
+				args:      []string{"test", "--artifacts-dir", "sd-artifacts/{time}", "--artifacts-keep", "-1"},
+				expectErr: "artifacts-keep must be a positive integer: -1",
+			},
+			"keep without time": {
+				args:      []string{"test", "--artifacts-keep", "3"},
+				expectErr: "can't keep the runs by `artifacts-keep` without {time} in `artifacts-dir`, which makes the directory of each run",
+			},
+			"resume with time": {
+				args:      []string{"test", "--artifacts-dir", "sd-artifacts/{time}", "--resume"},
+				expectErr: "can't resume the build with {time} in `artifacts-dir`, the state of the previous build is in another directory",
+			},
+		}
+
+		for name, tt := range testCases {
+			t.Run(name, func(t *testing.T) {
+				root := newBuildCmd()
+				root.SetArgs(tt.args)
+				root.SetOut(bytes.NewBuffer(nil))
+				err := root.Execute()
+				assert.Equal(t, tt.expectErr, err.Error())
+			})
+		}
+	})
+
 	t.Run("Success build cmd with --env", func(t *testing.T) {
 		root := newBuildCmd()
 
@@ -81,6 +229,36 @@ func TestBuildCmd(t *testing.T) {
 		assert.Equal(t, "sd-artifacts", artifactsDir)
 	})
 
+	t.Run("Success build cmd with --env from the file and the host", func(t *testing.T) {
+		defLaunchNew := launchNew
+		defer func() {
+			launchNew = defLaunchNew
+			os.Unsetenv("SD_LOCAL_TEST_ENV")
+		}()
+		os.Setenv("SD_LOCAL_TEST_ENV", "host")
+
+		var env launch.EnvVar
+		launchNew = func(option launch.Option) launch.Launcher {
+			env = option.OptionEnv
+			return mockLaunch{}
+		}
+
+		root := newBuildCmd()
+		root.SetArgs([]string{"test", "--env", "CONFIG=@./testdata/test_env", "-e", "SD_LOCAL_TEST_ENV"})
+		root.SetOut(bytes.NewBuffer(nil))
+		err := root.Execute()
+		assert.Nil(t, err)
+		assert.Equal(t, launch.EnvVar{"CONFIG": "hoge=fuga\nfoo=bar\n", "SD_LOCAL_TEST_ENV": "host"}, env)
+	})
+
+	t.Run("Failed build cmd with --env from the file which does not exist", func(t *testing.T) {
+		root := newBuildCmd()
+		root.SetArgs([]string{"test", "--env", "CONFIG=@./testdata/doesnotexist"})
+		root.SetOut(bytes.NewBuffer(nil))
+		err := root.Execute()
+		assert.Equal(t, `invalid argument "CONFIG=@./testdata/doesnotexist" for "-e, --env" flag: failed to read the value of CONFIG from ./testdata/doesnotexist: open ./testdata/doesnotexist: no such file or directory`, err.Error())
+	})
+
 	t.Run("Success build cmd with --env-file", func(t *testing.T) {
 		root := newBuildCmd()
 
@@ -130,6 +308,192 @@ func TestBuildCmd(t *testing.T) {
 		assert.Equal(t, "sd-artifacts", artifactsDir)
 	})
 
+	t.Run("Failed build cmd with malformed --env-file", func(t *testing.T) {
+		root := newBuildCmd()
+
+		root.SetArgs([]string{"test", "--env-file", "./testdata/test_env_malformed"})
+		root.SetOut(bytes.NewBuffer(nil))
+
+		err := root.Execute()
+		path, _ := filepath.Abs("./testdata/test_env_malformed")
+		assert.Equal(t, fmt.Sprintf("failed to read env file in `%s`: line 2: unclosed double quote", path), err.Error())
+	})
+
+	t.Run("Success build cmd with --secrets-file", func(t *testing.T) {
+		defLaunchNew := launchNew
+		defer func() {
+			launchNew = defLaunchNew
+		}()
+
+		root := newBuildCmd()
+
+		root.SetArgs([]string{"test", "--secrets-file", "./testdata/test_secrets"})
+		buf := bytes.NewBuffer(nil)
+		root.SetOut(buf)
+
+		launchNew = func(option launch.Option) launch.Launcher {
+			assert.Equal(t, launch.EnvVar{"API_KEY": "apikey", "DB_PASSWORD": "dbpassword"}, option.Secrets)
+			assert.Equal(t, launch.EnvVar{}, option.OptionEnv)
+			return mockLaunch{}
+		}
+
+		err := root.Execute()
+		assert.Nil(t, err)
+	})
+
+	t.Run("Success build cmd with --retries", func(t *testing.T) {
+		defLaunchNew := launchNew
+		defer func() {
+			launchNew = defLaunchNew
+		}()
+
+		root := newBuildCmd()
+
+		root.SetArgs([]string{"test", "--retries", "3", "--retry-backoff", "2s"})
+		buf := bytes.NewBuffer(nil)
+		root.SetOut(buf)
+
+		launchNew = func(option launch.Option) launch.Launcher {
+			assert.Equal(t, retry.Policy{Retries: 3, Backoff: 2 * time.Second}, option.Retry)
+			return mockLaunch{}
+		}
+
+		err := root.Execute()
+		assert.Nil(t, err)
+	})
+
+	t.Run("Failed build cmd with --secrets-file masks the secrets", func(t *testing.T) {
+		defLaunchNew := launchNew
+		defer func() {
+			launchNew = defLaunchNew
+		}()
+
+		root := newBuildCmd()
+		root.SetArgs([]string{"test", "--secrets-file", "./testdata/test_secrets"})
+		buf := bytes.NewBuffer(nil)
+		root.SetOut(buf)
+		logBuf := bytes.NewBuffer(nil)
+		logrus.SetOutput(logBuf)
+		defer logrus.SetOutput(os.Stderr)
+
+		launchNew = func(option launch.Option) launch.Launcher {
+			logrus.Infof("connecting with apikey")
+			return failLaunch{err: errors.New("failed to login with dbpassword")}
+		}
+
+		err := root.Execute()
+		assert.Equal(t, "failed to login with ****", err.Error())
+		assert.Contains(t, logBuf.String(), "connecting with ****")
+		assert.NotContains(t, logBuf.String(), "apikey")
+	})
+
+	t.Run("Failed build cmd with --secrets-file which does not exist", func(t *testing.T) {
+		root := newBuildCmd()
+		root.SetArgs([]string{"test", "--secrets-file", "./testdata/not_found"})
+		buf := bytes.NewBuffer(nil)
+		root.SetOut(buf)
+
+		err := root.Execute()
+		assert.Equal(t, "failed to read secrets file ./testdata/not_found: open ./testdata/not_found: no such file or directory", err.Error())
+	})
+
+	t.Run("Success build cmd with --artifacts-s3", func(t *testing.T) {
+		defUploaderNew := uploaderNew
+		defLaunchNew := launchNew
+		defer func() {
+			uploaderNew = defUploaderNew
+			launchNew = defLaunchNew
+		}()
+		launchNew = func(option launch.Option) launch.Launcher {
+			return mockLaunch{}
+		}
+
+		root := newBuildCmd()
+		root.SetArgs([]string{"test", "--artifacts-s3", "s3://bucket/prefix", "--artifacts-s3-endpoint", "http://localhost:9000"})
+		buf := bytes.NewBuffer(nil)
+		root.SetOut(buf)
+		logBuf := bytes.NewBuffer(nil)
+		logrus.SetOutput(logBuf)
+		defer logrus.SetOutput(os.Stderr)
+
+		var uploadedDir string
+		uploaderNew = func(dest, endpoint string) (artifacts.Uploader, error) {
+			assert.Equal(t, "s3://bucket/prefix", dest)
+			assert.Equal(t, "http://localhost:9000", endpoint)
+			return mockUploader{dir: &uploadedDir, urls: []string{"http://localhost:9000/bucket/prefix/builds.log"}}, nil
+		}
+
+		err := root.Execute()
+		assert.Nil(t, err)
+		expectedDir, _ := filepath.Abs("sd-artifacts")
+		assert.Equal(t, expectedDir, uploadedDir)
+		assert.Contains(t, logBuf.String(), "Uploaded http://localhost:9000/bucket/prefix/builds.log")
+	})
+
+	t.Run("Success build cmd with --no-local-artifacts", func(t *testing.T) {
+		defUploaderNew := uploaderNew
+		defLaunchNew := launchNew
+		defer func() {
+			uploaderNew = defUploaderNew
+			launchNew = defLaunchNew
+		}()
+		launchNew = func(option launch.Option) launch.Launcher {
+			return mockLaunch{}
+		}
+
+		root := newBuildCmd()
+		root.SetArgs([]string{"test", "--artifacts-s3", "s3://bucket/prefix", "--no-local-artifacts"})
+		buf := bytes.NewBuffer(nil)
+		root.SetOut(buf)
+
+		var uploadedDir string
+		uploaderNew = func(dest, endpoint string) (artifacts.Uploader, error) {
+			return mockUploader{dir: &uploadedDir}, nil
+		}
+
+		err := root.Execute()
+		assert.Nil(t, err)
+		expectedDir, _ := filepath.Abs("sd-artifacts")
+		assert.NotEqual(t, expectedDir, uploadedDir)
+		_, err = os.Stat(uploadedDir)
+		assert.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("Failed build cmd with --artifacts-s3", func(t *testing.T) {
+		defUploaderNew := uploaderNew
+		defLaunchNew := launchNew
+		defer func() {
+			uploaderNew = defUploaderNew
+			launchNew = defLaunchNew
+		}()
+		launchNew = func(option launch.Option) launch.Launcher {
+			return mockLaunch{}
+		}
+
+		root := newBuildCmd()
+		root.SetArgs([]string{"test", "--artifacts-s3", "s3://bucket/prefix"})
+		buf := bytes.NewBuffer(nil)
+		root.SetOut(buf)
+
+		var uploadedDir string
+		uploaderNew = func(dest, endpoint string) (artifacts.Uploader, error) {
+			return mockUploader{dir: &uploadedDir, err: errors.New("failed to upload artifacts")}, nil
+		}
+
+		err := root.Execute()
+		assert.Equal(t, "failed to upload artifacts", err.Error())
+	})
+
+	t.Run("Failed build cmd with --no-local-artifacts without --artifacts-s3", func(t *testing.T) {
+		root := newBuildCmd()
+		root.SetArgs([]string{"test", "--no-local-artifacts"})
+		buf := bytes.NewBuffer(nil)
+		root.SetOut(buf)
+
+		err := root.Execute()
+		assert.Equal(t, "can't pass the option `no-local-artifacts` without `artifacts-s3`, the artifacts would be lost", err.Error())
+	})
+
 	t.Run("Success build cmd with --meta", func(t *testing.T) {
 		root := newBuildCmd()
 
@@ -177,6 +541,83 @@ func TestBuildCmd(t *testing.T) {
 		assert.Nil(t, err)
 	})
 
+	t.Run("Success build cmd with --meta of key=value", func(t *testing.T) {
+		root := newBuildCmd()
+
+		root.SetArgs([]string{"test", "--meta", "hoge=fuga", "--meta", "foo.bar=a=b"})
+		root.SetOut(bytes.NewBuffer(nil))
+
+		expected := launch.Meta{
+			"hoge": "fuga",
+			"foo": map[string]interface{}{
+				"bar": "a=b",
+			},
+		}
+
+		launchNew = func(option launch.Option) launch.Launcher {
+			assert.Equal(t, expected, option.Meta)
+			return mockLaunch{}
+		}
+
+		err := root.Execute()
+		assert.Nil(t, err)
+	})
+
+	t.Run("Success build cmd with --meta-out", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "meta-out")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+		metaOutPath := filepath.Join(dir, "meta.json")
+
+		root := newBuildCmd()
+		root.SetArgs([]string{"test", "--meta", "hoge=fuga", "--meta-out", metaOutPath})
+		root.SetOut(bytes.NewBuffer(nil))
+
+		var metaPath string
+		launchNew = func(option launch.Option) launch.Launcher {
+			metaPath = option.MetaPath
+			// the launcher writes the meta updated by the steps
+			err := ioutil.WriteFile(filepath.Join(option.MetaPath, "meta.json"), []byte(`{"hoge":"fuga","build":{"status":"ok"}}`), 0666)
+			assert.Nil(t, err)
+			return mockLaunch{}
+		}
+
+		err = root.Execute()
+		assert.Nil(t, err)
+
+		b, err := ioutil.ReadFile(metaOutPath)
+		assert.Nil(t, err)
+		assert.Equal(t, "{\n  \"build\": {\n    \"status\": \"ok\"\n  },\n  \"hoge\": \"fuga\"\n}\n", string(b))
+		_, err = os.Stat(metaPath)
+		assert.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("Failed build cmd with --meta-out of multiple jobs", func(t *testing.T) {
+		root := newBuildCmd()
+		root.SetArgs([]string{"test", "lint", "--meta-out", "meta.json"})
+		root.SetOut(bytes.NewBuffer(nil))
+		err := root.Execute()
+		assert.Equal(t, "can't write the meta of multiple jobs, please specify only one job with `meta-out`", err.Error())
+	})
+
+	t.Run("Failed build cmd with --meta-out and k8s", func(t *testing.T) {
+		root := newBuildCmd()
+		root.SetArgs([]string{"test", "--runtime", "k8s", "--meta-out", "meta.json"})
+		root.SetOut(bytes.NewBuffer(nil))
+		err := root.Execute()
+		assert.Equal(t, "runtime k8s does not support `meta-out`", err.Error())
+	})
+
+	t.Run("Failed build cmd with invalid --meta", func(t *testing.T) {
+		root := newBuildCmd()
+		root.SetArgs([]string{"test", "--meta", "hoge"})
+		root.SetOut(bytes.NewBuffer(nil))
+		err := root.Execute()
+		assert.Equal(t, "invalid meta hoge: must be key=value or a JSON object", err.Error())
+	})
+
 	t.Run("Failed build cmd with --meta and --meta-file", func(t *testing.T) {
 		root := newBuildCmd()
 
@@ -190,23 +631,2009 @@ func TestBuildCmd(t *testing.T) {
 
 		err := root.Execute()
 		want := "Error: can't pass the both options `meta` and `meta-file`, please specify only one of them\n" +
-			"Usage:\n  build [job name] [flags]\n" +
+			"Usage:\n  build [job name...] [flags]\n" +
 			buildLocalFlags()
 		assert.Equal(t, want, buf.String())
 		assert.NotNil(t, err)
 	})
 
-	t.Run("Failed build cmd when too many args", func(t *testing.T) {
+	t.Run("Failed build cmd with missing settings", func(t *testing.T) {
+		defConfigNew := configNew
+		defer func() {
+			configNew = defConfigNew
+		}()
+
 		root := newBuildCmd()
-		root.SetArgs([]string{"test", "main"})
+		root.SetArgs([]string{"test"})
 		buf := bytes.NewBuffer(nil)
 		root.SetOut(buf)
+
+		configNew = func(confPath string) (config.Config, error) {
+			return config.Config{
+				Entries: map[string]*config.Entry{
+					"default": config.DefaultEntry(),
+				},
+				Current: "default",
+			}, nil
+		}
+
 		err := root.Execute()
-		want := "Error: accepts 1 arg(s), received 2\n" +
-			"Usage:\n  build [job name] [flags]\n" +
-			buildLocalFlags()
-		assert.Equal(t, want, buf.String())
-		assert.NotNil(t, err)
+		want := "config `default` is not ready to build: missing required settings:\n  * api-url\n  * store-url\n  * token\nplease set them with `sd-local config set`"
+		assert.Equal(t, want, err.Error())
+	})
+
+	t.Run("Success build cmd with --config-entry", func(t *testing.T) {
+		defConfigNew := configNew
+		defAPINew := apiNew
+		defer func() {
+			configNew = defConfigNew
+			apiNew = defAPINew
+		}()
+
+		var c config.Config
+		configNew = func(confPath string) (config.Config, error) {
+			c = config.Config{
+				Entries: map[string]*config.Entry{
+					"default": config.DefaultEntry(),
+					"beta": {
+						APIURL:   "https://beta.api.screwdriver.cd",
+						StoreURL: "https://beta.store.screwdriver.cd",
+						Token:    "beta-token",
+						Launcher: config.Launcher{Version: "stable", Image: "screwdrivercd/launcher"},
+						UUID:     "eb004dc1-614c-11eb-bab9-0242ac120002",
+					},
+				},
+				Current: "default",
+			}
+			return c, nil
+		}
+		var apiURL string
+		apiNew = func(url, token, ua string, client *http.Client) screwdriver.API {
+			apiURL = url
+			return mockAPI{}
+		}
+
+		root := newBuildCmd()
+		root.SetArgs([]string{"test", "--config-entry", "beta"})
+		buf := bytes.NewBuffer(nil)
+		root.SetOut(buf)
+
+		err := root.Execute()
+		assert.Nil(t, err)
+		assert.Equal(t, "https://beta.api.screwdriver.cd", apiURL)
+		assert.Equal(t, "default", c.Current)
+	})
+
+	t.Run("Failed build cmd with unknown --config-entry", func(t *testing.T) {
+		root := newBuildCmd()
+		root.SetArgs([]string{"test", "--config-entry", "doesnotexist"})
+		buf := bytes.NewBuffer(nil)
+		root.SetOut(buf)
+
+		err := root.Execute()
+		assert.Equal(t, "config `doesnotexist` does not exist, see the configs with `sd-local config list`", err.Error())
+	})
+
+	t.Run("Build cmd with --check-launcher-version and --strict", func(t *testing.T) {
+		defConfigNew := configNew
+		defLauncherTags := launcherTags
+		defer func() {
+			configNew = defConfigNew
+			launcherTags = defLauncherTags
+		}()
+
+		configNew = func(confPath string) (config.Config, error) {
+			return config.Config{
+				Entries: map[string]*config.Entry{
+					"default": {
+						APIURL:   "https://api.screwdriver.cd",
+						StoreURL: "https://store.screwdriver.cd",
+						Token:    "token",
+						Launcher: config.Launcher{Version: "v6.0.2", Image: "screwdrivercd/launcher"},
+						UUID:     "eb004dc1-614c-11eb-bab9-0242ac120002",
+					},
+				},
+				Current: "default",
+			}, nil
+		}
+
+		testCases := []struct {
+			name      string
+			args      []string
+			tagsErr   error
+			expectErr string
+		}{
+			{
+				name: "warns on the missing tag",
+				args: []string{"test", "--check-launcher-version"},
+			},
+			{
+				name:      "fails on the missing tag with --strict",
+				args:      []string{"test", "--strict"},
+				expectErr: "launcher version v6.0.2 is not a tag of screwdrivercd/launcher, did you mean v6.0.0, v6.0.1?",
+			},
+			{
+				name:    "warns on the failure to list the tags even with --strict",
+				args:    []string{"test", "--strict"},
+				tagsErr: errors.New("registry responded with 503 Service Unavailable"),
+			},
+			{
+				name:      "fails offline",
+				args:      []string{"test", "--check-launcher-version", "--offline"},
+				expectErr: "can't check the launcher version by `check-launcher-version` offline",
+			},
+		}
+
+		for _, tt := range testCases {
+			t.Run(tt.name, func(t *testing.T) {
+				var image string
+				launcherTags = func(client *http.Client, launcherImage string) ([]string, error) {
+					image = launcherImage
+					return []string{"v6.0.0", "v6.0.1", "stable"}, tt.tagsErr
+				}
+
+				root := newBuildCmd()
+				root.SetArgs(tt.args)
+				root.SetOut(bytes.NewBuffer(nil))
+				err := root.Execute()
+				if tt.expectErr == "" {
+					assert.Nil(t, err)
+					assert.Equal(t, "screwdrivercd/launcher", image)
+				} else {
+					assert.Equal(t, tt.expectErr, err.Error())
+				}
+			})
+		}
+	})
+
+	t.Run("Build cmd with --launcher-profile", func(t *testing.T) {
+		defConfigNew := configNew
+		defLaunchNew := launchNew
+		defer func() {
+			configNew = defConfigNew
+			launchNew = defLaunchNew
+		}()
+
+		configNew = func(confPath string) (config.Config, error) {
+			return config.Config{
+				Entries: map[string]*config.Entry{
+					"default": {
+						APIURL:   "https://api.screwdriver.cd",
+						StoreURL: "https://store.screwdriver.cd",
+						Token:    "token",
+						Launcher: config.Launcher{Version: "stable", Image: "screwdrivercd/launcher"},
+						Launchers: map[string]config.Launcher{
+							"v5": {Version: "v5.0.0"},
+						},
+						UUID: "eb004dc1-614c-11eb-bab9-0242ac120002",
+					},
+				},
+				Current: "default",
+			}, nil
+		}
+
+		testCases := []struct {
+			name           string
+			args           []string
+			expectLauncher config.Launcher
+			expectErr      string
+		}{
+			{
+				name:           "success with the launcher of the config",
+				args:           []string{"test"},
+				expectLauncher: config.Launcher{Version: "stable", Image: "screwdrivercd/launcher"},
+			},
+			{
+				name:           "success with the launcher profile",
+				args:           []string{"test", "--launcher-profile", "v5"},
+				expectLauncher: config.Launcher{Version: "v5.0.0", Image: "screwdrivercd/launcher"},
+			},
+			{
+				name:      "failure by unknown launcher profile",
+				args:      []string{"test", "--launcher-profile", "v4"},
+				expectErr: "config `default` has no launcher to build with: launcher profile `v4` does not exist: must be one of v5",
+			},
+		}
+
+		for _, tt := range testCases {
+			t.Run(tt.name, func(t *testing.T) {
+				var launcher config.Launcher
+				launchNew = func(option launch.Option) launch.Launcher {
+					launcher = option.Entry.Launcher
+					return mockLaunch{}
+				}
+
+				root := newBuildCmd()
+				root.SetArgs(tt.args)
+				root.SetOut(bytes.NewBuffer(nil))
+				err := root.Execute()
+				if tt.expectErr == "" {
+					assert.Nil(t, err)
+					assert.Equal(t, tt.expectLauncher, launcher)
+				} else {
+					assert.Equal(t, tt.expectErr, err.Error())
+				}
+			})
+		}
+	})
+
+	t.Run("Build cmd with --launcher-archive", func(t *testing.T) {
+		defConfigNew := configNew
+		defLaunchNew := launchNew
+		defer func() {
+			configNew = defConfigNew
+			launchNew = defLaunchNew
+		}()
+
+		home, err := homedir.Dir()
+		if err != nil {
+			t.Fatal(err)
+		}
+		configNew = func(confPath string) (config.Config, error) {
+			return config.Config{
+				Entries: map[string]*config.Entry{
+					"default": {
+						APIURL:   "https://api.screwdriver.cd",
+						StoreURL: "https://store.screwdriver.cd",
+						Token:    "token",
+						Launcher: config.Launcher{Version: "stable", Image: "screwdrivercd/launcher", Archive: "~/launcher.tar"},
+						UUID:     "eb004dc1-614c-11eb-bab9-0242ac120002",
+					},
+				},
+				Current: "default",
+			}, nil
+		}
+
+		testCases := []struct {
+			name          string
+			args          []string
+			expectArchive string
+			expectErr     string
+		}{
+			{
+				name:          "success with launcher-archive of the config",
+				args:          []string{"test"},
+				expectArchive: filepath.Join(home, "launcher.tar"),
+			},
+			{
+				name:          "success with --launcher-archive",
+				args:          []string{"test", "--launcher-archive", "/media/launcher.tar"},
+				expectArchive: "/media/launcher.tar",
+			},
+			{
+				name:      "failure with k8s",
+				args:      []string{"test", "--launcher-archive", "/media/launcher.tar", "--runtime", "k8s"},
+				expectErr: "runtime k8s does not support `launcher-archive`",
+			},
+		}
+
+		for _, tt := range testCases {
+			t.Run(tt.name, func(t *testing.T) {
+				var archive string
+				launchNew = func(option launch.Option) launch.Launcher {
+					archive = option.Entry.Launcher.Archive
+					return mockLaunch{}
+				}
+
+				root := newBuildCmd()
+				root.SetArgs(tt.args)
+				root.SetOut(bytes.NewBuffer(nil))
+				err := root.Execute()
+				if tt.expectErr == "" {
+					assert.Nil(t, err)
+					assert.Equal(t, tt.expectArchive, archive)
+				} else {
+					assert.Equal(t, tt.expectErr, err.Error())
+				}
+			})
+		}
+	})
+
+	t.Run("Failed build cmd with invalid runtime", func(t *testing.T) {
+		root := newBuildCmd()
+		root.SetArgs([]string{"test", "--runtime", "lxc"})
+		buf := bytes.NewBuffer(nil)
+		root.SetOut(buf)
+
+		err := root.Execute()
+		assert.Equal(t, "invalid runtime lxc: must be one of docker, podman, k8s", err.Error())
+	})
+
+	t.Run("Success build cmd with --pull", func(t *testing.T) {
+		for expected, args := range map[string][]string{
+			launch.PullMissing: {"test"},
+			launch.PullNever:   {"test", "--pull", "never"},
+		} {
+			var pullPolicy string
+			launchNew = func(option launch.Option) launch.Launcher {
+				pullPolicy = option.PullPolicy
+				return mockLaunch{}
+			}
+
+			root := newBuildCmd()
+			root.SetArgs(args)
+			root.SetOut(bytes.NewBuffer(nil))
+			err := root.Execute()
+			assert.Nil(t, err)
+			assert.Equal(t, expected, pullPolicy)
+		}
+	})
+
+	t.Run("Failed build cmd with invalid pull policy", func(t *testing.T) {
+		root := newBuildCmd()
+		root.SetArgs([]string{"test", "--pull", "if-not-present"})
+		root.SetOut(bytes.NewBuffer(nil))
+
+		err := root.Execute()
+		assert.Equal(t, "invalid pull policy if-not-present: must be one of always, missing or never", err.Error())
+	})
+
+	t.Run("Success build cmd with --offline", func(t *testing.T) {
+		defAPINew := apiNew
+		defer func() { apiNew = defAPINew }()
+		// the API is not requested offline
+		apiNew = func(url, token, ua string, client *http.Client) screwdriver.API { return failedJWTAPI{} }
+
+		var option launch.Option
+		launchNew = func(o launch.Option) launch.Launcher {
+			option = o
+			return mockLaunch{}
+		}
+
+		for _, args := range [][]string{
+			{"test", "--offline"},
+			{"test", "--offline", "--pull", "never"},
+		} {
+			root := newBuildCmd()
+			root.SetArgs(args)
+			root.SetOut(bytes.NewBuffer(nil))
+			err := root.Execute()
+			assert.Nil(t, err)
+			assert.True(t, option.Offline)
+			assert.Equal(t, "", option.JWT)
+		}
+	})
+
+	t.Run("Failed build cmd with --offline", func(t *testing.T) {
+		defCachedJobs := cachedJobs
+		defer func() { cachedJobs = defCachedJobs }()
+		cachedJobs = func(cacheDir, sdYAMLPath string) (map[string]screwdriver.Job, error) {
+			return nil, errors.New("the jobs of screwdriver.yaml are not cached, run the build online once with the same screwdriver.yaml and --env to cache them")
+		}
+
+		cases := map[string]struct {
+			args      []string
+			expectErr string
+		}{
+			"not cached": {
+				args:      []string{"test", "--offline"},
+				expectErr: "the jobs of screwdriver.yaml are not cached, run the build online once with the same screwdriver.yaml and --env to cache them",
+			},
+			"pull": {
+				args:      []string{"test", "--offline", "--pull", "always"},
+				expectErr: "can't pull the launcher image by `pull always` offline",
+			},
+			"artifacts-s3": {
+				args:      []string{"test", "--offline", "--artifacts-s3", "s3://bucket/prefix"},
+				expectErr: "can't upload the artifacts by `artifacts-s3` offline",
+			},
+			"src-url": {
+				args:      []string{"test", "--offline", "--src-url", "git@github.com:org/repo.git"},
+				expectErr: "can't pull the source code by `src-url` offline, please specify the local directory",
+			},
+			"k8s": {
+				args:      []string{"test", "--offline", "--runtime", "k8s"},
+				expectErr: "runtime k8s does not support `offline`",
+			},
+		}
+
+		for name, c := range cases {
+			t.Run(name, func(t *testing.T) {
+				root := newBuildCmd()
+				root.SetArgs(c.args)
+				root.SetOut(bytes.NewBuffer(nil))
+				err := root.Execute()
+				assert.Equal(t, c.expectErr, err.Error())
+			})
+		}
+	})
+
+	t.Run("Success build cmd with --sudo", func(t *testing.T) {
+		defSudoValidate := sudoValidate
+		defer func() {
+			sudoValidate = defSudoValidate
+			useSudo = false
+		}()
+		validated := false
+		sudoValidate = func() error {
+			validated = true
+			return nil
+		}
+		var option launch.Option
+		launchNew = func(o launch.Option) launch.Launcher {
+			option = o
+			return mockLaunch{}
+		}
+
+		root := newBuildCmd()
+		root.SetArgs([]string{"test", "--sudo"})
+		root.SetOut(bytes.NewBuffer(nil))
+		err := root.Execute()
+		assert.Nil(t, err)
+		assert.True(t, validated)
+		assert.True(t, option.UseSudo)
+	})
+
+	t.Run("Failed build cmd with --sudo", func(t *testing.T) {
+		defSudoValidate := sudoValidate
+		defer func() {
+			sudoValidate = defSudoValidate
+			useSudo = false
+		}()
+		sudoValidate = func() error { return errors.New("exit status 1") }
+
+		root := newBuildCmd()
+		root.SetArgs([]string{"test", "--sudo"})
+		root.SetOut(bytes.NewBuffer(nil))
+		err := root.Execute()
+		assert.Equal(t, "failed to authenticate with sudo: exit status 1", err.Error())
+	})
+
+	t.Run("Success build cmd with --container-name", func(t *testing.T) {
+		defLaunchNew := launchNew
+		defer func() {
+			launchNew = defLaunchNew
+		}()
+		mutex := &sync.Mutex{}
+		names := map[string]string{}
+		var entryName string
+		launchNew = func(o launch.Option) launch.Launcher {
+			mutex.Lock()
+			defer mutex.Unlock()
+			names[o.JobName] = o.ContainerName
+			entryName = o.EntryName
+			return mockLaunch{}
+		}
+
+		root := newBuildCmd()
+		root.SetArgs([]string{"test"})
+		root.SetOut(bytes.NewBuffer(nil))
+		err := root.Execute()
+		assert.Nil(t, err)
+		assert.Regexp(t, `^sdlocal-test-\d{14}$`, names["test"])
+		assert.Equal(t, "default", entryName)
+
+		root = newBuildCmd()
+		root.SetArgs([]string{"test", "--container-name", "my-build"})
+		root.SetOut(bytes.NewBuffer(nil))
+		err = root.Execute()
+		assert.Nil(t, err)
+		assert.Equal(t, "my-build", names["test"])
+
+		root = newBuildCmd()
+		root.SetArgs([]string{"test", "lint", "--container-name", "my-build"})
+		root.SetOut(bytes.NewBuffer(nil))
+		err = root.Execute()
+		assert.Nil(t, err)
+		assert.Equal(t, map[string]string{"test": "my-build-test", "lint": "my-build-lint"}, names)
+	})
+
+	t.Run("Failed build cmd with invalid --container-name", func(t *testing.T) {
+		root := newBuildCmd()
+		root.SetArgs([]string{"test", "--container-name", "my build"})
+		root.SetOut(bytes.NewBuffer(nil))
+		err := root.Execute()
+		assert.Equal(t, "invalid container name my build: must start with a letter or a digit and consist of letters, digits, _, . and -", err.Error())
+	})
+
+	t.Run("Success build cmd with --workdir", func(t *testing.T) {
+		defLaunchNew := launchNew
+		defer func() {
+			launchNew = defLaunchNew
+		}()
+		var workdir string
+		launchNew = func(o launch.Option) launch.Launcher {
+			workdir = o.Workdir
+			return mockLaunch{}
+		}
+
+		root := newBuildCmd()
+		root.SetArgs([]string{"test", "--workdir", "/sd/workspace/src/app"})
+		root.SetOut(bytes.NewBuffer(nil))
+		err := root.Execute()
+		assert.Nil(t, err)
+		assert.Equal(t, "/sd/workspace/src/app", workdir)
+	})
+
+	t.Run("Failed build cmd with relative --workdir", func(t *testing.T) {
+		root := newBuildCmd()
+		root.SetArgs([]string{"test", "--workdir", "src/app"})
+		root.SetOut(bytes.NewBuffer(nil))
+		err := root.Execute()
+		assert.Equal(t, "invalid workdir src/app: must be an absolute path in the build container", err.Error())
+	})
+
+	t.Run("Success build cmd with --dump-env", func(t *testing.T) {
+		defLaunchNew := launchNew
+		defer func() {
+			launchNew = defLaunchNew
+		}()
+		launchNew = func(o launch.Option) launch.Launcher {
+			return envLaunch{}
+		}
+		dir, err := ioutil.TempDir("", "sd-local-dump-env")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+		path := filepath.Join(dir, "build.env")
+
+		root := newBuildCmd()
+		root.SetArgs([]string{"test", "--dump-env", path})
+		root.SetOut(bytes.NewBuffer(nil))
+		err = root.Execute()
+		assert.Nil(t, err)
+
+		b, err := ioutil.ReadFile(path)
+		assert.Nil(t, err)
+		assert.Contains(t, string(b), "# the environment of the build container of test\n")
+		assert.Contains(t, string(b), "SD_TOKEN=\"****\"\n")
+	})
+
+	t.Run("Failed build cmd with --dump-env", func(t *testing.T) {
+		testCases := map[string]struct {
+			args      []string
+			expectErr string
+		}{
+			"unmasked without dump-env": {
+				args:      []string{"test", "--dump-env-unmasked"},
+				expectErr: "can't pass the option `dump-env-unmasked` without `dump-env`",
+			},
+			"multiple jobs without {job}": {
+				args:      []string{"test", "lint", "--dump-env", "build.env"},
+				expectErr: "dump-env must have {job} to dump the environment of multiple jobs",
+			},
+		}
+
+		for name, tt := range testCases {
+			t.Run(name, func(t *testing.T) {
+				root := newBuildCmd()
+				root.SetArgs(tt.args)
+				root.SetOut(bytes.NewBuffer(nil))
+				err := root.Execute()
+				assert.Equal(t, tt.expectErr, err.Error())
+			})
+		}
+	})
+
+	t.Run("Success build cmd with --shell", func(t *testing.T) {
+		defLaunchNew := launchNew
+		defer func() {
+			launchNew = defLaunchNew
+		}()
+		var shell string
+		launchNew = func(o launch.Option) launch.Launcher {
+			shell = o.Shell
+			return mockLaunch{}
+		}
+
+		root := newBuildCmd()
+		root.SetArgs([]string{"test", "--shell", "/bin/bash"})
+		root.SetOut(bytes.NewBuffer(nil))
+		err := root.Execute()
+		assert.Nil(t, err)
+		assert.Equal(t, "/bin/bash", shell)
+	})
+
+	t.Run("Failed build cmd with relative --shell", func(t *testing.T) {
+		root := newBuildCmd()
+		root.SetArgs([]string{"test", "--shell", "bash"})
+		root.SetOut(bytes.NewBuffer(nil))
+		err := root.Execute()
+		assert.Equal(t, "invalid shell bash: must be an absolute path in the build image like /bin/bash", err.Error())
+	})
+
+	t.Run("Success build cmd with --user", func(t *testing.T) {
+		defLaunchNew := launchNew
+		defer func() {
+			launchNew = defLaunchNew
+		}()
+		var user string
+		launchNew = func(o launch.Option) launch.Launcher {
+			user = o.User
+			return mockLaunch{}
+		}
+
+		root := newBuildCmd()
+		root.SetArgs([]string{"test", "--user", "1000:1000"})
+		root.SetOut(bytes.NewBuffer(nil))
+		err := root.Execute()
+		assert.Nil(t, err)
+		assert.Equal(t, "1000:1000", user)
+	})
+
+	t.Run("Failed build cmd with --user", func(t *testing.T) {
+		testCases := map[string]struct {
+			args      []string
+			expectErr string
+		}{
+			"invalid": {
+				args:      []string{"test", "--user", "1000:"},
+				expectErr: "invalid user 1000:: must be in the form of uid[:gid] like 1000:1000",
+			},
+			"k8s": {
+				args:      []string{"test", "--user", "1000:1000", "--runtime", "k8s"},
+				expectErr: "runtime k8s does not support `user`",
+			},
+		}
+
+		for name, tt := range testCases {
+			t.Run(name, func(t *testing.T) {
+				root := newBuildCmd()
+				root.SetArgs(tt.args)
+				root.SetOut(bytes.NewBuffer(nil))
+				err := root.Execute()
+				assert.Equal(t, tt.expectErr, err.Error())
+			})
+		}
+	})
+
+	t.Run("Success build cmd with --mount", func(t *testing.T) {
+		defLaunchNew := launchNew
+		defer func() {
+			launchNew = defLaunchNew
+		}()
+		var option launch.Option
+		launchNew = func(o launch.Option) launch.Launcher {
+			option = o
+			return mockLaunch{}
+		}
+		dir, err := ioutil.TempDir("", "mount")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+
+		root := newBuildCmd()
+		root.SetArgs([]string{"test", "--mount", dir + ":/root/.m2:ro", "--mount", dir + ":/cache"})
+		root.SetOut(bytes.NewBuffer(nil))
+		err = root.Execute()
+		assert.Nil(t, err)
+		assert.Equal(t, []launch.Mount{
+			{Source: dir, Target: "/root/.m2", ReadOnly: true},
+			{Source: dir, Target: "/cache"},
+		}, option.Mounts)
+	})
+
+	t.Run("Failed build cmd with invalid --mount", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "mount")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+
+		testCases := map[string]struct {
+			args     []string
+			expected string
+		}{
+			"source code": {
+				[]string{"test", "--mount", dir + ":/sd/workspace/src/screwdriver.cd/sd-local/local-build"},
+				"invalid mount " + dir + ":/sd/workspace/src/screwdriver.cd/sd-local/local-build: /sd/workspace/src/screwdriver.cd/sd-local/local-build collides with /sd/workspace/src/screwdriver.cd/sd-local/local-build mounted by sd-local",
+			},
+			"k8s": {
+				[]string{"test", "--mount", dir + ":/cache", "--runtime", "k8s"},
+				"runtime k8s does not support `mount`",
+			},
+		}
+		for name, tt := range testCases {
+			t.Run(name, func(t *testing.T) {
+				root := newBuildCmd()
+				root.SetArgs(tt.args)
+				root.SetOut(bytes.NewBuffer(nil))
+				err := root.Execute()
+				assert.Equal(t, tt.expected, err.Error())
+			})
+		}
+	})
+
+	t.Run("Success build cmd with --pipeline-id", func(t *testing.T) {
+		defLaunchNew := launchNew
+		defer func() {
+			launchNew = defLaunchNew
+		}()
+		var option launch.Option
+		launchNew = func(o launch.Option) launch.Launcher {
+			option = o
+			return mockLaunch{}
+		}
+
+		root := newBuildCmd()
+		root.SetArgs([]string{"main", "--pipeline-id", "123"})
+		root.SetOut(bytes.NewBuffer(nil))
+		err := root.Execute()
+		assert.Nil(t, err)
+		assert.Equal(t, "main", option.JobName)
+		assert.Equal(t, "golang", option.Job.Image)
+		assert.Equal(t, []screwdriver.Step{{Name: "test", Command: "make test"}}, option.Job.Steps)
+	})
+
+	t.Run("Failed build cmd with --pipeline-id", func(t *testing.T) {
+		testCases := map[string]struct {
+			args     []string
+			expected string
+		}{
+			"not found": {
+				[]string{"main", "--pipeline-id", "404"},
+				"pipeline 404 is not found",
+			},
+			"job not in the pipeline": {
+				[]string{"test", "--pipeline-id", "123"},
+				"not found 'test' in parsed screwdriver.yaml",
+			},
+			"negative": {
+				[]string{"main", "--pipeline-id", "-1"},
+				"pipeline-id must be a positive integer: -1",
+			},
+			"file": {
+				[]string{"main", "--pipeline-id", "123", "--file", "ci/screwdriver.yaml"},
+				"can't pass the both options `pipeline-id` and `file`, the jobs are got from the pipeline",
+			},
+			"offline": {
+				[]string{"main", "--pipeline-id", "123", "--offline"},
+				"can't get the jobs of the pipeline by `pipeline-id` offline",
+			},
+		}
+		for name, tt := range testCases {
+			t.Run(name, func(t *testing.T) {
+				root := newBuildCmd()
+				root.SetArgs(tt.args)
+				root.SetOut(bytes.NewBuffer(nil))
+				err := root.Execute()
+				assert.Equal(t, tt.expected, err.Error())
+			})
+		}
+	})
+
+	t.Run("Success build cmd with --print-expanded", func(t *testing.T) {
+		defExpandTemplateFile := expandTemplateFile
+		defLaunchNew := launchNew
+		defer func() {
+			expandTemplateFile = defExpandTemplateFile
+			launchNew = defLaunchNew
+		}()
+
+		dir, err := ioutil.TempDir("", "expand")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+
+		expandedPath := filepath.Join(dir, "screwdriver.yaml")
+		expanded := "jobs:\n  test:\n    image: golang\n    steps:\n    - test: go test ./...\n"
+		if err := ioutil.WriteFile(expandedPath, []byte(expanded), 0644); err != nil {
+			t.Fatal(err)
+		}
+		expandTemplateFile = func(api screwdriver.API, sdYAMLPath string) (string, error) {
+			return expandedPath, nil
+		}
+		launched := false
+		launchNew = func(option launch.Option) launch.Launcher {
+			launched = true
+			return mockLaunch{}
+		}
+
+		root := newBuildCmd()
+		root.SetArgs([]string{"test", "--print-expanded"})
+		buf := bytes.NewBuffer(nil)
+		root.SetOut(buf)
+		err = root.Execute()
+		assert.Nil(t, err)
+		assert.Equal(t, expanded, buf.String())
+		assert.False(t, launched)
+	})
+
+	t.Run("Failed build cmd with --print-expanded", func(t *testing.T) {
+		testCases := map[string]struct {
+			args     []string
+			expected string
+		}{
+			"offline": {
+				[]string{"test", "--print-expanded", "--offline"},
+				"can't expand the templates by `print-expanded` offline",
+			},
+			"pipeline-id": {
+				[]string{"main", "--print-expanded", "--pipeline-id", "123"},
+				"can't pass the both options `print-expanded` and `pipeline-id`, the jobs of the pipeline are expanded by the API",
+			},
+		}
+		for name, tt := range testCases {
+			t.Run(name, func(t *testing.T) {
+				root := newBuildCmd()
+				root.SetArgs(tt.args)
+				root.SetOut(bytes.NewBuffer(nil))
+				err := root.Execute()
+				assert.Equal(t, tt.expected, err.Error())
+			})
+		}
+	})
+
+	t.Run("Success build cmd with matrix", func(t *testing.T) {
+		defCachedJobs := cachedJobs
+		defLaunchNew := launchNew
+		defer func() {
+			cachedJobs = defCachedJobs
+			launchNew = defLaunchNew
+		}()
+		cachedJobs = func(cacheDir, sdYAMLPath string) (map[string]screwdriver.Job, error) {
+			return testMatrixJobs(), nil
+		}
+		var mu sync.Mutex
+		envs := make(map[string]map[string]string)
+		launchNew = func(option launch.Option) launch.Launcher {
+			mu.Lock()
+			defer mu.Unlock()
+			envs[option.JobName] = option.Job.Environment
+			return mockLaunch{}
+		}
+
+		root := newBuildCmd()
+		root.SetArgs([]string{"main", "--offline", "--matrix", "NODE_VERSION=14", "--max-parallel", "2"})
+		root.SetOut(bytes.NewBuffer(nil))
+		err := root.Execute()
+		assert.Nil(t, err)
+		assert.Equal(t, map[string]map[string]string{
+			"main[NODE_VERSION=14,OS=darwin]": {"NODE_VERSION": "14", "OS": "darwin"},
+			"main[NODE_VERSION=14,OS=linux]":  {"NODE_VERSION": "14", "OS": "linux"},
+		}, envs)
+	})
+
+	t.Run("Success build cmd with the environment of the steps", func(t *testing.T) {
+		defExtractStepEnvFile := extractStepEnvFile
+		defLaunchNew := launchNew
+		defer func() {
+			extractStepEnvFile = defExtractStepEnvFile
+			launchNew = defLaunchNew
+		}()
+		extractStepEnvFile = func(sdYAMLPath string) (string, screwdriver.StepEnvironments, error) {
+			return sdYAMLPath, screwdriver.StepEnvironments{"test": {"test": {"NODE_ENV": "test"}}}, nil
+		}
+		var steps []screwdriver.Step
+		launchNew = func(option launch.Option) launch.Launcher {
+			steps = option.Job.Steps
+			return mockLaunch{}
+		}
+
+		root := newBuildCmd()
+		root.SetArgs([]string{"test"})
+		root.SetOut(bytes.NewBuffer(nil))
+		err := root.Execute()
+		assert.Nil(t, err)
+		assert.Equal(t, []screwdriver.Step{
+			{Name: "install", Command: "npm install"},
+			{Name: "test", Command: "npm test", Environment: map[string]string{"NODE_ENV": "test"}},
+		}, steps)
+	})
+
+	t.Run("Success build cmd with --changed-since", func(t *testing.T) {
+		defCachedJobs := cachedJobs
+		defChangedFiles := changedFiles
+		defLaunchNew := launchNew
+		defer func() {
+			cachedJobs = defCachedJobs
+			changedFiles = defChangedFiles
+			launchNew = defLaunchNew
+		}()
+		cachedJobs = func(cacheDir, sdYAMLPath string) (map[string]screwdriver.Job, error) {
+			return map[string]screwdriver.Job{
+				"a":    {Image: "node:12", Steps: []screwdriver.Step{{Name: "test", Command: "npm test"}}, SourcePaths: []string{"services/a/"}},
+				"b":    {Image: "node:12", Steps: []screwdriver.Step{{Name: "test", Command: "npm test"}}, SourcePaths: []string{"services/b/"}},
+				"lint": {Image: "node:12", Steps: []screwdriver.Step{{Name: "lint", Command: "npm run lint"}}},
+			}, nil
+		}
+		var mu sync.Mutex
+		launched := make([]string, 0)
+		launchNew = func(option launch.Option) launch.Launcher {
+			mu.Lock()
+			defer mu.Unlock()
+			launched = append(launched, option.JobName)
+			return mockLaunch{}
+		}
+
+		changedFiles = func(srcPath, ref string) ([]string, error) {
+			assert.Equal(t, "origin/main", ref)
+			return []string{"services/a/main.go"}, nil
+		}
+		root := newBuildCmd()
+		root.SetArgs([]string{"a", "b", "lint", "--offline", "--changed-since", "origin/main", "--max-parallel", "3"})
+		root.SetOut(bytes.NewBuffer(nil))
+		err := root.Execute()
+		assert.Nil(t, err)
+		sort.Strings(launched)
+		assert.Equal(t, []string{"a", "lint"}, launched)
+
+		launched = make([]string, 0)
+		changedFiles = func(srcPath, ref string) ([]string, error) { return []string{}, nil }
+		root = newBuildCmd()
+		root.SetArgs([]string{"a", "b", "--offline", "--changed-since", "origin/main"})
+		root.SetOut(bytes.NewBuffer(nil))
+		err = root.Execute()
+		assert.Nil(t, err)
+		assert.Equal(t, []string{}, launched)
+
+		changedFiles = func(srcPath, ref string) ([]string, error) {
+			return nil, errors.New("failed to get the files changed since unknown: exit status 128")
+		}
+		root = newBuildCmd()
+		root.SetArgs([]string{"a", "--offline", "--changed-since", "unknown"})
+		root.SetOut(bytes.NewBuffer(nil))
+		err = root.Execute()
+		assert.Equal(t, "failed to get the files changed since unknown: exit status 128", err.Error())
+	})
+
+	t.Run("Failed build cmd with matrix", func(t *testing.T) {
+		defCachedJobs := cachedJobs
+		defer func() {
+			cachedJobs = defCachedJobs
+		}()
+		cachedJobs = func(cacheDir, sdYAMLPath string) (map[string]screwdriver.Job, error) {
+			return testMatrixJobs(), nil
+		}
+
+		testCases := map[string]struct {
+			args     []string
+			expected string
+		}{
+			"interactive": {
+				[]string{"main", "--offline", "--interactive"},
+				"can't run multiple combinations of the matrix in interactive mode, please select one with `matrix`",
+			},
+			"meta-out": {
+				[]string{"main", "--offline", "--matrix", "OS=linux", "--meta-out", "meta.json"},
+				"can't write the meta of multiple combinations of the matrix, please select one with `matrix`",
+			},
+		}
+		for name, tt := range testCases {
+			t.Run(name, func(t *testing.T) {
+				root := newBuildCmd()
+				root.SetArgs(tt.args)
+				root.SetOut(bytes.NewBuffer(nil))
+				err := root.Execute()
+				assert.Equal(t, tt.expected, err.Error())
+			})
+		}
+	})
+
+	t.Run("Success build cmd with sd-cmd", func(t *testing.T) {
+		defLaunchNew := launchNew
+		defer func() {
+			launchNew = defLaunchNew
+		}()
+		var option launch.Option
+		launchNew = func(o launch.Option) launch.Launcher {
+			option = o
+			return mockLaunch{}
+		}
+
+		root := newBuildCmd()
+		root.SetArgs([]string{"main", "--pipeline-id", "456"})
+		root.SetOut(bytes.NewBuffer(nil))
+		err := root.Execute()
+		assert.Nil(t, err)
+		assert.True(t, strings.HasSuffix(option.CommandsDir, filepath.Join(".sdlocal", "cache", "commands")))
+	})
+
+	t.Run("Success build cmd without sd-cmd", func(t *testing.T) {
+		defLaunchNew := launchNew
+		defer func() {
+			launchNew = defLaunchNew
+		}()
+		var option launch.Option
+		launchNew = func(o launch.Option) launch.Launcher {
+			option = o
+			return mockLaunch{}
+		}
+
+		root := newBuildCmd()
+		root.SetArgs([]string{"test"})
+		root.SetOut(bytes.NewBuffer(nil))
+		err := root.Execute()
+		assert.Nil(t, err)
+		assert.Equal(t, "", option.CommandsDir)
+	})
+
+	t.Run("Failed build cmd with sd-cmd", func(t *testing.T) {
+		root := newBuildCmd()
+		root.SetArgs([]string{"main", "--pipeline-id", "457"})
+		root.SetOut(bytes.NewBuffer(nil))
+		err := root.Execute()
+		assert.Equal(t, "failed to resolve command foo/private@1.0.0: not authorized to get command foo/private@1.0.0: StatusCode 403", err.Error())
+	})
+
+	t.Run("Success build cmd with --cache-dir", func(t *testing.T) {
+		defLaunchNew := launchNew
+		defer func() {
+			launchNew = defLaunchNew
+		}()
+		var option launch.Option
+		launchNew = func(o launch.Option) launch.Launcher {
+			option = o
+			return mockLaunch{}
+		}
+		dir, err := ioutil.TempDir("", "cache")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+		cacheDir := filepath.Join(dir, "build")
+
+		root := newBuildCmd()
+		root.SetArgs([]string{"test", "--cache-dir", cacheDir})
+		root.SetOut(bytes.NewBuffer(nil))
+		err = root.Execute()
+		assert.Nil(t, err)
+		assert.Equal(t, cacheDir, option.CacheDir)
+		_, err = os.Stat(filepath.Join(cacheDir, cacheMarkerFile))
+		assert.Nil(t, err)
+	})
+
+	t.Run("Failed build cmd with --cache-dir by k8s", func(t *testing.T) {
+		root := newBuildCmd()
+		root.SetArgs([]string{"test", "--cache-dir", "/tmp/cache", "--runtime", "k8s"})
+		root.SetOut(bytes.NewBuffer(nil))
+		err := root.Execute()
+		assert.Equal(t, "runtime k8s does not support `cache-dir`", err.Error())
+	})
+
+	t.Run("Success build cmd with --docker-host", func(t *testing.T) {
+		defConfigNew := configNew
+		defer func() { configNew = defConfigNew }()
+		configNew = func(confPath string) (config.Config, error) {
+			c, err := defConfigNew(confPath)
+			c.Entries["default"].DockerHost = "unix:///var/run/docker.sock"
+			return c, err
+		}
+		home, err := homedir.Dir()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for expected, args := range map[string][]string{
+			"unix:///var/run/docker.sock":                             {"test"},
+			"tcp://192.168.1.10:2376":                                 {"test", "--docker-host", "tcp://192.168.1.10:2376"},
+			"unix://" + filepath.Join(home, ".colima", "docker.sock"): {"test", "--docker-host", "unix://~/.colima/docker.sock"},
+		} {
+			var dockerHost string
+			launchNew = func(option launch.Option) launch.Launcher {
+				dockerHost = option.Entry.DockerHost
+				return mockLaunch{}
+			}
+
+			root := newBuildCmd()
+			root.SetArgs(args)
+			root.SetOut(bytes.NewBuffer(nil))
+			err := root.Execute()
+			assert.Nil(t, err)
+			assert.Equal(t, expected, dockerHost)
+		}
+	})
+
+	t.Run("Failed build cmd with invalid docker host", func(t *testing.T) {
+		root := newBuildCmd()
+		root.SetArgs([]string{"test", "--docker-host", "docker.sock"})
+		root.SetOut(bytes.NewBuffer(nil))
+
+		err := root.Execute()
+		assert.Equal(t, "invalid docker-host docker.sock: must be a URL whose scheme is one of unix, tcp, ssh, npipe, or a path of a unix socket", err.Error())
+	})
+
+	t.Run("Failed build cmd with invalid platform", func(t *testing.T) {
+		root := newBuildCmd()
+		root.SetArgs([]string{"test", "--platform", "arm64"})
+		buf := bytes.NewBuffer(nil)
+		root.SetOut(buf)
+
+		err := root.Execute()
+		assert.Equal(t, "invalid platform arm64: must be in the form of os/arch[/variant] like linux/arm64", err.Error())
+	})
+
+	t.Run("Success build cmd with multiple jobs", func(t *testing.T) {
+		defLaunchNew := launchNew
+		defer func() {
+			launchNew = defLaunchNew
+		}()
+
+		var mutex sync.Mutex
+		launched := map[string]string{}
+		launchNew = func(option launch.Option) launch.Launcher {
+			mutex.Lock()
+			defer mutex.Unlock()
+			launched[option.JobName] = option.ArtifactsPath
+			return mockLaunch{}
+		}
+
+		root := newBuildCmd()
+		root.SetArgs([]string{"publish", "test", "lint", "--max-parallel", "2"})
+		buf := bytes.NewBuffer(nil)
+		root.SetOut(buf)
+		err := root.Execute()
+		assert.Nil(t, err)
+
+		artifactsPath, _ := filepath.Abs("sd-artifacts")
+		assert.Equal(t, map[string]string{
+			"publish": filepath.Join(artifactsPath, "publish"),
+			"test":    filepath.Join(artifactsPath, "test"),
+			"lint":    filepath.Join(artifactsPath, "lint"),
+		}, launched)
+	})
+
+	t.Run("Failed build cmd with job that does not exist", func(t *testing.T) {
+		root := newBuildCmd()
+		root.SetArgs([]string{"test", "main"})
+		buf := bytes.NewBuffer(nil)
+		root.SetOut(buf)
+		err := root.Execute()
+		assert.Equal(t, "not found 'main' in parsed screwdriver.yaml", err.Error())
+	})
+
+	t.Run("Failed build cmd with multiple jobs in interactive mode", func(t *testing.T) {
+		root := newBuildCmd()
+		root.SetArgs([]string{"test", "lint", "-i"})
+		buf := bytes.NewBuffer(nil)
+		root.SetOut(buf)
+		err := root.Execute()
+		assert.Equal(t, "can't run multiple jobs in interactive mode, please specify only one job", err.Error())
+	})
+
+	t.Run("Failed build cmd with invalid max-parallel", func(t *testing.T) {
+		root := newBuildCmd()
+		root.SetArgs([]string{"test", "--max-parallel", "0"})
+		buf := bytes.NewBuffer(nil)
+		root.SetOut(buf)
+		err := root.Execute()
+		assert.Equal(t, "max-parallel must be a positive integer: 0", err.Error())
+	})
+
+	t.Run("Success build cmd with steps", func(t *testing.T) {
+		defLaunchNew := launchNew
+		defer func() {
+			launchNew = defLaunchNew
+		}()
+
+		var steps []screwdriver.Step
+		launchNew = func(option launch.Option) launch.Launcher {
+			steps = option.Job.Steps
+			return mockLaunch{}
+		}
+
+		root := newBuildCmd()
+		root.SetArgs([]string{"test", "--step", "test"})
+		buf := bytes.NewBuffer(nil)
+		root.SetOut(buf)
+		err := root.Execute()
+		assert.Nil(t, err)
+		assert.Equal(t, []screwdriver.Step{{Name: "test", Command: "npm test"}}, steps)
+	})
+
+	t.Run("Success build cmd with image", func(t *testing.T) {
+		defLaunchNew := launchNew
+		defer func() {
+			launchNew = defLaunchNew
+		}()
+
+		images := make(map[string]string)
+		var mutex sync.Mutex
+		launchNew = func(option launch.Option) launch.Launcher {
+			mutex.Lock()
+			defer mutex.Unlock()
+			images[option.JobName] = option.Job.Image
+			return mockLaunch{}
+		}
+
+		root := newBuildCmd()
+		root.SetArgs([]string{"test", "lint", "--image", "node:20"})
+		buf := bytes.NewBuffer(nil)
+		root.SetOut(buf)
+		err := root.Execute()
+		assert.Nil(t, err)
+		assert.Equal(t, map[string]string{"test": "node:20", "lint": "node:20"}, images)
+	})
+
+	t.Run("Success build cmd with no teardown", func(t *testing.T) {
+		defLaunchNew := launchNew
+		defer func() {
+			launchNew = defLaunchNew
+		}()
+
+		var noTeardown bool
+		launchNew = func(option launch.Option) launch.Launcher {
+			noTeardown = option.NoTeardown
+			return mockLaunch{}
+		}
+
+		root := newBuildCmd()
+		root.SetArgs([]string{"test", "--no-teardown"})
+		buf := bytes.NewBuffer(nil)
+		root.SetOut(buf)
+		err := root.Execute()
+		assert.Nil(t, err)
+		assert.True(t, noTeardown)
+	})
+
+	t.Run("Success build cmd with --fail-fast=false", func(t *testing.T) {
+		defLaunchNew := launchNew
+		defer func() {
+			launchNew = defLaunchNew
+		}()
+
+		var noFailFast bool
+		launchNew = func(option launch.Option) launch.Launcher {
+			noFailFast = option.NoFailFast
+			return mockLaunch{}
+		}
+
+		root := newBuildCmd()
+		root.SetArgs([]string{"test"})
+		root.SetOut(bytes.NewBuffer(nil))
+		err := root.Execute()
+		assert.Nil(t, err)
+		assert.False(t, noFailFast)
+
+		root = newBuildCmd()
+		root.SetArgs([]string{"test", "--fail-fast=false"})
+		root.SetOut(bytes.NewBuffer(nil))
+		err = root.Execute()
+		assert.Nil(t, err)
+		assert.True(t, noFailFast)
+	})
+
+	t.Run("Success build cmd with --on-complete", func(t *testing.T) {
+		defRunHook := runHook
+		defer func() {
+			runHook = defRunHook
+		}()
+
+		var commands []string
+		var hookEnv []string
+		runHook = func(command string, env []string, out io.Writer) error {
+			commands = append(commands, command)
+			hookEnv = env
+			return nil
+		}
+
+		root := newBuildCmd()
+		root.SetArgs([]string{"test", "--on-complete", "notify-send done", "--on-failure", "notify-send failed"})
+		root.SetOut(bytes.NewBuffer(nil))
+		err := root.Execute()
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"notify-send done"}, commands)
+		assert.Contains(t, hookEnv, "SD_LOCAL_JOB=test")
+		assert.Contains(t, hookEnv, "SD_LOCAL_STATUS=success")
+	})
+
+	t.Run("Failed build cmd with the runtime not running", func(t *testing.T) {
+		defCheckBuildRuntime := checkBuildRuntime
+		defer func() {
+			checkBuildRuntime = defCheckBuildRuntime
+		}()
+
+		var checked []interface{}
+		checkBuildRuntime = func(runtime string, useSudo bool, dockerHost string) error {
+			checked = []interface{}{runtime, useSudo, dockerHost}
+			return errors.New("Docker does not appear to be running; start it and retry")
+		}
+
+		root := newBuildCmd()
+		root.SetArgs([]string{"test", "--sudo", "--docker-host", "tcp://remote:2376"})
+		root.SetOut(bytes.NewBuffer(nil))
+		err := root.Execute()
+		assert.Equal(t, "Docker does not appear to be running; start it and retry", err.Error())
+		assert.Equal(t, []interface{}{"", true, "tcp://remote:2376"}, checked)
+
+		checked = nil
+		root = newBuildCmd()
+		root.SetArgs([]string{"test", "--dry-run"})
+		root.SetOut(bytes.NewBuffer(nil))
+		_ = root.Execute()
+		assert.Nil(t, checked)
+	})
+
+	t.Run("Success build cmd with log file", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "sd-local")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+		logFile := filepath.Join(dir, "build.log")
+
+		root := newBuildCmd()
+		root.SetArgs([]string{"test", "--log-file", logFile, "--quiet"})
+		buf := bytes.NewBuffer(nil)
+		root.SetOut(buf)
+		err = root.Execute()
+		assert.Nil(t, err)
+
+		b, err := ioutil.ReadFile(logFile)
+		assert.Nil(t, err)
+		assert.Contains(t, string(b), "Prepare to start build of test...")
+		assert.NotContains(t, string(b), "\x1b[")
+		assert.Equal(t, 0, len(logrus.StandardLogger().Hooks))
+	})
+
+	t.Run("Failed build cmd with log file that can't be opened", func(t *testing.T) {
+		root := newBuildCmd()
+		root.SetArgs([]string{"test", "--log-file", "not-exist/build.log"})
+		buf := bytes.NewBuffer(nil)
+		root.SetOut(buf)
+		err := root.Execute()
+		assert.Equal(t, "failed to open log file not-exist/build.log: open not-exist/build.log: no such file or directory", err.Error())
+	})
+
+	t.Run("Success build cmd with json events", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "sd-local")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+		eventsFile := filepath.Join(dir, "events.jsonl")
+
+		root := newBuildCmd()
+		root.SetArgs([]string{"test", "--json-events", eventsFile})
+		buf := bytes.NewBuffer(nil)
+		root.SetOut(buf)
+		err = root.Execute()
+		assert.Nil(t, err)
+
+		b, err := ioutil.ReadFile(eventsFile)
+		assert.Nil(t, err)
+		assert.Contains(t, string(b), `"type":"build-started","time":`)
+		assert.Contains(t, string(b), `"type":"build-finished","time":`)
+		assert.NotContains(t, buf.String(), "build-started")
+	})
+
+	t.Run("Failed build cmd with json events file that can't be opened", func(t *testing.T) {
+		root := newBuildCmd()
+		root.SetArgs([]string{"test", "--json-events", "not-exist/events.jsonl"})
+		buf := bytes.NewBuffer(nil)
+		root.SetOut(buf)
+		err := root.Execute()
+		assert.Equal(t, "failed to open json events file not-exist/events.jsonl: open not-exist/events.jsonl: no such file or directory", err.Error())
+	})
+
+	t.Run("Success build cmd with colors", func(t *testing.T) {
+		defBuildLogNew := buildLogNew
+		defIsTerminal := isTerminal
+		defer func() {
+			buildLogNew = defBuildLogNew
+			isTerminal = defIsTerminal
+		}()
+
+		var color bool
+		buildLogNew = func(filepath string, writer io.Writer, done chan<- struct{}, c bool) (buildlog.Logger, error) {
+			color = c
+			return mockLogger{done: done}, nil
+		}
+
+		cases := []struct {
+			name     string
+			args     []string
+			terminal bool
+			expect   bool
+		}{
+			{"terminal", []string{"test"}, true, true},
+			{"no color", []string{"test", "--no-color"}, true, false},
+			{"not terminal", []string{"test"}, false, false},
+		}
+
+		for _, c := range cases {
+			isTerminal = func(fd int) bool { return c.terminal }
+
+			root := newBuildCmd()
+			root.SetArgs(c.args)
+			buf := bytes.NewBuffer(nil)
+			root.SetOut(buf)
+			err := root.Execute()
+			assert.Nil(t, err)
+			assert.Equal(t, c.expect, color, c.name)
+		}
+	})
+
+	t.Run("Success build cmd with timing summary", func(t *testing.T) {
+		defBuildLogNew := buildLogNew
+		defer func() {
+			buildLogNew = defBuildLogNew
+		}()
+
+		buildLogNew = func(filepath string, writer io.Writer, done chan<- struct{}, color bool) (buildlog.Logger, error) {
+			return mockLogger{done: done, timings: []buildlog.StepTiming{
+				{Name: "install", Duration: 2 * time.Second},
+				{Name: "test", Duration: 3 * time.Second},
+			}}, nil
+		}
+
+		root := newBuildCmd()
+		root.SetArgs([]string{"test", "-o", "json"})
+		buf := bytes.NewBuffer(nil)
+		root.SetOut(buf)
+		err := root.Execute()
+		assert.Nil(t, err)
+		assert.Contains(t, buf.String(), `"totalSeconds": 5`)
+	})
+
+	t.Run("Failed build cmd with invalid output format", func(t *testing.T) {
+		root := newBuildCmd()
+		root.SetArgs([]string{"test", "-o", "yaml"})
+		buf := bytes.NewBuffer(nil)
+		root.SetOut(buf)
+		err := root.Execute()
+		assert.Equal(t, "invalid output format yaml: only json is supported", err.Error())
+	})
+
+	t.Run("Success build cmd with --log-format", func(t *testing.T) {
+		defBuildLogNew := buildLogNew
+		defer func() {
+			buildLogNew = defBuildLogNew
+		}()
+
+		buildLogNew = func(filepath string, writer io.Writer, done chan<- struct{}, color bool) (buildlog.Logger, error) {
+			_, ok := writer.(buildlog.Formatter)
+			assert.True(t, ok)
+			return mockLogger{done: done, timings: []buildlog.StepTiming{
+				{Name: "test", Duration: 3 * time.Second},
+			}}, nil
+		}
+
+		root := newBuildCmd()
+		root.SetArgs([]string{"test", "--log-format", "tap"})
+		buf := bytes.NewBuffer(nil)
+		root.SetOut(buf)
+		err := root.Execute()
+		assert.Nil(t, err)
+		assert.NotContains(t, buf.String(), "TOTAL")
+	})
+
+	t.Run("Failed build cmd with --log-format", func(t *testing.T) {
+		testCases := map[string]struct {
+			args     []string
+			expected string
+		}{
+			"invalid format": {
+				[]string{"test", "--log-format", "xml"},
+				"invalid log format xml: must be one of plain, json or tap",
+			},
+			"interactive": {
+				[]string{"test", "--log-format", "json", "-i"},
+				"can't print the build logs in json in interactive mode",
+			},
+		}
+		for name, tt := range testCases {
+			t.Run(name, func(t *testing.T) {
+				defer func() {
+					interactiveMode = false
+				}()
+				root := newBuildCmd()
+				root.SetArgs(tt.args)
+				root.SetOut(bytes.NewBuffer(nil))
+				err := root.Execute()
+				assert.Equal(t, tt.expected, err.Error())
+			})
+		}
+	})
+
+	t.Run("Success build cmd with report", func(t *testing.T) {
+		defBuildLogNew := buildLogNew
+		defLaunchNew := launchNew
+		defer func() {
+			buildLogNew = defBuildLogNew
+			launchNew = defLaunchNew
+		}()
+		launchNew = func(option launch.Option) launch.Launcher {
+			return mockLaunch{}
+		}
+		buildLogNew = func(filepath string, writer io.Writer, done chan<- struct{}, color bool) (buildlog.Logger, error) {
+			return mockLogger{done: done, timings: []buildlog.StepTiming{
+				{Name: "install", Duration: 2 * time.Second, Status: buildlog.StepSucceeded},
+				{Name: "test", Duration: time.Second, Status: buildlog.StepSucceeded},
+			}}, nil
+		}
+
+		dir, err := ioutil.TempDir("", "report")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+		reportPath := filepath.Join(dir, "report.json")
+
+		root := newBuildCmd()
+		root.SetArgs([]string{"test", "--report", "json=" + reportPath})
+		buf := bytes.NewBuffer(nil)
+		root.SetOut(buf)
+		err = root.Execute()
+		assert.Nil(t, err)
+
+		b, err := ioutil.ReadFile(reportPath)
+		assert.Nil(t, err)
+		var report testReport
+		assert.Nil(t, json.Unmarshal(b, &report))
+		assert.Equal(t, 1, report.SchemaVersion)
+		assert.Equal(t, "succeeded", report.Status)
+		assert.Equal(t, 1, len(report.Jobs))
+		assert.Equal(t, "test", report.Jobs[0].Name)
+		assert.Equal(t, "succeeded", report.Jobs[0].Status)
+		assert.Equal(t, float64(3), report.Jobs[0].Seconds)
+		assert.Equal(t, []testStepReport{
+			{Name: "install", Status: buildlog.StepSucceeded, Seconds: 2},
+			{Name: "test", Status: buildlog.StepSucceeded, Seconds: 1},
+		}, report.Jobs[0].Steps)
+	})
+
+	t.Run("Failed build cmd with report", func(t *testing.T) {
+		defLaunchNew := launchNew
+		defer func() {
+			launchNew = defLaunchNew
+		}()
+		launchNew = func(option launch.Option) launch.Launcher {
+			return failLaunch{err: errors.New("build failed")}
+		}
+
+		dir, err := ioutil.TempDir("", "report")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+		reportPath := filepath.Join(dir, "report.json")
+
+		root := newBuildCmd()
+		root.SetArgs([]string{"test", "--report", "json=" + reportPath})
+		buf := bytes.NewBuffer(nil)
+		root.SetOut(buf)
+		err = root.Execute()
+		assert.NotNil(t, err)
+
+		b, err := ioutil.ReadFile(reportPath)
+		assert.Nil(t, err)
+		var report testReport
+		assert.Nil(t, json.Unmarshal(b, &report))
+		assert.Equal(t, "failed", report.Status)
+		assert.Equal(t, "failed", report.Jobs[0].Status)
+		assert.Contains(t, report.Jobs[0].Error, "build failed")
+	})
+
+	t.Run("Failed build cmd with invalid report", func(t *testing.T) {
+		root := newBuildCmd()
+		root.SetArgs([]string{"test", "--report", "yaml=report.yaml"})
+		buf := bytes.NewBuffer(nil)
+		root.SetOut(buf)
+		err := root.Execute()
+		assert.Equal(t, "invalid report yaml=report.yaml: must be like json=<path>", err.Error())
+	})
+
+	t.Run("Success build cmd with resume", func(t *testing.T) {
+		defBuildLogNew := buildLogNew
+		defLaunchNew := launchNew
+		defMkdirAll := osMkdirAll
+		defRevision := sourceRevision
+		defer func() {
+			buildLogNew = defBuildLogNew
+			launchNew = defLaunchNew
+			osMkdirAll = defMkdirAll
+			sourceRevision = defRevision
+		}()
+		osMkdirAll = os.MkdirAll
+		sourceRevision = func(srcPath string) (string, error) { return "rev", nil }
+		buildLogNew = func(filepath string, writer io.Writer, done chan<- struct{}, color bool) (buildlog.Logger, error) {
+			return mockLogger{done: done, timings: []buildlog.StepTiming{
+				{Name: "test", Duration: time.Second, Status: buildlog.StepSucceeded},
+			}}, nil
+		}
+
+		dir, err := ioutil.TempDir("", "artifacts")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+
+		var option launch.Option
+		launchNew = func(o launch.Option) launch.Launcher {
+			option = o
+			return failLaunch{err: errors.New("build failed")}
+		}
+		buf := bytes.NewBuffer(nil)
+
+		// the state of the failed build is persisted to resume it
+		root := newBuildCmd()
+		root.SetArgs([]string{"test", "--artifacts-dir", dir})
+		root.SetOut(buf)
+		assert.NotNil(t, root.Execute())
+		state, err := build.ReadState(dir)
+		assert.Nil(t, err)
+		fingerprint := state.Fingerprint
+		state.SucceededSteps = []string{"install"}
+		state.Container = "cid"
+		assert.Nil(t, build.WriteState(dir, *state))
+
+		launchNew = func(o launch.Option) launch.Launcher {
+			option = o
+			return mockLaunch{}
+		}
+		root = newBuildCmd()
+		root.SetArgs([]string{"test", "--resume", "--artifacts-dir", dir})
+		root.SetOut(buf)
+		err = root.Execute()
+		assert.Nil(t, err)
+		assert.Equal(t, []screwdriver.Step{{Name: "test", Command: "npm test"}}, option.Job.Steps)
+		assert.Equal(t, "cid", option.ResumeContainer)
+
+		state, err = build.ReadState(dir)
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"install", "test"}, state.SucceededSteps)
+		assert.Equal(t, fingerprint, state.Fingerprint)
+		assert.Empty(t, state.Container)
+
+		// the build which already succeeded is skipped
+		launched := false
+		launchNew = func(o launch.Option) launch.Launcher {
+			launched = true
+			return mockLaunch{}
+		}
+		root = newBuildCmd()
+		root.SetArgs([]string{"test", "--resume", "--artifacts-dir", dir})
+		root.SetOut(buf)
+		err = root.Execute()
+		assert.Nil(t, err)
+		assert.False(t, launched)
+
+		// the build starts fresh if the source code changed
+		sourceRevision = func(srcPath string) (string, error) { return "changed", nil }
+		launchNew = func(o launch.Option) launch.Launcher {
+			option = o
+			return mockLaunch{}
+		}
+		root = newBuildCmd()
+		root.SetArgs([]string{"test", "--resume", "--artifacts-dir", dir})
+		root.SetOut(buf)
+		err = root.Execute()
+		assert.Nil(t, err)
+		jobs, _ := mockAPI{}.Jobs("")
+		assert.Equal(t, jobs["test"].Steps, option.Job.Steps)
+		assert.Empty(t, option.ResumeContainer)
+	})
+
+	t.Run("Failed build cmd with resume and invalid options", func(t *testing.T) {
+		testCases := map[string]struct {
+			args     []string
+			expected string
+		}{
+			"step":               {[]string{"test", "--resume", "--step", "test"}, "can't pass the both options `resume` and `step`, the steps to run are decided by the previous build"},
+			"interactive":        {[]string{"test", "--resume", "-i"}, "can't resume the build in interactive mode"},
+			"no-local-artifacts": {[]string{"test", "--resume", "--artifacts-s3", "s3://bucket", "--no-local-artifacts"}, "can't pass the both options `resume` and `no-local-artifacts`, the state of the build is kept with the artifacts"},
+		}
+		for name, tt := range testCases {
+			t.Run(name, func(t *testing.T) {
+				defer func() {
+					interactiveMode = false
+				}()
+				root := newBuildCmd()
+				root.SetArgs(tt.args)
+				buf := bytes.NewBuffer(nil)
+				root.SetOut(buf)
+				err := root.Execute()
+				assert.Equal(t, tt.expected, err.Error())
+			})
+		}
+	})
+
+	t.Run("Failed build cmd with watch and invalid options", func(t *testing.T) {
+		testCases := map[string]struct {
+			args     []string
+			expected string
+		}{
+			"interactive": {[]string{"test", "--watch", "-i"}, "can't watch the source code in interactive mode"},
+			"dry-run":     {[]string{"test", "--watch", "--dry-run"}, "can't pass the both options `watch` and `dry-run`"},
+			"src-url":     {[]string{"test", "--watch", "--src-url", "git@github.com:org/repo.git"}, "can't watch the source code pulled by `src-url`, please specify the local directory"},
+		}
+		for name, tt := range testCases {
+			t.Run(name, func(t *testing.T) {
+				defer func() {
+					interactiveMode = false
+				}()
+				root := newBuildCmd()
+				root.SetArgs(tt.args)
+				buf := bytes.NewBuffer(nil)
+				root.SetOut(buf)
+				err := root.Execute()
+				assert.Equal(t, tt.expected, err.Error())
+			})
+		}
+	})
+
+	t.Run("Success build cmd with dry run", func(t *testing.T) {
+		defLaunchNew := launchNew
+		defer func() {
+			launchNew = defLaunchNew
+		}()
+		var options []launch.Option
+		launchNew = func(option launch.Option) launch.Launcher {
+			options = append(options, option)
+			return planLaunch{plan: launch.Plan{Image: "node:12", Steps: option.Job.Steps}}
+		}
+
+		root := newBuildCmd()
+		root.SetArgs([]string{"test", "lint", "--dry-run"})
+		buf := bytes.NewBuffer(nil)
+		root.SetOut(buf)
+		err := root.Execute()
+		assert.Nil(t, err)
+		assert.Equal(t, 2, len(options))
+		artifactsPath, _ := filepath.Abs("sd-artifacts")
+		assert.Equal(t, filepath.Join(artifactsPath, options[0].JobName), options[0].ArtifactsPath)
+		assert.Contains(t, buf.String(), "Job: test\n  Image: node:12\n  Runtime: docker\n")
+		assert.Contains(t, buf.String(), "    install:\n      npm install\n    test:\n      npm test\n")
+	})
+
+	t.Run("Failed build cmd with dry run and resume", func(t *testing.T) {
+		root := newBuildCmd()
+		root.SetArgs([]string{"test", "--dry-run", "--resume"})
+		buf := bytes.NewBuffer(nil)
+		root.SetOut(buf)
+		err := root.Execute()
+		assert.Equal(t, "can't pass the both options `dry-run` and `resume`", err.Error())
+	})
+
+	t.Run("Success build cmd with --src-dir", func(t *testing.T) {
+		defLaunchNew := launchNew
+		defer func() {
+			launchNew = defLaunchNew
+		}()
+
+		dir, err := ioutil.TempDir("", "src")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+		_ = os.MkdirAll(filepath.Join(dir, "node_modules"), 0777)
+		_ = ioutil.WriteFile(filepath.Join(dir, ".gitignore"), []byte("node_modules/\n"), 0666)
+		_ = ioutil.WriteFile(filepath.Join(dir, ".sdignore"), []byte("*.log\n"), 0666)
+		_ = ioutil.WriteFile(filepath.Join(dir, "debug.log"), []byte(""), 0666)
+
+		testCases := map[string]struct {
+			args    []string
+			ignored []string
+		}{
+			"src-dir":           {[]string{"test", "--src-dir", dir}, []string{"debug.log", "node_modules/"}},
+			"local src-url":     {[]string{"test", "--src-url", dir}, []string{"debug.log", "node_modules/"}},
+			"src-dir no-ignore": {[]string{"test", "--src-dir", dir, "--no-ignore"}, nil},
+		}
+		for name, tt := range testCases {
+			t.Run(name, func(t *testing.T) {
+				var option launch.Option
+				launchNew = func(o launch.Option) launch.Launcher {
+					option = o
+					return mockLaunch{}
+				}
+
+				root := newBuildCmd()
+				root.SetArgs(tt.args)
+				buf := bytes.NewBuffer(nil)
+				root.SetOut(buf)
+				err := root.Execute()
+				assert.Nil(t, err)
+				assert.Equal(t, dir, option.SrcPath)
+				assert.Equal(t, tt.ignored, option.IgnoredPaths)
+			})
+		}
+	})
+
+	t.Run("Success build cmd with .sdignore", func(t *testing.T) {
+		defLaunchNew := launchNew
+		cwd, _ := os.Getwd()
+		defer func() {
+			launchNew = defLaunchNew
+			_ = os.Chdir(cwd)
+		}()
+
+		dir, err := ioutil.TempDir("", "src")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+		_ = os.MkdirAll(filepath.Join(dir, "node_modules"), 0777)
+		_ = os.MkdirAll(filepath.Join(dir, "dist"), 0777)
+		_ = ioutil.WriteFile(filepath.Join(dir, ".gitignore"), []byte("dist/\n"), 0666)
+		_ = os.Chdir(dir)
+
+		var option launch.Option
+		launchNew = func(o launch.Option) launch.Launcher {
+			option = o
+			return mockLaunch{}
+		}
+
+		// nothing is ignored without .sdignore
+		root := newBuildCmd()
+		root.SetArgs([]string{"test"})
+		buf := bytes.NewBuffer(nil)
+		root.SetOut(buf)
+		err = root.Execute()
+		assert.Nil(t, err)
+		assert.Equal(t, []string{}, option.IgnoredPaths)
+
+		_ = ioutil.WriteFile(filepath.Join(dir, ".sdignore"), []byte("node_modules\n"), 0666)
+
+		logBuf := bytes.NewBuffer(nil)
+		logrus.SetOutput(logBuf)
+		defer logrus.SetOutput(os.Stderr)
+
+		root = newBuildCmd()
+		root.SetArgs([]string{"test", "--print-ignored"})
+		root.SetOut(buf)
+		err = root.Execute()
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"node_modules/"}, option.IgnoredPaths)
+		assert.Contains(t, logBuf.String(), "Ignored node_modules/")
+	})
+
+	t.Run("Failed build cmd with --src-dir", func(t *testing.T) {
+		root := newBuildCmd()
+		root.SetArgs([]string{"test", "--src-dir", "./testdata/test_env"})
+		buf := bytes.NewBuffer(nil)
+		root.SetOut(buf)
+		err := root.Execute()
+		assert.Equal(t, "source directory ./testdata/test_env is not a directory", err.Error())
+
+		root = newBuildCmd()
+		root.SetArgs([]string{"test", "--src-dir", "./testdata", "--src-url", "git@github.com:org/repo.git"})
+		root.SetOut(buf)
+		err = root.Execute()
+		assert.Equal(t, "can't pass the both options `src-url` and `src-dir`, please specify only one of them", err.Error())
+	})
+
+	t.Run("Success build cmd with --file", func(t *testing.T) {
+		defExpandYAMLFile := expandYAMLFile
+		defer func() { expandYAMLFile = defExpandYAMLFile }()
+		cwd, err := os.Getwd()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, flag := range []string{"--file", "-f"} {
+			var sdYAMLPath string
+			expandYAMLFile = func(path string, env map[string]string) (string, error) {
+				sdYAMLPath = path
+				return path, nil
+			}
+
+			root := newBuildCmd()
+			root.SetArgs([]string{"test", flag, "testdata/test_env"})
+			root.SetOut(bytes.NewBuffer(nil))
+			err := root.Execute()
+			assert.Nil(t, err)
+			assert.Equal(t, filepath.Join(cwd, "testdata", "test_env"), sdYAMLPath)
+		}
+	})
+
+	t.Run("Failed build cmd with --file that does not exist", func(t *testing.T) {
+		root := newBuildCmd()
+		root.SetArgs([]string{"test", "--file", "ci/screwdriver.yaml"})
+		root.SetOut(bytes.NewBuffer(nil))
+		err := root.Execute()
+		assert.Equal(t, "pipeline file ci/screwdriver.yaml does not exist", err.Error())
+	})
+
+	t.Run("Success build cmd with the job of the path", func(t *testing.T) {
+		defExpandYAMLFile := expandYAMLFile
+		defer func() { expandYAMLFile = defExpandYAMLFile }()
+		cwd, err := os.Getwd()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var sdYAMLPath string
+		expandYAMLFile = func(path string, env map[string]string) (string, error) {
+			sdYAMLPath = path
+			return path, nil
+		}
+		var jobName string
+		launchNew = func(option launch.Option) launch.Launcher {
+			jobName = option.JobName
+			return mockLaunch{}
+		}
+
+		root := newBuildCmd()
+		root.SetArgs([]string{"testdata/test_env::test"})
+		root.SetOut(bytes.NewBuffer(nil))
+		err = root.Execute()
+		assert.Nil(t, err)
+		assert.Equal(t, filepath.Join(cwd, "testdata", "test_env"), sdYAMLPath)
+		assert.Equal(t, "test", jobName)
+	})
+
+	t.Run("Failed build cmd with the job not found in the path", func(t *testing.T) {
+		root := newBuildCmd()
+		root.SetArgs([]string{"testdata/test_env::deploy"})
+		root.SetOut(bytes.NewBuffer(nil))
+		err := root.Execute()
+		assert.Equal(t, "not found 'deploy' in testdata/test_env, the jobs in it are: lint, publish, test", err.Error())
+	})
+
+	t.Run("Failed build cmd with step that does not exist", func(t *testing.T) {
+		root := newBuildCmd()
+		root.SetArgs([]string{"test", "--step", "lint"})
+		buf := bytes.NewBuffer(nil)
+		root.SetOut(buf)
+		err := root.Execute()
+		assert.Equal(t, "not found step 'lint' in the job, valid steps are: install, test", err.Error())
+	})
+
+	t.Run("Failed build cmd with steps of multiple jobs", func(t *testing.T) {
+		root := newBuildCmd()
+		root.SetArgs([]string{"test", "lint", "--step", "test"})
+		buf := bytes.NewBuffer(nil)
+		root.SetOut(buf)
+		err := root.Execute()
+		assert.Equal(t, "can't select steps of multiple jobs, please specify only one job", err.Error())
+	})
+
+	t.Run("Failed build cmd by timeout", func(t *testing.T) {
+		defLaunchNew := launchNew
+		defCleaners := cleaners
+		defer func() {
+			launchNew = defLaunchNew
+			cleaners = defCleaners
+		}()
+
+		hang := &hangLaunch{stop: make(chan struct{})}
+		launchNew = func(option launch.Option) launch.Launcher {
+			return hang
+		}
+
+		root := newBuildCmd()
+		root.SetArgs([]string{"test", "--timeout", "100ms"})
+		buf := bytes.NewBuffer(nil)
+		root.SetOut(buf)
+		err := root.Execute()
+		assert.Equal(t, "build timed out after 100ms", err.Error())
+		assert.Equal(t, syscall.SIGTERM, hang.signal)
+	})
+
+	t.Run("Success build cmd without leaving the finished runner to be killed", func(t *testing.T) {
+		defCleaners := cleaners
+		defer func() { cleaners = defCleaners }()
+		cleaners = make([]Cleaner, 0, 2)
+
+		// the builds are run repeatedly by --watch
+		for i := 0; i < 2; i++ {
+			root := newBuildCmd()
+			root.SetArgs([]string{"test"})
+			root.SetOut(bytes.NewBuffer(nil))
+			assert.Nil(t, root.Execute())
+		}
+		assert.Equal(t, 0, len(cleaners))
+	})
+
+	t.Run("Failed build cmd with negative timeout", func(t *testing.T) {
+		root := newBuildCmd()
+		root.SetArgs([]string{"test", "--timeout", "-1m"})
+		buf := bytes.NewBuffer(nil)
+		root.SetOut(buf)
+		err := root.Execute()
+		assert.Equal(t, "timeout must not be negative: -1m0s", err.Error())
+	})
+
+	t.Run("Failed build cmd with negative retries", func(t *testing.T) {
+		root := newBuildCmd()
+		root.SetArgs([]string{"test", "--retries", "-1"})
+		buf := bytes.NewBuffer(nil)
+		root.SetOut(buf)
+		err := root.Execute()
+		assert.Equal(t, "retries must not be negative: -1", err.Error())
+	})
+
+	t.Run("Failed build cmd with negative retry backoff", func(t *testing.T) {
+		root := newBuildCmd()
+		root.SetArgs([]string{"test", "--retry-backoff", "-1s"})
+		buf := bytes.NewBuffer(nil)
+		root.SetOut(buf)
+		err := root.Execute()
+		assert.Equal(t, "retry-backoff must not be negative: -1s", err.Error())
 	})
 
 	t.Run("Failed build cmd when too little args", func(t *testing.T) {
@@ -216,8 +2643,8 @@ func TestBuildCmd(t *testing.T) {
 		buf := bytes.NewBuffer(nil)
 		root.SetOut(buf)
 		err := root.Execute()
-		want := "Error: accepts 1 arg(s), received 0\n" +
-			"Usage:\n  build [job name] [flags]\n" +
+		want := "Error: requires at least 1 arg(s), only received 0\n" +
+			"Usage:\n  build [job name...] [flags]\n" +
 			buildLocalFlags()
 		assert.Equal(t, want, buf.String())
 		assert.NotNil(t, err)
@@ -235,6 +2662,9 @@ func TestBuildCmd(t *testing.T) {
 
 		configNew = func(confPath string) (config.Config, error) {
 			defaultEntry := &config.Entry{
+				APIURL:   "https://api.screwdriver.cd",
+				StoreURL: "https://store.screwdriver.cd",
+				Token:    "token",
 				Launcher: config.Launcher{
 					Version: "stable",
 					Image:   "screwdrivercd/launcher",
@@ -272,6 +2702,9 @@ Would you please cooperate with the survey? [y/N]: `
 
 		configNew = func(confPath string) (config.Config, error) {
 			defaultEntry := &config.Entry{
+				APIURL:   "https://api.screwdriver.cd",
+				StoreURL: "https://store.screwdriver.cd",
+				Token:    "token",
 				Launcher: config.Launcher{
 					Version: "stable",
 					Image:   "screwdrivercd/launcher",
@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/mitchellh/go-homedir"
+	"github.com/spf13/cobra"
+)
+
+// defaultCacheDir is the build cache used by --cache-dir without the path
+const defaultCacheDir = "~/.sdlocal/cache/build"
+
+// cacheMarkerFile marks the directory as the build cache not to clean the other directories by mistake
+const cacheMarkerFile = ".sdlocal-cache"
+
+// prepareCacheDir creates the build cache directory and returns its absolute path.
+// It is writable by everyone because the user of the build container may differ from the user of sd-local.
+func prepareCacheDir(dir string) (string, error) {
+	dir, err := homedir.Expand(dir)
+	if err != nil {
+		return "", err
+	}
+	dir, err = filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		if err := os.MkdirAll(dir, 0777); err != nil {
+			return "", fmt.Errorf("failed to create the build cache %s: %v", dir, err)
+		}
+		if err := os.Chmod(dir, 0777); err != nil {
+			return "", err
+		}
+	}
+
+	marker := filepath.Join(dir, cacheMarkerFile)
+	if _, err := os.Stat(marker); os.IsNotExist(err) {
+		if err := ioutil.WriteFile(marker, nil, 0666); err != nil {
+			return "", fmt.Errorf("failed to create the build cache %s: %v", dir, err)
+		}
+	}
+	return dir, nil
+}
+
+// cleanCacheDir removes everything in the build cache directory. It fails if the directory is not used as the build cache.
+func cleanCacheDir(dir string) (string, error) {
+	dir, err := homedir.Expand(dir)
+	if err != nil {
+		return "", err
+	}
+	dir, err = filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return "", fmt.Errorf("build cache %s does not exist", dir)
+	}
+	if _, err := os.Stat(filepath.Join(dir, cacheMarkerFile)); err != nil {
+		if os.IsNotExist(err) {
+			return "", fmt.Errorf("%s is not a build cache of sd-local", dir)
+		}
+		return "", err
+	}
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return "", err
+	}
+	for _, f := range files {
+		if f.Name() == cacheMarkerFile {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(dir, f.Name())); err != nil {
+			return "", fmt.Errorf("failed to clean the build cache: %v, the files created by root in the build container need to be removed with sudo", err)
+		}
+	}
+	return dir, nil
+}
+
+func newCacheCmd() *cobra.Command {
+	cacheCmd := &cobra.Command{
+		Use:   "cache",
+		Short: "Manage the build cache.",
+		Long: `Manage the build cache mounted by build --cache-dir.
+The build cache persists across the builds and the jobs, so point the caches of the tools at it
+like GOMODCACHE=$SD_LOCAL_CACHE_DIR/go/mod and npm_config_cache=$SD_LOCAL_CACHE_DIR/npm.`,
+	}
+	cacheCmd.AddCommand(newCacheCleanCmd())
+	return cacheCmd
+}
+
+func newCacheCleanCmd() *cobra.Command {
+	var cacheDir string
+
+	cleanCmd := &cobra.Command{
+		Use:   "clean",
+		Short: "Remove everything in the build cache.",
+		Long: `Remove everything in the build cache.
+Only the directory used by build --cache-dir can be cleaned.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+
+			dir, err := cleanCacheDir(cacheDir)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Cleaned the build cache %s\n", dir)
+			return nil
+		},
+	}
+
+	cleanCmd.Flags().StringVar(
+		&cacheDir,
+		"cache-dir",
+		defaultCacheDir,
+		"Path to the build cache to clean.")
+
+	return cleanCmd
+}
@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrepareCacheDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	t.Run("create the cache", func(t *testing.T) {
+		cacheDir := filepath.Join(dir, "a", "build")
+		actual, err := prepareCacheDir(cacheDir)
+		assert.Nil(t, err)
+		assert.Equal(t, cacheDir, actual)
+
+		info, err := os.Stat(cacheDir)
+		assert.Nil(t, err)
+		assert.Equal(t, os.FileMode(0777), info.Mode().Perm())
+		_, err = os.Stat(filepath.Join(cacheDir, cacheMarkerFile))
+		assert.Nil(t, err)
+	})
+
+	t.Run("existing directory", func(t *testing.T) {
+		cacheDir := filepath.Join(dir, "b")
+		if err := os.Mkdir(cacheDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		actual, err := prepareCacheDir(cacheDir)
+		assert.Nil(t, err)
+		assert.Equal(t, cacheDir, actual)
+		_, err = os.Stat(filepath.Join(cacheDir, cacheMarkerFile))
+		assert.Nil(t, err)
+	})
+}
+
+func TestCacheCleanCmd(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	t.Run("clean the cache", func(t *testing.T) {
+		cacheDir, err := prepareCacheDir(filepath.Join(dir, "build"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.MkdirAll(filepath.Join(cacheDir, "go", "mod"), 0777); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(cacheDir, "go", "mod", "a"), []byte("a"), 0666); err != nil {
+			t.Fatal(err)
+		}
+
+		out := bytes.NewBuffer(nil)
+		cmd := newCacheCmd()
+		cmd.SetArgs([]string{"clean", "--cache-dir", cacheDir})
+		cmd.SetOut(out)
+		err = cmd.Execute()
+		assert.Nil(t, err)
+		assert.Equal(t, "Cleaned the build cache "+cacheDir+"\n", out.String())
+
+		files, err := ioutil.ReadDir(cacheDir)
+		assert.Nil(t, err)
+		assert.Len(t, files, 1)
+		assert.Equal(t, cacheMarkerFile, files[0].Name())
+	})
+
+	t.Run("not the cache", func(t *testing.T) {
+		otherDir := filepath.Join(dir, "other")
+		if err := os.Mkdir(otherDir, 0777); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(otherDir, "a"), []byte("a"), 0666); err != nil {
+			t.Fatal(err)
+		}
+
+		cmd := newCacheCmd()
+		cmd.SetArgs([]string{"clean", "--cache-dir", otherDir})
+		cmd.SetOut(bytes.NewBuffer(nil))
+		err := cmd.Execute()
+		assert.Equal(t, otherDir+" is not a build cache of sd-local", err.Error())
+		_, err = os.Stat(filepath.Join(otherDir, "a"))
+		assert.Nil(t, err)
+	})
+
+	t.Run("no cache", func(t *testing.T) {
+		cmd := newCacheCmd()
+		cmd.SetArgs([]string{"clean", "--cache-dir", filepath.Join(dir, "none")})
+		cmd.SetOut(bytes.NewBuffer(nil))
+		err := cmd.Execute()
+		assert.Equal(t, "build cache "+filepath.Join(dir, "none")+" does not exist", err.Error())
+	})
+}
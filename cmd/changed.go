@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/screwdriver-cd/sd-local/screwdriver"
+	"github.com/sirupsen/logrus"
+)
+
+// changedFiles returns the files changed since the git ref, which are relative to the root of the repository
+var changedFiles = gitChangedFiles
+
+// gitChangedFiles returns the files changed in the git repository at srcPath since the ref including the uncommitted changes.
+// The untracked files are included as well except the ones ignored by git.
+func gitChangedFiles(srcPath, ref string) ([]string, error) {
+	files := make([]string, 0)
+	for _, args := range [][]string{
+		{"diff", "--name-only", "--no-renames", ref},
+		{"ls-files", "--others", "--exclude-standard", "--full-name"},
+	} {
+		out, err := exec.Command("git", append([]string{"-C", srcPath}, args...)...).Output()
+		if err != nil {
+			if exitErr, ok := err.(*exec.ExitError); ok && len(exitErr.Stderr) != 0 {
+				err = fmt.Errorf("%v: %s", err, strings.TrimSpace(string(exitErr.Stderr)))
+			}
+			return nil, fmt.Errorf("failed to get the files changed since %s: %v", ref, err)
+		}
+		for _, f := range strings.Split(string(out), "\n") {
+			if f != "" {
+				files = append(files, f)
+			}
+		}
+	}
+	return files, nil
+}
+
+// matchesSourcePath returns true if the file is the source path, or in it if the source path is a directory ending with a slash
+func matchesSourcePath(sourcePath, file string) bool {
+	sourcePath = strings.TrimPrefix(sourcePath, "./")
+	if strings.HasSuffix(sourcePath, "/") {
+		return strings.HasPrefix(file, sourcePath)
+	}
+	return file == sourcePath
+}
+
+// touchesSourcePaths returns true if any of the files matches the source paths.
+// The source paths prefixed with ! exclude the files from the others as well as Screwdriver.cd.
+func touchesSourcePaths(sourcePaths, files []string) bool {
+	for _, f := range files {
+		included, excluded := false, false
+		for _, p := range sourcePaths {
+			if strings.HasPrefix(p, "!") {
+				excluded = excluded || matchesSourcePath(strings.TrimPrefix(p, "!"), f)
+			} else {
+				included = included || matchesSourcePath(p, f)
+			}
+		}
+		if included && !excluded {
+			return true
+		}
+	}
+	return false
+}
+
+// changedJobNames returns the names of the jobs to run whose sourcePaths have any of the changed files.
+// The jobs without sourcePaths run on every change as well as Screwdriver.cd, and the skipped jobs are logged with the reason.
+func changedJobNames(jobs map[string]screwdriver.Job, names, files []string, ref string) []string {
+	changed := make([]string, 0, len(names))
+	for _, name := range names {
+		sourcePaths := jobs[name].SourcePaths
+		if len(sourcePaths) == 0 || touchesSourcePaths(sourcePaths, files) {
+			changed = append(changed, name)
+			continue
+		}
+		logrus.Infof("Skipped %s, no file in its sourcePaths %s has changed since %s", name, strings.Join(sourcePaths, ", "), ref)
+	}
+	return changed
+}
@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/screwdriver-cd/sd-local/screwdriver"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGitChangedFiles(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir, err := ioutil.TempDir("", "src")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	git := func(args ...string) {
+		c := exec.Command("git", append([]string{"-C", dir, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		if out, err := c.CombinedOutput(); err != nil {
+			t.Fatalf("%v: %s", err, out)
+		}
+	}
+
+	git("init")
+	_ = os.MkdirAll(filepath.Join(dir, "services", "a"), 0777)
+	_ = ioutil.WriteFile(filepath.Join(dir, "services", "a", "main.go"), []byte("v1"), 0666)
+	_ = ioutil.WriteFile(filepath.Join(dir, "README.md"), []byte("v1"), 0666)
+	_ = ioutil.WriteFile(filepath.Join(dir, ".gitignore"), []byte("bin\n"), 0666)
+	git("add", ".")
+	git("commit", "-m", "init")
+	git("tag", "base")
+
+	files, err := gitChangedFiles(dir, "base")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{}, files)
+
+	_ = ioutil.WriteFile(filepath.Join(dir, "README.md"), []byte("v2"), 0666)
+	git("commit", "-am", "update")
+	_ = ioutil.WriteFile(filepath.Join(dir, "services", "a", "main.go"), []byte("v2"), 0666)
+	_ = ioutil.WriteFile(filepath.Join(dir, "services", "a", "new.go"), []byte(""), 0666)
+	_ = os.MkdirAll(filepath.Join(dir, "bin"), 0777)
+	_ = ioutil.WriteFile(filepath.Join(dir, "bin", "a"), []byte(""), 0666)
+
+	// the paths are relative to the root of the repository even in the subdirectory
+	files, err = gitChangedFiles(filepath.Join(dir, "services"), "base")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"README.md", "services/a/main.go", "services/a/new.go"}, files)
+
+	_, err = gitChangedFiles(dir, "unknown")
+	assert.Contains(t, err.Error(), "failed to get the files changed since unknown: ")
+}
+
+func TestTouchesSourcePaths(t *testing.T) {
+	testCases := []struct {
+		name        string
+		sourcePaths []string
+		files       []string
+		expected    bool
+	}{
+		{name: "file in the directory", sourcePaths: []string{"src/app/"}, files: []string{"README.md", "src/app/main.go"}, expected: true},
+		{name: "file", sourcePaths: []string{"screwdriver.yaml"}, files: []string{"screwdriver.yaml"}, expected: true},
+		{name: "prefixed with ./", sourcePaths: []string{"./src/"}, files: []string{"src/main.go"}, expected: true},
+		{name: "file with the same prefix", sourcePaths: []string{"src/app"}, files: []string{"src/app.go"}, expected: false},
+		{name: "directory without the files", sourcePaths: []string{"src/app/"}, files: []string{"src/lib/main.go"}, expected: false},
+		{name: "excluded", sourcePaths: []string{"src/", "!src/docs/"}, files: []string{"src/docs/index.md"}, expected: false},
+		{name: "excluded with another file", sourcePaths: []string{"src/", "!src/docs/"}, files: []string{"src/docs/index.md", "src/main.go"}, expected: true},
+		{name: "no change", sourcePaths: []string{"src/"}, files: []string{}, expected: false},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, touchesSourcePaths(tt.sourcePaths, tt.files))
+		})
+	}
+}
+
+func TestChangedJobNames(t *testing.T) {
+	jobs := map[string]screwdriver.Job{
+		"a":    {SourcePaths: []string{"services/a/"}},
+		"b":    {SourcePaths: []string{"services/b/", "screwdriver.yaml"}},
+		"lint": {},
+	}
+
+	assert.Equal(t, []string{"a", "lint"}, changedJobNames(jobs, []string{"a", "b", "lint"}, []string{"services/a/main.go"}, "origin/main"))
+	assert.Equal(t, []string{"a", "b", "lint"}, changedJobNames(jobs, []string{"a", "b", "lint"}, []string{"services/a/main.go", "screwdriver.yaml"}, "origin/main"))
+	assert.Equal(t, []string{"lint"}, changedJobNames(jobs, []string{"a", "b", "lint"}, []string{}, "origin/main"))
+}
@@ -0,0 +1,281 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/screwdriver-cd/sd-local/screwdriver"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// The kinds of the names completed dynamically
+const (
+	completeEntries = "entries"
+	completeJobs    = "jobs"
+)
+
+// completeNamesCmd is the hidden command which prints the names for the completion scripts
+const completeNamesCmd = "__names"
+
+// zshCompArgsAnnotation is the annotation of cobra to complete the arguments in zsh
+const zshCompArgsAnnotation = "cobra_annotations_zsh_completion_argument_annotation"
+
+// dynamicArgs are the kinds of the names completed for the arguments of the commands.
+// The names are read when they are completed because they change after the script is generated.
+var dynamicArgs = map[string]string{
+	"sd-local build":         completeJobs,
+	"sd-local exec":          completeJobs,
+	"sd-local config copy":   completeEntries,
+	"sd-local config delete": completeEntries,
+	"sd-local config lock":   completeEntries,
+	"sd-local config rename": completeEntries,
+	"sd-local config unlock": completeEntries,
+	"sd-local config use":    completeEntries,
+}
+
+// completionNames returns the names of the kind, the config entries or the jobs in screwdriver.yaml of the current directory
+func completionNames(kind string) ([]string, error) {
+	if kind == completeJobs {
+		return screwdriver.JobNames("screwdriver.yaml")
+	}
+
+	path, err := configFilePath()
+	if err != nil {
+		return nil, err
+	}
+	c, err := configNew(path)
+	if err != nil {
+		return nil, err
+	}
+	defer c.Close()
+	return c.EntryNames(), nil
+}
+
+// dynamicCommands returns the paths of the commands whose arguments are completed with the names of the kind in order
+func dynamicCommands(kind string) []string {
+	paths := make([]string, 0, len(dynamicArgs))
+	for path, k := range dynamicArgs {
+		if k == kind {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// bashCompletionFunction returns the function called by the bash completion when there is no static completion
+func bashCompletionFunction() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, `__sd-local_complete_names()
+{
+    local names
+    names=$(sd-local %s "$1" 2>/dev/null) || return
+    COMPREPLY=( $(compgen -W "${names}" -- "${cur}") )
+}
+
+__sd-local_custom_func()
+{
+    case ${last_command} in
+`, completeNamesCmd)
+	for _, kind := range []string{completeEntries, completeJobs} {
+		commands := dynamicCommands(kind)
+		for i, path := range commands {
+			commands[i] = strings.Replace(path, " ", "_", -1)
+		}
+		fmt.Fprintf(&b, "        %s)\n            __sd-local_complete_names %s\n            return\n            ;;\n", strings.Join(commands, " | "), kind)
+	}
+	b.WriteString("    esac\n}\n")
+	return b.String()
+}
+
+// genZshCompletion generates the zsh completion whose arguments are completed by the names printed by sd-local.
+// The generator of cobra supports only the static words, so they are replaced with the function.
+func genZshCompletion(w io.Writer, root *cobra.Command) error {
+	placeholders := make(map[string]string)
+	for path, kind := range dynamicArgs {
+		c, _, err := root.Find(strings.Fields(path)[1:])
+		if err != nil {
+			continue
+		}
+		placeholder := "__sd-local_" + kind
+		if _, ok := c.Annotations[zshCompArgsAnnotation]; !ok {
+			if err := c.MarkZshCompPositionalArgumentWords(1, placeholder); err != nil {
+				return err
+			}
+		}
+		// the jobs can be specified multiple times
+		position := "1"
+		if kind == completeJobs {
+			position = "*"
+		}
+		placeholders[fmt.Sprintf(`'1: :("%s")'`, placeholder)] = fmt.Sprintf(`'%s: :__sd-local_complete_names %s'`, position, kind)
+	}
+
+	buf := bytes.NewBuffer(nil)
+	if err := root.GenZshCompletion(buf); err != nil {
+		return err
+	}
+	script := buf.String()
+	for placeholder, action := range placeholders {
+		script = strings.Replace(script, placeholder, action, -1)
+	}
+
+	header := fmt.Sprintf("#compdef _%s %s\n", root.Name(), root.Name())
+	function := fmt.Sprintf(`
+function __sd-local_complete_names {
+  local -a names
+  names=(${(f)"$(sd-local %s $1 2>/dev/null)"})
+  compadd -a names
+}
+`, completeNamesCmd)
+	script = strings.Replace(script, header, header+function, 1)
+
+	_, err := io.WriteString(w, script)
+	return err
+}
+
+// fishQuote quotes the string in single quotes for fish
+func fishQuote(s string) string {
+	s = strings.Replace(s, `\`, `\\`, -1)
+	return "'" + strings.Replace(s, "'", `\'`, -1) + "'"
+}
+
+// fishCondition returns the condition that the subcommands of the path are seen
+func fishCondition(c *cobra.Command) string {
+	var conditions []string
+	for ; c.HasParent(); c = c.Parent() {
+		conditions = append([]string{"__fish_seen_subcommand_from " + c.Name()}, conditions...)
+	}
+	return strings.Join(conditions, "; and ")
+}
+
+// genFishCompletion generates the fish completion of the subcommands, the flags and the names of the arguments
+func genFishCompletion(w io.Writer, root *cobra.Command) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, `# fish completion for %[1]s
+
+function __sd-local_complete_names
+    %[1]s %[2]s $argv 2>/dev/null
+end
+
+complete -c %[1]s -f
+`, root.Name(), completeNamesCmd)
+
+	var walk func(c *cobra.Command)
+	walk = func(c *cobra.Command) {
+		condition := fishCondition(c)
+		prefix := fmt.Sprintf("complete -c %s", root.Name())
+		if condition != "" {
+			prefix += " -n " + fishQuote(condition)
+		}
+
+		var subcommands []string
+		for _, sub := range c.Commands() {
+			if !sub.Hidden {
+				subcommands = append(subcommands, sub.Name())
+			}
+		}
+		for _, sub := range c.Commands() {
+			if sub.Hidden {
+				continue
+			}
+			subCondition := "__fish_use_subcommand"
+			if c.HasParent() {
+				subCondition = fmt.Sprintf("%s; and not __fish_seen_subcommand_from %s", condition, strings.Join(subcommands, " "))
+			}
+			fmt.Fprintf(&b, "complete -c %s -n %s -a %s -d %s\n", root.Name(), fishQuote(subCondition), sub.Name(), fishQuote(sub.Short))
+		}
+
+		flags := c.LocalNonPersistentFlags()
+		if !c.HasParent() {
+			flags = c.PersistentFlags()
+		}
+		flags.VisitAll(func(f *pflag.Flag) {
+			if f.Hidden {
+				return
+			}
+			line := prefix + " -l " + f.Name
+			if f.Shorthand != "" {
+				line += " -s " + f.Shorthand
+			}
+			if f.NoOptDefVal == "" {
+				line += " -r -F"
+			}
+			fmt.Fprintf(&b, "%s -d %s\n", line, fishQuote(strings.Split(f.Usage, "\n")[0]))
+		})
+
+		if len(c.ValidArgs) != 0 {
+			fmt.Fprintf(&b, "%s -a %s\n", prefix, fishQuote(strings.Join(c.ValidArgs, " ")))
+		}
+		if kind, ok := dynamicArgs[c.CommandPath()]; ok {
+			fmt.Fprintf(&b, "%s -a %s\n", prefix, fishQuote("(__sd-local_complete_names "+kind+")"))
+		}
+
+		for _, sub := range c.Commands() {
+			if !sub.Hidden {
+				walk(sub)
+			}
+		}
+	}
+	walk(root)
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func newCompletionCmd() *cobra.Command {
+	completionCmd := &cobra.Command{
+		Use:   "completion [bash|zsh|fish|powershell]",
+		Short: "Generate the completion script for the shell.",
+		Long: `Generate the completion script for the shell.
+The config entry names and the job names in screwdriver.yaml of the current directory are completed as well in bash, zsh and fish.
+
+To load the completions in the current shell:
+  bash: source <(sd-local completion bash)
+  zsh:  source <(sd-local completion zsh); compdef _sd-local sd-local
+  fish: sd-local completion fish | source`,
+		ValidArgs: []string{"bash", "zsh", "fish", "powershell"},
+		Args:      cobra.ExactValidArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			root := cmd.Root()
+			w := cmd.OutOrStdout()
+			switch args[0] {
+			case "bash":
+				root.BashCompletionFunction = bashCompletionFunction()
+				return root.GenBashCompletion(w)
+			case "zsh":
+				return genZshCompletion(w, root)
+			case "fish":
+				return genFishCompletion(w, root)
+			default:
+				return root.GenPowerShellCompletion(w)
+			}
+		},
+	}
+
+	return completionCmd
+}
+
+// newCompleteNamesCmd creates the hidden command which prints the names completed by the completion scripts
+func newCompleteNamesCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:       completeNamesCmd + " [entries|jobs]",
+		Hidden:    true,
+		ValidArgs: []string{completeEntries, completeJobs},
+		Args:      cobra.ExactValidArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			names, err := completionNames(args[0])
+			if err != nil {
+				return err
+			}
+			for _, name := range names {
+				fmt.Fprintln(cmd.OutOrStdout(), name)
+			}
+			return nil
+		},
+	}
+}
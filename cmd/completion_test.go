@@ -0,0 +1,151 @@
+package cmd
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/screwdriver-cd/sd-local/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompletionCmd(t *testing.T) {
+	cases := []struct {
+		name     string
+		args     []string
+		contains []string
+		wantErr  bool
+	}{
+		{
+			name: "bash",
+			args: []string{"completion", "bash"},
+			contains: []string{
+				"__sd-local_custom_func()",
+				"sd-local_build | sd-local_exec)\n            __sd-local_complete_names jobs",
+			},
+		},
+		{
+			name: "zsh",
+			args: []string{"completion", "zsh"},
+			contains: []string{
+				"#compdef _sd-local sd-local\n\nfunction __sd-local_complete_names {",
+				"'*: :__sd-local_complete_names jobs'",
+			},
+		},
+		{
+			name: "fish",
+			args: []string{"completion", "fish"},
+			contains: []string{
+				"complete -c sd-local -n '__fish_use_subcommand' -a build -d 'Run screwdriver build.'",
+				"complete -c sd-local -n '__fish_seen_subcommand_from build' -l env -s e -r -F",
+				"complete -c sd-local -n '__fish_seen_subcommand_from build' -a '(__sd-local_complete_names jobs)'",
+			},
+		},
+		{
+			name:     "powershell",
+			args:     []string{"completion", "powershell"},
+			contains: []string{"Register-ArgumentCompleter -Native -CommandName 'sd-local'"},
+		},
+		{
+			name:    "failure by unknown shell",
+			args:    []string{"completion", "tcsh"},
+			wantErr: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			root := newRootCmd()
+			root.AddCommand(newBuildCmd(), newCompletionCmd())
+			buf := bytes.NewBuffer(nil)
+			root.SetOut(buf)
+			root.SetErr(ioutil.Discard)
+			root.SetArgs(c.args)
+
+			err := root.Execute()
+			if c.wantErr {
+				assert.NotNil(t, err)
+				return
+			}
+			assert.Nil(t, err)
+			for _, s := range c.contains {
+				assert.Contains(t, buf.String(), s)
+			}
+		})
+	}
+}
+
+func TestCompleteNamesCmd(t *testing.T) {
+	defConfigNew := configNew
+	defer func() { configNew = defConfigNew }()
+	configNew = func(confPath string) (config.Config, error) {
+		return config.Config{
+			Entries: map[string]*config.Entry{
+				"default": {},
+				"beta":    {},
+			},
+			Current: "default",
+		}, nil
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir, err := ioutil.TempDir("", "complete")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	err = ioutil.WriteFile(filepath.Join(dir, "screwdriver.yaml"), []byte("jobs:\n  test:\n    steps: []\n  main:\n    steps: []\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	cases := []struct {
+		name    string
+		args    []string
+		expect  string
+		wantErr bool
+	}{
+		{
+			name:   "entries",
+			args:   []string{completeEntries},
+			expect: "beta\ndefault\n",
+		},
+		{
+			name:   "jobs",
+			args:   []string{completeJobs},
+			expect: "main\ntest\n",
+		},
+		{
+			name:    "failure by unknown kind",
+			args:    []string{"steps"},
+			wantErr: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			cmd := newCompleteNamesCmd()
+			buf := bytes.NewBuffer(nil)
+			cmd.SetOut(buf)
+			cmd.SetErr(ioutil.Discard)
+			cmd.SetArgs(c.args)
+
+			err := cmd.Execute()
+			if c.wantErr {
+				assert.NotNil(t, err)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, c.expect, buf.String())
+		})
+	}
+}
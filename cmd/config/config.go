@@ -1,23 +1,44 @@
 package config
 
 import (
-	"path/filepath"
+	"fmt"
 
-	"github.com/mitchellh/go-homedir"
+	"github.com/screwdriver-cd/sd-local/config"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 const (
-	configFileName = "config"
-	configDirName  = ".sdlocal"
+	outputJSON    = "json"
+	redactedToken = "***"
 )
 
+// flagFilePath is the path of the config file set by --config
+var flagFilePath string
+
 var filePath = func() (string, error) {
-	home, err := homedir.Dir()
-	if err != nil {
-		return "", err
+	return config.ResolveFilePath(flagFilePath)
+}
+
+// AddFilePathFlag adds --config to set the path of the config file to the flags like the persistent flags of the root command
+func AddFilePathFlag(flags *pflag.FlagSet) {
+	flags.StringVar(
+		&flagFilePath,
+		"config",
+		"",
+		fmt.Sprintf("Path to the config file, which is created if it does not exist. %s or ~/.sdlocal/config is used if it is not specified.", config.EnvFilePath))
+}
+
+// FilePath returns the absolute path of the config file by --config, SD_LOCAL_CONFIG or the default path
+func FilePath() (string, error) {
+	return filePath()
+}
+
+func validateOutput(output string) error {
+	if output != "" && output != outputJSON {
+		return fmt.Errorf("invalid output format %s: only %s is supported", output, outputJSON)
 	}
-	return filepath.Join(home, configDirName, configFileName), nil
+	return nil
 }
 
 // NewConfigCmd return config command.
@@ -37,10 +58,19 @@ func NewConfigCmd() *cobra.Command {
 
 	configCmd.AddCommand(
 		newConfigSetCmd(),
+		newConfigGetCmd(),
 		newConfigViewCmd(),
 		newConfigCreateCmd(),
 		newConfigDeleteCmd(),
 		newConfigUseCmd(),
+		newConfigRenameCmd(),
+		newConfigCopyCmd(),
+		newConfigListCmd(),
+		newConfigExportCmd(),
+		newConfigImportCmd(),
+		newConfigLockCmd(),
+		newConfigUnlockCmd(),
+		newConfigPathCmd(),
 	)
 
 	return configCmd
@@ -0,0 +1,19 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestMain removes the lock files of the config files made by the tests, which are left by sd-local
+func TestMain(m *testing.M) {
+	ret := m.Run()
+	for _, pattern := range []string{".*.lock", filepath.Join("testdata", ".*.lock")} {
+		locks, _ := filepath.Glob(pattern)
+		for _, lock := range locks {
+			os.Remove(lock)
+		}
+	}
+	os.Exit(ret)
+}
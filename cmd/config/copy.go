@@ -0,0 +1,44 @@
+package config
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func newConfigCopyCmd() *cobra.Command {
+	configCopyCmd := &cobra.Command{
+		Use:   "copy [src] [dst]",
+		Short: "Copy the config of sd-local",
+		Long: `Copy the config of sd-local.
+The new config has the same settings as the source config.`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+
+			src, dst := args[0], args[1]
+
+			path, err := filePath()
+			if err != nil {
+				return err
+			}
+
+			config, err := configNew(path)
+			if err != nil {
+				return err
+			}
+			defer config.Close()
+
+			err = config.CopyEntry(src, dst)
+			if err != nil {
+				return err
+			}
+
+			err = config.Save()
+			if err != nil {
+				return err
+			}
+			return nil
+		},
+	}
+
+	return configCopyCmd
+}
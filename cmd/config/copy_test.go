@@ -0,0 +1,93 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/screwdriver-cd/sd-local/config"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigCopyCmd(t *testing.T) {
+	f, err := os.Open("./testdata/config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	cnfPath, err := createRandNameConfig(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(cnfPath)
+
+	cnew := configNew
+	defer func() {
+		configNew = cnew
+	}()
+	configNew = func(configPath string) (c config.Config, err error) {
+		return config.New(cnfPath)
+	}
+
+	testCase := []struct {
+		name     string
+		args     []string
+		wantOut  string
+		checkErr bool
+	}{
+		{
+			name:     "success",
+			args:     []string{"copy", "test", "copied"},
+			wantOut:  "",
+			checkErr: false,
+		},
+		{
+			name:     "failure by Entry that does not exist",
+			args:     []string{"copy", "doesnotexist", "copied2"},
+			wantOut:  "",
+			checkErr: true,
+		},
+		{
+			name:     "failure by Entry that already exists",
+			args:     []string{"copy", "test", "copied"},
+			wantOut:  "",
+			checkErr: true,
+		},
+		{
+			name:     "failure by too many args",
+			args:     []string{"copy", "test", "copied", "many"},
+			wantOut:  "",
+			checkErr: true,
+		},
+		{
+			name:     "failure by too little args",
+			args:     []string{"copy", "test"},
+			wantOut:  "",
+			checkErr: true,
+		},
+	}
+
+	for _, tt := range testCase {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := NewConfigCmd()
+			cmd.SetArgs(tt.args)
+			buf := bytes.NewBuffer(nil)
+			cmd.SetOut(buf)
+			err := cmd.Execute()
+			if tt.checkErr {
+				assert.NotNil(t, err)
+			} else {
+				assert.Nil(t, err)
+				assert.Equal(t, tt.wantOut, buf.String())
+			}
+		})
+	}
+
+	c, err := config.New(cnfPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, c.Entries["test"], c.Entries["copied"])
+}
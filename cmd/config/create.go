@@ -9,12 +9,27 @@ var (
 	configNew = config.New
 )
 
+// createKeys are the keys of the config which can be set on creation
+var createKeys = []struct {
+	key   string
+	usage string
+}{
+	{"api-url", "Screwdriver.cd API URL."},
+	{"store-url", "Screwdriver.cd Store URL."},
+	{"token", "Screwdriver.cd Token."},
+	{"launcher-version", "Screwdriver.cd launcher version."},
+	{"launcher-image", "Screwdriver.cd launcher image."},
+}
+
 func newConfigCreateCmd() *cobra.Command {
+	values := make(map[string]*string, len(createKeys))
+
 	configCreateCmd := &cobra.Command{
 		Use:   "create [name]",
 		Short: "Create the config of sd-local",
 		Long: `Create the config of sd-local.
-The new config has only launcher-version and launcher-image.`,
+The new config has only launcher-version and launcher-image, which are taken from defaults of the config file if they are set.
+The initial values can be set with flags.`,
 		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			cmd.SilenceUsage = true
@@ -30,9 +45,20 @@ The new config has only launcher-version and launcher-image.`,
 			if err != nil {
 				return err
 			}
+			defer c.Close()
 
-			defaultEntry := config.DefaultEntry()
-			err = c.AddEntry(name, defaultEntry)
+			entry := c.DefaultEntry()
+			for _, k := range createKeys {
+				if !cmd.Flags().Changed(k.key) {
+					continue
+				}
+				err = entry.Set(k.key, *values[k.key])
+				if err != nil {
+					return err
+				}
+			}
+
+			err = c.AddEntry(name, entry)
 			if err != nil {
 				return err
 			}
@@ -45,5 +71,9 @@ The new config has only launcher-version and launcher-image.`,
 		},
 	}
 
+	for _, k := range createKeys {
+		values[k.key] = configCreateCmd.Flags().String(k.key, "", k.usage)
+	}
+
 	return configCreateCmd
 }
@@ -6,6 +6,7 @@ import (
 	"io"
 	"math/rand"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
@@ -54,6 +55,18 @@ func TestConfigCreateCmd(t *testing.T) {
 			wantOut:  "",
 			checkErr: false,
 		},
+		{
+			name:     "success with initial values",
+			args:     []string{"create", "staging", "--api-url", "https://api.example.com", "--token", "staging-token", "--launcher-version", "1.2.3"},
+			wantOut:  "",
+			checkErr: false,
+		},
+		{
+			name:     "failure by invalid initial value",
+			args:     []string{"create", "invalid", "--api-url", "api.example.com"},
+			wantOut:  "",
+			checkErr: true,
+		},
 		{
 			name:     "failure by Entry that already exists",
 			args:     []string{"create", "default"},
@@ -91,4 +104,58 @@ func TestConfigCreateCmd(t *testing.T) {
 		})
 	}
 
+	c, err := config.New(cnfPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, config.DefaultEntry(), c.Entries["test"])
+
+	expected := config.DefaultEntry()
+	expected.APIURL = "https://api.example.com"
+	expected.Token = "staging-token"
+	expected.Launcher.Version = "1.2.3"
+	assert.Equal(t, expected, c.Entries["staging"])
+
+	_, exists := c.Entries["invalid"]
+	assert.False(t, exists)
+}
+
+func TestConfigCreateCmdWithDefaults(t *testing.T) {
+	cnfPath, err := createRandNameConfig(strings.NewReader(`version: 1
+defaults:
+  launcher:
+    image: registry.example.com/launcher
+configs:
+  default:
+    launcher:
+      version: stable
+      image: screwdrivercd/launcher
+current: default
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(cnfPath)
+
+	cnew := configNew
+	defer func() {
+		configNew = cnew
+	}()
+	configNew = func(configPath string) (c config.Config, err error) {
+		return config.New(cnfPath)
+	}
+
+	cmd := NewConfigCmd()
+	cmd.SetArgs([]string{"create", "test"})
+	cmd.SetOut(bytes.NewBuffer(nil))
+	err = cmd.Execute()
+	assert.Nil(t, err)
+
+	c, err := config.New(cnfPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, config.Launcher{Version: "stable", Image: "registry.example.com/launcher"}, c.Entries["test"].Launcher)
+	assert.Equal(t, "screwdrivercd/launcher", c.Entries["default"].Launcher.Image)
+	assert.Equal(t, &config.Defaults{Launcher: config.Launcher{Image: "registry.example.com/launcher"}}, c.Defaults)
 }
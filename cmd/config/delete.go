@@ -24,6 +24,7 @@ func newConfigDeleteCmd() *cobra.Command {
 			if err != nil {
 				return err
 			}
+			defer config.Close()
 
 			err = config.DeleteEntry(name)
 			if err != nil {
@@ -0,0 +1,61 @@
+package config
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+func newConfigExportCmd() *cobra.Command {
+	var outputPath string
+	var noSecrets bool
+
+	configExportCmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export the configs of sd-local",
+		Long: `Export all the configs of sd-local in YAML format.
+The exported file can be imported with import sub command.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+
+			path, err := filePath()
+			if err != nil {
+				return err
+			}
+
+			config, err := configNew(path)
+			if err != nil {
+				return err
+			}
+			defer config.Close()
+
+			if outputPath == "" {
+				return config.Export(cmd.OutOrStdout(), noSecrets)
+			}
+
+			file, err := os.OpenFile(outputPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+
+			return config.Export(file, noSecrets)
+		},
+	}
+
+	configExportCmd.Flags().StringVarP(
+		&outputPath,
+		"file",
+		"f",
+		"",
+		"Path to the file to write. The configs are written to stdout if it is not specified.")
+
+	configExportCmd.Flags().BoolVar(
+		&noSecrets,
+		"no-secrets",
+		false,
+		"Leave the tokens empty in the exported configs. The tokens stored in the OS keychain are exported as well otherwise.")
+
+	return configExportCmd
+}
@@ -0,0 +1,90 @@
+package config
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigExportCmd(t *testing.T) {
+	fp := filePath
+	defer func() {
+		filePath = fp
+	}()
+	filePath = func() (string, error) {
+		return "./testdata/config", nil
+	}
+
+	dir, err := ioutil.TempDir("", "export")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	testCase := []struct {
+		name        string
+		args        []string
+		outputPath  string
+		wantContain []string
+		wantExclude []string
+		checkErr    bool
+	}{
+		{
+			name:        "success",
+			args:        []string{"export"},
+			wantContain: []string{"current: default", "token: sd-token", "token: sd-token-test"},
+		},
+		{
+			name:        "success without secrets",
+			args:        []string{"export", "--no-secrets"},
+			wantContain: []string{"current: default", `token: ""`},
+			wantExclude: []string{"sd-token"},
+		},
+		{
+			name:        "success to write file",
+			args:        []string{"export", "-f", filepath.Join(dir, "exported")},
+			outputPath:  filepath.Join(dir, "exported"),
+			wantContain: []string{"current: default", "token: sd-token"},
+		},
+		{
+			name:     "failure by too many args",
+			args:     []string{"export", "many"},
+			checkErr: true,
+		},
+	}
+
+	for _, tt := range testCase {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := NewConfigCmd()
+			cmd.SetArgs(tt.args)
+			buf := bytes.NewBuffer(nil)
+			cmd.SetOut(buf)
+			err := cmd.Execute()
+			if tt.checkErr {
+				assert.NotNil(t, err)
+				return
+			}
+			assert.Nil(t, err)
+
+			actual := buf.String()
+			if tt.outputPath != "" {
+				b, err := ioutil.ReadFile(tt.outputPath)
+				if err != nil {
+					t.Fatal(err)
+				}
+				actual = string(b)
+			}
+			for _, expect := range tt.wantContain {
+				assert.True(t, strings.Contains(actual, expect), "expect to contain %q \nbut got \n%q", expect, actual)
+			}
+			for _, expect := range tt.wantExclude {
+				assert.False(t, strings.Contains(actual, expect), "expect not to contain %q \nbut got \n%q", expect, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+func newConfigGetCmd() *cobra.Command {
+	var configEntry string
+	var showToken bool
+
+	configGetCmd := &cobra.Command{
+		Use:   "get [key]",
+		Short: "Get the config of sd-local",
+		Long: `Get the value of the current config named by the key like "api-url".
+The keys are the same as the ones of set sub command.
+The value is printed without decoration, e.g. to capture it with $(sd-local config get api-url).
+The token is printed only with --show-token not to leak it into logs by accident.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+
+			path, err := filePath()
+			if err != nil {
+				return err
+			}
+
+			c, err := configNew(path)
+			if err != nil {
+				return err
+			}
+			defer c.Close()
+
+			key := args[0]
+			if key == "token" && !showToken {
+				return errors.New("the token is printed only with --show-token")
+			}
+
+			_, entry, err := c.RunEntry(configEntry)
+			if err != nil {
+				return err
+			}
+
+			value, err := entry.Get(key)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), value)
+			return nil
+		},
+	}
+
+	configGetCmd.Flags().StringVar(
+		&configEntry,
+		"config-entry",
+		"",
+		"Name of the config to read instead of the current config.")
+
+	configGetCmd.Flags().BoolVar(
+		&showToken,
+		"show-token",
+		false,
+		"Allow to print the token.")
+
+	return configGetCmd
+}
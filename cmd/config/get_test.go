@@ -0,0 +1,101 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/screwdriver-cd/sd-local/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigGetCmd(t *testing.T) {
+	f, err := os.Open("./testdata/config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	cnfPath, err := createRandNameConfig(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(cnfPath)
+
+	preconf := configNew
+	defer func() {
+		configNew = preconf
+	}()
+	configNew = func(configPath string) (c config.Config, err error) {
+		return config.New(cnfPath)
+	}
+
+	testCase := []struct {
+		name     string
+		args     []string
+		wantOut  string
+		checkErr bool
+	}{
+		{
+			name:    "success",
+			args:    []string{"get", "api-url"},
+			wantOut: "api.screwdriver.com\n",
+		},
+		{
+			name:    "success with launcher-version",
+			args:    []string{"get", "launcher-version"},
+			wantOut: "1.0.0\n",
+		},
+		{
+			name:    "success with --config-entry",
+			args:    []string{"get", "api-url", "--config-entry", "test"},
+			wantOut: "api-test.screwdriver.com\n",
+		},
+		{
+			name:    "success with token and --show-token",
+			args:    []string{"get", "token", "--show-token"},
+			wantOut: "sd-token\n",
+		},
+		{
+			name:     "failure with token without --show-token",
+			args:     []string{"get", "token"},
+			wantOut:  "Error: the token is printed only with --show-token\n",
+			checkErr: true,
+		},
+		{
+			name:     "failure with unknown --config-entry",
+			args:     []string{"get", "api-url", "--config-entry", "doesnotexist"},
+			wantOut:  "Error: config `doesnotexist` does not exist, see the configs with `sd-local config list`\n",
+			checkErr: true,
+		},
+		{
+			name:     "failure with unknown key",
+			args:     []string{"get", "unknown"},
+			wantOut:  "Error: invalid key unknown\n",
+			checkErr: true,
+		},
+		{
+			name:     "failure without args",
+			args:     []string{"get"},
+			wantOut:  "Error: accepts 1 arg(s), received 0\n",
+			checkErr: true,
+		},
+	}
+
+	for _, tt := range testCase {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := NewConfigCmd()
+			cmd.SilenceUsage = true
+			cmd.SetArgs(tt.args)
+			buf := bytes.NewBuffer(nil)
+			cmd.SetOut(buf)
+			err := cmd.Execute()
+			if tt.checkErr {
+				assert.NotNil(t, err)
+			} else {
+				assert.Nil(t, err)
+			}
+			assert.Equal(t, tt.wantOut, buf.String())
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package config
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+func newConfigImportCmd() *cobra.Command {
+	var overwrite bool
+
+	configImportCmd := &cobra.Command{
+		Use:   "import [file]",
+		Short: "Import the configs of sd-local",
+		Long: `Import the configs exported by export sub command.
+The imported current config is used only if it did not exist before the import.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+
+			importPath := args[0]
+
+			path, err := filePath()
+			if err != nil {
+				return err
+			}
+
+			config, err := configNew(path)
+			if err != nil {
+				return err
+			}
+			defer config.Close()
+
+			file, err := os.Open(importPath)
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+
+			err = config.Import(file, overwrite)
+			if err != nil {
+				return err
+			}
+
+			err = config.Save()
+			if err != nil {
+				return err
+			}
+			return nil
+		},
+	}
+
+	configImportCmd.Flags().BoolVar(
+		&overwrite,
+		"overwrite",
+		false,
+		"Overwrite the configs which have the same name.")
+
+	return configImportCmd
+}
@@ -0,0 +1,86 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/screwdriver-cd/sd-local/config"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigImportCmd(t *testing.T) {
+	testCase := []struct {
+		name          string
+		args          []string
+		wantCurrent   string
+		wantTestToken string
+		checkErr      bool
+	}{
+		{
+			name:     "failure by Entry that already exists",
+			args:     []string{"import", "./testdata/import_config"},
+			checkErr: true,
+		},
+		{
+			name:          "success with --overwrite",
+			args:          []string{"import", "./testdata/import_config", "--overwrite"},
+			wantCurrent:   "prod",
+			wantTestToken: "sd-token-imported",
+		},
+		{
+			name:     "failure by file that does not exist",
+			args:     []string{"import", "./testdata/doesnotexist"},
+			checkErr: true,
+		},
+		{
+			name:     "failure by too little args",
+			args:     []string{"import"},
+			checkErr: true,
+		},
+	}
+
+	for _, tt := range testCase {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := os.Open("./testdata/config")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+
+			cnfPath, err := createRandNameConfig(f)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.Remove(cnfPath)
+
+			cnew := configNew
+			defer func() {
+				configNew = cnew
+			}()
+			configNew = func(configPath string) (c config.Config, err error) {
+				return config.New(cnfPath)
+			}
+
+			cmd := NewConfigCmd()
+			cmd.SetArgs(tt.args)
+			buf := bytes.NewBuffer(nil)
+			cmd.SetOut(buf)
+			err = cmd.Execute()
+			if tt.checkErr {
+				assert.NotNil(t, err)
+				return
+			}
+			assert.Nil(t, err)
+
+			c, err := config.New(cnfPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, tt.wantCurrent, c.Current)
+			assert.Equal(t, tt.wantTestToken, c.Entries["test"].Token)
+			assert.Equal(t, "sd-token", c.Entries["default"].Token)
+		})
+	}
+}
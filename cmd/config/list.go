@@ -0,0 +1,76 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+type entryList struct {
+	Current string   `json:"current"`
+	Entries []string `json:"entries"`
+}
+
+func newConfigListCmd() *cobra.Command {
+	var output string
+
+	configListCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the configs of sd-local",
+		Long: `List the configs of sd-local.
+The current config is marked with "*".`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			err := cobra.NoArgs(cmd, args)
+			if err != nil {
+				return err
+			}
+
+			return validateOutput(output)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+
+			path, err := filePath()
+			if err != nil {
+				return err
+			}
+
+			config, err := configNew(path)
+			if err != nil {
+				return err
+			}
+			defer config.Close()
+
+			names := config.EntryNames()
+
+			if output == outputJSON {
+				encoder := json.NewEncoder(cmd.OutOrStdout())
+				encoder.SetIndent("", "  ")
+				return encoder.Encode(entryList{
+					Current: config.Current,
+					Entries: names,
+				})
+			}
+
+			for _, name := range names {
+				if name == config.Current {
+					fmt.Fprintf(cmd.OutOrStdout(), "* %s\n", name)
+				} else {
+					fmt.Fprintf(cmd.OutOrStdout(), "  %s\n", name)
+				}
+			}
+
+			return nil
+		},
+	}
+
+	configListCmd.Flags().StringVarP(
+		&output,
+		"output",
+		"o",
+		"",
+		"Output format. Only 'json' is supported.")
+
+	return configListCmd
+}
@@ -0,0 +1,93 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigListCmd(t *testing.T) {
+	fp := filePath
+	defer func() {
+		filePath = fp
+	}()
+
+	testCase := []struct {
+		name     string
+		args     []string
+		config   string
+		wantOut  string
+		checkErr bool
+	}{
+		{
+			name:    "success",
+			args:    []string{"list"},
+			config:  "./testdata/config",
+			wantOut: "* default\n  test\n",
+		},
+		{
+			name:    "success with no current",
+			args:    []string{"list"},
+			config:  "./testdata/config_no_current",
+			wantOut: "* default\n  test\n",
+		},
+		{
+			name:   "success with json output",
+			args:   []string{"list", "-o", "json"},
+			config: "./testdata/config",
+			wantOut: `{
+  "current": "default",
+  "entries": [
+    "default",
+    "test"
+  ]
+}
+`,
+		},
+		{
+			name:     "failure by unknown output format",
+			args:     []string{"list", "-o", "xml"},
+			config:   "./testdata/config",
+			checkErr: true,
+		},
+		{
+			name:     "failure by too many args",
+			args:     []string{"list", "many"},
+			config:   "./testdata/config",
+			checkErr: true,
+		},
+	}
+
+	for _, tt := range testCase {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := os.Open(tt.config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+			cnfPath, err := createRandNameConfig(f)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.Remove(cnfPath)
+
+			filePath = func() (string, error) {
+				return cnfPath, nil
+			}
+
+			cmd := NewConfigCmd()
+			cmd.SetArgs(tt.args)
+			buf := bytes.NewBuffer(nil)
+			cmd.SetOut(buf)
+			err = cmd.Execute()
+			if tt.checkErr {
+				assert.NotNil(t, err)
+			} else {
+				assert.Nil(t, err)
+				assert.Equal(t, tt.wantOut, buf.String())
+			}
+		})
+	}
+}
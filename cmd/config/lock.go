@@ -0,0 +1,44 @@
+package config
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func newConfigLockCmd() *cobra.Command {
+	configLockCmd := &cobra.Command{
+		Use:   "lock [name]",
+		Short: "Lock the config of sd-local",
+		Long: `Lock the config of sd-local.
+A locked config cannot be changed, renamed, deleted or overwritten until it is unlocked.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+
+			name := args[0]
+
+			path, err := filePath()
+			if err != nil {
+				return err
+			}
+
+			config, err := configNew(path)
+			if err != nil {
+				return err
+			}
+			defer config.Close()
+
+			err = config.Lock(name)
+			if err != nil {
+				return err
+			}
+
+			err = config.Save()
+			if err != nil {
+				return err
+			}
+			return nil
+		},
+	}
+
+	return configLockCmd
+}
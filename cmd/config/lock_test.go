@@ -0,0 +1,94 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/screwdriver-cd/sd-local/config"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigLockCmd(t *testing.T) {
+	f, err := os.Open("./testdata/config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	cnfPath, err := createRandNameConfig(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(cnfPath)
+
+	fp := filePath
+	defer func() {
+		filePath = fp
+	}()
+	filePath = func() (string, error) {
+		return cnfPath, nil
+	}
+
+	testCase := []struct {
+		name     string
+		args     []string
+		wantOut  string
+		checkErr bool
+	}{
+		{
+			name:     "success",
+			args:     []string{"lock", "default"},
+			wantOut:  "",
+			checkErr: false,
+		},
+		{
+			name:     "failure to set locked config",
+			args:     []string{"set", "token", "new-token"},
+			wantOut:  "",
+			checkErr: true,
+		},
+		{
+			name:     "failure to rename locked config",
+			args:     []string{"rename", "default", "renamed"},
+			wantOut:  "",
+			checkErr: true,
+		},
+		{
+			name:     "failure by Entry that does not exist",
+			args:     []string{"lock", "doesnotexist"},
+			wantOut:  "",
+			checkErr: true,
+		},
+		{
+			name:     "failure by too many args",
+			args:     []string{"lock", "default", "many"},
+			wantOut:  "",
+			checkErr: true,
+		},
+	}
+
+	for _, tt := range testCase {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := NewConfigCmd()
+			cmd.SetArgs(tt.args)
+			buf := bytes.NewBuffer(nil)
+			cmd.SetOut(buf)
+			err := cmd.Execute()
+			if tt.checkErr {
+				assert.NotNil(t, err)
+			} else {
+				assert.Nil(t, err)
+				assert.Equal(t, tt.wantOut, buf.String())
+			}
+		})
+	}
+
+	c, err := config.New(cnfPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, c.Entries["default"].Locked)
+	assert.Equal(t, "sd-token", c.Entries["default"].Token)
+}
@@ -0,0 +1,40 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+func newConfigPathCmd() *cobra.Command {
+	configPathCmd := &cobra.Command{
+		Use:   "path",
+		Short: "Print the path of the config file of sd-local",
+		Long: `Print the absolute path of the config file which sd-local loads.
+The file is created with the default config if it does not exist.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+
+			path, err := filePath()
+			if err != nil {
+				return err
+			}
+
+			config, err := configNew(path)
+			if err != nil {
+				return err
+			}
+			defer config.Close()
+
+			absPath, err := config.FilePath()
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), absPath)
+			return nil
+		},
+	}
+
+	return configPathCmd
+}
@@ -0,0 +1,130 @@
+package config
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/screwdriver-cd/sd-local/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigPathCmd(t *testing.T) {
+	f, err := os.Open("./testdata/config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	cnfPath, err := createRandNameConfig(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(cnfPath)
+
+	absPath, err := filepath.Abs(cnfPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	preconf := configNew
+	defer func() {
+		configNew = preconf
+	}()
+	configNew = func(configPath string) (c config.Config, err error) {
+		return config.New(cnfPath)
+	}
+
+	testCase := []struct {
+		name     string
+		args     []string
+		wantOut  string
+		checkErr bool
+	}{
+		{
+			name:    "success",
+			args:    []string{"path"},
+			wantOut: absPath + "\n",
+		},
+		{
+			name:     "failure by too many args",
+			args:     []string{"path", "many"},
+			wantOut:  "Error: unknown command \"many\" for \"config path\"\n",
+			checkErr: true,
+		},
+	}
+
+	for _, tt := range testCase {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := NewConfigCmd()
+			cmd.SilenceUsage = true
+			cmd.SetArgs(tt.args)
+			buf := bytes.NewBuffer(nil)
+			cmd.SetOut(buf)
+			err := cmd.Execute()
+			if tt.checkErr {
+				assert.NotNil(t, err)
+			} else {
+				assert.Nil(t, err)
+			}
+			assert.Equal(t, tt.wantOut, buf.String())
+		})
+	}
+}
+
+func TestFilePath(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sdlocal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	defer func(path string) {
+		flagFilePath = path
+	}(flagFilePath)
+
+	testCase := []struct {
+		name     string
+		flagPath string
+		envPath  string
+		wantPath string
+	}{
+		{
+			name:     "success with SD_LOCAL_CONFIG",
+			envPath:  filepath.Join(dir, "env", "config"),
+			wantPath: filepath.Join(dir, "env", "config"),
+		},
+		{
+			name:     "success with --config over SD_LOCAL_CONFIG",
+			flagPath: filepath.Join(dir, "flag", "config"),
+			envPath:  filepath.Join(dir, "env", "config"),
+			wantPath: filepath.Join(dir, "flag", "config"),
+		},
+	}
+
+	for _, tt := range testCase {
+		t.Run(tt.name, func(t *testing.T) {
+			os.Setenv(config.EnvFilePath, tt.envPath)
+			defer os.Unsetenv(config.EnvFilePath)
+			flagFilePath = tt.flagPath
+
+			path, err := FilePath()
+			assert.Nil(t, err)
+			assert.Equal(t, tt.wantPath, path)
+
+			cmd := NewConfigCmd()
+			cmd.SetArgs([]string{"path"})
+			buf := bytes.NewBuffer(nil)
+			cmd.SetOut(buf)
+			err = cmd.Execute()
+			assert.Nil(t, err)
+			assert.Equal(t, tt.wantPath+"\n", buf.String())
+
+			// the config file is created in the path if it does not exist
+			_, err = os.Stat(tt.wantPath)
+			assert.Nil(t, err)
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package config
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func newConfigRenameCmd() *cobra.Command {
+	configRenameCmd := &cobra.Command{
+		Use:   "rename [old] [new]",
+		Short: "Rename the config of sd-local",
+		Long: `Rename the config of sd-local.
+If the renamed config is the current config, the current config follows the new name.`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+
+			oldName, newName := args[0], args[1]
+
+			path, err := filePath()
+			if err != nil {
+				return err
+			}
+
+			config, err := configNew(path)
+			if err != nil {
+				return err
+			}
+			defer config.Close()
+
+			err = config.RenameEntry(oldName, newName)
+			if err != nil {
+				return err
+			}
+
+			err = config.Save()
+			if err != nil {
+				return err
+			}
+			return nil
+		},
+	}
+
+	return configRenameCmd
+}
@@ -1,16 +1,79 @@
 package config
 
 import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
 	"strings"
 
+	"github.com/screwdriver-cd/sd-local/config"
+	"github.com/screwdriver-cd/sd-local/screwdriver"
 	"github.com/spf13/cobra"
+	"golang.org/x/term"
 )
 
+var (
+	isTerminal        = term.IsTerminal
+	readPassword      = term.ReadPassword
+	newTokenValidator = screwdriver.NewTokenValidator
+)
+
+// tokenKey is the key of the token, which can be verified by the API before it is saved
+const tokenKey = "token"
+
 func isInvalidKeyError(err error) bool {
 	return strings.Contains(err.Error(), "invalid key")
 }
 
+// readValue reads the value from the terminal without echo, or a single line if stdin is not a terminal
+func readValue(cmd *cobra.Command, key string) (string, error) {
+	in := cmd.InOrStdin()
+
+	if f, ok := in.(*os.File); ok && isTerminal(int(f.Fd())) {
+		fmt.Fprintf(cmd.ErrOrStderr(), "Enter %s: ", key)
+		value, err := readPassword(int(f.Fd()))
+		fmt.Fprintln(cmd.ErrOrStderr())
+		if err != nil {
+			return "", err
+		}
+		return string(value), nil
+	}
+
+	line, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// verifyToken checks that the API of the entry accepts the token with the proxies and the CA bundle of the entry
+func verifyToken(entry *config.Entry, token string) error {
+	if entry.APIURL == "" {
+		return fmt.Errorf("can't verify the token without api-url, please set it first")
+	}
+
+	resolved, err := entry.Resolve()
+	if err != nil {
+		return err
+	}
+	client, err := screwdriver.NewHTTPClient(screwdriver.HTTPClientOption{
+		HTTPProxy:  resolved.HTTPProxy,
+		HTTPSProxy: resolved.HTTPSProxy,
+		CABundle:   resolved.CABundle,
+	})
+	if err != nil {
+		return err
+	}
+
+	return newTokenValidator("sd-local", client).ValidateToken(context.Background(), entry.APIURL, token)
+}
+
 func newConfigSetCmd() *cobra.Command {
+	var fromStdin bool
+	var verify bool
+
 	configSetCmd := &cobra.Command{
 		Use:   "set [key] [value]",
 		Short: "Set the config of sd-local",
@@ -19,26 +82,63 @@ Can set the below settings:
 * Screwdriver.cd API URL as "api-url"
 * Screwdriver.cd Store URL as "store-url"
 * Screwdriver.cd Token as "token"
+* Where to store the token as "token-source" (empty for the config file or "keychain" for the OS keychain)
 * Screwdriver.cd launcher version as "launcher-version"
+* HTTP proxy URL as "http-proxy"
+* HTTPS proxy URL as "https-proxy"
+* Runtime to run builds as "runtime" (docker, podman or k8s)
+* Address of the daemon of docker or podman as "docker-host" (a URL like tcp://host:2376 or a path of a unix socket, ~ and environment variables in the path are expanded on use, defaults to DOCKER_HOST or CONTAINER_HOST)
+* Default timeout of builds as "timeout" (e.g. 30m, overridden by --timeout of build)
+* Path to the CA certificate bundle to trust as "ca-bundle" (~ and environment variables are expanded on use)
+* Directory of the Docker config.json with the registry credentials as "registry-auth" (defaults to ~/.docker with sudo)
 * Screwdriver.cd UUID as "uuid"
-* Screwdriver.cd launcher image as "launcher-image"`,
-		Args: cobra.ExactArgs(2),
+* Screwdriver.cd launcher image as "launcher-image" (pin it with "<image>@sha256:<digest>" to verify the pulled image)
+* Tarball made by docker save to load the launcher image from instead of pulling it as "launcher-archive"
+
+The value can be read from stdin with --stdin not to leave secrets in the shell history.
+The token can be verified by the API of the config with --verify before it is saved.`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if verify && len(args) != 0 && args[0] != tokenKey {
+				return fmt.Errorf("can't verify %s by `verify`, only the token can be verified", args[0])
+			}
+			if fromStdin {
+				return cobra.ExactArgs(1)(cmd, args)
+			}
+			return cobra.ExactArgs(2)(cmd, args)
+		},
 		RunE: func(cmd *cobra.Command, args []string) error {
 			cmd.SilenceUsage = true
 
-			key, value := args[0], args[1]
+			key := args[0]
+
+			var value string
+			if fromStdin {
+				v, err := readValue(cmd, key)
+				if err != nil {
+					return err
+				}
+				value = v
+			} else {
+				value = args[1]
+			}
 
 			path, err := filePath()
 			if err != nil {
 				return err
 			}
 
-			config, err := configNew(path)
+			c, err := configNew(path)
 			if err != nil {
 				return err
 			}
+			defer c.Close()
 
-			entry, err := config.Entry(config.Current)
+			entry, err := c.CurrentEntry()
+			if err != nil {
+				return err
+			}
+
+			err = c.CheckUnlocked(c.Current)
 			if err != nil {
 				return err
 			}
@@ -55,7 +155,15 @@ Can set the below settings:
 				}
 			}
 
-			err = config.Save()
+			// the token rejected by the API is not saved
+			if verify {
+				err = verifyToken(entry, value)
+				if err != nil {
+					return err
+				}
+			}
+
+			err = c.Save()
 			if err != nil {
 				return err
 			}
@@ -63,5 +171,17 @@ Can set the below settings:
 		},
 	}
 
+	configSetCmd.Flags().BoolVar(
+		&fromStdin,
+		"stdin",
+		false,
+		"Read the value from stdin. It is not echoed when stdin is a terminal.")
+
+	configSetCmd.Flags().BoolVar(
+		&verify,
+		"verify",
+		false,
+		"Verify the token by the API of the config before saving it. Only the token can be verified.")
+
 	return configSetCmd
 }
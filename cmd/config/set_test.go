@@ -2,12 +2,18 @@ package config
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"math/rand"
+	"net/http"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/screwdriver-cd/sd-local/config"
+	"github.com/screwdriver-cd/sd-local/screwdriver"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -27,18 +33,38 @@ func TestConfigSetCmd(t *testing.T) {
 	testCase := []struct {
 		name     string
 		args     []string
+		stdin    string
 		wantOut  string
 		checkErr bool
 	}{
 		{
 			name:     "success",
+			args:     []string{"set", "api-url", "https://example.com"},
+			wantOut:  "",
+			checkErr: false,
+		},
+		{
+			name:     "failure by invalid url",
 			args:     []string{"set", "api-url", "example.com"},
 			wantOut:  "",
+			checkErr: true,
+		},
+		{
+			name:     "success with --stdin",
+			args:     []string{"set", "token", "--stdin"},
+			stdin:    "secret-token\n",
+			wantOut:  "",
 			checkErr: false,
 		},
+		{
+			name:     "failure by value with --stdin",
+			args:     []string{"set", "token", "secret-token", "--stdin"},
+			wantOut:  "",
+			checkErr: true,
+		},
 		{
 			name:     "failure by too many args",
-			args:     []string{"set", "api-url", "example.com", "many"},
+			args:     []string{"set", "api-url", "https://example.com", "many"},
 			wantOut:  "",
 			checkErr: true,
 		},
@@ -54,6 +80,7 @@ func TestConfigSetCmd(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			cmd := NewConfigCmd()
 			cmd.SetArgs(tt.args)
+			cmd.SetIn(strings.NewReader(tt.stdin))
 			buf := bytes.NewBuffer(nil)
 			cmd.SetOut(buf)
 			err := cmd.Execute()
@@ -66,4 +93,132 @@ func TestConfigSetCmd(t *testing.T) {
 
 		})
 	}
+
+	c, err := config.New(cnfPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "secret-token", c.Entries[c.Current].Token)
+}
+
+func TestConfigSetCmdWithTerminal(t *testing.T) {
+	rand.Seed(time.Now().UnixNano())
+	cnfPath := fmt.Sprintf("%vconfig", rand.Int())
+	defer os.Remove(cnfPath)
+
+	defFilePath, defIsTerminal, defReadPassword := filePath, isTerminal, readPassword
+	defer func() {
+		filePath, isTerminal, readPassword = defFilePath, defIsTerminal, defReadPassword
+	}()
+	filePath = func() (string, error) {
+		return cnfPath, nil
+	}
+	isTerminal = func(fd int) bool { return true }
+	readPassword = func(fd int) ([]byte, error) { return []byte("terminal-token"), nil }
+
+	cmd := NewConfigCmd()
+	cmd.SetArgs([]string{"set", "token", "--stdin"})
+	cmd.SetIn(os.Stdin)
+	errBuf := bytes.NewBuffer(nil)
+	cmd.SetErr(errBuf)
+	err := cmd.Execute()
+	assert.Nil(t, err)
+	assert.Equal(t, "Enter token: \n", errBuf.String())
+
+	c, err := config.New(cnfPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "terminal-token", c.Entries[c.Current].Token)
+}
+
+type mockTokenValidator struct {
+	apiURL *string
+	err    error
+}
+
+func (m mockTokenValidator) ValidateToken(ctx context.Context, apiURL, token string) error {
+	*m.apiURL = apiURL
+	return m.err
+}
+
+func TestConfigSetCmdWithVerify(t *testing.T) {
+	rand.Seed(time.Now().UnixNano())
+	cnfPath := fmt.Sprintf("%vconfig", rand.Int())
+	defer os.Remove(cnfPath)
+
+	defFilePath, defNewTokenValidator := filePath, newTokenValidator
+	defer func() {
+		filePath, newTokenValidator = defFilePath, defNewTokenValidator
+	}()
+	filePath = func() (string, error) {
+		return cnfPath, nil
+	}
+
+	testCase := []struct {
+		name        string
+		args        []string
+		validateErr error
+		wantErr     string
+		wantAPIURL  string
+		wantToken   string
+	}{
+		{
+			name:    "failure without api-url",
+			args:    []string{"set", "token", "no-api-token", "--verify"},
+			wantErr: "can't verify the token without api-url, please set it first",
+		},
+		{
+			name: "success without --verify",
+			args: []string{"set", "api-url", "https://api.example.com"},
+		},
+		{
+			name:       "success with valid token",
+			args:       []string{"set", "token", "valid-token", "--verify"},
+			wantAPIURL: "https://api.example.com",
+			wantToken:  "valid-token",
+		},
+		{
+			name:        "failure with invalid token",
+			args:        []string{"set", "token", "invalid-token", "--verify"},
+			validateErr: errors.New("token is rejected by https://api.example.com: StatusCode 401"),
+			wantErr:     "token is rejected by https://api.example.com: StatusCode 401",
+			wantAPIURL:  "https://api.example.com",
+			wantToken:   "valid-token",
+		},
+		{
+			name:      "failure by --verify of other key",
+			args:      []string{"set", "api-url", "https://api.example.com", "--verify"},
+			wantErr:   "can't verify api-url by `verify`, only the token can be verified",
+			wantToken: "valid-token",
+		},
+	}
+
+	for _, tt := range testCase {
+		t.Run(tt.name, func(t *testing.T) {
+			var apiURL string
+			newTokenValidator = func(ua string, client *http.Client) screwdriver.TokenValidator {
+				return mockTokenValidator{apiURL: &apiURL, err: tt.validateErr}
+			}
+
+			cmd := NewConfigCmd()
+			cmd.SetArgs(tt.args)
+			cmd.SetOut(bytes.NewBuffer(nil))
+			cmd.SetErr(bytes.NewBuffer(nil))
+			err := cmd.Execute()
+			if tt.wantErr == "" {
+				assert.Nil(t, err)
+			} else {
+				assert.Equal(t, tt.wantErr, err.Error())
+			}
+			assert.Equal(t, tt.wantAPIURL, apiURL)
+
+			// the token is not saved if it is rejected
+			c, err := config.New(cnfPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, tt.wantToken, c.Entries[c.Current].Token)
+		})
+	}
 }
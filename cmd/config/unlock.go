@@ -0,0 +1,43 @@
+package config
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func newConfigUnlockCmd() *cobra.Command {
+	configUnlockCmd := &cobra.Command{
+		Use:   "unlock [name]",
+		Short: "Unlock the config of sd-local",
+		Long:  `Unlock the config of sd-local locked by lock sub command.`,
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+
+			name := args[0]
+
+			path, err := filePath()
+			if err != nil {
+				return err
+			}
+
+			config, err := configNew(path)
+			if err != nil {
+				return err
+			}
+			defer config.Close()
+
+			err = config.Unlock(name)
+			if err != nil {
+				return err
+			}
+
+			err = config.Save()
+			if err != nil {
+				return err
+			}
+			return nil
+		},
+	}
+
+	return configUnlockCmd
+}
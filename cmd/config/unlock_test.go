@@ -0,0 +1,94 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/screwdriver-cd/sd-local/config"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigUnlockCmd(t *testing.T) {
+	f, err := os.Open("./testdata/config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	cnfPath, err := createRandNameConfig(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(cnfPath)
+
+	fp := filePath
+	defer func() {
+		filePath = fp
+	}()
+	filePath = func() (string, error) {
+		return cnfPath, nil
+	}
+
+	testCase := []struct {
+		name     string
+		args     []string
+		wantOut  string
+		checkErr bool
+	}{
+		{
+			name:     "success to lock",
+			args:     []string{"lock", "default"},
+			wantOut:  "",
+			checkErr: false,
+		},
+		{
+			name:     "success",
+			args:     []string{"unlock", "default"},
+			wantOut:  "",
+			checkErr: false,
+		},
+		{
+			name:     "success to set unlocked config",
+			args:     []string{"set", "token", "new-token"},
+			wantOut:  "",
+			checkErr: false,
+		},
+		{
+			name:     "failure by Entry that does not exist",
+			args:     []string{"unlock", "doesnotexist"},
+			wantOut:  "",
+			checkErr: true,
+		},
+		{
+			name:     "failure by too little args",
+			args:     []string{"unlock"},
+			wantOut:  "",
+			checkErr: true,
+		},
+	}
+
+	for _, tt := range testCase {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := NewConfigCmd()
+			cmd.SetArgs(tt.args)
+			buf := bytes.NewBuffer(nil)
+			cmd.SetOut(buf)
+			err := cmd.Execute()
+			if tt.checkErr {
+				assert.NotNil(t, err)
+			} else {
+				assert.Nil(t, err)
+				assert.Equal(t, tt.wantOut, buf.String())
+			}
+		})
+	}
+
+	c, err := config.New(cnfPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.False(t, c.Entries["default"].Locked)
+	assert.Equal(t, "new-token", c.Entries["default"].Token)
+}
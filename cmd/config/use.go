@@ -25,6 +25,7 @@ You can confirm the current config in view sub command.`,
 			if err != nil {
 				return err
 			}
+			defer config.Close()
 
 			err = config.SetCurrent(name)
 			if err != nil {
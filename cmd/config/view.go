@@ -1,14 +1,19 @@
 package config
 
 import (
+	"encoding/json"
 	"fmt"
 	"strings"
 
 	"github.com/go-yaml/yaml"
+	"github.com/screwdriver-cd/sd-local/config"
 	"github.com/spf13/cobra"
 )
 
 func newConfigViewCmd() *cobra.Command {
+	var output string
+	var showToken bool
+
 	configViewCmd := &cobra.Command{
 		Use:   "view",
 		Short: "View the config of sd-local.",
@@ -16,10 +21,13 @@ func newConfigViewCmd() *cobra.Command {
 Can see the below settings:
 * Screwdriver.cd API URL
 * Screwdriver.cd Store URL
-* Screwdriver.cd Token
+* Screwdriver.cd Token (masked when it is stored in the OS keychain)
 * Screwdriver.cd launcher version
 * Screwdriver.cd UUID
 * Screwdriver.cd launcher image`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			return validateOutput(output)
+		},
 		RunE: func(cmd *cobra.Command, args []string) error {
 			cmd.SilenceUsage = true
 
@@ -28,18 +36,40 @@ Can see the below settings:
 				return err
 			}
 
-			config, err := configNew(path)
+			c, err := configNew(path)
 			if err != nil {
 				return err
 			}
+			defer c.Close()
+
+			if output == outputJSON {
+				entry, err := c.CurrentEntry()
+				if err != nil {
+					return err
+				}
+
+				entry = entry.Clone()
+				if !showToken && entry.Token != "" {
+					entry.Token = redactedToken
+				}
+
+				encoder := json.NewEncoder(cmd.OutOrStdout())
+				encoder.SetIndent("", "  ")
+				return encoder.Encode(entry)
+			}
 
-			for name, entry := range config.Entries {
-				if name == config.Current {
+			for name, entry := range c.Entries {
+				if name == c.Current {
 					fmt.Fprintf(cmd.OutOrStdout(), "* %s:\n", name)
 				} else {
 					fmt.Fprintf(cmd.OutOrStdout(), "  %s:\n", name)
 				}
 
+				if entry.UseKeychain() {
+					entry = entry.Clone()
+					entry.Token = config.MaskedKeychainToken
+				}
+
 				yaml, err := yaml.Marshal(entry)
 				if err != nil {
 					return err
@@ -57,5 +87,18 @@ Can see the below settings:
 		},
 	}
 
+	configViewCmd.Flags().StringVarP(
+		&output,
+		"output",
+		"o",
+		"",
+		"Output format. Only 'json' is supported, which prints the current config.")
+
+	configViewCmd.Flags().BoolVar(
+		&showToken,
+		"show-token",
+		false,
+		"Show the token without redaction in json output.")
+
 	return configViewCmd
 }
@@ -2,6 +2,7 @@ package config
 
 import (
 	"bytes"
+	"os"
 	"strings"
 	"testing"
 
@@ -51,7 +52,7 @@ func TestViewCmd(t *testing.T) {
 		{
 			name: "success with no current",
 			args: []string{"view"},
-			expect: []string{`  default:
+			expect: []string{`* default:
     api-url: api.screwdriver.com
     store-url: store.screwdriver.com
     token: sd-token
@@ -70,16 +71,38 @@ func TestViewCmd(t *testing.T) {
 `},
 			config: "./testdata/config_no_current",
 		},
+		{
+			name: "success with token in keychain",
+			args: []string{"view"},
+			expect: []string{`  test:
+    api-url: api-test.screwdriver.com
+    store-url: store-test.screwdriver.com
+    token: '***keychain***'
+    token-source: keychain
+    UUID: eb004dc1-614c-11eb-bab9-0242ac120002
+`},
+			config: "./testdata/config_keychain",
+		},
 	}
 
 	for _, tt := range testCase {
 		t.Run(tt.name, func(t *testing.T) {
-			testConfig = tt.config
+			f, err := os.Open(tt.config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+			testConfig, err = createRandNameConfig(f)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.Remove(testConfig)
+
 			cmd := NewConfigCmd()
 			cmd.SetArgs(tt.args)
 			buf := bytes.NewBuffer(nil)
 			cmd.SetOut(buf)
-			err := cmd.Execute()
+			err = cmd.Execute()
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -93,3 +116,73 @@ func TestViewCmd(t *testing.T) {
 		})
 	}
 }
+
+func TestViewCmdWithJSONOutput(t *testing.T) {
+	fp := filePath
+	defer func() {
+		filePath = fp
+	}()
+
+	filePath = func() (string, error) {
+		return "./testdata/config", nil
+	}
+
+	testCase := []struct {
+		name     string
+		args     []string
+		expect   string
+		checkErr bool
+	}{
+		{
+			name: "success",
+			args: []string{"view", "-o", "json"},
+			expect: `{
+  "apiURL": "api.screwdriver.com",
+  "storeURL": "store.screwdriver.com",
+  "token": "***",
+  "uuid": "-",
+  "launcher": {
+    "version": "1.0.0",
+    "image": "screwdrivercd/launcher"
+  }
+}
+`,
+		},
+		{
+			name: "success with --show-token",
+			args: []string{"view", "--output", "json", "--show-token"},
+			expect: `{
+  "apiURL": "api.screwdriver.com",
+  "storeURL": "store.screwdriver.com",
+  "token": "sd-token",
+  "uuid": "-",
+  "launcher": {
+    "version": "1.0.0",
+    "image": "screwdrivercd/launcher"
+  }
+}
+`,
+		},
+		{
+			name:     "failure by unknown output format",
+			args:     []string{"view", "-o", "xml"},
+			checkErr: true,
+		},
+	}
+
+	for _, tt := range testCase {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := NewConfigCmd()
+			cmd.SetArgs(tt.args)
+			buf := bytes.NewBuffer(nil)
+			cmd.SetOut(buf)
+			err := cmd.Execute()
+			if tt.checkErr {
+				assert.NotNil(t, err)
+			} else {
+				assert.Nil(t, err)
+				assert.Equal(t, tt.expect, buf.String())
+			}
+		})
+	}
+}
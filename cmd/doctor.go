@@ -0,0 +1,296 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/screwdriver-cd/sd-local/config"
+	"github.com/screwdriver-cd/sd-local/launch"
+	"github.com/screwdriver-cd/sd-local/screwdriver"
+	"github.com/spf13/cobra"
+)
+
+var (
+	checkRuntime   = launch.CheckRuntime
+	pullLauncher   = launch.PullLauncher
+	findContainers = launch.FindContainers
+)
+
+// doctorHTTPTimeout is the timeout of the requests to the API and the store
+const doctorHTTPTimeout = 10 * time.Second
+
+const (
+	checkPass = "PASS"
+	checkFail = "FAIL"
+	checkWarn = "WARN"
+	checkSkip = "SKIP"
+)
+
+// check is a diagnosis of doctor. The failure of the critical checks makes the builds fail,
+// and the others are reported as warnings.
+type check struct {
+	name     string
+	critical bool
+	hint     string
+	// run returns the message of the passed check, or the error
+	run func() (string, error)
+	// skip returns the reason to skip the check if it can't be run because of the failed checks
+	skip func(failed map[string]bool) string
+}
+
+// statusURL returns the URL of the status endpoint of the API or the store
+func statusURL(baseURL, apiVersion string) (string, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return "", err
+	}
+	u.Path = path.Join(u.Path, apiVersion, "status")
+	return u.String(), nil
+}
+
+// getStatus requests the status endpoint and returns an error if the response is not expected
+func getStatus(client *http.Client, baseURL, apiVersion string, ok func(statusCode int) bool) error {
+	u, err := statusURL(baseURL, apiVersion)
+	if err != nil {
+		return fmt.Errorf("invalid URL %s: %v", baseURL, err)
+	}
+	res, err := client.Get(u)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if !ok(res.StatusCode) {
+		return fmt.Errorf("%s responded with StatusCode %d", u, res.StatusCode)
+	}
+	return nil
+}
+
+// newChecks returns the checks of the environment to run the builds with the current config
+func newChecks(runtimeName string) []check {
+	var entry *config.Entry
+	var client *http.Client
+
+	configured := func(failed map[string]bool) string {
+		if failed["config"] {
+			return "the config is not valid"
+		}
+		return ""
+	}
+
+	runtime := func() string {
+		if runtimeName != "" {
+			return runtimeName
+		}
+		if entry != nil && entry.Runtime != "" {
+			return entry.Runtime
+		}
+		return config.RuntimeDocker
+	}
+
+	return []check{
+		{
+			name:     "config",
+			critical: true,
+			hint:     "create the config with `sd-local config create` and set the missing or invalid settings with `sd-local config set`",
+			run: func() (string, error) {
+				name, e, err := currentEntry()
+				if err != nil {
+					return "", err
+				}
+				entry = e
+				if err := entry.Validate(); err != nil {
+					return "", err
+				}
+				resolved, err := entry.Resolve()
+				if err != nil {
+					return "", err
+				}
+				client, err = screwdriver.NewHTTPClient(screwdriver.HTTPClientOption{
+					HTTPProxy:  resolved.HTTPProxy,
+					HTTPSProxy: resolved.HTTPSProxy,
+					CABundle:   resolved.CABundle,
+				})
+				if err != nil {
+					return "", err
+				}
+				c := *client
+				c.Timeout = doctorHTTPTimeout
+				client = &c
+				return fmt.Sprintf("the config %s is valid", name), nil
+			},
+		},
+		{
+			name:     "runtime",
+			critical: true,
+			hint:     "start the runtime and check that the user can access it, or check the current context with `kubectl config current-context` for k8s",
+			run: func() (string, error) {
+				if err := checkRuntime(runtime()); err != nil {
+					return "", err
+				}
+				return fmt.Sprintf("%s is reachable", runtime()), nil
+			},
+		},
+		{
+			name:     "launcher",
+			critical: true,
+			hint:     "check launcher-image and launcher-version of the config, and log in to the registry or set registry-auth with `sd-local config set registry-auth` for the private registries",
+			run: func() (string, error) {
+				if err := pullLauncher(runtime(), entry.Launcher, entry.RegistryAuth); err != nil {
+					return "", err
+				}
+				return fmt.Sprintf("the launcher image %s is pullable", entry.Launcher.Image), nil
+			},
+			skip: func(failed map[string]bool) string {
+				if reason := configured(failed); reason != "" {
+					return reason
+				}
+				if failed["runtime"] {
+					return "the runtime is not reachable"
+				}
+				if runtime() == config.RuntimeKubernetes {
+					return "the launcher image is pulled by the nodes of the cluster with k8s"
+				}
+				return ""
+			},
+		},
+		{
+			name:     "api",
+			critical: true,
+			hint:     "check api-url of the config, and http-proxy, https-proxy and ca-bundle if the API is accessed via a proxy",
+			run: func() (string, error) {
+				err := getStatus(client, entry.APIURL, "v4", func(statusCode int) bool { return statusCode == http.StatusOK })
+				if err != nil {
+					return "", err
+				}
+				return fmt.Sprintf("%s responds", entry.APIURL), nil
+			},
+			skip: configured,
+		},
+		{
+			name:     "token",
+			critical: true,
+			hint:     "create a new user access token in the user settings of Screwdriver.cd and set it with `sd-local config set token`",
+			run: func() (string, error) {
+				api := apiNew(entry.APIURL, entry.Token, generateUserAgent(entry.UUID), client)
+				if err := api.InitJWT(); err != nil {
+					return "", err
+				}
+				return "the token is valid", nil
+			},
+			skip: func(failed map[string]bool) string {
+				if reason := configured(failed); reason != "" {
+					return reason
+				}
+				if failed["api"] {
+					return "the API does not respond"
+				}
+				return ""
+			},
+		},
+		{
+			name: "store",
+			hint: "check store-url of the config, which is used by the builds to get the commands and the caches",
+			run: func() (string, error) {
+				err := getStatus(client, entry.StoreURL, "v1", func(statusCode int) bool { return statusCode < http.StatusInternalServerError })
+				if err != nil {
+					return "", err
+				}
+				return fmt.Sprintf("%s is reachable", entry.StoreURL), nil
+			},
+			skip: configured,
+		},
+		{
+			name: "containers",
+			hint: "remove them with `sd-local prune` unless any build is running in them",
+			run: func() (string, error) {
+				names, err := findContainers(runtime())
+				if err != nil {
+					return "", err
+				}
+				if len(names) != 0 {
+					return "", fmt.Errorf("%d build container(s) of sd-local are left: %s", len(names), strings.Join(names, ", "))
+				}
+				return "no build container of sd-local is left", nil
+			},
+			skip: func(failed map[string]bool) string {
+				if failed["runtime"] {
+					return "the runtime is not reachable"
+				}
+				if runtime() == config.RuntimeKubernetes {
+					return "the build containers are not labeled with k8s"
+				}
+				return ""
+			},
+		},
+	}
+}
+
+func newDoctorCmd() *cobra.Command {
+	var runtimeName string
+
+	doctorCmd := &cobra.Command{
+		Annotations: map[string]string{usesTokenAnnotation: ""},
+		Use:         "doctor",
+		Short:       "Diagnose the environment to run the builds.",
+		Long: `Diagnose the environment to run the builds with the current config.
+The config, the container runtime, the launcher image, the API, the token, the store and the build containers left are checked,
+and the hints to fix them are printed for the failed checks.
+It exits with non-zero status if any critical check fails.`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if err := cobra.NoArgs(cmd, args); err != nil {
+				return err
+			}
+			return config.ValidateRuntime(runtimeName)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+
+			failures := 0
+			failed := map[string]bool{}
+			for _, c := range newChecks(runtimeName) {
+				if c.skip != nil {
+					if reason := c.skip(failed); reason != "" {
+						failed[c.name] = true
+						fmt.Fprintf(cmd.OutOrStdout(), "[%s] %s: %s\n", checkSkip, c.name, reason)
+						continue
+					}
+				}
+
+				msg, err := c.run()
+				failed[c.name] = err != nil
+				if err == nil {
+					fmt.Fprintf(cmd.OutOrStdout(), "[%s] %s: %s\n", checkPass, c.name, msg)
+					continue
+				}
+
+				status := checkWarn
+				if c.critical {
+					status = checkFail
+					failures++
+				}
+				fmt.Fprintf(cmd.OutOrStdout(), "[%s] %s: %v\n       hint: %s\n", status, c.name, err, c.hint)
+			}
+
+			if failures != 0 {
+				err := fmt.Errorf("%d critical check(s) failed", failures)
+				if failed["runtime"] {
+					return withExitCode(err, ExitRuntime)
+				}
+				return err
+			}
+			return nil
+		},
+	}
+
+	doctorCmd.Flags().StringVar(
+		&runtimeName,
+		"runtime",
+		"",
+		"Runtime to check, docker, podman or k8s. The runtime of the config or docker is used if it is not specified.")
+
+	return doctorCmd
+}
@@ -0,0 +1,192 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/screwdriver-cd/sd-local/config"
+	"github.com/screwdriver-cd/sd-local/screwdriver"
+	"github.com/stretchr/testify/assert"
+)
+
+type failedJWTAPI struct {
+	mockAPI
+}
+
+func (mock failedJWTAPI) InitJWT() error {
+	return errors.New("failed to get JWT: StatusCode 401")
+}
+
+func TestDoctorCmd(t *testing.T) {
+	defConfigNew, defAPINew, defCheckRuntime, defPullLauncher, defFindContainers := configNew, apiNew, checkRuntime, pullLauncher, findContainers
+	defer func() {
+		configNew, apiNew, checkRuntime, pullLauncher, findContainers = defConfigNew, defAPINew, defCheckRuntime, defPullLauncher, defFindContainers
+	}()
+
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v4/status" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte("OK"))
+	}))
+	defer api.Close()
+	store := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer store.Close()
+
+	newEntry := func() *config.Entry {
+		return &config.Entry{
+			APIURL:   api.URL,
+			StoreURL: api.URL,
+			Token:    "token",
+			Launcher: config.Launcher{Version: "stable", Image: "screwdrivercd/launcher"},
+		}
+	}
+
+	cases := []struct {
+		name       string
+		args       []string
+		entry      func(e *config.Entry)
+		runtimeErr error
+		pullErr    error
+		containers []string
+		jwtErr     bool
+		expect     string
+		expectErr  string
+		exitCode   int
+	}{
+		{
+			name: "success",
+			expect: "[PASS] config: the config default is valid\n" +
+				"[PASS] runtime: docker is reachable\n" +
+				"[PASS] launcher: the launcher image screwdrivercd/launcher is pullable\n" +
+				"[PASS] api: " + api.URL + " responds\n" +
+				"[PASS] token: the token is valid\n" +
+				"[PASS] store: " + api.URL + " is reachable\n" +
+				"[PASS] containers: no build container of sd-local is left\n",
+		},
+		{
+			name:       "success with the warning of the containers",
+			containers: []string{"sdlocal-main-20210102150405", "sdlocal-test-20210102150405"},
+			expect: "[PASS] config: the config default is valid\n" +
+				"[PASS] runtime: docker is reachable\n" +
+				"[PASS] launcher: the launcher image screwdrivercd/launcher is pullable\n" +
+				"[PASS] api: " + api.URL + " responds\n" +
+				"[PASS] token: the token is valid\n" +
+				"[PASS] store: " + api.URL + " is reachable\n" +
+				"[WARN] containers: 2 build container(s) of sd-local are left: sdlocal-main-20210102150405, sdlocal-test-20210102150405\n" +
+				"       hint: remove them with `sd-local prune` unless any build is running in them\n",
+		},
+		{
+			name:  "success with k8s and the warning of the store",
+			args:  []string{"--runtime", "k8s"},
+			entry: func(e *config.Entry) { e.StoreURL = store.URL },
+			expect: "[PASS] config: the config default is valid\n" +
+				"[PASS] runtime: k8s is reachable\n" +
+				"[SKIP] launcher: the launcher image is pulled by the nodes of the cluster with k8s\n" +
+				"[PASS] api: " + api.URL + " responds\n" +
+				"[PASS] token: the token is valid\n" +
+				"[WARN] store: " + store.URL + "/v1/status responded with StatusCode 503\n" +
+				"       hint: check store-url of the config, which is used by the builds to get the commands and the caches\n" +
+				"[SKIP] containers: the build containers are not labeled with k8s\n",
+		},
+		{
+			name:  "failure by config",
+			entry: func(e *config.Entry) { e.Token = "" },
+			expect: "[FAIL] config: missing required settings:\n  * token\n" +
+				"       hint: create the config with `sd-local config create` and set the missing or invalid settings with `sd-local config set`\n" +
+				"[PASS] runtime: docker is reachable\n" +
+				"[SKIP] launcher: the config is not valid\n" +
+				"[SKIP] api: the config is not valid\n" +
+				"[SKIP] token: the config is not valid\n" +
+				"[SKIP] store: the config is not valid\n" +
+				"[PASS] containers: no build container of sd-local is left\n",
+			expectErr: "1 critical check(s) failed",
+			exitCode:  ExitUsage,
+		},
+		{
+			name:       "failure by runtime and token",
+			entry:      func(e *config.Entry) { e.Runtime = "podman" },
+			runtimeErr: errors.New("failed to connect to podman: exit status 125"),
+			jwtErr:     true,
+			expect: "[PASS] config: the config default is valid\n" +
+				"[FAIL] runtime: failed to connect to podman: exit status 125\n" +
+				"       hint: start the runtime and check that the user can access it, or check the current context with `kubectl config current-context` for k8s\n" +
+				"[SKIP] launcher: the runtime is not reachable\n" +
+				"[PASS] api: " + api.URL + " responds\n" +
+				"[FAIL] token: failed to get JWT: StatusCode 401\n" +
+				"       hint: create a new user access token in the user settings of Screwdriver.cd and set it with `sd-local config set token`\n" +
+				"[PASS] store: " + api.URL + " is reachable\n" +
+				"[SKIP] containers: the runtime is not reachable\n",
+			expectErr: "2 critical check(s) failed",
+			exitCode:  ExitRuntime,
+		},
+		{
+			name: "failure by launcher and api",
+			entry: func(e *config.Entry) {
+				e.APIURL = store.URL
+			},
+			pullErr: errors.New("not authorized to pull screwdrivercd/launcher:stable from docker.io"),
+			expect: "[PASS] config: the config default is valid\n" +
+				"[PASS] runtime: docker is reachable\n" +
+				"[FAIL] launcher: not authorized to pull screwdrivercd/launcher:stable from docker.io\n" +
+				"       hint: check launcher-image and launcher-version of the config, and log in to the registry or set registry-auth with `sd-local config set registry-auth` for the private registries\n" +
+				"[FAIL] api: " + store.URL + "/v4/status responded with StatusCode 503\n" +
+				"       hint: check api-url of the config, and http-proxy, https-proxy and ca-bundle if the API is accessed via a proxy\n" +
+				"[SKIP] token: the API does not respond\n" +
+				"[PASS] store: " + api.URL + " is reachable\n" +
+				"[PASS] containers: no build container of sd-local is left\n",
+			expectErr: "2 critical check(s) failed",
+			exitCode:  ExitUsage,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			entry := newEntry()
+			if c.entry != nil {
+				c.entry(entry)
+			}
+			configNew = func(confPath string) (config.Config, error) {
+				return config.Config{Entries: map[string]*config.Entry{"default": entry}, Current: "default"}, nil
+			}
+			checkRuntime = func(runtime string) error { return c.runtimeErr }
+			pullLauncher = func(runtime string, launcher config.Launcher, registryAuth string) error { return c.pullErr }
+			findContainers = func(runtime string) ([]string, error) { return c.containers, nil }
+			apiNew = func(url, token, ua string, client *http.Client) screwdriver.API {
+				if c.jwtErr {
+					return failedJWTAPI{}
+				}
+				return mockAPI{}
+			}
+
+			cmd := newDoctorCmd()
+			cmd.SetArgs(c.args)
+			cmd.SilenceErrors = true
+			buf := bytes.NewBuffer(nil)
+			cmd.SetOut(buf)
+			err := cmd.Execute()
+			assert.Equal(t, c.expect, buf.String())
+			if c.expectErr != "" {
+				assert.Equal(t, c.expectErr, err.Error())
+				assert.Equal(t, c.exitCode, ExitCode(err))
+				return
+			}
+			assert.Nil(t, err)
+		})
+	}
+
+	t.Run("failure by invalid runtime", func(t *testing.T) {
+		cmd := newDoctorCmd()
+		cmd.SetArgs([]string{"--runtime", "lxc"})
+		cmd.SilenceErrors = true
+		cmd.SetOut(bytes.NewBuffer(nil))
+		err := cmd.Execute()
+		assert.NotNil(t, err)
+	})
+}
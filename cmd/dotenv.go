@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// dotenvKeyPattern is the pattern of the keys of the environment variables
+var dotenvKeyPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// dotenvVarPattern matches ${VAR} and $VAR in the values, which refer to the variables defined above in the file, and the escaped \$
+var dotenvVarPattern = regexp.MustCompile(`\\\$|\$(\{[A-Za-z_][A-Za-z0-9_]*\}|[A-Za-z_][A-Za-z0-9_]*)`)
+
+// expandDotenvVars expands the variables in the value with the variables defined above. The undefined ones are expanded to empty.
+func expandDotenvVars(value string, env map[string]string) string {
+	return dotenvVarPattern.ReplaceAllStringFunc(value, func(s string) string {
+		if s == `\$` {
+			return "$"
+		}
+		return env[strings.Trim(s[1:], "{}")]
+	})
+}
+
+// unquoteDotenvValue returns the value in the double quotes with the escape sequences like \n replaced,
+// and the rest of the line after the closing quote
+func unquoteDotenvValue(s string) (string, string, bool) {
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '"':
+			return b.String(), s[i+1:], true
+		case '\\':
+			if i+1 == len(s) {
+				return "", "", false
+			}
+			i++
+			switch s[i] {
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 't':
+				b.WriteByte('\t')
+			case '$':
+				// the escaped $ is kept to be left by the expansion
+				b.WriteString(`\$`)
+			default:
+				b.WriteByte(s[i])
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", "", false
+}
+
+// parseDotenvValue parses the value of a line, which can be quoted by the single or double quotes and followed by a comment.
+// The values except in the single quotes are expanded with the variables defined above.
+func parseDotenvValue(s string, env map[string]string) (string, error) {
+	s = strings.TrimSpace(s)
+	switch {
+	case strings.HasPrefix(s, "'"):
+		end := strings.Index(s[1:], "'")
+		if end < 0 {
+			return "", fmt.Errorf("unclosed single quote")
+		}
+		if rest := strings.TrimSpace(s[end+2:]); rest != "" && !strings.HasPrefix(rest, "#") {
+			return "", fmt.Errorf("unexpected characters after the closing quote")
+		}
+		return s[1 : end+1], nil
+	case strings.HasPrefix(s, `"`):
+		value, rest, ok := unquoteDotenvValue(s)
+		if !ok {
+			return "", fmt.Errorf("unclosed double quote")
+		}
+		if rest = strings.TrimSpace(rest); rest != "" && !strings.HasPrefix(rest, "#") {
+			return "", fmt.Errorf("unexpected characters after the closing quote")
+		}
+		return expandDotenvVars(value, env), nil
+	}
+
+	// the comment in the unquoted value starts with # after a whitespace
+	for i := 0; i < len(s); i++ {
+		if s[i] == '#' && (i == 0 || s[i-1] == ' ' || s[i-1] == '\t') {
+			s = strings.TrimSpace(s[:i])
+			break
+		}
+	}
+	return expandDotenvVars(s, env), nil
+}
+
+// readDotenv reads the environment variables from the file in the '.env' format.
+// The lines are `KEY=value` optionally prefixed with `export`, and the empty lines and the lines starting with # are ignored.
+// The errors have the line numbers but not the contents of the lines, which may contain the secrets.
+func readDotenv(filePath string) (map[string]string, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	env := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if rest := strings.TrimPrefix(line, "export"); rest != line && (strings.HasPrefix(rest, " ") || strings.HasPrefix(rest, "\t")) {
+			line = strings.TrimSpace(rest)
+		}
+
+		i := strings.Index(line, "=")
+		if i < 0 {
+			return nil, fmt.Errorf("line %d: missing '=' between the key and the value", n)
+		}
+		key := strings.TrimSpace(line[:i])
+		if !dotenvKeyPattern.MatchString(key) {
+			return nil, fmt.Errorf("line %d: invalid key, it must consist of letters, digits and underscores and not start with a digit", n)
+		}
+
+		value, err := parseDotenvValue(line[i+1:], env)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", n, err)
+		}
+		env[key] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return env, nil
+}
@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadDotenv(t *testing.T) {
+	cases := []struct {
+		name      string
+		content   string
+		expect    map[string]string
+		expectErr string
+	}{
+		{
+			name: "success",
+			content: `# comment
+FOO=bar
+export EXPORTED=1
+exported_key=2
+
+SPACED = value with spaces  # comment
+HASH=a#b
+`,
+			expect: map[string]string{"FOO": "bar", "EXPORTED": "1", "exported_key": "2", "SPACED": "value with spaces", "HASH": "a#b"},
+		},
+		{
+			name: "success with quoted values",
+			content: `DOUBLE="hello # world"  # comment
+ESCAPED="line1\nline2 \"quoted\""
+SINGLE='$FOO and \n' # comment
+EMPTY=
+EMPTY_QUOTED=""
+`,
+			expect: map[string]string{"DOUBLE": "hello # world", "ESCAPED": "line1\nline2 \"quoted\"", "SINGLE": `$FOO and \n`, "EMPTY": "", "EMPTY_QUOTED": ""},
+		},
+		{
+			name: "success with variables",
+			content: `HOST=localhost
+URL=http://${HOST}:8080
+QUOTED="$HOST/\$HOST"
+UNDEFINED=${NOT_DEFINED}
+`,
+			expect: map[string]string{"HOST": "localhost", "URL": "http://localhost:8080", "QUOTED": "localhost/$HOST", "UNDEFINED": ""},
+		},
+		{
+			name:      "failure by missing =",
+			content:   "FOO=bar\n\nmalformed line\n",
+			expectErr: "line 3: missing '=' between the key and the value",
+		},
+		{
+			name:      "failure by invalid key",
+			content:   "1FOO=bar\n",
+			expectErr: "line 1: invalid key, it must consist of letters, digits and underscores and not start with a digit",
+		},
+		{
+			name:      "failure by unclosed double quote",
+			content:   "# comment\nFOO=\"secret\n",
+			expectErr: "line 2: unclosed double quote",
+		},
+		{
+			name:      "failure by unclosed single quote",
+			content:   "FOO='secret\n",
+			expectErr: "line 1: unclosed single quote",
+		},
+		{
+			name:      "failure by characters after the quote",
+			content:   "FOO=\"secret\" value\n",
+			expectErr: "line 1: unexpected characters after the closing quote",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			f, err := ioutil.TempFile("", "test.env")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.Remove(f.Name())
+			if _, err := f.WriteString(c.content); err != nil {
+				t.Fatal(err)
+			}
+			f.Close()
+
+			env, err := readDotenv(f.Name())
+			if c.expectErr != "" {
+				assert.Equal(t, c.expectErr, err.Error())
+				assert.NotContains(t, err.Error(), "secret")
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, c.expect, env)
+		})
+	}
+}
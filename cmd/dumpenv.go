@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"github.com/screwdriver-cd/sd-local/build"
+	"github.com/screwdriver-cd/sd-local/buildlog"
+	"github.com/screwdriver-cd/sd-local/launch"
+)
+
+// environmenter is the launcher which tells the environment of the build container
+type environmenter interface {
+	Environment() (launch.EnvVar, []string)
+}
+
+// dotenvValueReplacer escapes the values in the double quotes of the '.env' format read by readDotenv
+var dotenvValueReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", `\$`, "\n", `\n`, "\r", `\r`, "\t", `\t`)
+
+// dumpEnvPath returns the path of --dump-env of the job, whose {job} is replaced with the job name
+func dumpEnvPath(path, jobName string) string {
+	return strings.Replace(path, build.ArtifactsJobToken, launch.ContainerName(jobName), -1)
+}
+
+// formatDotenv returns the environment in the '.env' format sorted by the keys. The values of the secrets are masked
+// and the secrets in the other values are masked by mask unless unmasked is true.
+func formatDotenv(jobName string, env launch.EnvVar, secrets []string, mask func(string) string, unmasked bool) string {
+	isSecret := make(map[string]bool, len(secrets))
+	for _, s := range secrets {
+		isSecret[s] = true
+	}
+	keys := make([]string, 0, len(env))
+	for k := range env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# the environment of the build container of %s\n", jobName)
+	for _, k := range keys {
+		v := env[k]
+		if !unmasked {
+			if isSecret[k] {
+				v = buildlog.Mask
+			} else {
+				v = mask(v)
+			}
+		}
+		fmt.Fprintf(&b, "%s=\"%s\"\n", k, dotenvValueReplacer.Replace(v))
+	}
+	return b.String()
+}
+
+// dumpEnv writes the environment of the build container of the launcher into the path of --dump-env of the job
+func dumpEnv(l launch.Launcher, path, jobName string, mask func(string) string, unmasked bool) error {
+	e, ok := l.(environmenter)
+	if !ok {
+		return fmt.Errorf("failed to dump the environment of %s: the launcher does not tell it", jobName)
+	}
+	env, secrets := e.Environment()
+	path = dumpEnvPath(path, jobName)
+	// the file is readable only by the user because it may have the secrets
+	if err := ioutil.WriteFile(path, []byte(formatDotenv(jobName, env, secrets, mask, unmasked)), 0600); err != nil {
+		return fmt.Errorf("failed to dump the environment of %s into %s: %v", jobName, path, err)
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/screwdriver-cd/sd-local/launch"
+	"github.com/stretchr/testify/assert"
+)
+
+type envLaunch struct {
+	mockLaunch
+}
+
+func (e envLaunch) Environment() (launch.EnvVar, []string) {
+	return launch.EnvVar{
+		"API_KEY":  "apikey",
+		"SD_TOKEN": "jwt",
+		"LOGIN":    "user:apikey",
+		"CERT":     "-----BEGIN-----\n\"$PEM\"\n-----END-----",
+	}, []string{"API_KEY", "SD_TOKEN"}
+}
+
+func TestFormatDotenv(t *testing.T) {
+	env, secrets := envLaunch{}.Environment()
+	mask := strings.NewReplacer("apikey", "****").Replace
+
+	testCases := map[string]struct {
+		unmasked bool
+		expected string
+	}{
+		"masked": {
+			expected: "# the environment of the build container of main\n" +
+				"API_KEY=\"****\"\nCERT=\"-----BEGIN-----\\n\\\"\\$PEM\\\"\\n-----END-----\"\nLOGIN=\"user:****\"\nSD_TOKEN=\"****\"\n",
+		},
+		"unmasked": {
+			unmasked: true,
+			expected: "# the environment of the build container of main\n" +
+				"API_KEY=\"apikey\"\nCERT=\"-----BEGIN-----\\n\\\"\\$PEM\\\"\\n-----END-----\"\nLOGIN=\"user:apikey\"\nSD_TOKEN=\"jwt\"\n",
+		},
+	}
+
+	for name, tt := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, formatDotenv("main", env, secrets, mask, tt.unmasked))
+		})
+	}
+}
+
+func TestDumpEnv(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sd-local-dump-env")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	t.Run("success", func(t *testing.T) {
+		path := filepath.Join(dir, "{job}.env")
+		err := dumpEnv(envLaunch{}, path, "PR-1:main", func(s string) string { return s }, true)
+		assert.Nil(t, err)
+
+		// the file is read as the '.env' format as it is
+		env, err := readDotenv(filepath.Join(dir, "PR-1-main.env"))
+		assert.Nil(t, err)
+		expected, _ := envLaunch{}.Environment()
+		assert.Equal(t, map[string]string(expected), env)
+
+		info, err := os.Stat(filepath.Join(dir, "PR-1-main.env"))
+		assert.Nil(t, err)
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	})
+
+	t.Run("failure by the launcher", func(t *testing.T) {
+		err := dumpEnv(mockLaunch{}, filepath.Join(dir, "main.env"), "main", func(s string) string { return s }, false)
+		assert.Equal(t, "failed to dump the environment of main: the launcher does not tell it", err.Error())
+	})
+
+	t.Run("failure by the path", func(t *testing.T) {
+		path := filepath.Join(dir, "not-exist", "main.env")
+		err := dumpEnv(envLaunch{}, path, "main", func(s string) string { return s }, false)
+		assert.Equal(t, "failed to dump the environment of main into "+path+": open "+path+": no such file or directory", err.Error())
+	})
+}
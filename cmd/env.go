@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+)
+
+// envFilePrefix is the prefix of the values of --env which are read from the files like KEY=@path
+const envFilePrefix = "@"
+
+// envValue is the value of --env, which is parsed like the stringToString flag of pflag.
+// The values like KEY=@path are read from the files, and the keys alone like KEY are passed through from the host environment.
+type envValue struct {
+	value   *map[string]string
+	changed bool
+}
+
+func newEnvValue(p *map[string]string) *envValue {
+	*p = map[string]string{}
+	return &envValue{value: p}
+}
+
+// resolveEnv returns the value of the pair of --env, and false if the key alone is not set in the host environment
+func resolveEnv(pair string) (string, string, bool, error) {
+	kv := strings.SplitN(pair, "=", 2)
+	if len(kv) == 1 {
+		v, ok := os.LookupEnv(kv[0])
+		return kv[0], v, ok, nil
+	}
+
+	k, v := kv[0], kv[1]
+	if !strings.HasPrefix(v, envFilePrefix) {
+		return k, v, true, nil
+	}
+	path := strings.TrimPrefix(v, envFilePrefix)
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", "", false, fmt.Errorf("failed to read the value of %s from %s: %v", k, path, err)
+	}
+	return k, string(b), true, nil
+}
+
+func (e *envValue) Set(val string) error {
+	var pairs []string
+	if strings.Count(val, "=") <= 1 {
+		pairs = []string{strings.Trim(val, `"`)}
+	} else {
+		var err error
+		pairs, err = csv.NewReader(strings.NewReader(val)).Read()
+		if err != nil {
+			return err
+		}
+	}
+
+	env := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		if pair == "" || strings.HasPrefix(pair, "=") {
+			return fmt.Errorf("%s must be formatted as key=value, key=@path or key", pair)
+		}
+		k, v, ok, err := resolveEnv(pair)
+		if err != nil {
+			return err
+		}
+		if ok {
+			env[k] = v
+		}
+	}
+
+	if !e.changed {
+		*e.value = env
+	} else {
+		for k, v := range env {
+			(*e.value)[k] = v
+		}
+	}
+	e.changed = true
+	return nil
+}
+
+func (e *envValue) Type() string {
+	return "stringToString"
+}
+
+func (e *envValue) String() string {
+	keys := make([]string, 0, len(*e.value))
+	for k := range *e.value {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	records := make([]string, 0, len(keys))
+	for _, k := range keys {
+		records = append(records, k+"="+(*e.value)[k])
+	}
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.Write(records); err != nil {
+		return ""
+	}
+	w.Flush()
+	return "[" + strings.TrimSpace(buf.String()) + "]"
+}
@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnvValue(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sd-local-env")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	certPath := filepath.Join(dir, "cert.pem")
+	if err := ioutil.WriteFile(certPath, []byte("-----BEGIN CERTIFICATE-----\nMIIB\n-----END CERTIFICATE-----\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	defEnv, defOk := os.LookupEnv("SD_LOCAL_TEST_HOST_ENV")
+	os.Setenv("SD_LOCAL_TEST_HOST_ENV", "from host")
+	defer func() {
+		if defOk {
+			os.Setenv("SD_LOCAL_TEST_HOST_ENV", defEnv)
+		} else {
+			os.Unsetenv("SD_LOCAL_TEST_HOST_ENV")
+		}
+	}()
+	os.Unsetenv("SD_LOCAL_TEST_UNSET_ENV")
+
+	testCases := map[string]struct {
+		values    []string
+		expected  map[string]string
+		expectErr string
+	}{
+		"key and value": {
+			values:   []string{"FOO=foo", "BAR=bar=baz"},
+			expected: map[string]string{"FOO": "foo", "BAR": "bar=baz"},
+		},
+		"comma separated": {
+			values:   []string{"FOO=foo,BAR=bar"},
+			expected: map[string]string{"FOO": "foo", "BAR": "bar"},
+		},
+		"value from file": {
+			values:   []string{"CERT=@" + certPath},
+			expected: map[string]string{"CERT": "-----BEGIN CERTIFICATE-----\nMIIB\n-----END CERTIFICATE-----\n"},
+		},
+		"key from host": {
+			values:   []string{"SD_LOCAL_TEST_HOST_ENV", "SD_LOCAL_TEST_UNSET_ENV"},
+			expected: map[string]string{"SD_LOCAL_TEST_HOST_ENV": "from host"},
+		},
+		"file not found": {
+			values:    []string{"CERT=@" + filepath.Join(dir, "missing.pem")},
+			expectErr: "failed to read the value of CERT from " + filepath.Join(dir, "missing.pem") + ": open " + filepath.Join(dir, "missing.pem") + ": no such file or directory",
+		},
+		"no key": {
+			values:    []string{"=foo"},
+			expectErr: "=foo must be formatted as key=value, key=@path or key",
+		},
+	}
+
+	for name, tt := range testCases {
+		t.Run(name, func(t *testing.T) {
+			var env map[string]string
+			v := newEnvValue(&env)
+			var err error
+			for _, value := range tt.values {
+				if err = v.Set(value); err != nil {
+					break
+				}
+			}
+			if tt.expectErr != "" {
+				assert.Equal(t, tt.expectErr, err.Error())
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, tt.expected, env)
+		})
+	}
+}
+
+func TestEnvValueString(t *testing.T) {
+	env := map[string]string{}
+	v := newEnvValue(&env)
+	assert.Equal(t, "[]", v.String())
+
+	env["FOO"] = "foo"
+	env["BAR"] = "bar"
+	assert.Equal(t, "[BAR=bar,FOO=foo]", v.String())
+}
@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// newExecCmd creates the command which runs the build of the job in the interactive mode.
+// It shares the flags and the setup of the build with the build command.
+func newExecCmd() *cobra.Command {
+	execCmd := newBuildCmd()
+	execCmd.Use = "exec [job name]"
+	execCmd.Short = "Run an interactive shell in the build environment of the job."
+	execCmd.Long = `Run an interactive shell in the build environment of the job.
+The build container is set up like the job with the source code mounted and the environment variables of the job exported.
+The teardown steps of the job run and the build container is removed when the shell exits.`
+
+	buildArgs := execCmd.Args
+	execCmd.Args = func(cmd *cobra.Command, args []string) error {
+		err := cobra.ExactArgs(1)(cmd, args)
+		if err != nil {
+			return err
+		}
+
+		interactiveMode = true
+		return buildArgs(cmd, args)
+	}
+
+	// These flags are meaningless for a single interactive shell.
+	for _, name := range []string{"artifacts-s3", "artifacts-s3-endpoint", "dry-run", "interactive", "max-parallel", "no-local-artifacts", "no-teardown", "output", "report", "resume", "sort-time", "step", "timeout", "watch"} {
+		_ = execCmd.Flags().MarkHidden(name)
+	}
+
+	return execCmd
+}
@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/screwdriver-cd/sd-local/launch"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExecCmd(t *testing.T) {
+	t.Run("Success exec cmd", func(t *testing.T) {
+		defLaunchNew := launchNew
+		defer func() {
+			launchNew = defLaunchNew
+		}()
+
+		var option launch.Option
+		launchNew = func(o launch.Option) launch.Launcher {
+			option = o
+			return mockLaunch{}
+		}
+
+		root := newExecCmd()
+		root.SetArgs([]string{"test"})
+		buf := bytes.NewBuffer(nil)
+		root.SetOut(buf)
+		err := root.Execute()
+		assert.Nil(t, err)
+		assert.Equal(t, "test", option.JobName)
+		assert.True(t, option.InteractiveMode)
+	})
+
+	t.Run("Failed exec cmd when too many args", func(t *testing.T) {
+		root := newExecCmd()
+		root.SetArgs([]string{"test", "lint"})
+		buf := bytes.NewBuffer(nil)
+		root.SetOut(buf)
+		err := root.Execute()
+		assert.Equal(t, "accepts 1 arg(s), received 2", err.Error())
+	})
+}
@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+	"sync"
+)
+
+// The exit codes of sd-local by the class of the failure, which the automation around sd-local can rely on
+const (
+	// ExitBuildFailure is the exit code of the build which failed by a step or timed out
+	ExitBuildFailure = 1
+	// ExitUsage is the exit code of the invalid usage like the flags and the arguments, the invalid config,
+	// and the other failures of sd-local to prepare the build
+	ExitUsage = 2
+	// ExitRuntime is the exit code of the container runtime which is not installed, not running or not permitted,
+	// or failed apart from the steps like pulling the images or the daemon which died during the build
+	ExitRuntime = 3
+	// ExitAPI is the exit code of the requests to the API of Screwdriver.cd which failed or were refused,
+	// like the invalid token, the network errors and screwdriver.yaml rejected by the validator
+	ExitAPI = 4
+	// ExitInterrupted is the exit code of sd-local stopped by Ctrl-C, which is 128 + the number of the signal for the other signals
+	ExitInterrupted = 130
+)
+
+// exitError is the error which exits sd-local with the code
+type exitError struct {
+	err  error
+	code int
+}
+
+func (e *exitError) Error() string {
+	return e.err.Error()
+}
+
+func (e *exitError) Unwrap() error {
+	return e.err
+}
+
+// withExitCode returns the error which exits sd-local with the code, which is nil if err is nil
+func withExitCode(err error, code int) error {
+	if err == nil {
+		return nil
+	}
+	return &exitError{err: err, code: code}
+}
+
+// ExitCode returns the exit code of the error returned by Execute, which is ExitUsage if the error has no exit code
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var e *exitError
+	if errors.As(err, &e) {
+		return e.code
+	}
+	return ExitUsage
+}
+
+var (
+	receivedSignal      os.Signal
+	receivedSignalMutex sync.Mutex
+)
+
+// setReceivedSignal records the signal stopping sd-local, whose exit code takes precedence over the error of the stopped build
+func setReceivedSignal(sig os.Signal) {
+	receivedSignalMutex.Lock()
+	defer receivedSignalMutex.Unlock()
+	receivedSignal = sig
+}
+
+// interruptedError returns the error exiting with the code of the received signal if sd-local is stopped by it
+func interruptedError(err error) error {
+	receivedSignalMutex.Lock()
+	defer receivedSignalMutex.Unlock()
+	if err == nil || receivedSignal == nil {
+		return err
+	}
+	return withExitCode(err, signalExitCode(receivedSignal))
+}
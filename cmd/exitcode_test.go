@@ -0,0 +1,168 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/screwdriver-cd/sd-local/artifacts"
+	"github.com/screwdriver-cd/sd-local/config"
+	"github.com/screwdriver-cd/sd-local/launch"
+	"github.com/screwdriver-cd/sd-local/screwdriver"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExitCode(t *testing.T) {
+	testCases := map[string]struct {
+		err      error
+		expected int
+	}{
+		"no error":        {err: nil, expected: 0},
+		"usage error":     {err: errors.New("unknown flag: --foo"), expected: ExitUsage},
+		"build failure":   {err: withExitCode(errors.New("failed steps: test"), ExitBuildFailure), expected: ExitBuildFailure},
+		"runtime error":   {err: withExitCode(errors.New("Docker does not appear to be running"), ExitRuntime), expected: ExitRuntime},
+		"wrapped error":   {err: fmt.Errorf("watch: %w", withExitCode(errors.New("failed"), ExitBuildFailure)), expected: ExitBuildFailure},
+		"no error to tag": {err: withExitCode(nil, ExitRuntime), expected: 0},
+	}
+
+	for name, tt := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, ExitCode(tt.err))
+		})
+	}
+}
+
+func TestInterruptedError(t *testing.T) {
+	defer setReceivedSignal(nil)
+
+	err := withExitCode(errors.New("build failed"), ExitBuildFailure)
+	assert.Equal(t, ExitBuildFailure, ExitCode(interruptedError(err)))
+
+	setReceivedSignal(os.Interrupt)
+	assert.Equal(t, ExitInterrupted, ExitCode(interruptedError(err)))
+	assert.Equal(t, "build failed", interruptedError(err).Error())
+	assert.Nil(t, interruptedError(nil))
+
+	setReceivedSignal(syscall.SIGTERM)
+	assert.Equal(t, 143, ExitCode(interruptedError(err)))
+}
+
+func TestBuildCmdExitCode(t *testing.T) {
+	defConfigNew, defAPINew, defLaunchNew, defCheckBuildRuntime, defUploaderNew := configNew, apiNew, launchNew, checkBuildRuntime, uploaderNew
+	defer func() {
+		configNew, apiNew, launchNew, checkBuildRuntime, uploaderNew = defConfigNew, defAPINew, defLaunchNew, defCheckBuildRuntime, defUploaderNew
+	}()
+
+	testCases := map[string]struct {
+		args     []string
+		mock     func()
+		expected int
+	}{
+		"success": {
+			args:     []string{"test"},
+			expected: 0,
+		},
+		"invalid flag": {
+			args:     []string{"test", "--no-such-flag"},
+			expected: ExitUsage,
+		},
+		"invalid arguments": {
+			args:     []string{"test", "--meta", `{"foo":"bar"}`, "--meta-file", "meta.json"},
+			expected: ExitUsage,
+		},
+		"invalid config": {
+			args: []string{"test"},
+			mock: func() {
+				configNew = func(confPath string) (config.Config, error) {
+					return config.Config{}, errors.New("failed to parse the config")
+				}
+			},
+			expected: ExitUsage,
+		},
+		"runtime unavailable": {
+			args: []string{"test"},
+			mock: func() {
+				checkBuildRuntime = func(runtime string, useSudo bool, dockerHost string) error {
+					return errors.New("Docker does not appear to be running; start it and retry")
+				}
+			},
+			expected: ExitRuntime,
+		},
+		"build failure": {
+			args: []string{"test"},
+			mock: func() {
+				launchNew = func(option launch.Option) launch.Launcher {
+					return failLaunch{err: errors.New("exit status 1")}
+				}
+			},
+			expected: ExitBuildFailure,
+		},
+		"runtime failure during the build": {
+			args: []string{"test"},
+			mock: func() {
+				launchNew = func(option launch.Option) launch.Launcher {
+					return failLaunch{err: fmt.Errorf("failed to setup build: %w", launch.ErrRuntime)}
+				}
+			},
+			expected: ExitRuntime,
+		},
+		"runtime failure of one of the jobs": {
+			args: []string{"test", "lint"},
+			mock: func() {
+				launchNew = func(option launch.Option) launch.Launcher {
+					if option.JobName == "lint" {
+						return failLaunch{err: fmt.Errorf("failed to run build: %w", launch.ErrRuntime)}
+					}
+					return failLaunch{err: errors.New("exit status 1")}
+				}
+			},
+			expected: ExitRuntime,
+		},
+		"API failure": {
+			args: []string{"test"},
+			mock: func() {
+				apiNew = func(url, token, ua string, client *http.Client) screwdriver.API { return failedJWTAPI{} }
+			},
+			expected: ExitAPI,
+		},
+		"timed out": {
+			args: []string{"test", "--timeout", "1ms"},
+			mock: func() {
+				launchNew = func(option launch.Option) launch.Launcher {
+					return &hangLaunch{stop: make(chan struct{})}
+				}
+			},
+			expected: ExitBuildFailure,
+		},
+		"upload failure after the successful build": {
+			args: []string{"test", "--artifacts-s3", "s3://bucket/prefix"},
+			mock: func() {
+				uploaderNew = func(dest, endpoint string) (artifacts.Uploader, error) {
+					var dir string
+					return mockUploader{dir: &dir, err: errors.New("failed to upload artifacts")}, nil
+				}
+			},
+			expected: ExitUsage,
+		},
+	}
+
+	for name, tt := range testCases {
+		t.Run(name, func(t *testing.T) {
+			configNew, apiNew, launchNew, checkBuildRuntime, uploaderNew = defConfigNew, defAPINew, defLaunchNew, defCheckBuildRuntime, defUploaderNew
+			if tt.mock != nil {
+				tt.mock()
+			}
+
+			root := newBuildCmd()
+			root.SetArgs(tt.args)
+			root.SetOut(bytes.NewBuffer(nil))
+			root.SetErr(bytes.NewBuffer(nil))
+			err := root.Execute()
+			assert.Equal(t, tt.expected, ExitCode(err), "error: %v", err)
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/screwdriver-cd/sd-local/screwdriver"
+)
+
+// expandYAML expands the variables in screwdriver.yaml with the environment variables of --env and sd-local itself,
+// and returns the path to the expanded file which should be removed by the caller.
+// The path is returned as it is if there is nothing to expand.
+func expandYAML(sdYAMLPath string, env map[string]string) (string, error) {
+	content, err := ioutil.ReadFile(sdYAMLPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read screwdriver.yaml: %v", err)
+	}
+	if !bytes.Contains(content, []byte("$")) {
+		return sdYAMLPath, nil
+	}
+
+	expanded, err := screwdriver.ExpandEnv(content, func(name string) (string, bool) {
+		if v, ok := env[name]; ok {
+			return v, true
+		}
+		return os.LookupEnv(name)
+	})
+	if err != nil {
+		return "", fmt.Errorf("%v, or pass --no-expand to use it as it is", err)
+	}
+
+	return writeTempYAML(expanded)
+}
+
+// expandTemplate merges the job templates used in screwdriver.yaml into the jobs with the API,
+// and returns the path to the expanded file which should be removed by the caller.
+// The path is returned as it is if no job uses a template.
+func expandTemplate(api screwdriver.API, sdYAMLPath string) (string, error) {
+	content, err := ioutil.ReadFile(sdYAMLPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read screwdriver.yaml: %v", err)
+	}
+	if !bytes.Contains(content, []byte("template")) {
+		return sdYAMLPath, nil
+	}
+
+	t, ok := api.(screwdriver.TemplateAPI)
+	if !ok {
+		return "", errors.New("the API does not support getting the templates")
+	}
+	expanded, err := screwdriver.ExpandTemplates(context.Background(), t, content)
+	if err != nil {
+		return "", err
+	}
+	return writeTempYAML(expanded)
+}
+
+// extractStepEnv removes the environment of the steps from screwdriver.yaml, which the validator of the API doesn't accept,
+// and returns the path to the file without it which should be removed by the caller and the removed environment.
+// The path is returned as it is if no step has its own environment.
+func extractStepEnv(sdYAMLPath string) (string, screwdriver.StepEnvironments, error) {
+	content, err := ioutil.ReadFile(sdYAMLPath)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to read screwdriver.yaml: %v", err)
+	}
+
+	extracted, envs, err := screwdriver.ExtractStepEnvironments(content)
+	if err != nil {
+		return "", nil, err
+	}
+	if bytes.Equal(extracted, content) {
+		return sdYAMLPath, envs, nil
+	}
+	path, err := writeTempYAML(extracted)
+	return path, envs, err
+}
+
+// writeTempYAML writes the expanded screwdriver.yaml into the temporary file and returns its path
+func writeTempYAML(content []byte) (string, error) {
+	f, err := ioutil.TempFile("", "screwdriver-*.yaml")
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	if _, err := f.Write(content); err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
@@ -0,0 +1,188 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/screwdriver-cd/sd-local/screwdriver"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpandYAML(t *testing.T) {
+	dir, err := ioutil.TempDir("", "expand")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	os.Setenv("SD_LOCAL_TEST_REGION", "us-west-2")
+	defer os.Unsetenv("SD_LOCAL_TEST_REGION")
+
+	cases := []struct {
+		name      string
+		yaml      string
+		env       map[string]string
+		expect    string
+		expectErr string
+	}{
+		{
+			name:   "success",
+			yaml:   "jobs:\n  main:\n    image: node:${IMAGE_TAG}\n    annotations:\n      region: $SD_LOCAL_TEST_REGION\n",
+			env:    map[string]string{"IMAGE_TAG": "12"},
+			expect: "jobs:\n  main:\n    image: node:12\n    annotations:\n      region: us-west-2\n",
+		},
+		{
+			name:   "success with --env preferred",
+			yaml:   "jobs:\n  main:\n    annotations:\n      region: $SD_LOCAL_TEST_REGION\n",
+			env:    map[string]string{"SD_LOCAL_TEST_REGION": "ap-northeast-1"},
+			expect: "jobs:\n  main:\n    annotations:\n      region: ap-northeast-1\n",
+		},
+		{
+			name:   "success without variables",
+			yaml:   "jobs:\n  main:\n    image: node:12\n",
+			expect: "jobs:\n  main:\n    image: node:12\n",
+		},
+		{
+			name:      "failure by undefined variable",
+			yaml:      "jobs:\n  main:\n    image: node:${IMAGE_TAG}\n",
+			expectErr: "undefined variable IMAGE_TAG, use ${IMAGE_TAG:-default} for the default value, or pass --no-expand to use it as it is",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			sdYAMLPath := filepath.Join(dir, "screwdriver.yaml")
+			if err := ioutil.WriteFile(sdYAMLPath, []byte(c.yaml), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			expandedPath, err := expandYAML(sdYAMLPath, c.env)
+			if c.expectErr != "" {
+				assert.Contains(t, err.Error(), c.expectErr)
+				return
+			}
+			assert.Nil(t, err)
+			if expandedPath != sdYAMLPath {
+				defer os.Remove(expandedPath)
+			}
+
+			actual, err := ioutil.ReadFile(expandedPath)
+			assert.Nil(t, err)
+			assert.Equal(t, c.expect, string(actual))
+		})
+	}
+
+	t.Run("failure by missing file", func(t *testing.T) {
+		_, err := expandYAML(filepath.Join(dir, "missing.yaml"), nil)
+		assert.Contains(t, err.Error(), "failed to read screwdriver.yaml")
+	})
+}
+
+func TestExpandTemplate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "expand")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	cases := []struct {
+		name      string
+		yaml      string
+		expect    string
+		expectErr string
+	}{
+		{
+			name:   "success",
+			yaml:   "jobs:\n  main:\n    template: foo/bar\n    requires: [~pr]\n",
+			expect: "jobs:\n  main:\n    image: golang\n    steps:\n    - test: go test ./...\n    requires:\n    - ~pr\n    environment:\n      SD_TEMPLATE_FULLNAME: foo/bar\n      SD_TEMPLATE_NAME: bar\n      SD_TEMPLATE_NAMESPACE: foo\n      SD_TEMPLATE_VERSION: 1.0.0\n",
+		},
+		{
+			name:   "success without templates",
+			yaml:   "jobs:\n  main:\n    image: node:12\n",
+			expect: "jobs:\n  main:\n    image: node:12\n",
+		},
+		{
+			name:      "failure by template not found",
+			yaml:      "jobs:\n  main:\n    template: foo/baz@1\n",
+			expectErr: "template foo/baz@1 is not found",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			sdYAMLPath := filepath.Join(dir, "screwdriver.yaml")
+			if err := ioutil.WriteFile(sdYAMLPath, []byte(c.yaml), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			expandedPath, err := expandTemplate(mockAPI{}, sdYAMLPath)
+			if c.expectErr != "" {
+				assert.Equal(t, c.expectErr, err.Error())
+				return
+			}
+			assert.Nil(t, err)
+			if expandedPath != sdYAMLPath {
+				defer os.Remove(expandedPath)
+			}
+
+			actual, err := ioutil.ReadFile(expandedPath)
+			assert.Nil(t, err)
+			assert.Equal(t, c.expect, string(actual))
+		})
+	}
+}
+
+func TestExtractStepEnv(t *testing.T) {
+	dir, err := ioutil.TempDir("", "expand")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	cases := []struct {
+		name       string
+		yaml       string
+		expect     string
+		expectEnvs screwdriver.StepEnvironments
+	}{
+		{
+			name:       "success",
+			yaml:       "jobs:\n  main:\n    steps:\n    - test:\n        command: go test ./...\n        environment:\n          GOFLAGS: -v\n",
+			expect:     "jobs:\n  main:\n    steps:\n    - test: go test ./...\n",
+			expectEnvs: screwdriver.StepEnvironments{"main": {"test": {"GOFLAGS": "-v"}}},
+		},
+		{
+			name:       "success without the environment of the steps",
+			yaml:       "jobs:\n  main:\n    steps:\n    - test: go test ./...\n",
+			expect:     "jobs:\n  main:\n    steps:\n    - test: go test ./...\n",
+			expectEnvs: screwdriver.StepEnvironments{},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			sdYAMLPath := filepath.Join(dir, "screwdriver.yaml")
+			if err := ioutil.WriteFile(sdYAMLPath, []byte(c.yaml), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			extractedPath, envs, err := extractStepEnv(sdYAMLPath)
+			assert.Nil(t, err)
+			if extractedPath != sdYAMLPath {
+				defer os.Remove(extractedPath)
+			}
+			assert.Equal(t, c.expectEnvs, envs)
+
+			actual, err := ioutil.ReadFile(extractedPath)
+			assert.Nil(t, err)
+			assert.Equal(t, c.expect, string(actual))
+		})
+	}
+
+	t.Run("failure by missing file", func(t *testing.T) {
+		_, _, err := extractStepEnv(filepath.Join(dir, "missing.yaml"))
+		assert.Contains(t, err.Error(), "failed to read screwdriver.yaml")
+	})
+}
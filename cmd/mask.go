@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"errors"
+	"net/url"
+	"os"
+
+	"github.com/screwdriver-cd/sd-local/buildlog"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// usesTokenAnnotation is the annotation of the commands which send the token of the config to the API
+const usesTokenAnnotation = "sd-local/uses-token"
+
+// tokenSecrets returns SD_TOKEN and the token of the config which the command uses, the one of --config-entry or the current one,
+// and their query-escaped forms which are contained in the URLs of the wrapped errors of the HTTP client.
+// The config file of --config is read after the flags are parsed without changing it, and only for the commands using the token
+// not to read the OS keychain for the others.
+func tokenSecrets(cmd *cobra.Command) []string {
+	tokens := []string{os.Getenv("SD_TOKEN")}
+
+	if _, ok := cmd.Annotations[usesTokenAnnotation]; ok {
+		if path, err := configFilePath(); err == nil {
+			if c, err := configRead(path); err == nil {
+				name := ""
+				if f := cmd.Flags().Lookup("config-entry"); f != nil {
+					name = f.Value.String()
+				}
+				if _, entry, err := c.RunEntry(name); err == nil {
+					tokens = append(tokens, entry.Token)
+				}
+			}
+		}
+	}
+
+	secrets := make([]string, 0, 2*len(tokens))
+	for _, token := range tokens {
+		if token != "" {
+			secrets = append(secrets, token, url.QueryEscape(token))
+		}
+	}
+	return secrets
+}
+
+// executeMasked executes the command with masking the tokens in all the messages logged with the logger
+// and the returned error, so that the token never leaks from any code path even with --verbose.
+// The mask is installed by the PersistentPreRunE of the command to find the tokens with the parsed flags like --config.
+func executeMasked(cmd *cobra.Command, logger *logrus.Logger) error {
+	var secrets []string
+	preRun := cmd.PersistentPreRunE
+	cmd.PersistentPreRunE = func(c *cobra.Command, args []string) error {
+		if preRun != nil {
+			if err := preRun(c, args); err != nil {
+				return err
+			}
+		}
+		secrets = tokenSecrets(c)
+		if len(secrets) != 0 {
+			logger.SetFormatter(buildlog.NewMaskFormatter(logger.Formatter, secrets))
+		}
+		return nil
+	}
+
+	err := cmd.Execute()
+	if err != nil && len(secrets) != 0 {
+		// the exit code is kept with the masked message
+		return withExitCode(errors.New(buildlog.NewMasker(secrets).Replace(err.Error())), ExitCode(err))
+	}
+	return err
+}
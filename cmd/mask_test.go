@@ -0,0 +1,155 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"testing"
+
+	"github.com/screwdriver-cd/sd-local/config"
+	"github.com/screwdriver-cd/sd-local/screwdriver"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+const leakedToken = "sd+token/aBcD1234"
+
+type leakingAPI struct {
+	mockAPI
+}
+
+// InitJWT leaks the token in the log and the error like the wrapped errors of the HTTP client
+func (mock leakingAPI) InitJWT() error {
+	u := "https://api.screwdriver.cd/v4/auth/token?api_token=" + url.QueryEscape(leakedToken)
+	logrus.Infof("GET %s with token %s", u, leakedToken)
+	return fmt.Errorf("failed to send request: Get %s: dial tcp: i/o timeout", u)
+}
+
+func TestExecuteMasked(t *testing.T) {
+	defConfigNew, defConfigRead, defAPINew := configNew, configRead, apiNew
+	logger := logrus.StandardLogger()
+	defFormatter := logger.Formatter
+	defer func() {
+		configNew, configRead, apiNew = defConfigNew, defConfigRead, defAPINew
+		logger.SetFormatter(defFormatter)
+		logger.SetOutput(os.Stderr)
+		logger.SetLevel(logrus.InfoLevel)
+		flagVerbose = false
+	}()
+
+	// the config is read from the file of --config without changing it
+	cnfFile, err := ioutil.TempFile("", "sd-local-config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cnfFile.Close()
+	defer os.Remove(cnfFile.Name())
+
+	var loadedPath, current string
+	configRead = func(confPath string) (config.Config, error) {
+		loadedPath = confPath
+		return config.Config{
+			Entries: map[string]*config.Entry{
+				"default": {
+					APIURL:   "https://api.screwdriver.cd",
+					StoreURL: "https://store.screwdriver.cd",
+					Token:    "current-token",
+					Launcher: config.Launcher{Version: "stable", Image: "screwdrivercd/launcher"},
+					UUID:     "eb004dc1-614c-11eb-bab9-0242ac120002",
+				},
+				"other": {
+					APIURL:   "https://api.screwdriver.cd",
+					StoreURL: "https://store.screwdriver.cd",
+					Token:    leakedToken,
+					Launcher: config.Launcher{Version: "stable", Image: "screwdrivercd/launcher"},
+					UUID:     "eb004dc1-614c-11eb-bab9-0242ac120002",
+				},
+			},
+			Current: current,
+		}, nil
+	}
+	// the build loads the same config
+	configNew = func(confPath string) (config.Config, error) {
+		return configRead(confPath)
+	}
+	apiNew = func(url, token, ua string, client *http.Client) screwdriver.API { return leakingAPI{} }
+
+	testCases := map[string]struct {
+		current string
+		env     map[string]string
+		args    []string
+	}{
+		"token of current config": {
+			current: "other",
+			args:    []string{"build", "test", "--verbose", "--config", cnfFile.Name()},
+		},
+		"token of config-entry": {
+			current: "default",
+			args:    []string{"--config", cnfFile.Name(), "build", "test", "--log-level", "debug", "--config-entry", "other"},
+		},
+		"token of SD_TOKEN": {
+			current: "default",
+			env:     map[string]string{"SD_TOKEN": leakedToken},
+			args:    []string{"build", "test", "--verbose", "--config", cnfFile.Name()},
+		},
+	}
+
+	for name, tt := range testCases {
+		t.Run(name, func(t *testing.T) {
+			loadedPath, current = "", tt.current
+			for k, v := range tt.env {
+				os.Setenv(k, v)
+				defer os.Unsetenv(k)
+			}
+
+			out := bytes.NewBuffer(nil)
+			logger.SetOutput(out)
+			logger.SetFormatter(defFormatter)
+
+			root := newRootCmd()
+			root.AddCommand(newBuildCmd())
+			root.SetArgs(tt.args)
+			root.SilenceErrors = true
+			root.SetOut(bytes.NewBuffer(nil))
+			err := executeMasked(root, logger)
+			logger.Error(err)
+
+			assert.Equal(t, cnfFile.Name(), loadedPath)
+			assert.Contains(t, out.String(), "api_token=****")
+			for _, token := range []string{leakedToken, url.QueryEscape(leakedToken)} {
+				assert.NotContains(t, out.String(), token)
+				assert.NotContains(t, err.Error(), token)
+			}
+		})
+	}
+}
+
+func TestTokenSecrets(t *testing.T) {
+	defConfigRead := configRead
+	defer func() { configRead = defConfigRead }()
+	var read bool
+	configRead = func(confPath string) (config.Config, error) {
+		read = true
+		return config.Config{
+			Entries: map[string]*config.Entry{"default": {Token: "current-token"}},
+			Current: "default",
+		}, nil
+	}
+
+	t.Run("success with the token of the config", func(t *testing.T) {
+		read = false
+		cmd := &cobra.Command{Annotations: map[string]string{usesTokenAnnotation: ""}}
+		assert.Equal(t, []string{"current-token", "current-token"}, tokenSecrets(cmd))
+		assert.True(t, read)
+	})
+
+	t.Run("success without reading the config not using the token", func(t *testing.T) {
+		read = false
+		assert.Equal(t, []string{}, tokenSecrets(&cobra.Command{}))
+		assert.False(t, read)
+	})
+}
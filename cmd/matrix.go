@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/screwdriver-cd/sd-local/screwdriver"
+)
+
+// formatMatrix returns the combination of the matrix like NODE_VERSION=12,OS=linux in alphabetical order
+func formatMatrix(matrix map[string]string) string {
+	pairs := make([]string, 0, len(matrix))
+	for k, v := range matrix {
+		pairs = append(pairs, fmt.Sprintf("%s=%s", k, v))
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+// matchesMatrix returns true if the environment variables of the job have all the values of the filter
+func matchesMatrix(job screwdriver.Job, filter map[string]string) bool {
+	for k, v := range filter {
+		if value, ok := job.Environment[k]; !ok || value != v {
+			return false
+		}
+	}
+	return true
+}
+
+// matrixJobNames replaces the names of the jobs with the matrix with the names of their combinations like main[NODE_VERSION=12],
+// which are narrowed down to the ones whose environment variables match the filter of --matrix.
+// The names of the jobs without the matrix and the combinations are returned as they are.
+func matrixJobNames(jobs map[string]screwdriver.Job, names []string, filter map[string]string) ([]string, error) {
+	expanded := make([]string, 0, len(names))
+	hasMatrix := false
+	for _, name := range names {
+		if _, ok := jobs[name]; ok {
+			expanded = append(expanded, name)
+			continue
+		}
+
+		combinations := make([]string, 0)
+		for n := range jobs {
+			if screwdriver.BaseJobName(n) == name {
+				combinations = append(combinations, n)
+			}
+		}
+		// the job which is not found is reported by the runner
+		if len(combinations) == 0 {
+			expanded = append(expanded, name)
+			continue
+		}
+		hasMatrix = true
+		sort.Strings(combinations)
+
+		matched := make([]string, 0, len(combinations))
+		for _, n := range combinations {
+			if matchesMatrix(jobs[n], filter) {
+				matched = append(matched, n)
+			}
+		}
+		if len(matched) == 0 {
+			return nil, fmt.Errorf("no combination of the matrix of job %s matches %s, the combinations are: %s",
+				name, formatMatrix(filter), strings.Join(combinations, ", "))
+		}
+		expanded = append(expanded, matched...)
+	}
+
+	if len(filter) != 0 && !hasMatrix {
+		return nil, fmt.Errorf("can't filter the combinations by `matrix` %s, the jobs don't have the matrix", formatMatrix(filter))
+	}
+	return expanded, nil
+}
@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/screwdriver-cd/sd-local/screwdriver"
+	"github.com/stretchr/testify/assert"
+)
+
+func testMatrixJobs() map[string]screwdriver.Job {
+	return map[string]screwdriver.Job{
+		"main[NODE_VERSION=12,OS=linux]": {
+			Environment: map[string]string{"NODE_VERSION": "12", "OS": "linux"},
+			Matrix:      map[string]string{"NODE_VERSION": "12", "OS": "linux"},
+		},
+		"main[NODE_VERSION=14,OS=linux]": {
+			Environment: map[string]string{"NODE_VERSION": "14", "OS": "linux"},
+			Matrix:      map[string]string{"NODE_VERSION": "14", "OS": "linux"},
+		},
+		"main[NODE_VERSION=14,OS=darwin]": {
+			Environment: map[string]string{"NODE_VERSION": "14", "OS": "darwin"},
+			Matrix:      map[string]string{"NODE_VERSION": "14", "OS": "darwin"},
+		},
+		"publish": {Requires: []string{"main"}},
+	}
+}
+
+func TestMatrixJobNames(t *testing.T) {
+	testCases := []struct {
+		name      string
+		names     []string
+		filter    map[string]string
+		expected  []string
+		expectErr string
+	}{
+		{
+			name:     "all the combinations",
+			names:    []string{"main", "publish"},
+			expected: []string{"main[NODE_VERSION=12,OS=linux]", "main[NODE_VERSION=14,OS=darwin]", "main[NODE_VERSION=14,OS=linux]", "publish"},
+		},
+		{
+			name:     "filtered combinations",
+			names:    []string{"main"},
+			filter:   map[string]string{"NODE_VERSION": "14"},
+			expected: []string{"main[NODE_VERSION=14,OS=darwin]", "main[NODE_VERSION=14,OS=linux]"},
+		},
+		{
+			name:     "filtered by multiple keys",
+			names:    []string{"main"},
+			filter:   map[string]string{"NODE_VERSION": "14", "OS": "linux"},
+			expected: []string{"main[NODE_VERSION=14,OS=linux]"},
+		},
+		{
+			name:     "combination",
+			names:    []string{"main[NODE_VERSION=12,OS=linux]"},
+			expected: []string{"main[NODE_VERSION=12,OS=linux]"},
+		},
+		{
+			name:     "not found",
+			names:    []string{"lint"},
+			expected: []string{"lint"},
+		},
+		{
+			name:      "no combination matches",
+			names:     []string{"main"},
+			filter:    map[string]string{"NODE_VERSION": "16"},
+			expectErr: "no combination of the matrix of job main matches NODE_VERSION=16, the combinations are: main[NODE_VERSION=12,OS=linux], main[NODE_VERSION=14,OS=darwin], main[NODE_VERSION=14,OS=linux]",
+		},
+		{
+			name:      "no matrix",
+			names:     []string{"publish"},
+			filter:    map[string]string{"NODE_VERSION": "14"},
+			expectErr: "can't filter the combinations by `matrix` NODE_VERSION=14, the jobs don't have the matrix",
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			actual, err := matrixJobNames(testMatrixJobs(), tt.names, tt.filter)
+			if tt.expectErr != "" {
+				assert.Equal(t, tt.expectErr, err.Error())
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, tt.expected, actual)
+		})
+	}
+}
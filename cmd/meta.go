@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/screwdriver-cd/sd-local/config"
+	"github.com/screwdriver-cd/sd-local/launch"
+)
+
+// setMeta sets the value of the key into the meta. The dotted keys like foo.bar set the nested values.
+func setMeta(meta launch.Meta, key, value string) error {
+	keys := strings.Split(key, ".")
+	m := map[string]interface{}(meta)
+	for i, k := range keys {
+		if k == "" {
+			return fmt.Errorf("invalid meta key %s: must not have empty parts", key)
+		}
+		if i == len(keys)-1 {
+			m[k] = value
+			break
+		}
+
+		child, ok := m[k].(map[string]interface{})
+		if !ok {
+			child = make(map[string]interface{})
+			m[k] = child
+		}
+		m = child
+	}
+	return nil
+}
+
+// parseMeta parses the values of --meta, which are key=value or a JSON object.
+// The later values take precedence over the former ones.
+func parseMeta(values []string) (launch.Meta, error) {
+	meta := launch.Meta{}
+	for _, v := range values {
+		if strings.HasPrefix(strings.TrimSpace(v), "{") {
+			var m launch.Meta
+			if err := json.Unmarshal([]byte(v), &m); err != nil {
+				return nil, fmt.Errorf("failed to parse meta %s, meta must be formated with JSON: %v", v, err)
+			}
+			for k, value := range m {
+				meta[k] = value
+			}
+			continue
+		}
+
+		kv := strings.SplitN(v, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("invalid meta %s: must be key=value or a JSON object", v)
+		}
+		if err := setMeta(meta, kv[0], kv[1]); err != nil {
+			return nil, err
+		}
+	}
+	return meta, nil
+}
+
+// supportsMetaOut returns true if the meta directory of the build container can be mounted with the runtime
+func supportsMetaOut(runtime string) bool {
+	return runtime != config.RuntimeKubernetes
+}
+
+// newMetaDir creates the directory to mount into the meta directory of the build container.
+// It is writable by everyone because the user of the build container may differ from the user of sd-local.
+func newMetaDir() (string, error) {
+	dir, err := ioutil.TempDir("", "sd-local-meta")
+	if err != nil {
+		return "", err
+	}
+	if err := os.Chmod(dir, 0777); err != nil {
+		os.RemoveAll(dir)
+		return "", err
+	}
+	return dir, nil
+}
+
+// writeMetaOut writes the meta written by the launcher in the meta directory into the file
+func writeMetaOut(metaDir, metaOutPath string) error {
+	b, err := ioutil.ReadFile(filepath.Join(metaDir, launch.MetaFile))
+	if err != nil {
+		return fmt.Errorf("failed to read the meta of the build: %v", err)
+	}
+
+	var meta launch.Meta
+	if err := json.Unmarshal(b, &meta); err != nil {
+		return fmt.Errorf("failed to parse the meta of the build: %v", err)
+	}
+	b, err = json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := ioutil.WriteFile(metaOutPath, append(b, '\n'), 0666); err != nil {
+		return fmt.Errorf("failed to write the meta into %s: %v", metaOutPath, err)
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/screwdriver-cd/sd-local/launch"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMeta(t *testing.T) {
+	cases := []struct {
+		name      string
+		values    []string
+		expect    launch.Meta
+		expectErr string
+	}{
+		{
+			name:   "key=value",
+			values: []string{"foo=bar", "empty="},
+			expect: launch.Meta{"foo": "bar", "empty": ""},
+		},
+		{
+			name:   "nested keys",
+			values: []string{"foo.bar=1", "foo.baz.qux=2"},
+			expect: launch.Meta{"foo": map[string]interface{}{"bar": "1", "baz": map[string]interface{}{"qux": "2"}}},
+		},
+		{
+			name:   "JSON object and key=value",
+			values: []string{`{"foo": {"bar": 1}, "hoge": "fuga"}`, "foo.baz=2", "hoge=piyo"},
+			expect: launch.Meta{"foo": map[string]interface{}{"bar": float64(1), "baz": "2"}, "hoge": "piyo"},
+		},
+		{
+			name:   "the later value overwrites the nested value",
+			values: []string{"foo.bar=1", "foo=2"},
+			expect: launch.Meta{"foo": "2"},
+		},
+		{
+			name:      "failure by missing =",
+			values:    []string{"foo"},
+			expectErr: "invalid meta foo: must be key=value or a JSON object",
+		},
+		{
+			name:      "failure by empty key",
+			values:    []string{"foo..bar=1"},
+			expectErr: "invalid meta key foo..bar: must not have empty parts",
+		},
+		{
+			name:      "failure by invalid JSON",
+			values:    []string{`{"foo"}`},
+			expectErr: `failed to parse meta {"foo"}, meta must be formated with JSON: invalid character '}' after object key`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			meta, err := parseMeta(c.values)
+			if c.expectErr != "" {
+				assert.Equal(t, c.expectErr, err.Error())
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, c.expect, meta)
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/screwdriver-cd/sd-local/config"
+	"github.com/screwdriver-cd/sd-local/screwdriver"
+)
+
+var (
+	cacheJobs  = saveJobsCache
+	cachedJobs = loadJobsCache
+)
+
+// supportsOffline returns true if the runtime can run the build only with the local images.
+// The images of k8s are pulled by the nodes of the cluster, which sd-local can't prevent.
+func supportsOffline(runtime string) bool {
+	return runtime != config.RuntimeKubernetes
+}
+
+// jobsCacheDir returns the directory of the jobs parsed by the API, which are used in the offline mode
+func jobsCacheDir(sdlocalDir string) string {
+	return filepath.Join(sdlocalDir, "cache", "jobs")
+}
+
+// jobsCachePath returns the path of the cached jobs of screwdriver.yaml, which is keyed by its content.
+// The jobs of the edited screwdriver.yaml are not found in the cache.
+func jobsCachePath(cacheDir, sdYAMLPath string) (string, error) {
+	content, err := ioutil.ReadFile(sdYAMLPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read screwdriver.yaml: %v", err)
+	}
+	sum := sha256.Sum256(content)
+	return filepath.Join(cacheDir, hex.EncodeToString(sum[:])+".json"), nil
+}
+
+// saveJobsCache saves the jobs of screwdriver.yaml parsed by the API into the cache
+func saveJobsCache(cacheDir, sdYAMLPath string, jobs map[string]screwdriver.Job) error {
+	cachePath, err := jobsCachePath(cacheDir, sdYAMLPath)
+	if err != nil {
+		return err
+	}
+	b, err := json.Marshal(jobs)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(cacheDir, 0777); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(cachePath, b, 0666)
+}
+
+// loadJobsCache loads the jobs of screwdriver.yaml from the cache instead of the API
+func loadJobsCache(cacheDir, sdYAMLPath string) (map[string]screwdriver.Job, error) {
+	cachePath, err := jobsCachePath(cacheDir, sdYAMLPath)
+	if err != nil {
+		return nil, err
+	}
+	b, err := ioutil.ReadFile(cachePath)
+	if os.IsNotExist(err) {
+		return nil, errors.New("the jobs of screwdriver.yaml are not cached, run the build online once with the same screwdriver.yaml and --env to cache them")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var jobs map[string]screwdriver.Job
+	if err := json.Unmarshal(b, &jobs); err != nil {
+		return nil, fmt.Errorf("failed to parse the cached jobs of screwdriver.yaml: %v", err)
+	}
+	return jobs, nil
+}
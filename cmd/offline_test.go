@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/screwdriver-cd/sd-local/screwdriver"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJobsCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "jobs-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	sdYAMLPath := filepath.Join(dir, "screwdriver.yaml")
+	if err := ioutil.WriteFile(sdYAMLPath, []byte("jobs:\n  main:\n    image: node:12\n"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	cacheDir := jobsCacheDir(filepath.Join(dir, ".sdlocal"))
+
+	_, err = loadJobsCache(cacheDir, sdYAMLPath)
+	assert.Equal(t, "the jobs of screwdriver.yaml are not cached, run the build online once with the same screwdriver.yaml and --env to cache them", err.Error())
+
+	jobs := map[string]screwdriver.Job{
+		"main": {
+			Image:       "node:12",
+			Steps:       []screwdriver.Step{{Name: "test", Command: "npm test"}},
+			Environment: map[string]string{"FOO": "foo"},
+			Requires:    []string{"~commit"},
+		},
+	}
+	err = saveJobsCache(cacheDir, sdYAMLPath, jobs)
+	assert.Nil(t, err)
+
+	cached, err := loadJobsCache(cacheDir, sdYAMLPath)
+	assert.Nil(t, err)
+	assert.Equal(t, jobs, cached)
+
+	// the edited screwdriver.yaml is parsed by the API again
+	if err := ioutil.WriteFile(sdYAMLPath, []byte("jobs:\n  main:\n    image: node:14\n"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	_, err = loadJobsCache(cacheDir, sdYAMLPath)
+	assert.NotNil(t, err)
+}
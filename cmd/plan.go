@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/screwdriver-cd/sd-local/buildlog"
+	"github.com/screwdriver-cd/sd-local/config"
+	"github.com/screwdriver-cd/sd-local/launch"
+)
+
+// planner is the launcher which tells the plan of the build without running it
+type planner interface {
+	Plan() launch.Plan
+}
+
+// printPlan prints the plan of the build of the job. The values of the secrets are never printed.
+func printPlan(w io.Writer, jobName, runtimeName string, plan launch.Plan, mask func(string) string) {
+	if runtimeName == "" {
+		runtimeName = config.RuntimeDocker
+	}
+
+	fmt.Fprintf(w, "Job: %s\n", jobName)
+	fmt.Fprintf(w, "  Image: %s\n", plan.Image)
+	fmt.Fprintf(w, "  Runtime: %s\n", runtimeName)
+	if plan.CPU != "" {
+		fmt.Fprintf(w, "  CPU: %s\n", plan.CPU)
+	}
+	if plan.Memory != "" {
+		fmt.Fprintf(w, "  Memory: %s\n", plan.Memory)
+	}
+	if plan.Privileged {
+		fmt.Fprintln(w, "  Privileged: true")
+	}
+	if plan.User != "" {
+		fmt.Fprintf(w, "  User: %s\n", plan.User)
+	}
+
+	fmt.Fprintln(w, "  Mounts:")
+	for _, m := range plan.Mounts {
+		fmt.Fprintf(w, "    %s\n", m)
+	}
+
+	fmt.Fprintln(w, "  Environment:")
+	for _, e := range plan.Environment {
+		fmt.Fprintf(w, "    %s\n", mask(e))
+	}
+	for _, k := range plan.Secrets {
+		fmt.Fprintf(w, "    %s=%s\n", k, buildlog.Mask)
+	}
+
+	fmt.Fprintln(w, "  Steps:")
+	for _, s := range plan.Steps {
+		fmt.Fprintf(w, "    %s:\n", s.Name)
+		for _, line := range strings.Split(strings.TrimRight(s.Command, "\n"), "\n") {
+			fmt.Fprintf(w, "      %s\n", mask(line))
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/screwdriver-cd/sd-local/launch"
+	"github.com/screwdriver-cd/sd-local/screwdriver"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrintPlan(t *testing.T) {
+	plan := launch.Plan{
+		Image: "node:12",
+		Steps: []screwdriver.Step{
+			{Name: "install", Command: "npm install"},
+			{Name: "test", Command: "npm test\necho secret-value\n"},
+		},
+		Environment: []string{"FOO=foo", "LOGIN=user:secret-value"},
+		Secrets:     []string{"API_KEY", "SD_TOKEN"},
+		Mounts:      []string{"/src/:/sd/workspace/src/screwdriver.cd/sd-local/local-build"},
+		CPU:         "2",
+		Memory:      "4g",
+		Privileged:  true,
+		User:        "1000:1000",
+	}
+	mask := strings.NewReplacer("secret-value", "****").Replace
+
+	t.Run("success", func(t *testing.T) {
+		buf := bytes.NewBuffer(nil)
+		printPlan(buf, "main", "podman", plan, mask)
+		assert.Equal(t, `Job: main
+  Image: node:12
+  Runtime: podman
+  CPU: 2
+  Memory: 4g
+  Privileged: true
+  User: 1000:1000
+  Mounts:
+    /src/:/sd/workspace/src/screwdriver.cd/sd-local/local-build
+  Environment:
+    FOO=foo
+    LOGIN=user:****
+    API_KEY=****
+    SD_TOKEN=****
+  Steps:
+    install:
+      npm install
+    test:
+      npm test
+      echo ****
+`, buf.String())
+	})
+
+	t.Run("success without limits", func(t *testing.T) {
+		buf := bytes.NewBuffer(nil)
+		printPlan(buf, "main", "", launch.Plan{Image: "node:12"}, mask)
+		assert.Equal(t, "Job: main\n  Image: node:12\n  Runtime: docker\n  Mounts:\n  Environment:\n  Steps:\n", buf.String())
+	})
+}
@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/screwdriver-cd/sd-local/config"
+	"github.com/screwdriver-cd/sd-local/launch"
+	"github.com/spf13/cobra"
+)
+
+var (
+	findLeftovers   = launch.FindLeftovers
+	removeLeftovers = launch.RemoveLeftovers
+)
+
+func newPruneCmd() *cobra.Command {
+	var runtimeName string
+	var images bool
+	var dryRun bool
+
+	pruneCmd := &cobra.Command{
+		Use:   "prune",
+		Short: "Remove the containers, volumes and images left by sd-local.",
+		Long: `Remove the build containers labeled by sd-local and the volumes of the launcher,
+which are left by the interrupted builds or kept by --no-teardown.
+The dangling launcher images left by pulling the new versions are removed as well with --images.
+The other containers, volumes and images are not touched. Stop the running builds before pruning.`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if err := cobra.NoArgs(cmd, args); err != nil {
+				return err
+			}
+			return config.ValidateRuntime(runtimeName)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+
+			// the runtime and the launcher of the current config are used if it exists
+			launcher := config.DefaultEntry().Launcher
+			if _, entry, err := currentEntry(); err == nil {
+				if runtimeName == "" {
+					runtimeName = entry.Runtime
+				}
+				launcher = entry.Launcher
+			}
+			if runtimeName == "" {
+				runtimeName = config.RuntimeDocker
+			}
+			if runtimeName == config.RuntimeKubernetes {
+				return errors.New("runtime k8s does not support prune, the pods of the builds are removed by the cluster")
+			}
+
+			launcherImage := ""
+			if images {
+				launcherImage = launcher.Image
+			}
+			leftovers, err := findLeftovers(runtimeName, launcherImage)
+			if err != nil {
+				return withExitCode(err, ExitRuntime)
+			}
+			if leftovers.Empty() {
+				fmt.Fprintln(cmd.OutOrStdout(), "Nothing to prune")
+				return nil
+			}
+
+			action := "Removed"
+			if dryRun {
+				action = "Would remove"
+			} else if err := removeLeftovers(runtimeName, leftovers); err != nil {
+				return withExitCode(err, ExitRuntime)
+			}
+			for _, c := range leftovers.Containers {
+				fmt.Fprintf(cmd.OutOrStdout(), "%s container %s\n", action, c)
+			}
+			for _, v := range leftovers.Volumes {
+				fmt.Fprintf(cmd.OutOrStdout(), "%s volume %s\n", action, v)
+			}
+			for _, i := range leftovers.Images {
+				fmt.Fprintf(cmd.OutOrStdout(), "%s image %s\n", action, i)
+			}
+			return nil
+		},
+	}
+
+	pruneCmd.Flags().StringVar(
+		&runtimeName,
+		"runtime",
+		"",
+		"Runtime to prune, docker or podman. The runtime of the config or docker is used if it is not specified.")
+
+	pruneCmd.Flags().BoolVar(
+		&images,
+		"images",
+		false,
+		"Remove the dangling images of the launcher of the config as well.")
+
+	pruneCmd.Flags().BoolVar(
+		&dryRun,
+		"dry-run",
+		false,
+		"Print the containers, volumes and images to remove without removing them.")
+
+	return pruneCmd
+}
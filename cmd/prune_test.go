@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/screwdriver-cd/sd-local/config"
+	"github.com/screwdriver-cd/sd-local/launch"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPruneCmd(t *testing.T) {
+	defConfigNew, defFindLeftovers, defRemoveLeftovers := configNew, findLeftovers, removeLeftovers
+	defer func() {
+		configNew, findLeftovers, removeLeftovers = defConfigNew, defFindLeftovers, defRemoveLeftovers
+	}()
+
+	leftovers := launch.Leftovers{
+		Containers: []string{"sdlocal-main-20210102150405"},
+		Volumes:    []string{"SD_LAUNCH_BIN", "SD_LAUNCH_HAB"},
+		Images:     []string{"ba9876543210"},
+	}
+
+	cases := []struct {
+		name          string
+		args          []string
+		runtime       string
+		leftovers     launch.Leftovers
+		findErr       error
+		removeErr     error
+		expectRuntime string
+		expectImage   string
+		expectRemoved bool
+		expect        string
+		expectErr     string
+	}{
+		{
+			name:          "success",
+			leftovers:     leftovers,
+			expectRuntime: "docker",
+			expectRemoved: true,
+			expect: "Removed container sdlocal-main-20210102150405\n" +
+				"Removed volume SD_LAUNCH_BIN\n" +
+				"Removed volume SD_LAUNCH_HAB\n" +
+				"Removed image ba9876543210\n",
+		},
+		{
+			name:          "success with dry run and images",
+			args:          []string{"--dry-run", "--images"},
+			runtime:       "podman",
+			leftovers:     leftovers,
+			expectRuntime: "podman",
+			expectImage:   "screwdrivercd/launcher",
+			expect: "Would remove container sdlocal-main-20210102150405\n" +
+				"Would remove volume SD_LAUNCH_BIN\n" +
+				"Would remove volume SD_LAUNCH_HAB\n" +
+				"Would remove image ba9876543210\n",
+		},
+		{
+			name:          "success with nothing to prune",
+			args:          []string{"--runtime", "podman"},
+			expectRuntime: "podman",
+			expect:        "Nothing to prune\n",
+		},
+		{
+			name:      "failure by k8s",
+			runtime:   "k8s",
+			expectErr: "runtime k8s does not support prune, the pods of the builds are removed by the cluster",
+		},
+		{
+			name:          "failure by find",
+			findErr:       errors.New("failed to list the volumes: exit status 1"),
+			expectRuntime: "docker",
+			expectErr:     "failed to list the volumes: exit status 1",
+		},
+		{
+			name:          "failure by remove",
+			leftovers:     leftovers,
+			removeErr:     errors.New("failed to remove the containers: exit status 1"),
+			expectRuntime: "docker",
+			expectRemoved: true,
+			expectErr:     "failed to remove the containers: exit status 1",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			configNew = func(confPath string) (config.Config, error) {
+				entry := config.DefaultEntry()
+				entry.Runtime = c.runtime
+				return config.Config{Entries: map[string]*config.Entry{"default": entry}, Current: "default"}, nil
+			}
+			var runtime, image string
+			findLeftovers = func(r, i string) (launch.Leftovers, error) {
+				runtime, image = r, i
+				return c.leftovers, c.findErr
+			}
+			removed := false
+			removeLeftovers = func(r string, l launch.Leftovers) error {
+				removed = true
+				assert.Equal(t, c.leftovers, l)
+				return c.removeErr
+			}
+
+			cmd := newPruneCmd()
+			cmd.SetArgs(c.args)
+			cmd.SilenceErrors = true
+			buf := bytes.NewBuffer(nil)
+			cmd.SetOut(buf)
+			err := cmd.Execute()
+			assert.Equal(t, c.expectRuntime, runtime)
+			assert.Equal(t, c.expectImage, image)
+			assert.Equal(t, c.expectRemoved, removed)
+			assert.Equal(t, c.expect, buf.String())
+			if c.expectErr != "" {
+				assert.Equal(t, c.expectErr, err.Error())
+				return
+			}
+			assert.Nil(t, err)
+		})
+	}
+}
@@ -0,0 +1,15 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+)
+
+// parseReport parses the report option like json=<path> and returns the path
+func parseReport(report string) (string, error) {
+	kv := strings.SplitN(report, "=", 2)
+	if len(kv) != 2 || kv[0] != outputJSON || kv[1] == "" {
+		return "", fmt.Errorf("invalid report %s: must be like json=<path>", report)
+	}
+	return kv[1], nil
+}
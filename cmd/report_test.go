@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseReport(t *testing.T) {
+	testCases := map[string]struct {
+		report  string
+		path    string
+		wantErr bool
+	}{
+		"json":           {report: "json=report.json", path: "report.json"},
+		"path with '='":  {report: "json=a=b.json", path: "a=b.json"},
+		"unknown format": {report: "yaml=report.yaml", wantErr: true},
+		"without path":   {report: "json=", wantErr: true},
+		"only path":      {report: "report.json", wantErr: true},
+	}
+
+	for name, tt := range testCases {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			path, err := parseReport(tt.report)
+			if tt.wantErr {
+				assert.EqualError(t, err, "invalid report "+tt.report+": must be like json=<path>")
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, tt.path, path)
+		})
+	}
+}
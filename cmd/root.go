@@ -1,16 +1,21 @@
 package cmd
 
 import (
+	"fmt"
 	"os"
 	"os/signal"
+	"strings"
+	"sync"
 	"syscall"
 
 	"github.com/screwdriver-cd/sd-local/cmd/config"
+	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
 
 var (
-	cleaners []Cleaner
+	cleaners      []Cleaner
+	cleanersMutex sync.Mutex
 )
 
 // Cleaner will post-process sd-local.
@@ -20,9 +25,39 @@ type Cleaner interface {
 }
 
 var (
-	flagVerbose bool
+	flagVerbose  bool
+	flagLogLevel string
 )
 
+// configFilePath returns the path of the config file set by --config, SD_LOCAL_CONFIG or the default path
+var configFilePath = config.FilePath
+
+// logLevels are the levels of the logs which can be set by --log-level
+var logLevels = []string{"error", "warn", "info", "debug"}
+
+// setupLogLevel sets the level of the logs by --log-level and --verbose, which is the same as debug.
+// flagVerbose is turned on at debug level so that the verbose outputs like the container commands are logged.
+func setupLogLevel() error {
+	valid := false
+	for _, l := range logLevels {
+		valid = valid || flagLogLevel == l
+	}
+	if !valid {
+		return fmt.Errorf("invalid log level %s: must be one of %s", flagLogLevel, strings.Join(logLevels, ", "))
+	}
+
+	level, err := logrus.ParseLevel(flagLogLevel)
+	if err != nil {
+		return err
+	}
+	if flagVerbose && level < logrus.DebugLevel {
+		level = logrus.DebugLevel
+	}
+	logrus.SetLevel(level)
+	flagVerbose = level >= logrus.DebugLevel
+	return nil
+}
+
 func newRootCmd() *cobra.Command {
 
 	rootCmd := &cobra.Command{
@@ -30,6 +65,9 @@ func newRootCmd() *cobra.Command {
 		Short: "Run build in local",
 		Long: `Run build instantly on your local machine with
 a mostly the same environment as Screwdriver.cd's`,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			return setupLogLevel()
+		},
 	}
 
 	rootCmd.PersistentFlags().BoolVarP(
@@ -37,48 +75,109 @@ a mostly the same environment as Screwdriver.cd's`,
 		"verbose",
 		"v",
 		false,
-		"verbose output.")
+		"verbose output. It is the same as --log-level debug.")
+
+	rootCmd.PersistentFlags().StringVar(
+		&flagLogLevel,
+		"log-level",
+		"info",
+		"Level of the logs, error, warn, info or debug. The requests to the API, the mounts and the container commands are logged at debug level.")
+
+	config.AddFilePathFlag(rootCmd.PersistentFlags())
 
 	return rootCmd
 }
 
+// addCleaner registers the Cleaner. It can be called from the goroutines running jobs in parallel.
+func addCleaner(c Cleaner) {
+	cleanersMutex.Lock()
+	defer cleanersMutex.Unlock()
+	cleaners = append(cleaners, c)
+}
+
+// removeCleaner unregisters the Cleaner which finished, so that it is not killed by the next run of --watch
+func removeCleaner(c Cleaner) {
+	cleanersMutex.Lock()
+	defer cleanersMutex.Unlock()
+	for i, v := range cleaners {
+		if v == c {
+			cleaners = append(cleaners[:i], cleaners[i+1:]...)
+			return
+		}
+	}
+}
+
 func kill(sig os.Signal) {
+	cleanersMutex.Lock()
+	defer cleanersMutex.Unlock()
 	for _, v := range cleaners {
 		v.Kill(sig)
 	}
 }
 
 func clean() {
+	cleanersMutex.Lock()
+	defer cleanersMutex.Unlock()
 	for _, v := range cleaners {
 		v.Clean()
 	}
 }
 
+var osExit = os.Exit
+
+// signalExitCode returns the exit code of sd-local stopped by the signal like shells, which is 130 for Ctrl-C
+func signalExitCode(sig os.Signal) int {
+	if s, ok := sig.(syscall.Signal); ok {
+		return 128 + int(s)
+	}
+	return 1
+}
+
+// handleSignals stops the running builds and removes their containers and volumes before exiting on the first signal.
+// The second signal exits immediately without waiting for them.
+func handleSignals(quit <-chan os.Signal) {
+	sig := <-quit
+	setReceivedSignal(sig)
+	logrus.Warnf("Received %v, stopping the build and removing the container... Press Ctrl-C again to exit immediately", sig)
+
+	done := make(chan struct{})
+	go func() {
+		kill(sig)
+		clean()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-quit:
+		logrus.Warn("Exited without cleaning up, the build container and volumes may be left")
+	}
+	osExit(signalExitCode(sig))
+}
+
 // Execute executes the root command.
 func Execute() error {
 	cleaners = make([]Cleaner, 0, 2)
 	defer clean()
 
-	go func() {
-		quit := make(chan os.Signal)
-		signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
-		for {
-			select {
-			case sig := <-quit:
-				kill(sig)
-				clean()
-				os.Exit(1)
-			}
-		}
-	}()
+	quit := make(chan os.Signal, 2)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+	go handleSignals(quit)
 
 	rootCmd := newRootCmd()
 	rootCmd.SilenceErrors = true
 	rootCmd.AddCommand(
 		newBuildCmd(),
+		newExecCmd(),
 		config.NewConfigCmd(),
 		newVersionCmd(),
 		newUpdateCmd(),
+		newValidateCmd(),
+		newDoctorCmd(),
+		newPruneCmd(),
+		newCacheCmd(),
+		newCompletionCmd(),
+		newCompleteNamesCmd(),
 	)
-	return rootCmd.Execute()
+	return interruptedError(executeMasked(rootCmd, logrus.StandardLogger()))
 }
@@ -2,14 +2,23 @@ package cmd
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
+	"io/ioutil"
+	"net/http"
 	"os"
+	"path/filepath"
+	"syscall"
 	"testing"
 
+	"github.com/go-yaml/yaml"
+	"github.com/mitchellh/go-homedir"
+	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 
 	"github.com/screwdriver-cd/sd-local/buildlog"
+	cmdconfig "github.com/screwdriver-cd/sd-local/cmd/config"
 	"github.com/screwdriver-cd/sd-local/config"
 	"github.com/screwdriver-cd/sd-local/launch"
 
@@ -17,20 +26,82 @@ import (
 )
 
 type mockAPI struct{}
-type mockLogger struct{}
+type mockLogger struct {
+	done    chan<- struct{}
+	timings []buildlog.StepTiming
+}
 type mockLaunch struct{}
 
 func (mock mockAPI) Job(jobName, filePath string) (screwdriver.Job, error) {
 	return screwdriver.Job{}, nil
 }
 
+func (mock mockAPI) Jobs(filePath string) (map[string]screwdriver.Job, error) {
+	return map[string]screwdriver.Job{
+		"test": {
+			Steps: []screwdriver.Step{
+				{Name: "install", Command: "npm install"},
+				{Name: "test", Command: "npm test"},
+			},
+		},
+		"lint":    {},
+		"publish": {Requires: []string{"test", "lint"}},
+	}, nil
+}
+
+func (mock mockAPI) PipelineJobs(ctx context.Context, pipelineID int) (map[string]screwdriver.Job, error) {
+	switch pipelineID {
+	case 123:
+	case 456:
+		return map[string]screwdriver.Job{
+			"main": {Steps: []screwdriver.Step{{Name: "test", Command: "sd-cmd exec foo/bar@stable"}}},
+		}, nil
+	case 457:
+		return map[string]screwdriver.Job{
+			"main": {Steps: []screwdriver.Step{{Name: "test", Command: "sd-cmd exec foo/private@1.0.0"}}},
+		}, nil
+	default:
+		return nil, fmt.Errorf("pipeline %d is not found", pipelineID)
+	}
+	return map[string]screwdriver.Job{
+		"main": {
+			Steps: []screwdriver.Step{{Name: "test", Command: "make test"}},
+			Image: "golang",
+		},
+	}, nil
+}
+
+func (mock mockAPI) Command(ctx context.Context, namespace, name, version string) (screwdriver.Command, error) {
+	if name == "private" {
+		return screwdriver.Command{}, fmt.Errorf("not authorized to get command %s/%s@%s: StatusCode 403", namespace, name, version)
+	}
+	return screwdriver.Command{Namespace: namespace, Name: name, Version: "1.0.0", Format: "habitat"}, nil
+}
+
+func (mock mockAPI) Template(ctx context.Context, name, version string) (screwdriver.Template, error) {
+	if name != "foo/bar" {
+		return screwdriver.Template{}, fmt.Errorf("template %s@%s is not found", name, version)
+	}
+	return screwdriver.Template{
+		Namespace: "foo",
+		Name:      "bar",
+		Version:   "1.0.0",
+		Config: yaml.MapSlice{
+			{Key: "image", Value: "golang"},
+			{Key: "steps", Value: []interface{}{yaml.MapSlice{{Key: "test", Value: "go test ./..."}}}},
+		},
+	}, nil
+}
+
 func (mock mockAPI) JWT() string { return "" }
 
 func (mock mockAPI) InitJWT() error { return nil }
 
 func (mock mockLogger) Run() {}
 
-func (mock mockLogger) Stop() { close(loggerDone) }
+func (mock mockLogger) Stop(err error) { close(mock.done) }
+
+func (mock mockLogger) Timings() []buildlog.StepTiming { return mock.timings }
 
 func (mock mockLaunch) Run() error { return nil }
 
@@ -46,21 +117,97 @@ func buildLocalFlags() string {
 
 	return fmt.Sprintf(`
 Flags:
-      --artifacts-dir string   Path to the host side directory which is mounted into $SD_ARTIFACTS_DIR. (default "sd-artifacts")
-  -e, --env stringToString     Set key and value relationship which is set as environment variables of Build Container. (<key>=<value>) (default [])
-      --env-file string        Path to config file of environment variables. '.env' format file can be used.
-  -h, --help                   help for build
-  -i, --interactive            Attach the build container in interactive mode.
-  -m, --memory string          Memory limit for build container, which take a positive integer, followed by a suffix of b, k, m, g.
-      --meta string            Metadata to pass into the build environment, which is represented with JSON format
-      --meta-file string       Path to the meta file. meta file is represented with JSON format.
-      --privileged             Use privileged mode for container runtime.
-  -S, --socket string          Path to the socket. It will used in build container.%s
-      --src-url string         Specify the source url to build.
-                               ex) git@github.com:<org>/<repo>.git[#<branch>]
-                                   https://github.com/<org>/<repo>.git[#<branch>]
-      --sudo                   Use sudo command for container runtime.
-      --vol strings            Volumes to mount into build container.
+      --artifacts-dir string           Path to the host side directory which is mounted into $SD_ARTIFACTS_DIR. {job} is replaced with the job name, which joins the names with - for multiple jobs,
+                                       and {time} is replaced with the time the build started like 20060102150405 to keep the artifacts of every run like sd-artifacts/{job}/{time}. (default "sd-artifacts")
+      --artifacts-keep int             Number of the runs made by {time} of --artifacts-dir to keep. The oldest runs beyond it are removed after a successful build. All the runs are kept if it is 0.
+      --artifacts-s3 string            Destination like s3://bucket/prefix to upload the artifacts to after the build. They are uploaded by the AWS CLI with its credentials.
+      --artifacts-s3-endpoint string   Endpoint URL of the S3 compatible storage like MinIO to upload the artifacts to.
+      --cache-dir string               Path to the build cache like ~/.sdlocal/cache/build, which persists across the builds and the jobs. It is mounted into /sd/cache set to $SD_LOCAL_CACHE_DIR.
+                                       Point the caches of the tools at it like GOMODCACHE=$SD_LOCAL_CACHE_DIR/go/mod. It is cleaned by sd-local cache clean. It is not supported by k8s.
+      --changed-since string           Run only the jobs whose sourcePaths have the files changed since the git ref like origin/main, including the uncommitted and untracked files.
+                                       The jobs without sourcePaths always run, and the skipped jobs are printed.
+      --check-launcher-version         Warn if the launcher version is not a tag of the launcher image in the registry, which is accessed anonymously. latest and stable are always accepted.
+      --config-entry string            Name of the config to run the build with instead of the current config, which is not changed by it.
+      --container-name string          Name of the build container, which is suffixed with the job name for multiple jobs. sdlocal-<job>-<timestamp> is used if it is not specified.
+                                       The build container is labeled with sdlocal.job and sdlocal.entry to be listed like docker ps --filter label=sdlocal.job. It is not supported by k8s.
+      --docker-host string             Address of the daemon of docker or podman like tcp://host:2376 or a path of a unix socket. docker-host of the config, or DOCKER_HOST or CONTAINER_HOST is used if it is not specified.
+      --dry-run                        Print the plan of the build like the steps, the image, the environment variables and the mounts without running it.
+      --dump-env string                Path to the file to write the environment variables of the build container into in '.env' format before the build, except the ones of the steps.
+                                       The secrets and SD_TOKEN are masked unless --dump-env-unmasked is passed, and {job} is replaced with the job name, which is required for multiple jobs.
+      --dump-env-unmasked              Write the values of the secrets and SD_TOKEN into the file of --dump-env as they are. Be careful not to share the file.
+  -e, --env stringToString             Set key and value relationship which is set as environment variables of Build Container. (<key>=<value>)
+                                       The value of <key>=@<path> is read from the file like a PEM or JSON, and <key> alone passes the variable of the host through if it is set. (default [])
+      --env-file string                Path to the file of environment variables in '.env' format, which can have comments, quoted values and export prefixes. --env takes precedence over it.
+      --fail-fast                      Fail the build at the first failed step. With --fail-fast=false, the next steps run after a step failed,
+                                       and the build fails after all the user steps with the failed steps listed. The teardown steps run regardless of it. (default true)
+  -f, --file string                    Path to the screwdriver.yaml to run the jobs in like ci/screwdriver.yaml, which is relative to the working directory. screwdriver.yaml in the source directory is used if it is not specified.
+  -h, --help                           help for build
+      --image string                   Image to run the jobs with instead of the image in screwdriver.yaml like node:20. It can be used with --platform to try the other images.
+  -i, --interactive                    Attach the build container in interactive mode.
+      --json-events string             Path to the file like /dev/fd/3 to write the events of the builds into as newline-delimited JSON apart from the build logs,
+                                       build-started, step-started, log-line, step-finished and build-finished with the time, the job and the step.
+      --launcher-archive string        Path to the tarball made by docker save to load the launcher image from instead of pulling it, which has to have the launcher image of the config. launcher-archive of the config is used if it is not specified. It is not supported by k8s.
+      --launcher-profile string        Name of the launcher profile in launchers of the config to run the build with. The launcher of the config is used if it is not specified.
+      --log-append                     Append the build logs to the log file instead of truncating it.
+      --log-file string                Path to the file to write the build logs into as well as the terminal. ANSI escape sequences are removed in the file.
+      --log-format string              Format of the build logs, plain, json or tap. json prints an object with the job, the step, the time and the stream per line of the logs and per result of the steps,
+                                       and tap prints the steps as the tests of the Test Anything Protocol with the logs as the diagnostics. The summary of the steps is printed only in plain unless --output is passed. (default "plain")
+      --matrix stringToString          Run only the combinations of the matrix of the jobs whose environment variables match like NODE_VERSION=12. All the combinations run by default. (default [])
+      --max-parallel int               Maximum number of jobs to run in parallel. (default 1)
+  -m, --memory string                  Memory limit for build container, which take a positive integer, followed by a suffix of b, k, m, g. It caps the memory of the annotations.
+      --meta stringArray               Metadata to pass into the build environment like key=value, which can be specified multiple times. The nested keys are separated by dots like foo.bar=baz, and a JSON object is accepted as well.
+      --meta-file string               Path to the meta file. meta file is represented with JSON format.
+      --meta-out string                Path to the file to write the meta of the build into in JSON format after the build. It is written even if the build fails.
+      --mount stringArray              Bind mount the host path into the build container like ~/.m2:/root/.m2:ro. It can be specified multiple times.
+                                       The host path must exist, and the paths mounted by sd-local like the source code can't be mounted. It is not supported by k8s.
+      --no-color                       Disable the colors of the build logs. They are disabled if the output is not a terminal as well.
+      --no-expand                      Use the variables like ${VAR} and $VAR in screwdriver.yaml as they are.
+                                       They are expanded with the environment variables of --env and sd-local except in the steps and the environment by default, and ${VAR:-default} can be used for the undefined ones.
+      --no-ignore                      Mount all the files of the source code including the paths matched by .sdignore, and .gitignore of --src-dir.
+      --no-local-artifacts             Do not keep the artifacts in --artifacts-dir when they are uploaded by --artifacts-s3.
+      --no-teardown                    Skip the teardown steps and keep the build container if the build fails for debugging.
+                                       The kept container and volumes must be removed by yourself.
+      --offline                        Run the build without the network. The jobs parsed by the API in the previous builds and the local images are used, and it fails if they are not available. It is not supported by k8s.
+      --on-complete string             Command to run in the shell of the host when the build of each job finishes like a notification, with the result in the environment variables
+                                       SD_LOCAL_JOB, SD_LOCAL_STATUS of success or failure, SD_LOCAL_DURATION in seconds and SD_LOCAL_ARTIFACTS_DIR, and SD_LOCAL_ERROR and SD_LOCAL_FAILED_STEP on failure.
+      --on-failure string              Command to run like --on-complete only when the build of each job fails, which runs before --on-complete.
+      --on-success string              Command to run like --on-complete only when the build of each job succeeds, which runs before --on-complete.
+  -o, --output string                  Output format of the timing summary of the steps printed at the end of the build. Only 'json' is supported.
+      --pipeline-id int                ID of the pipeline in Screwdriver.cd to run the jobs of as the API has them instead of screwdriver.yaml. The source code is still taken from the working directory, --src-dir or --src-url.
+      --platform string                Platform of the images like linux/arm64. The architecture of the host is used if it is not specified.
+      --print-expanded                 Print screwdriver.yaml whose variables are expanded and whose job templates are merged into the jobs without running the build.
+      --print-ignored                  Print the paths of the source code which are not mounted because they are matched by the ignore files.
+      --privileged                     Use privileged mode for container runtime.
+      --pull string                    Policy to pull the launcher image, always, missing or never. The local image is used without contacting the registry unless it is always, and the image pinned to a digest is used only if the local one has the digest. It is ignored by k8s. (default "missing")
+  -q, --quiet                          Show only the logs of the failed steps and the summary on the terminal. The last 1000 lines of each step are kept until it finishes.
+                                       The build logs are not shown at all in the formats other than plain, and all the lines are in the build logs of the artifacts.
+      --report string                  Write the result of the build like the status of the steps and the artifacts into the file in the format, like json=<path>. It is written even if the build fails.
+      --resume                         Resume the failed build from the first step which did not succeed with the artifacts of the previous build.
+                                       The build container kept by --no-teardown is re-used. The build starts fresh if the job or the source code changed.
+      --retries int                    Number of the retries of the requests to the API and the pulls of the images failed by the transient errors like the network timeouts, 5xx and rate limits.
+      --retry-backoff duration         Wait before the first retry of --retries, which doubles on every retry. (default 1s)
+      --runtime string                 Runtime to run the build, docker, podman or k8s. The runtime of the config or docker is used if it is not specified.
+      --secrets-file string            Path to the file of secrets in '.env' format. They are set as environment variables of Build Container and masked in the logs.
+      --shell string                   Absolute path of the shell in the build image the steps run in like /bin/bash, which fails the build if it is not found in the image.
+                                       It overrides the annotation screwdriver.cd/shell and USER_SHELL_BIN of the job, and /bin/sh is used if none of them is specified.
+  -S, --socket string                  Path to the socket. It will used in build container.%s
+      --sort-time                      Sort the steps by duration in the timing summary.
+      --src-dir string                 Path to the local source directory to build, which is mounted into the build container without cloning.
+                                       The paths matched by .gitignore and .sdignore in it are not mounted.
+      --src-url string                 Specify the source url to build. The local directory is used like --src-dir.
+                                       ex) git@github.com:<org>/<repo>.git[#<branch>]
+                                           https://github.com/<org>/<repo>.git[#<branch>]
+      --step stringArray               Run only the specified step of the job. It can be specified multiple times to run the steps in the order of the job.
+      --strict                         Fail the build instead of warning when the launcher version is not a tag of the launcher image. The launcher version is checked as --check-launcher-version.
+      --sudo                           Use sudo command for container runtime. The password is asked once before the build, and the owner of the artifacts is changed back to the user after the build.
+      --timeout duration               Abort the build if it does not finish within the duration like 30m. The timeout of the config is used if it is not specified.
+      --user string                    User of the build container in the form of uid[:gid], which owns the artifacts and the files written into the source directory.
+                                       The user of sd-local is used with Docker on Linux, and the user of the image otherwise. Specify root for the images which require root.
+      --vol strings                    Volumes to mount into build container.
+      --watch                          Rerun the build whenever the files in the source directory change until it is interrupted.
+                                       The changes of the paths ignored by .sdignore do not trigger it, and the running build is cancelled by a new change.
+      --workdir string                 Absolute path of the working directory of the steps in the build container, which is created if it does not exist.
+                                       It overrides the annotation screwdriver.cd/workdir of the job, and the source directory of the launcher is used if neither is specified.
 
 `, defaultSocketPath)
 }
@@ -68,6 +215,9 @@ Flags:
 func setup() {
 	configNew = func(confPath string) (config.Config, error) {
 		defaultEntry := &config.Entry{
+			APIURL:   "https://api.screwdriver.cd",
+			StoreURL: "https://store.screwdriver.cd",
+			Token:    "token",
 			Launcher: config.Launcher{
 				Version: "stable",
 				Image:   "screwdrivercd/launcher",
@@ -82,14 +232,25 @@ func setup() {
 			Current: "default",
 		}, nil
 	}
-	apiNew = func(url, token, ua string) screwdriver.API { return mockAPI{} }
-	buildLogNew = func(filepath string, writer io.Writer, done chan<- struct{}) (logger buildlog.Logger, err error) {
-		return mockLogger{}, nil
+	apiNew = func(url, token, ua string, client *http.Client) screwdriver.API { return mockAPI{} }
+	buildLogNew = func(filepath string, writer io.Writer, done chan<- struct{}, color bool) (logger buildlog.Logger, err error) {
+		return mockLogger{done: done}, nil
 	}
 	launchNew = func(option launch.Option) launch.Launcher {
 		return mockLaunch{}
 	}
 	osMkdirAll = func(path string, filemode os.FileMode) error { return nil }
+	expandYAMLFile = func(sdYAMLPath string, env map[string]string) (string, error) { return sdYAMLPath, nil }
+	expandTemplateFile = func(api screwdriver.API, sdYAMLPath string) (string, error) { return sdYAMLPath, nil }
+	extractStepEnvFile = func(sdYAMLPath string) (string, screwdriver.StepEnvironments, error) {
+		return sdYAMLPath, screwdriver.StepEnvironments{}, nil
+	}
+	cacheJobs = func(cacheDir, sdYAMLPath string, jobs map[string]screwdriver.Job) error { return nil }
+	sudoValidate = func() error { return nil }
+	checkBuildRuntime = func(runtime string, useSudo bool, dockerHost string) error { return nil }
+	cachedJobs = func(cacheDir, sdYAMLPath string) (map[string]screwdriver.Job, error) {
+		return mockAPI{}.Jobs(sdYAMLPath)
+	}
 }
 
 func TestMain(m *testing.M) {
@@ -98,6 +259,28 @@ func TestMain(m *testing.M) {
 	os.Exit(ret)
 }
 
+func TestRemoveCleaner(t *testing.T) {
+	defCleaners := cleaners
+	defer func() { cleaners = defCleaners }()
+
+	finished, running := &killedCleaner{killed: make(chan struct{})}, &killedCleaner{killed: make(chan struct{})}
+	cleaners = make([]Cleaner, 0, 2)
+	addCleaner(finished)
+	addCleaner(running)
+	removeCleaner(finished)
+	removeCleaner(finished)
+
+	// only the running one is killed
+	kill(os.Interrupt)
+	assert.Equal(t, []Cleaner{running}, cleaners)
+	assert.Equal(t, struct{}{}, <-running.killed)
+	select {
+	case <-finished.killed:
+		t.Error("the finished cleaner is killed")
+	default:
+	}
+}
+
 func TestRootCmd(t *testing.T) {
 	t.Run("Success root cmd", func(t *testing.T) {
 		root := newRootCmd()
@@ -106,7 +289,7 @@ func TestRootCmd(t *testing.T) {
 		buf := bytes.NewBuffer(nil)
 		root.SetOut(buf)
 		err := root.Execute()
-		want := "Run build instantly on your local machine with\na mostly the same environment as Screwdriver.cd's\n\nUsage:\n  sd-local [command]\n\nAvailable Commands:\n  build       Run screwdriver build.\n  help        Help about any command\n\nFlags:\n  -h, --help      help for sd-local\n  -v, --verbose   verbose output.\n\nUse \"sd-local [command] --help\" for more information about a command.\n"
+		want := "Run build instantly on your local machine with\na mostly the same environment as Screwdriver.cd's\n\nUsage:\n  sd-local [command]\n\nAvailable Commands:\n  build       Run screwdriver build.\n  help        Help about any command\n\nFlags:\n      --config string      Path to the config file, which is created if it does not exist. SD_LOCAL_CONFIG or ~/.sdlocal/config is used if it is not specified.\n  -h, --help               help for sd-local\n      --log-level string   Level of the logs, error, warn, info or debug. The requests to the API, the mounts and the container commands are logged at debug level. (default \"info\")\n  -v, --verbose            verbose output. It is the same as --log-level debug.\n\nUse \"sd-local [command] --help\" for more information about a command.\n"
 		assert.Equal(t, want, buf.String())
 		assert.Nil(t, err)
 	})
@@ -118,7 +301,7 @@ func TestRootCmd(t *testing.T) {
 		buf := bytes.NewBuffer(nil)
 		root.SetOut(buf)
 		err := root.Execute()
-		want := "Run build instantly on your local machine with\na mostly the same environment as Screwdriver.cd's\n\nUsage:\n  sd-local [command]\n\nAvailable Commands:\n  help        Help about any command\n  update      Update to the latest version\n\nFlags:\n  -h, --help      help for sd-local\n  -v, --verbose   verbose output.\n\nUse \"sd-local [command] --help\" for more information about a command.\n"
+		want := "Run build instantly on your local machine with\na mostly the same environment as Screwdriver.cd's\n\nUsage:\n  sd-local [command]\n\nAvailable Commands:\n  help        Help about any command\n  update      Update to the latest version\n\nFlags:\n      --config string      Path to the config file, which is created if it does not exist. SD_LOCAL_CONFIG or ~/.sdlocal/config is used if it is not specified.\n  -h, --help               help for sd-local\n      --log-level string   Level of the logs, error, warn, info or debug. The requests to the API, the mounts and the container commands are logged at debug level. (default \"info\")\n  -v, --verbose            verbose output. It is the same as --log-level debug.\n\nUse \"sd-local [command] --help\" for more information about a command.\n"
 		assert.Equal(t, want, buf.String())
 		assert.Nil(t, err)
 	})
@@ -130,10 +313,10 @@ func TestRootCmd(t *testing.T) {
 		buf := bytes.NewBuffer(nil)
 		root.SetOut(buf)
 		err := root.Execute()
-		want := "Error: accepts 1 arg(s), received 0\n" +
-			"Usage:\n  sd-local build [job name] [flags]\n" +
+		want := "Error: requires at least 1 arg(s), only received 0\n" +
+			"Usage:\n  sd-local build [job name...] [flags]\n" +
 			buildLocalFlags() +
-			"Global Flags:\n  -v, --verbose   verbose output.\n\n"
+			"Global Flags:\n      --config string      Path to the config file, which is created if it does not exist. SD_LOCAL_CONFIG or ~/.sdlocal/config is used if it is not specified.\n      --log-level string   Level of the logs, error, warn, info or debug. The requests to the API, the mounts and the container commands are logged at debug level. (default \"info\")\n  -v, --verbose            verbose output. It is the same as --log-level debug.\n\n"
 		assert.Equal(t, want, buf.String())
 		assert.NotNil(t, err)
 	})
@@ -150,3 +333,175 @@ func TestRootCmd(t *testing.T) {
 		assert.NotNil(t, err)
 	})
 }
+
+func TestRootCmdWithConfig(t *testing.T) {
+	defConfigNew := configNew
+	defHome := os.Getenv("HOME")
+	defer func() {
+		configNew = defConfigNew
+		os.Setenv("HOME", defHome)
+		homedir.DisableCache = false
+	}()
+	configNew = config.New
+
+	home, err := ioutil.TempDir("", "sd-local-home")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(home)
+	os.Setenv("HOME", home)
+	homedir.DisableCache = true
+
+	cnfPath := filepath.Join(home, "other.yaml")
+	root := newRootCmd()
+	root.AddCommand(newBuildCmd(), cmdconfig.NewConfigCmd())
+	root.SetArgs([]string{"--config", cnfPath, "config", "view"})
+	root.SetOut(bytes.NewBuffer(nil))
+	err = executeMasked(root, logrus.StandardLogger())
+	assert.Nil(t, err)
+
+	// only the config file of --config is read, which is created if it does not exist
+	assert.FileExists(t, cnfPath)
+	_, err = os.Stat(filepath.Join(home, ".sdlocal", "config"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestSetupLogLevel(t *testing.T) {
+	defer func() {
+		logrus.SetLevel(logrus.InfoLevel)
+		flagVerbose, flagLogLevel = false, "info"
+	}()
+
+	cases := []struct {
+		name          string
+		args          []string
+		expectLevel   logrus.Level
+		expectVerbose bool
+		expectErr     string
+	}{
+		{
+			name:        "default",
+			expectLevel: logrus.InfoLevel,
+		},
+		{
+			name:        "log level",
+			args:        []string{"--log-level", "warn"},
+			expectLevel: logrus.WarnLevel,
+		},
+		{
+			name:          "debug log level is verbose",
+			args:          []string{"--log-level", "debug"},
+			expectLevel:   logrus.DebugLevel,
+			expectVerbose: true,
+		},
+		{
+			name:          "verbose",
+			args:          []string{"-v"},
+			expectLevel:   logrus.DebugLevel,
+			expectVerbose: true,
+		},
+		{
+			name:          "verbose wins over the lower log level",
+			args:          []string{"-v", "--log-level", "error"},
+			expectLevel:   logrus.DebugLevel,
+			expectVerbose: true,
+		},
+		{
+			name:      "failure by invalid log level",
+			args:      []string{"--log-level", "trace"},
+			expectErr: "invalid log level trace: must be one of error, warn, info, debug",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			logrus.SetLevel(logrus.InfoLevel)
+			flagVerbose, flagLogLevel = false, "info"
+
+			root := newRootCmd()
+			root.AddCommand(newValidateCmd())
+			root.SetArgs(append([]string{"validate", "-f", "../screwdriver.yaml"}, c.args...))
+			root.SilenceErrors = true
+			root.SilenceUsage = true
+			root.SetOut(bytes.NewBuffer(nil))
+			err := root.Execute()
+			if c.expectErr != "" {
+				assert.Equal(t, c.expectErr, err.Error())
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, c.expectLevel, logrus.GetLevel())
+			assert.Equal(t, c.expectVerbose, flagVerbose)
+		})
+	}
+}
+
+type blockedCleaner struct {
+	cleaned bool
+	block   chan struct{}
+}
+
+func (c *blockedCleaner) Kill(os.Signal) {
+	<-c.block
+}
+
+func (c *blockedCleaner) Clean() {
+	c.cleaned = true
+}
+
+func TestHandleSignals(t *testing.T) {
+	defOsExit := osExit
+	defCleaners := cleaners
+	defer func() {
+		osExit = defOsExit
+		cleaners = defCleaners
+		logrus.SetOutput(os.Stderr)
+	}()
+	logrus.SetOutput(ioutil.Discard)
+
+	t.Run("success to clean up and exit with 130 by Ctrl-C", func(t *testing.T) {
+		c := &killedCleaner{killed: make(chan struct{})}
+		cleaners = []Cleaner{c}
+		code := -1
+		osExit = func(c int) { code = c }
+
+		quit := make(chan os.Signal, 2)
+		quit <- syscall.SIGINT
+		handleSignals(quit)
+
+		assert.Equal(t, 130, code)
+		select {
+		case <-c.killed:
+		default:
+			t.Error("the build is not killed")
+		}
+	})
+
+	t.Run("success to exit with 143 by SIGTERM", func(t *testing.T) {
+		cleaners = []Cleaner{}
+		code := -1
+		osExit = func(c int) { code = c }
+
+		quit := make(chan os.Signal, 2)
+		quit <- syscall.SIGTERM
+		handleSignals(quit)
+
+		assert.Equal(t, 143, code)
+	})
+
+	t.Run("success to exit immediately by the second Ctrl-C", func(t *testing.T) {
+		c := &blockedCleaner{block: make(chan struct{})}
+		defer close(c.block)
+		cleaners = []Cleaner{c}
+		code := -1
+		osExit = func(c int) { code = c }
+
+		quit := make(chan os.Signal, 2)
+		quit <- syscall.SIGINT
+		quit <- syscall.SIGINT
+		handleSignals(quit)
+
+		assert.Equal(t, 130, code)
+		assert.False(t, c.cleaned)
+	})
+}
@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/screwdriver-cd/sd-local/ignore"
+	"github.com/sirupsen/logrus"
+)
+
+// sdIgnoreFile is the file of the patterns in the gitignore syntax to exclude the paths from the source code of the build.
+// Nothing is excluded if it does not exist in the source directory.
+const sdIgnoreFile = ".sdignore"
+
+// isLocalDir returns true if the path is an existing directory
+func isLocalDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+// findIgnoredPaths returns the paths in the source directory matched by the patterns of the ignore files
+func findIgnoredPaths(srcPath string, files ...string) ([]string, error) {
+	m, err := ignore.Load(srcPath, files...)
+	if err != nil {
+		return nil, err
+	}
+
+	paths, err := m.IgnoredPaths(srcPath)
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) != 0 {
+		logrus.Infof("%d ignored paths in %s are not mounted into the build container", len(paths), srcPath)
+	}
+	return paths, nil
+}
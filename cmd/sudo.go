@@ -0,0 +1,18 @@
+package cmd
+
+import (
+	"os"
+	"os/exec"
+)
+
+var sudoValidate = validateSudo
+
+// validateSudo asks the password of sudo once before the build on the terminal.
+// The credentials are cached by sudo, so the prompts of the container commands run in parallel are not mixed up.
+func validateSudo() error {
+	c := exec.Command("sudo", "-v")
+	c.Stdin = os.Stdin
+	c.Stdout = os.Stderr
+	c.Stderr = os.Stderr
+	return c.Run()
+}
@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/screwdriver-cd/sd-local/screwdriver"
+)
+
+// targetSeparator separates the path to screwdriver.yaml and the job name in the arguments like services/a/screwdriver.yaml::test
+const targetSeparator = "::"
+
+// parseTargets splits the arguments into the path to screwdriver.yaml and the job names.
+// The jobs must be in the same file, which must be the same as --file if it is specified.
+func parseTargets(targets []string, file string) (string, []string, error) {
+	names := make([]string, 0, len(targets))
+	for _, target := range targets {
+		i := strings.LastIndex(target, targetSeparator)
+		if i < 0 {
+			names = append(names, target)
+			continue
+		}
+
+		path, name := target[:i], target[i+len(targetSeparator):]
+		if path == "" || name == "" {
+			return "", nil, fmt.Errorf("invalid job %s: must be <path to screwdriver.yaml>%s<job name>", target, targetSeparator)
+		}
+		if file != "" && filepath.Clean(file) != filepath.Clean(path) {
+			return "", nil, fmt.Errorf("can't run the jobs of multiple pipeline files %s and %s, please specify the jobs of only one file", file, path)
+		}
+		file = path
+		names = append(names, name)
+	}
+	return file, names, nil
+}
+
+// findJobs returns an error listing the jobs in the pipeline file if any of the names is not found in it
+func findJobs(jobs map[string]screwdriver.Job, names []string, file string) error {
+	for _, name := range names {
+		if _, ok := jobs[name]; ok {
+			continue
+		}
+
+		available := make([]string, 0, len(jobs))
+		for n := range jobs {
+			available = append(available, n)
+		}
+		sort.Strings(available)
+		return fmt.Errorf("not found '%s' in %s, the jobs in it are: %s", name, file, strings.Join(available, ", "))
+	}
+	return nil
+}
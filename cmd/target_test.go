@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/screwdriver-cd/sd-local/screwdriver"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTargets(t *testing.T) {
+	cases := []struct {
+		name        string
+		targets     []string
+		file        string
+		expectFile  string
+		expectNames []string
+		expectErr   string
+	}{
+		{
+			name:        "job names",
+			targets:     []string{"test", "lint"},
+			expectNames: []string{"test", "lint"},
+		},
+		{
+			name:        "job names with --file",
+			targets:     []string{"test"},
+			file:        "ci/screwdriver.yaml",
+			expectFile:  "ci/screwdriver.yaml",
+			expectNames: []string{"test"},
+		},
+		{
+			name:        "jobs with the path",
+			targets:     []string{"services/a/screwdriver.yaml::test", "lint", "./services/a/screwdriver.yaml::publish"},
+			expectFile:  "./services/a/screwdriver.yaml",
+			expectNames: []string{"test", "lint", "publish"},
+		},
+		{
+			name:        "jobs with the path same as --file",
+			targets:     []string{"services/a/screwdriver.yaml::test"},
+			file:        "./services/a/screwdriver.yaml",
+			expectFile:  "services/a/screwdriver.yaml",
+			expectNames: []string{"test"},
+		},
+		{
+			name:      "failure by multiple files",
+			targets:   []string{"services/a/screwdriver.yaml::test", "services/b/screwdriver.yaml::test"},
+			expectErr: "can't run the jobs of multiple pipeline files services/a/screwdriver.yaml and services/b/screwdriver.yaml, please specify the jobs of only one file",
+		},
+		{
+			name:      "failure by the path different from --file",
+			targets:   []string{"services/a/screwdriver.yaml::test"},
+			file:      "ci/screwdriver.yaml",
+			expectErr: "can't run the jobs of multiple pipeline files ci/screwdriver.yaml and services/a/screwdriver.yaml, please specify the jobs of only one file",
+		},
+		{
+			name:      "failure by empty job name",
+			targets:   []string{"services/a/screwdriver.yaml::"},
+			expectErr: "invalid job services/a/screwdriver.yaml::: must be <path to screwdriver.yaml>::<job name>",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			file, names, err := parseTargets(c.targets, c.file)
+			if c.expectErr != "" {
+				assert.Equal(t, c.expectErr, err.Error())
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, c.expectFile, file)
+			assert.Equal(t, c.expectNames, names)
+		})
+	}
+}
+
+func TestFindJobs(t *testing.T) {
+	jobs := map[string]screwdriver.Job{"test": {}, "lint": {}, "publish": {}}
+
+	assert.Nil(t, findJobs(jobs, []string{"test", "publish"}, "services/a/screwdriver.yaml"))
+
+	err := findJobs(jobs, []string{"test", "deploy"}, "services/a/screwdriver.yaml")
+	assert.Equal(t, "not found 'deploy' in services/a/screwdriver.yaml, the jobs in it are: lint, publish, test", err.Error())
+}
@@ -2,23 +2,39 @@ package cmd
 
 import (
 	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
 	"os"
+	"path"
+	"path/filepath"
 	"strings"
 
 	"github.com/blang/semver"
+	"github.com/inconshreveable/go-update"
 	"github.com/rhysd/go-github-selfupdate/selfupdate"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
 
-const githubSlug = "screwdriver-cd/sd-local"
+const (
+	githubSlug = "screwdriver-cd/sd-local"
+	// checksumsFile is the file of the SHA256 checksums of the assets published with each release
+	checksumsFile = "checksums.txt"
+)
 
 var (
-	updateFlag   = false
-	detectLatest = selfupdate.DetectLatest
-	updateTo     = selfupdate.UpdateTo
+	updateFlag    = false
+	checkFlag     = false
+	pinnedVersion = ""
+	detectLatest  = selfupdate.DetectLatest
+	detectVersion = selfupdate.DetectVersion
+	updateTo      = verifiedUpdateTo
 )
 
 func getLatestVersion() (*selfupdate.Release, error) {
@@ -34,7 +50,23 @@ func getLatestVersion() (*selfupdate.Release, error) {
 	return latest, nil
 }
 
-func canUpdate(latest *selfupdate.Release) (bool, error) {
+// getPinnedVersion returns the release of the version, which can be prefixed with v
+func getPinnedVersion(v string) (*selfupdate.Release, error) {
+	release, found, err := detectVersion(githubSlug, v)
+
+	if err != nil {
+		return &selfupdate.Release{}, err
+	}
+	if !found {
+		return &selfupdate.Release{}, fmt.Errorf("version %s is not found", v)
+	}
+
+	return release, nil
+}
+
+// canUpdate returns true if the update is aborted because the current version is the target.
+// The older version than the current one can be the target only if it is pinned.
+func canUpdate(target *selfupdate.Release) (bool, error) {
 	currentVersion := version
 	logrus.Info("Current version: ", currentVersion)
 
@@ -44,13 +76,82 @@ func canUpdate(latest *selfupdate.Release) (bool, error) {
 
 	v := semver.MustParse(currentVersion)
 
-	if latest.Version.LTE(v) {
+	if pinnedVersion != "" && target.Version.Equals(v) {
+		logrus.Warn("Current version is ", v)
+		return true, nil
+	}
+	if pinnedVersion == "" && target.Version.LTE(v) {
 		logrus.Warn("Current version is latest")
 		return true, nil
 	}
 	return false, nil
 }
 
+func download(url string) ([]byte, error) {
+	res, err := http.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %v", url, err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download %s: StatusCode %d", url, res.StatusCode)
+	}
+	return ioutil.ReadAll(res.Body)
+}
+
+// findChecksum returns the checksum of the asset in the checksums file, whose lines are `<sha256>  <asset name>`
+func findChecksum(checksums []byte, asset string) ([]byte, error) {
+	for _, line := range strings.Split(string(checksums), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 && fields[1] == asset {
+			return hex.DecodeString(fields[0])
+		}
+	}
+	return nil, fmt.Errorf("checksum of %s is not found in %s", asset, checksumsFile)
+}
+
+// verifiedUpdateTo replaces the executable with the asset after its SHA256 checksum is verified with the checksums file of the release.
+// The executable is replaced atomically by renaming, and it is rolled back if the replacement fails.
+func verifiedUpdateTo(assetURL, cmdPath string) error {
+	asset, err := download(assetURL)
+	if err != nil {
+		return err
+	}
+	// the checksums file is published in the same place as the assets
+	u, err := url.Parse(assetURL)
+	if err != nil {
+		return err
+	}
+	assetName := path.Base(u.Path)
+	u.Path = path.Join(path.Dir(u.Path), checksumsFile)
+	checksums, err := download(u.String())
+	if err != nil {
+		return err
+	}
+
+	expected, err := findChecksum(checksums, assetName)
+	if err != nil {
+		return err
+	}
+	actual := sha256.Sum256(asset)
+	if !bytes.Equal(expected, actual[:]) {
+		return fmt.Errorf("checksum of %s mismatched: expected %x, got %x", assetName, expected, actual)
+	}
+
+	bin, err := selfupdate.UncompressCommand(bytes.NewReader(asset), assetURL, filepath.Base(cmdPath))
+	if err != nil {
+		return err
+	}
+	if err := update.Apply(bin, update.Options{TargetPath: cmdPath}); err != nil {
+		if rerr := update.RollbackError(err); rerr != nil {
+			return fmt.Errorf("failed to update %s and to roll it back, please reinstall it: %v", cmdPath, rerr)
+		}
+		return fmt.Errorf("failed to update %s: %v", cmdPath, err)
+	}
+	return nil
+}
+
 func isAborted(input string) (aborted bool, err error) {
 	if input == "y" || input == "Y" || input == "yes" || input == "Yes" {
 		return false, nil
@@ -63,7 +164,13 @@ func isAborted(input string) (aborted bool, err error) {
 }
 
 func selfUpdate() error {
-	latestVersion, err := getLatestVersion()
+	var latestVersion *selfupdate.Release
+	var err error
+	if pinnedVersion != "" {
+		latestVersion, err = getPinnedVersion(pinnedVersion)
+	} else {
+		latestVersion, err = getLatestVersion()
+	}
 	if err != nil {
 		return err
 	}
@@ -73,6 +180,11 @@ func selfUpdate() error {
 		return err
 	}
 
+	if checkFlag {
+		logrus.Infof("Version %s is available", latestVersion.Version)
+		return nil
+	}
+
 	if !updateFlag {
 		fmt.Print("Do you want to update to ", latestVersion.Version.String(), "? [y/N]: ")
 		input, err := bufio.NewReader(os.Stdin).ReadString('\n')
@@ -89,6 +201,11 @@ func selfUpdate() error {
 	if err != nil {
 		return err
 	}
+	// the binary is replaced instead of the symbolic link to it
+	exe, err = filepath.EvalSymlinks(exe)
+	if err != nil {
+		return err
+	}
 	logrus.Info("Updating ...")
 	if err := updateTo(latestVersion.AssetURL, exe); err != nil {
 		return err
@@ -101,11 +218,15 @@ func newUpdateCmd() *cobra.Command {
 	updateCmd := &cobra.Command{
 		Use:   "update",
 		Short: "Update to the latest version",
+		Long: `Update to the latest version.
+The downloaded binary is verified with the SHA256 checksums published with the release before it replaces the current one.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return selfUpdate()
 		},
 	}
 	updateCmd.Flags().BoolVarP(&updateFlag, "yes", "y", false, "answer yes for all questions")
+	updateCmd.Flags().BoolVar(&checkFlag, "check", false, "only check whether the update is available")
+	updateCmd.Flags().StringVar(&pinnedVersion, "version", "", "update to the specified version instead of the latest, which can be older than the current one")
 
 	return updateCmd
 }
@@ -2,8 +2,14 @@ package cmd
 
 import (
 	"bytes"
+	"crypto/sha256"
 	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/blang/semver"
@@ -84,11 +90,15 @@ func TestIsAborted(t *testing.T) {
 	}
 }
 
+const updateUsage = "Usage:\n  update [flags]\n\nFlags:\n      --check            only check whether the update is available\n  -h, --help             help for update\n      --version string   update to the specified version instead of the latest, which can be older than the current one\n  -y, --yes              answer yes for all questions\n\n"
+
 func TestSelfUpdate(t *testing.T) {
 	defaultDetectLatest := detectLatest
+	defaultDetectVersion := detectVersion
 	defaultUpdateTo := updateTo
 	defer func() {
 		detectLatest = defaultDetectLatest
+		detectVersion = defaultDetectVersion
 		updateTo = defaultUpdateTo
 	}()
 
@@ -100,20 +110,30 @@ func TestSelfUpdate(t *testing.T) {
 		}
 		return &selfupdate.Release{Version: latest}, true, nil
 	}
+	detectVersion = func(slug, v string) (*selfupdate.Release, bool, error) {
+		if v != "1.0.3" {
+			return nil, false, nil
+		}
+		return &selfupdate.Release{Version: semver.MustParse(v)}, true, nil
+	}
+	var updated bool
 	updateTo = func(url, path string) error {
+		updated = true
 		return nil
 	}
 
 	testCases := []struct {
-		name      string
-		current   string
-		errOutput string
-		logOutput []string
+		name          string
+		current       string
+		args          []string
+		errOutput     string
+		logOutput     []string
+		expectUpdated bool
 	}{
 		{
 			name:      "Failed current version is dev",
 			current:   "dev",
-			errOutput: "Error: This is a development version and cannot be updated\nUsage:\n  update [flags]\n\nFlags:\n  -h, --help   help for update\n  -y, --yes    answer yes for all questions\n\n",
+			errOutput: "Error: This is a development version and cannot be updated\n" + updateUsage,
 			logOutput: []string{
 				"Current version: dev",
 			},
@@ -136,6 +156,43 @@ func TestSelfUpdate(t *testing.T) {
 				"Updating ...",
 				"Successfully updated to version 1.0.5",
 			},
+			expectUpdated: true,
+		},
+		{
+			name:      "Success check",
+			current:   "1.0.4",
+			args:      []string{"--check"},
+			errOutput: "",
+			logOutput: []string{
+				"Current version: 1.0.4",
+				"Version 1.0.5 is available",
+			},
+		},
+		{
+			name:      "Success pinned older version",
+			current:   "1.0.4",
+			args:      []string{"--version", "1.0.3"},
+			errOutput: "",
+			logOutput: []string{
+				"Current version: 1.0.4",
+				"Successfully updated to version 1.0.3",
+			},
+			expectUpdated: true,
+		},
+		{
+			name:      "Failed pinned version is current",
+			current:   "1.0.3",
+			args:      []string{"--version", "1.0.3"},
+			errOutput: "",
+			logOutput: []string{
+				"Current version is 1.0.3",
+			},
+		},
+		{
+			name:      "Failed pinned version is not found",
+			current:   "1.0.4",
+			args:      []string{"--version", "9.9.9"},
+			errOutput: "Error: version 9.9.9 is not found\n" + updateUsage,
 		},
 	}
 
@@ -150,16 +207,101 @@ func TestSelfUpdate(t *testing.T) {
 			version = tt.current
 			logBuf := bytes.NewBuffer(nil)
 			logrus.SetOutput(logBuf)
+			updated = false
 
 			cmd := newUpdateCmd()
 			updateFlag = true
+			defer func() {
+				checkFlag = false
+				pinnedVersion = ""
+			}()
 			errBuf := bytes.NewBuffer(nil)
 			cmd.SetOut(errBuf)
+			cmd.SetArgs(tt.args)
 			cmd.Execute()
 			assert.Equal(t, tt.errOutput, errBuf.String())
 			for _, want := range tt.logOutput {
 				assert.Contains(t, logBuf.String(), want)
 			}
+			assert.Equal(t, tt.expectUpdated, updated)
+		})
+	}
+}
+
+func TestVerifiedUpdateTo(t *testing.T) {
+	newBinary := []byte("new sd-local")
+	sum := sha256.Sum256(newBinary)
+
+	cases := []struct {
+		name      string
+		checksums string
+		status    int
+		expect    string
+		expectErr string
+	}{
+		{
+			name:      "success",
+			checksums: fmt.Sprintf("%x  sd-local_darwin_amd64\n%x  sd-local_linux_amd64\n", sha256.Sum256([]byte("darwin")), sum),
+			status:    http.StatusOK,
+			expect:    "new sd-local",
+		},
+		{
+			name:      "failure by checksum mismatch",
+			checksums: fmt.Sprintf("%x  sd-local_linux_amd64\n", sha256.Sum256([]byte("tampered"))),
+			status:    http.StatusOK,
+			expect:    "old sd-local",
+			expectErr: "checksum of sd-local_linux_amd64 mismatched",
+		},
+		{
+			name:      "failure by missing checksum",
+			checksums: fmt.Sprintf("%x  sd-local_darwin_amd64\n", sum),
+			status:    http.StatusOK,
+			expect:    "old sd-local",
+			expectErr: "checksum of sd-local_linux_amd64 is not found in checksums.txt",
+		},
+		{
+			name:      "failure by missing checksums file",
+			status:    http.StatusNotFound,
+			expect:    "old sd-local",
+			expectErr: "checksums.txt: StatusCode 404",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/download/v1.0.5/sd-local_linux_amd64":
+					w.Write(newBinary)
+				case "/download/v1.0.5/checksums.txt":
+					w.WriteHeader(c.status)
+					w.Write([]byte(c.checksums))
+				default:
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+			defer server.Close()
+
+			dir, err := ioutil.TempDir("", "update")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(dir)
+			exe := filepath.Join(dir, "sd-local")
+			if err := ioutil.WriteFile(exe, []byte("old sd-local"), 0755); err != nil {
+				t.Fatal(err)
+			}
+
+			err = verifiedUpdateTo(server.URL+"/download/v1.0.5/sd-local_linux_amd64", exe)
+			if c.expectErr != "" {
+				assert.Contains(t, err.Error(), c.expectErr)
+			} else {
+				assert.Nil(t, err)
+			}
+
+			actual, err := ioutil.ReadFile(exe)
+			assert.Nil(t, err)
+			assert.Equal(t, c.expect, string(actual))
 		})
 	}
 }
@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/screwdriver-cd/sd-local/screwdriver"
+	"github.com/spf13/cobra"
+)
+
+func newValidateCmd() *cobra.Command {
+	var file string
+
+	validateCmd := &cobra.Command{
+		Use:   "validate",
+		Short: "Validate screwdriver.yaml.",
+		Long: `Validate screwdriver.yaml locally without the API.
+The problems like unknown keys, missing required keys and requires of unknown jobs are printed with their lines and columns.
+It exits with non-zero status if there is any problem.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+
+			problems, err := screwdriver.Validate(file)
+			if err != nil {
+				return err
+			}
+
+			for _, p := range problems {
+				if p.Column == 0 {
+					fmt.Fprintf(cmd.OutOrStdout(), "%s:%d: %s\n", file, p.Line, p.Message)
+					continue
+				}
+				fmt.Fprintf(cmd.OutOrStdout(), "%s:%d:%d: %s\n", file, p.Line, p.Column, p.Message)
+			}
+			if len(problems) != 0 {
+				return fmt.Errorf("found %d problem(s) in %s", len(problems), file)
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "%s is valid\n", file)
+			return nil
+		},
+	}
+
+	validateCmd.Flags().StringVarP(
+		&file,
+		"file",
+		"f",
+		"screwdriver.yaml",
+		"Path to the screwdriver.yaml to validate.")
+
+	return validateCmd
+}
@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateCmd(t *testing.T) {
+	dir, err := ioutil.TempDir("", "validate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	valid := filepath.Join(dir, "valid.yaml")
+	invalid := filepath.Join(dir, "invalid.yaml")
+	for path, content := range map[string]string{
+		valid:   "jobs:\n  main:\n    image: alpine\n    steps:\n      - test: echo test\n",
+		invalid: "jobs:\n  main:\n    imgae: alpine\n    steps:\n      - test: echo test\n",
+	} {
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cases := []struct {
+		name      string
+		args      []string
+		expectOut string
+		expectErr string
+	}{
+		{
+			name:      "success",
+			args:      []string{"--file", valid},
+			expectOut: valid + " is valid\n",
+		},
+		{
+			name: "failure by problems",
+			args: []string{"--file", invalid},
+			expectOut: invalid + ":2:3: missing required key 'image' in job 'main'\n" +
+				invalid + ":3:5: unknown key 'imgae' in job 'main'\n",
+			expectErr: "found 2 problem(s) in " + invalid,
+		},
+		{
+			name:      "failure by missing file",
+			args:      []string{"--file", filepath.Join(dir, "missing.yaml")},
+			expectErr: "failed to read screwdriver.yaml",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			cmd := newValidateCmd()
+			buf := bytes.NewBuffer(nil)
+			cmd.SetOut(buf)
+			cmd.SilenceErrors = true
+			cmd.SetArgs(c.args)
+
+			err := cmd.Execute()
+			if c.expectErr != "" {
+				assert.Contains(t, err.Error(), c.expectErr)
+			} else {
+				assert.Nil(t, err)
+			}
+			assert.Equal(t, c.expectOut, buf.String())
+		})
+	}
+}
@@ -1,9 +1,12 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
 	"runtime"
 
+	"github.com/screwdriver-cd/sd-local/config"
+	"github.com/screwdriver-cd/sd-local/launch"
 	"github.com/spf13/cobra"
 )
 
@@ -11,15 +14,130 @@ var (
 	// version is embedded when building this command using ldflags.
 	// if nothing is embedded, version is "dev"
 	version = "dev"
+
+	runtimeVersion = launch.RuntimeVersion
 )
 
+// versionInfo is the versions of sd-local and the environment to run the builds, which are helpful for the bug reports
+type versionInfo struct {
+	Version  string       `json:"version"`
+	Platform string       `json:"platform"`
+	Go       string       `json:"go"`
+	Compiler string       `json:"compiler"`
+	Launcher launcherInfo `json:"launcher"`
+	Runtime  runtimeInfo  `json:"runtime"`
+}
+
+// launcherInfo is the launcher of the current config
+type launcherInfo struct {
+	Config  string `json:"config,omitempty"`
+	Image   string `json:"image,omitempty"`
+	Version string `json:"version,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// runtimeInfo is the runtime of the current config
+type runtimeInfo struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// currentEntry returns the name and the entry of the current config
+func currentEntry() (string, *config.Entry, error) {
+	path, err := configFilePath()
+	if err != nil {
+		return "", nil, err
+	}
+	c, err := configNew(path)
+	if err != nil {
+		return "", nil, err
+	}
+	defer c.Close()
+	entry, err := c.CurrentEntry()
+	if err != nil {
+		return "", nil, err
+	}
+	return c.Current, entry, nil
+}
+
+// newVersionInfo collects the versions. The errors are reported in it because the versions are still useful without them.
+func newVersionInfo() versionInfo {
+	info := versionInfo{
+		Version:  version,
+		Platform: fmt.Sprintf("%s/%s", runtime.GOOS, runtime.GOARCH),
+		Go:       runtime.Version(),
+		Compiler: runtime.Compiler,
+		Runtime:  runtimeInfo{Name: config.RuntimeDocker},
+	}
+
+	name, entry, err := currentEntry()
+	if err != nil {
+		info.Launcher.Error = err.Error()
+	} else {
+		info.Launcher = launcherInfo{Config: name, Image: entry.Launcher.Image, Version: entry.Launcher.Version}
+		if entry.Runtime != "" {
+			info.Runtime.Name = entry.Runtime
+		}
+	}
+
+	info.Runtime.Version, err = runtimeVersion(info.Runtime.Name)
+	if err != nil {
+		info.Runtime.Error = err.Error()
+	}
+
+	return info
+}
+
+// String returns the versions in the lines of text
+func (v versionInfo) String() string {
+	launcher := fmt.Sprintf("%s (version: %s, config: %s)", v.Launcher.Image, v.Launcher.Version, v.Launcher.Config)
+	if v.Launcher.Error != "" {
+		launcher = fmt.Sprintf("unknown (%s)", v.Launcher.Error)
+	}
+	runtimeVersion := v.Runtime.Version
+	if v.Runtime.Error != "" {
+		runtimeVersion = fmt.Sprintf("unknown (%s)", v.Runtime.Error)
+	}
+
+	return fmt.Sprintf("%s\nplatform: %s\ngo: %s\ncompiler: %s\nlauncher: %s\nruntime: %s %s",
+		v.Version, v.Platform, v.Go, v.Compiler, launcher, v.Runtime.Name, runtimeVersion)
+}
+
 func newVersionCmd() *cobra.Command {
-	return &cobra.Command{
+	var output string
+
+	versionCmd := &cobra.Command{
 		Use:   "version",
 		Short: "Display command's version.",
-		Run: func(cmd *cobra.Command, args []string) {
-			ver := fmt.Sprintf("%s\nplatform: %s/%s\ngo: %s\ncompiler: %s", version, runtime.GOOS, runtime.GOARCH, runtime.Version(), runtime.Compiler)
-			fmt.Fprintln(cmd.OutOrStdout(), ver)
+		Long: `Display command's version.
+The launcher of the current config and the version of the container runtime are displayed as well.`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if output != "" && output != outputJSON {
+				return fmt.Errorf("invalid output format %s: only %s is supported", output, outputJSON)
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			info := newVersionInfo()
+
+			if output == outputJSON {
+				encoder := json.NewEncoder(cmd.OutOrStdout())
+				encoder.SetIndent("", "  ")
+				return encoder.Encode(info)
+			}
+
+			fmt.Fprintln(cmd.OutOrStdout(), info)
+			return nil
 		},
 	}
+
+	versionCmd.Flags().StringVarP(
+		&output,
+		"output",
+		"o",
+		"",
+		"Output format. Only 'json' is supported, which is helpful to be attached to the bug reports.")
+
+	return versionCmd
 }
@@ -2,14 +2,20 @@ package cmd
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"runtime"
 	"testing"
 
+	"github.com/screwdriver-cd/sd-local/config"
 	"github.com/stretchr/testify/assert"
 )
 
 func TestVersionCmd(t *testing.T) {
+	defRuntimeVersion := runtimeVersion
+	defer func() { runtimeVersion = defRuntimeVersion }()
+	runtimeVersion = func(runtime string) (string, error) { return "24.0.5", nil }
+
 	cases := []struct {
 		name    string
 		version string
@@ -44,8 +50,53 @@ func TestVersionCmd(t *testing.T) {
 			assert.Equal(t, fmt.Sprintf("%s\n", c.expect), buf.String())
 		})
 	}
+
+	t.Run("json", func(t *testing.T) {
+		cmd := newVersionCmd()
+		cmd.SetArgs([]string{"-o", "json"})
+		buf := bytes.NewBuffer(nil)
+		cmd.SetOut(buf)
+		err := cmd.Execute()
+		assert.Nil(t, err)
+		assert.JSONEq(t, fmt.Sprintf(`{
+  "version": "dev",
+  "platform": "%s/%s",
+  "go": "%s",
+  "compiler": "%s",
+  "launcher": {"config": "default", "image": "screwdrivercd/launcher", "version": "stable"},
+  "runtime": {"name": "docker", "version": "24.0.5"}
+}`, runtime.GOOS, runtime.GOARCH, runtime.Version(), runtime.Compiler), buf.String())
+	})
+
+	t.Run("errors of config and runtime", func(t *testing.T) {
+		defConfigNew := configNew
+		defer func() { configNew = defConfigNew }()
+		configNew = func(confPath string) (config.Config, error) {
+			return config.Config{}, nil
+		}
+		runtimeVersion = func(runtime string) (string, error) {
+			return "", errors.New("failed to get the version of docker: exit status 1")
+		}
+
+		cmd := newVersionCmd()
+		buf := bytes.NewBuffer(nil)
+		cmd.SetOut(buf)
+		err := cmd.Execute()
+		assert.Nil(t, err)
+		assert.Contains(t, buf.String(), "launcher: unknown (no config entries, run `sd-local config create`)\nruntime: docker unknown (failed to get the version of docker: exit status 1)\n")
+	})
+
+	t.Run("failure by invalid output", func(t *testing.T) {
+		cmd := newVersionCmd()
+		cmd.SetArgs([]string{"-o", "yaml"})
+		cmd.SilenceErrors = true
+		cmd.SilenceUsage = true
+		err := cmd.Execute()
+		assert.Equal(t, "invalid output format yaml: only json is supported", err.Error())
+	})
 }
 
 func detailVersion(version string) string {
-	return fmt.Sprintf("%s\nplatform: %s/%s\ngo: %s\ncompiler: %s", version, runtime.GOOS, runtime.GOARCH, runtime.Version(), runtime.Compiler)
+	return fmt.Sprintf("%s\nplatform: %s/%s\ngo: %s\ncompiler: %s\nlauncher: screwdrivercd/launcher (version: stable, config: default)\nruntime: docker 24.0.5",
+		version, runtime.GOOS, runtime.GOARCH, runtime.Version(), runtime.Compiler)
 }
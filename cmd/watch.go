@@ -0,0 +1,166 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/screwdriver-cd/sd-local/ignore"
+	"github.com/sirupsen/logrus"
+)
+
+// watchDebounce is the quiet period after the last change of the files to rerun the build
+var watchDebounce = 500 * time.Millisecond
+
+// sourceWatcher watches the files of the source directory except the ignored paths, the git directory and the artifacts
+type sourceWatcher struct {
+	watcher       *fsnotify.Watcher
+	srcPath       string
+	artifactsPath string
+	matcher       *ignore.Matcher
+}
+
+// ignored returns true if the changes of the path don't trigger the rerun
+func (w *sourceWatcher) ignored(path string, isDir bool) bool {
+	if path == w.artifactsPath || strings.HasPrefix(path, w.artifactsPath+string(filepath.Separator)) {
+		return true
+	}
+
+	rel, err := filepath.Rel(w.srcPath, path)
+	if err != nil || rel == "." {
+		return false
+	}
+	rel = filepath.ToSlash(rel)
+	if rel == ".git" || strings.HasPrefix(rel, ".git/") {
+		return true
+	}
+	if w.matcher == nil {
+		return false
+	}
+
+	// the paths in the ignored directories are ignored as well
+	parts := strings.Split(rel, "/")
+	for i := range parts {
+		if w.matcher.Match(strings.Join(parts[:i+1], "/"), isDir || i < len(parts)-1) {
+			return true
+		}
+	}
+	return false
+}
+
+// add watches the directory and its subdirectories which are not ignored
+func (w *sourceWatcher) add(dir string) error {
+	return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			return nil
+		}
+		if w.ignored(path, true) {
+			return filepath.SkipDir
+		}
+		return w.watcher.Add(path)
+	})
+}
+
+// changes sends the changed path after the files are not changed for watchDebounce
+func (w *sourceWatcher) changes(changed chan<- string) {
+	var timer *time.Timer
+	var last string
+	// the timer does not block on fire after the watcher is closed
+	fire := make(chan struct{}, 1)
+	for {
+		select {
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			info, err := os.Stat(event.Name)
+			isDir := err == nil && info.IsDir()
+			if w.ignored(event.Name, isDir) {
+				continue
+			}
+			// fsnotify does not watch the subdirectories, so the created ones are added
+			if isDir && event.Op&fsnotify.Create != 0 {
+				if err := w.add(event.Name); err != nil {
+					logrus.Warnf("failed to watch %s: %v", event.Name, err)
+				}
+			}
+
+			last = event.Name
+			if timer != nil {
+				timer.Stop()
+			}
+			timer = time.AfterFunc(watchDebounce, func() {
+				select {
+				case fire <- struct{}{}:
+				default:
+				}
+			})
+		case <-fire:
+			select {
+			case changed <- last:
+			default:
+			}
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+			logrus.Warnf("failed to watch the source code: %v", err)
+		}
+	}
+}
+
+// watchSource runs the build and reruns it whenever the files in the source directory change.
+// The build running when the files change is cancelled. It returns when stop is closed.
+func watchSource(out io.Writer, srcPath, artifactsPath string, matcher *ignore.Matcher, run func() error, stop <-chan struct{}) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to watch the source code: %v", err)
+	}
+	defer watcher.Close()
+
+	w := &sourceWatcher{watcher: watcher, srcPath: srcPath, artifactsPath: artifactsPath, matcher: matcher}
+	if err := w.add(srcPath); err != nil {
+		return fmt.Errorf("failed to watch the source code: %v", err)
+	}
+	changed := make(chan string, 1)
+	go w.changes(changed)
+
+	reason := "started"
+	for n := 1; ; n++ {
+		fmt.Fprintf(out, "======== Run #%d (%s) at %s ========\n", n, reason, time.Now().Format("15:04:05"))
+
+		done := make(chan error, 1)
+		go func() { done <- run() }()
+
+		select {
+		case err := <-done:
+			if err != nil {
+				logrus.Error(err)
+			}
+			logrus.Infof("Watching %s for changes...", srcPath)
+			select {
+			case path := <-changed:
+				reason = "changed " + path
+			case <-stop:
+				return nil
+			}
+		case path := <-changed:
+			logrus.Infof("%s changed, cancelling the running build...", path)
+			kill(syscall.SIGTERM)
+			<-done
+			reason = "changed " + path
+		case <-stop:
+			kill(syscall.SIGTERM)
+			<-done
+			return nil
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package cmd
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/screwdriver-cd/sd-local/ignore"
+	"github.com/stretchr/testify/assert"
+)
+
+type killedCleaner struct {
+	killed chan struct{}
+}
+
+func (c *killedCleaner) Kill(os.Signal) {
+	select {
+	case <-c.killed:
+	default:
+		close(c.killed)
+	}
+}
+
+func (c *killedCleaner) Clean() {}
+
+func TestSourceWatcherIgnored(t *testing.T) {
+	matcher, err := ignore.New([]string{"node_modules/", "*.log"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := &sourceWatcher{srcPath: "/src", artifactsPath: "/src/sd-artifacts", matcher: matcher}
+
+	testCases := []struct {
+		name     string
+		path     string
+		isDir    bool
+		expected bool
+	}{
+		{name: "source code", path: "/src/main.go", expected: false},
+		{name: "source directory", path: "/src", isDir: true, expected: false},
+		{name: "ignored file", path: "/src/a/debug.log", expected: true},
+		{name: "ignored directory", path: "/src/node_modules", isDir: true, expected: true},
+		{name: "file in ignored directory", path: "/src/node_modules/a/index.js", expected: true},
+		{name: "git directory", path: "/src/.git/index", expected: true},
+		{name: "artifacts", path: "/src/sd-artifacts/builds.log", expected: true},
+		{name: "similar name to artifacts", path: "/src/sd-artifacts2", isDir: true, expected: false},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, w.ignored(tt.path, tt.isDir))
+		})
+	}
+
+	t.Run("no matcher with --no-ignore", func(t *testing.T) {
+		w := &sourceWatcher{srcPath: "/src", artifactsPath: "/src/sd-artifacts"}
+		assert.False(t, w.ignored("/src/node_modules/a/index.js", false))
+	})
+}
+
+func TestWatchSource(t *testing.T) {
+	defWatchDebounce := watchDebounce
+	defCleaners := cleaners
+	defer func() {
+		watchDebounce = defWatchDebounce
+		cleaners = defCleaners
+	}()
+	watchDebounce = 50 * time.Millisecond
+
+	srcPath, err := ioutil.TempDir("", "watch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(srcPath)
+	if err := os.MkdirAll(filepath.Join(srcPath, "node_modules"), 0777); err != nil {
+		t.Fatal(err)
+	}
+	matcher, err := ignore.New([]string{"node_modules/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	write := func(name string) {
+		if err := ioutil.WriteFile(filepath.Join(srcPath, name), []byte(name), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wait := func(c <-chan struct{}) bool {
+		select {
+		case <-c:
+			return true
+		case <-time.After(3 * time.Second):
+			return false
+		}
+	}
+
+	t.Run("rerun on changes", func(t *testing.T) {
+		started := make(chan struct{}, 10)
+		run := func() error {
+			started <- struct{}{}
+			return nil
+		}
+
+		stop := make(chan struct{})
+		out := &bytes.Buffer{}
+		done := make(chan error)
+		go func() { done <- watchSource(out, srcPath, filepath.Join(srcPath, "sd-artifacts"), matcher, run, stop) }()
+
+		assert.True(t, wait(started))
+		// wait for the watcher to be ready
+		time.Sleep(100 * time.Millisecond)
+
+		write("node_modules/index.js")
+		assert.False(t, func() bool {
+			select {
+			case <-started:
+				return true
+			case <-time.After(300 * time.Millisecond):
+				return false
+			}
+		}())
+
+		write("main.go")
+		assert.True(t, wait(started))
+
+		close(stop)
+		assert.Nil(t, <-done)
+		assert.Contains(t, out.String(), "======== Run #1 (started)")
+		assert.Contains(t, out.String(), "======== Run #2 (changed "+filepath.Join(srcPath, "main.go")+")")
+		assert.NotContains(t, out.String(), "Run #3")
+	})
+
+	t.Run("cancel the running build", func(t *testing.T) {
+		cleaners = make([]Cleaner, 0, 2)
+		started := make(chan struct{}, 10)
+		count := 0
+		run := func() error {
+			count++
+			c := &killedCleaner{killed: make(chan struct{})}
+			addCleaner(c)
+			started <- struct{}{}
+			<-c.killed
+			return nil
+		}
+
+		stop := make(chan struct{})
+		done := make(chan error)
+		go func() {
+			done <- watchSource(ioutil.Discard, srcPath, filepath.Join(srcPath, "sd-artifacts"), matcher, run, stop)
+		}()
+
+		assert.True(t, wait(started))
+		time.Sleep(100 * time.Millisecond)
+
+		write("main.go")
+		assert.True(t, wait(started))
+
+		close(stop)
+		assert.Nil(t, <-done)
+		assert.Equal(t, 2, count)
+	})
+}
@@ -2,33 +2,77 @@ package config
 
 import (
 	"fmt"
+	"io"
+	"net/url"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
 
 	"github.com/go-yaml/yaml"
 	"github.com/mitchellh/mapstructure"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	imageNamePattern   = regexp.MustCompile(`^(?:[a-zA-Z0-9.-]+(?::[0-9]+)?/)?[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*(?:/[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*)*$`)
+	imageTagPattern    = regexp.MustCompile(`^[\w][\w.-]{0,127}$`)
+	imageDigestPattern = regexp.MustCompile(`^sha256:[a-f0-9]{64}$`)
 )
 
 // Launcher is launcher entity struct
 type Launcher struct {
-	Version string `yaml:"version" mapstructure:"launcher-version"`
-	Image   string `yaml:"image" mapstructure:"launcher-image"`
+	Version string `yaml:"version" toml:"version" mapstructure:"launcher-version" json:"version"`
+	Image   string `yaml:"image" toml:"image" mapstructure:"launcher-image" json:"image"`
+	// Archive is the tarball made by `docker save` to load the image from instead of pulling it
+	Archive string `yaml:"archive,omitempty" toml:"archive,omitempty" mapstructure:"launcher-archive" json:"archive,omitempty"`
 }
 
 // Entry is entity struct of sd-local config
 type Entry struct {
-	APIURL   string   `yaml:"api-url" mapstructure:"api-url"`
-	StoreURL string   `yaml:"store-url" mapstructure:"store-url"`
-	Token    string   `yaml:"token" mapstructure:"token"`
-	UUID     string   `yaml:"UUID" mapstructure:"uuid"`
-	Launcher Launcher `yaml:"launcher" mapstructure:",squash"`
+	APIURL       string   `yaml:"api-url" toml:"api-url" mapstructure:"api-url" json:"apiURL"`
+	StoreURL     string   `yaml:"store-url" toml:"store-url" mapstructure:"store-url" json:"storeURL"`
+	Token        string   `yaml:"token" toml:"token" mapstructure:"token" json:"token"`
+	TokenSource  string   `yaml:"token-source,omitempty" toml:"token-source,omitempty" mapstructure:"token-source" json:"tokenSource,omitempty"`
+	HTTPProxy    string   `yaml:"http-proxy,omitempty" toml:"http-proxy,omitempty" mapstructure:"http-proxy" json:"httpProxy,omitempty"`
+	HTTPSProxy   string   `yaml:"https-proxy,omitempty" toml:"https-proxy,omitempty" mapstructure:"https-proxy" json:"httpsProxy,omitempty"`
+	CABundle     string   `yaml:"ca-bundle,omitempty" toml:"ca-bundle,omitempty" mapstructure:"ca-bundle" json:"caBundle,omitempty"`
+	RegistryAuth string   `yaml:"registry-auth,omitempty" toml:"registry-auth,omitempty" mapstructure:"registry-auth" json:"registryAuth,omitempty"`
+	Runtime      string   `yaml:"runtime,omitempty" toml:"runtime,omitempty" mapstructure:"runtime" json:"runtime,omitempty"`
+	DockerHost   string   `yaml:"docker-host,omitempty" toml:"docker-host,omitempty" mapstructure:"docker-host" json:"dockerHost,omitempty"`
+	Timeout      string   `yaml:"timeout,omitempty" toml:"timeout,omitempty" mapstructure:"timeout" json:"timeout,omitempty"`
+	UUID         string   `yaml:"UUID" toml:"UUID" mapstructure:"uuid" json:"uuid"`
+	Launcher     Launcher `yaml:"launcher" toml:"launcher" mapstructure:",squash" json:"launcher"`
+	// Launchers are the named launcher profiles selected by --launcher-profile of build, which are set only in the config file
+	Launchers map[string]Launcher `yaml:"launchers,omitempty" toml:"launchers,omitempty" mapstructure:"-" json:"launchers,omitempty"`
+	// Locked is changed only by Lock and Unlock, so it cannot be set by Set
+	Locked bool `yaml:"locked,omitempty" toml:"locked,omitempty" mapstructure:"-" json:"locked,omitempty"`
+	// keychainResolved is true once Token holds the token read from the OS keychain
+	keychainResolved bool
+	// lockedName is the name of the entry while it is locked, to name it in the error of Set
+	lockedName string
+}
+
+// Defaults is the initial values of the new entries shared by the configs, which override the ones of DefaultEntry
+type Defaults struct {
+	Launcher Launcher `yaml:"launcher,omitempty" toml:"launcher,omitempty"`
 }
 
 // Config is a set of sd-local config entities
 type Config struct {
-	Entries  map[string]*Entry `yaml:"configs"`
-	Current  string            `yaml:"current"`
-	filePath string            `yaml:"-"`
+	Version   int               `yaml:"version" toml:"version"`
+	Defaults  *Defaults         `yaml:"defaults,omitempty" toml:"defaults,omitempty"`
+	Entries   map[string]*Entry `yaml:"configs" toml:"configs"`
+	Current   string            `yaml:"current" toml:"current"`
+	filePath  string            `yaml:"-" toml:"-"`
+	overrides []appliedOverride `yaml:"-" toml:"-"`
+	// keychainTokens holds the tokens read from the OS keychain to detect changes on Save
+	keychainTokens map[string]string `yaml:"-" toml:"-"`
+	// removedKeychainTokens are the names of the entries whose tokens are removed from the OS keychain on Save
+	removedKeychainTokens []string `yaml:"-" toml:"-"`
+	// locked is true while the lock of the config file taken by New is held, which is shared by the copies of Config
+	locked *bool `yaml:"-" toml:"-"`
 }
 
 // DefaultEntry describes the initial value of an entry
@@ -45,6 +89,23 @@ func DefaultEntry() *Entry {
 	}
 }
 
+// DefaultEntry describes the initial value of an entry with the defaults of Config.
+// The entries which already exist are not changed by the defaults.
+func (c *Config) DefaultEntry() *Entry {
+	entry := DefaultEntry()
+	if c.Defaults == nil {
+		return entry
+	}
+
+	if c.Defaults.Launcher.Version != "" {
+		entry.Launcher.Version = c.Defaults.Launcher.Version
+	}
+	if c.Defaults.Launcher.Image != "" {
+		entry.Launcher.Image = c.Defaults.Launcher.Image
+	}
+	return entry
+}
+
 func create(configPath string) error {
 	_, err := os.Stat(configPath)
 	// if file exists return nil
@@ -57,85 +118,302 @@ func create(configPath string) error {
 	if err != nil {
 		return err
 	}
-	file, err := os.Create(configPath)
-	if err != nil {
-		return err
-	}
-	defer file.Close()
 
-	err = yaml.NewEncoder(file).Encode(Config{
+	return encodeFile(configPath, Config{
+		Version: currentVersion,
 		Entries: map[string]*Entry{
 			"default": DefaultEntry(),
 		},
 		Current: "default",
 	})
+}
+
+// parse decodes the config in the format and migrates it to the current version
+func parse(r io.Reader, f format) (Config, bool, error) {
+	doc, err := f.decode(r)
 	if err != nil {
-		return err
+		return Config{}, false, fmt.Errorf("failed to parse config file: %v", err)
+	}
+	if doc == nil {
+		doc = make(map[interface{}]interface{})
 	}
 
-	return nil
+	migrated, err := migrate(doc)
+	if err != nil {
+		return Config{}, false, err
+	}
+
+	migratedYAML, err := yaml.Marshal(doc)
+	if err != nil {
+		return Config{}, false, fmt.Errorf("failed to parse config file: %v", err)
+	}
+
+	var c Config
+	err = yaml.Unmarshal(migratedYAML, &c)
+	if err != nil {
+		return Config{}, false, fmt.Errorf("failed to parse config file: %v", err)
+	}
+
+	if c.Entries == nil {
+		c.Entries = make(map[string]*Entry)
+	}
+
+	return c, migrated, nil
 }
 
-// New returns parsed config
+// New returns parsed config.
+// The config file is locked until Save or Close not to lose the changes by the other processes of sd-local between the load and the save.
 func New(configPath string) (Config, error) {
-	err := create(configPath)
+	logrus.Debugf("Loading config from %s", configPath)
+	if err := os.MkdirAll(filepath.Dir(configPath), 0777); err != nil {
+		return Config{}, err
+	}
+	if err := lockPath(configPath); err != nil {
+		return Config{}, err
+	}
+
+	c, err := load(configPath)
 	if err != nil {
+		unlockPath(configPath)
 		return Config{}, err
 	}
+	locked := true
+	c.locked = &locked
+	return c, nil
+}
 
+// Read reads the config file as it is for the callers which don't change it, like masking the tokens in the logs.
+// Unlike New, the file is neither created, migrated on the disk nor locked, and the tokens in the OS keychain are read only for the entries in use.
+func Read(configPath string) (Config, error) {
 	file, err := os.Open(configPath)
 	if err != nil {
 		return Config{}, fmt.Errorf("failed to read config file: %v", err)
 	}
 	defer file.Close()
 
-	var c = Config{
-		filePath: configPath,
+	c, _, err := parse(file, formatOf(configPath))
+	if err != nil {
+		return Config{}, err
 	}
+	c.filePath = configPath
+	c.applyEnvOverrides()
 
-	err = yaml.NewDecoder(file).Decode(&c)
+	return c, nil
+}
+
+// load reads the config file, which is created if it does not exist
+func load(configPath string) (Config, error) {
+	err := create(configPath)
 	if err != nil {
-		return Config{}, fmt.Errorf("failed to parse config file: %v", err)
+		return Config{}, err
 	}
 
-	if c.Entries == nil {
-		c.Entries = make(map[string]*Entry)
+	file, err := os.Open(configPath)
+	if err != nil {
+		return Config{}, fmt.Errorf("failed to read config file: %v", err)
 	}
+	defer file.Close()
+
+	c, migrated, err := parse(file, formatOf(configPath))
+	if err != nil {
+		return Config{}, err
+	}
+	c.filePath = configPath
+	c.nameLockedEntries()
+
+	if migrated {
+		err = c.save()
+		if err != nil {
+			return Config{}, fmt.Errorf("failed to save migrated config file: %v", err)
+		}
+	}
+
+	if c.healCurrent() {
+		err = c.save()
+		if err != nil {
+			return Config{}, fmt.Errorf("failed to save config file: %v", err)
+		}
+	}
+
+	err = c.resolveKeychainToken(c.Current)
+	if err != nil {
+		return Config{}, err
+	}
+
+	c.applyEnvOverrides()
 
 	return c, nil
 }
 
-// AddEntry create new Entry and add it to Config
+// healCurrent switches Current to an existing entry if it points to a deleted one.
+// `default` is preferred, otherwise the first entry in alphabetical order is used.
+func (c *Config) healCurrent() bool {
+	_, exists := c.Entries[c.Current]
+	if exists || len(c.Entries) == 0 {
+		return false
+	}
+
+	next := "default"
+	if _, exists := c.Entries[next]; !exists {
+		next = c.EntryNames()[0]
+	}
+	logrus.Warnf("current config `%s` does not exist, switched to `%s`", c.Current, next)
+	c.Current = next
+	return true
+}
+
+// AddEntry create new Entry and add it to Config.
+// The new entry becomes the current config if there is no current config.
 func (c *Config) AddEntry(name string, entry *Entry) error {
-	_, exist := c.Entries[name]
-	if exist {
-		return fmt.Errorf("config `%s` already exists", name)
+	if c.HasEntry(name) {
+		return entryExists(name)
+	}
+
+	return c.AddOrUpdateEntry(name, entry)
+}
+
+// AddOrUpdateEntry adds the Entry to Config, replacing the existing Entry named `name` unless it is locked.
+// The new entry becomes the current config if there is no current config.
+func (c *Config) AddOrUpdateEntry(name string, entry *Entry) error {
+	if err := c.CheckUnlocked(name); err != nil {
+		return err
 	}
 
 	c.Entries[name] = entry
+	c.nameLockedEntries()
+	if !c.HasEntry(c.Current) {
+		c.Current = name
+	}
 	return nil
 }
 
+// HasEntry returns true if the Entry named `name` exists
+func (c *Config) HasEntry(name string) bool {
+	_, exist := c.Entries[name]
+	return exist
+}
+
 // Entry returns an Entry object named `name`
 func (c *Config) Entry(name string) (*Entry, error) {
 	entry, exists := c.Entries[name]
 	if !exists {
-		return &Entry{}, fmt.Errorf("config `%s` does not exist", name)
+		return &Entry{}, entryNotFound(name)
+	}
+	if err := c.resolveKeychainToken(name); err != nil {
+		return &Entry{}, err
 	}
 
 	return entry, nil
 }
 
+// CurrentEntry returns the Entry object of the current config
+func (c *Config) CurrentEntry() (*Entry, error) {
+	if len(c.Entries) == 0 {
+		return &Entry{}, &EntryError{Err: ErrEntryNotFound, msg: "no config entries, run `sd-local config create`"}
+	}
+
+	entry, exists := c.Entries[c.Current]
+	if !exists {
+		return &Entry{}, newEntryError(ErrEntryNotFound, c.Current, "current config `%s` does not exist, switch to another config with `sd-local config use`")
+	}
+	if err := c.resolveKeychainToken(c.Current); err != nil {
+		return &Entry{}, err
+	}
+
+	return entry, nil
+}
+
+// RunEntry returns the name and the Entry object to run the build with, which is the current config unless name is specified.
+// The current config is not changed by name.
+func (c *Config) RunEntry(name string) (string, *Entry, error) {
+	if name == "" {
+		entry, err := c.CurrentEntry()
+		return c.Current, entry, err
+	}
+
+	entry, err := c.Entry(name)
+	if err != nil {
+		return name, entry, fmt.Errorf("%w, see the configs with `sd-local config list`", err)
+	}
+	return name, entry, nil
+}
+
+// EntryNames returns the names of all entries in alphabetical order
+func (c *Config) EntryNames() []string {
+	names := make([]string, 0, len(c.Entries))
+	for name := range c.Entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
 // DeleteEntry deletes Entry object named `name`
 func (c *Config) DeleteEntry(name string) error {
 	if name == c.Current {
-		return fmt.Errorf("config `%s` is current config", name)
+		return newEntryError(ErrCurrentEntry, name, "config `%s` is current config")
 	}
-	_, exist := c.Entries[name]
+	entry, exist := c.Entries[name]
 	if !exist {
-		return fmt.Errorf("config `%s` does not exist", name)
+		return entryNotFound(name)
+	}
+	if err := c.CheckUnlocked(name); err != nil {
+		return err
 	}
 	delete(c.Entries, name)
+	c.removeKeychainToken(name, entry)
+	return nil
+}
+
+// RenameEntry renames Entry object named `oldName` to `newName`
+func (c *Config) RenameEntry(oldName, newName string) error {
+	entry, exist := c.Entries[oldName]
+	if !exist {
+		return entryNotFound(oldName)
+	}
+	if err := c.CheckUnlocked(oldName); err != nil {
+		return err
+	}
+	_, exist = c.Entries[newName]
+	if exist {
+		return entryExists(newName)
+	}
+	// the token in the keychain is moved to the new name on Save
+	if err := c.resolveKeychainToken(oldName); err != nil {
+		return err
+	}
+
+	c.Entries[newName] = entry
+	delete(c.Entries, oldName)
+	c.removeKeychainToken(oldName, entry)
+
+	if c.Current == oldName {
+		c.Current = newName
+	}
+	return nil
+}
+
+// CopyEntry copies Entry object named `src` to `dst`
+func (c *Config) CopyEntry(src, dst string) error {
+	entry, exist := c.Entries[src]
+	if !exist {
+		return entryNotFound(src)
+	}
+	_, exist = c.Entries[dst]
+	if exist {
+		return entryExists(dst)
+	}
+	// the token in the keychain is copied to the new name on Save
+	if err := c.resolveKeychainToken(src); err != nil {
+		return err
+	}
+
+	// the copy is not locked to be customized
+	copied := entry.Clone()
+	copied.Locked = false
+	copied.lockedName = ""
+	c.Entries[dst] = copied
 	return nil
 }
 
@@ -151,28 +429,128 @@ func (c *Config) SetCurrent(name string) error {
 	return nil
 }
 
-// Save write Config to config file
+// FilePath returns the absolute path of the config file which Config is loaded from
+func (c *Config) FilePath() (string, error) {
+	return filepath.Abs(c.filePath)
+}
+
+// Save write Config to config file.
+// The file is replaced atomically, so it is never left partially written by the process killed while saving.
+// The lock of the config file taken by New is released.
 func (c *Config) Save() error {
-	file, err := os.OpenFile(c.filePath, os.O_RDWR|os.O_TRUNC, 0666)
-	if err != nil {
-		return err
+	defer c.Close()
+	return c.save()
+}
+
+func (c *Config) save() error {
+	return c.withoutEnvOverrides(func() error {
+		return c.withoutKeychainTokens(c.write)
+	})
+}
+
+// Close releases the lock of the config file taken by New without saving it. It does nothing if the lock is released already.
+func (c *Config) Close() {
+	if c.locked != nil && *c.locked {
+		*c.locked = false
+		unlockPath(c.filePath)
 	}
-	defer file.Close()
+}
 
-	err = yaml.NewEncoder(file).Encode(c)
-	if err != nil {
-		return err
+func (c *Config) write() error {
+	return encodeFile(c.filePath, c)
+}
+
+// Clone returns a deep copy of the Entry
+func (e *Entry) Clone() *Entry {
+	clone := *e
+	if e.Launchers != nil {
+		clone.Launchers = make(map[string]Launcher, len(e.Launchers))
+		for name, launcher := range e.Launchers {
+			clone.Launchers[name] = launcher
+		}
 	}
+	return &clone
+}
 
+// Validate checks that the Entry has all the fields required to run a build
+func (e *Entry) Validate() error {
+	missing := make([]string, 0, 5)
+	if e.APIURL == "" {
+		missing = append(missing, "api-url")
+	}
+	if e.StoreURL == "" {
+		missing = append(missing, "store-url")
+	}
+	if e.Token == "" {
+		missing = append(missing, "token")
+	}
+	if e.Launcher.Version == "" {
+		missing = append(missing, "launcher-version")
+	}
+	if e.Launcher.Image == "" {
+		missing = append(missing, "launcher-image")
+	}
+
+	if len(missing) != 0 {
+		return fmt.Errorf("missing required settings:\n  * %s", strings.Join(missing, "\n  * "))
+	}
+	return e.Launcher.Validate()
+}
+
+// Validate checks that the Launcher refers to a well-formed image.
+// The image can be pinned to a digest like `screwdrivercd/launcher@sha256:<digest>`, then the version is not used.
+func (l *Launcher) Validate() error {
+	name := l.Image
+	if i := strings.Index(l.Image, "@"); i >= 0 {
+		name = l.Image[:i]
+		if !imageDigestPattern.MatchString(l.Image[i+1:]) {
+			return fmt.Errorf("invalid launcher-image %s: digest must be sha256 followed by 64 hex characters", l.Image)
+		}
+	} else if !imageTagPattern.MatchString(l.Version) {
+		return fmt.Errorf("invalid launcher-version %s: must be a valid image tag", l.Version)
+	}
+
+	if !imageNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid launcher-image %s: must be a lowercase image name without a tag", l.Image)
+	}
 	return nil
 }
 
+func validateURL(key, value string) error {
+	if value == "" {
+		return nil
+	}
+
+	u, err := url.ParseRequestURI(value)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid %s: must be an absolute http(s) URL", key)
+	}
+	return nil
+}
+
+// Get returns the value of the config named `key` like api-url, which is one of the keys of Set
+func (e *Entry) Get(key string) (string, error) {
+	var m map[string]interface{}
+	if err := mapstructure.Decode(e, &m); err != nil {
+		return "", err
+	}
+	value, ok := m[key]
+	if !ok {
+		return "", fmt.Errorf("invalid key %s", key)
+	}
+	return fmt.Sprint(value), nil
+}
+
 // Set preserve sd-local config with new value.
 func (e *Entry) Set(key, value string) error {
 	// Update the receiver(*Entry) with the args `key` and `value` as follows.
 	// 1. Encode current entry to empty map
 	// 2. Check map key found (error handring for unknown key) and set value
 	// 3. Update current entry by map
+	if e.Locked {
+		return newEntryError(ErrEntryLocked, e.lockedName, "entry `%s` is locked; unlock first")
+	}
+
 	var m map[string]interface{}
 	if err := mapstructure.Decode(e, &m); err != nil {
 		return err
@@ -183,6 +561,10 @@ func (e *Entry) Set(key, value string) error {
 
 	// To preserve compatibility
 	switch key {
+	case "api-url", "store-url", "http-proxy", "https-proxy":
+		if err := validateURL(key, value); err != nil {
+			return err
+		}
 	case "launcher-version":
 		if value == "" {
 			value = "stable"
@@ -195,6 +577,22 @@ func (e *Entry) Set(key, value string) error {
 		if value == "" {
 			value = "-"
 		}
+	case "runtime":
+		if err := ValidateRuntime(value); err != nil {
+			return err
+		}
+	case "docker-host":
+		if err := ValidateDockerHost(value); err != nil {
+			return err
+		}
+	case "timeout":
+		if _, err := ParseTimeout(value); err != nil {
+			return err
+		}
+	case "token-source":
+		if value != "" && value != TokenSourceKeychain {
+			return fmt.Errorf("invalid token-source: must be empty or %q", TokenSourceKeychain)
+		}
 	}
 	m[key] = value
 	if err := mapstructure.Decode(m, &e); err != nil {
@@ -18,6 +18,16 @@ import (
 
 var testDir string = "./testdata"
 
+// TestMain removes the lock files of the config files made by the tests, which are left by sd-local
+func TestMain(m *testing.M) {
+	ret := m.Run()
+	locks, _ := filepath.Glob(filepath.Join(testDir, ".*.lock"))
+	for _, lock := range locks {
+		os.Remove(lock)
+	}
+	os.Exit(ret)
+}
+
 func dummyEntry() *Entry {
 	return &Entry{
 		APIURL:   "api-url",
@@ -47,6 +57,7 @@ func TestCreateConfig(t *testing.T) {
 		defer os.Remove(cnfPath)
 
 		expect := Config{
+			Version: 1,
 			Entries: map[string]*Entry{
 				"default": DefaultEntry(),
 			},
@@ -67,6 +78,7 @@ func TestCreateConfig(t *testing.T) {
 		defer os.Remove(cnfPath)
 
 		expect := Config{
+			Version: 1,
 			Entries: map[string]*Entry{
 				"default": DefaultEntry(),
 			},
@@ -84,6 +96,33 @@ func TestCreateConfig(t *testing.T) {
 		assert.Equal(t, expect, actual)
 	})
 }
+func TestReadConfig(t *testing.T) {
+	t.Run("success without changing the file", func(t *testing.T) {
+		cnfPath := copyTestConfig(t, "successConfig")
+		defer os.Remove(cnfPath)
+		before, _ := ioutil.ReadFile(cnfPath)
+
+		actual, err := Read(cnfPath)
+		assert.Nil(t, err)
+		assert.Equal(t, dummyEntry(), actual.Entries["default"])
+
+		// the migrated config is not written, and the file is not locked
+		after, _ := ioutil.ReadFile(cnfPath)
+		assert.Equal(t, string(before), string(after))
+		_, locked := fileLocks[lockFilePath(resolvePath(cnfPath))]
+		assert.False(t, locked)
+	})
+
+	t.Run("failure without creating the file", func(t *testing.T) {
+		cnfPath := filepath.Join(testDir, "doesnotexist")
+
+		_, err := Read(cnfPath)
+		assert.NotNil(t, err)
+		_, err = os.Stat(cnfPath)
+		assert.True(t, os.IsNotExist(err))
+	})
+}
+
 func TestNewConfig(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
 		cnfPath := filepath.Join(testDir, "successConfig")
@@ -94,12 +133,43 @@ func TestNewConfig(t *testing.T) {
 		}
 
 		testConfig := dummyConfig()
+		testConfig.Version = 1
 		testConfig.filePath = cnfPath
+		locked := true
+		testConfig.locked = &locked
 
 		assert.Nil(t, err)
 		assert.Equal(t, testConfig, actual)
 	})
 
+	t.Run("success to fall back to default when current does not exist", func(t *testing.T) {
+		cnfPath := copyTestConfig(t, "failureCurrentConfig")
+		defer os.Remove(cnfPath)
+
+		actual, err := New(cnfPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, "default", actual.Current)
+
+		saved, err := New(cnfPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, "default", saved.Current)
+	})
+
+	t.Run("success to fall back to the first entry when current and default do not exist", func(t *testing.T) {
+		cnfPath := copyTestConfig(t, "danglingCurrentConfig")
+		defer os.Remove(cnfPath)
+
+		actual, err := New(cnfPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, "beta", actual.Current)
+	})
+
 	t.Run("failure by invalid yaml", func(t *testing.T) {
 		cnfPath := filepath.Join(testDir, "failureConfig")
 
@@ -123,7 +193,7 @@ func TestConfigEntry(t *testing.T) {
 		"failed": {
 			current:     "doesnotexist",
 			expectEntry: &Entry{},
-			expectErr:   fmt.Errorf("config `doesnotexist` does not exist"),
+			expectErr:   entryNotFound("doesnotexist"),
 		},
 	}
 
@@ -145,6 +215,123 @@ func TestConfigEntry(t *testing.T) {
 	}
 }
 
+func TestConfigCurrentEntry(t *testing.T) {
+	cases := map[string]struct {
+		current     string
+		noEntries   bool
+		expectEntry *Entry
+		expectErr   error
+	}{
+		"success": {
+			current:     "default",
+			expectEntry: dummyEntry(),
+			expectErr:   nil,
+		},
+		"failed": {
+			current:     "doesnotexist",
+			expectEntry: &Entry{},
+			expectErr:   newEntryError(ErrEntryNotFound, "doesnotexist", "current config `%s` does not exist, switch to another config with `sd-local config use`"),
+		},
+		"failed by no entries": {
+			current:     "default",
+			noEntries:   true,
+			expectEntry: &Entry{},
+			expectErr:   &EntryError{Err: ErrEntryNotFound, msg: "no config entries, run `sd-local config create`"},
+		},
+	}
+
+	for name, test := range cases {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			config := Config{
+				Entries: map[string]*Entry{
+					"default": dummyEntry(),
+				},
+				Current: test.current,
+			}
+			if test.noEntries {
+				config.Entries = map[string]*Entry{}
+			}
+			actual, err := config.CurrentEntry()
+
+			assert.Equal(t, test.expectErr, err)
+			assert.Equal(t, test.expectEntry, actual)
+		})
+	}
+}
+
+func TestConfigRunEntry(t *testing.T) {
+	cases := map[string]struct {
+		name        string
+		expectName  string
+		expectEntry *Entry
+		expectErr   error
+	}{
+		"success with current": {
+			name:        "",
+			expectName:  "default",
+			expectEntry: dummyEntry(),
+			expectErr:   nil,
+		},
+		"success with name": {
+			name:        "beta",
+			expectName:  "beta",
+			expectEntry: DefaultEntry(),
+			expectErr:   nil,
+		},
+		"failed": {
+			name:        "doesnotexist",
+			expectName:  "doesnotexist",
+			expectEntry: &Entry{},
+			expectErr:   fmt.Errorf("%w, see the configs with `sd-local config list`", entryNotFound("doesnotexist")),
+		},
+	}
+
+	for name, test := range cases {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			config := Config{
+				Entries: map[string]*Entry{
+					"default": dummyEntry(),
+					"beta":    DefaultEntry(),
+				},
+				Current: "default",
+			}
+			actualName, actual, err := config.RunEntry(test.name)
+
+			assert.Equal(t, test.expectErr, err)
+			assert.Equal(t, test.expectName, actualName)
+			assert.Equal(t, test.expectEntry, actual)
+			assert.Equal(t, "default", config.Current)
+		})
+	}
+}
+
+func TestConfigEntryNames(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		config := Config{
+			Entries: map[string]*Entry{
+				"prod":    dummyEntry(),
+				"default": dummyEntry(),
+				"beta":    DefaultEntry(),
+			},
+			Current: "default",
+		}
+
+		assert.Equal(t, []string{"beta", "default", "prod"}, config.EntryNames())
+	})
+
+	t.Run("success with no entries", func(t *testing.T) {
+		config := Config{
+			Entries: map[string]*Entry{},
+		}
+
+		assert.Equal(t, []string{}, config.EntryNames())
+	})
+}
+
 func TestConfigAddEntry(t *testing.T) {
 	cases := map[string]struct {
 		addedEntryName string
@@ -170,7 +357,7 @@ func TestConfigAddEntry(t *testing.T) {
 				},
 				Current: "default",
 			},
-			expectErr: fmt.Errorf("config `default` already exists"),
+			expectErr: entryExists("default"),
 		},
 	}
 
@@ -184,6 +371,90 @@ func TestConfigAddEntry(t *testing.T) {
 			assert.Equal(t, test.expectConfig, config)
 		})
 	}
+
+	t.Run("success to use the added entry as current when there are no entries", func(t *testing.T) {
+		config := Config{
+			Entries: map[string]*Entry{},
+			Current: "default",
+		}
+		err := config.AddEntry("test", DefaultEntry())
+		assert.Nil(t, err)
+		assert.Equal(t, "test", config.Current)
+	})
+}
+
+func TestConfigAddOrUpdateEntry(t *testing.T) {
+	cases := map[string]struct {
+		entryName    string
+		locked       bool
+		expectConfig Config
+		expectErr    error
+	}{
+		"successfully added a test entry": {
+			entryName: "test",
+			expectConfig: Config{
+				Entries: map[string]*Entry{
+					"default": dummyEntry(),
+					"test":    DefaultEntry(),
+				},
+				Current: "default",
+			},
+			expectErr: nil,
+		},
+		"successfully updated the entry that exists": {
+			entryName: "default",
+			expectConfig: Config{
+				Entries: map[string]*Entry{
+					"default": DefaultEntry(),
+				},
+				Current: "default",
+			},
+			expectErr: nil,
+		},
+		"failure by the locked entry": {
+			entryName: "default",
+			locked:    true,
+			expectConfig: Config{
+				Entries: map[string]*Entry{
+					"default": func() *Entry {
+						e := dummyEntry()
+						e.Locked = true
+						return e
+					}(),
+				},
+				Current: "default",
+			},
+			expectErr: newEntryError(ErrEntryLocked, "default", "entry `%s` is locked; unlock first"),
+		},
+	}
+
+	for name, test := range cases {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			config := dummyConfig()
+			config.Entries["default"].Locked = test.locked
+			err := config.AddOrUpdateEntry(test.entryName, DefaultEntry())
+			assert.Equal(t, test.expectErr, err)
+			assert.Equal(t, test.expectConfig, config)
+		})
+	}
+
+	t.Run("success to use the added entry as current when there are no entries", func(t *testing.T) {
+		config := Config{
+			Entries: map[string]*Entry{},
+			Current: "default",
+		}
+		err := config.AddOrUpdateEntry("test", DefaultEntry())
+		assert.Nil(t, err)
+		assert.Equal(t, "test", config.Current)
+	})
+}
+
+func TestConfigHasEntry(t *testing.T) {
+	config := dummyConfig()
+	assert.True(t, config.HasEntry("default"))
+	assert.False(t, config.HasEntry("doesnotexist"))
 }
 
 func TestConfigDeleteEntry(t *testing.T) {
@@ -211,7 +482,7 @@ func TestConfigDeleteEntry(t *testing.T) {
 				},
 				Current: "default",
 			},
-			expectErr: fmt.Errorf("config `doesnotexist` does not exist"),
+			expectErr: entryNotFound("doesnotexist"),
 		},
 		"failure by trying to delete current entry": {
 			deletedEntryName: "default",
@@ -222,7 +493,7 @@ func TestConfigDeleteEntry(t *testing.T) {
 				},
 				Current: "default",
 			},
-			expectErr: fmt.Errorf("config `default` is current config"),
+			expectErr: newEntryError(ErrCurrentEntry, "default", "config `%s` is current config"),
 		},
 	}
 
@@ -246,6 +517,164 @@ func TestConfigDeleteEntry(t *testing.T) {
 	}
 }
 
+func TestConfigRenameEntry(t *testing.T) {
+	cases := map[string]struct {
+		oldName      string
+		newName      string
+		expectConfig Config
+		expectErr    error
+	}{
+		"successfully renamed a test entry": {
+			oldName: "test",
+			newName: "renamed",
+			expectConfig: Config{
+				Entries: map[string]*Entry{
+					"default": dummyEntry(),
+					"renamed": DefaultEntry(),
+				},
+				Current: "default",
+			},
+			expectErr: nil,
+		},
+		"successfully renamed current entry": {
+			oldName: "default",
+			newName: "renamed",
+			expectConfig: Config{
+				Entries: map[string]*Entry{
+					"renamed": dummyEntry(),
+					"test":    DefaultEntry(),
+				},
+				Current: "renamed",
+			},
+			expectErr: nil,
+		},
+		"failure by the name that does not exist": {
+			oldName: "doesnotexist",
+			newName: "renamed",
+			expectConfig: Config{
+				Entries: map[string]*Entry{
+					"default": dummyEntry(),
+					"test":    DefaultEntry(),
+				},
+				Current: "default",
+			},
+			expectErr: entryNotFound("doesnotexist"),
+		},
+		"failure by the new name that exists": {
+			oldName: "test",
+			newName: "default",
+			expectConfig: Config{
+				Entries: map[string]*Entry{
+					"default": dummyEntry(),
+					"test":    DefaultEntry(),
+				},
+				Current: "default",
+			},
+			expectErr: entryExists("default"),
+		},
+	}
+
+	for name, test := range cases {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			config := Config{
+				Entries: map[string]*Entry{
+					"default": dummyEntry(),
+					"test":    DefaultEntry(),
+				},
+				Current: "default",
+			}
+
+			err := config.RenameEntry(test.oldName, test.newName)
+			assert.Equal(t, test.expectErr, err)
+			assert.Equal(t, test.expectConfig, config)
+		})
+	}
+}
+
+func TestConfigCopyEntry(t *testing.T) {
+	cases := map[string]struct {
+		src          string
+		dst          string
+		expectConfig Config
+		expectErr    error
+	}{
+		"successfully copied a default entry": {
+			src: "default",
+			dst: "copied",
+			expectConfig: Config{
+				Entries: map[string]*Entry{
+					"default": dummyEntry(),
+					"test":    DefaultEntry(),
+					"copied":  dummyEntry(),
+				},
+				Current: "default",
+			},
+			expectErr: nil,
+		},
+		"failure by the name that does not exist": {
+			src: "doesnotexist",
+			dst: "copied",
+			expectConfig: Config{
+				Entries: map[string]*Entry{
+					"default": dummyEntry(),
+					"test":    DefaultEntry(),
+				},
+				Current: "default",
+			},
+			expectErr: entryNotFound("doesnotexist"),
+		},
+		"failure by the destination name that exists": {
+			src: "default",
+			dst: "test",
+			expectConfig: Config{
+				Entries: map[string]*Entry{
+					"default": dummyEntry(),
+					"test":    DefaultEntry(),
+				},
+				Current: "default",
+			},
+			expectErr: entryExists("test"),
+		},
+	}
+
+	for name, test := range cases {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			config := Config{
+				Entries: map[string]*Entry{
+					"default": dummyEntry(),
+					"test":    DefaultEntry(),
+				},
+				Current: "default",
+			}
+
+			err := config.CopyEntry(test.src, test.dst)
+			assert.Equal(t, test.expectErr, err)
+			assert.Equal(t, test.expectConfig, config)
+		})
+	}
+
+	t.Run("copied entry is independent of the source", func(t *testing.T) {
+		config := dummyConfig()
+		err := config.CopyEntry("default", "copied")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = config.Entries["copied"].Set("launcher-version", "copied-version")
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, "latest", config.Entries["default"].Launcher.Version)
+		assert.Equal(t, "copied-version", config.Entries["copied"].Launcher.Version)
+	})
+}
+
 func TestConfigSetCurrent(t *testing.T) {
 	cases := map[string]struct {
 		setEntryName  string
@@ -260,7 +689,7 @@ func TestConfigSetCurrent(t *testing.T) {
 		"failure to set": {
 			setEntryName:  "doesnotexist",
 			expectCurrent: "default",
-			expectErr:     fmt.Errorf("config `doesnotexist` does not exist"),
+			expectErr:     entryNotFound("doesnotexist"),
 		},
 	}
 
@@ -331,6 +760,104 @@ func TestConfigSave(t *testing.T) {
 	})
 }
 
+func TestValidateEntry(t *testing.T) {
+	cases := map[string]struct {
+		entry     *Entry
+		expectErr error
+	}{
+		"success": {
+			entry:     dummyEntry(),
+			expectErr: nil,
+		},
+		"failure by missing token": {
+			entry: func() *Entry {
+				e := dummyEntry()
+				e.Token = ""
+				return e
+			}(),
+			expectErr: fmt.Errorf("missing required settings:\n  * token"),
+		},
+		"failure by default entry": {
+			entry:     DefaultEntry(),
+			expectErr: fmt.Errorf("missing required settings:\n  * api-url\n  * store-url\n  * token"),
+		},
+		"failure by empty entry": {
+			entry:     &Entry{},
+			expectErr: fmt.Errorf("missing required settings:\n  * api-url\n  * store-url\n  * token\n  * launcher-version\n  * launcher-image"),
+		},
+		"failure by malformed launcher": {
+			entry: func() *Entry {
+				e := dummyEntry()
+				e.Launcher.Image = "screwdrivercd/launcher:stable"
+				return e
+			}(),
+			expectErr: fmt.Errorf("invalid launcher-image screwdrivercd/launcher:stable: must be a lowercase image name without a tag"),
+		},
+	}
+
+	for name, test := range cases {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			err := test.entry.Validate()
+			assert.Equal(t, test.expectErr, err)
+		})
+	}
+}
+
+func TestValidateLauncher(t *testing.T) {
+	digest := "sha256:" + strings.Repeat("0123456789abcdef", 4)
+
+	cases := map[string]struct {
+		launcher  Launcher
+		expectErr error
+	}{
+		"success": {
+			launcher:  Launcher{Version: "stable", Image: "screwdrivercd/launcher"},
+			expectErr: nil,
+		},
+		"success with registry": {
+			launcher:  Launcher{Version: "v6.0.1", Image: "registry.example.com:5000/sd/launcher"},
+			expectErr: nil,
+		},
+		"success with digest": {
+			launcher:  Launcher{Version: "stable", Image: "screwdrivercd/launcher@" + digest},
+			expectErr: nil,
+		},
+		"success with digest and no version": {
+			launcher:  Launcher{Version: "", Image: "screwdrivercd/launcher@" + digest},
+			expectErr: nil,
+		},
+		"failure by tag in image": {
+			launcher:  Launcher{Version: "stable", Image: "screwdrivercd/launcher:latest"},
+			expectErr: fmt.Errorf("invalid launcher-image screwdrivercd/launcher:latest: must be a lowercase image name without a tag"),
+		},
+		"failure by upper case image": {
+			launcher:  Launcher{Version: "stable", Image: "screwdrivercd/Launcher"},
+			expectErr: fmt.Errorf("invalid launcher-image screwdrivercd/Launcher: must be a lowercase image name without a tag"),
+		},
+		"failure by malformed digest": {
+			launcher:  Launcher{Version: "stable", Image: "screwdrivercd/launcher@sha256:abc"},
+			expectErr: fmt.Errorf("invalid launcher-image screwdrivercd/launcher@sha256:abc: digest must be sha256 followed by 64 hex characters"),
+		},
+		"failure by malformed version": {
+			launcher:  Launcher{Version: "v1 beta", Image: "screwdrivercd/launcher"},
+			expectErr: fmt.Errorf("invalid launcher-version v1 beta: must be a valid image tag"),
+		},
+	}
+
+	for name, test := range cases {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			err := test.launcher.Validate()
+			assert.Equal(t, test.expectErr, err)
+		})
+	}
+}
+
 func TestSetEntry(t *testing.T) {
 	type setting struct {
 		key   string
@@ -345,9 +872,62 @@ func TestSetEntry(t *testing.T) {
 		"set api-url": {
 			input: setting{
 				key:   "api-url",
-				value: "example-api-url",
+				value: "https://api.example.com",
+			},
+			expectValue: "https://api.example.com",
+		},
+		"set store-url": {
+			input: setting{
+				key:   "store-url",
+				value: "http://store.example.com:8080/path",
+			},
+			expectValue: "http://store.example.com:8080/path",
+		},
+		"set empty to api-url": {
+			input: setting{
+				key:   "api-url",
+				value: "",
+			},
+			expectValue: "",
+		},
+		"set api-url without scheme": {
+			input: setting{
+				key:   "api-url",
+				value: "api.example.com",
+			},
+			expectValue: "",
+			expectErr:   fmt.Errorf("invalid api-url: must be an absolute http(s) URL"),
+		},
+		"set api-url with invalid scheme": {
+			input: setting{
+				key:   "api-url",
+				value: "ftp://api.example.com",
+			},
+			expectValue: "",
+			expectErr:   fmt.Errorf("invalid api-url: must be an absolute http(s) URL"),
+		},
+		"set http-proxy": {
+			input: setting{
+				key:   "http-proxy",
+				value: "http://proxy.example.com:8080",
+			},
+			expectValue: "http://proxy.example.com:8080",
+		},
+		"set invalid https-proxy": {
+			input: setting{
+				key:   "https-proxy",
+				value: "proxy.example.com:8080",
 			},
-			expectValue: "example-api-url",
+			expectValue: "",
+			expectErr:   fmt.Errorf("invalid https-proxy: must be an absolute http(s) URL"),
+		},
+		"set store-url without host": {
+			input: setting{
+				key:   "store-url",
+				value: "https://",
+			},
+			expectValue: "",
+			expectErr:   fmt.Errorf("invalid store-url: must be an absolute http(s) URL"),
 		},
 		"set launcher-version": {
 			input: setting{
@@ -370,6 +950,13 @@ func TestSetEntry(t *testing.T) {
 			},
 			expectValue: "screwdrivercd/launcher",
 		},
+		"set launcher-archive": {
+			input: setting{
+				key:   "launcher-archive",
+				value: "~/launcher.tar",
+			},
+			expectValue: "~/launcher.tar",
+		},
 		"set empty to uuid": {
 			input: setting{
 				key:   "uuid",
@@ -377,6 +964,58 @@ func TestSetEntry(t *testing.T) {
 			},
 			expectValue: "-",
 		},
+		"set runtime": {
+			input: setting{
+				key:   "runtime",
+				value: RuntimeKubernetes,
+			},
+			expectValue: "k8s",
+		},
+		"set invalid runtime": {
+			input: setting{
+				key:   "runtime",
+				value: "lxc",
+			},
+			expectValue: "",
+			expectErr:   fmt.Errorf("invalid runtime lxc: must be one of docker, podman, k8s"),
+		},
+		"set timeout": {
+			input: setting{
+				key:   "timeout",
+				value: "30m",
+			},
+			expectValue: "30m",
+		},
+		"set invalid timeout": {
+			input: setting{
+				key:   "timeout",
+				value: "-1h",
+			},
+			expectValue: "",
+			expectErr:   fmt.Errorf("invalid timeout -1h: must be a positive duration like 30m"),
+		},
+		"set docker-host of URL": {
+			input: setting{
+				key:   "docker-host",
+				value: "tcp://192.168.1.10:2376",
+			},
+			expectValue: "tcp://192.168.1.10:2376",
+		},
+		"set docker-host of socket path": {
+			input: setting{
+				key:   "docker-host",
+				value: "~/.docker/run/docker.sock",
+			},
+			expectValue: "~/.docker/run/docker.sock",
+		},
+		"set invalid docker-host": {
+			input: setting{
+				key:   "docker-host",
+				value: "192.168.1.10:2376",
+			},
+			expectValue: "",
+			expectErr:   fmt.Errorf("invalid docker-host 192.168.1.10:2376: must be a URL whose scheme is one of unix, tcp, ssh, npipe, or a path of a unix socket"),
+		},
 		"set invalid-key": {
 			input: setting{
 				key:   "invalid-key",
@@ -402,3 +1041,90 @@ func TestSetEntry(t *testing.T) {
 		})
 	}
 }
+
+func TestGetEntry(t *testing.T) {
+	e := dummyEntry()
+	e.Timeout = "30m"
+
+	cases := map[string]struct {
+		key         string
+		expectValue string
+		expectErr   error
+	}{
+		"get api-url":          {key: "api-url", expectValue: "api-url"},
+		"get store-url":        {key: "store-url", expectValue: "store-api-url"},
+		"get token":            {key: "token", expectValue: "dummy_token"},
+		"get launcher-version": {key: "launcher-version", expectValue: "latest"},
+		"get launcher-image":   {key: "launcher-image", expectValue: "screwdrivercd/launcher"},
+		"get timeout":          {key: "timeout", expectValue: "30m"},
+		"get empty runtime":    {key: "runtime", expectValue: ""},
+		"get invalid-key":      {key: "invalid-key", expectErr: fmt.Errorf("invalid key invalid-key")},
+	}
+
+	for name, test := range cases {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			value, err := e.Get(test.key)
+			assert.Equal(t, test.expectErr, err)
+			assert.Equal(t, test.expectValue, value)
+		})
+	}
+
+	t.Run("get the value set by Set", func(t *testing.T) {
+		e := DefaultEntry()
+		if err := e.Set("launcher-version", ""); err != nil {
+			t.Fatal(err)
+		}
+		value, err := e.Get("launcher-version")
+		assert.Nil(t, err)
+		assert.Equal(t, "stable", value)
+	})
+}
+
+func TestConfigDefaultEntry(t *testing.T) {
+	testCases := map[string]struct {
+		defaults *Defaults
+		expect   Launcher
+	}{
+		"without defaults": {
+			defaults: nil,
+			expect:   Launcher{Version: "stable", Image: "screwdrivercd/launcher"},
+		},
+		"with the image of defaults": {
+			defaults: &Defaults{Launcher: Launcher{Image: "registry.example.com/launcher"}},
+			expect:   Launcher{Version: "stable", Image: "registry.example.com/launcher"},
+		},
+		"with the version and the image of defaults": {
+			defaults: &Defaults{Launcher: Launcher{Version: "6.0.100", Image: "registry.example.com/launcher"}},
+			expect:   Launcher{Version: "6.0.100", Image: "registry.example.com/launcher"},
+		},
+	}
+
+	for name, tt := range testCases {
+		t.Run(name, func(t *testing.T) {
+			c := Config{Defaults: tt.defaults}
+			entry := c.DefaultEntry()
+			assert.Equal(t, tt.expect, entry.Launcher)
+			assert.Equal(t, "", entry.APIURL)
+		})
+	}
+}
+
+func TestConfigFilePath(t *testing.T) {
+	cnfPath := filepath.Join(testDir, "successConfig")
+	c, err := New(cnfPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected, err := filepath.Abs(cnfPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	actual, err := c.FilePath()
+	assert.Nil(t, err)
+	assert.Equal(t, expected, actual)
+	assert.True(t, filepath.IsAbs(actual))
+}
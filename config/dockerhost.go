@@ -0,0 +1,57 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// dockerHostSchemes are the schemes of the daemon addresses which the container CLIs accept
+var dockerHostSchemes = []string{"unix", "tcp", "ssh", "npipe"}
+
+// ValidateDockerHost checks that the docker host is a URL like tcp://host:2376 or a path of a unix socket.
+// Empty means the environment variables or the default of the runtime.
+func ValidateDockerHost(host string) error {
+	if host == "" || isSocketPath(host) {
+		return nil
+	}
+
+	u, err := url.Parse(host)
+	if err == nil {
+		for _, s := range dockerHostSchemes {
+			if u.Scheme == s && (u.Host != "" || u.Path != "") {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("invalid docker-host %s: must be a URL whose scheme is one of %s, or a path of a unix socket", host, strings.Join(dockerHostSchemes, ", "))
+}
+
+// isSocketPath returns true if the docker host is a path instead of a URL, which can start with ~ or a variable
+func isSocketPath(host string) bool {
+	return strings.HasPrefix(host, "/") || strings.HasPrefix(host, "~") || strings.HasPrefix(host, "$")
+}
+
+// DockerHostURL returns the docker host in the URL form, whose path is converted to a unix socket URL
+func DockerHostURL(host string) string {
+	if host != "" && isSocketPath(host) {
+		return "unix://" + host
+	}
+	return host
+}
+
+// expandDockerHost expands the path of the docker host like expandPath, which can be in a unix socket URL like unix://~/docker.sock
+func expandDockerHost(host string) (string, error) {
+	const unixScheme = "unix://"
+	if strings.HasPrefix(host, unixScheme) {
+		path, err := expandPath(strings.TrimPrefix(host, unixScheme))
+		if err != nil {
+			return "", err
+		}
+		return unixScheme + path, nil
+	}
+	if isSocketPath(host) {
+		return expandPath(host)
+	}
+	return host, nil
+}
@@ -0,0 +1,49 @@
+package config
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/mitchellh/go-homedir"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateDockerHost(t *testing.T) {
+	for _, host := range []string{"", "unix:///var/run/docker.sock", "tcp://192.168.1.10:2376", "ssh://user@host", "/run/user/1000/podman/podman.sock", "$XDG_RUNTIME_DIR/docker.sock"} {
+		assert.Nil(t, ValidateDockerHost(host), host)
+	}
+	for _, host := range []string{"192.168.1.10:2376", "http://host:2375", "tcp://", "docker.sock"} {
+		assert.NotNil(t, ValidateDockerHost(host), host)
+	}
+}
+
+func TestDockerHostURL(t *testing.T) {
+	assert.Equal(t, "", DockerHostURL(""))
+	assert.Equal(t, "tcp://192.168.1.10:2376", DockerHostURL("tcp://192.168.1.10:2376"))
+	assert.Equal(t, "unix:///run/user/1000/docker.sock", DockerHostURL("/run/user/1000/docker.sock"))
+}
+
+func TestExpandDockerHost(t *testing.T) {
+	defer setEnv(t, map[string]string{
+		"XDG_RUNTIME_DIR": "/run/user/1000",
+	})()
+
+	home, err := homedir.Dir()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for host, expected := range map[string]string{
+		"":                                    "",
+		"tcp://192.168.1.10:2376":             "tcp://192.168.1.10:2376",
+		"unix:///var/run/docker.sock":         "unix:///var/run/docker.sock",
+		"unix://~/.colima/docker.sock":        "unix://" + filepath.Join(home, ".colima", "docker.sock"),
+		"unix://$XDG_RUNTIME_DIR/docker.sock": "unix:///run/user/1000/docker.sock",
+		"~/.colima/docker.sock":               filepath.Join(home, ".colima", "docker.sock"),
+		"$XDG_RUNTIME_DIR/docker.sock":        "/run/user/1000/docker.sock",
+	} {
+		actual, err := expandDockerHost(host)
+		assert.Nil(t, err, host)
+		assert.Equal(t, expected, actual, host)
+	}
+}
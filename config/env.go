@@ -0,0 +1,73 @@
+package config
+
+import "os"
+
+// envOverride describes an environment variable which overrides a field of the current entry.
+//
+// The following fields can be overridden:
+//
+//	| Environment variable | Config key | Entry field |
+//	|----------------------|------------|-------------|
+//	| SD_TOKEN             | token      | Token       |
+//	| SD_API_URL           | api-url    | APIURL      |
+//	| SD_STORE_URL         | store-url  | StoreURL    |
+//
+// The overridden values are never written back to the config file by Save.
+type envOverride struct {
+	name  string
+	field func(e *Entry) *string
+}
+
+var envOverrides = []envOverride{
+	{name: "SD_TOKEN", field: func(e *Entry) *string { return &e.Token }},
+	{name: "SD_API_URL", field: func(e *Entry) *string { return &e.APIURL }},
+	{name: "SD_STORE_URL", field: func(e *Entry) *string { return &e.StoreURL }},
+}
+
+// appliedOverride keeps both values of an overridden field of the entry to restore the file value on Save.
+// The entry is kept because the current entry may be changed after the override was applied.
+type appliedOverride struct {
+	envOverride
+	entry     *Entry
+	fileValue string
+	envValue  string
+}
+
+func (c *Config) applyEnvOverrides() {
+	entry, exists := c.Entries[c.Current]
+	if !exists {
+		return
+	}
+
+	for _, o := range envOverrides {
+		value := os.Getenv(o.name)
+		if value == "" {
+			continue
+		}
+		field := o.field(entry)
+		c.overrides = append(c.overrides, appliedOverride{
+			envOverride: o,
+			entry:       entry,
+			fileValue:   *field,
+			envValue:    value,
+		})
+		*field = value
+	}
+}
+
+// withoutEnvOverrides calls f while the overridden fields hold the values of the config file.
+// Fields which were changed after the override was applied are left as they are.
+func (c *Config) withoutEnvOverrides(f func() error) error {
+	for _, o := range c.overrides {
+		field := o.field(o.entry)
+		if *field != o.envValue {
+			continue
+		}
+		*field = o.fileValue
+		defer func(field *string, value string) {
+			*field = value
+		}(field, o.envValue)
+	}
+
+	return f()
+}
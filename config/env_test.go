@@ -0,0 +1,145 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func setEnv(t *testing.T, env map[string]string) func() {
+	t.Helper()
+	for k, v := range env {
+		if err := os.Setenv(k, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return func() {
+		for k := range env {
+			os.Unsetenv(k)
+		}
+	}
+}
+
+func copyTestConfig(t *testing.T, name string) string {
+	t.Helper()
+	rand.Seed(time.Now().UnixNano())
+	cnfPath := filepath.Join(testDir, fmt.Sprintf("%vconfig", rand.Int()))
+
+	b, err := ioutil.ReadFile(filepath.Join(testDir, name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ioutil.WriteFile(cnfPath, b, 0666)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cnfPath
+}
+
+func TestNewConfigWithEnvOverrides(t *testing.T) {
+	t.Run("success to override current entry", func(t *testing.T) {
+		defer setEnv(t, map[string]string{
+			"SD_TOKEN":     "env_token",
+			"SD_API_URL":   "https://api.example.com",
+			"SD_STORE_URL": "https://store.example.com",
+		})()
+
+		actual, err := New(filepath.Join(testDir, "successConfig"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected := dummyEntry()
+		expected.Token = "env_token"
+		expected.APIURL = "https://api.example.com"
+		expected.StoreURL = "https://store.example.com"
+		assert.Equal(t, expected, actual.Entries["default"])
+	})
+
+	t.Run("success to keep file value when env is empty", func(t *testing.T) {
+		defer setEnv(t, map[string]string{
+			"SD_TOKEN": "",
+		})()
+
+		actual, err := New(filepath.Join(testDir, "successConfig"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, dummyEntry(), actual.Entries["default"])
+	})
+
+	t.Run("success not to write overridden value on save", func(t *testing.T) {
+		cnfPath := copyTestConfig(t, "successConfig")
+		defer os.Remove(cnfPath)
+		defer setEnv(t, map[string]string{
+			"SD_TOKEN":   "env_token",
+			"SD_API_URL": "https://api.example.com",
+		})()
+
+		c, err := New(cnfPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = c.Entries["default"].Set("api-url", "https://api-updated.example.com")
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = c.Save()
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, "env_token", c.Entries["default"].Token)
+
+		os.Unsetenv("SD_TOKEN")
+		os.Unsetenv("SD_API_URL")
+		actual, err := New(cnfPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected := dummyEntry()
+		expected.APIURL = "https://api-updated.example.com"
+		assert.Equal(t, expected, actual.Entries["default"])
+	})
+	t.Run("success not to write overridden value on save after changing current entry", func(t *testing.T) {
+		cnfPath := copyTestConfig(t, "successConfig")
+		defer os.Remove(cnfPath)
+		defer setEnv(t, map[string]string{
+			"SD_TOKEN": "env_token",
+		})()
+
+		c, err := New(cnfPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = c.AddEntry("test", &Entry{Launcher: Launcher{Version: "stable", Image: "screwdrivercd/launcher"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = c.SetCurrent("test")
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = c.Save()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		os.Unsetenv("SD_TOKEN")
+		actual, err := New(cnfPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, "test", actual.Current)
+		assert.Equal(t, dummyEntry(), actual.Entries["default"])
+		assert.Equal(t, "", actual.Entries["test"].Token)
+	})
+}
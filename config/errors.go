@@ -0,0 +1,48 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+)
+
+// The errors of the entries, which the errors returned by Config are compared with by errors.Is
+var (
+	// ErrEntryNotFound is the error of the entry which does not exist
+	ErrEntryNotFound = errors.New("config does not exist")
+	// ErrEntryExists is the error of the entry which already exists
+	ErrEntryExists = errors.New("config already exists")
+	// ErrCurrentEntry is the error of the current entry which can't be deleted
+	ErrCurrentEntry = errors.New("config is current config")
+	// ErrEntryLocked is the error of the locked entry which can't be changed
+	ErrEntryLocked = errors.New("config is locked")
+)
+
+// EntryError is the error of the entry named Name, which is one of the errors like ErrEntryNotFound by errors.Is.
+// The message is the same as the one before the errors were typed.
+type EntryError struct {
+	Name string
+	Err  error
+	msg  string
+}
+
+// newEntryError returns the EntryError whose message is formatted with the name
+func newEntryError(err error, name, format string) error {
+	return &EntryError{Name: name, Err: err, msg: fmt.Sprintf(format, name)}
+}
+
+func (e *EntryError) Error() string {
+	return e.msg
+}
+
+// Unwrap returns the error like ErrEntryNotFound for errors.Is
+func (e *EntryError) Unwrap() error {
+	return e.Err
+}
+
+func entryNotFound(name string) error {
+	return newEntryError(ErrEntryNotFound, name, "config `%s` does not exist")
+}
+
+func entryExists(name string) error {
+	return newEntryError(ErrEntryExists, name, "config `%s` already exists")
+}
@@ -0,0 +1,76 @@
+package config
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEntryError(t *testing.T) {
+	newConfig := func() Config {
+		return Config{
+			Entries: map[string]*Entry{
+				"default": dummyEntry(),
+				"prod":    {Locked: true},
+			},
+			Current: "default",
+		}
+	}
+
+	testCases := map[string]struct {
+		err    func(c Config) error
+		target error
+		name   string
+		msg    string
+	}{
+		"Entry": {
+			err:    func(c Config) error { _, err := c.Entry("doesnotexist"); return err },
+			target: ErrEntryNotFound,
+			name:   "doesnotexist",
+			msg:    "config `doesnotexist` does not exist",
+		},
+		"RunEntry": {
+			err:    func(c Config) error { _, _, err := c.RunEntry("doesnotexist"); return err },
+			target: ErrEntryNotFound,
+			name:   "doesnotexist",
+			msg:    "config `doesnotexist` does not exist, see the configs with `sd-local config list`",
+		},
+		"AddEntry": {
+			err:    func(c Config) error { return c.AddEntry("default", dummyEntry()) },
+			target: ErrEntryExists,
+			name:   "default",
+			msg:    "config `default` already exists",
+		},
+		"DeleteEntry of the current entry": {
+			err:    func(c Config) error { return c.DeleteEntry("default") },
+			target: ErrCurrentEntry,
+			name:   "default",
+			msg:    "config `default` is current config",
+		},
+		"DeleteEntry of the locked entry": {
+			err:    func(c Config) error { return c.DeleteEntry("prod") },
+			target: ErrEntryLocked,
+			name:   "prod",
+			msg:    "entry `prod` is locked; unlock first",
+		},
+		"SetCurrent": {
+			err:    func(c Config) error { return c.SetCurrent("doesnotexist") },
+			target: ErrEntryNotFound,
+			name:   "doesnotexist",
+			msg:    "config `doesnotexist` does not exist",
+		},
+	}
+
+	for name, tt := range testCases {
+		t.Run(name, func(t *testing.T) {
+			err := tt.err(newConfig())
+			assert.True(t, errors.Is(err, tt.target))
+			assert.Equal(t, tt.msg, err.Error())
+
+			var entryErr *EntryError
+			assert.True(t, errors.As(err, &entryErr))
+			assert.Equal(t, tt.name, entryErr.Name)
+		})
+	}
+}
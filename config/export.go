@@ -0,0 +1,85 @@
+package config
+
+import (
+	"io"
+
+	"github.com/go-yaml/yaml"
+)
+
+// Export writes Config in YAML format to w.
+// The values overridden by environment variables are not exported, and tokens are left empty if noSecrets is true.
+// The tokens of all the entries stored in the OS keychain are read to be exported with them.
+func (c *Config) Export(w io.Writer, noSecrets bool) error {
+	if !noSecrets {
+		for _, name := range c.EntryNames() {
+			if err := c.resolveKeychainToken(name); err != nil {
+				return err
+			}
+		}
+	}
+
+	return c.withoutEnvOverrides(func() error {
+		exported := Config{
+			Version:  c.Version,
+			Defaults: c.Defaults,
+			Entries:  make(map[string]*Entry, len(c.Entries)),
+			Current:  c.Current,
+		}
+		for name, entry := range c.Entries {
+			clone := entry.Clone()
+			if noSecrets {
+				clone.Token = ""
+			}
+			exported.Entries[name] = clone
+		}
+
+		return yaml.NewEncoder(w).Encode(exported)
+	})
+}
+
+// Import reads the config exported by Export from r and merges it into Config.
+// The current config is switched to the imported one only if it did not exist before the import.
+func (c *Config) Import(r io.Reader, overwrite bool) error {
+	imported, _, err := parse(r, formatYAML)
+	if err != nil {
+		return err
+	}
+
+	return c.Merge(imported, overwrite)
+}
+
+// Merge adds the entries of `other` to Config.
+// It fails on name collisions and leaves Config unchanged unless `overwrite` is true.
+// The defaults of `other` are taken only if Config has none.
+func (c *Config) Merge(other Config, overwrite bool) error {
+	for _, name := range other.EntryNames() {
+		if _, exist := c.Entries[name]; !exist {
+			continue
+		}
+		if !overwrite {
+			return entryExists(name)
+		}
+		if err := c.CheckUnlocked(name); err != nil {
+			return err
+		}
+	}
+
+	_, currentExists := c.Entries[other.Current]
+
+	for name, entry := range other.Entries {
+		c.Entries[name] = entry
+	}
+	c.nameLockedEntries()
+
+	if c.Defaults == nil {
+		c.Defaults = other.Defaults
+	}
+
+	if other.Current != "" && !currentExists {
+		if _, exist := other.Entries[other.Current]; exist {
+			c.Current = other.Current
+		}
+	}
+
+	return nil
+}
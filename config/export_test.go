@@ -0,0 +1,169 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/go-yaml/yaml"
+	"github.com/stretchr/testify/assert"
+	"github.com/zalando/go-keyring"
+)
+
+func TestConfigExport(t *testing.T) {
+	cases := map[string]struct {
+		noSecrets   bool
+		expectToken string
+	}{
+		"success": {
+			noSecrets:   false,
+			expectToken: "dummy_token",
+		},
+		"success without secrets": {
+			noSecrets:   true,
+			expectToken: "",
+		},
+	}
+
+	for name, test := range cases {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			config := dummyConfig()
+			buf := bytes.NewBuffer(nil)
+
+			err := config.Export(buf, test.noSecrets)
+			assert.Nil(t, err)
+
+			actual := Config{}
+			err = yaml.Unmarshal(buf.Bytes(), &actual)
+			assert.Nil(t, err)
+
+			expected := dummyConfig()
+			expected.Entries["default"].Token = test.expectToken
+			assert.Equal(t, expected, actual)
+			assert.Equal(t, "dummy_token", config.Entries["default"].Token)
+		})
+	}
+}
+
+func TestConfigExportWithKeychain(t *testing.T) {
+	keyring.MockInit()
+	cnfPath := writeKeychainConfig(t)
+	defer os.Remove(cnfPath)
+	if err := keyring.Set("sd-local:other", "token", "other_token"); err != nil {
+		t.Fatal(err)
+	}
+	defer keyring.Delete("sd-local:other", "token")
+
+	config, err := New(cnfPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer config.Close()
+	buf := bytes.NewBuffer(nil)
+
+	// the token of the entry which is not current is exported as well
+	err = config.Export(buf, false)
+	assert.Nil(t, err)
+
+	actual := Config{}
+	err = yaml.Unmarshal(buf.Bytes(), &actual)
+	assert.Nil(t, err)
+	assert.Equal(t, "dummy_token", actual.Entries["default"].Token)
+	assert.Equal(t, "other_token", actual.Entries["other"].Token)
+	assert.Equal(t, TokenSourceKeychain, actual.Entries["other"].TokenSource)
+}
+
+func TestConfigMerge(t *testing.T) {
+	cases := map[string]struct {
+		other        Config
+		overwrite    bool
+		expectConfig Config
+		expectErr    error
+	}{
+		"success to merge new entries": {
+			other: Config{
+				Entries: map[string]*Entry{
+					"prod": dummyEntry(),
+				},
+				Current: "prod",
+			},
+			expectConfig: Config{
+				Entries: map[string]*Entry{
+					"default": dummyEntry(),
+					"test":    DefaultEntry(),
+					"prod":    dummyEntry(),
+				},
+				Current: "prod",
+			},
+		},
+		"success to keep current which exists locally": {
+			other: Config{
+				Entries: map[string]*Entry{
+					"prod": dummyEntry(),
+				},
+				Current: "test",
+			},
+			expectConfig: Config{
+				Entries: map[string]*Entry{
+					"default": dummyEntry(),
+					"test":    DefaultEntry(),
+					"prod":    dummyEntry(),
+				},
+				Current: "default",
+			},
+		},
+		"success to overwrite entries": {
+			other: Config{
+				Entries: map[string]*Entry{
+					"test": dummyEntry(),
+				},
+				Current: "test",
+			},
+			overwrite: true,
+			expectConfig: Config{
+				Entries: map[string]*Entry{
+					"default": dummyEntry(),
+					"test":    dummyEntry(),
+				},
+				Current: "default",
+			},
+		},
+		"failure by the name that exists": {
+			other: Config{
+				Entries: map[string]*Entry{
+					"prod": dummyEntry(),
+					"test": dummyEntry(),
+				},
+				Current: "prod",
+			},
+			expectConfig: Config{
+				Entries: map[string]*Entry{
+					"default": dummyEntry(),
+					"test":    DefaultEntry(),
+				},
+				Current: "default",
+			},
+			expectErr: entryExists("test"),
+		},
+	}
+
+	for name, test := range cases {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			config := Config{
+				Entries: map[string]*Entry{
+					"default": dummyEntry(),
+					"test":    DefaultEntry(),
+				},
+				Current: "default",
+			}
+
+			err := config.Merge(test.other, test.overwrite)
+			assert.Equal(t, test.expectErr, err)
+			assert.Equal(t, test.expectConfig, config)
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// newFileMode is the mode of the config file created by sd-local, which is the one of os.Create with the common umask
+const newFileMode = 0644
+
+// fileLock is the lock of the config file held by the process
+type fileLock struct {
+	file *os.File
+	// count is the number of the holders in the process, which share the lock
+	count int
+}
+
+// fileLocks are the locks held by the process by the paths to lock
+var (
+	fileLocksMutex sync.Mutex
+	fileLocks      = map[string]*fileLock{}
+)
+
+// resolvePath returns the absolute path of the config file whose symbolic links are resolved,
+// so that the target of the link is written instead of replacing the link with a regular file
+func resolvePath(path string) string {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	return path
+}
+
+// lockFilePath returns the lock file next to the config file, which is locked instead of the config file
+// because the config file is replaced by rename on every write
+func lockFilePath(path string) string {
+	dir, base := filepath.Split(path)
+	return filepath.Join(dir, "."+base+".lock")
+}
+
+// lockPath takes the lock of the config file shared with the other processes of sd-local.
+// The lock is reentrant in the process, so that the config loaded by New can be saved while New holds the lock.
+func lockPath(path string) error {
+	path = lockFilePath(resolvePath(path))
+	fileLocksMutex.Lock()
+	defer fileLocksMutex.Unlock()
+
+	l, ok := fileLocks[path]
+	if !ok {
+		f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, newFileMode)
+		if err != nil {
+			return fmt.Errorf("failed to lock config file: %v", err)
+		}
+		if err := lockFile(f); err != nil {
+			f.Close()
+			return fmt.Errorf("failed to lock config file: %v", err)
+		}
+		l = &fileLock{file: f}
+		fileLocks[path] = l
+	}
+	l.count++
+	return nil
+}
+
+// unlockPath releases the lock of the config file taken by lockPath
+func unlockPath(path string) {
+	path = lockFilePath(resolvePath(path))
+	fileLocksMutex.Lock()
+	defer fileLocksMutex.Unlock()
+
+	l, ok := fileLocks[path]
+	if !ok {
+		return
+	}
+	l.count--
+	if l.count == 0 {
+		_ = unlockFile(l.file)
+		l.file.Close()
+		delete(fileLocks, path)
+	}
+}
+
+// writeFile writes the content into a temporary file in the same directory and renames it to the path,
+// so the file is never partially written even if the process is killed. The mode of the existing file is kept,
+// and the symbolic link is kept by writing its target.
+func writeFile(path string, content []byte) (err error) {
+	path = resolvePath(path)
+	mode := os.FileMode(newFileMode)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	dir, base := filepath.Split(path)
+	tmp, err := ioutil.TempFile(dir, "."+base+".tmp")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	if _, err := bytes.NewReader(content).WriteTo(tmp); err != nil {
+		return err
+	}
+	if err := tmp.Chmod(mode); err != nil {
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// encodeFile writes the config into the file in the format of its extension while the file is locked,
+// so that the concurrent writes by the other processes of sd-local don't clobber each other.
+func encodeFile(path string, v interface{}) error {
+	var buf bytes.Buffer
+	if err := formatOf(path).encode(&buf, v); err != nil {
+		return err
+	}
+
+	if err := lockPath(path); err != nil {
+		return err
+	}
+	defer unlockPath(path)
+	return writeFile(path, buf.Bytes())
+}
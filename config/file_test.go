@@ -0,0 +1,191 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	t.Run("success with the mode kept", func(t *testing.T) {
+		path := filepath.Join(dir, "config")
+		if err := ioutil.WriteFile(path, []byte("old"), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chmod(path, 0600); err != nil {
+			t.Fatal(err)
+		}
+
+		err := writeFile(path, []byte("new"))
+		assert.Nil(t, err)
+		actual, _ := ioutil.ReadFile(path)
+		assert.Equal(t, "new", string(actual))
+		info, _ := os.Stat(path)
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+		files, _ := ioutil.ReadDir(dir)
+		assert.Equal(t, 1, len(files), "the temporary file must not be left")
+	})
+
+	t.Run("success with new file", func(t *testing.T) {
+		path := filepath.Join(dir, "new")
+		err := writeFile(path, []byte("new"))
+		assert.Nil(t, err)
+		info, _ := os.Stat(path)
+		assert.Equal(t, os.FileMode(newFileMode), info.Mode().Perm())
+	})
+
+	t.Run("success with the symbolic link kept", func(t *testing.T) {
+		target := filepath.Join(dir, "target")
+		if err := ioutil.WriteFile(target, []byte("old"), 0600); err != nil {
+			t.Fatal(err)
+		}
+		link := filepath.Join(dir, "link")
+		if err := os.Symlink(target, link); err != nil {
+			t.Fatal(err)
+		}
+
+		err := writeFile(link, []byte("new"))
+		assert.Nil(t, err)
+		info, _ := os.Lstat(link)
+		assert.True(t, info.Mode()&os.ModeSymlink != 0)
+		actual, _ := ioutil.ReadFile(target)
+		assert.Equal(t, "new", string(actual))
+	})
+
+	t.Run("failure by missing directory", func(t *testing.T) {
+		err := writeFile(filepath.Join(dir, "missing", "config"), []byte("new"))
+		assert.NotNil(t, err)
+	})
+}
+
+func TestEncodeFileConcurrently(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "config")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			entry := DefaultEntry()
+			entry.APIURL = fmt.Sprintf("https://api-%d.screwdriver.cd", i)
+			err := encodeFile(path, Config{Version: currentVersion, Entries: map[string]*Entry{"default": entry}, Current: "default"})
+			assert.Nil(t, err)
+		}(i)
+	}
+	wg.Wait()
+
+	c, err := New(path)
+	assert.Nil(t, err)
+	assert.Contains(t, c.Entries["default"].APIURL, "https://api-")
+	// only the lock file is left besides the config file
+	files, _ := ioutil.ReadDir(dir)
+	assert.Equal(t, 2, len(files))
+	_, err = os.Stat(filepath.Join(dir, ".config.lock"))
+	assert.Nil(t, err)
+}
+
+func TestConfigClose(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "config")
+	lock := lockFilePath(resolvePath(path))
+
+	c1, err := New(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c2, err := New(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 2, fileLocks[lock].count)
+
+	// closing twice releases only the lock held by the config
+	c1.Close()
+	c1.Close()
+	assert.Equal(t, 1, fileLocks[lock].count)
+
+	// the lock is released by Save as well
+	assert.Nil(t, c2.Save())
+	c2.Close()
+	_, held := fileLocks[lock]
+	assert.False(t, held)
+}
+
+// TestHelperProcess adds the entry of GO_TEST_ENTRY to the config of GO_TEST_CONFIG in the process of another sd-local
+func TestHelperProcess(t *testing.T) {
+	if os.Getenv("GO_WANT_HELPER_PROCESS") != "1" {
+		return
+	}
+	c, err := New(os.Getenv("GO_TEST_CONFIG"))
+	if err != nil {
+		fmt.Fprint(os.Stderr, err)
+		os.Exit(1)
+	}
+	// the other processes load the config in the meantime without the lock
+	time.Sleep(100 * time.Millisecond)
+	if err := c.AddEntry(os.Getenv("GO_TEST_ENTRY"), DefaultEntry()); err != nil {
+		fmt.Fprint(os.Stderr, err)
+		os.Exit(1)
+	}
+	if err := c.Save(); err != nil {
+		fmt.Fprint(os.Stderr, err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+func TestSaveConcurrentlyByProcesses(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "config")
+
+	const processes = 5
+	var wg sync.WaitGroup
+	for i := 0; i < processes; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			cmd := exec.Command(os.Args[0], "-test.run=TestHelperProcess")
+			cmd.Env = []string{"GO_WANT_HELPER_PROCESS=1", "GO_TEST_CONFIG=" + path, fmt.Sprintf("GO_TEST_ENTRY=entry-%d", i)}
+			out, err := cmd.CombinedOutput()
+			assert.Nil(t, err, string(out))
+		}(i)
+	}
+	wg.Wait()
+
+	// none of the entries added by the processes is lost
+	c, err := New(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	for i := 0; i < processes; i++ {
+		assert.True(t, c.HasEntry(fmt.Sprintf("entry-%d", i)))
+	}
+}
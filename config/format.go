@@ -0,0 +1,57 @@
+package config
+
+import (
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/go-yaml/yaml"
+)
+
+// format is the file format of the config file
+type format string
+
+const (
+	formatYAML format = "yaml"
+	formatTOML format = "toml"
+)
+
+// formatOf detects the format from the extension of the config file.
+// YAML is used unless the extension is `.toml` for backward compatibility.
+func formatOf(path string) format {
+	if strings.EqualFold(filepath.Ext(path), ".toml") {
+		return formatTOML
+	}
+	return formatYAML
+}
+
+func (f format) encode(w io.Writer, v interface{}) error {
+	if f == formatTOML {
+		return toml.NewEncoder(w).Encode(v)
+	}
+	return yaml.NewEncoder(w).Encode(v)
+}
+
+// decode decodes the config into a raw YAML document to migrate it regardless of the format
+func (f format) decode(r io.Reader) (map[interface{}]interface{}, error) {
+	var doc map[interface{}]interface{}
+
+	if f == formatTOML {
+		var m map[string]interface{}
+		_, err := toml.DecodeReader(r, &m)
+		if err != nil {
+			return nil, err
+		}
+
+		b, err := yaml.Marshal(m)
+		if err != nil {
+			return nil, err
+		}
+		err = yaml.Unmarshal(b, &doc)
+		return doc, err
+	}
+
+	err := yaml.NewDecoder(r).Decode(&doc)
+	return doc, err
+}
@@ -0,0 +1,78 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatOf(t *testing.T) {
+	cases := map[string]format{
+		"/home/user/.sdlocal/config":      formatYAML,
+		"/home/user/.sdlocal/config.yaml": formatYAML,
+		"/home/user/.sdlocal/config.toml": formatTOML,
+		"/home/user/.sdlocal/config.TOML": formatTOML,
+	}
+
+	for path, expected := range cases {
+		assert.Equal(t, expected, formatOf(path), path)
+	}
+}
+
+func TestNewConfigWithTOML(t *testing.T) {
+	t.Run("success to parse toml", func(t *testing.T) {
+		cnfPath := filepath.Join(testDir, "successConfig.toml")
+
+		actual, err := New(cnfPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected := dummyConfig()
+		expected.Version = 1
+		expected.filePath = cnfPath
+		locked := true
+		expected.locked = &locked
+		assert.Equal(t, expected, actual)
+	})
+
+	t.Run("success to create and save toml", func(t *testing.T) {
+		rand.Seed(time.Now().UnixNano())
+		cnfPath := filepath.Join(testDir, fmt.Sprintf("%vconfig.toml", rand.Int()))
+		defer os.Remove(cnfPath)
+
+		c, err := New(cnfPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, DefaultEntry(), c.Entries["default"])
+
+		err = c.AddEntry("test", dummyEntry())
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = c.Save()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		b, err := ioutil.ReadFile(cnfPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Contains(t, string(b), `current = "default"`)
+
+		actual, err := New(cnfPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, dummyEntry(), actual.Entries["test"])
+		assert.Equal(t, DefaultEntry(), actual.Entries["default"])
+	})
+}
@@ -0,0 +1,119 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/zalando/go-keyring"
+)
+
+const (
+	// TokenSourceKeychain is the token-source value to store the token in the OS keychain
+	TokenSourceKeychain = "keychain"
+	// MaskedKeychainToken is displayed instead of the token stored in the OS keychain
+	MaskedKeychainToken = "***keychain***"
+
+	keychainServicePrefix = "sd-local"
+	keychainUser          = "token"
+)
+
+var (
+	keyringGet    = keyring.Get
+	keyringSet    = keyring.Set
+	keyringDelete = keyring.Delete
+)
+
+func keychainService(name string) string {
+	return fmt.Sprintf("%s:%s", keychainServicePrefix, name)
+}
+
+// UseKeychain reports whether the token of the Entry is stored in the OS keychain
+func (e *Entry) UseKeychain() bool {
+	return e.TokenSource == TokenSourceKeychain
+}
+
+// resolveKeychainToken reads the token of the entry from the OS keychain unless it is read already.
+// The tokens are read only for the entries in use not to access the keychain for the other entries.
+func (c *Config) resolveKeychainToken(name string) error {
+	entry, exists := c.Entries[name]
+	if !exists || !entry.UseKeychain() {
+		return nil
+	}
+	if entry.keychainResolved {
+		return nil
+	}
+
+	token, err := keyringGet(keychainService(name), keychainUser)
+	if err == keyring.ErrNotFound {
+		token = ""
+	} else if err != nil {
+		return fmt.Errorf("failed to read token of config `%s` from keychain, remove `token-source` from %s to store the token in it: %v", name, c.filePath, err)
+	}
+
+	entry.Token = token
+	entry.keychainResolved = true
+	if c.keychainTokens == nil {
+		c.keychainTokens = make(map[string]string)
+	}
+	c.keychainTokens[name] = token
+	return nil
+}
+
+// removeKeychainToken removes the token of the entry from the OS keychain on Save,
+// after the entry is deleted or renamed
+func (c *Config) removeKeychainToken(name string, entry *Entry) {
+	if !entry.UseKeychain() {
+		return
+	}
+	delete(c.keychainTokens, name)
+	c.removedKeychainTokens = append(c.removedKeychainTokens, name)
+}
+
+// withoutKeychainTokens calls f while the tokens of entries using the keychain are removed.
+// The changed tokens are written to the OS keychain beforehand, and the cleared tokens and the tokens of the deleted or renamed entries
+// are removed from it.
+func (c *Config) withoutKeychainTokens(f func() error) error {
+	for _, name := range c.removedKeychainTokens {
+		err := keyringDelete(keychainService(name), keychainUser)
+		if err != nil && err != keyring.ErrNotFound {
+			return fmt.Errorf("failed to remove token of config `%s` from keychain: %v", name, err)
+		}
+	}
+	c.removedKeychainTokens = nil
+
+	for name, entry := range c.Entries {
+		if !entry.UseKeychain() {
+			continue
+		}
+
+		token := entry.Token
+		if !entry.keychainResolved && token == "" {
+			// the token which is not read yet is left in the keychain as it is
+			continue
+		}
+		if loaded, ok := c.keychainTokens[name]; !ok || loaded != token {
+			if token != "" {
+				err := keyringSet(keychainService(name), keychainUser, token)
+				if err != nil {
+					return fmt.Errorf("failed to write token of config `%s` to keychain: %v", name, err)
+				}
+			} else {
+				// the cleared token is removed not to be read again on the next load
+				err := keyringDelete(keychainService(name), keychainUser)
+				if err != nil && err != keyring.ErrNotFound {
+					return fmt.Errorf("failed to remove token of config `%s` from keychain: %v", name, err)
+				}
+			}
+			if c.keychainTokens == nil {
+				c.keychainTokens = make(map[string]string)
+			}
+			c.keychainTokens[name] = token
+		}
+
+		entry.Token = ""
+		defer func(entry *Entry, token string) {
+			entry.Token = token
+		}(entry, token)
+	}
+
+	return f()
+}
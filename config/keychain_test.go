@@ -0,0 +1,248 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zalando/go-keyring"
+)
+
+func TestKeychainToken(t *testing.T) {
+	keyring.MockInit()
+
+	t.Run("success to move token to keychain", func(t *testing.T) {
+		cnfPath := copyTestConfig(t, "successConfig")
+		defer os.Remove(cnfPath)
+
+		c, err := New(cnfPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = c.Entries["default"].Set("token-source", TokenSourceKeychain)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = c.Save()
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, "dummy_token", c.Entries["default"].Token)
+
+		token, err := keyring.Get("sd-local:default", "token")
+		assert.Nil(t, err)
+		assert.Equal(t, "dummy_token", token)
+
+		actual, err := New(cnfPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, "dummy_token", actual.Entries["default"].Token)
+		assert.Equal(t, TokenSourceKeychain, actual.Entries["default"].TokenSource)
+	})
+
+	t.Run("success to set token to keychain", func(t *testing.T) {
+		cnfPath := copyTestConfig(t, "successConfig")
+		defer os.Remove(cnfPath)
+
+		c, err := New(cnfPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		entry := c.Entries["default"]
+		_ = entry.Set("token-source", TokenSourceKeychain)
+		_ = entry.Set("token", "new_token")
+		err = c.Save()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		token, err := keyring.Get("sd-local:default", "token")
+		assert.Nil(t, err)
+		assert.Equal(t, "new_token", token)
+	})
+
+	t.Run("success to remove cleared token from keychain", func(t *testing.T) {
+		cnfPath := writeKeychainConfig(t)
+		defer os.Remove(cnfPath)
+
+		c, err := New(cnfPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = c.Entries["default"].Set("token", "new_token")
+		if err := c.Save(); err != nil {
+			t.Fatal(err)
+		}
+
+		c, err = New(cnfPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, "new_token", c.Entries["default"].Token)
+		_ = c.Entries["default"].Set("token", "")
+		assert.Nil(t, c.Save())
+
+		_, err = keyring.Get("sd-local:default", "token")
+		assert.Equal(t, keyring.ErrNotFound, err)
+		actual, err := New(cnfPath)
+		assert.Nil(t, err)
+		assert.Equal(t, "", actual.Entries["default"].Token)
+	})
+
+	t.Run("failure by unavailable keychain", func(t *testing.T) {
+		cnfPath := copyTestConfig(t, "successConfig")
+		defer os.Remove(cnfPath)
+
+		c, err := New(cnfPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = c.Entries["default"].Set("token-source", TokenSourceKeychain)
+
+		defKeyringSet := keyringSet
+		defer func() {
+			keyringSet = defKeyringSet
+		}()
+		keyringSet = func(service, user, password string) error {
+			return errors.New("no keyring backend")
+		}
+
+		err = c.Save()
+		assert.Equal(t, errors.New("failed to write token of config `default` to keychain: no keyring backend"), err)
+
+		// the config file must not be truncated by the failure
+		actual, err := New(cnfPath)
+		assert.Nil(t, err)
+		assert.Equal(t, dummyEntry(), actual.Entries["default"])
+	})
+
+	t.Run("failure to read token from unavailable keychain", func(t *testing.T) {
+		cnfPath := copyTestConfig(t, "successConfig")
+		defer os.Remove(cnfPath)
+
+		c, err := New(cnfPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = c.Entries["default"].Set("token-source", TokenSourceKeychain)
+		err = c.Save()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		defKeyringGet := keyringGet
+		defer func() {
+			keyringGet = defKeyringGet
+		}()
+		keyringGet = func(service, user string) (string, error) {
+			return "", errors.New("no keyring backend")
+		}
+
+		_, err = New(cnfPath)
+		assert.Equal(t, fmt.Errorf("failed to read token of config `default` from keychain, remove `token-source` from %s to store the token in it: no keyring backend", cnfPath), err)
+	})
+	t.Run("success to read token of another entry lazily", func(t *testing.T) {
+		cnfPath := writeKeychainConfig(t)
+		defer os.Remove(cnfPath)
+		if err := keyring.Set("sd-local:other", "token", "other_token"); err != nil {
+			t.Fatal(err)
+		}
+
+		c, err := New(cnfPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, "", c.Entries["other"].Token)
+
+		name, entry, err := c.RunEntry("other")
+		assert.Nil(t, err)
+		assert.Equal(t, "other", name)
+		assert.Equal(t, "other_token", entry.Token)
+	})
+
+	t.Run("success to move token of renamed entry", func(t *testing.T) {
+		cnfPath := writeKeychainConfig(t)
+		defer os.Remove(cnfPath)
+		if err := keyring.Set("sd-local:other", "token", "other_token"); err != nil {
+			t.Fatal(err)
+		}
+
+		c, err := New(cnfPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Nil(t, c.RenameEntry("other", "renamed"))
+		assert.Nil(t, c.Save())
+
+		token, err := keyring.Get("sd-local:renamed", "token")
+		assert.Nil(t, err)
+		assert.Equal(t, "other_token", token)
+		_, err = keyring.Get("sd-local:other", "token")
+		assert.Equal(t, keyring.ErrNotFound, err)
+		keyring.Delete("sd-local:renamed", "token")
+	})
+
+	t.Run("success to copy token of copied entry", func(t *testing.T) {
+		cnfPath := writeKeychainConfig(t)
+		defer os.Remove(cnfPath)
+		if err := keyring.Set("sd-local:other", "token", "other_token"); err != nil {
+			t.Fatal(err)
+		}
+
+		c, err := New(cnfPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Nil(t, c.CopyEntry("other", "copied"))
+		assert.Nil(t, c.Save())
+
+		for _, name := range []string{"other", "copied"} {
+			token, err := keyring.Get("sd-local:"+name, "token")
+			assert.Nil(t, err)
+			assert.Equal(t, "other_token", token)
+		}
+		keyring.Delete("sd-local:copied", "token")
+	})
+
+	t.Run("success to remove token of deleted entry", func(t *testing.T) {
+		cnfPath := writeKeychainConfig(t)
+		defer os.Remove(cnfPath)
+		if err := keyring.Set("sd-local:other", "token", "other_token"); err != nil {
+			t.Fatal(err)
+		}
+
+		c, err := New(cnfPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Nil(t, c.DeleteEntry("other"))
+		assert.Nil(t, c.Save())
+
+		_, err = keyring.Get("sd-local:other", "token")
+		assert.Equal(t, keyring.ErrNotFound, err)
+	})
+}
+
+// writeKeychainConfig writes the config whose entries default and other store the tokens in the keychain
+func writeKeychainConfig(t *testing.T) string {
+	t.Helper()
+	cnfPath := copyTestConfig(t, "successConfig")
+	c, err := New(cnfPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Entries["default"].TokenSource = TokenSourceKeychain
+	c.Entries["other"] = &Entry{
+		APIURL:      "api-url",
+		StoreURL:    "store-api-url",
+		TokenSource: TokenSourceKeychain,
+		Launcher:    Launcher{Version: "latest", Image: "screwdrivercd/launcher"},
+	}
+	if err := c.Save(); err != nil {
+		t.Fatal(err)
+	}
+	return cnfPath
+}
@@ -0,0 +1,39 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// LauncherProfile returns the launcher profile named `name` in the launchers of the entry, or the launcher of the entry if name is empty.
+// The version and the image which are not set in the profile are taken from the launcher of the entry.
+func (e *Entry) LauncherProfile(name string) (Launcher, error) {
+	if name == "" {
+		return e.Launcher, nil
+	}
+
+	profile, exists := e.Launchers[name]
+	if !exists {
+		if len(e.Launchers) == 0 {
+			return Launcher{}, fmt.Errorf("launcher profile `%s` does not exist, add it to launchers of the config", name)
+		}
+		names := make([]string, 0, len(e.Launchers))
+		for n := range e.Launchers {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return Launcher{}, fmt.Errorf("launcher profile `%s` does not exist: must be one of %s", name, strings.Join(names, ", "))
+	}
+
+	if profile.Version == "" {
+		profile.Version = e.Launcher.Version
+	}
+	if profile.Image == "" {
+		profile.Image = e.Launcher.Image
+	}
+	if err := profile.Validate(); err != nil {
+		return Launcher{}, fmt.Errorf("launcher profile `%s` is invalid: %v", name, err)
+	}
+	return profile, nil
+}
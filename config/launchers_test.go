@@ -0,0 +1,74 @@
+package config
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEntryLauncherProfile(t *testing.T) {
+	entry := &Entry{
+		Launcher: Launcher{Version: "stable", Image: "screwdrivercd/launcher"},
+		Launchers: map[string]Launcher{
+			"v5":      {Version: "v5.0.0"},
+			"mirror":  {Version: "v6.0.0", Image: "registry.example.com/screwdrivercd/launcher"},
+			"invalid": {Version: "v6:0"},
+		},
+	}
+
+	cases := map[string]struct {
+		entry     *Entry
+		name      string
+		expect    Launcher
+		expectErr error
+	}{
+		"success without name": {
+			entry:  entry,
+			name:   "",
+			expect: Launcher{Version: "stable", Image: "screwdrivercd/launcher"},
+		},
+		"success with the image of the entry": {
+			entry:  entry,
+			name:   "v5",
+			expect: Launcher{Version: "v5.0.0", Image: "screwdrivercd/launcher"},
+		},
+		"success with the version and the image": {
+			entry:  entry,
+			name:   "mirror",
+			expect: Launcher{Version: "v6.0.0", Image: "registry.example.com/screwdrivercd/launcher"},
+		},
+		"failure by invalid profile": {
+			entry:     entry,
+			name:      "invalid",
+			expectErr: fmt.Errorf("launcher profile `invalid` is invalid: invalid launcher-version v6:0: must be a valid image tag"),
+		},
+		"failure by unknown profile": {
+			entry:     entry,
+			name:      "v4",
+			expectErr: fmt.Errorf("launcher profile `v4` does not exist: must be one of invalid, mirror, v5"),
+		},
+		"failure without profiles": {
+			entry:     &Entry{Launcher: Launcher{Version: "stable", Image: "screwdrivercd/launcher"}},
+			name:      "v5",
+			expectErr: fmt.Errorf("launcher profile `v5` does not exist, add it to launchers of the config"),
+		},
+	}
+
+	for name, test := range cases {
+		t.Run(name, func(t *testing.T) {
+			actual, err := test.entry.LauncherProfile(test.name)
+			assert.Equal(t, test.expectErr, err)
+			assert.Equal(t, test.expect, actual)
+		})
+	}
+}
+
+func TestEntryCloneLaunchers(t *testing.T) {
+	entry := &Entry{Launchers: map[string]Launcher{"v5": {Version: "v5.0.0"}}}
+
+	clone := entry.Clone()
+	clone.Launchers["v5"] = Launcher{Version: "v5.1.0"}
+
+	assert.Equal(t, "v5.0.0", entry.Launchers["v5"].Version)
+}
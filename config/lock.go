@@ -0,0 +1,44 @@
+package config
+
+// Lock marks the Entry named `name` as locked.
+// A locked entry cannot be changed, renamed, deleted or overwritten until it is unlocked.
+func (c *Config) Lock(name string) error {
+	entry, err := c.Entry(name)
+	if err != nil {
+		return err
+	}
+
+	entry.Locked = true
+	entry.lockedName = name
+	return nil
+}
+
+// Unlock marks the Entry named `name` as unlocked
+func (c *Config) Unlock(name string) error {
+	entry, err := c.Entry(name)
+	if err != nil {
+		return err
+	}
+
+	entry.Locked = false
+	entry.lockedName = ""
+	return nil
+}
+
+// CheckUnlocked returns an error if the Entry named `name` is locked
+func (c *Config) CheckUnlocked(name string) error {
+	entry, exist := c.Entries[name]
+	if exist && entry.Locked {
+		return newEntryError(ErrEntryLocked, name, "entry `%s` is locked; unlock first")
+	}
+	return nil
+}
+
+// nameLockedEntries keeps the names of the locked entries, which are loaded or added to Config, to name them in the errors of Set
+func (c *Config) nameLockedEntries() {
+	for name, entry := range c.Entries {
+		if entry.Locked {
+			entry.lockedName = name
+		}
+	}
+}
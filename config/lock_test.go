@@ -0,0 +1,106 @@
+package config
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func lockedConfig() Config {
+	c := Config{
+		Entries: map[string]*Entry{
+			"default": dummyEntry(),
+			"prod":    dummyEntry(),
+		},
+		Current: "default",
+	}
+	_ = c.Lock("prod")
+	return c
+}
+
+func TestConfigLock(t *testing.T) {
+	t.Run("success to lock and unlock", func(t *testing.T) {
+		config := dummyConfig()
+
+		err := config.Lock("default")
+		assert.Nil(t, err)
+		assert.True(t, config.Entries["default"].Locked)
+
+		err = config.Unlock("default")
+		assert.Nil(t, err)
+		assert.False(t, config.Entries["default"].Locked)
+	})
+
+	t.Run("failure by the name that does not exist", func(t *testing.T) {
+		config := dummyConfig()
+
+		assert.Equal(t, entryNotFound("doesnotexist"), config.Lock("doesnotexist"))
+		assert.Equal(t, entryNotFound("doesnotexist"), config.Unlock("doesnotexist"))
+	})
+}
+
+func TestConfigLockedEntry(t *testing.T) {
+	lockedErr := newEntryError(ErrEntryLocked, "prod", "entry `%s` is locked; unlock first")
+
+	t.Run("failure to set", func(t *testing.T) {
+		config := lockedConfig()
+
+		err := config.Entries["prod"].Set("token", "new_token")
+		assert.Equal(t, lockedErr, err)
+		assert.True(t, errors.Is(err, ErrEntryLocked))
+		assert.Equal(t, lockedConfig(), config)
+	})
+
+	t.Run("failure to set merged entry", func(t *testing.T) {
+		config := dummyConfig()
+		imported := dummyEntry()
+		imported.Locked = true
+		other := Config{
+			Entries: map[string]*Entry{
+				"imported": imported,
+			},
+		}
+		if err := config.Merge(other, false); err != nil {
+			t.Fatal(err)
+		}
+
+		err := config.Entries["imported"].Set("token", "new_token")
+		assert.Equal(t, newEntryError(ErrEntryLocked, "imported", "entry `%s` is locked; unlock first"), err)
+	})
+
+	t.Run("failure to delete", func(t *testing.T) {
+		config := lockedConfig()
+
+		assert.Equal(t, lockedErr, config.DeleteEntry("prod"))
+		assert.Equal(t, lockedConfig(), config)
+	})
+
+	t.Run("failure to rename", func(t *testing.T) {
+		config := lockedConfig()
+
+		assert.Equal(t, lockedErr, config.RenameEntry("prod", "renamed"))
+		assert.Equal(t, lockedConfig(), config)
+	})
+
+	t.Run("failure to overwrite by merge", func(t *testing.T) {
+		config := lockedConfig()
+		other := Config{
+			Entries: map[string]*Entry{
+				"prod": DefaultEntry(),
+			},
+		}
+
+		assert.Equal(t, lockedErr, config.Merge(other, true))
+		assert.Equal(t, lockedConfig(), config)
+	})
+
+	t.Run("success to copy and use", func(t *testing.T) {
+		config := lockedConfig()
+
+		assert.Nil(t, config.CopyEntry("prod", "copied"))
+		assert.False(t, config.Entries["copied"].Locked)
+		assert.Nil(t, config.SetCurrent("prod"))
+		assert.Nil(t, config.CheckUnlocked("default"))
+	})
+}
@@ -0,0 +1,46 @@
+package config
+
+import "fmt"
+
+// migration upgrades a raw config document from a version to the next one.
+type migration func(doc map[interface{}]interface{}) error
+
+var (
+	// currentVersion is the version of the config layout which this sd-local reads and writes.
+	// The config files written before versioning was introduced have no version and are treated as version 1.
+	currentVersion = 1
+
+	// migrations holds the migration from each version to the next one, keyed by the old version.
+	// When the layout changes (e.g. a key is renamed), add a migration here and bump currentVersion.
+	migrations = map[int]migration{}
+)
+
+// migrate upgrades the raw config document to currentVersion and reports whether it was changed.
+func migrate(doc map[interface{}]interface{}) (bool, error) {
+	version := 1
+	if v, ok := doc["version"]; ok {
+		version, ok = v.(int)
+		if !ok {
+			return false, fmt.Errorf("invalid config version: %v", v)
+		}
+	}
+
+	if version > currentVersion {
+		return false, fmt.Errorf("config written by a newer sd-local (version %d), this sd-local supports up to version %d: please update sd-local", version, currentVersion)
+	}
+
+	migrated := false
+	for ; version < currentVersion; version++ {
+		m, ok := migrations[version]
+		if !ok {
+			return false, fmt.Errorf("failed to migrate config from version %d: migration not found", version)
+		}
+		if err := m(doc); err != nil {
+			return false, fmt.Errorf("failed to migrate config from version %d: %v", version, err)
+		}
+		migrated = true
+	}
+	doc["version"] = version
+
+	return migrated, nil
+}
@@ -0,0 +1,110 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-yaml/yaml"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMigrate(t *testing.T) {
+	defVersion, defMigrations := currentVersion, migrations
+	defer func() {
+		currentVersion, migrations = defVersion, defMigrations
+	}()
+
+	// v2 renames `current` to `current-config` for testing
+	currentVersion = 2
+	migrations = map[int]migration{
+		1: func(doc map[interface{}]interface{}) error {
+			doc["current-config"] = doc["current"]
+			delete(doc, "current")
+			return nil
+		},
+	}
+
+	cases := map[string]struct {
+		doc            map[interface{}]interface{}
+		expectDoc      map[interface{}]interface{}
+		expectMigrated bool
+		expectErr      error
+	}{
+		"success to migrate implicit version 1": {
+			doc:            map[interface{}]interface{}{"current": "default"},
+			expectDoc:      map[interface{}]interface{}{"version": 2, "current-config": "default"},
+			expectMigrated: true,
+		},
+		"success to migrate version 1": {
+			doc:            map[interface{}]interface{}{"version": 1, "current": "default"},
+			expectDoc:      map[interface{}]interface{}{"version": 2, "current-config": "default"},
+			expectMigrated: true,
+		},
+		"success with current version": {
+			doc:            map[interface{}]interface{}{"version": 2, "current-config": "default"},
+			expectDoc:      map[interface{}]interface{}{"version": 2, "current-config": "default"},
+			expectMigrated: false,
+		},
+		"failure by newer version": {
+			doc:       map[interface{}]interface{}{"version": 3},
+			expectDoc: map[interface{}]interface{}{"version": 3},
+			expectErr: fmt.Errorf("config written by a newer sd-local (version 3), this sd-local supports up to version 2: please update sd-local"),
+		},
+		"failure by invalid version": {
+			doc:       map[interface{}]interface{}{"version": "v1"},
+			expectDoc: map[interface{}]interface{}{"version": "v1"},
+			expectErr: fmt.Errorf("invalid config version: v1"),
+		},
+	}
+
+	for name, test := range cases {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			migrated, err := migrate(test.doc)
+			assert.Equal(t, test.expectErr, err)
+			assert.Equal(t, test.expectMigrated, migrated)
+			assert.Equal(t, test.expectDoc, test.doc)
+		})
+	}
+}
+
+func TestNewConfigWithVersion(t *testing.T) {
+	t.Run("failure by newer version", func(t *testing.T) {
+		_, err := New(filepath.Join(testDir, "newerVersionConfig"))
+		assert.Equal(t, fmt.Errorf("config written by a newer sd-local (version 100), this sd-local supports up to version 1: please update sd-local"), err)
+	})
+
+	t.Run("success to rewrite migrated config", func(t *testing.T) {
+		defVersion, defMigrations := currentVersion, migrations
+		defer func() {
+			currentVersion, migrations = defVersion, defMigrations
+		}()
+
+		currentVersion = 2
+		migrations = map[int]migration{
+			1: func(doc map[interface{}]interface{}) error { return nil },
+		}
+
+		cnfPath := copyTestConfig(t, "successConfig")
+		defer os.Remove(cnfPath)
+
+		c, err := New(cnfPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, 2, c.Version)
+
+		b, err := ioutil.ReadFile(cnfPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		actual := Config{}
+		err = yaml.Unmarshal(b, &actual)
+		assert.Nil(t, err)
+		assert.Equal(t, 2, actual.Version)
+		assert.Equal(t, dummyEntry(), actual.Entries["default"])
+	})
+}
@@ -0,0 +1,66 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/mitchellh/go-homedir"
+)
+
+// EnvFilePath is the environment variable of the path of the config file
+const EnvFilePath = "SD_LOCAL_CONFIG"
+
+// pathFields are the fields of an Entry holding a path, apart from docker-host which can be a URL.
+// They are stored as they are set and expanded only when the entry is resolved,
+// so that the config file stays portable.
+var pathFields = []func(e *Entry) *string{
+	func(e *Entry) *string { return &e.CABundle },
+	func(e *Entry) *string { return &e.RegistryAuth },
+	func(e *Entry) *string { return &e.Launcher.Archive },
+}
+
+// expandPath expands $VAR and ${VAR} references and a leading ~ to the home directory
+func expandPath(path string) (string, error) {
+	expanded, err := homedir.Expand(os.ExpandEnv(path))
+	if err != nil {
+		return "", fmt.Errorf("failed to expand %s: %v", path, err)
+	}
+	return expanded, nil
+}
+
+// Resolve returns a copy of the Entry whose path-type values are expanded
+func (e *Entry) Resolve() (*Entry, error) {
+	resolved := e.Clone()
+	for _, field := range pathFields {
+		path, err := expandPath(*field(resolved))
+		if err != nil {
+			return nil, err
+		}
+		*field(resolved) = path
+	}
+	host, err := expandDockerHost(resolved.DockerHost)
+	if err != nil {
+		return nil, err
+	}
+	resolved.DockerHost = host
+
+	return resolved, nil
+}
+
+// ResolveFilePath returns the absolute path of the config file, which is `path` if it is not empty,
+// SD_LOCAL_CONFIG if it is set, or ~/.sdlocal/config. ~ and the environment variables in it are expanded.
+func ResolveFilePath(path string) (string, error) {
+	if path == "" {
+		path = os.Getenv(EnvFilePath)
+	}
+	if path == "" {
+		path = filepath.Join("~", ".sdlocal", "config")
+	}
+
+	expanded, err := expandPath(path)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Abs(expanded)
+}
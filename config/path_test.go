@@ -0,0 +1,129 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mitchellh/go-homedir"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEntryResolve(t *testing.T) {
+	defer setEnv(t, map[string]string{
+		"SD_LOCAL_CERTS": "/etc/certs",
+	})()
+
+	home, err := homedir.Dir()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[string]struct {
+		caBundle  string
+		expect    string
+		expectErr error
+	}{
+		"success with empty path": {
+			caBundle: "",
+			expect:   "",
+		},
+		"success with absolute path": {
+			caBundle: "/etc/ssl/ca.pem",
+			expect:   "/etc/ssl/ca.pem",
+		},
+		"success with relative path": {
+			caBundle: "certs/ca.pem",
+			expect:   "certs/ca.pem",
+		},
+		"success with home directory": {
+			caBundle: "~/certs/ca.pem",
+			expect:   filepath.Join(home, "certs", "ca.pem"),
+		},
+		"success with environment variables": {
+			caBundle: "$SD_LOCAL_CERTS/ca.pem",
+			expect:   "/etc/certs/ca.pem",
+		},
+		"success with braced environment variables": {
+			caBundle: "${SD_LOCAL_CERTS}/ca.pem",
+			expect:   "/etc/certs/ca.pem",
+		},
+		"failure by home directory of other user": {
+			caBundle:  "~other/ca.pem",
+			expectErr: fmt.Errorf("failed to expand ~other/ca.pem: cannot expand user-specific home dir"),
+		},
+	}
+
+	for name, test := range cases {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			entry := dummyEntry()
+			entry.CABundle = test.caBundle
+			entry.RegistryAuth = test.caBundle
+			entry.Launcher.Archive = test.caBundle
+
+			actual, err := entry.Resolve()
+			assert.Equal(t, test.expectErr, err)
+			if test.expectErr == nil {
+				assert.Equal(t, test.expect, actual.CABundle)
+				assert.Equal(t, test.expect, actual.RegistryAuth)
+				assert.Equal(t, test.expect, actual.Launcher.Archive)
+			}
+			assert.Equal(t, test.caBundle, entry.CABundle)
+		})
+	}
+}
+
+func TestResolveFilePath(t *testing.T) {
+	home, err := homedir.Dir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[string]struct {
+		path      string
+		env       map[string]string
+		expect    string
+		expectErr error
+	}{
+		"success with default path": {
+			expect: filepath.Join(home, ".sdlocal", "config"),
+		},
+		"success with SD_LOCAL_CONFIG": {
+			env:    map[string]string{EnvFilePath: "/etc/sd-local/config"},
+			expect: "/etc/sd-local/config",
+		},
+		"success with path over SD_LOCAL_CONFIG": {
+			path:   "/project/.sdlocal.yaml",
+			env:    map[string]string{EnvFilePath: "/etc/sd-local/config"},
+			expect: "/project/.sdlocal.yaml",
+		},
+		"success with relative path": {
+			path:   ".sdlocal/config",
+			expect: filepath.Join(cwd, ".sdlocal", "config"),
+		},
+		"success with home directory": {
+			env:    map[string]string{EnvFilePath: "~/sd-local.toml"},
+			expect: filepath.Join(home, "sd-local.toml"),
+		},
+		"failure by home directory of other user": {
+			path:      "~other/config",
+			expectErr: fmt.Errorf("failed to expand ~other/config: cannot expand user-specific home dir"),
+		},
+	}
+
+	for name, test := range cases {
+		t.Run(name, func(t *testing.T) {
+			defer setEnv(t, test.env)()
+
+			actual, err := ResolveFilePath(test.path)
+			assert.Equal(t, test.expectErr, err)
+			assert.Equal(t, test.expect, actual)
+		})
+	}
+}
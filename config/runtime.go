@@ -0,0 +1,31 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// RuntimeDocker runs builds as Docker containers
+	RuntimeDocker = "docker"
+	// RuntimePodman runs builds as Podman containers
+	RuntimePodman = "podman"
+	// RuntimeKubernetes runs builds as Pods of the Kubernetes cluster of the current kubectl context
+	RuntimeKubernetes = "k8s"
+)
+
+// Runtimes are the runtimes which can run builds
+var Runtimes = []string{RuntimeDocker, RuntimePodman, RuntimeKubernetes}
+
+// ValidateRuntime checks that the runtime is supported. Empty means the default runtime.
+func ValidateRuntime(runtime string) error {
+	if runtime == "" {
+		return nil
+	}
+	for _, r := range Runtimes {
+		if runtime == r {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid runtime %s: must be one of %s", runtime, strings.Join(Runtimes, ", "))
+}
@@ -0,0 +1,23 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// ParseTimeout parses the build timeout like "30m". Empty means no timeout and is parsed as 0.
+func ParseTimeout(timeout string) (time.Duration, error) {
+	if timeout == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(timeout)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid timeout %s: must be a positive duration like 30m", timeout)
+	}
+	return d, nil
+}
+
+// BuildTimeout returns the default timeout of the builds run with the entry
+func (e *Entry) BuildTimeout() (time.Duration, error) {
+	return ParseTimeout(e.Timeout)
+}
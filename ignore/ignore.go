@@ -0,0 +1,199 @@
+package ignore
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Matcher matches the paths with the patterns in the gitignore syntax
+type Matcher struct {
+	patterns []pattern
+}
+
+type pattern struct {
+	regexp  *regexp.Regexp
+	negate  bool
+	dirOnly bool
+}
+
+// New creates the Matcher of the lines in the gitignore syntax. The later patterns take precedence.
+func New(lines []string) (*Matcher, error) {
+	m := &Matcher{}
+	for _, line := range lines {
+		line = strings.TrimRight(line, " \r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var p pattern
+		if strings.HasPrefix(line, "!") {
+			p.negate = true
+			line = line[1:]
+		} else if strings.HasPrefix(line, `\`) {
+			// `\#` and `\!` match the names starting with them
+			line = line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			p.dirOnly = true
+			line = strings.TrimSuffix(line, "/")
+		}
+		if line == "" {
+			continue
+		}
+
+		// the patterns without a slash match the names at any level, others match the paths from the root
+		anchored := strings.Contains(line, "/")
+		line = strings.TrimPrefix(line, "/")
+
+		expr, err := toRegexp(line, anchored)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ignore pattern %s: %v", line, err)
+		}
+		p.regexp = expr
+		m.patterns = append(m.patterns, p)
+	}
+	return m, nil
+}
+
+// toRegexp converts the glob with `**` into the regular expression which matches the whole path
+func toRegexp(glob string, anchored bool) (*regexp.Regexp, error) {
+	var b strings.Builder
+	b.WriteString("^")
+	if !anchored {
+		b.WriteString("(?:.*/)?")
+	}
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch {
+		case strings.HasPrefix(glob[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "/**") && i+3 == len(glob):
+			b.WriteString("/.*")
+			i += 2
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(glob[i:], ']')
+			if end < 0 {
+				b.WriteString(regexp.QuoteMeta(string(c)))
+				continue
+			}
+			class := glob[i+1 : i+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + class + "]")
+			i += end
+		case c == '\\' && i+1 < len(glob):
+			i++
+			b.WriteString(regexp.QuoteMeta(string(glob[i])))
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
+
+// Match returns true if the path relative to the root with slashes is ignored
+func (m *Matcher) Match(path string, isDir bool) bool {
+	ignored := false
+	for _, p := range m.patterns {
+		if p.dirOnly && !isDir {
+			continue
+		}
+		if p.regexp.MatchString(path) {
+			ignored = !p.negate
+		}
+	}
+	return ignored
+}
+
+// Empty returns true if the Matcher has no patterns
+func (m *Matcher) Empty() bool {
+	return len(m.patterns) == 0
+}
+
+// ReadFile reads the patterns from the file. It returns no patterns if the file does not exist.
+func ReadFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ignore file %s: %v", path, err)
+	}
+	defer f.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read ignore file %s: %v", path, err)
+	}
+	return lines, nil
+}
+
+// Load creates the Matcher of the ignore files like .gitignore in the root directory.
+// The patterns of the later files take precedence.
+func Load(root string, files ...string) (*Matcher, error) {
+	var lines []string
+	for _, file := range files {
+		l, err := ReadFile(filepath.Join(root, file))
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, l...)
+	}
+	return New(lines)
+}
+
+// IgnoredPaths returns the ignored paths under the root relative to it in order.
+// The directories end with a slash, and the paths in them are not listed.
+func (m *Matcher) IgnoredPaths(root string) ([]string, error) {
+	paths := make([]string, 0)
+	if m.Empty() {
+		return paths, nil
+	}
+
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == root {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if !m.Match(rel, info.IsDir()) {
+			return nil
+		}
+
+		if info.IsDir() {
+			paths = append(paths, rel+"/")
+			return filepath.SkipDir
+		}
+		paths = append(paths, rel)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find ignored paths in %s: %v", root, err)
+	}
+
+	sort.Strings(paths)
+	return paths, nil
+}
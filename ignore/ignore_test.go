@@ -0,0 +1,100 @@
+package ignore
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatch(t *testing.T) {
+	testCases := map[string]struct {
+		patterns []string
+		path     string
+		isDir    bool
+		expected bool
+	}{
+		"name at the root":            {[]string{"node_modules"}, "node_modules", true, true},
+		"name at any level":           {[]string{"node_modules"}, "web/node_modules", true, true},
+		"wildcard":                    {[]string{"*.log"}, "logs/debug.log", false, true},
+		"wildcard does not match dir": {[]string{"*.log"}, "logs/debug.txt", false, false},
+		"question mark":               {[]string{"file?.txt"}, "file1.txt", false, true},
+		"character class":             {[]string{"file[0-9].txt"}, "filea.txt", false, false},
+		"negated character class":     {[]string{"file[!0-9].txt"}, "filea.txt", false, true},
+		"anchored by leading slash":   {[]string{"/build"}, "web/build", true, false},
+		"anchored at the root":        {[]string{"/build"}, "build", true, true},
+		"anchored by middle slash":    {[]string{"web/build"}, "web/build", true, true},
+		"anchored not at any level":   {[]string{"web/build"}, "app/web/build", true, false},
+		"directory only":              {[]string{"build/"}, "build", false, false},
+		"directory only matches dir":  {[]string{"build/"}, "web/build", true, true},
+		"leading double asterisks":    {[]string{"**/cache"}, "a/b/cache", true, true},
+		"trailing double asterisks":   {[]string{"tmp/**"}, "tmp/a/b", false, true},
+		"middle double asterisks":     {[]string{"a/**/b"}, "a/x/y/b", true, true},
+		"middle double asterisks dir": {[]string{"a/**/b"}, "a/b", true, true},
+		"negation":                    {[]string{"*.log", "!keep.log"}, "keep.log", false, false},
+		"later pattern wins":          {[]string{"!keep.log", "*.log"}, "keep.log", false, true},
+		"comment":                     {[]string{"# node_modules"}, "# node_modules", true, false},
+		"escaped hash":                {[]string{`\#file`}, "#file", false, true},
+		"blank lines":                 {[]string{"", "  "}, "file", false, false},
+	}
+
+	for name, tt := range testCases {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			m, err := New(tt.patterns)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.expected, m.Match(tt.path, tt.isDir))
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "src")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	_ = ioutil.WriteFile(filepath.Join(dir, ".gitignore"), []byte("*.log\nnode_modules/\n"), 0666)
+	_ = ioutil.WriteFile(filepath.Join(dir, ".sdignore"), []byte("!keep.log\n"), 0666)
+
+	m, err := Load(dir, ".gitignore", ".sdignore", ".missing")
+	assert.Nil(t, err)
+	assert.True(t, m.Match("debug.log", false))
+	assert.False(t, m.Match("keep.log", false))
+	assert.True(t, m.Match("node_modules", true))
+
+	m, err = Load(dir, ".missing")
+	assert.Nil(t, err)
+	assert.True(t, m.Empty())
+}
+
+func TestIgnoredPaths(t *testing.T) {
+	dir, err := ioutil.TempDir("", "src")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, f := range []string{"main.js", "debug.log", "node_modules/dep/index.js", "web/node_modules/dep.js", "web/app.js", "web/keep.log"} {
+		path := filepath.Join(dir, f)
+		_ = os.MkdirAll(filepath.Dir(path), 0777)
+		_ = ioutil.WriteFile(path, []byte(""), 0666)
+	}
+
+	m, _ := New([]string{"node_modules/", "*.log", "!keep.log"})
+	paths, err := m.IgnoredPaths(dir)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"debug.log", "node_modules/", "web/node_modules/"}, paths)
+
+	m, _ = New(nil)
+	paths, err = m.IgnoredPaths(dir)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{}, paths)
+
+	m, _ = New([]string{"*.log"})
+	_, err = m.IgnoredPaths(filepath.Join(dir, "missing"))
+	assert.Contains(t, err.Error(), "failed to find ignored paths")
+}
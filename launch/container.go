@@ -0,0 +1,52 @@
+package launch
+
+import "path/filepath"
+
+// containerClient describes a container CLI compatible with docker.
+// The differences between the CLIs are absorbed by its implementations.
+type containerClient interface {
+	// command returns the name of the CLI
+	command() string
+	// runOptions returns the additional options of `container run` for the build container
+	runOptions() []string
+	// pullArgs returns the arguments to pull the image with the credentials in the Docker config directory
+	pullArgs(configDir, image string) []string
+	// hostEnv returns the environment variable of the address of the daemon
+	hostEnv() string
+}
+
+type dockerClient struct{}
+
+func (dockerClient) command() string { return "docker" }
+
+func (dockerClient) runOptions() []string { return nil }
+
+func (dockerClient) hostEnv() string { return "DOCKER_HOST" }
+
+// pullArgs passes the config directory as the global option, which lets docker use the credential helpers in it as well
+func (dockerClient) pullArgs(configDir, image string) []string {
+	if configDir == "" {
+		return []string{"pull", image}
+	}
+	return []string{"--config", configDir, "pull", image}
+}
+
+type podmanClient struct{}
+
+func (podmanClient) command() string { return "podman" }
+
+func (podmanClient) hostEnv() string { return "CONTAINER_HOST" }
+
+// runOptions disables SELinux labeling, otherwise the source and artifacts directories mounted
+// from the host can not be accessed from the build container on SELinux enabled hosts.
+func (podmanClient) runOptions() []string {
+	return []string{"--security-opt", "label=disable"}
+}
+
+// pullArgs passes the Docker config.json as the auth file, which podman can read
+func (podmanClient) pullArgs(configDir, image string) []string {
+	if configDir == "" {
+		return []string{"pull", image}
+	}
+	return []string{"pull", "--authfile", filepath.Join(configDir, "config.json"), image}
+}
@@ -7,6 +7,7 @@ import (
 	"io"
 	"os"
 	"os/exec"
+	"path"
 	"path/filepath"
 	"strconv"
 	"strings"
@@ -14,6 +15,8 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/google/uuid"
+	"github.com/screwdriver-cd/sd-local/retry"
 	"github.com/screwdriver-cd/sd-local/screwdriver"
 	"github.com/sirupsen/logrus"
 )
@@ -31,6 +34,23 @@ type docker struct {
 	interact          Interacter
 	socketPath        string
 	localVolumes      []string
+	client            containerClient
+	noTeardown        bool
+	registryAuth      string
+	platform          string
+	pullRetry         retry.Policy
+	// pullPolicy is the policy to pull the launcher image, the empty one means always
+	pullPolicy string
+	// offline uses only the local images without pulling them
+	offline bool
+	// host is the address of the daemon, the empty one means the environment variables or the default
+	host string
+	// launcherArchive is the tarball made by `docker save` to load the launcher image from instead of pulling it
+	launcherArchive string
+	// keptContainer is the ID of the build container kept for debugging
+	keptContainer string
+	// buildContainer is the name of the build container to remove it when the build is interrupted
+	buildContainer string
 }
 
 var _ runner = (*docker)(nil)
@@ -44,12 +64,24 @@ const (
 	// The definition of "ScmHost" and "OrgRepo" is in "PipelineFromID" of "screwdriver/screwdriver_local.go"
 	scmHost = "screwdriver.cd"
 	orgRepo = "sd-local/local-build"
+	// keepAliveScript keeps the build container running until it is stopped
+	keepAliveScript = "trap 'exit 0' TERM; while true; do sleep 1; done"
+	// launchBinVolume and launchHabVolume are the prefixes of the volumes of the launcher, which are suffixed for every launcher
+	// not to be overwritten or removed by the other builds running in parallel
+	launchBinVolume = "SD_LAUNCH_BIN"
+	launchHabVolume = "SD_LAUNCH_HAB"
 )
 
-func newDocker(setupImage, setupImageVer string, useSudo bool, interactiveMode bool, socketPath string, flagVerbose bool, localVolumes []string) runner {
+// volumeSuffix returns the suffix of the volumes of the launcher, which is replaced in the tests
+var volumeSuffix = func() string {
+	return strings.Split(uuid.NewString(), "-")[0]
+}
+
+func newDocker(setupImage, setupImageVer string, useSudo bool, interactiveMode bool, socketPath string, flagVerbose bool, localVolumes []string, noTeardown bool, registryAuth, platform string, pullRetry retry.Policy, pullPolicy string, offline bool, host, launcherArchive string) runner {
+	suffix := volumeSuffix()
 	return &docker{
-		volume:            "SD_LAUNCH_BIN",
-		habVolume:         "SD_LAUNCH_HAB",
+		volume:            launchBinVolume + "_" + suffix,
+		habVolume:         launchHabVolume + "_" + suffix,
 		setupImage:        setupImage,
 		setupImageVersion: setupImageVer,
 		useSudo:           useSudo,
@@ -60,24 +92,77 @@ func newDocker(setupImage, setupImageVer string, useSudo bool, interactiveMode b
 		interact:          &Interact{},
 		socketPath:        socketPath,
 		localVolumes:      localVolumes,
+		client:            dockerClient{},
+		noTeardown:        noTeardown,
+		registryAuth:      registryAuth,
+		platform:          platform,
+		pullRetry:         pullRetry,
+		pullPolicy:        pullPolicy,
+		offline:           offline,
+		host:              host,
+		launcherArchive:   launcherArchive,
+	}
+}
+
+// newPodman returns the runner which runs the build with podman instead of docker
+func newPodman(setupImage, setupImageVer string, useSudo bool, interactiveMode bool, socketPath string, flagVerbose bool, localVolumes []string, noTeardown bool, registryAuth, platform string, pullRetry retry.Policy, pullPolicy string, offline bool, host, launcherArchive string) runner {
+	d := newDocker(setupImage, setupImageVer, useSudo, interactiveMode, socketPath, flagVerbose, localVolumes, noTeardown, registryAuth, platform, pullRetry, pullPolicy, offline, host, launcherArchive).(*docker)
+	d.client = podmanClient{}
+	return d
+}
+
+// launcherImage returns the reference of the launcher image.
+// The version is ignored if the image is pinned to a digest.
+func launcherImage(image, version string) string {
+	if strings.Contains(image, "@") {
+		return image
+	}
+	return fmt.Sprintf("%s:%s", image, version)
+}
+
+// verifyDigest checks that the pulled image has the digest pinned in the reference
+func (d *docker) verifyDigest(image string) error {
+	i := strings.Index(image, "@")
+	if i < 0 {
+		return nil
+	}
+	expected := image[i:]
+
+	out, err := d.execDockerCommand("image", "inspect", "--format", "{{range .RepoDigests}}{{println .}}{{end}}", image)
+	if err != nil {
+		return fmt.Errorf("failed to inspect launcher image: %v", err)
+	}
+
+	for _, repoDigest := range strings.Split(out, "\n") {
+		if strings.HasSuffix(repoDigest, expected) {
+			return nil
+		}
 	}
+	return fmt.Errorf("launcher image digest mismatch: the pulled image does not have %s", expected[1:])
 }
 
 func (d *docker) setupBin() error {
 	mount := fmt.Sprintf("%s:/opt/sd/", d.volume)
 	habMount := fmt.Sprintf("%s:/hab", d.habVolume)
-	image := fmt.Sprintf("%s:%s", d.setupImage, d.setupImageVersion)
-	_, err := d.execDockerCommand("pull", image)
+	image := launcherImage(d.setupImage, d.setupImageVersion)
+	err := d.pullLauncherImage(image)
 	if err != nil {
 		return fmt.Errorf("failed to pull launcher image: %v", err)
 	}
 
+	err = d.verifyDigest(image)
+	if err != nil {
+		return err
+	}
+
 	// The mechanism for population is that VOLUMEs were declared in the image, so they copy what was in their layer to
 	// the mounted location on first mount of non-existing volumes
 	// NOTE: docker allows copying to first-time mounted as well, but both docker and podman copy to non-existing ones.
 	//       therefore, volumes are not pre-created, but created on first mention by the image that populates them
 	//       and then used by subsequent images that then use their content.
-	_, err = d.execDockerCommand("container", "run", "--rm", "-v", mount, "-v", habMount, "--entrypoint", "/bin/echo", image, "set up bin")
+	args := append([]string{"container", "run", "--rm"}, d.platformOptions()...)
+	args = append(args, "-v", mount, "-v", habMount, "--entrypoint", "/bin/echo", image, "set up bin")
+	_, err = d.execDockerCommand(args...)
 	if err != nil {
 		return fmt.Errorf("failed to prepare build scripts: %v", err)
 	}
@@ -88,27 +173,34 @@ func (d *docker) setupBin() error {
 func (d *docker) runBuild(buildEntry buildEntry) error {
 	environment := buildEntry.Environment[0]
 
-	srcDir := buildEntry.SrcPath
-	hostArtDir := buildEntry.ArtifactsPath
 	containerArtDir := environment["SD_ARTIFACTS_DIR"]
 	buildImage := buildEntry.Image
 	logfilePath := filepath.Join(containerArtDir, LogFile)
 
-	srcVol := fmt.Sprintf("%s/:/sd/workspace/src/%s/%s", srcDir, scmHost, orgRepo)
-	artVol := fmt.Sprintf("%s/:%s", hostArtDir, containerArtDir)
-	binVol := fmt.Sprintf("%s:%s", d.volume, "/opt/sd")
-	habVol := fmt.Sprintf("%s:%s", d.habVolume, "/opt/sd/hab")
-
-	dockerVolumes := append(d.localVolumes, srcVol, artVol, binVol, habVol, fmt.Sprintf("%s:/tmp/auth.sock:rw", d.socketPath))
+	dockerVolumes := d.mounts(buildEntry)
 
 	// Overwrite steps for sd-local interact mode. The env will load later.
+	// The teardown steps are written into the script which runs when the shell exits.
+	var teardownSteps []screwdriver.Step
 	if d.interactiveMode {
+		for _, s := range buildEntry.Steps {
+			if s.IsTeardown() {
+				teardownSteps = append(teardownSteps, s)
+			}
+		}
+
 		buildEntry.Steps = []screwdriver.Step{
 			{
 				Name:    "sd-local-init",
 				Command: "export > /tmp/sd-local.env",
 			},
 		}
+		if len(teardownSteps) != 0 {
+			buildEntry.Steps = append(buildEntry.Steps, screwdriver.Step{
+				Name:    "sd-local-teardown",
+				Command: teardownScript(teardownSteps),
+			})
+		}
 	}
 
 	configJSON, err := json.Marshal(buildEntry)
@@ -116,17 +208,47 @@ func (d *docker) runBuild(buildEntry buildEntry) error {
 		return err
 	}
 
-	logrus.Infof("Pulling docker image from %s...", buildImage)
-	_, err = d.execDockerCommand("pull", buildImage)
+	err = d.pullBuildImage(buildImage)
 	if err != nil {
-		return fmt.Errorf("failed to pull user image %v", err)
+		return newRuntimeError(fmt.Errorf("failed to pull user image %v", err))
 	}
 
+	// the launcher fails with the obscure error if the shell of the steps is not found
+	interactiveShell := "/bin/sh"
+	if shell := environment[ShellEnv]; shell != "" {
+		args := append([]string{"container", "run", "--rm"}, d.platformOptions()...)
+		args = append(args, "--entrypoint", shell, buildImage, "-c", "true")
+		if _, err := d.execDockerCommand(args...); err != nil {
+			return shellNotFoundError(shell, buildImage, err)
+		}
+		interactiveShell = shell
+	}
+
+	// the build container is started in the background and kept if the build fails in no teardown mode
+	keepContainer := d.noTeardown && !d.interactiveMode
+
 	dockerCommandArgs := []string{"container", "run"}
 	dockerCommandOptions := []string{"--rm"}
+	if keepContainer {
+		dockerCommandOptions = []string{"-d"}
+	}
+	d.buildContainer = buildEntry.ContainerName
+	if d.buildContainer != "" {
+		dockerCommandOptions = append(dockerCommandOptions, "--name", d.buildContainer)
+	}
+	dockerCommandOptions = append(dockerCommandOptions, containerLabels(buildEntry)...)
 	for _, v := range dockerVolumes {
 		dockerCommandOptions = append(dockerCommandOptions, "-v", v)
 	}
+	dockerCommandOptions = append(dockerCommandOptions, d.platformOptions()...)
+	dockerCommandOptions = append(dockerCommandOptions, d.client.runOptions()...)
+	// only the keys of the secrets are passed, and the values are taken from the environment of the container CLI
+	secretKeys := sortedKeys(buildEntry.Secrets)
+	secretEnv := make([]string, 0, len(secretKeys))
+	for _, k := range secretKeys {
+		dockerCommandOptions = append(dockerCommandOptions, "-e", k)
+		secretEnv = append(secretEnv, fmt.Sprintf("%s=%s", k, buildEntry.Secrets[k]))
+	}
 	dockerCommandOptions = append(dockerCommandOptions, "-e", "SSH_AUTH_SOCK=/tmp/auth.sock", buildImage)
 	configJSONArg := string(configJSON)
 	if d.interactiveMode {
@@ -135,7 +257,9 @@ func (d *docker) runBuild(buildEntry buildEntry) error {
 	launchCommands := []string{"/opt/sd/local_run.sh", configJSONArg, buildEntry.JobName, environment["SD_API_URL"], environment["SD_STORE_URL"], logfilePath}
 	if d.interactiveMode {
 		dockerCommandOptions = append([]string{"-itd"}, dockerCommandOptions...)
-		dockerCommandOptions = append(dockerCommandOptions, "/bin/sh")
+		dockerCommandOptions = append(dockerCommandOptions, interactiveShell)
+	} else if keepContainer {
+		dockerCommandOptions = append(dockerCommandOptions, "/bin/sh", "-c", keepAliveScript)
 	} else {
 		dockerCommandOptions = append(dockerCommandOptions, launchCommands...)
 	}
@@ -144,15 +268,23 @@ func (d *docker) runBuild(buildEntry buildEntry) error {
 		dockerCommandOptions = append([]string{fmt.Sprintf("-m%s", buildEntry.MemoryLimit)}, dockerCommandOptions...)
 	}
 
+	if buildEntry.CPULimit != "" {
+		dockerCommandOptions = append([]string{fmt.Sprintf("--cpus=%s", buildEntry.CPULimit)}, dockerCommandOptions...)
+	}
+
 	if buildEntry.UsePrivileged {
 		dockerCommandOptions = append([]string{"--privileged"}, dockerCommandOptions...)
 	}
 
+	if buildEntry.User != "" {
+		dockerCommandOptions = append([]string{"--user", buildEntry.User}, dockerCommandOptions...)
+	}
+
 	if d.interactiveMode {
 		// attach build container for sd-local interact mode
-		cid, err := d.execDockerCommand(append(dockerCommandArgs, dockerCommandOptions...)...)
+		cid, _, err := d.runDockerCommand(secretEnv, append(dockerCommandArgs, dockerCommandOptions...)...)
 		if err != nil {
-			return fmt.Errorf("failed to run build container: %v", err)
+			return newRuntimeError(fmt.Errorf("failed to run build container: %v", err))
 		}
 
 		attachCommands := []string{"attach", cid}
@@ -164,43 +296,203 @@ func (d *docker) runBuild(buildEntry buildEntry) error {
 			{"export", "PS1='sd-local# '"},
 			{"cd", "$SD_CHECKOUT_DIR"},
 		}
+		if len(teardownSteps) != 0 {
+			commands = append(commands, []string{"trap", fmt.Sprintf("'. %s'", teardownScriptPath), "EXIT"})
+		}
 		err = d.attachDockerCommand(attachCommands, commands)
 		if err != nil {
 			return fmt.Errorf("failed to attach build container: %v", err)
 		}
+	} else if keepContainer {
+		// run for sd-local build mode without teardown
+		// the container kept by the failed build is re-entered to resume the build in the same environment
+		cid := buildEntry.ResumeContainer
+		if cid != "" && !d.isRunning(cid) {
+			logrus.Warnf("The build container %s of the previous build is not running, starting a new one", cid)
+			cid = ""
+		}
+		if cid == "" {
+			cid, _, err = d.runDockerCommand(secretEnv, append(dockerCommandArgs, dockerCommandOptions...)...)
+			if err != nil {
+				return newRuntimeError(fmt.Errorf("failed to run build container: %v", err))
+			}
+		}
+
+		_, err = d.execDockerCommand(append([]string{"container", "exec", cid}, launchCommands...)...)
+		if err != nil {
+			d.keptContainer = cid
+			command := d.client.command()
+			if d.host != "" {
+				command = fmt.Sprintf("%s=%s %s", d.client.hostEnv(), d.host, command)
+			}
+			if d.useSudo {
+				command = "sudo " + command
+			}
+			logrus.Warnf("The build container %s is kept for debugging. Enter it with:\n  %s exec -it %s /bin/sh\nPlease remove it and the volumes %s, %s by yourself when you finish.",
+				cid, command, cid, d.habVolume, d.volume)
+			return fmt.Errorf("failed to run build container: %v", err)
+		}
+
+		// the anonymous volumes which hide the ignored paths are removed with the container
+		_, err = d.execDockerCommand("container", "rm", "--force", "--volumes", cid)
+		if err != nil {
+			logrus.Warn(fmt.Errorf("failed to remove build container: %v", err))
+		}
 	} else {
 		// run for sd-local build mode
-		_, err = d.execDockerCommand(append(dockerCommandArgs, dockerCommandOptions...)...)
+		_, _, err = d.runDockerCommand(secretEnv, append(dockerCommandArgs, dockerCommandOptions...)...)
 		if err != nil {
-			return fmt.Errorf("failed to run build container: %v", err)
+			return fmt.Errorf("failed to run build container: %w", containerError(err))
 		}
 	}
 
 	return nil
 }
 
-func (d *docker) attachDockerCommand(attachCommands []string, commands [][]string) error {
-	attachCommands = append([]string{"docker"}, attachCommands...)
-	if d.useSudo {
-		attachCommands = append([]string{"sudo"}, attachCommands...)
+// mounts returns the volumes mounted into the build container
+func (d *docker) mounts(buildEntry buildEntry) []string {
+	srcVol := fmt.Sprintf("%s:%s", hostDir(buildEntry.SrcPath), srcMountDir)
+	artVol := fmt.Sprintf("%s:%s", hostDir(buildEntry.ArtifactsPath), buildEntry.Environment[0]["SD_ARTIFACTS_DIR"])
+	binVol := fmt.Sprintf("%s:%s", d.volume, "/opt/sd")
+	habVol := fmt.Sprintf("%s:%s", d.habVolume, "/opt/sd/hab")
+
+	volumes := append(d.localVolumes, srcVol, artVol, binVol, habVol, fmt.Sprintf("%s:/tmp/auth.sock:rw", d.socketPath))
+	if buildEntry.MetaPath != "" {
+		volumes = append(volumes, fmt.Sprintf("%s:%s", hostDir(buildEntry.MetaPath), MetaDir))
+	}
+	if buildEntry.CacheDir != "" {
+		volumes = append(volumes, fmt.Sprintf("%s:%s", hostDir(buildEntry.CacheDir), CacheDir))
+	}
+	if buildEntry.CommandsDir != "" {
+		volumes = append(volumes, fmt.Sprintf("%s:%s", hostDir(buildEntry.CommandsDir), CommandsDir))
+	}
+	for _, m := range buildEntry.Mounts {
+		volumes = append(volumes, m.volume())
+	}
+	return append(volumes, ignoredMounts(buildEntry.IgnoredPaths)...)
+}
+
+// ignoredMounts returns the volumes which hide the ignored paths of the mounted source code.
+// The directories are hidden by the empty anonymous volumes, and the files by /dev/null.
+func ignoredMounts(paths []string) []string {
+	mounts := make([]string, 0, len(paths))
+	for _, p := range paths {
+		if strings.HasSuffix(p, "/") {
+			mounts = append(mounts, path.Join(srcMountDir, p))
+		} else {
+			mounts = append(mounts, fmt.Sprintf("/dev/null:%s:ro", path.Join(srcMountDir, p)))
+		}
 	}
-	c := execCommand(attachCommands[0], attachCommands[1:]...)
+	return mounts
+}
+
+// teardownScriptPath is the path of the script to run the teardown steps in the interactive mode
+const teardownScriptPath = "/tmp/sd-local-teardown.sh"
+
+// teardownScript returns the command which writes the teardown steps into the script
+func teardownScript(steps []screwdriver.Step) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "cat > %s <<'SD_LOCAL_TEARDOWN'\n", teardownScriptPath)
+	for _, s := range steps {
+		fmt.Fprintf(&b, "echo '$ %s'\n%s\n", s.Name, s.Command)
+	}
+	b.WriteString("SD_LOCAL_TEARDOWN")
+	return b.String()
+}
+
+func (d *docker) attachDockerCommand(attachCommands []string, commands [][]string) error {
+	c := d.containerCommand(nil, attachCommands...)
 
 	if d.flagVerbose {
-		logrus.Infof("$ %s", c.String())
+		logrus.Debugf("$ %s", c.String())
 	}
 
 	return d.interact.Run(c, commands)
 }
 
+// isRunning returns true if the container exists and is running
+func (d *docker) isRunning(cid string) bool {
+	out, err := d.execDockerCommand("container", "inspect", "--format", "{{.State.Running}}", cid)
+	return err == nil && strings.HasSuffix(out, "true")
+}
+
+// keptContainerID returns the ID of the build container kept for debugging
+func (d *docker) keptContainerID() string {
+	return d.keptContainer
+}
+
+// platformOptions returns the options to pull and run the images of the platform
+func (d *docker) platformOptions() []string {
+	if d.platform == "" {
+		return nil
+	}
+	return []string{"--platform", d.platform}
+}
+
+// pullImage pulls the image of the platform with the credentials in the registry auth directory.
+// The pull failed by the transient errors of the network or the registry is retried.
+func (d *docker) pullImage(image string) error {
+	args := d.client.pullArgs(d.registryAuth, image)
+	args = append(append(args[:len(args)-1:len(args)-1], d.platformOptions()...), image)
+	var stderr string
+	err := d.pullRetry.Do("pulling "+image, func() error {
+		var err error
+		_, stderr, err = d.runDockerCommand(nil, args...)
+		if err != nil && isTransient(stderr) {
+			return retry.Transient(err)
+		}
+		return err
+	})
+	if err != nil && isUnauthorized(stderr) {
+		registry := registryHost(image)
+		return fmt.Errorf("not authorized to pull %s from %s: log in with `%s login %s` or set the directory of the Docker config.json with the credentials by `sd-local config set registry-auth`",
+			image, registry, d.client.command(), registry)
+	}
+	if err != nil && isPlatformUnavailable(stderr) {
+		return fmt.Errorf("image %s is not available for platform %s: specify the platform provided by the image with --platform, which runs under emulation", image, d.platform)
+	}
+	return err
+}
+
 func (d *docker) execDockerCommand(args ...string) (string, error) {
-	commands := append([]string{"docker"}, args...)
+	out, _, err := d.runDockerCommand(nil, args...)
+	return out, err
+}
+
+// containerCommand returns the command of the container CLI with the additional environment variables and the address of the daemon.
+// The environment variables are preserved by sudo.
+func (d *docker) containerCommand(env []string, args ...string) *exec.Cmd {
+	if d.host != "" {
+		env = append([]string{fmt.Sprintf("%s=%s", d.client.hostEnv(), d.host)}, env...)
+	}
+
+	commands := append([]string{d.client.command()}, args...)
 	if d.useSudo {
-		commands = append([]string{"sudo"}, commands...)
+		sudo := []string{"sudo"}
+		if len(env) != 0 {
+			keys := make([]string, 0, len(env))
+			for _, e := range env {
+				keys = append(keys, strings.SplitN(e, "=", 2)[0])
+			}
+			sudo = append(sudo, "--preserve-env="+strings.Join(keys, ","))
+		}
+		commands = append(sudo, commands...)
 	}
 	cmd := execCommand(commands[0], commands[1:]...)
+	if len(env) != 0 {
+		if cmd.Env == nil {
+			cmd.Env = os.Environ()
+		}
+		cmd.Env = append(cmd.Env, env...)
+	}
+	return cmd
+}
+
+// runDockerCommand runs the container CLI with the additional environment variables and returns its stdout and stderr
+func (d *docker) runDockerCommand(env []string, args ...string) (string, string, error) {
+	cmd := d.containerCommand(env, args...)
 	if d.flagVerbose {
-		logrus.Infof("$ %s", strings.Join(commands, " "))
+		logrus.Debugf("$ %s", strings.Join(cmd.Args, " "))
 	}
 	cmd.Stderr = logrus.StandardLogger().WriterLevel(logrus.ErrorLevel)
 	d.commands = append(d.commands, cmd)
@@ -208,13 +500,17 @@ func (d *docker) execDockerCommand(args ...string) (string, error) {
 	cmd.Stderr = buf
 	out, err := cmd.Output()
 	if d.flagVerbose {
-		logrus.Infof("%s", out)
+		logrus.Debugf("%s", out)
 	}
+	stderr := buf.String()
 	if err != nil {
 		io.Copy(os.Stderr, buf)
-		return strings.TrimRight(string(out), "\n"), err
+		if !d.useSudo && isPermissionDenied(stderr) {
+			err = newRuntimeError(fmt.Errorf("%v: permission denied to access the %s daemon, add the user to the docker group or run with --sudo", err, d.client.command()))
+		}
+		return strings.TrimRight(string(out), "\n"), stderr, err
 	}
-	return strings.TrimRight(string(out), "\n"), nil
+	return strings.TrimRight(string(out), "\n"), stderr, nil
 }
 
 func (d *docker) kill(sig os.Signal) {
@@ -223,10 +519,11 @@ func (d *docker) kill(sig os.Signal) {
 	for _, v := range d.commands {
 		var err error
 		d.mutex.Lock()
-		if v.ProcessState != nil {
+		finished := v.ProcessState != nil || v.Process == nil
+		d.mutex.Unlock()
+		if finished {
 			continue
 		}
-		d.mutex.Unlock()
 
 		if d.useSudo {
 			cmd := execCommand("sudo", "kill", fmt.Sprintf("-%v", signum(sig)), strconv.Itoa(v.Process.Pid))
@@ -246,9 +543,30 @@ func (d *docker) kill(sig os.Signal) {
 	if err != nil {
 		logrus.Warn(err)
 	}
+
+	d.removeBuildContainer()
+}
+
+// removeBuildContainer removes the build container left running by the killed container CLI.
+// The container kept for debugging is not removed.
+func (d *docker) removeBuildContainer() {
+	if d.buildContainer == "" || d.keptContainer != "" {
+		return
+	}
+
+	// the container has been removed by --rm unless the container CLI was killed before the container stopped
+	out, err := d.containerCommand(nil, "container", "rm", "--force", "--volumes", d.buildContainer).CombinedOutput()
+	if err != nil {
+		logrus.Debugf("build container %s is not removed: %v: %s", d.buildContainer, err, strings.TrimSpace(string(out)))
+	}
 }
 
 func (d *docker) clean() {
+	// The volumes are used by the build container kept for debugging.
+	if d.keptContainer != "" {
+		return
+	}
+
 	// Since the habVolume is mounted inside the mountpoint for volume, it must be removed first.
 	_, err := d.execDockerCommand("volume", "rm", "--force", d.habVolume)
 
@@ -2,15 +2,19 @@ package launch
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"os"
 	"os/exec"
+	"strconv"
 	"strings"
 	"sync"
 	"syscall"
 	"testing"
 	"time"
 
+	"github.com/screwdriver-cd/sd-local/retry"
+	"github.com/screwdriver-cd/sd-local/screwdriver"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 )
@@ -30,6 +34,7 @@ type fakeExecCommand struct {
 
 type mockInteract struct {
 	Interacter
+	commands [][]string
 }
 
 func newFakeExecCommand(id string) *fakeExecCommand {
@@ -48,14 +53,21 @@ func newFakeExecCommand(id string) *fakeExecCommand {
 }
 
 func (d *mockInteract) Run(c *exec.Cmd, commands [][]string) error {
+	d.commands = commands
 	return c.Run()
 }
 
 func TestNewDocker(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
+		defVolumeSuffix := volumeSuffix
+		defer func() {
+			volumeSuffix = defVolumeSuffix
+		}()
+		volumeSuffix = func() string { return "1b9d6bcd" }
+
 		expected := &docker{
-			volume:            "SD_LAUNCH_BIN",
-			habVolume:         "SD_LAUNCH_HAB",
+			volume:            "SD_LAUNCH_BIN_1b9d6bcd",
+			habVolume:         "SD_LAUNCH_HAB_1b9d6bcd",
 			setupImage:        "launcher",
 			setupImageVersion: "latest",
 			useSudo:           false,
@@ -66,12 +78,37 @@ func TestNewDocker(t *testing.T) {
 			interact:          &Interact{},
 			socketPath:        "/auth.sock",
 			localVolumes:      []string{"path:path"},
+			client:            dockerClient{},
+			noTeardown:        false,
+			platform:          "linux/arm64",
+			pullPolicy:        PullMissing,
 		}
 
-		d := newDocker("launcher", "latest", false, false, "/auth.sock", false, []string{"path:path"})
+		d := newDocker("launcher", "latest", false, false, "/auth.sock", false, []string{"path:path"}, false, "", "linux/arm64", retry.Policy{}, PullMissing, false, "", "")
 
 		assert.Equal(t, expected, d)
 	})
+
+	t.Run("success with the volumes of every launcher", func(t *testing.T) {
+		d1 := newDocker("launcher", "latest", false, false, "/auth.sock", false, nil, false, "", "", retry.Policy{}, PullMissing, false, "", "").(*docker)
+		d2 := newDocker("launcher", "latest", false, false, "/auth.sock", false, nil, false, "", "", retry.Policy{}, PullMissing, false, "", "").(*docker)
+
+		assert.Regexp(t, "^SD_LAUNCH_BIN_[0-9a-f]{8}$", d1.volume)
+		assert.Regexp(t, "^SD_LAUNCH_HAB_[0-9a-f]{8}$", d1.habVolume)
+		assert.NotEqual(t, d1.volume, d2.volume)
+		assert.NotEqual(t, d1.habVolume, d2.habVolume)
+	})
+}
+
+func TestNewPodman(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		d, ok := newPodman("launcher", "latest", false, false, "/auth.sock", false, []string{"path:path"}, false, "", "linux/arm64", retry.Policy{}, PullMissing, false, "", "").(*docker)
+
+		assert.True(t, ok)
+		assert.Equal(t, podmanClient{}, d.client)
+		assert.Equal(t, "launcher", d.setupImage)
+		assert.Equal(t, "linux/arm64", d.platform)
+	})
 }
 
 func TestSetupBin(t *testing.T) {
@@ -83,6 +120,7 @@ func TestSetupBin(t *testing.T) {
 		volume:            "SD_LAUNCH_BIN",
 		setupImage:        "launcher",
 		setupImageVersion: "latest",
+		client:            dockerClient{},
 	}
 
 	testCase := []struct {
@@ -106,6 +144,286 @@ func TestSetupBin(t *testing.T) {
 	}
 }
 
+func TestSetupBinWithDigest(t *testing.T) {
+	defer func() {
+		execCommand = exec.Command
+	}()
+
+	d := &docker{
+		volume:            "SD_LAUNCH_BIN",
+		setupImage:        "launcher@sha256:" + strings.Repeat("a", 64),
+		setupImageVersion: "latest",
+		client:            dockerClient{},
+	}
+
+	testCase := []struct {
+		name        string
+		id          string
+		expectError error
+	}{
+		{"success", "SUCCESS_SETUP_BIN_DIGEST", nil},
+		{"failure digest mismatch", "FAIL_SETUP_BIN_DIGEST_MISMATCH", fmt.Errorf("launcher image digest mismatch: the pulled image does not have sha256:%s", strings.Repeat("a", 64))},
+		{"failure image inspect", "FAIL_SETUP_BIN_DIGEST_INSPECT", fmt.Errorf("failed to inspect launcher image: exit status 1")},
+	}
+
+	for _, tt := range testCase {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newFakeExecCommand(tt.id)
+			execCommand = c.execCmd
+			err := d.setupBin()
+
+			assert.Equal(t, tt.expectError, err)
+			assert.Equal(t, fmt.Sprintf("docker pull launcher@sha256:%s", strings.Repeat("a", 64)), c.commands[0])
+		})
+	}
+}
+
+func TestPullImage(t *testing.T) {
+	defer func() {
+		execCommand = exec.Command
+	}()
+
+	testCase := []struct {
+		name         string
+		id           string
+		client       containerClient
+		registryAuth string
+		platform     string
+		image        string
+		expectError  error
+		expectCmd    string
+	}{
+		{"success", "SUCCESS_PULL_IMAGE", dockerClient{}, "", "", "node:12", nil, "docker pull node:12"},
+		{"success with docker config", "SUCCESS_PULL_IMAGE", dockerClient{}, "/home/user/.docker", "", "node:12", nil, "docker --config /home/user/.docker pull node:12"},
+		{"success with podman auth file", "SUCCESS_PULL_IMAGE", podmanClient{}, "/home/user/.docker", "", "node:12", nil, "podman pull --authfile /home/user/.docker/config.json node:12"},
+		{"success with platform", "SUCCESS_PULL_IMAGE", dockerClient{}, "/home/user/.docker", "linux/arm64", "node:12", nil, "docker --config /home/user/.docker pull --platform linux/arm64 node:12"},
+		{"failure unauthorized", "FAIL_PULL_UNAUTHORIZED", dockerClient{}, "", "", "registry.example.com/org/node:12",
+			fmt.Errorf("not authorized to pull registry.example.com/org/node:12 from registry.example.com: log in with `docker login registry.example.com` or set the directory of the Docker config.json with the credentials by `sd-local config set registry-auth`"),
+			"docker pull registry.example.com/org/node:12"},
+		{"failure platform unavailable", "FAIL_PULL_PLATFORM", dockerClient{}, "", "linux/arm64", "node:12",
+			fmt.Errorf("image node:12 is not available for platform linux/arm64: specify the platform provided by the image with --platform, which runs under emulation"),
+			"docker pull --platform linux/arm64 node:12"},
+		{"failure other", "FAIL_PULL_IMAGE", dockerClient{}, "", "", "node:12", fmt.Errorf("exit status 1"), "docker pull node:12"},
+	}
+
+	for _, tt := range testCase {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &docker{client: tt.client, registryAuth: tt.registryAuth, platform: tt.platform}
+			c := newFakeExecCommand(tt.id)
+			execCommand = c.execCmd
+			err := d.pullImage(tt.image)
+
+			if tt.expectError == nil {
+				assert.Nil(t, err)
+			} else {
+				assert.EqualError(t, err, tt.expectError.Error())
+			}
+			assert.Equal(t, tt.expectCmd, c.commands[0])
+		})
+	}
+}
+
+func TestPullImageRetry(t *testing.T) {
+	defer func() { execCommand = exec.Command }()
+
+	testCase := []struct {
+		name        string
+		id          string
+		expectError string
+		expectCmds  int
+	}{
+		{"failure by timeout after retries", "FAIL_PULL_TIMEOUT", "exit status 1", 3},
+		{"failure unauthorized without retries", "FAIL_PULL_UNAUTHORIZED", "not authorized to pull node:12 from docker.io", 1},
+		{"failure other without retries", "FAIL_PULL_IMAGE", "exit status 1", 1},
+	}
+
+	for _, tt := range testCase {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &docker{client: dockerClient{}, pullRetry: retry.Policy{Retries: 2}}
+			c := newFakeExecCommand(tt.id)
+			execCommand = c.execCmd
+			err := d.pullImage("node:12")
+
+			assert.True(t, strings.HasPrefix(err.Error(), tt.expectError), err.Error())
+			assert.Equal(t, tt.expectCmds, len(c.commands))
+		})
+	}
+}
+
+func TestSetupBinWithPullPolicy(t *testing.T) {
+	defer func() { execCommand = exec.Command }()
+
+	inspect := "docker image inspect --format {{.Id}} launcher:latest"
+	pull := "docker pull --platform linux/amd64 launcher:latest"
+	testCase := []struct {
+		name        string
+		id          string
+		policy      string
+		expectError string
+		expectCmds  []string
+	}{
+		{"always", "SUCCESS_SETUP_BIN", PullAlways, "", []string{pull}},
+		{"missing with the local image", "SUCCESS_SETUP_BIN", PullMissing, "", []string{inspect}},
+		{"missing without the local image", "SUCCESS_SETUP_BIN_MISSING", PullMissing, "", []string{inspect, pull}},
+		{"never with the local image", "SUCCESS_SETUP_BIN", PullNever, "", []string{inspect}},
+		{"never without the local image", "SUCCESS_SETUP_BIN_MISSING", PullNever,
+			"failed to pull launcher image: image launcher:latest is not present locally: pull it or run with `--pull missing`", []string{inspect}},
+	}
+
+	for _, tt := range testCase {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &docker{
+				volume:            "SD_LAUNCH_BIN",
+				setupImage:        "launcher",
+				setupImageVersion: "latest",
+				client:            dockerClient{},
+				platform:          "linux/amd64",
+				pullPolicy:        tt.policy,
+			}
+			c := newFakeExecCommand(tt.id)
+			execCommand = c.execCmd
+			err := d.setupBin()
+
+			if tt.expectError == "" {
+				assert.Nil(t, err)
+			} else {
+				assert.EqualError(t, err, tt.expectError)
+			}
+			assert.Equal(t, tt.expectCmds, c.commands[:len(tt.expectCmds)])
+		})
+	}
+}
+
+func TestSetupBinWithLauncherArchive(t *testing.T) {
+	defer func() { execCommand = exec.Command }()
+
+	inspect := "docker image inspect --format {{.Id}} launcher:latest"
+	load := "docker image load --input /tmp/launcher.tar"
+	testCase := []struct {
+		name        string
+		id          string
+		policy      string
+		expectError string
+		expectCmds  []string
+	}{
+		{"always", "SUCCESS_LOAD_LAUNCHER", PullAlways, "", []string{load, inspect}},
+		{"missing with the local image", "SUCCESS_LOAD_LAUNCHER", PullMissing, "", []string{inspect}},
+		{"missing without the local image", "FAIL_LOAD_LAUNCHER_MISMATCH", PullMissing,
+			"failed to pull launcher image: /tmp/launcher.tar does not have launcher:latest but launcher:old", []string{inspect, load, inspect}},
+		{"failure by load", "FAIL_LOAD_LAUNCHER", PullAlways,
+			"failed to pull launcher image: failed to load /tmp/launcher.tar: exit status 1", []string{load}},
+	}
+
+	for _, tt := range testCase {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &docker{
+				volume:            "SD_LAUNCH_BIN",
+				setupImage:        "launcher",
+				setupImageVersion: "latest",
+				client:            dockerClient{},
+				platform:          "linux/amd64",
+				pullPolicy:        tt.policy,
+				offline:           true,
+				launcherArchive:   "/tmp/launcher.tar",
+			}
+			c := newFakeExecCommand(tt.id)
+			execCommand = c.execCmd
+			err := d.setupBin()
+
+			if tt.expectError == "" {
+				assert.Nil(t, err)
+			} else {
+				assert.EqualError(t, err, tt.expectError)
+			}
+			assert.Equal(t, tt.expectCmds, c.commands[:len(tt.expectCmds)])
+		})
+	}
+}
+
+func TestLoadedImages(t *testing.T) {
+	assert.Equal(t, []string{"screwdrivercd/launcher:stable"}, loadedImages("Loaded image: screwdrivercd/launcher:stable\n"))
+	assert.Equal(t, []string{"docker.io/screwdrivercd/launcher:stable", "docker.io/screwdrivercd/launcher:v6"},
+		loadedImages("Getting image source signatures\nLoaded image(s): docker.io/screwdrivercd/launcher:stable,docker.io/screwdrivercd/launcher:v6\n"))
+	assert.Equal(t, []string{"sha256:abc"}, loadedImages("Loaded image ID: sha256:abc\n"))
+	assert.Nil(t, loadedImages("open /tmp/launcher.tar: no such file or directory\n"))
+}
+
+func TestPullImagesOffline(t *testing.T) {
+	defer func() { execCommand = exec.Command }()
+
+	testCase := []struct {
+		name        string
+		id          string
+		expectError string
+	}{
+		{"with the local image", "SUCCESS_SETUP_BIN", ""},
+		{"without the local image", "SUCCESS_SETUP_BIN_MISSING", "image node:12 is not present locally, which can't be pulled offline"},
+	}
+
+	for _, tt := range testCase {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &docker{client: dockerClient{}, pullPolicy: PullAlways, offline: true}
+			for _, pull := range []func(string) error{d.pullLauncherImage, d.pullBuildImage} {
+				c := newFakeExecCommand(tt.id)
+				execCommand = c.execCmd
+				err := pull("node:12")
+
+				if tt.expectError == "" {
+					assert.Nil(t, err)
+				} else {
+					assert.EqualError(t, err, tt.expectError)
+				}
+				assert.Equal(t, []string{"docker image inspect --format {{.Id}} node:12"}, c.commands)
+			}
+		})
+	}
+}
+
+func TestValidatePullPolicy(t *testing.T) {
+	for _, policy := range []string{PullAlways, PullMissing, PullNever} {
+		assert.Nil(t, ValidatePullPolicy(policy))
+	}
+	assert.EqualError(t, ValidatePullPolicy("if-not-present"), "invalid pull policy if-not-present: must be one of always, missing or never")
+}
+
+func TestValidatePlatform(t *testing.T) {
+	testCase := []struct {
+		platform    string
+		expectError error
+	}{
+		{"linux/arm64", nil},
+		{"linux/arm/v7", nil},
+		{"arm64", fmt.Errorf("invalid platform arm64: must be in the form of os/arch[/variant] like linux/arm64")},
+		{"linux/", fmt.Errorf("invalid platform linux/: must be in the form of os/arch[/variant] like linux/arm64")},
+		{"linux/arm/v7/x", fmt.Errorf("invalid platform linux/arm/v7/x: must be in the form of os/arch[/variant] like linux/arm64")},
+	}
+
+	for _, tt := range testCase {
+		t.Run(tt.platform, func(t *testing.T) {
+			assert.Equal(t, tt.expectError, ValidatePlatform(tt.platform))
+		})
+	}
+}
+
+func TestRegistryHost(t *testing.T) {
+	testCase := []struct {
+		image  string
+		expect string
+	}{
+		{"node:12", "docker.io"},
+		{"screwdrivercd/launcher:stable", "docker.io"},
+		{"ghcr.io/org/node:12", "ghcr.io"},
+		{"localhost/node:12", "localhost"},
+		{"registry:5000/node:12", "registry:5000"},
+	}
+
+	for _, tt := range testCase {
+		t.Run(tt.image, func(t *testing.T) {
+			assert.Equal(t, tt.expect, registryHost(tt.image))
+		})
+	}
+}
+
 func TestSetupBinWithSudo(t *testing.T) {
 	defer func() {
 		execCommand = exec.Command
@@ -115,6 +433,7 @@ func TestSetupBinWithSudo(t *testing.T) {
 		volume:            "SD_LAUNCH_BIN",
 		setupImage:        "launcher",
 		setupImageVersion: "latest",
+		client:            dockerClient{},
 		useSudo:           true,
 	}
 
@@ -148,6 +467,7 @@ func TestRunBuild(t *testing.T) {
 		volume:            "SD_LAUNCH_BIN",
 		setupImage:        "launcher",
 		setupImageVersion: "latest",
+		client:            dockerClient{},
 		socketPath:        os.Getenv("SSH_AUTH_SOCK"),
 	}
 
@@ -161,17 +481,45 @@ func TestRunBuild(t *testing.T) {
 		{"success", "SUCCESS_RUN_BUILD", nil,
 			[]string{
 				"docker pull node:12",
-				fmt.Sprintf("docker container run --rm -v /:/sd/workspace/src/screwdriver.cd/sd-local/local-build -v sd-artifacts/:/test/artifacts -v %s:/opt/sd -v %s:/opt/sd/hab -v %s -e SSH_AUTH_SOCK=/tmp/auth.sock node:12 /opt/sd/local_run.sh ", d.volume, d.habVolume, sshSocket)},
+				fmt.Sprintf("docker container run --rm --label sdlocal.job=test -v /:/sd/workspace/src/screwdriver.cd/sd-local/local-build -v sd-artifacts/:/test/artifacts -v %s:/opt/sd -v %s:/opt/sd/hab -v %s -e SSH_AUTH_SOCK=/tmp/auth.sock node:12 /opt/sd/local_run.sh ", d.volume, d.habVolume, sshSocket)},
 			newBuildEntry()},
 		{"success with memory limit", "SUCCESS_RUN_BUILD", nil,
 			[]string{
 				"docker pull node:12",
-				fmt.Sprintf("docker container run -m2GB --rm -v /:/sd/workspace/src/screwdriver.cd/sd-local/local-build -v sd-artifacts/:/test/artifacts -v %s:/opt/sd -v %s:/opt/sd/hab -v %s -e SSH_AUTH_SOCK=/tmp/auth.sock node:12 /opt/sd/local_run.sh ", d.volume, d.habVolume, sshSocket)},
+				fmt.Sprintf("docker container run -m2GB --rm --label sdlocal.job=test -v /:/sd/workspace/src/screwdriver.cd/sd-local/local-build -v sd-artifacts/:/test/artifacts -v %s:/opt/sd -v %s:/opt/sd/hab -v %s -e SSH_AUTH_SOCK=/tmp/auth.sock node:12 /opt/sd/local_run.sh ", d.volume, d.habVolume, sshSocket)},
 			newBuildEntry(func(b *buildEntry) {
 				b.MemoryLimit = "2GB"
 			})},
+		{"success with cpu and memory limits", "SUCCESS_RUN_BUILD", nil,
+			[]string{
+				"docker pull node:12",
+				fmt.Sprintf("docker container run --cpus=6 -m12288m --rm --label sdlocal.job=test -v /:/sd/workspace/src/screwdriver.cd/sd-local/local-build -v sd-artifacts/:/test/artifacts -v %s:/opt/sd -v %s:/opt/sd/hab -v %s -e SSH_AUTH_SOCK=/tmp/auth.sock node:12 /opt/sd/local_run.sh ", d.volume, d.habVolume, sshSocket)},
+			newBuildEntry(func(b *buildEntry) {
+				b.MemoryLimit = "12288m"
+				b.CPULimit = "6"
+			})},
+		{"success with secrets", "SUCCESS_RUN_BUILD_SECRETS", nil,
+			[]string{
+				"docker pull node:12",
+				fmt.Sprintf("docker container run --rm --label sdlocal.job=test -v /:/sd/workspace/src/screwdriver.cd/sd-local/local-build -v sd-artifacts/:/test/artifacts -v %s:/opt/sd -v %s:/opt/sd/hab -v %s -e API_KEY -e DB_PASSWORD -e SSH_AUTH_SOCK=/tmp/auth.sock node:12 /opt/sd/local_run.sh ", d.volume, d.habVolume, sshSocket)},
+			newBuildEntry(func(b *buildEntry) {
+				b.Secrets = EnvVar{"DB_PASSWORD": "dbpassword", "API_KEY": "apikey"}
+			})},
 		{"failure build run", "FAIL_BUILD_CONTAINER_RUN", fmt.Errorf("failed to run build container: exit status 1"), []string{}, newBuildEntry()},
 		{"failure build image pull", "FAIL_BUILD_IMAGE_PULL", fmt.Errorf("failed to pull user image exit status 1"), []string{}, newBuildEntry()},
+		{"success with shell", "SUCCESS_RUN_BUILD", nil,
+			[]string{
+				"docker pull node:12",
+				"docker container run --rm --entrypoint /bin/bash node:12 -c true",
+				fmt.Sprintf("docker container run --rm --label sdlocal.job=test -v /:/sd/workspace/src/screwdriver.cd/sd-local/local-build -v sd-artifacts/:/test/artifacts -v %s:/opt/sd -v %s:/opt/sd/hab -v %s -e SSH_AUTH_SOCK=/tmp/auth.sock node:12 /opt/sd/local_run.sh ", d.volume, d.habVolume, sshSocket)},
+			newBuildEntry(func(b *buildEntry) {
+				b.Environment[0][ShellEnv] = "/bin/bash"
+			})},
+		{"failure shell not found", "FAIL_BUILD_CONTAINER_RUN", fmt.Errorf("shell /bin/bash is not found in the image node:12, install it in the image or change the shell: exit status 1"),
+			[]string{"docker pull node:12", "docker container run --rm --entrypoint /bin/bash node:12 -c true"},
+			newBuildEntry(func(b *buildEntry) {
+				b.Environment[0][ShellEnv] = "/bin/bash"
+			})},
 	}
 
 	for _, tt := range testCase {
@@ -191,6 +539,162 @@ func TestRunBuild(t *testing.T) {
 	}
 }
 
+func TestRunBuildWithPodman(t *testing.T) {
+	defer func() {
+		execCommand = exec.Command
+	}()
+
+	d := &docker{
+		volume:            "SD_LAUNCH_BIN",
+		setupImage:        "launcher",
+		setupImageVersion: "latest",
+		client:            podmanClient{},
+		socketPath:        os.Getenv("SSH_AUTH_SOCK"),
+	}
+
+	c := newFakeExecCommand("SUCCESS_RUN_BUILD")
+	execCommand = c.execCmd
+	err := d.runBuild(newBuildEntry())
+
+	assert.Nil(t, err)
+	assert.Equal(t, "podman pull node:12", c.commands[0])
+	expectedCommand := fmt.Sprintf("podman container run --rm --label sdlocal.job=test -v /:/sd/workspace/src/screwdriver.cd/sd-local/local-build -v sd-artifacts/:/test/artifacts -v %s:/opt/sd -v %s:/opt/sd/hab -v %s --security-opt label=disable -e SSH_AUTH_SOCK=/tmp/auth.sock node:12 /opt/sd/local_run.sh ", d.volume, d.habVolume, sshSocket)
+	assert.True(t, strings.Contains(c.commands[1], expectedCommand), "expect %q \nbut got \n%q", expectedCommand, c.commands[1])
+}
+
+func TestRunBuildWithContainerName(t *testing.T) {
+	defer func() {
+		execCommand = exec.Command
+	}()
+
+	d := &docker{
+		volume:            "SD_LAUNCH_BIN",
+		setupImage:        "launcher",
+		setupImageVersion: "latest",
+		client:            dockerClient{},
+		socketPath:        os.Getenv("SSH_AUTH_SOCK"),
+	}
+
+	c := newFakeExecCommand("SUCCESS_RUN_BUILD")
+	execCommand = c.execCmd
+	err := d.runBuild(newBuildEntry(func(b *buildEntry) {
+		b.ContainerName = "sdlocal-test-20210102150405"
+		b.EntryName = "default"
+	}))
+
+	assert.Nil(t, err)
+	assert.Equal(t, "sdlocal-test-20210102150405", d.buildContainer)
+	expectedCommand := fmt.Sprintf("docker container run --rm --name sdlocal-test-20210102150405 --label sdlocal.job=test --label sdlocal.entry=default -v /:/sd/workspace/src/screwdriver.cd/sd-local/local-build -v sd-artifacts/:/test/artifacts -v %s:/opt/sd -v %s:/opt/sd/hab -v %s -e SSH_AUTH_SOCK=/tmp/auth.sock node:12 /opt/sd/local_run.sh ", d.volume, d.habVolume, sshSocket)
+	assert.True(t, strings.Contains(c.commands[1], expectedCommand), "expect %q \nbut got \n%q", expectedCommand, c.commands[1])
+}
+
+func TestRunBuildWithUser(t *testing.T) {
+	defer func() {
+		execCommand = exec.Command
+	}()
+
+	d := &docker{
+		volume:            "SD_LAUNCH_BIN",
+		setupImage:        "launcher",
+		setupImageVersion: "latest",
+		client:            dockerClient{},
+		socketPath:        os.Getenv("SSH_AUTH_SOCK"),
+	}
+
+	c := newFakeExecCommand("SUCCESS_RUN_BUILD")
+	execCommand = c.execCmd
+	err := d.runBuild(newBuildEntry(func(b *buildEntry) {
+		b.User = "1000:1000"
+	}))
+
+	assert.Nil(t, err)
+	expectedCommand := fmt.Sprintf("docker container run --user 1000:1000 --rm --label sdlocal.job=test -v /:/sd/workspace/src/screwdriver.cd/sd-local/local-build -v sd-artifacts/:/test/artifacts -v %s:/opt/sd -v %s:/opt/sd/hab -v %s -e SSH_AUTH_SOCK=/tmp/auth.sock node:12 /opt/sd/local_run.sh ", d.volume, d.habVolume, sshSocket)
+	assert.True(t, strings.Contains(c.commands[1], expectedCommand), "expect %q \nbut got \n%q", expectedCommand, c.commands[1])
+}
+
+func TestRunBuildWithNoTeardown(t *testing.T) {
+	defer func() {
+		execCommand = exec.Command
+		logrus.SetOutput(os.Stderr)
+	}()
+
+	testCase := []struct {
+		name             string
+		id               string
+		expectError      error
+		expectedCommands []string
+		expectKept       string
+		resumeContainer  string
+	}{
+		{"success", "SUCCESS_RUN_BUILD", nil,
+			[]string{
+				"docker pull node:12",
+				fmt.Sprintf("docker container run -d --label sdlocal.job=test -v /:/sd/workspace/src/screwdriver.cd/sd-local/local-build -v sd-artifacts/:/test/artifacts -v SD_LAUNCH_BIN:/opt/sd -v SD_LAUNCH_HAB:/opt/sd/hab -v %s -e SSH_AUTH_SOCK=/tmp/auth.sock node:12 /bin/sh -c %s", sshSocket, keepAliveScript),
+				"docker container exec SUCCESS_RUN_BUILD /opt/sd/local_run.sh ",
+				"docker container rm --force --volumes SUCCESS_RUN_BUILD",
+			}, "", ""},
+		{"failure build to keep the container", "FAIL_BUILD_CONTAINER_EXEC", fmt.Errorf("failed to run build container: exit status 1"),
+			[]string{
+				"docker pull node:12",
+				"docker container run -d",
+				"docker container exec FAIL_BUILD_CONTAINER_EXEC /opt/sd/local_run.sh ",
+			}, "FAIL_BUILD_CONTAINER_EXEC", ""},
+		{"resume in the kept container", "SUCCESS_RESUME_BUILD", nil,
+			[]string{
+				"docker pull node:12",
+				"docker container inspect --format {{.State.Running}} kept",
+				"docker container exec kept /opt/sd/local_run.sh ",
+				"docker container rm --force --volumes kept",
+			}, "", "kept"},
+		{"resume in a new container if the kept container is not running", "SUCCESS_RUN_BUILD", nil,
+			[]string{
+				"docker pull node:12",
+				"docker container inspect --format {{.State.Running}} removed",
+				"docker container run -d",
+				"docker container exec SUCCESS_RUN_BUILD /opt/sd/local_run.sh ",
+				"docker container rm --force --volumes SUCCESS_RUN_BUILD",
+			}, "", "removed"},
+		{"failure resumed build to keep the container again", "FAIL_RESUME_BUILD", fmt.Errorf("failed to run build container: exit status 1"),
+			[]string{
+				"docker pull node:12",
+				"docker container inspect --format {{.State.Running}} kept",
+				"docker container exec kept /opt/sd/local_run.sh ",
+			}, "kept", "kept"},
+	}
+
+	for _, tt := range testCase {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &docker{
+				volume:            "SD_LAUNCH_BIN",
+				habVolume:         "SD_LAUNCH_HAB",
+				setupImage:        "launcher",
+				setupImageVersion: "latest",
+				client:            dockerClient{},
+				socketPath:        os.Getenv("SSH_AUTH_SOCK"),
+				noTeardown:        true,
+			}
+
+			buf := bytes.NewBuffer(nil)
+			logrus.SetOutput(buf)
+
+			c := newFakeExecCommand(tt.id)
+			execCommand = c.execCmd
+			buildEntry := newBuildEntry()
+			buildEntry.ResumeContainer = tt.resumeContainer
+			err := d.runBuild(buildEntry)
+			assert.Equal(t, len(tt.expectedCommands), len(c.commands))
+			for i, expectedCommand := range tt.expectedCommands {
+				assert.True(t, strings.Contains(c.commands[i], expectedCommand), "expect %q \nbut got \n%q", expectedCommand, c.commands[i])
+			}
+			assert.Equal(t, tt.expectError, err)
+			assert.Equal(t, tt.expectKept, d.keptContainer)
+			if tt.expectKept != "" {
+				assert.Contains(t, buf.String(), fmt.Sprintf("docker exec -it %s /bin/sh", tt.expectKept))
+			}
+		})
+	}
+}
+
 func TestRunBuildWithSudo(t *testing.T) {
 	defer func() {
 		execCommand = exec.Command
@@ -200,6 +704,7 @@ func TestRunBuildWithSudo(t *testing.T) {
 		volume:            "SD_LAUNCH_BIN",
 		setupImage:        "launcher",
 		setupImageVersion: "latest",
+		client:            dockerClient{},
 		useSudo:           true,
 		socketPath:        os.Getenv("SSH_AUTH_SOCK"),
 	}
@@ -214,15 +719,22 @@ func TestRunBuildWithSudo(t *testing.T) {
 		{"success", "SUCCESS_RUN_BUILD_SUDO", nil,
 			[]string{
 				"sudo docker pull node:12",
-				fmt.Sprintf("sudo docker container run --rm -v /:/sd/workspace/src/screwdriver.cd/sd-local/local-build -v sd-artifacts/:/test/artifacts -v %s:/opt/sd -v %s:/opt/sd/hab -v %s -e SSH_AUTH_SOCK=/tmp/auth.sock node:12 /opt/sd/local_run.sh ", d.volume, d.habVolume, sshSocket)},
+				fmt.Sprintf("sudo docker container run --rm --label sdlocal.job=test -v /:/sd/workspace/src/screwdriver.cd/sd-local/local-build -v sd-artifacts/:/test/artifacts -v %s:/opt/sd -v %s:/opt/sd/hab -v %s -e SSH_AUTH_SOCK=/tmp/auth.sock node:12 /opt/sd/local_run.sh ", d.volume, d.habVolume, sshSocket)},
 			newBuildEntry()},
 		{"success with memory limit", "SUCCESS_RUN_BUILD_SUDO", nil,
 			[]string{
 				"sudo docker pull node:12",
-				fmt.Sprintf("sudo docker container run -m2GB --rm -v /:/sd/workspace/src/screwdriver.cd/sd-local/local-build -v sd-artifacts/:/test/artifacts -v %s:/opt/sd -v %s:/opt/sd/hab -v %s -e SSH_AUTH_SOCK=/tmp/auth.sock node:12 /opt/sd/local_run.sh ", d.volume, d.habVolume, sshSocket)},
+				fmt.Sprintf("sudo docker container run -m2GB --rm --label sdlocal.job=test -v /:/sd/workspace/src/screwdriver.cd/sd-local/local-build -v sd-artifacts/:/test/artifacts -v %s:/opt/sd -v %s:/opt/sd/hab -v %s -e SSH_AUTH_SOCK=/tmp/auth.sock node:12 /opt/sd/local_run.sh ", d.volume, d.habVolume, sshSocket)},
 			newBuildEntry(func(b *buildEntry) {
 				b.MemoryLimit = "2GB"
 			})},
+		{"success with secrets", "SUCCESS_RUN_BUILD_SECRETS_SUDO", nil,
+			[]string{
+				"sudo docker pull node:12",
+				fmt.Sprintf("sudo --preserve-env=API_KEY,DB_PASSWORD docker container run --rm --label sdlocal.job=test -v /:/sd/workspace/src/screwdriver.cd/sd-local/local-build -v sd-artifacts/:/test/artifacts -v %s:/opt/sd -v %s:/opt/sd/hab -v %s -e API_KEY -e DB_PASSWORD -e SSH_AUTH_SOCK=/tmp/auth.sock node:12 /opt/sd/local_run.sh ", d.volume, d.habVolume, sshSocket)},
+			newBuildEntry(func(b *buildEntry) {
+				b.Secrets = EnvVar{"DB_PASSWORD": "dbpassword", "API_KEY": "apikey"}
+			})},
 		{"failure build run", "FAIL_BUILD_CONTAINER_RUN_SUDO", fmt.Errorf("failed to run build container: exit status 1"), []string{}, newBuildEntry()},
 		{"failure build image pull", "FAIL_BUILD_IMAGE_PULL_SUDO", fmt.Errorf("failed to pull user image exit status 1"), []string{}, newBuildEntry()},
 	}
@@ -253,6 +765,7 @@ func TestRunBuildWithInteractiveMode(t *testing.T) {
 		volume:            "SD_LAUNCH_BIN",
 		setupImage:        "launcher",
 		setupImageVersion: "latest",
+		client:            dockerClient{},
 		useSudo:           true,
 		interactiveMode:   true,
 		interact:          &mockInteract{},
@@ -269,13 +782,13 @@ func TestRunBuildWithInteractiveMode(t *testing.T) {
 		{"success", "SUCCESS_RUN_BUILD_INTERACT", nil,
 			[]string{
 				"sudo docker pull node:12",
-				fmt.Sprintf("sudo docker container run -itd --rm -v /:/sd/workspace/src/screwdriver.cd/sd-local/local-build -v sd-artifacts/:/test/artifacts -v %s:/opt/sd -v %s:/opt/sd/hab -v %s -e SSH_AUTH_SOCK=/tmp/auth.sock node:12 /bin/sh", d.volume, d.habVolume, sshSocket),
+				fmt.Sprintf("sudo docker container run -itd --rm --label sdlocal.job=test -v /:/sd/workspace/src/screwdriver.cd/sd-local/local-build -v sd-artifacts/:/test/artifacts -v %s:/opt/sd -v %s:/opt/sd/hab -v %s -e SSH_AUTH_SOCK=/tmp/auth.sock node:12 /bin/sh", d.volume, d.habVolume, sshSocket),
 				"sudo docker attach "},
 			newBuildEntry()},
 		{"success with memory limit", "SUCCESS_RUN_BUILD_INTERACT", nil,
 			[]string{
 				"sudo docker pull node:12",
-				fmt.Sprintf("sudo docker container run -m2GB -itd --rm -v /:/sd/workspace/src/screwdriver.cd/sd-local/local-build -v sd-artifacts/:/test/artifacts -v %s:/opt/sd -v %s:/opt/sd/hab -v %s -e SSH_AUTH_SOCK=/tmp/auth.sock node:12 /bin/sh", d.volume, d.habVolume, sshSocket),
+				fmt.Sprintf("sudo docker container run -m2GB -itd --rm --label sdlocal.job=test -v /:/sd/workspace/src/screwdriver.cd/sd-local/local-build -v sd-artifacts/:/test/artifacts -v %s:/opt/sd -v %s:/opt/sd/hab -v %s -e SSH_AUTH_SOCK=/tmp/auth.sock node:12 /bin/sh", d.volume, d.habVolume, sshSocket),
 				"sudo docker attach SUCCESS_RUN_BUILD_INTERACT"},
 			newBuildEntry(func(b *buildEntry) {
 				b.MemoryLimit = "2GB"
@@ -302,6 +815,41 @@ func TestRunBuildWithInteractiveMode(t *testing.T) {
 	}
 }
 
+func TestRunBuildWithInteractiveModeAndTeardown(t *testing.T) {
+	defer func() {
+		execCommand = exec.Command
+	}()
+
+	interact := &mockInteract{}
+	d := &docker{
+		volume:            "SD_LAUNCH_BIN",
+		setupImage:        "launcher",
+		setupImageVersion: "latest",
+		client:            dockerClient{},
+		interactiveMode:   true,
+		interact:          interact,
+		socketPath:        os.Getenv("SSH_AUTH_SOCK"),
+	}
+
+	c := newFakeExecCommand("SUCCESS_RUN_BUILD_INTERACT")
+	execCommand = c.execCmd
+	err := d.runBuild(newBuildEntry(func(b *buildEntry) {
+		b.Steps = append(b.Steps, screwdriver.Step{Name: "teardown-report", Command: "npm run report"})
+	}))
+
+	assert.Nil(t, err)
+	configJSON, err := strconv.Unquote(interact.commands[0][1])
+	assert.Nil(t, err)
+	var config buildEntry
+	err = json.Unmarshal([]byte(configJSON), &config)
+	assert.Nil(t, err)
+	assert.Equal(t, []screwdriver.Step{
+		{Name: "sd-local-init", Command: "export > /tmp/sd-local.env"},
+		{Name: "sd-local-teardown", Command: "cat > /tmp/sd-local-teardown.sh <<'SD_LOCAL_TEARDOWN'\necho '$ teardown-report'\nnpm run report\nSD_LOCAL_TEARDOWN"},
+	}, config.Steps)
+	assert.Equal(t, []string{"trap", "'. /tmp/sd-local-teardown.sh'", "EXIT"}, interact.commands[len(interact.commands)-1])
+}
+
 func TestDockerKill(t *testing.T) {
 	t.Run("success with no commands", func(t *testing.T) {
 		defer func() {
@@ -312,6 +860,7 @@ func TestDockerKill(t *testing.T) {
 			volume:            "SD_LAUNCH_BIN",
 			setupImage:        "launcher",
 			setupImageVersion: "latest",
+			client:            dockerClient{},
 			useSudo:           false,
 			mutex:             &sync.Mutex{},
 		}
@@ -334,6 +883,7 @@ func TestDockerKill(t *testing.T) {
 			volume:            "SD_LAUNCH_BIN",
 			setupImage:        "launcher",
 			setupImageVersion: "latest",
+			client:            dockerClient{},
 			useSudo:           false,
 			commands:          []*exec.Cmd{execCommand("sleep")},
 			mutex:             &sync.Mutex{},
@@ -357,6 +907,29 @@ func TestDockerKill(t *testing.T) {
 		assert.Equal(t, "", actual)
 	})
 
+	t.Run("success to remove the build container", func(t *testing.T) {
+		defer func() {
+			execCommand = exec.Command
+		}()
+		c := newFakeExecCommand("SUCCESS_TO_KILL")
+		execCommand = c.execCmd
+		d := &docker{
+			client:         dockerClient{},
+			mutex:          &sync.Mutex{},
+			buildContainer: "sd-local-build",
+		}
+
+		d.kill(syscall.SIGINT)
+		assert.Equal(t, []string{"docker container rm --force --volumes sd-local-build"}, c.commands)
+
+		// the container kept for debugging is not removed
+		c = newFakeExecCommand("SUCCESS_TO_KILL")
+		execCommand = c.execCmd
+		d.keptContainer = "kept"
+		d.kill(syscall.SIGINT)
+		assert.Empty(t, c.commands)
+	})
+
 	t.Run("failure", func(t *testing.T) {
 		defer func() {
 			execCommand = exec.Command
@@ -369,6 +942,7 @@ func TestDockerKill(t *testing.T) {
 			volume:            "SD_LAUNCH_BIN",
 			setupImage:        "launcher",
 			setupImageVersion: "latest",
+			client:            dockerClient{},
 			useSudo:           false,
 			commands:          []*exec.Cmd{command},
 			mutex:             &sync.Mutex{},
@@ -377,7 +951,8 @@ func TestDockerKill(t *testing.T) {
 		d.commands[0].Start()
 		PidTmp := d.commands[0].Process.Pid
 		defer func() {
-			syscall.Kill(PidTmp, syscall.SIGINT)
+			p, _ := os.FindProcess(PidTmp)
+			p.Signal(os.Interrupt)
 		}()
 		d.commands[0].Process.Pid = 0
 
@@ -401,6 +976,7 @@ func TestDockerKill(t *testing.T) {
 			volume:            "SD_LAUNCH_BIN",
 			setupImage:        "launcher",
 			setupImageVersion: "latest",
+			client:            dockerClient{},
 			useSudo:           true,
 			commands:          []*exec.Cmd{execCommand("sleep")},
 			mutex:             &sync.Mutex{},
@@ -420,6 +996,37 @@ func TestDockerKill(t *testing.T) {
 	})
 }
 
+func TestDockerKillFinishedCommands(t *testing.T) {
+	defer logrus.SetOutput(os.Stderr)
+	finished := exec.Command("true")
+	if err := finished.Run(); err != nil {
+		t.Fatal(err)
+	}
+	d := &docker{
+		volume:            "SD_LAUNCH_BIN",
+		setupImage:        "launcher",
+		setupImageVersion: "latest",
+		client:            dockerClient{},
+		// the command which is not started yet has no process to kill
+		commands: []*exec.Cmd{finished, exec.Command("true"), finished},
+		mutex:    &sync.Mutex{},
+	}
+	buf := bytes.NewBuffer(nil)
+	logrus.SetOutput(buf)
+
+	done := make(chan struct{})
+	go func() {
+		d.kill(syscall.SIGTERM)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("kill did not return with the finished commands")
+	}
+	assert.Equal(t, "", buf.String())
+}
+
 func TestDockerClean(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
 		defer func() {
@@ -432,6 +1039,7 @@ func TestDockerClean(t *testing.T) {
 			volume:            "SD_LAUNCH_BIN",
 			setupImage:        "launcher",
 			setupImageVersion: "latest",
+			client:            dockerClient{},
 			commands:          []*exec.Cmd{},
 			useSudo:           false,
 		}
@@ -452,6 +1060,7 @@ func TestDockerClean(t *testing.T) {
 			volume:            "SD_LAUNCH_BIN",
 			setupImage:        "launcher",
 			setupImageVersion: "latest",
+			client:            dockerClient{},
 			commands:          []*exec.Cmd{},
 			useSudo:           true,
 		}
@@ -472,6 +1081,7 @@ func TestDockerClean(t *testing.T) {
 			volume:            "SD_LAUNCH_BIN",
 			setupImage:        "launcher",
 			setupImageVersion: "latest",
+			client:            dockerClient{},
 			commands:          []*exec.Cmd{},
 			useSudo:           false,
 		}
@@ -484,6 +1094,24 @@ func TestDockerClean(t *testing.T) {
 		expected := "failed to remove volume:"
 		assert.True(t, strings.Contains(buf.String(), expected), fmt.Sprintf("\nexpected: %s \nactual: %s\n", expected, buf.String()))
 	})
+
+	t.Run("success to keep the volumes for the kept container", func(t *testing.T) {
+		defer func() {
+			execCommand = exec.Command
+		}()
+		c := newFakeExecCommand("SUCCESS_TO_CLEAN")
+		execCommand = c.execCmd
+		d := &docker{
+			habVolume:     "SD_LAUNCH_HAB",
+			volume:        "SD_LAUNCH_BIN",
+			client:        dockerClient{},
+			commands:      []*exec.Cmd{},
+			keptContainer: "cid",
+		}
+
+		d.clean()
+		assert.Equal(t, []string{}, c.commands)
+	})
 }
 
 func TestHelperProcess(t *testing.T) {
@@ -523,6 +1151,67 @@ func TestHelperProcess(t *testing.T) {
 		os.Exit(0)
 	case "SUCCESS_SETUP_BIN_INTERACT":
 		os.Exit(0)
+	case "SUCCESS_SETUP_BIN_MISSING":
+		if subcmd == "image" {
+			os.Exit(1)
+		}
+		os.Exit(0)
+	case "SUCCESS_LOAD_LAUNCHER":
+		if subcmd == "image" && args[0] == "load" {
+			fmt.Print("\nLoaded image: launcher:latest\n")
+		}
+		os.Exit(0)
+	case "FAIL_LOAD_LAUNCHER_MISMATCH":
+		if subcmd == "image" && args[0] == "load" {
+			fmt.Print("\nLoaded image: launcher:old\n")
+			os.Exit(0)
+		}
+		if subcmd == "image" {
+			os.Exit(1)
+		}
+		os.Exit(0)
+	case "FAIL_LOAD_LAUNCHER":
+		if subcmd == "image" {
+			os.Exit(1)
+		}
+		os.Exit(0)
+	case "SUCCESS_SETUP_BIN_DIGEST":
+		if subcmd == "image" {
+			fmt.Printf("\nlauncher@sha256:%s\n", strings.Repeat("a", 64))
+		}
+		os.Exit(0)
+	case "FAIL_SETUP_BIN_DIGEST_MISMATCH":
+		if subcmd == "image" {
+			fmt.Printf("\nlauncher@sha256:%s\n", strings.Repeat("b", 64))
+		}
+		os.Exit(0)
+	case "FAIL_SETUP_BIN_DIGEST_INSPECT":
+		if subcmd == "image" {
+			os.Exit(1)
+		}
+		os.Exit(0)
+	case "SUCCESS_PULL_IMAGE":
+		os.Exit(0)
+	case "FAIL_PULL_IMAGE":
+		os.Exit(1)
+	case "SUCCESS_RUN_BUILD_SECRETS", "SUCCESS_RUN_BUILD_SECRETS_SUDO":
+		// the values of the secrets are passed only in the environment of the container CLI
+		if subcmd != "pull" && (os.Getenv("API_KEY") != "apikey" || os.Getenv("DB_PASSWORD") != "dbpassword") {
+			os.Exit(1)
+		}
+		os.Exit(0)
+	case "FAIL_PULL_PLATFORM":
+		fmt.Fprintf(os.Stderr, "Error response from daemon: no matching manifest for linux/arm64/v8 in the manifest list entries\n")
+		os.Exit(1)
+	case "FAIL_PULL_TIMEOUT":
+		fmt.Fprintf(os.Stderr, "Error response from daemon: Get \"https://registry-1.docker.io/v2/\": net/http: TLS handshake timeout\n")
+		os.Exit(1)
+	case "FAIL_PERMISSION_DENIED":
+		fmt.Fprintf(os.Stderr, "Got permission denied while trying to connect to the Docker daemon socket at unix:///var/run/docker.sock: connect: permission denied\n")
+		os.Exit(1)
+	case "FAIL_PULL_UNAUTHORIZED":
+		fmt.Fprintf(os.Stderr, "Error response from daemon: unauthorized: authentication required\n")
+		os.Exit(1)
 	case "FAIL_CREATING_VOLUME":
 		os.Exit(1)
 	case "FAIL_CREATING_VOLUME_SUDO":
@@ -564,6 +1253,24 @@ func TestHelperProcess(t *testing.T) {
 			os.Exit(0)
 		}
 		os.Exit(1)
+	case "FAIL_BUILD_CONTAINER_EXEC":
+		if subcmd == "container" && args[0] == "exec" {
+			os.Exit(1)
+		}
+		os.Exit(0)
+	case "SUCCESS_RESUME_BUILD":
+		if subcmd == "container" && args[0] == "inspect" {
+			fmt.Print("true")
+		}
+		os.Exit(0)
+	case "FAIL_RESUME_BUILD":
+		if subcmd == "container" && args[0] == "inspect" {
+			fmt.Print("true")
+		}
+		if subcmd == "container" && args[0] == "exec" {
+			os.Exit(1)
+		}
+		os.Exit(0)
 	case "FAIL_BUILD_CONTAINER_ATTACH_INTERACT":
 		if subcmd == "attach" {
 			os.Exit(1)
@@ -606,9 +1313,130 @@ func TestHelperProcess(t *testing.T) {
 			os.Exit(0)
 		}
 		os.Exit(1)
+	case "SUCCESS_K8S_RUN_BUILD":
+		os.Exit(0)
+	case "FAIL_K8S_APPLY":
+		if subcmd == "apply" {
+			os.Exit(1)
+		}
+		os.Exit(0)
+	case "FAIL_K8S_WAIT":
+		if subcmd == "wait" {
+			os.Exit(1)
+		}
+		os.Exit(0)
+	case "FAIL_K8S_COPY_SRC":
+		if subcmd == "cp" {
+			os.Exit(1)
+		}
+		os.Exit(0)
+	case "FAIL_K8S_RUN_BUILD":
+		if subcmd == "exec" && strings.Contains(strings.Join(args, " "), "local_run.sh") {
+			os.Exit(1)
+		}
+		os.Exit(0)
+	case "FAIL_K8S_SHELL":
+		if subcmd == "exec" && strings.Contains(strings.Join(args, " "), "-c true") {
+			os.Exit(1)
+		}
+		os.Exit(0)
 	case "SUCCESS_TO_CLEAN":
 		os.Exit(0)
 	case "FAIL_TO_CLEAN":
 		os.Exit(1)
 	}
 }
+
+func TestIgnoredMounts(t *testing.T) {
+	assert.Equal(t, []string{
+		"/sd/workspace/src/screwdriver.cd/sd-local/local-build/node_modules",
+		"/dev/null:/sd/workspace/src/screwdriver.cd/sd-local/local-build/web/debug.log:ro",
+	}, ignoredMounts([]string{"node_modules/", "web/debug.log"}))
+	assert.Equal(t, []string{}, ignoredMounts(nil))
+
+	d := &docker{volume: "SD_LAUNCH_BIN", habVolume: "SD_LAUNCH_HAB", socketPath: "/auth.sock"}
+	buildEntry := newBuildEntry(func(b *buildEntry) {
+		b.SrcPath = "/src"
+		b.IgnoredPaths = []string{"node_modules/"}
+	})
+	assert.Equal(t, []string{
+		"/src/:/sd/workspace/src/screwdriver.cd/sd-local/local-build",
+		"sd-artifacts/:/test/artifacts",
+		"SD_LAUNCH_BIN:/opt/sd",
+		"SD_LAUNCH_HAB:/opt/sd/hab",
+		"/auth.sock:/tmp/auth.sock:rw",
+		"/sd/workspace/src/screwdriver.cd/sd-local/local-build/node_modules",
+	}, d.mounts(buildEntry))
+}
+
+func TestMetaMount(t *testing.T) {
+	d := &docker{volume: "SD_LAUNCH_BIN", habVolume: "SD_LAUNCH_HAB", socketPath: "/auth.sock"}
+	buildEntry := newBuildEntry(func(b *buildEntry) {
+		b.SrcPath = "/src"
+		b.MetaPath = "/tmp/sd-local-meta"
+	})
+	assert.Equal(t, []string{
+		"/src/:/sd/workspace/src/screwdriver.cd/sd-local/local-build",
+		"sd-artifacts/:/test/artifacts",
+		"SD_LAUNCH_BIN:/opt/sd",
+		"SD_LAUNCH_HAB:/opt/sd/hab",
+		"/auth.sock:/tmp/auth.sock:rw",
+		"/tmp/sd-local-meta/:/sd/meta",
+	}, d.mounts(buildEntry))
+}
+
+func TestPermissionDenied(t *testing.T) {
+	defer func() { execCommand = exec.Command }()
+
+	testCase := []struct {
+		name        string
+		useSudo     bool
+		expectError string
+	}{
+		{"suggest sudo", false, "exit status 1: permission denied to access the docker daemon, add the user to the docker group or run with --sudo"},
+		{"with sudo", true, "exit status 1"},
+	}
+
+	for _, tt := range testCase {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &docker{client: dockerClient{}, useSudo: tt.useSudo}
+			c := newFakeExecCommand("FAIL_PERMISSION_DENIED")
+			execCommand = c.execCmd
+			_, err := d.execDockerCommand("volume", "create", "SD_LAUNCH_BIN")
+
+			assert.EqualError(t, err, tt.expectError)
+		})
+	}
+}
+
+func TestContainerCommand(t *testing.T) {
+	testCase := []struct {
+		name      string
+		client    containerClient
+		useSudo   bool
+		host      string
+		env       []string
+		expectCmd []string
+		expectEnv []string
+	}{
+		{"without host", dockerClient{}, false, "", nil, []string{"docker", "ps"}, nil},
+		{"docker host", dockerClient{}, false, "tcp://192.168.1.10:2376", nil, []string{"docker", "ps"}, []string{"DOCKER_HOST=tcp://192.168.1.10:2376"}},
+		{"podman host", podmanClient{}, false, "unix:///run/podman.sock", nil, []string{"podman", "ps"}, []string{"CONTAINER_HOST=unix:///run/podman.sock"}},
+		{"docker host with sudo", dockerClient{}, true, "tcp://192.168.1.10:2376", []string{"API_KEY=apikey"},
+			[]string{"sudo", "--preserve-env=DOCKER_HOST,API_KEY", "docker", "ps"}, []string{"DOCKER_HOST=tcp://192.168.1.10:2376", "API_KEY=apikey"}},
+	}
+
+	for _, tt := range testCase {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &docker{client: tt.client, useSudo: tt.useSudo, host: tt.host}
+			cmd := d.containerCommand(tt.env, "ps")
+
+			assert.Equal(t, tt.expectCmd, cmd.Args)
+			if tt.expectEnv == nil {
+				assert.Nil(t, cmd.Env)
+				return
+			}
+			assert.Equal(t, tt.expectEnv, cmd.Env[len(cmd.Env)-len(tt.expectEnv):])
+		})
+	}
+}
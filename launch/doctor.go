@@ -0,0 +1,186 @@
+package launch
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/screwdriver-cd/sd-local/config"
+)
+
+// runCommand runs the command and returns its error with the message in stderr
+func runCommand(args ...string) (string, error) {
+	return runCommandContext(context.Background(), args...)
+}
+
+// runCommandContext is runCommand which kills the command when ctx is done
+func runCommandContext(ctx context.Context, args ...string) (string, error) {
+	cmd := execCommand(args[0], args[1:]...)
+	stdout := bytes.NewBuffer(nil)
+	stderr := bytes.NewBuffer(nil)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	if err := cmd.Start(); err != nil {
+		return "", err
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = cmd.Process.Kill()
+		case <-done:
+		}
+	}()
+
+	if err := cmd.Wait(); err != nil {
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			err = fmt.Errorf("%v: %s", err, msg)
+		}
+		return "", err
+	}
+	return stdout.String(), nil
+}
+
+// CheckRuntime checks that the daemon of the runtime, or the cluster of the current context for k8s, is reachable.
+// The default runtime is docker.
+func CheckRuntime(runtime string) error {
+	return CheckRuntimeWith(runtime, false, "")
+}
+
+// CheckRuntimeWith is CheckRuntime which accesses the daemon of docker or podman at docker-host of the config with sudo like the builds.
+// The error tells whether the runtime is not installed, not running or not permitted to access.
+func CheckRuntimeWith(runtime string, useSudo bool, dockerHost string) error {
+	if runtime == "" {
+		runtime = config.RuntimeDocker
+	}
+	host := config.DockerHostURL(dockerHost)
+
+	var cmd *exec.Cmd
+	switch runtime {
+	case config.RuntimeKubernetes:
+		cmd = execCommand("kubectl", "cluster-info")
+	case config.RuntimePodman:
+		d := &docker{client: podmanClient{}, useSudo: useSudo, host: host}
+		cmd = d.containerCommand(nil, "info", "--format", "{{.Host.Arch}}")
+	default:
+		d := &docker{client: dockerClient{}, useSudo: useSudo, host: host}
+		cmd = d.containerCommand(nil, "info", "--format", "{{.OSType}}")
+	}
+
+	stdout := bytes.NewBuffer(nil)
+	stderr := bytes.NewBuffer(nil)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	err := cmd.Run()
+	if err == nil {
+		// Docker Desktop on Windows may be switched to the Windows containers, which can't run the Linux images of the builds
+		if runtime == config.RuntimeDocker && strings.TrimSpace(stdout.String()) == "windows" {
+			return errors.New("Docker runs Windows containers, which can't run the builds in the Linux containers; switch Docker Desktop to Linux containers and retry")
+		}
+		return nil
+	}
+	return runtimeError(runtime, useSudo, err, stderr.String())
+}
+
+// runtimeNames are the names of the runtimes in the messages
+var runtimeNames = map[string]string{
+	config.RuntimeDocker:     "Docker",
+	config.RuntimePodman:     "Podman",
+	config.RuntimeKubernetes: "the cluster of the current context of kubectl",
+}
+
+// notRunningMessages are the messages of the clients which can't connect to the daemon or the cluster
+var notRunningMessages = []string{
+	"cannot connect to the docker daemon",
+	"is the docker daemon running",
+	"cannot connect to podman",
+	"unable to connect to podman",
+	"unable to connect to the server",
+	"connection refused",
+	"was refused",
+	"no such file or directory",
+}
+
+// runtimeError returns the error of the failed check of the runtime with the way to fix it
+func runtimeError(runtime string, useSudo bool, err error, stderr string) error {
+	command := runtime
+	if runtime == config.RuntimeKubernetes {
+		command = "kubectl"
+	}
+	// sudo prints that the command is not found instead of failing to run it
+	var execErr *exec.Error
+	if errors.As(err, &execErr) {
+		return fmt.Errorf("%s is not installed or not found in PATH; install it and retry", execErr.Name)
+	}
+	if useSudo && strings.Contains(stderr, command+": command not found") {
+		return fmt.Errorf("%s is not installed or not found in PATH; install it and retry", command)
+	}
+
+	if !useSudo && runtime != config.RuntimeKubernetes && isPermissionDenied(stderr) {
+		return fmt.Errorf("permission denied to access the %s daemon; add the user to the %s group or run with --sudo", command, command)
+	}
+
+	lower := strings.ToLower(stderr)
+	for _, m := range notRunningMessages {
+		if strings.Contains(lower, m) {
+			if runtime == config.RuntimeKubernetes {
+				return fmt.Errorf("%s does not appear to be running; start it or switch the context and retry", runtimeNames[runtime])
+			}
+			return fmt.Errorf("%s does not appear to be running; start it and retry", runtimeNames[runtime])
+		}
+	}
+
+	if msg := strings.TrimSpace(stderr); msg != "" {
+		err = fmt.Errorf("%v: %s", err, msg)
+	}
+	return fmt.Errorf("failed to connect to %s: %v", runtime, err)
+}
+
+// PullLauncher pulls the launcher image with the credentials in the registry auth directory.
+// The runtime must be docker or podman, the images are pulled by the nodes of the cluster with k8s.
+func PullLauncher(runtime string, launcher config.Launcher, registryAuth string) error {
+	return PullLauncherContext(context.Background(), runtime, launcher, registryAuth)
+}
+
+// PullLauncherContext is PullLauncher which stops pulling the image when ctx is done
+func PullLauncherContext(ctx context.Context, runtime string, launcher config.Launcher, registryAuth string) error {
+	var client containerClient = dockerClient{}
+	if runtime == config.RuntimePodman {
+		client = podmanClient{}
+	}
+
+	image := launcherImage(launcher.Image, launcher.Version)
+	args := append([]string{client.command()}, client.pullArgs(registryAuth, image)...)
+	_, err := runCommandContext(ctx, args...)
+	if err != nil && isUnauthorized(err.Error()) {
+		registry := registryHost(image)
+		return fmt.Errorf("not authorized to pull %s from %s", image, registry)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to pull %s: %v", image, err)
+	}
+	return nil
+}
+
+// FindContainers returns the names of the build containers of sd-local which are found by the label,
+// like the ones left by the interrupted builds or kept for debugging. The runtime must be docker or podman.
+func FindContainers(runtime string) ([]string, error) {
+	var client containerClient = dockerClient{}
+	if runtime == config.RuntimePodman {
+		client = podmanClient{}
+	}
+
+	out, err := runCommand(client.command(), "container", "ls", "--all", "--filter", "label="+LabelJob, "--format", "{{.Names}}")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the containers: %v", err)
+	}
+	return strings.Fields(out), nil
+}
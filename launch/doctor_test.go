@@ -0,0 +1,284 @@
+package launch
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/screwdriver-cd/sd-local/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckRuntime(t *testing.T) {
+	cases := []struct {
+		name          string
+		runtime       string
+		fail          bool
+		expectCommand []string
+		expectErr     string
+	}{
+		{
+			name:          "docker",
+			expectCommand: []string{"docker", "info", "--format", "{{.OSType}}"},
+		},
+		{
+			name:          "podman",
+			runtime:       "podman",
+			expectCommand: []string{"podman", "info", "--format", "{{.Host.Arch}}"},
+		},
+		{
+			name:          "k8s",
+			runtime:       "k8s",
+			expectCommand: []string{"kubectl", "cluster-info"},
+		},
+		{
+			name:          "failure by command",
+			fail:          true,
+			expectCommand: []string{"docker", "info", "--format", "{{.OSType}}"},
+			expectErr:     "failed to connect to docker: exit status 1",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			defer func() { execCommand = exec.Command }()
+			var command []string
+			execCommand = func(name string, args ...string) *exec.Cmd {
+				command = append([]string{name}, args...)
+				if c.fail {
+					return exec.Command("false")
+				}
+				return exec.Command("true")
+			}
+
+			err := CheckRuntime(c.runtime)
+			assert.Equal(t, c.expectCommand, command)
+			if c.expectErr != "" {
+				assert.Equal(t, c.expectErr, err.Error())
+				return
+			}
+			assert.Nil(t, err)
+		})
+	}
+}
+
+func TestCheckRuntimeWith(t *testing.T) {
+	defer func() { execCommand = exec.Command }()
+	var command []string
+	execCommand = func(name string, args ...string) *exec.Cmd {
+		command = append([]string{name}, args...)
+		return exec.Command("true")
+	}
+
+	err := CheckRuntimeWith("docker", true, "tcp://remote:2376")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"sudo", "--preserve-env=DOCKER_HOST", "docker", "info", "--format", "{{.OSType}}"}, command)
+
+	execCommand = func(name string, args ...string) *exec.Cmd {
+		return exec.Command("sh", "-c", "echo 'Cannot connect to the Docker daemon at unix:///var/run/docker.sock. Is the docker daemon running?' >&2; exit 1")
+	}
+	err = CheckRuntimeWith("docker", false, "")
+	assert.Equal(t, "Docker does not appear to be running; start it and retry", err.Error())
+
+	execCommand = func(name string, args ...string) *exec.Cmd {
+		return exec.Command("echo", "windows")
+	}
+	err = CheckRuntimeWith("docker", false, "")
+	assert.Equal(t, "Docker runs Windows containers, which can't run the builds in the Linux containers; switch Docker Desktop to Linux containers and retry", err.Error())
+}
+
+func TestRuntimeError(t *testing.T) {
+	cases := []struct {
+		name      string
+		runtime   string
+		useSudo   bool
+		err       error
+		stderr    string
+		expectErr string
+	}{
+		{
+			name:      "docker not installed",
+			runtime:   "docker",
+			err:       &exec.Error{Name: "docker", Err: exec.ErrNotFound},
+			expectErr: "docker is not installed or not found in PATH; install it and retry",
+		},
+		{
+			name:      "podman not installed with sudo",
+			runtime:   "podman",
+			useSudo:   true,
+			err:       errors.New("exit status 1"),
+			stderr:    "sudo: podman: command not found",
+			expectErr: "podman is not installed or not found in PATH; install it and retry",
+		},
+		{
+			name:      "docker not running",
+			runtime:   "docker",
+			err:       errors.New("exit status 1"),
+			stderr:    "Cannot connect to the Docker daemon at unix:///var/run/docker.sock. Is the docker daemon running?",
+			expectErr: "Docker does not appear to be running; start it and retry",
+		},
+		{
+			name:      "podman not running",
+			runtime:   "podman",
+			err:       errors.New("exit status 125"),
+			stderr:    "Error: unable to connect to Podman socket: dial unix /run/podman/podman.sock: connect: no such file or directory",
+			expectErr: "Podman does not appear to be running; start it and retry",
+		},
+		{
+			name:      "k8s not running",
+			runtime:   "k8s",
+			err:       errors.New("exit status 1"),
+			stderr:    "The connection to the server localhost:8080 was refused - did you specify the right host or port?",
+			expectErr: "the cluster of the current context of kubectl does not appear to be running; start it or switch the context and retry",
+		},
+		{
+			name:      "docker permission denied",
+			runtime:   "docker",
+			err:       errors.New("exit status 1"),
+			stderr:    "Got permission denied while trying to connect to the Docker daemon socket at unix:///var/run/docker.sock",
+			expectErr: "permission denied to access the docker daemon; add the user to the docker group or run with --sudo",
+		},
+		{
+			name:      "unknown failure",
+			runtime:   "docker",
+			err:       errors.New("exit status 1"),
+			stderr:    "unexpected error\n",
+			expectErr: "failed to connect to docker: exit status 1: unexpected error",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := runtimeError(c.runtime, c.useSudo, c.err, c.stderr)
+			assert.Equal(t, c.expectErr, err.Error())
+		})
+	}
+}
+
+func TestPullLauncher(t *testing.T) {
+	launcher := config.Launcher{Image: "screwdrivercd/launcher", Version: "stable"}
+
+	cases := []struct {
+		name          string
+		runtime       string
+		registryAuth  string
+		stderr        string
+		expectCommand []string
+		expectErr     string
+	}{
+		{
+			name:          "docker",
+			expectCommand: []string{"docker", "pull", "screwdrivercd/launcher:stable"},
+		},
+		{
+			name:          "podman with registry auth",
+			runtime:       "podman",
+			registryAuth:  "/home/user/.docker",
+			expectCommand: []string{"podman", "pull", "--authfile", "/home/user/.docker/config.json", "screwdrivercd/launcher:stable"},
+		},
+		{
+			name:          "failure by unauthorized",
+			stderr:        "unauthorized: authentication required",
+			expectCommand: []string{"docker", "pull", "screwdrivercd/launcher:stable"},
+			expectErr:     "not authorized to pull screwdrivercd/launcher:stable from docker.io",
+		},
+		{
+			name:          "failure by command",
+			stderr:        "manifest unknown",
+			expectCommand: []string{"docker", "pull", "screwdrivercd/launcher:stable"},
+			expectErr:     "failed to pull screwdrivercd/launcher:stable: exit status 1: manifest unknown",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			defer func() { execCommand = exec.Command }()
+			var command []string
+			execCommand = func(name string, args ...string) *exec.Cmd {
+				command = append([]string{name}, args...)
+				if c.stderr != "" {
+					return exec.Command("sh", "-c", "echo \"$0\" >&2; exit 1", c.stderr)
+				}
+				return exec.Command("true")
+			}
+
+			err := PullLauncher(c.runtime, launcher, c.registryAuth)
+			assert.Equal(t, c.expectCommand, command)
+			if c.expectErr != "" {
+				assert.Equal(t, c.expectErr, err.Error())
+				return
+			}
+			assert.Nil(t, err)
+		})
+	}
+
+	t.Run("failure by the cancelled context", func(t *testing.T) {
+		defer func() { execCommand = exec.Command }()
+		execCommand = func(name string, args ...string) *exec.Cmd {
+			return exec.Command("sleep", "10")
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		start := time.Now()
+		err := PullLauncherContext(ctx, "docker", launcher, "")
+		assert.Equal(t, "failed to pull screwdrivercd/launcher:stable: context deadline exceeded", err.Error())
+		assert.True(t, time.Since(start) < 5*time.Second)
+	})
+}
+
+func TestFindContainers(t *testing.T) {
+	cases := []struct {
+		name          string
+		runtime       string
+		stdout        string
+		stderr        string
+		expectCommand []string
+		expect        []string
+		expectErr     string
+	}{
+		{
+			name:          "docker",
+			stdout:        "sdlocal-main-20210102150405\nsdlocal-test-20210102150405\n",
+			expectCommand: []string{"docker", "container", "ls", "--all", "--filter", "label=sdlocal.job", "--format", "{{.Names}}"},
+			expect:        []string{"sdlocal-main-20210102150405", "sdlocal-test-20210102150405"},
+		},
+		{
+			name:          "podman with no containers",
+			runtime:       "podman",
+			expectCommand: []string{"podman", "container", "ls", "--all", "--filter", "label=sdlocal.job", "--format", "{{.Names}}"},
+			expect:        []string{},
+		},
+		{
+			name:          "failure by command",
+			stderr:        "Cannot connect to the Docker daemon",
+			expectCommand: []string{"docker", "container", "ls", "--all", "--filter", "label=sdlocal.job", "--format", "{{.Names}}"},
+			expectErr:     "failed to list the containers: exit status 1: Cannot connect to the Docker daemon",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			defer func() { execCommand = exec.Command }()
+			var command []string
+			execCommand = func(name string, args ...string) *exec.Cmd {
+				command = append([]string{name}, args...)
+				if c.stderr != "" {
+					return exec.Command("sh", "-c", "echo \"$0\" >&2; exit 1", c.stderr)
+				}
+				return exec.Command("printf", "%s", c.stdout)
+			}
+
+			names, err := FindContainers(c.runtime)
+			assert.Equal(t, c.expectCommand, command)
+			if c.expectErr != "" {
+				assert.Equal(t, c.expectErr, err.Error())
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, c.expect, names)
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package launch
+
+import (
+	"errors"
+	"os/exec"
+)
+
+// ErrRuntime is matched by errors.Is with the errors of the container runtime apart from the steps of the build,
+// like the failures to pull the images and to set up the launcher, and the daemon which is not reachable or died during the build
+var ErrRuntime = errors.New("container runtime failed")
+
+// containerRuntimeError is the error of the container runtime, whose message is the one of the wrapped error
+type containerRuntimeError struct {
+	err error
+}
+
+func (e *containerRuntimeError) Error() string {
+	return e.err.Error()
+}
+
+func (e *containerRuntimeError) Unwrap() error {
+	return e.err
+}
+
+func (e *containerRuntimeError) Is(target error) bool {
+	return target == ErrRuntime
+}
+
+// newRuntimeError returns the error of the container runtime, which is nil if err is nil
+func newRuntimeError(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &containerRuntimeError{err: err}
+}
+
+// containerError returns the error of the container CLI running the build container, which is the error of the container runtime
+// if the CLI itself failed with 125 like the daemon which is not reachable or died, instead of the command in the container
+func containerError(err error) error {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 125 {
+		return newRuntimeError(err)
+	}
+	return err
+}
@@ -0,0 +1,40 @@
+package launch
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewRuntimeError(t *testing.T) {
+	err := newRuntimeError(errors.New("failed to pull user image exit status 1"))
+	assert.EqualError(t, err, "failed to pull user image exit status 1")
+	assert.True(t, errors.Is(err, ErrRuntime))
+	assert.True(t, errors.Is(fmt.Errorf("failed to run build: %w", err), ErrRuntime))
+	assert.Nil(t, newRuntimeError(nil))
+}
+
+func TestContainerError(t *testing.T) {
+	testCases := map[string]struct {
+		code    int
+		runtime bool
+	}{
+		"failed step":       {code: 1, runtime: false},
+		"failed CLI":        {code: 125, runtime: true},
+		"command not found": {code: 127, runtime: false},
+	}
+
+	for name, tt := range testCases {
+		t.Run(name, func(t *testing.T) {
+			exitErr := exec.Command("sh", "-c", fmt.Sprintf("exit %d", tt.code)).Run()
+			err := containerError(exitErr)
+			assert.Equal(t, exitErr.Error(), err.Error())
+			assert.Equal(t, tt.runtime, errors.Is(err, ErrRuntime))
+		})
+	}
+
+	assert.False(t, errors.Is(containerError(errors.New("failed")), ErrRuntime))
+}
@@ -0,0 +1,70 @@
+package launch
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/screwdriver-cd/sd-local/screwdriver"
+)
+
+const (
+	// FailedStepsFile is the file in the artifacts directory the names of the failed steps are written into without fail fast
+	FailedStepsFile = "sd-local-failed-steps"
+	// CheckFailedStepsStep is the step appended to the user steps without fail fast, which fails if any step failed
+	CheckFailedStepsStep = "check-failed-steps"
+)
+
+// withoutFailFast returns the steps whose user steps write their names into FailedStepsFile on failure instead of failing the build,
+// followed by CheckFailedStepsStep before the teardown steps, which fails the build with the failed steps.
+// The steps are not run in the subshells so that the variables exported by them are kept for the next steps.
+func withoutFailFast(steps []screwdriver.Step) []screwdriver.Step {
+	failedStepsPath := fmt.Sprintf(`"$SD_ARTIFACTS_DIR/%s"`, FailedStepsFile)
+	check := screwdriver.Step{
+		Name:    CheckFailedStepsStep,
+		Command: fmt.Sprintf("if [ -s %s ]; then\necho \"failed steps:\"\ncat %s\nexit 1\nfi", failedStepsPath, failedStepsPath),
+	}
+
+	wrapped := make([]screwdriver.Step, 0, len(steps)+1)
+	checked := false
+	for _, s := range steps {
+		if s.IsTeardown() {
+			if !checked {
+				wrapped = append(wrapped, check)
+				checked = true
+			}
+			wrapped = append(wrapped, s)
+			continue
+		}
+		s.Command = fmt.Sprintf("{\n%s\n} || {\necho \"%s failed with exit status $?, the next steps run without fail fast\"\necho \"%s\" >> %s\n}",
+			strings.TrimRight(s.Command, "\n"), s.Name, s.Name, failedStepsPath)
+		wrapped = append(wrapped, s)
+	}
+	if !checked {
+		wrapped = append(wrapped, check)
+	}
+	return wrapped
+}
+
+// FailedSteps returns the names of the steps which failed without fail fast in the build of the artifacts directory
+func FailedSteps(artifactsPath string) ([]string, error) {
+	b, err := ioutil.ReadFile(filepath.Join(artifactsPath, FailedStepsFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return strings.Fields(string(b)), nil
+}
+
+// RemoveFailedSteps removes FailedStepsFile of the previous build in the artifacts directory
+func RemoveFailedSteps(artifactsPath string) error {
+	err := os.Remove(filepath.Join(artifactsPath, FailedStepsFile))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
@@ -5,9 +5,7 @@ import (
 	"io"
 	"os"
 	"os/exec"
-	"os/signal"
 	"strings"
-	"syscall"
 	"time"
 
 	"github.com/creack/pty"
@@ -46,16 +44,7 @@ func (d *Interact) Run(c *exec.Cmd, commands [][]string) error {
 	}()
 
 	// Handle pty size.
-	ch := make(chan os.Signal, 1)
-	signal.Notify(ch, syscall.SIGWINCH)
-	go func() {
-		for range ch {
-			if err := pty.InheritSize(os.Stdin, ptmx); err != nil {
-				logrus.Warn(fmt.Errorf("error resizing pty: %s", err))
-			}
-		}
-	}()
-	ch <- syscall.SIGWINCH // Initial resize.
+	inheritSize(ptmx)
 
 	// Set stdin in raw mode.
 	oldState, err := terminal.MakeRaw(int(os.Stdin.Fd()))
@@ -0,0 +1,28 @@
+//go:build !windows
+// +build !windows
+
+package launch
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/creack/pty"
+	"github.com/sirupsen/logrus"
+)
+
+// inheritSize resizes the pty to the terminal of sd-local whenever it is resized
+func inheritSize(ptmx *os.File) {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, syscall.SIGWINCH)
+	go func() {
+		for range ch {
+			if err := pty.InheritSize(os.Stdin, ptmx); err != nil {
+				logrus.Warn(fmt.Errorf("error resizing pty: %s", err))
+			}
+		}
+	}()
+	ch <- syscall.SIGWINCH // Initial resize.
+}
@@ -0,0 +1,8 @@
+package launch
+
+import (
+	"os"
+)
+
+// inheritSize does nothing on Windows, where the pty of the interactive mode is not supported
+func inheritSize(ptmx *os.File) {}
@@ -0,0 +1,336 @@
+package launch
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// kubernetes runs the build as a Pod of the cluster of the current kubectl context.
+// The launcher image is run as an init container which copies the build scripts into the build container,
+// then the build is run with `kubectl exec` and the artifacts are copied back with `kubectl cp`.
+type kubernetes struct {
+	podName           string
+	setupImage        string
+	setupImageVersion string
+	interactiveMode   bool
+	commands          []*exec.Cmd
+	mutex             *sync.Mutex
+	flagVerbose       bool
+	localVolumes      []string
+	// stdout is where the build logs are streamed to
+	stdout     io.Writer
+	noTeardown bool
+	platform   string
+	// kept is true if the build pod is kept for debugging
+	kept bool
+}
+
+var _ runner = (*kubernetes)(nil)
+
+const (
+	kubernetesBuildContainer = "build"
+	kubernetesReadyTimeout   = "5m"
+)
+
+func newKubernetes(setupImage, setupImageVer string, interactiveMode bool, flagVerbose bool, localVolumes []string, noTeardown bool, platform string) runner {
+	return &kubernetes{
+		podName:           "sd-local-" + strconv.FormatInt(time.Now().UnixNano(), 36),
+		setupImage:        setupImage,
+		setupImageVersion: setupImageVer,
+		interactiveMode:   interactiveMode,
+		commands:          make([]*exec.Cmd, 0, 10),
+		mutex:             &sync.Mutex{},
+		flagVerbose:       flagVerbose,
+		localVolumes:      localVolumes,
+		stdout:            os.Stdout,
+		noTeardown:        noTeardown,
+		platform:          platform,
+	}
+}
+
+// setupBin does nothing because the build scripts are copied by the init container of the build Pod
+func (k *kubernetes) setupBin() error {
+	if k.interactiveMode {
+		return fmt.Errorf("interactive mode is not supported in k8s runtime")
+	}
+	if len(k.localVolumes) != 0 {
+		logrus.Warn("--vol is ignored in k8s runtime")
+	}
+	return nil
+}
+
+// kubernetesMemory converts the memory limit in the docker format to the kubernetes one
+func kubernetesMemory(memory string) string {
+	units := map[string]string{"b": "", "k": "Ki", "m": "Mi", "g": "Gi"}
+	suffix := strings.ToLower(memory[len(memory)-1:])
+	if unit, ok := units[suffix]; ok {
+		return memory[:len(memory)-1] + unit
+	}
+	return memory
+}
+
+func (k *kubernetes) podManifest(buildEntry buildEntry) ([]byte, error) {
+	container := map[string]interface{}{
+		"name":    kubernetesBuildContainer,
+		"image":   buildEntry.Image,
+		"command": []string{"/bin/sh", "-c", keepAliveScript},
+		"volumeMounts": []map[string]interface{}{
+			{"name": "sd-bin", "mountPath": "/opt/sd"},
+		},
+	}
+	// the secrets are referred from the Secret of the build not to be written into the Pod spec which is readable by getting the pods
+	if len(buildEntry.Secrets) != 0 {
+		env := make([]map[string]interface{}, 0, len(buildEntry.Secrets))
+		for _, key := range sortedKeys(buildEntry.Secrets) {
+			env = append(env, map[string]interface{}{
+				"name": key,
+				"valueFrom": map[string]interface{}{
+					"secretKeyRef": map[string]string{"name": k.podName, "key": key},
+				},
+			})
+		}
+		container["env"] = env
+	}
+
+	limits := map[string]string{}
+	if buildEntry.MemoryLimit != "" {
+		limits["memory"] = kubernetesMemory(buildEntry.MemoryLimit)
+	}
+	if buildEntry.CPULimit != "" {
+		limits["cpu"] = buildEntry.CPULimit
+	}
+	if len(limits) != 0 {
+		container["resources"] = map[string]interface{}{"limits": limits}
+	}
+	if buildEntry.UsePrivileged {
+		container["securityContext"] = map[string]bool{"privileged": true}
+	}
+
+	spec := map[string]interface{}{
+		"restartPolicy": "Never",
+		"initContainers": []map[string]interface{}{
+			{
+				"name":    "launcher",
+				"image":   launcherImage(k.setupImage, k.setupImageVersion),
+				"command": []string{"/bin/sh", "-c", "cp -a /opt/sd/. /sd-bin/ && mkdir -p /sd-bin/hab && cp -a /hab/. /sd-bin/hab/"},
+				"volumeMounts": []map[string]interface{}{
+					{"name": "sd-bin", "mountPath": "/sd-bin"},
+				},
+			},
+		},
+		"containers": []map[string]interface{}{container},
+		"volumes": []map[string]interface{}{
+			{"name": "sd-bin", "emptyDir": map[string]interface{}{}},
+		},
+	}
+	// the Pod is scheduled on the nodes of the platform only if it is specified, because the host architecture is irrelevant to the cluster
+	if k.platform != "" {
+		spec["nodeSelector"] = nodeSelector(k.platform)
+	}
+
+	pod := map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Pod",
+		"metadata": map[string]interface{}{
+			"name":   k.podName,
+			"labels": map[string]string{"app.kubernetes.io/managed-by": "sd-local"},
+		},
+		"spec": spec,
+	}
+
+	return json.Marshal(pod)
+}
+
+// secretManifest returns the Secret of the secrets of the build, which has the same name as the build Pod
+func (k *kubernetes) secretManifest(buildEntry buildEntry) ([]byte, error) {
+	data := make(map[string][]byte, len(buildEntry.Secrets))
+	for key, value := range buildEntry.Secrets {
+		data[key] = []byte(value)
+	}
+
+	secret := map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Secret",
+		"metadata": map[string]interface{}{
+			"name":   k.podName,
+			"labels": map[string]string{"app.kubernetes.io/managed-by": "sd-local"},
+		},
+		"type": "Opaque",
+		"data": data,
+	}
+
+	return json.Marshal(secret)
+}
+
+// buildManifest returns the list of the build Pod and the Secret of its secrets if any, which are created at once
+func (k *kubernetes) buildManifest(buildEntry buildEntry) ([]byte, error) {
+	items := make([]json.RawMessage, 0, 2)
+	if len(buildEntry.Secrets) != 0 {
+		secret, err := k.secretManifest(buildEntry)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, secret)
+	}
+
+	pod, err := k.podManifest(buildEntry)
+	if err != nil {
+		return nil, err
+	}
+	items = append(items, pod)
+
+	return json.Marshal(map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "List",
+		"items":      items,
+	})
+}
+
+func (k *kubernetes) runBuild(buildEntry buildEntry) error {
+	environment := buildEntry.Environment[0]
+
+	containerSrcDir := fmt.Sprintf("/sd/workspace/src/%s/%s", scmHost, orgRepo)
+	containerArtDir := environment["SD_ARTIFACTS_DIR"]
+	logfilePath := filepath.Join(containerArtDir, LogFile)
+	pod := fmt.Sprintf("pod/%s", k.podName)
+
+	configJSON, err := json.Marshal(buildEntry)
+	if err != nil {
+		return err
+	}
+
+	manifest, err := k.buildManifest(buildEntry)
+	if err != nil {
+		return err
+	}
+
+	logrus.Infof("Creating pod %s...", k.podName)
+	_, err = k.execKubectlCommand(bytes.NewReader(manifest), nil, "apply", "-f", "-")
+	if err != nil {
+		return fmt.Errorf("failed to create build pod: %v", err)
+	}
+
+	_, err = k.execKubectlCommand(nil, nil, "wait", "--for=condition=Ready", pod, "--timeout", kubernetesReadyTimeout)
+	if err != nil {
+		return fmt.Errorf("failed to wait for build pod: %v", err)
+	}
+
+	_, err = k.execKubectlCommand(nil, nil, "exec", k.podName, "-c", kubernetesBuildContainer, "--", "mkdir", "-p", containerSrcDir, containerArtDir)
+	if err != nil {
+		return fmt.Errorf("failed to prepare build pod: %v", err)
+	}
+
+	if shell := environment[ShellEnv]; shell != "" {
+		_, err = k.execKubectlCommand(nil, nil, "exec", k.podName, "-c", kubernetesBuildContainer, "--", shell, "-c", "true")
+		if err != nil {
+			return shellNotFoundError(shell, buildEntry.Image, err)
+		}
+	}
+
+	if len(buildEntry.IgnoredPaths) != 0 {
+		logrus.Warn("The ignored paths are copied into the build pod, because kubectl cp can't exclude them")
+	}
+
+	// kubectl cp requires tar in the build image
+	_, err = k.execKubectlCommand(nil, nil, "cp", "-c", kubernetesBuildContainer, fmt.Sprintf("%s/.", buildEntry.SrcPath), fmt.Sprintf("%s:%s", k.podName, containerSrcDir))
+	if err != nil {
+		return fmt.Errorf("failed to copy source code into build pod: %v", err)
+	}
+
+	launchCommands := []string{"/opt/sd/local_run.sh", string(configJSON), buildEntry.JobName, environment["SD_API_URL"], environment["SD_STORE_URL"], logfilePath}
+	_, runErr := k.execKubectlCommand(nil, k.stdout, append([]string{"exec", k.podName, "-c", kubernetesBuildContainer, "--"}, launchCommands...)...)
+
+	// copy the artifacts back even if the build failed to investigate it
+	_, err = k.execKubectlCommand(nil, nil, "cp", "-c", kubernetesBuildContainer, fmt.Sprintf("%s:%s/.", k.podName, containerArtDir), buildEntry.ArtifactsPath)
+	if err != nil {
+		logrus.Warn(fmt.Errorf("failed to copy artifacts from build pod: %v", err))
+	}
+
+	if runErr != nil {
+		if k.noTeardown {
+			k.kept = true
+			logrus.Warnf("The build pod %s is kept for debugging. Enter it with:\n  kubectl exec -it %s -c %s -- /bin/sh\nPlease remove it with `kubectl delete pod/%s secret/%s --ignore-not-found` by yourself when you finish.",
+				k.podName, k.podName, kubernetesBuildContainer, k.podName, k.podName)
+		}
+		return fmt.Errorf("failed to run build pod: %v", runErr)
+	}
+
+	return nil
+}
+
+// execKubectlCommand runs kubectl. The output is written to stdout if it is not nil, otherwise it is returned.
+func (k *kubernetes) execKubectlCommand(stdin io.Reader, stdout io.Writer, args ...string) (string, error) {
+	commands := append([]string{"kubectl"}, args...)
+	cmd := execCommand(commands[0], commands[1:]...)
+	if k.flagVerbose {
+		logrus.Debugf("$ %s", strings.Join(commands, " "))
+	}
+	cmd.Stdin = stdin
+	out := bytes.NewBuffer(nil)
+	cmd.Stdout = out
+	if stdout != nil {
+		cmd.Stdout = stdout
+	}
+	errBuf := bytes.NewBuffer(nil)
+	cmd.Stderr = errBuf
+
+	k.mutex.Lock()
+	k.commands = append(k.commands, cmd)
+	k.mutex.Unlock()
+
+	err := cmd.Run()
+	if k.flagVerbose {
+		logrus.Debugf("%s", out)
+	}
+	if err != nil {
+		io.Copy(os.Stderr, errBuf)
+	}
+	return strings.TrimRight(out.String(), "\n"), err
+}
+
+// mounts returns the volume of the build scripts, because the source code and the artifacts are copied from and to the host
+func (k *kubernetes) mounts(buildEntry buildEntry) []string {
+	return []string{"sd-bin:/opt/sd"}
+}
+
+// keptContainerID returns nothing because the build pod can't be re-entered to resume the build
+func (k *kubernetes) keptContainerID() string {
+	return ""
+}
+
+func (k *kubernetes) kill(sig os.Signal) {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	for _, v := range k.commands {
+		if v.Process == nil || v.ProcessState != nil {
+			continue
+		}
+		if err := v.Process.Signal(sig); err != nil {
+			logrus.Warn(fmt.Errorf("failed to stop process: %v", err))
+		}
+	}
+}
+
+func (k *kubernetes) clean() {
+	if k.kept {
+		return
+	}
+
+	// the Secret of the secrets is deleted with the pod, which is not created if the build has no secrets
+	_, err := k.execKubectlCommand(nil, nil, "delete", "pod/"+k.podName, "secret/"+k.podName, "--ignore-not-found", "--wait=false")
+	if err != nil {
+		logrus.Warn(fmt.Errorf("failed to delete build pod: %v", err))
+	}
+}
@@ -0,0 +1,259 @@
+package launch
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestKubernetes() *kubernetes {
+	return &kubernetes{
+		podName:           "sd-local-test",
+		setupImage:        "launcher",
+		setupImageVersion: "latest",
+		commands:          make([]*exec.Cmd, 0, 10),
+		mutex:             &sync.Mutex{},
+		stdout:            bytes.NewBuffer(nil),
+	}
+}
+
+func TestNewKubernetes(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		k, ok := newKubernetes("launcher", "latest", false, false, []string{"path:path"}, true, "").(*kubernetes)
+		assert.True(t, ok)
+		assert.True(t, strings.HasPrefix(k.podName, "sd-local-"))
+		assert.Equal(t, "launcher", k.setupImage)
+		assert.Equal(t, "latest", k.setupImageVersion)
+		assert.Equal(t, []string{"path:path"}, k.localVolumes)
+		assert.True(t, k.noTeardown)
+	})
+}
+
+func TestKubernetesSetupBin(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		k := newTestKubernetes()
+		assert.Nil(t, k.setupBin())
+	})
+
+	t.Run("failure by interactive mode", func(t *testing.T) {
+		k := newTestKubernetes()
+		k.interactiveMode = true
+		assert.Equal(t, fmt.Errorf("interactive mode is not supported in k8s runtime"), k.setupBin())
+	})
+}
+
+func TestKubernetesPodManifest(t *testing.T) {
+	k := newTestKubernetes()
+	b := newBuildEntry(func(b *buildEntry) {
+		b.MemoryLimit = "2g"
+		b.CPULimit = "0.5"
+		b.UsePrivileged = true
+		b.Secrets = EnvVar{"API_KEY": "apikey"}
+	})
+
+	manifest, err := k.podManifest(b)
+	assert.Nil(t, err)
+
+	var pod struct {
+		Metadata struct {
+			Name string `json:"name"`
+		} `json:"metadata"`
+		Spec struct {
+			InitContainers []struct {
+				Image string `json:"image"`
+			} `json:"initContainers"`
+			Containers []struct {
+				Image     string `json:"image"`
+				Resources struct {
+					Limits map[string]string `json:"limits"`
+				} `json:"resources"`
+				SecurityContext map[string]bool          `json:"securityContext"`
+				Env             []map[string]interface{} `json:"env"`
+			} `json:"containers"`
+			NodeSelector map[string]string `json:"nodeSelector"`
+		} `json:"spec"`
+	}
+	err = json.Unmarshal(manifest, &pod)
+	assert.Nil(t, err)
+	assert.Equal(t, "sd-local-test", pod.Metadata.Name)
+	assert.Equal(t, "launcher:latest", pod.Spec.InitContainers[0].Image)
+	assert.Equal(t, b.Image, pod.Spec.Containers[0].Image)
+	assert.Equal(t, "2Gi", pod.Spec.Containers[0].Resources.Limits["memory"])
+	assert.Equal(t, "0.5", pod.Spec.Containers[0].Resources.Limits["cpu"])
+	// the value of the secret is not written into the Pod spec
+	assert.Equal(t, []map[string]interface{}{
+		{"name": "API_KEY", "valueFrom": map[string]interface{}{"secretKeyRef": map[string]interface{}{"name": "sd-local-test", "key": "API_KEY"}}},
+	}, pod.Spec.Containers[0].Env)
+	assert.NotContains(t, string(manifest), "apikey")
+	assert.True(t, pod.Spec.Containers[0].SecurityContext["privileged"])
+	assert.Nil(t, pod.Spec.NodeSelector)
+}
+
+func TestKubernetesPodManifestWithPlatform(t *testing.T) {
+	k := newTestKubernetes()
+	k.platform = "linux/arm64"
+
+	manifest, err := k.podManifest(newBuildEntry())
+	assert.Nil(t, err)
+
+	var pod struct {
+		Spec struct {
+			NodeSelector map[string]string `json:"nodeSelector"`
+		} `json:"spec"`
+	}
+	err = json.Unmarshal(manifest, &pod)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"kubernetes.io/os": "linux", "kubernetes.io/arch": "arm64"}, pod.Spec.NodeSelector)
+}
+
+func TestKubernetesBuildManifest(t *testing.T) {
+	type item struct {
+		Kind     string `json:"kind"`
+		Metadata struct {
+			Name string `json:"name"`
+		} `json:"metadata"`
+		Data map[string][]byte `json:"data"`
+	}
+	var list struct {
+		Kind  string `json:"kind"`
+		Items []item `json:"items"`
+	}
+
+	t.Run("success with secrets", func(t *testing.T) {
+		k := newTestKubernetes()
+		manifest, err := k.buildManifest(newBuildEntry(func(b *buildEntry) {
+			b.Secrets = EnvVar{"API_KEY": "apikey", "PASSWORD": "password"}
+		}))
+		assert.Nil(t, err)
+
+		err = json.Unmarshal(manifest, &list)
+		assert.Nil(t, err)
+		assert.Equal(t, "List", list.Kind)
+		assert.Equal(t, 2, len(list.Items))
+		assert.Equal(t, "Secret", list.Items[0].Kind)
+		assert.Equal(t, "sd-local-test", list.Items[0].Metadata.Name)
+		assert.Equal(t, map[string][]byte{"API_KEY": []byte("apikey"), "PASSWORD": []byte("password")}, list.Items[0].Data)
+		assert.Equal(t, "Pod", list.Items[1].Kind)
+	})
+
+	t.Run("success without secrets", func(t *testing.T) {
+		k := newTestKubernetes()
+		manifest, err := k.buildManifest(newBuildEntry())
+		assert.Nil(t, err)
+
+		err = json.Unmarshal(manifest, &list)
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(list.Items))
+		assert.Equal(t, "Pod", list.Items[0].Kind)
+	})
+}
+
+func TestKubernetesRunBuild(t *testing.T) {
+	defer func() {
+		execCommand = exec.Command
+	}()
+
+	testCase := []struct {
+		name          string
+		id            string
+		expectError   error
+		expectCommand int
+	}{
+		{"success", "SUCCESS_K8S_RUN_BUILD", nil, 6},
+		{"failure pod apply", "FAIL_K8S_APPLY", fmt.Errorf("failed to create build pod: exit status 1"), 1},
+		{"failure pod wait", "FAIL_K8S_WAIT", fmt.Errorf("failed to wait for build pod: exit status 1"), 2},
+		{"failure copy source", "FAIL_K8S_COPY_SRC", fmt.Errorf("failed to copy source code into build pod: exit status 1"), 4},
+		{"failure build with artifacts copied", "FAIL_K8S_RUN_BUILD", fmt.Errorf("failed to run build pod: exit status 1"), 6},
+	}
+
+	for _, tt := range testCase {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newFakeExecCommand(tt.id)
+			execCommand = c.execCmd
+			k := newTestKubernetes()
+			err := k.runBuild(newBuildEntry())
+
+			assert.Equal(t, tt.expectError, err)
+			assert.Equal(t, tt.expectCommand, len(c.commands))
+			assert.Equal(t, "kubectl apply -f -", c.commands[0])
+			if tt.expectCommand > 4 {
+				assert.Equal(t, tt.id, k.stdout.(*bytes.Buffer).String())
+			}
+		})
+	}
+}
+
+func TestKubernetesRunBuildWithShell(t *testing.T) {
+	defer func() {
+		execCommand = exec.Command
+	}()
+
+	buildEntry := newBuildEntry(func(b *buildEntry) {
+		b.Environment[0][ShellEnv] = "/bin/bash"
+	})
+
+	c := newFakeExecCommand("SUCCESS_K8S_RUN_BUILD")
+	execCommand = c.execCmd
+	err := newTestKubernetes().runBuild(buildEntry)
+	assert.Nil(t, err)
+	assert.Equal(t, "kubectl exec sd-local-test -c build -- /bin/bash -c true", c.commands[3])
+
+	c = newFakeExecCommand("FAIL_K8S_SHELL")
+	execCommand = c.execCmd
+	err = newTestKubernetes().runBuild(buildEntry)
+	assert.Equal(t, "shell /bin/bash is not found in the image node:12, install it in the image or change the shell: exit status 1", err.Error())
+}
+
+func TestKubernetesRunBuildWithNoTeardown(t *testing.T) {
+	defer func() {
+		execCommand = exec.Command
+	}()
+
+	c := newFakeExecCommand("FAIL_K8S_RUN_BUILD")
+	execCommand = c.execCmd
+	k := newTestKubernetes()
+	k.noTeardown = true
+	err := k.runBuild(newBuildEntry())
+
+	assert.Equal(t, fmt.Errorf("failed to run build pod: exit status 1"), err)
+	assert.True(t, k.kept)
+}
+
+func TestKubernetesClean(t *testing.T) {
+	defer func() {
+		execCommand = exec.Command
+	}()
+
+	t.Run("success", func(t *testing.T) {
+		c := newFakeExecCommand("SUCCESS_TO_CLEAN")
+		execCommand = c.execCmd
+		k := newTestKubernetes()
+		k.clean()
+
+		assert.Equal(t, []string{"kubectl delete pod/sd-local-test secret/sd-local-test --ignore-not-found --wait=false"}, c.commands)
+	})
+
+	t.Run("success to keep the pod for debugging", func(t *testing.T) {
+		c := newFakeExecCommand("SUCCESS_TO_CLEAN")
+		execCommand = c.execCmd
+		k := newTestKubernetes()
+		k.kept = true
+		k.clean()
+
+		assert.Equal(t, []string{}, c.commands)
+	})
+}
+
+func TestKubernetesMemory(t *testing.T) {
+	assert.Equal(t, "512", kubernetesMemory("512b"))
+	assert.Equal(t, "512Ki", kubernetesMemory("512k"))
+	assert.Equal(t, "512Mi", kubernetesMemory("512M"))
+	assert.Equal(t, "2Gi", kubernetesMemory("2g"))
+	assert.Equal(t, "1024", kubernetesMemory("1024"))
+}
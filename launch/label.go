@@ -0,0 +1,52 @@
+package launch
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	// LabelJob is the label of the build container with the job name.
+	// The containers of sd-local are listed like `docker ps --filter label=sdlocal.job`.
+	LabelJob = "sdlocal.job"
+	// LabelEntry is the label of the build container with the name of the config
+	LabelEntry = "sdlocal.entry"
+)
+
+var (
+	validContainerName = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+	invalidNameChars   = regexp.MustCompile(`[^a-zA-Z0-9_.-]+`)
+)
+
+// ValidateContainerName validates the name of the build container by the rule of docker
+func ValidateContainerName(name string) error {
+	if !validContainerName.MatchString(name) {
+		return fmt.Errorf("invalid container name %s: must start with a letter or a digit and consist of letters, digits, _, . and -", name)
+	}
+	return nil
+}
+
+// ContainerName returns the readable name of the build container joining the parts with -, like sdlocal-main-20210102150405.
+// The characters which can't be used in the name, like : of PR-1:main, are replaced with -.
+func ContainerName(parts ...string) string {
+	names := make([]string, 0, len(parts))
+	for _, p := range parts {
+		if p = strings.Trim(invalidNameChars.ReplaceAllString(p, "-"), "-"); p != "" {
+			names = append(names, p)
+		}
+	}
+	return strings.Join(names, "-")
+}
+
+// containerLabels returns the options of the container CLI to label the build container with the job and the config
+func containerLabels(buildEntry buildEntry) []string {
+	labels := make([]string, 0, 4)
+	if buildEntry.JobName != "" {
+		labels = append(labels, "--label", fmt.Sprintf("%s=%s", LabelJob, buildEntry.JobName))
+	}
+	if buildEntry.EntryName != "" {
+		labels = append(labels, "--label", fmt.Sprintf("%s=%s", LabelEntry, buildEntry.EntryName))
+	}
+	return labels
+}
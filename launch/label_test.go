@@ -0,0 +1,50 @@
+package launch
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContainerName(t *testing.T) {
+	testCases := []struct {
+		name     string
+		parts    []string
+		expected string
+	}{
+		{name: "job", parts: []string{"sdlocal", "main", "20210102150405"}, expected: "sdlocal-main-20210102150405"},
+		{name: "PR job", parts: []string{"sdlocal", "PR-1:main", "20210102150405"}, expected: "sdlocal-PR-1-main-20210102150405"},
+		{name: "invalid characters only", parts: []string{"sdlocal", "::", "20210102150405"}, expected: "sdlocal-20210102150405"},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			actual := ContainerName(tt.parts...)
+			assert.Equal(t, tt.expected, actual)
+			assert.Nil(t, ValidateContainerName(actual))
+		})
+	}
+}
+
+func TestValidateContainerName(t *testing.T) {
+	testCases := []struct {
+		name      string
+		expectErr bool
+	}{
+		{name: "my-build_1.0", expectErr: false},
+		{name: "-build", expectErr: true},
+		{name: "my build", expectErr: true},
+		{name: "", expectErr: true},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateContainerName(tt.name)
+			if tt.expectErr {
+				assert.Equal(t, "invalid container name "+tt.name+": must start with a letter or a digit and consist of letters, digits, _, . and -", err.Error())
+			} else {
+				assert.Nil(t, err)
+			}
+		})
+	}
+}
@@ -7,8 +7,11 @@ import (
 	"os/exec"
 	"path"
 	"runtime"
+	"sort"
+	"strings"
 
 	"github.com/screwdriver-cd/sd-local/config"
+	"github.com/screwdriver-cd/sd-local/retry"
 	"github.com/screwdriver-cd/sd-local/screwdriver"
 	"github.com/sirupsen/logrus"
 )
@@ -24,6 +27,8 @@ type runner interface {
 	setupBin() error
 	kill(os.Signal)
 	clean()
+	keptContainerID() string
+	mounts(buildEntry buildEntry) []string
 }
 
 // Launcher able to run local build
@@ -38,6 +43,7 @@ var _ (Launcher) = (*launch)(nil)
 type launch struct {
 	buildEntry buildEntry
 	runner     runner
+	command    string
 }
 
 // EnvVar is a map for environment variables
@@ -59,12 +65,33 @@ type buildEntry struct {
 	JobName         string             `json:"-"`
 	ArtifactsPath   string             `json:"-"`
 	MemoryLimit     string             `json:"-"`
+	CPULimit        string             `json:"-"`
 	SrcPath         string             `json:"-"`
 	UseSudo         bool               `json:"-"`
 	InteractiveMode bool               `json:"-"`
 	SocketPath      string             `json:"-"`
 	UsePrivileged   bool               `json:"-"`
 	LocalVolumes    []string           `json:"-"`
+	// Secrets are set to the environment of the build container instead of the config of the build not to show them in the command line
+	Secrets EnvVar `json:"-"`
+	// ResumeContainer is the build container kept by the failed build to resume the build in it
+	ResumeContainer string `json:"-"`
+	// IgnoredPaths are the paths relative to the source directory which are not mounted into the build container
+	IgnoredPaths []string `json:"-"`
+	// MetaPath is the host side directory mounted into the meta directory to read the meta after the build
+	MetaPath string `json:"-"`
+	// ContainerName is the name of the build container
+	ContainerName string `json:"-"`
+	// EntryName is the name of the config to label the build container with
+	EntryName string `json:"-"`
+	// Mounts are the bind mounts of the host paths into the build container
+	Mounts []Mount `json:"-"`
+	// CacheDir is the host side directory mounted into CacheDir of the build container
+	CacheDir string `json:"-"`
+	// CommandsDir is the host side directory mounted into CommandsDir of the build container
+	CommandsDir string `json:"-"`
+	// User is the user of the build container, which is the user of the image if it is empty
+	User string `json:"-"`
 }
 
 // Option is option for launch New
@@ -84,10 +111,54 @@ type Option struct {
 	SocketPath      string
 	FlagVerbose     bool
 	LocalVolumes    []string
+	Runtime         string
+	NoTeardown      bool
+	Platform        string
+	Secrets         EnvVar
+	ResumeContainer string
+	IgnoredPaths    []string
+	// Retry is the policy to retry the pulls of the images failed by the transient errors
+	Retry retry.Policy
+	// MetaPath is the directory to mount into the meta directory of the build container, which is not supported by k8s
+	MetaPath string
+	// PullPolicy is the policy to pull the launcher image, which is not supported by k8s
+	PullPolicy string
+	// Offline runs the build only with the local images, which is not supported by k8s
+	Offline bool
+	// ContainerName is the name of the build container, which is not supported by k8s
+	ContainerName string
+	// EntryName is the name of Entry, which the build container is labeled with
+	EntryName string
+	// Mounts are the bind mounts of the host paths parsed by ParseMount, which are not supported by k8s
+	Mounts []Mount
+	// CacheDir is the directory of the build cache persisted across the builds, which is not supported by k8s
+	CacheDir string
+	// CommandsDir is the directory of the commands of sd-cmd fetched by sdcmd.Fetcher, which is not supported by k8s
+	CommandsDir string
+	// NoFailFast keeps running the user steps after a step failed, and the build fails by CheckFailedStepsStep
+	// with the failed steps written into FailedStepsFile of the artifacts directory.
+	NoFailFast bool
+	// Workdir is the working directory of the steps in the build container, which overrides the annotation screwdriver.cd/workdir of the job
+	Workdir string
+	// User is the user of the build container in the form of uid[:gid] validated by ValidateUser, which is not supported by k8s
+	User string
+	// Shell is the absolute path of the shell the steps run in, which overrides the annotation screwdriver.cd/shell of the job
+	// and is set to USER_SHELL_BIN of the launcher
+	Shell string
 }
 
 const (
 	defaultArtDir = "/sd/workspace/artifacts"
+	// MetaDir is the directory the launcher writes the meta of the build into
+	MetaDir = "/sd/meta"
+	// MetaFile is the file of the meta in MetaDir
+	MetaFile = "meta.json"
+	// CacheDir is the directory of the build container the build cache is mounted into
+	CacheDir = "/sd/cache"
+	// CacheDirEnv is the environment variable of CacheDir, which is set only if the build cache is mounted
+	CacheDirEnv = "SD_LOCAL_CACHE_DIR"
+	// CommandsDir is the directory of the build container sd-cmd looks up the binaries of the commands in
+	CommandsDir = "/opt/sd/commands"
 )
 
 // DefaultSocketPath is a socket path on the localhost to bring in the build container.
@@ -113,6 +184,43 @@ func mergeEnv(env, jobEnv, optionEnv EnvVar) []EnvVar {
 	return []EnvVar{env}
 }
 
+// sortedKeys returns the keys of the environment variables in order
+func sortedKeys(env EnvVar) []string {
+	keys := make([]string, 0, len(env))
+	for k := range env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// stepEnvReplacer escapes the values of the environment of the steps in the double quotes, in which the variables like $PATH are expanded
+var stepEnvReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "`", "\\`")
+
+// withStepEnvironment returns the steps whose commands run in the subshells exporting the environment of the steps,
+// so that it overrides the environment of the job and --env only in the step. The secrets can't be overridden.
+func withStepEnvironment(steps []screwdriver.Step, secrets EnvVar) []screwdriver.Step {
+	wrapped := make([]screwdriver.Step, 0, len(steps))
+	for _, s := range steps {
+		if len(s.Environment) == 0 {
+			wrapped = append(wrapped, s)
+			continue
+		}
+
+		var b strings.Builder
+		b.WriteString("(\n")
+		for _, k := range sortedKeys(s.Environment) {
+			if _, ok := secrets[k]; ok {
+				continue
+			}
+			fmt.Fprintf(&b, "export %s=\"%s\"\n", k, stepEnvReplacer.Replace(s.Environment[k]))
+		}
+		fmt.Fprintf(&b, "%s\n)", strings.TrimRight(s.Command, "\n"))
+		wrapped = append(wrapped, screwdriver.Step{Name: s.Name, Command: b.String()})
+	}
+	return wrapped
+}
+
 func createBuildEntry(option Option) buildEntry {
 	apiURL, storeURL := option.Entry.APIURL, option.Entry.StoreURL
 
@@ -140,7 +248,46 @@ func createBuildEntry(option Option) buildEntry {
 		"SD_BASE_COMMAND_PATH": "/sd/commands/",
 	}
 
+	if option.Entry.HTTPProxy != "" {
+		defaultEnv["HTTP_PROXY"] = option.Entry.HTTPProxy
+		defaultEnv["http_proxy"] = option.Entry.HTTPProxy
+	}
+
+	if option.Entry.HTTPSProxy != "" {
+		defaultEnv["HTTPS_PROXY"] = option.Entry.HTTPSProxy
+		defaultEnv["https_proxy"] = option.Entry.HTTPSProxy
+	}
+
+	if option.CacheDir != "" {
+		defaultEnv[CacheDirEnv] = CacheDir
+	}
+
 	env := mergeEnv(defaultEnv, option.Job.Environment, option.OptionEnv)
+	if shell := buildShell(option); shell != "" {
+		env[0][ShellEnv] = shell
+	}
+	// the secrets must not be overridden by the same names in the config of the build
+	for k := range option.Secrets {
+		delete(env[0], k)
+	}
+
+	// the teardown steps are deferred to run after the user steps as the launcher runs them even if the user steps failed
+	steps := option.Job.PlannedSteps()
+	if option.NoTeardown {
+		steps = make([]screwdriver.Step, 0, len(option.Job.Steps))
+		for _, s := range option.Job.Steps {
+			if !s.IsTeardown() {
+				steps = append(steps, s)
+			}
+		}
+	}
+
+	cpu, memory := buildResources(option, steps)
+	steps = withStepEnvironment(steps, option.Secrets)
+	if option.NoFailFast {
+		steps = withoutFailFast(steps)
+	}
+	steps = withWorkdir(steps, buildWorkdir(option))
 
 	return buildEntry{
 		ID:              0,
@@ -150,17 +297,28 @@ func createBuildEntry(option Option) buildEntry {
 		ParentBuildID:   []int{0},
 		Sha:             "dummy",
 		Meta:            option.Meta,
-		Steps:           option.Job.Steps,
+		Steps:           steps,
 		Image:           option.Job.Image,
 		JobName:         option.JobName,
 		ArtifactsPath:   option.ArtifactsPath,
-		MemoryLimit:     option.Memory,
+		MemoryLimit:     memory,
+		CPULimit:        cpu,
 		SrcPath:         option.SrcPath,
 		UseSudo:         option.UseSudo,
 		InteractiveMode: option.InteractiveMode,
 		SocketPath:      option.SocketPath,
 		UsePrivileged:   option.UsePrivileged,
 		LocalVolumes:    option.LocalVolumes,
+		Secrets:         option.Secrets,
+		ResumeContainer: option.ResumeContainer,
+		IgnoredPaths:    option.IgnoredPaths,
+		MetaPath:        option.MetaPath,
+		ContainerName:   option.ContainerName,
+		EntryName:       option.EntryName,
+		Mounts:          option.Mounts,
+		CacheDir:        option.CacheDir,
+		CommandsDir:     option.CommandsDir,
+		User:            option.User,
 	}
 }
 
@@ -168,7 +326,29 @@ func createBuildEntry(option Option) buildEntry {
 func New(option Option) Launcher {
 	l := new(launch)
 
-	l.runner = newDocker(option.Entry.Launcher.Image, option.Entry.Launcher.Version, option.UseSudo, option.InteractiveMode, option.SocketPath, option.FlagVerbose, option.LocalVolumes)
+	// the container CLI run with sudo reads the Docker config.json of root
+	registryAuth := option.Entry.RegistryAuth
+	if registryAuth == "" && option.UseSudo {
+		registryAuth = defaultRegistryAuth()
+	}
+
+	// the images are pulled for the architecture of the host instead of running under emulation
+	platform := option.Platform
+	if platform == "" {
+		platform = DefaultPlatform()
+	}
+
+	switch option.Runtime {
+	case config.RuntimeKubernetes:
+		l.runner = newKubernetes(option.Entry.Launcher.Image, option.Entry.Launcher.Version, option.InteractiveMode, option.FlagVerbose, option.LocalVolumes, option.NoTeardown, option.Platform)
+		l.command = "kubectl"
+	case config.RuntimePodman:
+		l.runner = newPodman(option.Entry.Launcher.Image, option.Entry.Launcher.Version, option.UseSudo, option.InteractiveMode, option.SocketPath, option.FlagVerbose, option.LocalVolumes, option.NoTeardown, registryAuth, platform, option.Retry, option.PullPolicy, option.Offline, config.DockerHostURL(option.Entry.DockerHost), option.Entry.Launcher.Archive)
+		l.command = "podman"
+	default:
+		l.runner = newDocker(option.Entry.Launcher.Image, option.Entry.Launcher.Version, option.UseSudo, option.InteractiveMode, option.SocketPath, option.FlagVerbose, option.LocalVolumes, option.NoTeardown, registryAuth, platform, option.Retry, option.PullPolicy, option.Offline, config.DockerHostURL(option.Entry.DockerHost), option.Entry.Launcher.Archive)
+		l.command = "docker"
+	}
 	l.buildEntry = createBuildEntry(option)
 
 	return l
@@ -176,17 +356,27 @@ func New(option Option) Launcher {
 
 // Run runs the build specified.
 func (l *launch) Run() error {
-	if _, err := lookPath("docker"); err != nil {
-		return fmt.Errorf("`docker` command is not found in $PATH: %v", err)
+	if _, err := lookPath(l.command); err != nil {
+		return newRuntimeError(fmt.Errorf("`%s` command is not found in $PATH: %v", l.command, err))
+	}
+
+	for _, m := range l.runner.mounts(l.buildEntry) {
+		logrus.Debugf("Mount %s", m)
 	}
 
 	if err := l.runner.setupBin(); err != nil {
-		return fmt.Errorf("failed to setup build: %v", err)
+		return newRuntimeError(fmt.Errorf("failed to setup build: %v", err))
 	}
 
 	err := l.runner.runBuild(l.buildEntry)
+	// the artifacts of the failed build are kept as well
+	if l.buildEntry.UseSudo {
+		if err := chownArtifacts(l.buildEntry.ArtifactsPath); err != nil {
+			logrus.Warnf("failed to change the owner of the artifacts in %s: %v", l.buildEntry.ArtifactsPath, err)
+		}
+	}
 	if err != nil {
-		return fmt.Errorf("failed to run build: %v", err)
+		return fmt.Errorf("failed to run build: %w", err)
 	}
 
 	return nil
@@ -199,3 +389,8 @@ func (l *launch) Kill(sig os.Signal) {
 func (l *launch) Clean() {
 	l.runner.clean()
 }
+
+// KeptContainer returns the ID of the build container kept by the failed build, or empty if it is not kept
+func (l *launch) KeptContainer() string {
+	return l.runner.keptContainerID()
+}
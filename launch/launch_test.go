@@ -2,11 +2,13 @@ package launch
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"runtime"
 	"syscall"
 	"testing"
 
@@ -112,6 +114,471 @@ func TestNew(t *testing.T) {
 		assert.True(t, ok)
 		assert.Equal(t, expectedBuildEntry, l.buildEntry)
 	})
+
+	t.Run("success with proxies", func(t *testing.T) {
+		buf, _ := ioutil.ReadFile(filepath.Join(testDir, "job.json"))
+		job := screwdriver.Job{}
+		_ = json.Unmarshal(buf, &job)
+
+		config := config.Entry{
+			APIURL:     "http://api-test.screwdriver.cd",
+			StoreURL:   "http://store-test.screwdriver.cd",
+			Token:      "testtoken",
+			HTTPProxy:  "http://proxy.example.com:8080",
+			HTTPSProxy: "http://secure-proxy.example.com:8443",
+			Launcher:   config.Launcher{Version: "latest", Image: "screwdrivercd/launcher"},
+		}
+
+		expectedBuildEntry := newBuildEntry()
+		expectedBuildEntry.Environment[0]["SD_ARTIFACTS_DIR"] = "/sd/workspace/artifacts"
+		expectedBuildEntry.Environment[0]["HTTP_PROXY"] = "http://proxy.example.com:8080"
+		expectedBuildEntry.Environment[0]["http_proxy"] = "http://proxy.example.com:8080"
+		expectedBuildEntry.Environment[0]["HTTPS_PROXY"] = "http://secure-proxy.example.com:8443"
+		expectedBuildEntry.Environment[0]["https_proxy"] = "http://secure-proxy.example.com:8443"
+
+		option := Option{
+			Job:           job,
+			Entry:         config,
+			JobName:       "test",
+			JWT:           "testjwt",
+			ArtifactsPath: "sd-artifacts",
+			Meta:          Meta{},
+		}
+
+		launcher := New(option)
+		l, ok := launcher.(*launch)
+		assert.True(t, ok)
+		assert.Equal(t, expectedBuildEntry, l.buildEntry)
+	})
+
+	t.Run("success with cache dir", func(t *testing.T) {
+		buf, _ := ioutil.ReadFile(filepath.Join(testDir, "job.json"))
+		job := screwdriver.Job{}
+		_ = json.Unmarshal(buf, &job)
+
+		config := config.Entry{
+			APIURL:   "http://api-test.screwdriver.cd",
+			StoreURL: "http://store-test.screwdriver.cd",
+			Token:    "testtoken",
+			Launcher: config.Launcher{Version: "latest", Image: "screwdrivercd/launcher"},
+		}
+
+		expectedBuildEntry := newBuildEntry()
+		expectedBuildEntry.Environment[0]["SD_ARTIFACTS_DIR"] = "/sd/workspace/artifacts"
+		expectedBuildEntry.Environment[0]["SD_LOCAL_CACHE_DIR"] = "/sd/cache"
+		expectedBuildEntry.CacheDir = "/home/user/.sdlocal/cache/build"
+
+		option := Option{
+			Job:           job,
+			Entry:         config,
+			JobName:       "test",
+			JWT:           "testjwt",
+			ArtifactsPath: "sd-artifacts",
+			Meta:          Meta{},
+			CacheDir:      "/home/user/.sdlocal/cache/build",
+		}
+
+		launcher := New(option)
+		l, ok := launcher.(*launch)
+		assert.True(t, ok)
+		assert.Equal(t, expectedBuildEntry, l.buildEntry)
+	})
+
+	t.Run("success with no teardown", func(t *testing.T) {
+		buf, _ := ioutil.ReadFile(filepath.Join(testDir, "job.json"))
+		job := screwdriver.Job{}
+		_ = json.Unmarshal(buf, &job)
+		job.Environment["SD_ARTIFACTS_DIR"] = "/test/artifacts"
+		job.Steps = append(job.Steps, screwdriver.Step{Name: "teardown-report", Command: "npm run report"})
+
+		config := config.Entry{
+			APIURL:   "http://api-test.screwdriver.cd",
+			StoreURL: "http://store-test.screwdriver.cd",
+			Token:    "testtoken",
+			Launcher: config.Launcher{Version: "latest", Image: "screwdrivercd/launcher"},
+		}
+
+		option := Option{
+			Job:           job,
+			Entry:         config,
+			JobName:       "test",
+			JWT:           "testjwt",
+			ArtifactsPath: "sd-artifacts",
+			Meta:          Meta{},
+			NoTeardown:    true,
+		}
+
+		launcher := New(option)
+		l, ok := launcher.(*launch)
+		assert.True(t, ok)
+		assert.Equal(t, newBuildEntry(), l.buildEntry)
+		d, ok := l.runner.(*docker)
+		assert.True(t, ok)
+		assert.True(t, d.noTeardown)
+	})
+}
+
+func TestNewWithTeardownSteps(t *testing.T) {
+	job := screwdriver.Job{
+		Steps: []screwdriver.Step{
+			{Name: "install", Command: "npm install"},
+			{Name: "teardown-report", Command: "npm run report"},
+			{Name: "test", Command: "npm test"},
+		},
+		Environment: map[string]string{},
+	}
+
+	launcher := New(Option{Job: job, JobName: "test", ArtifactsPath: "sd-artifacts"})
+	l, ok := launcher.(*launch)
+	assert.True(t, ok)
+	assert.Equal(t, []screwdriver.Step{
+		{Name: "install", Command: "npm install"},
+		{Name: "test", Command: "npm test"},
+		{Name: "teardown-report", Command: "npm run report"},
+	}, l.buildEntry.Steps)
+}
+
+func TestNewWithStepEnvironment(t *testing.T) {
+	job := screwdriver.Job{
+		Steps: []screwdriver.Step{
+			{Name: "install", Command: "npm install"},
+			{Name: "test", Command: "npm test\n", Environment: map[string]string{
+				"NODE_ENV": "test",
+				"PATH":     "/opt/bin:$PATH",
+				"MESSAGE":  `say "hi" from ` + "`echo`",
+				"API_KEY":  "plain",
+			}},
+		},
+		Environment: map[string]string{"NODE_ENV": "production"},
+	}
+
+	launcher := New(Option{Job: job, JobName: "test", ArtifactsPath: "sd-artifacts", Secrets: EnvVar{"API_KEY": "apikey"}})
+	l, ok := launcher.(*launch)
+	assert.True(t, ok)
+	assert.Equal(t, []screwdriver.Step{
+		{Name: "install", Command: "npm install"},
+		{Name: "test", Command: "(\nexport MESSAGE=\"say \\\"hi\\\" from \\`echo\\`\"\nexport NODE_ENV=\"test\"\nexport PATH=\"/opt/bin:$PATH\"\nnpm test\n)"},
+	}, l.buildEntry.Steps)
+}
+
+func TestNewWithWorkdir(t *testing.T) {
+	steps := []screwdriver.Step{
+		{Name: "install", Command: "npm install\n"},
+		{Name: "test", Command: "npm test", Environment: map[string]string{"NODE_ENV": "test"}},
+	}
+
+	testCases := map[string]struct {
+		annotations map[string]interface{}
+		workdir     string
+		expected    []screwdriver.Step
+	}{
+		"without workdir": {
+			expected: []screwdriver.Step{
+				{Name: "install", Command: "npm install\n"},
+				{Name: "test", Command: "(\nexport NODE_ENV=\"test\"\nnpm test\n)"},
+			},
+		},
+		"with the annotation": {
+			annotations: map[string]interface{}{"screwdriver.cd/workdir": "/app"},
+			expected: []screwdriver.Step{
+				{Name: "install", Command: "mkdir -p \"/app\" && cd \"/app\" && {\nnpm install\n}"},
+				{Name: "test", Command: "mkdir -p \"/app\" && cd \"/app\" && {\n(\nexport NODE_ENV=\"test\"\nnpm test\n)\n}"},
+			},
+		},
+		"with the option overriding the annotation": {
+			annotations: map[string]interface{}{"screwdriver.cd/workdir": "/app"},
+			workdir:     "/my app",
+			expected: []screwdriver.Step{
+				{Name: "install", Command: "mkdir -p \"/my app\" && cd \"/my app\" && {\nnpm install\n}"},
+				{Name: "test", Command: "mkdir -p \"/my app\" && cd \"/my app\" && {\n(\nexport NODE_ENV=\"test\"\nnpm test\n)\n}"},
+			},
+		},
+		"with the relative annotation ignored": {
+			annotations: map[string]interface{}{"screwdriver.cd/workdir": "app"},
+			expected: []screwdriver.Step{
+				{Name: "install", Command: "npm install\n"},
+				{Name: "test", Command: "(\nexport NODE_ENV=\"test\"\nnpm test\n)"},
+			},
+		},
+	}
+
+	for name, tt := range testCases {
+		t.Run(name, func(t *testing.T) {
+			job := screwdriver.Job{Steps: steps, Annotations: tt.annotations, Environment: map[string]string{}}
+			launcher := New(Option{Job: job, JobName: "test", ArtifactsPath: "sd-artifacts", Workdir: tt.workdir})
+			l, ok := launcher.(*launch)
+			assert.True(t, ok)
+			assert.Equal(t, tt.expected, l.buildEntry.Steps)
+		})
+	}
+}
+
+func TestValidateWorkdir(t *testing.T) {
+	assert.Nil(t, ValidateWorkdir("/sd/workspace/src/app"))
+	assert.Equal(t, "invalid workdir ./app: must be an absolute path in the build container", ValidateWorkdir("./app").Error())
+}
+
+func TestNewWithShell(t *testing.T) {
+	testCases := map[string]struct {
+		annotations map[string]interface{}
+		env         map[string]string
+		shell       string
+		expected    string
+	}{
+		"without shell": {
+			expected: "",
+		},
+		"with the environment of the job": {
+			env:      map[string]string{"USER_SHELL_BIN": "/bin/zsh"},
+			expected: "/bin/zsh",
+		},
+		"with the annotation overriding the environment": {
+			annotations: map[string]interface{}{"screwdriver.cd/shell": "/bin/bash"},
+			env:         map[string]string{"USER_SHELL_BIN": "/bin/zsh"},
+			expected:    "/bin/bash",
+		},
+		"with the option overriding the annotation": {
+			annotations: map[string]interface{}{"screwdriver.cd/shell": "/bin/bash"},
+			shell:       "/usr/local/bin/bash",
+			expected:    "/usr/local/bin/bash",
+		},
+		"with the relative annotation ignored": {
+			annotations: map[string]interface{}{"screwdriver.cd/shell": "bash"},
+			expected:    "",
+		},
+	}
+
+	for name, tt := range testCases {
+		t.Run(name, func(t *testing.T) {
+			env := tt.env
+			if env == nil {
+				env = map[string]string{}
+			}
+			job := screwdriver.Job{Annotations: tt.annotations, Environment: env}
+			launcher := New(Option{Job: job, JobName: "test", ArtifactsPath: "sd-artifacts", Shell: tt.shell})
+			l, ok := launcher.(*launch)
+			assert.True(t, ok)
+			assert.Equal(t, tt.expected, l.buildEntry.Environment[0][ShellEnv])
+		})
+	}
+}
+
+func TestValidateShell(t *testing.T) {
+	assert.Nil(t, ValidateShell("/bin/bash"))
+	assert.Equal(t, "invalid shell bash: must be an absolute path in the build image like /bin/bash", ValidateShell("bash").Error())
+}
+
+func TestValidateUser(t *testing.T) {
+	assert.Nil(t, ValidateUser("1000"))
+	assert.Nil(t, ValidateUser("1000:1000"))
+	assert.Nil(t, ValidateUser("root"))
+	assert.Equal(t, "invalid user 1000:1000:1000: must be in the form of uid[:gid] like 1000:1000", ValidateUser("1000:1000:1000").Error())
+	assert.Equal(t, "invalid user :1000: must be in the form of uid[:gid] like 1000:1000", ValidateUser(":1000").Error())
+}
+
+func TestDefaultUser(t *testing.T) {
+	defer func() {
+		goos = runtime.GOOS
+	}()
+
+	owner := ""
+	if os.Getuid() != 0 {
+		owner = fmt.Sprintf("%d:%d", os.Getuid(), os.Getgid())
+	}
+
+	goos = "linux"
+	assert.Equal(t, owner, DefaultUser(""))
+	assert.Equal(t, owner, DefaultUser("docker"))
+	assert.Equal(t, "", DefaultUser("podman"))
+	assert.Equal(t, "", DefaultUser("k8s"))
+
+	goos = "darwin"
+	assert.Equal(t, "", DefaultUser("docker"))
+}
+
+func TestNewWithoutFailFast(t *testing.T) {
+	job := screwdriver.Job{
+		Steps: []screwdriver.Step{
+			{Name: "lint", Command: "npm run lint\n"},
+			{Name: "teardown-report", Command: "npm run report"},
+			{Name: "test", Command: "npm test"},
+		},
+		Environment: map[string]string{},
+	}
+	check := screwdriver.Step{
+		Name:    "check-failed-steps",
+		Command: "if [ -s \"$SD_ARTIFACTS_DIR/sd-local-failed-steps\" ]; then\necho \"failed steps:\"\ncat \"$SD_ARTIFACTS_DIR/sd-local-failed-steps\"\nexit 1\nfi",
+	}
+
+	launcher := New(Option{Job: job, JobName: "test", ArtifactsPath: "sd-artifacts", NoFailFast: true})
+	l, ok := launcher.(*launch)
+	assert.True(t, ok)
+	assert.Equal(t, []screwdriver.Step{
+		{Name: "lint", Command: "{\nnpm run lint\n} || {\necho \"lint failed with exit status $?, the next steps run without fail fast\"\necho \"lint\" >> \"$SD_ARTIFACTS_DIR/sd-local-failed-steps\"\n}"},
+		{Name: "test", Command: "{\nnpm test\n} || {\necho \"test failed with exit status $?, the next steps run without fail fast\"\necho \"test\" >> \"$SD_ARTIFACTS_DIR/sd-local-failed-steps\"\n}"},
+		check,
+		{Name: "teardown-report", Command: "npm run report"},
+	}, l.buildEntry.Steps)
+
+	launcher = New(Option{Job: job, JobName: "test", ArtifactsPath: "sd-artifacts", NoFailFast: true, NoTeardown: true})
+	l, ok = launcher.(*launch)
+	assert.True(t, ok)
+	assert.Equal(t, check, l.buildEntry.Steps[len(l.buildEntry.Steps)-1])
+}
+
+func TestFailedSteps(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sd-artifacts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	failed, err := FailedSteps(dir)
+	assert.Nil(t, err)
+	assert.Nil(t, failed)
+
+	if err := ioutil.WriteFile(filepath.Join(dir, FailedStepsFile), []byte("lint\ntest\n"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	failed, err = FailedSteps(dir)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"lint", "test"}, failed)
+
+	assert.Nil(t, RemoveFailedSteps(dir))
+	assert.Nil(t, RemoveFailedSteps(dir))
+	failed, err = FailedSteps(dir)
+	assert.Nil(t, err)
+	assert.Nil(t, failed)
+}
+
+func TestNewWithSecrets(t *testing.T) {
+	buf, _ := ioutil.ReadFile(filepath.Join(testDir, "job.json"))
+	job := screwdriver.Job{}
+	_ = json.Unmarshal(buf, &job)
+	job.Environment["SD_ARTIFACTS_DIR"] = "/test/artifacts"
+
+	option := Option{
+		Job: job,
+		Entry: config.Entry{
+			APIURL:   "http://api-test.screwdriver.cd",
+			StoreURL: "http://store-test.screwdriver.cd",
+			Launcher: config.Launcher{Version: "latest", Image: "screwdrivercd/launcher"},
+		},
+		JobName:       "test",
+		JWT:           "testjwt",
+		ArtifactsPath: "sd-artifacts",
+		Meta:          Meta{},
+		OptionEnv:     EnvVar{"API_KEY": "plain"},
+		Secrets:       EnvVar{"API_KEY": "apikey"},
+	}
+
+	l, ok := New(option).(*launch)
+	assert.True(t, ok)
+	assert.Equal(t, EnvVar{"API_KEY": "apikey"}, l.buildEntry.Secrets)
+	_, ok = l.buildEntry.Environment[0]["API_KEY"]
+	assert.False(t, ok)
+
+	configJSON, err := json.Marshal(l.buildEntry)
+	assert.Nil(t, err)
+	assert.NotContains(t, string(configJSON), "apikey")
+}
+
+func TestNewWithRuntime(t *testing.T) {
+	entry := config.Entry{
+		APIURL:   "http://api-test.screwdriver.cd",
+		StoreURL: "http://store-test.screwdriver.cd",
+		Token:    "testtoken",
+		Launcher: config.Launcher{Version: "latest", Image: "screwdrivercd/launcher"},
+	}
+
+	t.Run("success with default runtime", func(t *testing.T) {
+		l, ok := New(Option{Entry: entry}).(*launch)
+		assert.True(t, ok)
+		d, ok := l.runner.(*docker)
+		assert.True(t, ok)
+		assert.Equal(t, "docker", l.command)
+		assert.Equal(t, DefaultPlatform(), d.platform)
+	})
+
+	t.Run("success with platform", func(t *testing.T) {
+		l, ok := New(Option{Entry: entry, Platform: "linux/amd64"}).(*launch)
+		assert.True(t, ok)
+		d, ok := l.runner.(*docker)
+		assert.True(t, ok)
+		assert.Equal(t, "linux/amd64", d.platform)
+	})
+
+	t.Run("success with podman runtime", func(t *testing.T) {
+		l, ok := New(Option{Entry: entry, Runtime: config.RuntimePodman}).(*launch)
+		assert.True(t, ok)
+		d, ok := l.runner.(*docker)
+		assert.True(t, ok)
+		assert.Equal(t, podmanClient{}, d.client)
+		assert.Equal(t, "podman", l.command)
+	})
+
+	t.Run("success with k8s runtime", func(t *testing.T) {
+		l, ok := New(Option{Entry: entry, Runtime: config.RuntimeKubernetes}).(*launch)
+		assert.True(t, ok)
+		k, ok := l.runner.(*kubernetes)
+		assert.True(t, ok)
+		assert.Equal(t, "", k.platform)
+		assert.Equal(t, "kubectl", l.command)
+	})
+}
+
+func TestBuildResources(t *testing.T) {
+	steps := []screwdriver.Step{{Name: "install"}, {Name: "test"}}
+
+	testCase := []struct {
+		name         string
+		annotations  map[string]interface{}
+		memory       string
+		expectCPU    string
+		expectMemory string
+	}{
+		{"no annotations", nil, "", "", ""},
+		{"no annotations with memory", nil, "2g", "", "2g"},
+		{"job annotations", map[string]interface{}{"screwdriver.cd/cpu": "HIGH", "screwdriver.cd/ram": "low"}, "", "6", "2048m"},
+		{"step annotations", map[string]interface{}{"screwdriver.cd/ram": "LOW", "screwdriver.cd/cpu/test": 4.0, "screwdriver.cd/ram/test": 8.0}, "", "4", "8192m"},
+		{"annotations of the steps not to run", map[string]interface{}{"screwdriver.cd/ram": "LOW", "screwdriver.cd/ram/deploy": "TURBO"}, "", "", "2048m"},
+		{"annotations under memory", map[string]interface{}{"screwdriver.cd/ram": 0.5}, "1GB", "", "512m"},
+		{"annotations over memory", map[string]interface{}{"screwdriver.cd/ram": "HIGH"}, "4g", "", "4g"},
+		{"invalid annotations", map[string]interface{}{"screwdriver.cd/cpu": "HUGE", "screwdriver.cd/ram": -1.0}, "", "", ""},
+	}
+
+	for _, tt := range testCase {
+		t.Run(tt.name, func(t *testing.T) {
+			option := Option{Job: screwdriver.Job{Annotations: tt.annotations}, Memory: tt.memory}
+			cpu, memory := buildResources(option, steps)
+
+			assert.Equal(t, tt.expectCPU, cpu)
+			assert.Equal(t, tt.expectMemory, memory)
+		})
+	}
+}
+
+func TestParseMemory(t *testing.T) {
+	testCase := []struct {
+		memory      string
+		expect      int64
+		expectError error
+	}{
+		{"512", 512, nil},
+		{"512m", 512 << 20, nil},
+		{"2GB", 2 << 30, nil},
+		{"1k", 1 << 10, nil},
+		{"2x", 0, fmt.Errorf("invalid memory 2x")},
+	}
+
+	for _, tt := range testCase {
+		t.Run(tt.memory, func(t *testing.T) {
+			actual, err := parseMemory(tt.memory)
+
+			assert.Equal(t, tt.expect, actual)
+			assert.Equal(t, tt.expectError, err)
+		})
+	}
 }
 
 type mockRunner struct {
@@ -119,6 +586,7 @@ type mockRunner struct {
 	errorSetupBin    error
 	killCalledCount  int
 	cleanCalledCount int
+	keptContainer    string
 }
 
 func (m *mockRunner) runBuild(buildEntry buildEntry) error {
@@ -137,6 +605,14 @@ func (m *mockRunner) kill(os.Signal) {
 	m.killCalledCount++
 }
 
+func (m *mockRunner) mounts(buildEntry buildEntry) []string {
+	return []string{"/src/:/sd/workspace/src"}
+}
+
+func (m *mockRunner) keptContainerID() string {
+	return m.keptContainer
+}
+
 func TestRun(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
 		buf, _ := ioutil.ReadFile(filepath.Join(testDir, "job.json"))
@@ -149,6 +625,7 @@ func TestRun(t *testing.T) {
 				errorRunBuild: nil,
 				errorSetupBin: nil,
 			},
+			command: "docker",
 		}
 
 		lookPath = func(cmd string) (string, error) {
@@ -164,6 +641,34 @@ func TestRun(t *testing.T) {
 		assert.Equal(t, nil, err)
 	})
 
+	t.Run("success with sudo", func(t *testing.T) {
+		defer func() {
+			lookPath = exec.LookPath
+			execCommand = exec.Command
+		}()
+		lookPath = func(cmd string) (string, error) {
+			return "/bin/docker", nil
+		}
+		c := newFakeExecCommand("SUCCESS_RUN_BUILD")
+		execCommand = c.execCmd
+
+		buildEntry := newBuildEntry()
+		buildEntry.UseSudo = true
+		launch := launch{
+			buildEntry: buildEntry,
+			runner: &mockRunner{
+				errorRunBuild: fmt.Errorf("docker: Error response from daemon"),
+			},
+			command: "docker",
+		}
+
+		err := launch.Run()
+
+		// the owner of the artifacts of the failed build is changed as well
+		assert.EqualError(t, err, "failed to run build: docker: Error response from daemon")
+		assert.Equal(t, []string{fmt.Sprintf("sudo chown -R %d:%d sd-artifacts", os.Getuid(), os.Getgid())}, c.commands)
+	})
+
 	t.Run("failure in lookPath", func(t *testing.T) {
 		buf, _ := ioutil.ReadFile(filepath.Join(testDir, "job.json"))
 		job := screwdriver.Job{}
@@ -175,6 +680,7 @@ func TestRun(t *testing.T) {
 				errorRunBuild: nil,
 				errorSetupBin: nil,
 			},
+			command: "docker",
 		}
 
 		lookPath = func(cmd string) (string, error) {
@@ -187,7 +693,8 @@ func TestRun(t *testing.T) {
 
 		err := launch.Run()
 
-		assert.Equal(t, fmt.Errorf("`docker` command is not found in $PATH: exec: \"docker\": executable file not found in $PATH"), err)
+		assert.EqualError(t, err, "`docker` command is not found in $PATH: exec: \"docker\": executable file not found in $PATH")
+		assert.True(t, errors.Is(err, ErrRuntime))
 	})
 
 	t.Run("failure in SetupBin", func(t *testing.T) {
@@ -201,6 +708,7 @@ func TestRun(t *testing.T) {
 				errorRunBuild: nil,
 				errorSetupBin: fmt.Errorf("docker: Error response from daemon"),
 			},
+			command: "docker",
 		}
 
 		lookPath = func(cmd string) (string, error) {
@@ -213,7 +721,8 @@ func TestRun(t *testing.T) {
 
 		err := launch.Run()
 
-		assert.Equal(t, fmt.Errorf("failed to setup build: docker: Error response from daemon"), err)
+		assert.EqualError(t, err, "failed to setup build: docker: Error response from daemon")
+		assert.True(t, errors.Is(err, ErrRuntime))
 	})
 
 	t.Run("failure in RunBuild", func(t *testing.T) {
@@ -227,6 +736,30 @@ func TestRun(t *testing.T) {
 				errorRunBuild: fmt.Errorf("docker: Error response from daemon"),
 				errorSetupBin: nil,
 			},
+			command: "docker",
+		}
+
+		lookPath = func(cmd string) (string, error) {
+			return "/bin/docker", nil
+		}
+
+		defer func() {
+			lookPath = exec.LookPath
+		}()
+
+		err := launch.Run()
+
+		assert.EqualError(t, err, "failed to run build: docker: Error response from daemon")
+		assert.False(t, errors.Is(err, ErrRuntime))
+	})
+
+	t.Run("failure in RunBuild by runtime", func(t *testing.T) {
+		launch := launch{
+			buildEntry: newBuildEntry(),
+			runner: &mockRunner{
+				errorRunBuild: newRuntimeError(fmt.Errorf("failed to pull user image exit status 1")),
+			},
+			command: "docker",
 		}
 
 		lookPath = func(cmd string) (string, error) {
@@ -239,7 +772,8 @@ func TestRun(t *testing.T) {
 
 		err := launch.Run()
 
-		assert.Equal(t, fmt.Errorf("failed to run build: docker: Error response from daemon"), err)
+		assert.EqualError(t, err, "failed to run build: failed to pull user image exit status 1")
+		assert.True(t, errors.Is(err, ErrRuntime))
 	})
 }
 
@@ -280,3 +814,71 @@ func TestClean(t *testing.T) {
 		assert.Equal(t, 1, mRunner.cleanCalledCount)
 	})
 }
+
+func TestKeptContainer(t *testing.T) {
+	t.Run("success to get kept container", func(t *testing.T) {
+		launch := launch{
+			buildEntry: newBuildEntry(),
+			runner: &mockRunner{
+				keptContainer: "cid",
+			},
+		}
+		assert.Equal(t, "cid", launch.KeptContainer())
+	})
+}
+
+func TestPlan(t *testing.T) {
+	t.Run("success to get plan", func(t *testing.T) {
+		buildEntry := newBuildEntry(func(b *buildEntry) {
+			b.Secrets = EnvVar{"API_KEY": "apikey"}
+			b.CPULimit = "2"
+			b.MemoryLimit = "4g"
+			b.UsePrivileged = true
+			b.User = "1000:1000"
+		})
+		launch := launch{
+			buildEntry: buildEntry,
+			runner:     &mockRunner{},
+		}
+
+		assert.Equal(t, Plan{
+			Image: buildEntry.Image,
+			Steps: buildEntry.Steps,
+			Environment: []string{
+				"FOO=foo",
+				"SD_API_URL=http://api-test.screwdriver.cd/v4",
+				"SD_ARTIFACTS_DIR=/test/artifacts",
+				"SD_BASE_COMMAND_PATH=/sd/commands/",
+				"SD_STORE_URL=http://store-test.screwdriver.cd/v1",
+			},
+			Secrets:    []string{"API_KEY", "SD_TOKEN"},
+			Mounts:     []string{"/src/:/sd/workspace/src"},
+			CPU:        "2",
+			Memory:     "4g",
+			Privileged: true,
+			User:       "1000:1000",
+		}, launch.Plan())
+	})
+}
+
+func TestEnvironment(t *testing.T) {
+	l := launch{
+		buildEntry: newBuildEntry(func(b *buildEntry) {
+			b.Secrets = EnvVar{"API_KEY": "apikey"}
+		}),
+		runner: &mockRunner{},
+	}
+
+	env, secrets := l.Environment()
+	assert.Equal(t, EnvVar{
+		"API_KEY":              "apikey",
+		"FOO":                  "foo",
+		"SD_API_URL":           "http://api-test.screwdriver.cd/v4",
+		"SD_ARTIFACTS_DIR":     "/test/artifacts",
+		"SD_BASE_COMMAND_PATH": "/sd/commands/",
+		"SD_STORE_URL":         "http://store-test.screwdriver.cd/v1",
+		"SD_TOKEN":             "testjwt",
+	}, env)
+	assert.Equal(t, []string{"API_KEY", "SD_TOKEN"}, secrets)
+	assert.Equal(t, 6, len(l.buildEntry.Environment[0]))
+}
@@ -0,0 +1,82 @@
+package launch
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/mitchellh/go-homedir"
+)
+
+// srcMountDir is the directory of the build container the source code is mounted into
+const srcMountDir = "/sd/workspace/src/" + scmHost + "/" + orgRepo
+
+// reservedMountDirs are the directories of the build container mounted by sd-local, which can't be mounted by --mount
+var reservedMountDirs = []string{srcMountDir, defaultArtDir, "/opt/sd", MetaDir, CacheDir, "/tmp/auth.sock"}
+
+// Mount is the bind mount of the host path into the build container
+type Mount struct {
+	Source   string
+	Target   string
+	ReadOnly bool
+}
+
+// ParseMount parses the mount like ~/.m2:/root/.m2:ro. The host path must exist,
+// and the path in the build container must not overlap the directories mounted by sd-local like the source code.
+func ParseMount(spec string) (Mount, error) {
+	parts := splitMountSpec(spec)
+	if len(parts) < 2 || len(parts) > 3 || parts[0] == "" || parts[1] == "" {
+		return Mount{}, fmt.Errorf("invalid mount %s: must be <host path>:<container path>[:ro]", spec)
+	}
+
+	m := Mount{Target: path.Clean(parts[1])}
+	if len(parts) == 3 {
+		switch parts[2] {
+		case "ro":
+			m.ReadOnly = true
+		case "rw":
+		default:
+			return Mount{}, fmt.Errorf("invalid mount %s: the mode must be ro or rw", spec)
+		}
+	}
+	if !path.IsAbs(m.Target) {
+		return Mount{}, fmt.Errorf("invalid mount %s: the container path must be absolute", spec)
+	}
+
+	source, err := homedir.Expand(parts[0])
+	if err != nil {
+		return Mount{}, err
+	}
+	m.Source, err = filepath.Abs(source)
+	if err != nil {
+		return Mount{}, err
+	}
+	if _, err := os.Stat(m.Source); err != nil {
+		return Mount{}, fmt.Errorf("invalid mount %s: the host path %s does not exist", spec, m.Source)
+	}
+	if err := ValidateHostPath(m.Source); err != nil {
+		return Mount{}, fmt.Errorf("invalid mount %s: %v", spec, err)
+	}
+
+	for _, dir := range reservedMountDirs {
+		if overlaps(m.Target, dir) {
+			return Mount{}, fmt.Errorf("invalid mount %s: %s collides with %s mounted by sd-local", spec, m.Target, dir)
+		}
+	}
+	return m, nil
+}
+
+// overlaps returns true if one of the paths is the same as or in the other
+func overlaps(a, b string) bool {
+	return a == b || strings.HasPrefix(a, b+"/") || strings.HasPrefix(b, a+"/") || a == "/" || b == "/"
+}
+
+// volume returns the volume option of the container CLI
+func (m Mount) volume() string {
+	if m.ReadOnly {
+		return fmt.Sprintf("%s:%s:ro", hostPath(m.Source), m.Target)
+	}
+	return fmt.Sprintf("%s:%s", hostPath(m.Source), m.Target)
+}
@@ -0,0 +1,110 @@
+package launch
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMount(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mount")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	missing := filepath.Join(dir, "missing")
+
+	testCases := []struct {
+		name      string
+		spec      string
+		expected  Mount
+		expectErr string
+	}{
+		{name: "read write", spec: dir + ":/root/.m2", expected: Mount{Source: dir, Target: "/root/.m2"}},
+		{name: "read only", spec: dir + ":/root/.m2/:ro", expected: Mount{Source: dir, Target: "/root/.m2", ReadOnly: true}},
+		{name: "explicit read write", spec: dir + ":/cache:rw", expected: Mount{Source: dir, Target: "/cache"}},
+		{name: "no container path", spec: dir, expectErr: "invalid mount " + dir + ": must be <host path>:<container path>[:ro]"},
+		{name: "invalid mode", spec: dir + ":/cache:rx", expectErr: "invalid mount " + dir + ":/cache:rx: the mode must be ro or rw"},
+		{name: "relative container path", spec: dir + ":cache", expectErr: "invalid mount " + dir + ":cache: the container path must be absolute"},
+		{name: "missing host path", spec: missing + ":/cache", expectErr: "invalid mount " + missing + ":/cache: the host path " + missing + " does not exist"},
+		{
+			name:      "source code",
+			spec:      dir + ":/sd/workspace/src/screwdriver.cd/sd-local/local-build/vendor",
+			expectErr: "invalid mount " + dir + ":/sd/workspace/src/screwdriver.cd/sd-local/local-build/vendor: /sd/workspace/src/screwdriver.cd/sd-local/local-build/vendor collides with /sd/workspace/src/screwdriver.cd/sd-local/local-build mounted by sd-local",
+		},
+		{
+			name:      "parent of source code",
+			spec:      dir + ":/sd/workspace",
+			expectErr: "invalid mount " + dir + ":/sd/workspace: /sd/workspace collides with /sd/workspace/src/screwdriver.cd/sd-local/local-build mounted by sd-local",
+		},
+		{
+			name:      "launcher",
+			spec:      dir + ":/opt/sd:ro",
+			expectErr: "invalid mount " + dir + ":/opt/sd:ro: /opt/sd collides with /opt/sd mounted by sd-local",
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := ParseMount(tt.spec)
+			if tt.expectErr != "" {
+				assert.Equal(t, tt.expectErr, err.Error())
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, tt.expected, m)
+		})
+	}
+}
+
+func TestMounts(t *testing.T) {
+	d := &docker{volume: "SD_LAUNCH_BIN", habVolume: "SD_LAUNCH_HAB", socketPath: "/auth.sock"}
+	buildEntry := newBuildEntry(func(b *buildEntry) {
+		b.SrcPath = "/src"
+		b.CacheDir = "/home/user/.sdlocal/cache/build"
+		b.CommandsDir = "/home/user/.sdlocal/cache/commands"
+		b.Mounts = []Mount{
+			{Source: "/home/user/.m2", Target: "/root/.m2", ReadOnly: true},
+			{Source: "/var/cache/build", Target: "/cache"},
+		}
+	})
+	assert.Equal(t, []string{
+		"/src/:/sd/workspace/src/screwdriver.cd/sd-local/local-build",
+		"sd-artifacts/:/test/artifacts",
+		"SD_LAUNCH_BIN:/opt/sd",
+		"SD_LAUNCH_HAB:/opt/sd/hab",
+		"/auth.sock:/tmp/auth.sock:rw",
+		"/home/user/.sdlocal/cache/build/:/sd/cache",
+		"/home/user/.sdlocal/cache/commands/:/opt/sd/commands",
+		"/home/user/.m2:/root/.m2:ro",
+		"/var/cache/build:/cache",
+	}, d.mounts(buildEntry))
+}
+
+func TestMountsOnWindows(t *testing.T) {
+	defer func() {
+		goos = runtime.GOOS
+	}()
+	goos = "windows"
+
+	d := &docker{volume: "SD_LAUNCH_BIN", habVolume: "SD_LAUNCH_HAB", socketPath: "/auth.sock"}
+	buildEntry := newBuildEntry(func(b *buildEntry) {
+		b.SrcPath = `C:\Users\user\src`
+		b.ArtifactsPath = `C:\Users\user\src\sd-artifacts`
+		b.Mounts = []Mount{
+			{Source: `C:\Users\user\.m2`, Target: "/root/.m2", ReadOnly: true},
+		}
+	})
+	assert.Equal(t, []string{
+		"C:/Users/user/src:/sd/workspace/src/screwdriver.cd/sd-local/local-build",
+		"C:/Users/user/src/sd-artifacts:/test/artifacts",
+		"SD_LAUNCH_BIN:/opt/sd",
+		"SD_LAUNCH_HAB:/opt/sd/hab",
+		"/auth.sock:/tmp/auth.sock:rw",
+		"C:/Users/user/.m2:/root/.m2:ro",
+	}, d.mounts(buildEntry))
+}
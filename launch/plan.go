@@ -0,0 +1,64 @@
+package launch
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/screwdriver-cd/sd-local/screwdriver"
+)
+
+// Plan describes how the build runs without running it
+type Plan struct {
+	Image       string
+	Steps       []screwdriver.Step
+	Environment []string
+	// Secrets are the names of the environment variables whose values must not be shown
+	Secrets    []string
+	Mounts     []string
+	CPU        string
+	Memory     string
+	Privileged bool
+	// User is the user of the build container, which is empty for the user of the image
+	User string
+}
+
+// Plan returns the plan of the build. It does not run any commands.
+func (l *launch) Plan() Plan {
+	env := l.buildEntry.Environment[0]
+	plan := Plan{
+		Image:       l.buildEntry.Image,
+		Steps:       l.buildEntry.Steps,
+		Environment: make([]string, 0, len(env)),
+		Secrets:     sortedKeys(l.buildEntry.Secrets),
+		Mounts:      l.runner.mounts(l.buildEntry),
+		CPU:         l.buildEntry.CPULimit,
+		Memory:      l.buildEntry.MemoryLimit,
+		Privileged:  l.buildEntry.UsePrivileged,
+		User:        l.buildEntry.User,
+	}
+	for _, k := range sortedKeys(env) {
+		// the token to access the API is as sensitive as the secrets
+		if k == "SD_TOKEN" {
+			plan.Secrets = append(plan.Secrets, k)
+			continue
+		}
+		plan.Environment = append(plan.Environment, fmt.Sprintf("%s=%s", k, env[k]))
+	}
+	sort.Strings(plan.Secrets)
+	return plan
+}
+
+// Environment returns the environment variables of the build container, which are the same as the build has including the secrets,
+// and the names of the secrets among them. The environment of the steps is not included because it is set only in the steps.
+func (l *launch) Environment() (EnvVar, []string) {
+	env := EnvVar{}
+	for k, v := range l.buildEntry.Environment[0] {
+		env[k] = v
+	}
+	for k, v := range l.buildEntry.Secrets {
+		env[k] = v
+	}
+	secrets := append(sortedKeys(l.buildEntry.Secrets), "SD_TOKEN")
+	sort.Strings(secrets)
+	return env, secrets
+}
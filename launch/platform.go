@@ -0,0 +1,46 @@
+package launch
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+)
+
+// DefaultPlatform returns the platform of the images which runs natively on the host
+func DefaultPlatform() string {
+	return "linux/" + runtime.GOARCH
+}
+
+// ValidatePlatform validates the platform in the form of os/arch[/variant]
+func ValidatePlatform(platform string) error {
+	parts := strings.Split(platform, "/")
+	if len(parts) < 2 || len(parts) > 3 {
+		return fmt.Errorf("invalid platform %s: must be in the form of os/arch[/variant] like linux/arm64", platform)
+	}
+	for _, p := range parts {
+		if p == "" {
+			return fmt.Errorf("invalid platform %s: must be in the form of os/arch[/variant] like linux/arm64", platform)
+		}
+	}
+	return nil
+}
+
+// isPlatformUnavailable returns true if the pull failed because the image is not provided for the platform
+func isPlatformUnavailable(stderr string) bool {
+	stderr = strings.ToLower(stderr)
+	for _, msg := range []string{"no matching manifest for", "does not match the specified platform", "no image found in manifest list for architecture"} {
+		if strings.Contains(stderr, msg) {
+			return true
+		}
+	}
+	return false
+}
+
+// nodeSelector returns the node selector of kubernetes to schedule the Pod on the nodes of the platform
+func nodeSelector(platform string) map[string]string {
+	parts := strings.Split(platform, "/")
+	return map[string]string{
+		"kubernetes.io/os":   parts[0],
+		"kubernetes.io/arch": parts[1],
+	}
+}
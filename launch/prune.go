@@ -0,0 +1,112 @@
+package launch
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/screwdriver-cd/sd-local/config"
+)
+
+// Leftovers are the resources of sd-local left in the runtime by the interrupted builds or --no-teardown
+type Leftovers struct {
+	Containers []string
+	Volumes    []string
+	Images     []string
+}
+
+// Empty returns true if nothing is left
+func (l Leftovers) Empty() bool {
+	return len(l.Containers) == 0 && len(l.Volumes) == 0 && len(l.Images) == 0
+}
+
+// imageRepository returns the repository of the image without the tag and the digest
+func imageRepository(image string) string {
+	if i := strings.Index(image, "@"); i >= 0 {
+		image = image[:i]
+	}
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		image = image[:i]
+	}
+	return image
+}
+
+// FindLeftovers finds the build containers labeled by sd-local and the volumes of the launcher.
+// The dangling images of the launcher, which are left by pulling the new versions, are found if launcherImage is specified.
+// The runtime must be docker or podman.
+func FindLeftovers(runtime, launcherImage string) (Leftovers, error) {
+	var client containerClient = dockerClient{}
+	if runtime == config.RuntimePodman {
+		client = podmanClient{}
+	}
+
+	containers, err := FindContainers(runtime)
+	if err != nil {
+		return Leftovers{}, err
+	}
+	l := Leftovers{Containers: containers, Volumes: []string{}, Images: []string{}}
+
+	out, err := runCommand(client.command(), "volume", "ls", "--quiet")
+	if err != nil {
+		return Leftovers{}, fmt.Errorf("failed to list the volumes: %v", err)
+	}
+	for _, v := range strings.Fields(out) {
+		if isLaunchVolume(v) {
+			l.Volumes = append(l.Volumes, v)
+		}
+	}
+
+	if launcherImage == "" {
+		return l, nil
+	}
+	out, err = runCommand(client.command(), "image", "ls", "--format", "{{.ID}} {{.Tag}}", imageRepository(launcherImage))
+	if err != nil {
+		return Leftovers{}, fmt.Errorf("failed to list the images: %v", err)
+	}
+	found := map[string]bool{}
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 && fields[1] == "<none>" && !found[fields[0]] {
+			found[fields[0]] = true
+			l.Images = append(l.Images, fields[0])
+		}
+	}
+	return l, nil
+}
+
+// RemoveLeftovers removes the leftovers. The containers are removed first because they use the volumes and the images.
+func RemoveLeftovers(runtime string, l Leftovers) error {
+	var client containerClient = dockerClient{}
+	if runtime == config.RuntimePodman {
+		client = podmanClient{}
+	}
+
+	if len(l.Containers) != 0 {
+		args := append([]string{client.command(), "container", "rm", "--force", "--volumes"}, l.Containers...)
+		if _, err := runCommand(args...); err != nil {
+			return fmt.Errorf("failed to remove the containers: %v", err)
+		}
+	}
+	if len(l.Volumes) != 0 {
+		args := append([]string{client.command(), "volume", "rm", "--force"}, l.Volumes...)
+		if _, err := runCommand(args...); err != nil {
+			return fmt.Errorf("failed to remove the volumes: %v", err)
+		}
+	}
+	if len(l.Images) != 0 {
+		args := append([]string{client.command(), "image", "rm"}, l.Images...)
+		if _, err := runCommand(args...); err != nil {
+			return fmt.Errorf("failed to remove the images: %v", err)
+		}
+	}
+	return nil
+}
+
+// isLaunchVolume returns true if the volume is the one of the launcher, which is suffixed by newDocker or not by the older versions
+func isLaunchVolume(v string) bool {
+	for _, prefix := range []string{launchBinVolume, launchHabVolume} {
+		if v == prefix || strings.HasPrefix(v, prefix+"_") {
+			return true
+		}
+	}
+	return false
+}
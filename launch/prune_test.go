@@ -0,0 +1,123 @@
+package launch
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImageRepository(t *testing.T) {
+	testCases := map[string]string{
+		"screwdrivercd/launcher":                         "screwdrivercd/launcher",
+		"screwdrivercd/launcher:stable":                  "screwdrivercd/launcher",
+		"registry.example.com:5000/launcher:v6":          "registry.example.com:5000/launcher",
+		"registry.example.com:5000/launcher":             "registry.example.com:5000/launcher",
+		"screwdrivercd/launcher@sha256:0123456789abcdef": "screwdrivercd/launcher",
+	}
+
+	for image, expected := range testCases {
+		t.Run(image, func(t *testing.T) {
+			assert.Equal(t, expected, imageRepository(image))
+		})
+	}
+}
+
+func TestFindLeftovers(t *testing.T) {
+	defer func() { execCommand = exec.Command }()
+
+	outputs := map[string]string{
+		"container ls": "sdlocal-main-20210102150405\n",
+		"volume ls":    "SD_LAUNCH_BIN\nSD_LAUNCH_HAB_1b9d6bcd\nSD_LAUNCH_BINARY\nmy-volume\n",
+		"image ls":     "0123456789ab stable\nba9876543210 <none>\nba9876543210 <none>\n",
+	}
+	var commands []string
+	execCommand = func(name string, args ...string) *exec.Cmd {
+		commands = append(commands, strings.Join(append([]string{name}, args...), " "))
+		return exec.Command("printf", "%s", outputs[strings.Join(args[:2], " ")])
+	}
+
+	t.Run("success", func(t *testing.T) {
+		commands = nil
+		l, err := FindLeftovers("docker", "screwdrivercd/launcher:stable")
+		assert.Nil(t, err)
+		assert.Equal(t, Leftovers{
+			Containers: []string{"sdlocal-main-20210102150405"},
+			Volumes:    []string{"SD_LAUNCH_BIN", "SD_LAUNCH_HAB_1b9d6bcd"},
+			Images:     []string{"ba9876543210"},
+		}, l)
+		assert.Equal(t, []string{
+			"docker container ls --all --filter label=sdlocal.job --format {{.Names}}",
+			"docker volume ls --quiet",
+			"docker image ls --format {{.ID}} {{.Tag}} screwdrivercd/launcher",
+		}, commands)
+	})
+
+	t.Run("success without images", func(t *testing.T) {
+		commands = nil
+		l, err := FindLeftovers("podman", "")
+		assert.Nil(t, err)
+		assert.Equal(t, []string{}, l.Images)
+		assert.Equal(t, []string{
+			"podman container ls --all --filter label=sdlocal.job --format {{.Names}}",
+			"podman volume ls --quiet",
+		}, commands)
+	})
+
+	t.Run("failure", func(t *testing.T) {
+		execCommand = func(name string, args ...string) *exec.Cmd {
+			if args[0] == "volume" {
+				return exec.Command("sh", "-c", "echo \"$0\" >&2; exit 1", "permission denied")
+			}
+			return exec.Command("true")
+		}
+		_, err := FindLeftovers("docker", "")
+		assert.Equal(t, "failed to list the volumes: exit status 1: permission denied", err.Error())
+	})
+}
+
+func TestRemoveLeftovers(t *testing.T) {
+	defer func() { execCommand = exec.Command }()
+
+	t.Run("success", func(t *testing.T) {
+		var commands []string
+		execCommand = func(name string, args ...string) *exec.Cmd {
+			commands = append(commands, strings.Join(append([]string{name}, args...), " "))
+			return exec.Command("true")
+		}
+
+		err := RemoveLeftovers("docker", Leftovers{
+			Containers: []string{"sdlocal-main-20210102150405", "sdlocal-test-20210102150405"},
+			Volumes:    []string{"SD_LAUNCH_BIN"},
+			Images:     []string{"ba9876543210"},
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, []string{
+			"docker container rm --force --volumes sdlocal-main-20210102150405 sdlocal-test-20210102150405",
+			"docker volume rm --force SD_LAUNCH_BIN",
+			"docker image rm ba9876543210",
+		}, commands)
+	})
+
+	t.Run("success with nothing", func(t *testing.T) {
+		var commands []string
+		execCommand = func(name string, args ...string) *exec.Cmd {
+			commands = append(commands, name)
+			return exec.Command("true")
+		}
+
+		err := RemoveLeftovers("docker", Leftovers{})
+		assert.Nil(t, err)
+		assert.Empty(t, commands)
+	})
+
+	t.Run("failure", func(t *testing.T) {
+		execCommand = func(name string, args ...string) *exec.Cmd {
+			return exec.Command("sh", "-c", "echo \"$0\" >&2; exit 1", "image is being used by running container")
+		}
+
+		err := RemoveLeftovers("podman", Leftovers{Images: []string{"ba9876543210"}})
+		assert.Equal(t, "failed to remove the images: exit status 1: image is being used by running container", err.Error())
+	})
+}
@@ -0,0 +1,116 @@
+package launch
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// PullAlways pulls the launcher image before every build
+	PullAlways = "always"
+	// PullMissing pulls the launcher image only if it is not present locally
+	PullMissing = "missing"
+	// PullNever uses the local launcher image without pulling it
+	PullNever = "never"
+)
+
+// ValidatePullPolicy validates the policy to pull the launcher image
+func ValidatePullPolicy(policy string) error {
+	switch policy {
+	case PullAlways, PullMissing, PullNever:
+		return nil
+	}
+	return fmt.Errorf("invalid pull policy %s: must be one of %s, %s or %s", policy, PullAlways, PullMissing, PullNever)
+}
+
+// imageExists returns true if the image is present locally.
+// The image pinned to a digest is present only if the local image has the digest.
+func (d *docker) imageExists(image string) bool {
+	_, err := d.execDockerCommand("image", "inspect", "--format", "{{.Id}}", image)
+	return err == nil
+}
+
+// pullLauncherImage pulls the launcher image by the pull policy.
+// The local image is used without contacting the registry unless the policy is always, which is the default of docker.
+// The image is loaded from the launcher archive instead of the registry if it is specified, which works offline as well.
+func (d *docker) pullLauncherImage(image string) error {
+	if d.launcherArchive != "" {
+		if d.pullPolicy != "" && d.pullPolicy != PullAlways && d.imageExists(image) {
+			logrus.Debugf("Using the local image %s without loading %s", image, d.launcherArchive)
+			return nil
+		}
+		return d.loadLauncherImage(image)
+	}
+	if d.offline {
+		return d.findLocalImage(image)
+	}
+	if d.pullPolicy == "" || d.pullPolicy == PullAlways {
+		return d.pullImage(image)
+	}
+
+	if d.imageExists(image) {
+		logrus.Debugf("Using the local image %s without pulling it", image)
+		return nil
+	}
+	if d.pullPolicy == PullNever {
+		return fmt.Errorf("image %s is not present locally: pull it or run with `--pull %s`", image, PullMissing)
+	}
+	return d.pullImage(image)
+}
+
+// loadLauncherImage loads the launcher image from the archive made by `docker save`,
+// and checks that the archive has the image of the config because `docker load` keeps the tags in it.
+func (d *docker) loadLauncherImage(image string) error {
+	logrus.Infof("Loading launcher image from %s...", d.launcherArchive)
+	out, err := d.execDockerCommand("image", "load", "--input", d.launcherArchive)
+	if err != nil {
+		return fmt.Errorf("failed to load %s: %v", d.launcherArchive, err)
+	}
+
+	if !d.imageExists(image) {
+		loaded := loadedImages(out)
+		if len(loaded) == 0 {
+			return fmt.Errorf("%s does not have %s", d.launcherArchive, image)
+		}
+		return fmt.Errorf("%s does not have %s but %s", d.launcherArchive, image, strings.Join(loaded, ", "))
+	}
+	return nil
+}
+
+// loadedImages returns the images printed by `docker load` like "Loaded image: screwdrivercd/launcher:stable",
+// or "Loaded image(s): docker.io/screwdrivercd/launcher:stable" by podman
+func loadedImages(out string) []string {
+	var images []string
+	for _, line := range strings.Split(out, "\n") {
+		i := strings.Index(line, ": ")
+		if i < 0 || !strings.HasPrefix(line, "Loaded image") {
+			continue
+		}
+		for _, image := range strings.Split(line[i+2:], ",") {
+			if image = strings.TrimSpace(image); image != "" {
+				images = append(images, image)
+			}
+		}
+	}
+	return images
+}
+
+// findLocalImage returns an error if the image is not present locally, which can't be pulled in the offline mode
+func (d *docker) findLocalImage(image string) error {
+	if !d.imageExists(image) {
+		return fmt.Errorf("image %s is not present locally, which can't be pulled offline", image)
+	}
+	logrus.Debugf("Using the local image %s offline", image)
+	return nil
+}
+
+// pullBuildImage pulls the image of the build unless it is offline
+func (d *docker) pullBuildImage(image string) error {
+	if d.offline {
+		return d.findLocalImage(image)
+	}
+	logrus.Infof("Pulling docker image from %s...", image)
+	return d.pullImage(image)
+}
@@ -0,0 +1,68 @@
+package launch
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mitchellh/go-homedir"
+)
+
+// dockerHubRegistry is the registry of the images without a registry host
+const dockerHubRegistry = "docker.io"
+
+// registryHost returns the host of the registry the image is pulled from
+func registryHost(image string) string {
+	i := strings.Index(image, "/")
+	if i < 0 {
+		return dockerHubRegistry
+	}
+
+	host := image[:i]
+	if strings.ContainsAny(host, ".:") || host == "localhost" {
+		return host
+	}
+	return dockerHubRegistry
+}
+
+// isUnauthorized returns true if the pull failed because of the missing or invalid credentials
+func isUnauthorized(stderr string) bool {
+	stderr = strings.ToLower(stderr)
+	for _, msg := range []string{"unauthorized", "authentication required", "access denied", "denied: "} {
+		if strings.Contains(stderr, msg) {
+			return true
+		}
+	}
+	return false
+}
+
+// isTransient returns true if the pull failed because of the network or the registry, which may succeed by retrying it.
+// The missing images and the credentials are not transient.
+func isTransient(stderr string) bool {
+	stderr = strings.ToLower(stderr)
+	for _, msg := range []string{"timeout", "connection reset", "connection refused", "unexpected eof", "toomanyrequests", "too many requests",
+		"500 internal server error", "502 bad gateway", "503 service unavailable", "504 gateway timeout"} {
+		if strings.Contains(stderr, msg) {
+			return true
+		}
+	}
+	return false
+}
+
+// defaultRegistryAuth returns the directory of the Docker config.json of the user,
+// which is not read by the container CLI run with sudo. It returns empty if there is no config.json.
+func defaultRegistryAuth() string {
+	dir := os.Getenv("DOCKER_CONFIG")
+	if dir == "" {
+		home, err := homedir.Dir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, ".docker")
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "config.json")); err != nil {
+		return ""
+	}
+	return dir
+}
@@ -0,0 +1,105 @@
+package launch
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/screwdriver-cd/sd-local/screwdriver"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// cpuAnnotation is the annotation of the number of cpus of the build
+	cpuAnnotation = "screwdriver.cd/cpu"
+	// ramAnnotation is the annotation of the memory of the build in GB
+	ramAnnotation = "screwdriver.cd/ram"
+)
+
+// The sizes of the resources of Screwdriver.cd
+var (
+	cpuSizes = map[string]float64{"MICRO": 0.5, "LOW": 2, "HIGH": 6, "TURBO": 12}
+	ramSizes = map[string]float64{"MICRO": 1, "LOW": 2, "HIGH": 12, "TURBO": 16}
+)
+
+// parseResource parses the value of the annotation which is a size like HIGH or a positive number
+func parseResource(value string, sizes map[string]float64) (float64, error) {
+	if v, ok := sizes[strings.ToUpper(value)]; ok {
+		return v, nil
+	}
+	v, err := strconv.ParseFloat(value, 64)
+	if err != nil || v <= 0 {
+		return 0, fmt.Errorf("must be one of MICRO, LOW, HIGH, TURBO or a positive number")
+	}
+	return v, nil
+}
+
+// annotatedResource returns the maximum of the resource annotated to the job and the steps to run.
+// The steps run in the same build container whose limits can't be changed while it runs, so the steps share the maximum.
+func annotatedResource(annotations map[string]interface{}, steps []screwdriver.Step, key string, sizes map[string]float64) float64 {
+	keys := []string{key}
+	for _, s := range steps {
+		keys = append(keys, key+"/"+s.Name)
+	}
+
+	max := 0.0
+	for _, k := range keys {
+		value, ok := annotations[k]
+		if !ok {
+			continue
+		}
+		v, err := parseResource(fmt.Sprint(value), sizes)
+		if err != nil {
+			logrus.Warnf("ignored the annotation %s: %v", k, err)
+			continue
+		}
+		if v > max {
+			max = v
+		}
+	}
+	return max
+}
+
+// parseMemory parses the memory limit in the docker format like 512m into bytes
+func parseMemory(memory string) (int64, error) {
+	units := map[string]int64{"b": 1, "k": 1 << 10, "m": 1 << 20, "g": 1 << 30}
+	m := strings.TrimSuffix(strings.ToLower(memory), "b")
+	unit := int64(1)
+	if len(m) > 0 {
+		if u, ok := units[m[len(m)-1:]]; ok {
+			unit = u
+			m = m[:len(m)-1]
+		}
+	}
+	v, err := strconv.ParseInt(m, 10, 64)
+	if err != nil || v <= 0 {
+		return 0, fmt.Errorf("invalid memory %s", memory)
+	}
+	return v * unit, nil
+}
+
+// buildResources returns the cpu and memory limits of the build container.
+// The memory of the annotations is capped by the memory of the option, which is used if there is no annotation.
+func buildResources(option Option, steps []screwdriver.Step) (cpu string, memory string) {
+	if v := annotatedResource(option.Job.Annotations, steps, cpuAnnotation, cpuSizes); v > 0 {
+		cpu = strconv.FormatFloat(v, 'f', -1, 64)
+	}
+
+	memory = option.Memory
+	ram := annotatedResource(option.Job.Annotations, steps, ramAnnotation, ramSizes)
+	if ram == 0 {
+		return cpu, memory
+	}
+
+	annotated := fmt.Sprintf("%dm", int64(ram*1024))
+	if option.Memory == "" {
+		return cpu, annotated
+	}
+
+	limit, err := parseMemory(option.Memory)
+	if err != nil || int64(ram*(1<<30)) <= limit {
+		return cpu, annotated
+	}
+	logrus.Warnf("the memory of the annotations %s exceeds --memory, which limits it to %s", annotated, option.Memory)
+	return cpu, memory
+}
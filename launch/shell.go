@@ -0,0 +1,46 @@
+package launch
+
+import (
+	"fmt"
+	"path"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// shellAnnotation is the annotation of the shell the steps run in
+	shellAnnotation = "screwdriver.cd/shell"
+	// ShellEnv is the environment variable of the launcher which has the shell the steps run in, /bin/sh if it is not set
+	ShellEnv = "USER_SHELL_BIN"
+)
+
+// ValidateShell returns an error if the shell is not an absolute path in the build image
+func ValidateShell(shell string) error {
+	if !path.IsAbs(shell) {
+		return fmt.Errorf("invalid shell %s: must be an absolute path in the build image like /bin/bash", shell)
+	}
+	return nil
+}
+
+// buildShell returns the shell of the steps, which is the option if it is set, otherwise the annotation of the job.
+// It is empty to leave the shell to USER_SHELL_BIN of the environment of the job and --env, or /bin/sh of the launcher.
+func buildShell(option Option) string {
+	if option.Shell != "" {
+		return option.Shell
+	}
+	value, ok := option.Job.Annotations[shellAnnotation]
+	if !ok {
+		return ""
+	}
+	shell := fmt.Sprint(value)
+	if err := ValidateShell(shell); err != nil {
+		logrus.Warnf("ignored the annotation %s: %v", shellAnnotation, err)
+		return ""
+	}
+	return shell
+}
+
+// shellNotFoundError returns the error of the shell which is not found in the image, which fails the build before the steps run
+func shellNotFoundError(shell, image string, err error) error {
+	return fmt.Errorf("shell %s is not found in the image %s, install it in the image or change the shell: %v", shell, image, err)
+}
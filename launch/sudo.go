@@ -0,0 +1,27 @@
+package launch
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// isPermissionDenied returns true if the container CLI failed because the user can't access the socket of the daemon
+func isPermissionDenied(stderr string) bool {
+	stderr = strings.ToLower(stderr)
+	return strings.Contains(stderr, "permission denied") && (strings.Contains(stderr, "daemon socket") || strings.Contains(stderr, ".sock"))
+}
+
+// chownArtifacts changes the owner of the artifacts written by the build run with sudo back to the user of sd-local,
+// otherwise the user can't remove them
+func chownArtifacts(path string) error {
+	owner := fmt.Sprintf("%d:%d", os.Getuid(), os.Getgid())
+	logrus.Debugf("$ sudo chown -R %s %s", owner, path)
+	out, err := execCommand("sudo", "chown", "-R", owner, path).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%v: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
@@ -0,0 +1,216 @@
+package launch
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/screwdriver-cd/sd-local/config"
+)
+
+// dockerHubEndpoint is the endpoint of the registry API of Docker Hub, whose images are named without it
+const dockerHubEndpoint = "https://registry-1.docker.io"
+
+// maxTagSuggestions is the number of the closest tags suggested for the launcher version which is not a tag
+const maxTagSuggestions = 3
+
+var (
+	// registryEndpoint returns the base URL of the registry API of the host
+	registryEndpoint = func(host string) string {
+		if host == dockerHubRegistry {
+			return dockerHubEndpoint
+		}
+		return "https://" + host
+	}
+	challengeParamPattern = regexp.MustCompile(`(\w+)="([^"]*)"`)
+	nextLinkPattern       = regexp.MustCompile(`<([^>]+)>;\s*rel="next"`)
+)
+
+// imagePath returns the path of the repository of the image in its registry, which is under library for the official images of Docker Hub
+func imagePath(image string) string {
+	repository := imageRepository(image)
+	host := registryHost(repository)
+	if host != dockerHubRegistry || strings.HasPrefix(repository, dockerHubRegistry+"/") {
+		repository = repository[strings.Index(repository, "/")+1:]
+	}
+	if host == dockerHubRegistry && !strings.Contains(repository, "/") {
+		repository = "library/" + repository
+	}
+	return repository
+}
+
+// LauncherTags returns the tags of the launcher image by the registry API.
+// The registry is accessed anonymously, so the tags of the private images are not listed.
+func LauncherTags(client *http.Client, image string) ([]string, error) {
+	base := registryEndpoint(registryHost(imageRepository(image)))
+	next := fmt.Sprintf("%s/v2/%s/tags/list", base, imagePath(image))
+
+	token := ""
+	var tags []string
+	for next != "" {
+		res, err := getRegistry(client, next, token)
+		if err != nil {
+			return nil, err
+		}
+		if res.StatusCode == http.StatusUnauthorized && token == "" {
+			res.Body.Close()
+			token, err = registryToken(client, res.Header.Get("WWW-Authenticate"))
+			if err != nil {
+				return nil, fmt.Errorf("failed to list the tags of %s: %v", image, err)
+			}
+			continue
+		}
+
+		var list struct {
+			Tags []string `json:"tags"`
+		}
+		err = decodeRegistryResponse(res, &list)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list the tags of %s: %v", image, err)
+		}
+		tags = append(tags, list.Tags...)
+
+		next = ""
+		if m := nextLinkPattern.FindStringSubmatch(res.Header.Get("Link")); m != nil {
+			next = base + m[1]
+		}
+	}
+	return tags, nil
+}
+
+func getRegistry(client *http.Client, url, token string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return client.Do(req)
+}
+
+func decodeRegistryResponse(res *http.Response, v interface{}) error {
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("registry responded with %s", res.Status)
+	}
+	return json.NewDecoder(res.Body).Decode(v)
+}
+
+// registryToken gets the anonymous token by the Bearer challenge of the registry
+func registryToken(client *http.Client, challenge string) (string, error) {
+	if !strings.HasPrefix(strings.ToLower(challenge), "bearer ") {
+		return "", fmt.Errorf("unsupported authentication %q", challenge)
+	}
+
+	params := make(map[string]string)
+	for _, m := range challengeParamPattern.FindAllStringSubmatch(challenge, -1) {
+		params[strings.ToLower(m[1])] = m[2]
+	}
+	realm, err := url.Parse(params["realm"])
+	if err != nil || params["realm"] == "" {
+		return "", fmt.Errorf("invalid realm in %q", challenge)
+	}
+	query := realm.Query()
+	for _, k := range []string{"service", "scope"} {
+		if params[k] != "" {
+			query.Set(k, params[k])
+		}
+	}
+	realm.RawQuery = query.Encode()
+
+	res, err := getRegistry(client, realm.String(), "")
+	if err != nil {
+		return "", err
+	}
+	var token struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := decodeRegistryResponse(res, &token); err != nil {
+		return "", err
+	}
+	if token.Token == "" {
+		return token.AccessToken, nil
+	}
+	return token.Token, nil
+}
+
+// CheckLauncherVersion returns an error with the closest tags if the version of the launcher is not one of the tags.
+// latest and stable are always accepted, and the version is not checked if the image is pinned to a digest.
+func CheckLauncherVersion(launcher config.Launcher, tags []string) error {
+	if launcher.Version == "latest" || launcher.Version == "stable" || strings.Contains(launcher.Image, "@") {
+		return nil
+	}
+	for _, tag := range tags {
+		if tag == launcher.Version {
+			return nil
+		}
+	}
+
+	err := fmt.Errorf("launcher version %s is not a tag of %s", launcher.Version, launcher.Image)
+	if closest := closestTags(launcher.Version, tags); len(closest) != 0 {
+		err = fmt.Errorf("%v, did you mean %s?", err, strings.Join(closest, ", "))
+	}
+	return err
+}
+
+// closestTags returns the tags within the edit distance of a third of the length of the version in the order of the distance
+func closestTags(version string, tags []string) []string {
+	maxDistance := len(version)/3 + 1
+	distances := make(map[string]int)
+	var closest []string
+	for _, tag := range tags {
+		d := editDistance(version, tag)
+		if d <= maxDistance {
+			distances[tag] = d
+			closest = append(closest, tag)
+		}
+	}
+
+	sort.Slice(closest, func(i, j int) bool {
+		if distances[closest[i]] != distances[closest[j]] {
+			return distances[closest[i]] < distances[closest[j]]
+		}
+		return closest[i] < closest[j]
+	})
+	if len(closest) > maxTagSuggestions {
+		closest = closest[:maxTagSuggestions]
+	}
+	return closest
+}
+
+// editDistance returns the Levenshtein distance between a and b
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min3(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
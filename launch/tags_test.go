@@ -0,0 +1,114 @@
+package launch
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/screwdriver-cd/sd-local/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImagePath(t *testing.T) {
+	testCases := map[string]string{
+		"screwdrivercd/launcher":                      "screwdrivercd/launcher",
+		"screwdrivercd/launcher:stable":               "screwdrivercd/launcher",
+		"alpine":                                      "library/alpine",
+		"docker.io/screwdrivercd/launcher":            "screwdrivercd/launcher",
+		"registry.example.com:5000/sd/launcher":       "sd/launcher",
+		"registry.example.com/sd/launcher@sha256:abc": "sd/launcher",
+	}
+
+	for image, expected := range testCases {
+		t.Run(image, func(t *testing.T) {
+			assert.Equal(t, expected, imagePath(image))
+		})
+	}
+}
+
+func TestLauncherTags(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/token":
+			assert.Equal(t, "registry.example.com", r.URL.Query().Get("service"))
+			assert.Equal(t, "repository:sd/launcher:pull", r.URL.Query().Get("scope"))
+			fmt.Fprint(w, `{"token": "anonymous"}`)
+		case "/v2/sd/launcher/tags/list":
+			if r.Header.Get("Authorization") != "Bearer anonymous" {
+				w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="registry.example.com",scope="repository:sd/launcher:pull"`, server.URL))
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			if r.URL.Query().Get("last") == "" {
+				w.Header().Set("Link", `</v2/sd/launcher/tags/list?last=v6.0.1&n=2>; rel="next"`)
+				fmt.Fprint(w, `{"name": "sd/launcher", "tags": ["v6.0.0", "v6.0.1"]}`)
+				return
+			}
+			fmt.Fprint(w, `{"name": "sd/launcher", "tags": ["stable"]}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	defer func(f func(string) string) {
+		registryEndpoint = f
+	}(registryEndpoint)
+	registryEndpoint = func(host string) string {
+		return server.URL
+	}
+
+	t.Run("success with the token and the pages", func(t *testing.T) {
+		tags, err := LauncherTags(server.Client(), "registry.example.com/sd/launcher")
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"v6.0.0", "v6.0.1", "stable"}, tags)
+	})
+
+	t.Run("failure by the missing repository", func(t *testing.T) {
+		_, err := LauncherTags(server.Client(), "registry.example.com/sd/missing")
+		assert.Equal(t, "failed to list the tags of registry.example.com/sd/missing: registry responded with 404 Not Found", err.Error())
+	})
+}
+
+func TestCheckLauncherVersion(t *testing.T) {
+	tags := []string{"v6.0.0", "v6.0.1", "v6.1.0", "v5.0.0", "stable", "latest"}
+
+	testCases := map[string]struct {
+		launcher  config.Launcher
+		expectErr string
+	}{
+		"success by the tag": {
+			launcher: config.Launcher{Image: "screwdrivercd/launcher", Version: "v6.0.1"},
+		},
+		"success by stable": {
+			launcher: config.Launcher{Image: "screwdrivercd/launcher", Version: "stable"},
+		},
+		"success by latest": {
+			launcher: config.Launcher{Image: "screwdrivercd/launcher", Version: "latest"},
+		},
+		"success by the image pinned to a digest": {
+			launcher: config.Launcher{Image: "screwdrivercd/launcher@sha256:abc", Version: "v9"},
+		},
+		"failure with the closest tags": {
+			launcher:  config.Launcher{Image: "screwdrivercd/launcher", Version: "v6.0.2"},
+			expectErr: "launcher version v6.0.2 is not a tag of screwdrivercd/launcher, did you mean v6.0.0, v6.0.1, v5.0.0?",
+		},
+		"failure without the close tags": {
+			launcher:  config.Launcher{Image: "screwdrivercd/launcher", Version: "nightly-build"},
+			expectErr: "launcher version nightly-build is not a tag of screwdrivercd/launcher",
+		},
+	}
+
+	for name, tt := range testCases {
+		t.Run(name, func(t *testing.T) {
+			err := CheckLauncherVersion(tt.launcher, tags)
+			if tt.expectErr == "" {
+				assert.Nil(t, err)
+			} else {
+				assert.Equal(t, tt.expectErr, err.Error())
+			}
+		})
+	}
+}
@@ -0,0 +1,33 @@
+package launch
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"runtime"
+
+	"github.com/screwdriver-cd/sd-local/config"
+)
+
+var userPattern = regexp.MustCompile(`^[A-Za-z0-9_.-]+(:[A-Za-z0-9_.-]+)?$`)
+
+// goos is the OS of the host, which is replaced in the tests
+var goos = runtime.GOOS
+
+// ValidateUser validates the user of the build container in the form of uid[:gid], where the names are also allowed
+func ValidateUser(user string) error {
+	if !userPattern.MatchString(user) {
+		return fmt.Errorf("invalid user %s: must be in the form of uid[:gid] like 1000:1000", user)
+	}
+	return nil
+}
+
+// DefaultUser returns the user of the build container to own the artifacts written into the bind mounts,
+// which is the user of sd-local with Docker on Linux. It is empty to run as the user of the image otherwise,
+// because Docker Desktop and rootless Podman map the owner of the files to the user already, and k8s does not mount the artifacts.
+func DefaultUser(runtimeName string) string {
+	if goos != "linux" || (runtimeName != "" && runtimeName != config.RuntimeDocker) || os.Getuid() == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d:%d", os.Getuid(), os.Getgid())
+}
@@ -0,0 +1,42 @@
+package launch
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/screwdriver-cd/sd-local/config"
+)
+
+// RuntimeVersion returns the version of the command line tool of the runtime, docker, podman or kubectl for k8s.
+// The default runtime is docker.
+func RuntimeVersion(runtime string) (string, error) {
+	var args []string
+	switch runtime {
+	case config.RuntimeKubernetes:
+		args = []string{"kubectl", "version", "--client", "-o", "json"}
+	case config.RuntimePodman:
+		args = []string{"podman", "version", "--format", "{{.Client.Version}}"}
+	default:
+		args = []string{"docker", "version", "--format", "{{.Client.Version}}"}
+	}
+
+	out, err := runCommand(args...)
+	if err != nil {
+		return "", fmt.Errorf("failed to get the version of %s: %v", args[0], err)
+	}
+
+	if runtime != config.RuntimeKubernetes {
+		return strings.TrimSpace(out), nil
+	}
+
+	var version struct {
+		ClientVersion struct {
+			GitVersion string `json:"gitVersion"`
+		} `json:"clientVersion"`
+	}
+	if err := json.Unmarshal([]byte(out), &version); err != nil {
+		return "", fmt.Errorf("failed to parse the version of kubectl: %v", err)
+	}
+	return version.ClientVersion.GitVersion, nil
+}
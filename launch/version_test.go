@@ -0,0 +1,77 @@
+package launch
+
+import (
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRuntimeVersion(t *testing.T) {
+	cases := []struct {
+		name          string
+		runtime       string
+		output        string
+		fail          bool
+		expectCommand []string
+		expect        string
+		expectErr     string
+	}{
+		{
+			name:          "docker",
+			output:        "24.0.5",
+			expectCommand: []string{"docker", "version", "--format", "{{.Client.Version}}"},
+			expect:        "24.0.5",
+		},
+		{
+			name:          "podman",
+			runtime:       "podman",
+			output:        "4.9.3",
+			expectCommand: []string{"podman", "version", "--format", "{{.Client.Version}}"},
+			expect:        "4.9.3",
+		},
+		{
+			name:          "k8s",
+			runtime:       "k8s",
+			output:        `{"clientVersion": {"gitVersion": "v1.29.1"}}`,
+			expectCommand: []string{"kubectl", "version", "--client", "-o", "json"},
+			expect:        "v1.29.1",
+		},
+		{
+			name:          "failure by command",
+			fail:          true,
+			expectCommand: []string{"docker", "version", "--format", "{{.Client.Version}}"},
+			expectErr:     "failed to get the version of docker: exit status 1",
+		},
+		{
+			name:          "failure by invalid json",
+			runtime:       "k8s",
+			output:        "invalid",
+			expectCommand: []string{"kubectl", "version", "--client", "-o", "json"},
+			expectErr:     "failed to parse the version of kubectl",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			defer func() { execCommand = exec.Command }()
+			var command []string
+			execCommand = func(name string, args ...string) *exec.Cmd {
+				command = append([]string{name}, args...)
+				if c.fail {
+					return exec.Command("false")
+				}
+				return exec.Command("echo", c.output)
+			}
+
+			version, err := RuntimeVersion(c.runtime)
+			assert.Equal(t, c.expectCommand, command)
+			if c.expectErr != "" {
+				assert.Contains(t, err.Error(), c.expectErr)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, c.expect, version)
+		})
+	}
+}
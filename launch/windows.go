@@ -0,0 +1,71 @@
+package launch
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/screwdriver-cd/sd-local/config"
+)
+
+// windowsDrivePattern matches the Windows paths with the drive letter like C:\Users or C:/Users
+var windowsDrivePattern = regexp.MustCompile(`^[A-Za-z]:[\\/]`)
+
+// CheckHost returns an error if the runtime can't run the builds on the host.
+// kubectl cp on Windows takes the drive letter of the source path for the name of the pod.
+func CheckHost(runtimeName string) error {
+	if goos == "windows" && runtimeName == config.RuntimeKubernetes {
+		return fmt.Errorf("runtime %s does not support Windows hosts because kubectl cp can't copy the paths with the drive letter, use docker or podman", runtimeName)
+	}
+	return nil
+}
+
+// CheckInteractive returns an error if the interactive mode is not supported on the host, which needs a pty Windows doesn't have
+func CheckInteractive() error {
+	if goos == "windows" {
+		return errors.New("interactive mode does not support Windows hosts because it needs a pty")
+	}
+	return nil
+}
+
+// ValidateHostPath returns an error if the path of the host can't be mounted into the build container.
+// The UNC paths like \\server\share on Windows are not shared with the VM of Docker Desktop.
+func ValidateHostPath(p string) error {
+	if goos == "windows" && (strings.HasPrefix(p, `\\`) || strings.HasPrefix(p, "//")) {
+		return fmt.Errorf("unsupported path %s: the network paths on Windows can't be mounted into the build container, map it to a drive or copy it to a local drive", p)
+	}
+	return nil
+}
+
+// hostPath returns the path of the host in the volume option of the container CLI.
+// The Windows paths like C:\Users\me are written with the slashes like C:/Users/me, which Docker Desktop accepts.
+func hostPath(p string) string {
+	if goos != "windows" || !windowsDrivePattern.MatchString(p) {
+		return p
+	}
+	return strings.Replace(p, `\`, "/", -1)
+}
+
+// hostDir returns the directory of the host in the volume option of the container CLI with the trailing slash,
+// which is left out on Windows because Docker Desktop does not accept it after the drive paths except the drive roots like C:/.
+func hostDir(p string) string {
+	if goos == "windows" && windowsDrivePattern.MatchString(p) {
+		dir := strings.TrimRight(hostPath(p), "/")
+		if len(dir) == 2 {
+			return dir + "/"
+		}
+		return dir
+	}
+	return p + "/"
+}
+
+// splitMountSpec splits the mount by the colons, keeping the drive letter of the host path on Windows like C:\Users\me\.m2:/root/.m2
+func splitMountSpec(spec string) []string {
+	if goos != "windows" || !windowsDrivePattern.MatchString(spec) {
+		return strings.Split(spec, ":")
+	}
+	parts := strings.Split(spec[2:], ":")
+	parts[0] = spec[:2] + parts[0]
+	return parts
+}
@@ -0,0 +1,120 @@
+package launch
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckHost(t *testing.T) {
+	defer func() {
+		goos = runtime.GOOS
+	}()
+
+	goos = "linux"
+	assert.Nil(t, CheckHost("k8s"))
+
+	goos = "windows"
+	assert.Nil(t, CheckHost("docker"))
+	assert.Nil(t, CheckHost("podman"))
+	assert.Equal(t, "runtime k8s does not support Windows hosts because kubectl cp can't copy the paths with the drive letter, use docker or podman", CheckHost("k8s").Error())
+}
+
+func TestCheckInteractive(t *testing.T) {
+	defer func() {
+		goos = runtime.GOOS
+	}()
+
+	goos = "linux"
+	assert.Nil(t, CheckInteractive())
+
+	goos = "windows"
+	assert.Equal(t, "interactive mode does not support Windows hosts because it needs a pty", CheckInteractive().Error())
+}
+
+func TestValidateHostPath(t *testing.T) {
+	defer func() {
+		goos = runtime.GOOS
+	}()
+
+	goos = "linux"
+	assert.Nil(t, ValidateHostPath("//server/share"))
+
+	goos = "windows"
+	assert.Nil(t, ValidateHostPath(`C:\Users\user\src`))
+	assert.Equal(t, `unsupported path \\server\share\src: the network paths on Windows can't be mounted into the build container, map it to a drive or copy it to a local drive`, ValidateHostPath(`\\server\share\src`).Error())
+}
+
+func TestHostPath(t *testing.T) {
+	defer func() {
+		goos = runtime.GOOS
+	}()
+
+	testCases := map[string]struct {
+		goos       string
+		path       string
+		expectPath string
+		expectDir  string
+	}{
+		"linux": {
+			goos:       "linux",
+			path:       "/home/user/src",
+			expectPath: "/home/user/src",
+			expectDir:  "/home/user/src/",
+		},
+		"windows": {
+			goos:       "windows",
+			path:       `C:\Users\user\src\`,
+			expectPath: "C:/Users/user/src/",
+			expectDir:  "C:/Users/user/src",
+		},
+		"windows with slashes": {
+			goos:       "windows",
+			path:       "d:/src",
+			expectPath: "d:/src",
+			expectDir:  "d:/src",
+		},
+		"windows drive root": {
+			goos:       "windows",
+			path:       `C:\`,
+			expectPath: "C:/",
+			expectDir:  "C:/",
+		},
+		"windows drive root with slash": {
+			goos:       "windows",
+			path:       "C:/",
+			expectPath: "C:/",
+			expectDir:  "C:/",
+		},
+		"windows without drive": {
+			goos:       "windows",
+			path:       "/src",
+			expectPath: "/src",
+			expectDir:  "/src/",
+		},
+	}
+
+	for name, tt := range testCases {
+		t.Run(name, func(t *testing.T) {
+			goos = tt.goos
+			assert.Equal(t, tt.expectPath, hostPath(tt.path))
+			assert.Equal(t, tt.expectDir, hostDir(tt.path))
+		})
+	}
+}
+
+func TestSplitMountSpec(t *testing.T) {
+	defer func() {
+		goos = runtime.GOOS
+	}()
+
+	goos = "linux"
+	assert.Equal(t, []string{"~/.m2", "/root/.m2", "ro"}, splitMountSpec("~/.m2:/root/.m2:ro"))
+	assert.Equal(t, []string{"C", `\m2`, "/root/.m2"}, splitMountSpec(`C:\m2:/root/.m2`))
+
+	goos = "windows"
+	assert.Equal(t, []string{`C:\Users\user\.m2`, "/root/.m2", "ro"}, splitMountSpec(`C:\Users\user\.m2:/root/.m2:ro`))
+	assert.Equal(t, []string{"D:/cache", "/cache"}, splitMountSpec("D:/cache:/cache"))
+	assert.Equal(t, []string{"~/.m2", "/root/.m2"}, splitMountSpec("~/.m2:/root/.m2"))
+}
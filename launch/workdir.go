@@ -0,0 +1,53 @@
+package launch
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/screwdriver-cd/sd-local/screwdriver"
+	"github.com/sirupsen/logrus"
+)
+
+// workdirAnnotation is the annotation of the working directory of the steps in the build container
+const workdirAnnotation = "screwdriver.cd/workdir"
+
+// ValidateWorkdir returns an error if the working directory is not an absolute path in the build container
+func ValidateWorkdir(dir string) error {
+	if !path.IsAbs(dir) {
+		return fmt.Errorf("invalid workdir %s: must be an absolute path in the build container", dir)
+	}
+	return nil
+}
+
+// buildWorkdir returns the working directory of the steps, which is the option if it is set, otherwise the annotation of the job
+func buildWorkdir(option Option) string {
+	if option.Workdir != "" {
+		return option.Workdir
+	}
+	value, ok := option.Job.Annotations[workdirAnnotation]
+	if !ok {
+		return ""
+	}
+	dir := fmt.Sprint(value)
+	if err := ValidateWorkdir(dir); err != nil {
+		logrus.Warnf("ignored the annotation %s: %v", workdirAnnotation, err)
+		return ""
+	}
+	return dir
+}
+
+// withWorkdir returns the steps whose commands run in the working directory, which is created if it does not exist.
+// The directory is changed in every step because the launcher runs the steps in the same shell in which the steps can change it.
+func withWorkdir(steps []screwdriver.Step, dir string) []screwdriver.Step {
+	if dir == "" {
+		return steps
+	}
+	quoted := stepEnvReplacer.Replace(dir)
+	wrapped := make([]screwdriver.Step, 0, len(steps))
+	for _, s := range steps {
+		s.Command = fmt.Sprintf("mkdir -p \"%s\" && cd \"%s\" && {\n%s\n}", quoted, quoted, strings.TrimRight(s.Command, "\n"))
+		wrapped = append(wrapped, s)
+	}
+	return wrapped
+}
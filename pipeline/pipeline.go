@@ -0,0 +1,159 @@
+package pipeline
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/screwdriver-cd/sd-local/screwdriver"
+)
+
+// Graph is a dependency graph of the jobs to run
+type Graph struct {
+	names    []string
+	requires map[string][]string
+}
+
+// requiredJob returns the job name of the `requires` item, or empty for triggers like ~commit and external jobs
+func requiredJob(require string) string {
+	name := strings.TrimPrefix(require, "~")
+	if strings.ContainsAny(name, ":@") {
+		return ""
+	}
+	return name
+}
+
+// New creates the dependency graph of the jobs named `names`.
+// Only the dependencies between the named jobs are considered.
+func New(jobs map[string]screwdriver.Job, names []string) (*Graph, error) {
+	g := &Graph{
+		names:    make([]string, 0, len(names)),
+		requires: make(map[string][]string, len(names)),
+	}
+
+	for _, name := range names {
+		if _, ok := jobs[name]; !ok {
+			return nil, fmt.Errorf("not found '%s' in parsed screwdriver.yaml", name)
+		}
+		if _, ok := g.requires[name]; ok {
+			continue
+		}
+		g.names = append(g.names, name)
+		g.requires[name] = []string{}
+	}
+
+	// the job requiring the job with the matrix requires all the combinations of the matrix among the named jobs
+	combinations := make(map[string][]string)
+	for _, name := range g.names {
+		if base := screwdriver.BaseJobName(name); base != name {
+			combinations[base] = append(combinations[base], name)
+		}
+	}
+
+	for _, name := range g.names {
+		for _, require := range jobs[name].Requires {
+			required := requiredJob(require)
+			if _, ok := g.requires[required]; ok && required != name {
+				g.requires[name] = append(g.requires[name], required)
+			}
+			for _, combination := range combinations[required] {
+				if combination != name {
+					g.requires[name] = append(g.requires[name], combination)
+				}
+			}
+		}
+	}
+
+	for _, name := range g.names {
+		if path := g.cycle(name, []string{}); path != nil {
+			return nil, fmt.Errorf("jobs have a circular dependency: %s", strings.Join(path, " -> "))
+		}
+	}
+
+	return g, nil
+}
+
+// cycle returns the path of the circular dependency through `name`, or nil if there is none
+func (g *Graph) cycle(name string, path []string) []string {
+	for i, n := range path {
+		if n == name {
+			return append(path[i:], name)
+		}
+	}
+
+	path = append(path, name)
+	for _, required := range g.requires[name] {
+		if c := g.cycle(required, path); c != nil {
+			return c
+		}
+	}
+	return nil
+}
+
+// Names returns the names of the jobs in the given order
+func (g *Graph) Names() []string {
+	return g.names
+}
+
+// Run runs `run` for every job in the graph, up to maxParallel jobs at once.
+// A job starts after all the jobs it requires succeeded and is skipped if any of them failed.
+func (g *Graph) Run(maxParallel int, run func(name string) error) error {
+	if maxParallel < 1 {
+		return fmt.Errorf("max parallel must be a positive integer: %d", maxParallel)
+	}
+
+	done := make(map[string]chan struct{}, len(g.names))
+	for _, name := range g.names {
+		done[name] = make(chan struct{})
+	}
+
+	var mutex sync.Mutex
+	errs := make(map[string]error, len(g.names))
+	slots := make(chan struct{}, maxParallel)
+	var wg sync.WaitGroup
+
+	for _, name := range g.names {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			defer close(done[name])
+
+			for _, required := range g.requires[name] {
+				<-done[required]
+
+				mutex.Lock()
+				failed := errs[required] != nil
+				if failed {
+					errs[name] = fmt.Errorf("skipped because `%s` failed", required)
+				}
+				mutex.Unlock()
+
+				if failed {
+					return
+				}
+			}
+
+			slots <- struct{}{}
+			err := run(name)
+			<-slots
+
+			if err != nil {
+				mutex.Lock()
+				errs[name] = err
+				mutex.Unlock()
+			}
+		}(name)
+	}
+	wg.Wait()
+
+	failures := make([]string, 0, len(errs))
+	for _, name := range g.names {
+		if err, ok := errs[name]; ok {
+			failures = append(failures, fmt.Sprintf("%s: %v", name, err))
+		}
+	}
+	if len(failures) != 0 {
+		return fmt.Errorf("failed to run jobs:\n  * %s", strings.Join(failures, "\n  * "))
+	}
+	return nil
+}
@@ -0,0 +1,179 @@
+package pipeline
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/screwdriver-cd/sd-local/screwdriver"
+	"github.com/stretchr/testify/assert"
+)
+
+func testJobs() map[string]screwdriver.Job {
+	return map[string]screwdriver.Job{
+		"main":    {Requires: []string{"~commit", "~pr"}},
+		"lint":    {Requires: []string{"~commit"}},
+		"publish": {Requires: []string{"main", "lint"}},
+		"deploy":  {Requires: []string{"~publish", "~sd@123:main"}},
+	}
+}
+
+func TestNew(t *testing.T) {
+	cases := map[string]struct {
+		jobs        map[string]screwdriver.Job
+		names       []string
+		expectGraph *Graph
+		expectErr   error
+	}{
+		"success": {
+			jobs:  testJobs(),
+			names: []string{"main", "lint", "publish", "deploy"},
+			expectGraph: &Graph{
+				names: []string{"main", "lint", "publish", "deploy"},
+				requires: map[string][]string{
+					"main":    {},
+					"lint":    {},
+					"publish": {"main", "lint"},
+					"deploy":  {"publish"},
+				},
+			},
+		},
+		"success with a part of jobs": {
+			jobs:  testJobs(),
+			names: []string{"publish", "main", "main"},
+			expectGraph: &Graph{
+				names: []string{"publish", "main"},
+				requires: map[string][]string{
+					"publish": {"main"},
+					"main":    {},
+				},
+			},
+		},
+		"success with matrix": {
+			jobs: map[string]screwdriver.Job{
+				"main[NODE_VERSION=12]": {Requires: []string{"~commit"}},
+				"main[NODE_VERSION=14]": {Requires: []string{"~commit"}},
+				"publish":               {Requires: []string{"main"}},
+			},
+			names: []string{"main[NODE_VERSION=12]", "main[NODE_VERSION=14]", "publish"},
+			expectGraph: &Graph{
+				names: []string{"main[NODE_VERSION=12]", "main[NODE_VERSION=14]", "publish"},
+				requires: map[string][]string{
+					"main[NODE_VERSION=12]": {},
+					"main[NODE_VERSION=14]": {},
+					"publish":               {"main[NODE_VERSION=12]", "main[NODE_VERSION=14]"},
+				},
+			},
+		},
+		"failure by job that does not exist": {
+			jobs:      testJobs(),
+			names:     []string{"main", "doesnotexist"},
+			expectErr: fmt.Errorf("not found 'doesnotexist' in parsed screwdriver.yaml"),
+		},
+		"failure by circular dependency": {
+			jobs: map[string]screwdriver.Job{
+				"a": {Requires: []string{"c"}},
+				"b": {Requires: []string{"a"}},
+				"c": {Requires: []string{"b"}},
+			},
+			names:     []string{"a", "b", "c"},
+			expectErr: fmt.Errorf("jobs have a circular dependency: a -> c -> b -> a"),
+		},
+	}
+
+	for name, test := range cases {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			g, err := New(test.jobs, test.names)
+			assert.Equal(t, test.expectErr, err)
+			if test.expectErr == nil {
+				assert.Equal(t, test.expectGraph, g)
+			}
+		})
+	}
+}
+
+func TestRun(t *testing.T) {
+	t.Run("success to run jobs after the required jobs", func(t *testing.T) {
+		g, err := New(testJobs(), []string{"deploy", "publish", "main", "lint"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var mutex sync.Mutex
+		finished := map[string]bool{}
+		err = g.Run(2, func(name string) error {
+			mutex.Lock()
+			defer mutex.Unlock()
+			for _, required := range g.requires[name] {
+				assert.True(t, finished[required], "%s started before %s finished", name, required)
+			}
+			finished[name] = true
+			return nil
+		})
+
+		assert.Nil(t, err)
+		assert.Equal(t, 4, len(finished))
+	})
+
+	t.Run("success to limit the number of parallel jobs", func(t *testing.T) {
+		jobs := map[string]screwdriver.Job{"a": {}, "b": {}, "c": {}, "d": {}}
+		g, err := New(jobs, []string{"a", "b", "c", "d"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var mutex sync.Mutex
+		running, maxRunning := 0, 0
+		err = g.Run(2, func(name string) error {
+			mutex.Lock()
+			running++
+			if running > maxRunning {
+				maxRunning = running
+			}
+			mutex.Unlock()
+
+			mutex.Lock()
+			running--
+			mutex.Unlock()
+			return nil
+		})
+
+		assert.Nil(t, err)
+		assert.True(t, maxRunning <= 2)
+	})
+
+	t.Run("failure to skip the jobs which require the failed job", func(t *testing.T) {
+		g, err := New(testJobs(), []string{"main", "lint", "publish", "deploy"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var mutex sync.Mutex
+		ran := map[string]bool{}
+		err = g.Run(1, func(name string) error {
+			mutex.Lock()
+			ran[name] = true
+			mutex.Unlock()
+			if name == "lint" {
+				return fmt.Errorf("exit status 1")
+			}
+			return nil
+		})
+
+		assert.Equal(t, fmt.Errorf("failed to run jobs:\n  * lint: exit status 1\n  * publish: skipped because `lint` failed\n  * deploy: skipped because `publish` failed"), err)
+		assert.Equal(t, map[string]bool{"main": true, "lint": true}, ran)
+	})
+
+	t.Run("failure by invalid max parallel", func(t *testing.T) {
+		g, err := New(testJobs(), []string{"main"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = g.Run(0, func(name string) error { return nil })
+		assert.Equal(t, fmt.Errorf("max parallel must be a positive integer: 0"), err)
+	})
+}
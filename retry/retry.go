@@ -0,0 +1,77 @@
+package retry
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultBackoff is the default wait before the first retry
+const DefaultBackoff = time.Second
+
+var sleep = sleepContext
+
+// sleepContext waits for d or until ctx is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// Policy is the policy to retry the operations failed by the transient errors.
+// The zero value does not retry.
+type Policy struct {
+	// Retries is the maximum number of the retries after the first attempt
+	Retries int
+	// Backoff is the wait before the first retry, which doubles on every retry
+	Backoff time.Duration
+}
+
+type transientError struct {
+	err error
+}
+
+func (e *transientError) Error() string {
+	return e.err.Error()
+}
+
+// Transient marks the error as transient, which is retried by Policy.Do
+func Transient(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &transientError{err: err}
+}
+
+// Do runs fn until it succeeds or returns the error not marked by Transient, up to Retries times after the first attempt.
+// The last error is returned without the mark if all the attempts fail.
+func (p Policy) Do(operation string, fn func() error) error {
+	return p.DoContext(context.Background(), operation, fn)
+}
+
+// DoContext is Do which stops retrying when ctx is done. The error of ctx is returned if it is done while waiting for the retry.
+func (p Policy) DoContext(ctx context.Context, operation string, fn func() error) error {
+	wait := p.Backoff
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		t, ok := err.(*transientError)
+		if !ok {
+			return err
+		}
+		if attempt > p.Retries {
+			return t.err
+		}
+
+		logrus.Debugf("Retrying %s in %s (%d/%d): %v", operation, wait, attempt, p.Retries, t.err)
+		if err := sleep(ctx, wait); err != nil {
+			return err
+		}
+		wait *= 2
+	}
+}
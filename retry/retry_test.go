@@ -0,0 +1,124 @@
+package retry
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDo(t *testing.T) {
+	defer func() { sleep = sleepContext }()
+
+	cases := []struct {
+		name         string
+		policy       Policy
+		errs         []error
+		expectErr    string
+		expectCalls  int
+		expectWaits  []time.Duration
+		expectLogged string
+	}{
+		{
+			name:        "success",
+			policy:      Policy{Retries: 3, Backoff: time.Second},
+			errs:        []error{nil},
+			expectCalls: 1,
+		},
+		{
+			name:         "success after retries",
+			policy:       Policy{Retries: 3, Backoff: time.Second},
+			errs:         []error{Transient(errors.New("i/o timeout")), Transient(errors.New("StatusCode 503")), nil},
+			expectCalls:  3,
+			expectWaits:  []time.Duration{time.Second, 2 * time.Second},
+			expectLogged: "Retrying GET /v4/auth/token in 1s (1/3): i/o timeout",
+		},
+		{
+			name:         "failure by exhausted retries",
+			policy:       Policy{Retries: 2, Backoff: time.Second},
+			errs:         []error{Transient(errors.New("i/o timeout")), Transient(errors.New("i/o timeout")), Transient(errors.New("StatusCode 503"))},
+			expectErr:    "StatusCode 503",
+			expectCalls:  3,
+			expectWaits:  []time.Duration{time.Second, 2 * time.Second},
+			expectLogged: "Retrying GET /v4/auth/token in 2s (2/2): i/o timeout",
+		},
+		{
+			name:        "failure by non-retryable error",
+			policy:      Policy{Retries: 2, Backoff: time.Second},
+			errs:        []error{errors.New("StatusCode 401")},
+			expectErr:   "StatusCode 401",
+			expectCalls: 1,
+		},
+		{
+			name:        "no retries",
+			errs:        []error{Transient(errors.New("i/o timeout"))},
+			expectErr:   "i/o timeout",
+			expectCalls: 1,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var waits []time.Duration
+			sleep = func(ctx context.Context, d time.Duration) error {
+				waits = append(waits, d)
+				return nil
+			}
+			buf := bytes.NewBuffer(nil)
+			logrus.SetOutput(buf)
+			logrus.SetLevel(logrus.DebugLevel)
+			defer func() {
+				logrus.SetOutput(os.Stderr)
+				logrus.SetLevel(logrus.InfoLevel)
+			}()
+
+			calls := 0
+			err := c.policy.Do("GET /v4/auth/token", func() error {
+				err := c.errs[calls]
+				calls++
+				return err
+			})
+
+			assert.Equal(t, c.expectCalls, calls)
+			assert.Equal(t, c.expectWaits, waits)
+			if c.expectLogged != "" {
+				assert.Contains(t, buf.String(), c.expectLogged)
+			} else {
+				assert.Empty(t, buf.String())
+			}
+			if c.expectErr != "" {
+				assert.Equal(t, c.expectErr, err.Error())
+				_, transient := err.(*transientError)
+				assert.False(t, transient)
+				return
+			}
+			assert.Nil(t, err)
+		})
+	}
+}
+
+func TestDoContext(t *testing.T) {
+	t.Run("stop retrying when the context is done", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		calls := 0
+		err := Policy{Retries: 3, Backoff: time.Hour}.DoContext(ctx, "pulling image", func() error {
+			calls++
+			cancel()
+			return Transient(errors.New("i/o timeout"))
+		})
+		assert.Equal(t, context.Canceled, err)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("succeed without the retry", func(t *testing.T) {
+		err := Policy{Retries: 3, Backoff: time.Hour}.DoContext(context.Background(), "pulling image", func() error {
+			return nil
+		})
+		assert.Nil(t, err)
+	})
+}
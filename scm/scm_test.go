@@ -156,7 +156,8 @@ func TestKill(t *testing.T) {
 
 		PidTmp := s.commands[0].Process.Pid
 		defer func() {
-			syscall.Kill(PidTmp, syscall.SIGINT)
+			p, _ := os.FindProcess(PidTmp)
+			p.Signal(os.Interrupt)
 		}()
 		s.commands[0].Process.Pid = 0
 
@@ -0,0 +1,63 @@
+package screwdriver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// commandEndpoint is the endpoint of the command of sd-cmd, whose version can be a tag like stable as well
+const commandEndpoint = "commands/%s/%s/%s"
+
+// CommandAPI is the API which returns the definitions of the commands of sd-cmd
+type CommandAPI interface {
+	Command(ctx context.Context, namespace, name, version string) (Command, error)
+}
+
+var _ CommandAPI = (*sdAPI)(nil)
+
+// Command is the definition of the command of sd-cmd
+type Command struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	// Version is the exact version the requested version or tag is resolved to
+	Version string `json:"version"`
+	// Format is binary, habitat or docker
+	Format string `json:"format"`
+	Binary struct {
+		File string `json:"file"`
+	} `json:"binary"`
+}
+
+// Command returns the definition of the command of sd-cmd. It requires the JWT.
+func (sd *sdAPI) Command(ctx context.Context, namespace, name, version string) (Command, error) {
+	ref := fmt.Sprintf("%s/%s@%s", namespace, name, version)
+	fullpath, err := sd.makeURL(fmt.Sprintf(commandEndpoint, namespace, name, version))
+	if err != nil {
+		return Command{}, fmt.Errorf("failed to make request url: %v", err)
+	}
+
+	res, err := sd.request(ctx, http.MethodGet, fullpath.String(), nil)
+	if err != nil {
+		return Command{}, fmt.Errorf("failed to send request: %v", err)
+	}
+	defer res.Body.Close()
+
+	switch res.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return Command{}, fmt.Errorf("command %s is not found", ref)
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return Command{}, fmt.Errorf("not authorized to get command %s: StatusCode %d", ref, res.StatusCode)
+	default:
+		return Command{}, fmt.Errorf("failed to get command %s: StatusCode %d", ref, res.StatusCode)
+	}
+
+	var command Command
+	err = json.NewDecoder(res.Body).Decode(&command)
+	if err != nil {
+		return Command{}, fmt.Errorf("failed to parse command %s: %v", ref, err)
+	}
+	return command, nil
+}
@@ -0,0 +1,57 @@
+package screwdriver
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommand(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/v4/commands/foo/bar/stable", r.URL.Path)
+			validateHeader(t, "Authorization", "Bearer jwt", r)
+			fmt.Fprintln(w, `{"namespace": "foo", "name": "bar", "version": "1.0.2", "format": "binary", "binary": {"file": "./bar.sh"}}`)
+		}))
+		defer server.Close()
+
+		testAPI := sdAPI{HTTPClient: http.DefaultClient, APIURL: server.URL, SDJWT: "jwt"}
+
+		command, err := testAPI.Command(context.Background(), "foo", "bar", "stable")
+		assert.Nil(t, err)
+		expected := Command{Namespace: "foo", Name: "bar", Version: "1.0.2", Format: "binary"}
+		expected.Binary.File = "./bar.sh"
+		assert.Equal(t, expected, command)
+	})
+
+	testCases := []struct {
+		name     string
+		status   int
+		body     string
+		expected string
+	}{
+		{name: "not found", status: http.StatusNotFound, expected: "command foo/bar@stable is not found"},
+		{name: "unauthorized", status: http.StatusUnauthorized, expected: "not authorized to get command foo/bar@stable: StatusCode 401"},
+		{name: "server error", status: http.StatusInternalServerError, expected: "failed to get command foo/bar@stable: StatusCode 500"},
+		{name: "invalid JSON", status: http.StatusOK, body: "{", expected: "failed to parse command foo/bar@stable: unexpected EOF"},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				fmt.Fprint(w, tt.body)
+			}))
+			defer server.Close()
+
+			testAPI := sdAPI{HTTPClient: http.DefaultClient, APIURL: server.URL, SDJWT: "jwt"}
+
+			_, err := testAPI.Command(context.Background(), "foo", "bar", "stable")
+			assert.Equal(t, tt.expected, err.Error())
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package screwdriver
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-yaml/yaml"
+)
+
+// isNameChar returns true if the character can be used in the names of the environment variables
+func isNameChar(c byte, first bool) bool {
+	return c == '_' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || (!first && '0' <= c && c <= '9')
+}
+
+// expandString expands ${VAR}, ${VAR:-default} and $VAR in s. $$ is expanded to $.
+func expandString(s string, lookup func(string) (string, bool)) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '$' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+
+		switch next := s[i+1]; {
+		case next == '$':
+			b.WriteByte('$')
+			i++
+		case next == '{':
+			end := strings.IndexByte(s[i+2:], '}')
+			if end < 0 {
+				return "", fmt.Errorf("unclosed variable in '%s'", s)
+			}
+			expr := s[i+2 : i+2+end]
+			name, def, hasDefault := expr, "", false
+			if j := strings.Index(expr, ":-"); j >= 0 {
+				name, def, hasDefault = expr[:j], expr[j+2:], true
+			}
+			value, ok := lookup(name)
+			switch {
+			case ok && (value != "" || !hasDefault):
+				b.WriteString(value)
+			case hasDefault:
+				b.WriteString(def)
+			default:
+				return "", fmt.Errorf("undefined variable %s, use ${%s:-default} for the default value", name, name)
+			}
+			i += end + 2
+		case isNameChar(next, true):
+			j := i + 2
+			for j < len(s) && isNameChar(s[j], false) {
+				j++
+			}
+			name := s[i+1 : j]
+			value, ok := lookup(name)
+			if !ok {
+				return "", fmt.Errorf("undefined variable %s, use ${%s:-default} for the default value", name, name)
+			}
+			b.WriteString(value)
+			i = j - 1
+		default:
+			b.WriteByte('$')
+		}
+	}
+	return b.String(), nil
+}
+
+// isContainerSide returns true if the path is the steps or the environment of the jobs or shared,
+// whose variables like $SD_ARTIFACTS_DIR are expanded in the build container
+func isContainerSide(path []string) bool {
+	key := ""
+	switch {
+	case len(path) == 3 && path[0] == "jobs":
+		key = path[2]
+	case len(path) == 2 && path[0] == "shared":
+		key = path[1]
+	}
+	return key == "steps" || key == "environment"
+}
+
+func expandValue(v interface{}, path []string, lookup func(string) (string, bool)) (interface{}, error) {
+	if isContainerSide(path) {
+		return v, nil
+	}
+
+	switch v := v.(type) {
+	case string:
+		return expandString(v, lookup)
+	case yaml.MapSlice:
+		for i, item := range v {
+			value, err := expandValue(item.Value, append(path, fmt.Sprint(item.Key)), lookup)
+			if err != nil {
+				return nil, err
+			}
+			v[i].Value = value
+		}
+	case []interface{}:
+		for i, item := range v {
+			value, err := expandValue(item, path, lookup)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = value
+		}
+	}
+	return v, nil
+}
+
+// ExpandEnv expands the variables like ${VAR}, ${VAR:-default} and $VAR in the values of screwdriver.yaml with lookup.
+// The steps and the environment are not expanded because the variables in them are expanded in the build container.
+// It returns an error if a variable without the default value is not defined.
+func ExpandEnv(content []byte, lookup func(string) (string, bool)) ([]byte, error) {
+	var pipeline yaml.MapSlice
+	if err := yaml.Unmarshal(content, &pipeline); err != nil {
+		return nil, fmt.Errorf("failed to parse screwdriver.yaml: %v", err)
+	}
+
+	expanded, err := expandValue(pipeline, nil, lookup)
+	if err != nil {
+		return nil, fmt.Errorf("failed to expand screwdriver.yaml: %v", err)
+	}
+
+	return yaml.Marshal(expanded)
+}
@@ -0,0 +1,103 @@
+package screwdriver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpandEnv(t *testing.T) {
+	env := map[string]string{
+		"IMAGE_TAG": "12",
+		"EMPTY":     "",
+		"STAGE":     "beta",
+	}
+	lookup := func(name string) (string, bool) {
+		v, ok := env[name]
+		return v, ok
+	}
+
+	cases := []struct {
+		name      string
+		yaml      string
+		expect    string
+		expectErr string
+	}{
+		{
+			name: "success",
+			yaml: `shared:
+  image: node:${IMAGE_TAG}
+  steps:
+    - echo: echo $HOME
+jobs:
+  main:
+    image: node:$IMAGE_TAG-alpine
+    environment:
+      ARTIFACTS: ${SD_ARTIFACTS_DIR}/report.json
+    annotations:
+      stage: ${STAGE}
+      region: ${REGION:-us-west-2}
+      name: ${EMPTY:-default}
+      empty: ${EMPTY}
+      price: $$5
+      dollar: $ and $-
+    requires: [~pr]
+    steps:
+      - test: echo ${UNDEFINED}
+`,
+			expect: `shared:
+  image: node:12
+  steps:
+  - echo: echo $HOME
+jobs:
+  main:
+    image: node:12-alpine
+    environment:
+      ARTIFACTS: ${SD_ARTIFACTS_DIR}/report.json
+    annotations:
+      stage: beta
+      region: us-west-2
+      name: default
+      empty: ""
+      price: $5
+      dollar: $ and $-
+    requires:
+    - ~pr
+    steps:
+    - test: echo ${UNDEFINED}
+`,
+		},
+		{
+			name:      "failure by undefined variable",
+			yaml:      "jobs:\n  main:\n    image: node:${UNDEFINED}\n",
+			expectErr: "failed to expand screwdriver.yaml: undefined variable UNDEFINED, use ${UNDEFINED:-default} for the default value",
+		},
+		{
+			name:      "failure by undefined variable without braces",
+			yaml:      "jobs:\n  main:\n    image: $UNDEFINED\n",
+			expectErr: "failed to expand screwdriver.yaml: undefined variable UNDEFINED, use ${UNDEFINED:-default} for the default value",
+		},
+		{
+			name:      "failure by unclosed variable",
+			yaml:      "jobs:\n  main:\n    image: node:${IMAGE_TAG\n",
+			expectErr: "failed to expand screwdriver.yaml: unclosed variable in 'node:${IMAGE_TAG'",
+		},
+		{
+			name:      "failure by invalid yaml",
+			yaml:      "jobs: [\n",
+			expectErr: "failed to parse screwdriver.yaml",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			actual, err := ExpandEnv([]byte(c.yaml), lookup)
+			if c.expectErr != "" {
+				assert.Contains(t, err.Error(), c.expectErr)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, c.expect, string(actual))
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package screwdriver
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// MatrixJobName returns the name of the job of the combination of the matrix like main[NODE_VERSION=12,OS=linux]
+func MatrixJobName(name string, matrix map[string]string) string {
+	keys := make([]string, 0, len(matrix))
+	for k := range matrix {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	coordinate := make([]string, 0, len(keys))
+	for _, k := range keys {
+		coordinate = append(coordinate, fmt.Sprintf("%s=%s", k, matrix[k]))
+	}
+	return fmt.Sprintf("%s[%s]", name, strings.Join(coordinate, ","))
+}
+
+// BaseJobName returns the name of the job in screwdriver.yaml which the combination of the matrix like main[NODE_VERSION=12] belongs to.
+// The name of the job without the matrix is returned as it is.
+func BaseJobName(name string) string {
+	if i := strings.Index(name, "["); i > 0 && strings.HasSuffix(name, "]") {
+		return name[:i]
+	}
+	return name
+}
+
+// matrixJobs returns the permutations of the job parsed from the matrix as the jobs named with their combinations,
+// whose keys are the environment variables with the different values between the permutations.
+// The job without the matrix has only one permutation, which is returned as it is.
+func matrixJobs(name string, permutations []Job) map[string]Job {
+	if len(permutations) == 1 {
+		return map[string]Job{name: permutations[0]}
+	}
+
+	keys := make(map[string]bool)
+	for _, p := range permutations {
+		for k, v := range p.Environment {
+			if permutations[0].Environment[k] != v {
+				keys[k] = true
+			}
+		}
+		for k, v := range permutations[0].Environment {
+			if p.Environment[k] != v {
+				keys[k] = true
+			}
+		}
+	}
+
+	jobs := make(map[string]Job, len(permutations))
+	for _, p := range permutations {
+		p.Matrix = make(map[string]string, len(keys))
+		for k := range keys {
+			p.Matrix[k] = p.Environment[k]
+		}
+		jobs[MatrixJobName(name, p.Matrix)] = p
+	}
+	return jobs
+}
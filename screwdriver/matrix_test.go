@@ -0,0 +1,29 @@
+package screwdriver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatrixJobName(t *testing.T) {
+	assert.Equal(t, "main[NODE_VERSION=12,OS=linux]", MatrixJobName("main", map[string]string{"OS": "linux", "NODE_VERSION": "12"}))
+}
+
+func TestBaseJobName(t *testing.T) {
+	testCases := []struct {
+		name     string
+		expected string
+	}{
+		{name: "main[NODE_VERSION=12,OS=linux]", expected: "main"},
+		{name: "main", expected: "main"},
+		{name: "PR-1:main", expected: "PR-1:main"},
+		{name: "[NODE_VERSION=12]", expected: "[NODE_VERSION=12]"},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, BaseJobName(tt.name))
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package screwdriver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// pipelineJobsEndpoint is the endpoint of the jobs of the pipeline
+const pipelineJobsEndpoint = "pipelines/%d/jobs"
+
+// PipelineAPI is the API which returns the jobs of the pipeline registered in Screwdriver.cd
+type PipelineAPI interface {
+	PipelineJobs(ctx context.Context, pipelineID int) (map[string]Job, error)
+}
+
+var _ PipelineAPI = (*sdAPI)(nil)
+
+// pipelineJob is the job of the pipeline, whose permutations are the configs of the job parsed from screwdriver.yaml
+type pipelineJob struct {
+	Name         string `json:"name"`
+	Archived     bool   `json:"archived"`
+	Permutations []Job  `json:"permutations"`
+}
+
+// isPRJob returns true if the job is the copy of the job for the pull request like PR-1:main
+func isPRJob(name string) bool {
+	return strings.HasPrefix(name, "PR-") && strings.Contains(name, ":")
+}
+
+// PipelineJobs returns the jobs of the pipeline by name as the API has them, which are parsed from screwdriver.yaml
+// of the repository when the pipeline is synced. The archived jobs and the jobs of the pull requests are not included.
+// It requires the JWT.
+func (sd *sdAPI) PipelineJobs(ctx context.Context, pipelineID int) (map[string]Job, error) {
+	fullpath, err := sd.makeURL(fmt.Sprintf(pipelineJobsEndpoint, pipelineID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request url: %v", err)
+	}
+
+	res, err := sd.request(ctx, http.MethodGet, fullpath.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %v", err)
+	}
+	defer res.Body.Close()
+
+	switch res.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, fmt.Errorf("pipeline %d is not found", pipelineID)
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return nil, fmt.Errorf("not authorized to get the jobs of pipeline %d: StatusCode %d", pipelineID, res.StatusCode)
+	default:
+		return nil, fmt.Errorf("failed to get the jobs of pipeline %d: StatusCode %d", pipelineID, res.StatusCode)
+	}
+
+	var pipelineJobs []pipelineJob
+	err = json.NewDecoder(res.Body).Decode(&pipelineJobs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the jobs of pipeline %d: %v", pipelineID, err)
+	}
+
+	jobs := make(map[string]Job, len(pipelineJobs))
+	for _, j := range pipelineJobs {
+		if j.Archived || isPRJob(j.Name) || len(j.Permutations) == 0 {
+			continue
+		}
+		for name, job := range matrixJobs(j.Name, j.Permutations) {
+			jobs[name] = job
+		}
+	}
+	return jobs, nil
+}
@@ -0,0 +1,85 @@
+package screwdriver
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPipelineJobs(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/v4/pipelines/123/jobs", r.URL.Path)
+			validateHeader(t, "Authorization", "Bearer jwt", r)
+
+			testJSON, err := ioutil.ReadFile(filepath.Join(testDir, "pipelineJobs.json"))
+			assert.Nil(t, err)
+			fmt.Fprintln(w, string(testJSON))
+		}))
+		defer server.Close()
+
+		testAPI := sdAPI{
+			HTTPClient: http.DefaultClient,
+			UserToken:  "dummy",
+			APIURL:     server.URL,
+			SDJWT:      "jwt",
+		}
+
+		expected := map[string]Job{
+			"main": {
+				Steps:       []Step{{Name: "test", Command: "echo test"}},
+				Environment: map[string]string{},
+				Image:       "alpine",
+				Requires:    []string{"~commit", "~pr"},
+				Annotations: map[string]interface{}{"screwdriver.cd/cpu": "HIGH"},
+			},
+			"publish": {
+				Steps:       []Step{{Name: "publish", Command: "echo publish"}},
+				Environment: map[string]string{},
+				Image:       "alpine",
+				Requires:    []string{"main"},
+			},
+		}
+
+		jobs, err := testAPI.PipelineJobs(context.Background(), 123)
+		assert.Nil(t, err)
+		assert.Equal(t, expected, jobs)
+	})
+
+	testCases := []struct {
+		name     string
+		status   int
+		body     string
+		expected string
+	}{
+		{name: "not found", status: http.StatusNotFound, expected: "pipeline 123 is not found"},
+		{name: "unauthorized", status: http.StatusForbidden, expected: "not authorized to get the jobs of pipeline 123: StatusCode 403"},
+		{name: "server error", status: http.StatusInternalServerError, expected: "failed to get the jobs of pipeline 123: StatusCode 500"},
+		{name: "invalid JSON", status: http.StatusOK, body: "{", expected: "failed to parse the jobs of pipeline 123: unexpected EOF"},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				fmt.Fprint(w, tt.body)
+			}))
+			defer server.Close()
+
+			testAPI := sdAPI{
+				HTTPClient: http.DefaultClient,
+				APIURL:     server.URL,
+				SDJWT:      "jwt",
+			}
+
+			_, err := testAPI.PipelineJobs(context.Background(), 123)
+			assert.Equal(t, tt.expected, err.Error())
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package screwdriver
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+
+	"github.com/screwdriver-cd/sd-local/retry"
+)
+
+// retryTransport retries the requests failed by the transient errors like the network timeouts,
+// 5xx and 429 Too Many Requests. The other responses like 401 and 404 are returned immediately.
+type retryTransport struct {
+	base   http.RoundTripper
+	policy retry.Policy
+}
+
+// isTransientStatus returns true if the request may succeed by retrying it
+func isTransientStatus(statusCode int) bool {
+	return statusCode >= http.StatusInternalServerError || statusCode == http.StatusTooManyRequests
+}
+
+// isTransientError returns true if the request failed by the network, not by the invalid request or the certificates
+func isTransientError(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// the request with the body which can't be rewound is sent only once
+	if req.Body != nil && req.GetBody == nil {
+		return t.base.RoundTrip(req)
+	}
+
+	// the URL is logged without the query which may contain the token
+	operation := fmt.Sprintf("%s %s://%s%s", req.Method, req.URL.Scheme, req.URL.Host, req.URL.Path)
+
+	var res *http.Response
+	attempt := 0
+	err := t.policy.DoContext(req.Context(), operation, func() error {
+		// the response of the previous attempt is discarded by retrying it
+		if res != nil {
+			res.Body.Close()
+			res = nil
+		}
+
+		r := req
+		if attempt > 0 && req.Body != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return err
+			}
+			r = req.Clone(req.Context())
+			r.Body = body
+		}
+		attempt++
+
+		var err error
+		res, err = t.base.RoundTrip(r)
+		if err != nil {
+			if isTransientError(err) {
+				return retry.Transient(err)
+			}
+			return err
+		}
+		if isTransientStatus(res.StatusCode) {
+			return retry.Transient(fmt.Errorf("StatusCode %d", res.StatusCode))
+		}
+		return nil
+	})
+
+	// the wait for the retry is stopped by the context
+	if err != nil && err == req.Context().Err() {
+		if res != nil {
+			res.Body.Close()
+		}
+		return nil, err
+	}
+
+	// the response of the last attempt is returned even if its status is transient
+	if res != nil {
+		return res, nil
+	}
+	return nil, err
+}
@@ -0,0 +1,119 @@
+package screwdriver
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/screwdriver-cd/sd-local/retry"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryTransport(t *testing.T) {
+	cases := []struct {
+		name         string
+		statuses     []int
+		body         string
+		expectStatus int
+		expectHits   int
+	}{
+		{
+			name:         "success after retries of 5xx and 429",
+			statuses:     []int{http.StatusServiceUnavailable, http.StatusTooManyRequests, http.StatusOK},
+			expectStatus: http.StatusOK,
+			expectHits:   3,
+		},
+		{
+			name:         "success after retries with the body",
+			statuses:     []int{http.StatusBadGateway, http.StatusOK},
+			body:         `{"yaml": "jobs: {}"}`,
+			expectStatus: http.StatusOK,
+			expectHits:   2,
+		},
+		{
+			name:         "failure by exhausted retries",
+			statuses:     []int{http.StatusInternalServerError, http.StatusInternalServerError, http.StatusInternalServerError},
+			expectStatus: http.StatusInternalServerError,
+			expectHits:   3,
+		},
+		{
+			name:         "failure by 401 without retries",
+			statuses:     []int{http.StatusUnauthorized},
+			expectStatus: http.StatusUnauthorized,
+			expectHits:   1,
+		},
+		{
+			name:         "failure by 404 without retries",
+			statuses:     []int{http.StatusNotFound},
+			expectStatus: http.StatusNotFound,
+			expectHits:   1,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			hits := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := ioutil.ReadAll(r.Body)
+				assert.Equal(t, c.body, string(body))
+				w.WriteHeader(c.statuses[hits])
+				hits++
+			}))
+			defer server.Close()
+
+			client, err := NewHTTPClient(HTTPClientOption{Retry: retry.Policy{Retries: 2}})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			method := http.MethodGet
+			if c.body != "" {
+				method = http.MethodPost
+			}
+			req, err := http.NewRequest(method, server.URL+"/v4/validator", strings.NewReader(c.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			res, err := client.Do(req)
+			assert.Nil(t, err)
+			assert.Equal(t, c.expectStatus, res.StatusCode)
+			assert.Equal(t, c.expectHits, hits)
+		})
+	}
+
+	t.Run("failure by network error after retries", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		server.Close()
+
+		client, err := NewHTTPClient(HTTPClientOption{Retry: retry.Policy{Retries: 2}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = client.Get(server.URL)
+		assert.Contains(t, err.Error(), "connection refused")
+	})
+
+	t.Run("failure by the cancelled context while waiting for the retry", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer server.Close()
+
+		client, err := NewHTTPClient(HTTPClientOption{Retry: retry.Policy{Retries: 2, Backoff: time.Hour}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = client.Do(req)
+		assert.Contains(t, err.Error(), "context deadline exceeded")
+	})
+}
@@ -1,6 +1,9 @@
 package screwdriver
 
 import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -8,8 +11,14 @@ import (
 	"net/http"
 	"net/url"
 	"path"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
+
+	"github.com/go-yaml/yaml"
+	"github.com/screwdriver-cd/sd-local/retry"
+	"github.com/sirupsen/logrus"
 )
 
 const (
@@ -21,10 +30,21 @@ const (
 // API has method to get job
 type API interface {
 	Job(jobName, filePath string) (Job, error)
+	Jobs(filePath string) (map[string]Job, error)
 	JWT() string
 	InitJWT() error
 }
 
+// ContextAPI is the API whose requests are cancelled when the context is done
+type ContextAPI interface {
+	API
+	JobContext(ctx context.Context, jobName, filePath string) (Job, error)
+	JobsContext(ctx context.Context, filePath string) (map[string]Job, error)
+	InitJWTContext(ctx context.Context) error
+}
+
+var _ ContextAPI = (*sdAPI)(nil)
+
 type sdAPI struct {
 	HTTPClient *http.Client
 	UserToken  string
@@ -39,6 +59,41 @@ var _ API = (*sdAPI)(nil)
 type Step struct {
 	Name    string `json:"name"`
 	Command string `json:"command"`
+	// Environment is the environment variables only for the step, which override the ones of the job and --env
+	Environment map[string]string `json:"environment,omitempty"`
+}
+
+// teardownStepPrefix is the prefix of the user-defined teardown steps, which run even if the previous steps failed
+const teardownStepPrefix = "teardown-"
+
+// The prefixes of the implicit steps of the launcher, which run before and after the steps of the job
+const (
+	launcherSetupStepPrefix    = "sd-setup-"
+	launcherTeardownStepPrefix = "sd-teardown-"
+)
+
+// The phases of the build. The steps of the teardown phase are deferred to run after the user steps even if they failed.
+const (
+	PhaseSetup    = "setup"
+	PhaseUser     = "user"
+	PhaseTeardown = "teardown"
+)
+
+// IsTeardown returns true if the step is a teardown step
+func (s Step) IsTeardown() bool {
+	return strings.HasPrefix(s.Name, teardownStepPrefix)
+}
+
+// StepPhase returns the phase of the step, which can be the implicit step of the launcher like sd-setup-scm as well
+func StepPhase(name string) string {
+	switch {
+	case strings.HasPrefix(name, launcherSetupStepPrefix):
+		return PhaseSetup
+	case strings.HasPrefix(name, teardownStepPrefix), strings.HasPrefix(name, launcherTeardownStepPrefix):
+		return PhaseTeardown
+	default:
+		return PhaseUser
+	}
 }
 
 // Job is job entity struct
@@ -46,6 +101,126 @@ type Job struct {
 	Steps       []Step            `json:"commands"`
 	Environment map[string]string `json:"environment"`
 	Image       string            `json:"image"`
+	Requires    []string          `json:"requires,omitempty"`
+	// Annotations like screwdriver.cd/ram can be numbers as well as strings
+	Annotations map[string]interface{} `json:"annotations,omitempty"`
+	// Matrix is the combination of the matrix of the job like NODE_VERSION=12, which is empty without the matrix
+	Matrix map[string]string `json:"matrix,omitempty"`
+	// SourcePaths are the files and the directories ending with a slash whose changes trigger the job
+	SourcePaths []string `json:"sourcePaths,omitempty"`
+}
+
+// requires is the requires of the job in screwdriver.yaml, which can be a job name as well as a list of them
+type requires []string
+
+// UnmarshalYAML decodes the requires in screwdriver.yaml
+func (r *requires) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var name string
+	if err := unmarshal(&name); err == nil {
+		*r = requires{name}
+		return nil
+	}
+	var names []string
+	if err := unmarshal(&names); err != nil {
+		return err
+	}
+	*r = names
+	return nil
+}
+
+// UnmarshalYAML decodes the job in screwdriver.yaml, whose steps are the mappings of the name to the command.
+// The validator of the API does the same and the job is returned in the format of its response.
+func (j *Job) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var job struct {
+		Steps       []yaml.MapSlice        `yaml:"steps"`
+		Environment map[string]string      `yaml:"environment"`
+		Image       string                 `yaml:"image"`
+		Requires    requires               `yaml:"requires"`
+		Annotations map[string]interface{} `yaml:"annotations"`
+		SourcePaths []string               `yaml:"sourcePaths"`
+	}
+	if err := unmarshal(&job); err != nil {
+		return err
+	}
+
+	steps := make([]Step, 0, len(job.Steps))
+	for _, s := range job.Steps {
+		// the malformed steps are reported by Validate
+		if len(s) != 1 {
+			continue
+		}
+		name, _ := s[0].Key.(string)
+		step := Step{Name: name}
+		// the step with its own environment is a mapping of the command and the environment
+		if m, ok := s[0].Value.(yaml.MapSlice); ok {
+			step.Command, step.Environment = stepOf(m)
+		} else {
+			step.Command, _ = s[0].Value.(string)
+		}
+		steps = append(steps, step)
+	}
+
+	*j = Job{
+		Steps:       steps,
+		Environment: job.Environment,
+		Image:       job.Image,
+		Requires:    job.Requires,
+		Annotations: job.Annotations,
+		SourcePaths: job.SourcePaths,
+	}
+	return nil
+}
+
+// PlannedSteps returns the steps of the job in the order to run them, which are the user steps followed by the teardown steps.
+// The teardown steps are deferred even if they are defined between the user steps.
+func (j Job) PlannedSteps() []Step {
+	steps := make([]Step, 0, len(j.Steps))
+	for _, s := range j.Steps {
+		if !s.IsTeardown() {
+			steps = append(steps, s)
+		}
+	}
+	for _, s := range j.Steps {
+		if s.IsTeardown() {
+			steps = append(steps, s)
+		}
+	}
+	return steps
+}
+
+// SelectSteps returns the job which runs only the named steps in the order of the job.
+// The teardown steps are kept to clean up the environment.
+func (j Job) SelectSteps(names []string) (Job, error) {
+	selected := make(map[string]bool, len(names))
+	for _, name := range names {
+		selected[name] = true
+	}
+
+	stepNames := make([]string, 0, len(j.Steps))
+	for _, s := range j.Steps {
+		stepNames = append(stepNames, s.Name)
+	}
+	for _, name := range names {
+		found := false
+		for _, stepName := range stepNames {
+			if name == stepName {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return Job{}, fmt.Errorf("not found step '%s' in the job, valid steps are: %s", name, strings.Join(stepNames, ", "))
+		}
+	}
+
+	steps := make([]Step, 0, len(names))
+	for _, s := range j.Steps {
+		if selected[s.Name] || s.IsTeardown() {
+			steps = append(steps, s)
+		}
+	}
+	j.Steps = steps
+	return j, nil
 }
 
 type jobs map[string][]Job
@@ -59,10 +234,88 @@ type tokenResponse struct {
 	JWT string `json:"token"`
 }
 
+// HTTPClientOption is option for NewHTTPClient
+type HTTPClientOption struct {
+	HTTPProxy  string
+	HTTPSProxy string
+	CABundle   string
+	// Retry is the policy to retry the requests failed by the transient errors
+	Retry retry.Policy
+}
+
+// NewHTTPClient creates a HTTP client to talk to Screwdriver.cd.
+// It returns http.DefaultClient if no option is specified.
+func NewHTTPClient(option HTTPClientOption) (*http.Client, error) {
+	if option == (HTTPClientOption{}) {
+		return http.DefaultClient, nil
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	proxy, err := proxyFunc(option.HTTPProxy, option.HTTPSProxy)
+	if err != nil {
+		return nil, err
+	}
+	transport.Proxy = proxy
+
+	if option.CABundle != "" {
+		pool, err := certPool(option.CABundle)
+		if err != nil {
+			return nil, err
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+	}
+
+	if option.Retry.Retries > 0 {
+		return &http.Client{Transport: &retryTransport{base: transport, policy: option.Retry}}, nil
+	}
+	return &http.Client{Transport: transport}, nil
+}
+
+// certPool returns the system cert pool with the certificates in caBundle
+func certPool(caBundle string) (*x509.CertPool, error) {
+	pem, err := ioutil.ReadFile(caBundle)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA bundle: %v", err)
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("failed to read CA bundle: no certificates found in %s", caBundle)
+	}
+
+	return pool, nil
+}
+
+// proxyFunc returns a proxy function which prefers the specified proxies to the environment variables
+func proxyFunc(httpProxy, httpsProxy string) (func(*http.Request) (*url.URL, error), error) {
+	proxies := make(map[string]*url.URL)
+	for scheme, proxy := range map[string]string{"http": httpProxy, "https": httpsProxy} {
+		if proxy == "" {
+			continue
+		}
+		u, err := url.Parse(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s proxy: %v", scheme, err)
+		}
+		proxies[scheme] = u
+	}
+
+	return func(req *http.Request) (*url.URL, error) {
+		if u, ok := proxies[req.URL.Scheme]; ok {
+			return u, nil
+		}
+		return http.ProxyFromEnvironment(req)
+	}, nil
+}
+
 // New creates a API
-func New(apiURL, token, ua string) API {
+func New(apiURL, token, ua string, client *http.Client) API {
 	s := &sdAPI{
-		HTTPClient: http.DefaultClient,
+		HTTPClient: client,
 		APIURL:     apiURL,
 		UserToken:  token,
 		UA:         ua,
@@ -81,18 +334,26 @@ func (sd *sdAPI) makeURL(endpoint string) (*url.URL, error) {
 	return u, nil
 }
 
-func (sd *sdAPI) request(method, path string, body io.Reader) (*http.Response, error) {
-	req, err := http.NewRequest(method, path, body)
+func (sd *sdAPI) request(ctx context.Context, method, path string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, path, body)
 	if err != nil {
 		return nil, err
 	}
 
 	req.Header.Add("User-Agent", sd.UA)
 
+	// the URL is logged without the query which may contain the token
+	endpoint := fmt.Sprintf("%s://%s%s", req.URL.Scheme, req.URL.Host, req.URL.Path)
+	logrus.Debugf("--> %s %s", method, endpoint)
+
 	switch method {
 	case http.MethodGet:
 		{
 			req.Header.Add("Accept", "application/json")
+			// the JWT is not initialized yet while getting it with the user token
+			if sd.SDJWT != "" {
+				req.Header.Add("Authorization", "Bearer "+sd.SDJWT)
+			}
 		}
 	case http.MethodPost, http.MethodPut, http.MethodDelete:
 		{
@@ -101,10 +362,17 @@ func (sd *sdAPI) request(method, path string, body io.Reader) (*http.Response, e
 		}
 	}
 
-	return sd.HTTPClient.Do(req)
+	start := time.Now()
+	res, err := sd.HTTPClient.Do(req)
+	if err != nil {
+		logrus.Debugf("<-- %s %s: %v", method, endpoint, err)
+		return nil, err
+	}
+	logrus.Debugf("<-- %s %s: %s in %s", method, endpoint, res.Status, time.Since(start).Round(time.Millisecond))
+	return res, nil
 }
 
-func (sd *sdAPI) jwt() (string, error) {
+func (sd *sdAPI) jwt(ctx context.Context) (string, error) {
 	fullpath, err := sd.makeURL(tokenEndpoint)
 	if err != nil {
 		return "", fmt.Errorf("failed to make request url: %v", err)
@@ -114,7 +382,7 @@ func (sd *sdAPI) jwt() (string, error) {
 	query.Set("api_token", sd.UserToken)
 	fullpath.RawQuery = query.Encode()
 
-	res, err := sd.request(http.MethodGet, fullpath.String(), nil)
+	res, err := sd.request(ctx, http.MethodGet, fullpath.String(), nil)
 	if err != nil {
 		return "", fmt.Errorf("failed to send request: %v", err)
 	}
@@ -140,7 +408,30 @@ func readScrewdriverYAML(filePath string) (string, error) {
 	return string(yaml), nil
 }
 
-func (sd *sdAPI) validate(filePath string) (jobs, error) {
+// JobNames returns the names of the jobs in screwdriver.yaml in alphabetical order.
+// It reads the file locally without the validator, so the names can be completed without the API.
+func JobNames(filePath string) ([]string, error) {
+	b, err := readScrewdriverYAML(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var pipeline struct {
+		Jobs map[string]interface{} `yaml:"jobs"`
+	}
+	if err := yaml.Unmarshal([]byte(b), &pipeline); err != nil {
+		return nil, fmt.Errorf("failed to parse screwdriver.yaml: %v", err)
+	}
+
+	names := make([]string, 0, len(pipeline.Jobs))
+	for name := range pipeline.Jobs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (sd *sdAPI) validate(ctx context.Context, filePath string) (jobs, error) {
 	fullpath, err := sd.makeURL(validatorEndpoint)
 	if err != nil {
 		return nil, fmt.Errorf("failed to make request url: %v", err)
@@ -154,7 +445,7 @@ func (sd *sdAPI) validate(filePath string) (jobs, error) {
 	escapedYaml := strconv.Quote(yaml)
 	body := fmt.Sprintf(`{"yaml": %s}`, escapedYaml)
 
-	res, err := sd.request(http.MethodPost, fullpath.String(), strings.NewReader(body))
+	res, err := sd.request(ctx, http.MethodPost, fullpath.String(), strings.NewReader(body))
 	if err != nil {
 		return nil, fmt.Errorf("failed to send request: %v", err)
 	}
@@ -179,7 +470,12 @@ func (sd *sdAPI) validate(filePath string) (jobs, error) {
 
 // Job returns job represented by "jobName"
 func (sd *sdAPI) Job(jobName, filepath string) (Job, error) {
-	jobs, err := sd.validate(filepath)
+	return sd.JobContext(context.Background(), jobName, filepath)
+}
+
+// JobContext is Job whose request is cancelled when ctx is done
+func (sd *sdAPI) JobContext(ctx context.Context, jobName, filepath string) (Job, error) {
+	jobs, err := sd.validate(ctx, filepath)
 	if err != nil {
 		return Job{}, err
 	}
@@ -192,8 +488,38 @@ func (sd *sdAPI) Job(jobName, filepath string) (Job, error) {
 	return job[0], nil
 }
 
+// Jobs returns all the jobs in screwdriver.yaml by name. The jobs with the matrix are named with their combinations like main[NODE_VERSION=12].
+func (sd *sdAPI) Jobs(filepath string) (map[string]Job, error) {
+	return sd.JobsContext(context.Background(), filepath)
+}
+
+// JobsContext is Jobs whose request is cancelled when ctx is done
+func (sd *sdAPI) JobsContext(ctx context.Context, filepath string) (map[string]Job, error) {
+	jobs, err := sd.validate(ctx, filepath)
+	if err != nil {
+		return nil, err
+	}
+
+	// the jobs with the matrix are parsed into the permutations, which run as the jobs of their combinations
+	m := make(map[string]Job, len(jobs))
+	for name, permutations := range jobs {
+		if len(permutations) != 0 {
+			for n, job := range matrixJobs(name, permutations) {
+				m[n] = job
+			}
+		}
+	}
+
+	return m, nil
+}
+
 func (sd *sdAPI) InitJWT() error {
-	jwt, err := sd.jwt()
+	return sd.InitJWTContext(context.Background())
+}
+
+// InitJWTContext is InitJWT whose request is cancelled when ctx is done
+func (sd *sdAPI) InitJWTContext(ctx context.Context) error {
+	jwt, err := sd.jwt(ctx)
 	if err != nil {
 		return err
 	}
@@ -1,14 +1,20 @@
 package screwdriver
 
 import (
+	"bytes"
+	"context"
+	"encoding/pem"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -29,14 +35,88 @@ func TestNew(t *testing.T) {
 		testToken := "token"
 
 		ua := "sd-local/dev (linux; eb004dc1-614c-11eb-bab9-0242ac120002)"
-		gotAPI := New("http://example.com:yyy", testToken, ua)
+		gotAPI := New("http://example.com:yyy", testToken, ua, http.DefaultClient)
 		api, ok := gotAPI.(*sdAPI)
 		assert.True(t, ok)
+		assert.Equal(t, http.DefaultClient, api.HTTPClient)
 		assert.Equal(t, testToken, api.UserToken)
 		assert.Equal(t, "http://example.com:yyy", api.APIURL)
 	})
 }
 
+func TestNewHTTPClient(t *testing.T) {
+	t.Run("success without option", func(t *testing.T) {
+		client, err := NewHTTPClient(HTTPClientOption{})
+		assert.Nil(t, err)
+		assert.Equal(t, http.DefaultClient, client)
+	})
+
+	t.Run("success with proxies", func(t *testing.T) {
+		client, err := NewHTTPClient(HTTPClientOption{
+			HTTPProxy:  "http://proxy.example.com:8080",
+			HTTPSProxy: "http://secure-proxy.example.com:8443",
+		})
+		assert.Nil(t, err)
+
+		transport, ok := client.Transport.(*http.Transport)
+		assert.True(t, ok)
+
+		for target, expected := range map[string]string{
+			"http://api.example.com/v4/validator":  "http://proxy.example.com:8080",
+			"https://api.example.com/v4/validator": "http://secure-proxy.example.com:8443",
+		} {
+			req := httptest.NewRequest(http.MethodGet, target, nil)
+			proxy, err := transport.Proxy(req)
+			assert.Nil(t, err)
+			assert.Equal(t, expected, proxy.String())
+		}
+	})
+
+	t.Run("success with CA bundle", func(t *testing.T) {
+		server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(200)
+		}))
+		defer server.Close()
+
+		caBundle, err := ioutil.TempFile("", "ca.pem")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.Remove(caBundle.Name())
+		err = pem.Encode(caBundle, &pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+		if err != nil {
+			t.Fatal(err)
+		}
+		caBundle.Close()
+
+		client, err := NewHTTPClient(HTTPClientOption{CABundle: caBundle.Name()})
+		assert.Nil(t, err)
+
+		res, err := client.Get(server.URL)
+		assert.Nil(t, err)
+		assert.Equal(t, 200, res.StatusCode)
+	})
+
+	t.Run("failure by CA bundle that does not exist", func(t *testing.T) {
+		_, err := NewHTTPClient(HTTPClientOption{CABundle: "./not-exist"})
+		assert.NotNil(t, err)
+		assert.Equal(t, 0, strings.Index(err.Error(), "failed to read CA bundle: "), fmt.Sprintf("expected error is `failed to read CA bundle: ...`, actual: `%v`", err))
+	})
+
+	t.Run("failure by CA bundle without certificates", func(t *testing.T) {
+		_, err := NewHTTPClient(HTTPClientOption{CABundle: filepath.Join(testDir, "screwdriver.yaml")})
+		assert.Equal(t, fmt.Errorf("failed to read CA bundle: no certificates found in %s", filepath.Join(testDir, "screwdriver.yaml")), err)
+	})
+
+	t.Run("failure by invalid proxy", func(t *testing.T) {
+		_, err := NewHTTPClient(HTTPClientOption{
+			HTTPProxy: "http://proxy.example.com:yyy",
+		})
+		assert.NotNil(t, err)
+		assert.Equal(t, 0, strings.Index(err.Error(), "failed to parse http proxy: "), fmt.Sprintf("expected error is `failed to parse http proxy: ...`, actual: `%v`", err))
+	})
+}
+
 func TestJob(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
 		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -228,6 +308,220 @@ func TestJob(t *testing.T) {
 	})
 }
 
+func TestJobs(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(200)
+			w.Header().Set("Content-Type", "application/json")
+
+			testJSON, err := ioutil.ReadFile(filepath.Join(testDir, "validatedPipeline.json"))
+			assert.Nil(t, err)
+			fmt.Fprintln(w, string(testJSON))
+		}))
+
+		testAPI := sdAPI{
+			HTTPClient: http.DefaultClient,
+			UserToken:  "dummy",
+			APIURL:     server.URL,
+			SDJWT:      "jwt",
+		}
+
+		testJobs := map[string]Job{
+			"main": {
+				Steps:       []Step{{Name: "test", Command: "echo test"}},
+				Environment: map[string]string{},
+				Image:       "alpine",
+				Requires:    []string{"~commit", "~pr"},
+				Annotations: map[string]interface{}{"screwdriver.cd/cpu": "HIGH", "screwdriver.cd/ram/test": 8.0},
+			},
+			"publish": {
+				Steps:       []Step{{Name: "publish", Command: "echo publish"}},
+				Environment: map[string]string{},
+				Image:       "alpine",
+				Requires:    []string{"main"},
+			},
+		}
+
+		gotJobs, err := testAPI.Jobs(filepath.Join(testDir, "screwdriver.yaml"))
+		assert.Nil(t, err)
+		assert.Equal(t, testJobs, gotJobs)
+	})
+
+	t.Run("success with matrix", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			testJSON, err := ioutil.ReadFile(filepath.Join(testDir, "validatedMatrix.json"))
+			assert.Nil(t, err)
+			fmt.Fprintln(w, string(testJSON))
+		}))
+		defer server.Close()
+
+		testAPI := sdAPI{
+			HTTPClient: http.DefaultClient,
+			UserToken:  "dummy",
+			APIURL:     server.URL,
+			SDJWT:      "jwt",
+		}
+
+		testJobs := map[string]Job{
+			"main[NODE_VERSION=12]": {
+				Steps:       []Step{{Name: "test", Command: "npm test"}},
+				Environment: map[string]string{"NODE_VERSION": "12", "OS": "linux", "CI": "true"},
+				Image:       "node:$NODE_VERSION",
+				Matrix:      map[string]string{"NODE_VERSION": "12"},
+			},
+			"main[NODE_VERSION=14]": {
+				Steps:       []Step{{Name: "test", Command: "npm test"}},
+				Environment: map[string]string{"NODE_VERSION": "14", "OS": "linux", "CI": "true"},
+				Image:       "node:$NODE_VERSION",
+				Matrix:      map[string]string{"NODE_VERSION": "14"},
+			},
+			"publish": {
+				Steps:       []Step{{Name: "publish", Command: "npm publish"}},
+				Environment: map[string]string{},
+				Image:       "node:14",
+				Requires:    []string{"main"},
+			},
+		}
+
+		gotJobs, err := testAPI.Jobs(filepath.Join(testDir, "screwdriver.yaml"))
+		assert.Nil(t, err)
+		assert.Equal(t, testJobs, gotJobs)
+	})
+
+	t.Run("failure by reading screwdriver.yaml", func(t *testing.T) {
+		testAPI := sdAPI{
+			HTTPClient: http.DefaultClient,
+			UserToken:  "dummy",
+			APIURL:     "http://example.com",
+			SDJWT:      "jwt",
+		}
+
+		_, err := testAPI.Jobs("./not-exist")
+		assert.NotNil(t, err)
+
+		msg := err.Error()
+		assert.Equal(t, 0, strings.Index(msg, "failed to read screwdriver.yaml: "), fmt.Sprintf("expected error is `failed to read screwdriver.yaml: ...`, actual: `%v`", msg))
+	})
+
+	t.Run("failure by the cancelled context", func(t *testing.T) {
+		stop := make(chan struct{})
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			<-stop
+		}))
+		defer server.Close()
+		defer close(stop)
+
+		testAPI := sdAPI{
+			HTTPClient: http.DefaultClient,
+			UserToken:  "dummy",
+			APIURL:     server.URL,
+			SDJWT:      "jwt",
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		_, err := testAPI.JobsContext(ctx, filepath.Join(testDir, "screwdriver.yaml"))
+		assert.Contains(t, err.Error(), "failed to send request: ")
+		assert.Contains(t, err.Error(), "context deadline exceeded")
+	})
+}
+
+func TestJobNames(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		names, err := JobNames(filepath.Join(testDir, "screwdriver.yaml"))
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"main"}, names)
+	})
+
+	t.Run("failure by invalid yaml", func(t *testing.T) {
+		_, err := JobNames(filepath.Join(testDir, "screwdriverInvalid.yaml"))
+		assert.Contains(t, err.Error(), "failed to parse screwdriver.yaml")
+	})
+
+	t.Run("failure by missing file", func(t *testing.T) {
+		_, err := JobNames(filepath.Join(testDir, "missing.yaml"))
+		assert.Contains(t, err.Error(), "failed to read screwdriver.yaml")
+	})
+}
+
+func TestSelectSteps(t *testing.T) {
+	job := Job{
+		Steps: []Step{
+			{Name: "install", Command: "npm install"},
+			{Name: "test", Command: "npm test"},
+			{Name: "lint", Command: "npm run lint"},
+			{Name: "teardown-report", Command: "npm run report"},
+		},
+		Image: "node:12",
+	}
+
+	cases := map[string]struct {
+		names     []string
+		expectJob Job
+		expectErr error
+	}{
+		"success": {
+			names: []string{"lint", "test"},
+			expectJob: Job{
+				Steps: []Step{
+					{Name: "test", Command: "npm test"},
+					{Name: "lint", Command: "npm run lint"},
+					{Name: "teardown-report", Command: "npm run report"},
+				},
+				Image: "node:12",
+			},
+		},
+		"failure by step that does not exist": {
+			names:     []string{"test", "build"},
+			expectErr: fmt.Errorf("not found step 'build' in the job, valid steps are: install, test, lint, teardown-report"),
+		},
+	}
+
+	for name, test := range cases {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := job.SelectSteps(test.names)
+			assert.Equal(t, test.expectErr, err)
+			if test.expectErr == nil {
+				assert.Equal(t, test.expectJob, got)
+			}
+		})
+	}
+}
+
+func TestPlannedSteps(t *testing.T) {
+	job := Job{
+		Steps: []Step{
+			{Name: "install", Command: "npm install"},
+			{Name: "teardown-report", Command: "npm run report"},
+			{Name: "test", Command: "npm test"},
+			{Name: "teardown-clean", Command: "npm run clean"},
+		},
+	}
+
+	assert.Equal(t, []Step{
+		{Name: "install", Command: "npm install"},
+		{Name: "test", Command: "npm test"},
+		{Name: "teardown-report", Command: "npm run report"},
+		{Name: "teardown-clean", Command: "npm run clean"},
+	}, job.PlannedSteps())
+}
+
+func TestStepPhase(t *testing.T) {
+	cases := map[string]string{
+		"sd-setup-scm":          PhaseSetup,
+		"install":               PhaseUser,
+		"teardown-report":       PhaseTeardown,
+		"sd-teardown-artifacts": PhaseTeardown,
+	}
+
+	for name, expect := range cases {
+		assert.Equal(t, expect, StepPhase(name), name)
+	}
+}
+
 func TestInitJWT(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
 		testJWT := "jwt"
@@ -257,6 +551,33 @@ func TestInitJWT(t *testing.T) {
 		assert.Equal(t, testJWT, s.JWT())
 	})
 
+	t.Run("success logs the request at debug level without the token", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintln(w, `{"token": "jwt"}`)
+		}))
+		defer server.Close()
+
+		buf := bytes.NewBuffer(nil)
+		logrus.SetOutput(buf)
+		logrus.SetLevel(logrus.DebugLevel)
+		defer func() {
+			logrus.SetOutput(os.Stderr)
+			logrus.SetLevel(logrus.InfoLevel)
+		}()
+
+		s := &sdAPI{
+			HTTPClient: http.DefaultClient,
+			APIURL:     server.URL,
+			UserToken:  "sd-token",
+		}
+
+		err := s.InitJWT()
+		assert.Nil(t, err)
+		assert.Contains(t, buf.String(), fmt.Sprintf("--> GET %s/v4/auth/token", server.URL))
+		assert.Contains(t, buf.String(), fmt.Sprintf("<-- GET %s/v4/auth/token: 200 OK in ", server.URL))
+		assert.NotContains(t, buf.String(), "sd-token")
+	})
+
 	t.Run("failure by invalid JSON", func(t *testing.T) {
 		testToken := "token"
 		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
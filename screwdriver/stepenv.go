@@ -0,0 +1,125 @@
+package screwdriver
+
+import (
+	"fmt"
+
+	"github.com/go-yaml/yaml"
+)
+
+// sharedJobName is the key of the steps of shared in StepEnvironments, which are used by the jobs without the steps
+const sharedJobName = ""
+
+// StepEnvironments is the environment of the steps by the job name and the step name
+type StepEnvironments map[string]map[string]map[string]string
+
+// stepOf returns the command and the environment of the step like {command: npm test, environment: {NODE_ENV: test}}
+func stepOf(step yaml.MapSlice) (string, map[string]string) {
+	var command string
+	var environment map[string]string
+	for _, item := range step {
+		switch fmt.Sprint(item.Key) {
+		case "command":
+			command, _ = item.Value.(string)
+		case "environment":
+			env, _ := item.Value.(yaml.MapSlice)
+			environment = make(map[string]string, len(env))
+			for _, e := range env {
+				environment[fmt.Sprint(e.Key)] = fmt.Sprint(e.Value)
+			}
+		}
+	}
+	return command, environment
+}
+
+// extractSteps replaces the steps of the mappings of the commands and the environment with the mappings of the names to the commands.
+// It returns the environment of the steps by the step name and whether any step is replaced.
+func extractSteps(steps []interface{}) (map[string]map[string]string, bool) {
+	envs := make(map[string]map[string]string)
+	replaced := false
+	for i, s := range steps {
+		step, ok := s.(yaml.MapSlice)
+		if !ok || len(step) != 1 {
+			continue
+		}
+		m, ok := step[0].Value.(yaml.MapSlice)
+		if !ok {
+			continue
+		}
+		name := fmt.Sprint(step[0].Key)
+		command, environment := stepOf(m)
+		steps[i] = yaml.MapSlice{{Key: name, Value: command}}
+		replaced = true
+		if len(environment) != 0 {
+			envs[name] = environment
+		}
+	}
+	return envs, replaced
+}
+
+// ExtractStepEnvironments removes the environment of the steps of the jobs and shared in screwdriver.yaml, which the validator
+// of the API doesn't accept, and returns screwdriver.yaml with the steps of only the commands and the removed environment.
+// The content is returned as it is if no step is a mapping of the command and the environment.
+func ExtractStepEnvironments(content []byte) ([]byte, StepEnvironments, error) {
+	var pipeline yaml.MapSlice
+	if err := yaml.Unmarshal(content, &pipeline); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse screwdriver.yaml: %v", err)
+	}
+
+	// the jobs with their own steps are recorded even without the environment not to use the one of shared
+	envs := make(StepEnvironments)
+	replaced := false
+	extract := func(jobName string, job interface{}) {
+		j, _ := job.(yaml.MapSlice)
+		steps, ok := mapValue(j, "steps")
+		if !ok {
+			return
+		}
+		list, _ := steps.([]interface{})
+		e, r := extractSteps(list)
+		envs[jobName] = e
+		replaced = replaced || r
+	}
+
+	if shared, ok := mapValue(pipeline, "shared"); ok {
+		extract(sharedJobName, shared)
+	}
+	v, _ := mapValue(pipeline, "jobs")
+	jobs, _ := v.(yaml.MapSlice)
+	for _, item := range jobs {
+		extract(fmt.Sprint(item.Key), item.Value)
+	}
+
+	if !replaced {
+		return content, StepEnvironments{}, nil
+	}
+	extracted, err := yaml.Marshal(pipeline)
+	if err != nil {
+		return nil, nil, err
+	}
+	return extracted, envs, nil
+}
+
+// Apply sets the environment of the steps to the steps of the jobs parsed by the API with the same names.
+// The steps of the combinations of the matrix get the environment of the job in screwdriver.yaml,
+// and the steps of the jobs without their own environment get the one of shared.
+func (e StepEnvironments) Apply(jobs map[string]Job) {
+	for name, job := range jobs {
+		envs, ok := e[BaseJobName(name)]
+		if !ok {
+			envs = e[sharedJobName]
+		}
+		if len(envs) == 0 {
+			continue
+		}
+
+		steps := make([]Step, 0, len(job.Steps))
+		for _, s := range job.Steps {
+			if env, ok := envs[s.Name]; ok {
+				s.Environment = env
+			}
+			steps = append(steps, s)
+		}
+		job.Steps = steps
+		jobs[name] = job
+	}
+}
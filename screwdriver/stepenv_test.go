@@ -0,0 +1,131 @@
+package screwdriver
+
+import (
+	"testing"
+
+	"github.com/go-yaml/yaml"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtractStepEnvironments(t *testing.T) {
+	testCases := []struct {
+		name         string
+		yaml         string
+		expected     string
+		expectedEnvs StepEnvironments
+	}{
+		{
+			name: "extract the environment of the steps of the jobs and shared",
+			yaml: `shared:
+  steps:
+    - test:
+        command: npm test
+        environment:
+          NODE_ENV: test
+jobs:
+  main:
+    image: node:12
+    steps:
+      - install: npm install
+      - build:
+          command: npm run build
+          environment:
+            NODE_ENV: production
+            DEBUG: "true"
+  lint:
+    steps:
+      - lint: npm run lint
+  publish: {}
+`,
+			expected: `shared:
+  steps:
+  - test: npm test
+jobs:
+  main:
+    image: node:12
+    steps:
+    - install: npm install
+    - build: npm run build
+  lint:
+    steps:
+    - lint: npm run lint
+  publish: {}
+`,
+			expectedEnvs: StepEnvironments{
+				"":     {"test": {"NODE_ENV": "test"}},
+				"main": {"build": {"NODE_ENV": "production", "DEBUG": "true"}},
+				"lint": {},
+			},
+		},
+		{
+			name: "without the environment of the steps",
+			yaml: `jobs:
+  main:
+    steps:
+      - test: go test ./...
+`,
+			expected: `jobs:
+  main:
+    steps:
+      - test: go test ./...
+`,
+			expectedEnvs: StepEnvironments{},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			actual, envs, err := ExtractStepEnvironments([]byte(tt.yaml))
+			assert.Nil(t, err)
+			assert.Equal(t, tt.expected, string(actual))
+			assert.Equal(t, tt.expectedEnvs, envs)
+		})
+	}
+
+	t.Run("invalid yaml", func(t *testing.T) {
+		_, _, err := ExtractStepEnvironments([]byte("jobs: ["))
+		assert.Contains(t, err.Error(), "failed to parse screwdriver.yaml: ")
+	})
+}
+
+func TestStepEnvironmentsApply(t *testing.T) {
+	envs := StepEnvironments{
+		"":     {"test": {"NODE_ENV": "test"}},
+		"main": {"build": {"NODE_ENV": "production"}},
+		"lint": {},
+	}
+	jobs := map[string]Job{
+		"main[NODE_VERSION=12]": {Steps: []Step{{Name: "install", Command: "npm install"}, {Name: "build", Command: "npm run build"}}},
+		"lint":                  {Steps: []Step{{Name: "test", Command: "npm run lint"}}},
+		"publish":               {Steps: []Step{{Name: "test", Command: "npm test"}}},
+	}
+
+	envs.Apply(jobs)
+	expected := map[string]Job{
+		"main[NODE_VERSION=12]": {Steps: []Step{
+			{Name: "install", Command: "npm install"},
+			{Name: "build", Command: "npm run build", Environment: map[string]string{"NODE_ENV": "production"}},
+		}},
+		"lint":    {Steps: []Step{{Name: "test", Command: "npm run lint"}}},
+		"publish": {Steps: []Step{{Name: "test", Command: "npm test", Environment: map[string]string{"NODE_ENV": "test"}}}},
+	}
+	assert.Equal(t, expected, jobs)
+}
+
+func TestJobUnmarshalStepEnvironment(t *testing.T) {
+	var job Job
+	err := yaml.Unmarshal([]byte(`image: node:12
+steps:
+  - install: npm install
+  - test:
+      command: npm test
+      environment:
+        NODE_ENV: test
+        PORT: 8080
+`), &job)
+	assert.Nil(t, err)
+	assert.Equal(t, []Step{
+		{Name: "install", Command: "npm install"},
+		{Name: "test", Command: "npm test", Environment: map[string]string{"NODE_ENV": "test", "PORT": "8080"}},
+	}, job.Steps)
+}
@@ -0,0 +1,292 @@
+package screwdriver
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/go-yaml/yaml"
+)
+
+// templateEndpoint is the endpoint of the templates, which is followed by the escaped name and the version or tag
+const templateEndpoint = "templates"
+
+// latestTag is the tag of the template used if the version is omitted like template: foo/bar
+const latestTag = "latest"
+
+// TemplateAPI is the API which returns the job templates
+type TemplateAPI interface {
+	Template(ctx context.Context, name, version string) (Template, error)
+}
+
+var _ TemplateAPI = (*sdAPI)(nil)
+
+// Template is the job template, whose config is merged into the jobs using it
+type Template struct {
+	Namespace string `yaml:"namespace"`
+	Name      string `yaml:"name"`
+	// Version is the exact version the requested version or tag is resolved to
+	Version string `yaml:"version"`
+	// Config is the config of the job like the steps, the image and the environment
+	Config yaml.MapSlice `yaml:"config"`
+}
+
+// FullName returns the name of the template with the namespace like foo/bar
+func (t Template) FullName() string {
+	if t.Namespace == "" {
+		return t.Name
+	}
+	return t.Namespace + "/" + t.Name
+}
+
+// Template returns the job template. The name can be prefixed with the namespace like foo/bar,
+// and the version can be a tag like stable as well.
+func (sd *sdAPI) Template(ctx context.Context, name, version string) (Template, error) {
+	ref := name + "@" + version
+	fullpath, err := sd.makeURL(templateEndpoint)
+	if err != nil {
+		return Template{}, fmt.Errorf("failed to make request url: %v", err)
+	}
+	// the slash between the namespace and the name is escaped to be a path segment
+	fullpath.RawPath = fullpath.EscapedPath() + "/" + url.PathEscape(name) + "/" + url.PathEscape(version)
+	fullpath.Path = fullpath.Path + "/" + name + "/" + version
+
+	res, err := sd.request(ctx, http.MethodGet, fullpath.String(), nil)
+	if err != nil {
+		return Template{}, fmt.Errorf("failed to send request: %v", err)
+	}
+	defer res.Body.Close()
+
+	switch res.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return Template{}, fmt.Errorf("template %s is not found", ref)
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return Template{}, fmt.Errorf("not authorized to get template %s: StatusCode %d", ref, res.StatusCode)
+	default:
+		return Template{}, fmt.Errorf("failed to get template %s: StatusCode %d", ref, res.StatusCode)
+	}
+
+	// the config is decoded by the YAML decoder to keep the order of the keys, since JSON is YAML
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return Template{}, fmt.Errorf("failed to get template %s: %v", ref, err)
+	}
+	var template Template
+	if err := yaml.Unmarshal(body, &template); err != nil {
+		return Template{}, fmt.Errorf("failed to parse template %s: %v", ref, err)
+	}
+	return template, nil
+}
+
+// parseTemplateRef returns the name and the version of the template like foo/bar@1.0.0.
+// The latest version is used if it is omitted.
+func parseTemplateRef(ref string) (string, string) {
+	i := strings.LastIndex(ref, "@")
+	if i < 0 {
+		return ref, latestTag
+	}
+	return ref[:i], ref[i+1:]
+}
+
+// mapValue returns the value of the key in the mapping
+func mapValue(m yaml.MapSlice, key string) (interface{}, bool) {
+	for _, item := range m {
+		if fmt.Sprint(item.Key) == key {
+			return item.Value, true
+		}
+	}
+	return nil, false
+}
+
+// setMapValue sets the value of the key in the mapping, which is appended if the key is not in it
+func setMapValue(m yaml.MapSlice, key string, value interface{}) yaml.MapSlice {
+	for i, item := range m {
+		if fmt.Sprint(item.Key) == key {
+			m[i].Value = value
+			return m
+		}
+	}
+	return append(m, yaml.MapItem{Key: key, Value: value})
+}
+
+// removeMapKey returns the mapping without the key
+func removeMapKey(m yaml.MapSlice, key string) yaml.MapSlice {
+	removed := make(yaml.MapSlice, 0, len(m))
+	for _, item := range m {
+		if fmt.Sprint(item.Key) != key {
+			removed = append(removed, item)
+		}
+	}
+	return removed
+}
+
+// mergeMaps returns the mapping of the template overridden by the one of the job
+func mergeMaps(template, job interface{}) yaml.MapSlice {
+	merged := yaml.MapSlice{}
+	if m, ok := template.(yaml.MapSlice); ok {
+		merged = append(merged, m...)
+	}
+	if m, ok := job.(yaml.MapSlice); ok {
+		for _, item := range m {
+			merged = setMapValue(merged, fmt.Sprint(item.Key), item.Value)
+		}
+	}
+	return merged
+}
+
+// mergeLists returns the items of the template and the job without the duplicates
+func mergeLists(template, job interface{}) []interface{} {
+	merged := make([]interface{}, 0)
+	found := make(map[string]bool)
+	for _, list := range []interface{}{template, job} {
+		items, _ := list.([]interface{})
+		for _, item := range items {
+			if key := fmt.Sprint(item); !found[key] {
+				found[key] = true
+				merged = append(merged, item)
+			}
+		}
+	}
+	return merged
+}
+
+// stepName returns the name of the step like {install: npm install}
+func stepName(step interface{}) string {
+	s, ok := step.(yaml.MapSlice)
+	if !ok || len(s) != 1 {
+		return ""
+	}
+	return fmt.Sprint(s[0].Key)
+}
+
+// mergeSteps returns the steps of the template overridden by the steps of the job with the same names.
+// The steps of the job named pre<step> and post<step> run before and after the step of the template.
+func mergeSteps(jobName string, template Template, templateSteps, jobSteps interface{}) ([]interface{}, error) {
+	tSteps, _ := templateSteps.([]interface{})
+	jSteps, _ := jobSteps.([]interface{})
+
+	overrides := make(map[string]interface{}, len(jSteps))
+	for _, step := range jSteps {
+		overrides[stepName(step)] = step
+	}
+
+	merged := make([]interface{}, 0, len(tSteps)+len(jSteps))
+	used := make(map[string]bool, len(jSteps))
+	for _, step := range tSteps {
+		name := stepName(step)
+		if pre, ok := overrides["pre"+name]; ok {
+			merged = append(merged, pre)
+			used["pre"+name] = true
+		}
+		if override, ok := overrides[name]; ok {
+			merged = append(merged, override)
+			used[name] = true
+		} else {
+			merged = append(merged, step)
+		}
+		if post, ok := overrides["post"+name]; ok {
+			merged = append(merged, post)
+			used["post"+name] = true
+		}
+	}
+
+	for _, step := range jSteps {
+		if name := stepName(step); !used[name] {
+			return nil, fmt.Errorf("step %s of job %s is not in template %s, only the steps of the template and their pre and post steps can be defined",
+				name, jobName, template.FullName())
+		}
+	}
+	return merged, nil
+}
+
+// mergeTemplate merges the config of the template into the job in the same way as Screwdriver.
+// The environment, the annotations and the settings are merged and the secrets are joined,
+// and the others of the job like the image override the ones of the template.
+func mergeTemplate(jobName string, job yaml.MapSlice, template Template) (yaml.MapSlice, error) {
+	merged := append(yaml.MapSlice{}, template.Config...)
+	for _, item := range removeMapKey(job, "template") {
+		key := fmt.Sprint(item.Key)
+		current, _ := mapValue(merged, key)
+		switch key {
+		case "environment", "annotations", "settings":
+			merged = setMapValue(merged, key, mergeMaps(current, item.Value))
+		case "secrets":
+			merged = setMapValue(merged, key, mergeLists(current, item.Value))
+		case "steps":
+			steps, err := mergeSteps(jobName, template, current, item.Value)
+			if err != nil {
+				return nil, err
+			}
+			merged = setMapValue(merged, key, steps)
+		default:
+			merged = setMapValue(merged, key, item.Value)
+		}
+	}
+
+	// the steps can know the template they run in as well as in Screwdriver
+	environment, _ := mapValue(merged, "environment")
+	merged = setMapValue(merged, "environment", mergeMaps(yaml.MapSlice{
+		{Key: "SD_TEMPLATE_FULLNAME", Value: template.FullName()},
+		{Key: "SD_TEMPLATE_NAME", Value: template.Name},
+		{Key: "SD_TEMPLATE_NAMESPACE", Value: template.Namespace},
+		{Key: "SD_TEMPLATE_VERSION", Value: template.Version},
+	}, environment))
+	return merged, nil
+}
+
+// ExpandTemplates merges the job templates referred by `template` of the jobs and shared in screwdriver.yaml
+// into the jobs with the API. The jobs with their own template don't use the one of shared.
+func ExpandTemplates(ctx context.Context, api TemplateAPI, content []byte) ([]byte, error) {
+	var pipeline yaml.MapSlice
+	if err := yaml.Unmarshal(content, &pipeline); err != nil {
+		return nil, fmt.Errorf("failed to parse screwdriver.yaml: %v", err)
+	}
+
+	var sharedRef string
+	if v, ok := mapValue(pipeline, "shared"); ok {
+		if shared, ok := v.(yaml.MapSlice); ok {
+			if ref, ok := mapValue(shared, "template"); ok {
+				sharedRef = fmt.Sprint(ref)
+				pipeline = setMapValue(pipeline, "shared", removeMapKey(shared, "template"))
+			}
+		}
+	}
+
+	templates := make(map[string]Template)
+	v, _ := mapValue(pipeline, "jobs")
+	jobs, _ := v.(yaml.MapSlice)
+	for i, item := range jobs {
+		jobName := fmt.Sprint(item.Key)
+		job, _ := item.Value.(yaml.MapSlice)
+		ref := sharedRef
+		if v, ok := mapValue(job, "template"); ok {
+			ref = fmt.Sprint(v)
+		}
+		if ref == "" {
+			continue
+		}
+
+		template, ok := templates[ref]
+		if !ok {
+			name, version := parseTemplateRef(ref)
+			var err error
+			template, err = api.Template(ctx, name, version)
+			if err != nil {
+				return nil, err
+			}
+			templates[ref] = template
+		}
+
+		merged, err := mergeTemplate(jobName, job, template)
+		if err != nil {
+			return nil, err
+		}
+		jobs[i].Value = merged
+	}
+
+	return yaml.Marshal(pipeline)
+}
@@ -0,0 +1,232 @@
+package screwdriver
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-yaml/yaml"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockTemplateAPI map[string]Template
+
+func (m mockTemplateAPI) Template(ctx context.Context, name, version string) (Template, error) {
+	t, ok := m[name+"@"+version]
+	if !ok {
+		return Template{}, fmt.Errorf("template %s@%s is not found", name, version)
+	}
+	return t, nil
+}
+
+func TestTemplate(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/v4/templates/foo%2Fbar/stable", r.RequestURI)
+			validateHeader(t, "Authorization", "Bearer jwt", r)
+			fmt.Fprintln(w, `{"namespace": "foo", "name": "bar", "version": "1.0.2", "config": {"image": "node:12", "steps": [{"install": "npm install"}, {"test": "npm test"}]}}`)
+		}))
+		defer server.Close()
+
+		testAPI := sdAPI{HTTPClient: http.DefaultClient, APIURL: server.URL, SDJWT: "jwt"}
+
+		template, err := testAPI.Template(context.Background(), "foo/bar", "stable")
+		assert.Nil(t, err)
+		expected := Template{
+			Namespace: "foo",
+			Name:      "bar",
+			Version:   "1.0.2",
+			Config: yaml.MapSlice{
+				{Key: "image", Value: "node:12"},
+				{Key: "steps", Value: []interface{}{
+					yaml.MapSlice{{Key: "install", Value: "npm install"}},
+					yaml.MapSlice{{Key: "test", Value: "npm test"}},
+				}},
+			},
+		}
+		assert.Equal(t, expected, template)
+		assert.Equal(t, "foo/bar", template.FullName())
+	})
+
+	testCases := []struct {
+		name     string
+		status   int
+		body     string
+		expected string
+	}{
+		{name: "not found", status: http.StatusNotFound, expected: "template foo/bar@stable is not found"},
+		{name: "forbidden", status: http.StatusForbidden, expected: "not authorized to get template foo/bar@stable: StatusCode 403"},
+		{name: "server error", status: http.StatusInternalServerError, expected: "failed to get template foo/bar@stable: StatusCode 500"},
+		{name: "invalid JSON", status: http.StatusOK, body: `{"config": [}`, expected: "failed to parse template foo/bar@stable: yaml: did not find expected node content"},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				fmt.Fprint(w, tt.body)
+			}))
+			defer server.Close()
+
+			testAPI := sdAPI{HTTPClient: http.DefaultClient, APIURL: server.URL, SDJWT: "jwt"}
+
+			_, err := testAPI.Template(context.Background(), "foo/bar", "stable")
+			assert.Equal(t, tt.expected, err.Error())
+		})
+	}
+}
+
+func TestExpandTemplates(t *testing.T) {
+	api := mockTemplateAPI{
+		"foo/bar@latest": {
+			Namespace: "foo",
+			Name:      "bar",
+			Version:   "1.0.2",
+			Config: yaml.MapSlice{
+				{Key: "image", Value: "node:12"},
+				{Key: "steps", Value: []interface{}{
+					yaml.MapSlice{{Key: "install", Value: "npm install"}},
+					yaml.MapSlice{{Key: "test", Value: "npm test"}},
+				}},
+				{Key: "environment", Value: yaml.MapSlice{{Key: "NODE_ENV", Value: "test"}, {Key: "CI", Value: "true"}}},
+				{Key: "secrets", Value: []interface{}{"NPM_TOKEN"}},
+			},
+		},
+		"baz@1": {
+			Name:    "baz",
+			Version: "1.1.0",
+			Config: yaml.MapSlice{
+				{Key: "image", Value: "golang"},
+				{Key: "steps", Value: []interface{}{yaml.MapSlice{{Key: "build", Value: "go build"}}}},
+			},
+		},
+	}
+
+	testCases := []struct {
+		name     string
+		yaml     string
+		expected string
+		err      string
+	}{
+		{
+			name: "merge the template into the job",
+			yaml: `jobs:
+  main:
+    template: foo/bar
+    image: node:14
+    requires: [~commit]
+    environment:
+      NODE_ENV: production
+    secrets: [NPM_TOKEN, GH_TOKEN]
+    steps:
+      - preinstall: npm config list
+      - test: npm run test:ci
+      - posttest: npm run lint
+`,
+			expected: `jobs:
+  main:
+    image: node:14
+    steps:
+    - preinstall: npm config list
+    - install: npm install
+    - test: npm run test:ci
+    - posttest: npm run lint
+    environment:
+      SD_TEMPLATE_FULLNAME: foo/bar
+      SD_TEMPLATE_NAME: bar
+      SD_TEMPLATE_NAMESPACE: foo
+      SD_TEMPLATE_VERSION: 1.0.2
+      NODE_ENV: production
+      CI: "true"
+    secrets:
+    - NPM_TOKEN
+    - GH_TOKEN
+    requires:
+    - ~commit
+`,
+		},
+		{
+			name: "use the template of shared",
+			yaml: `shared:
+  template: foo/bar
+jobs:
+  main: {}
+  build:
+    template: baz@1
+`,
+			expected: `shared: {}
+jobs:
+  main:
+    image: node:12
+    steps:
+    - install: npm install
+    - test: npm test
+    environment:
+      SD_TEMPLATE_FULLNAME: foo/bar
+      SD_TEMPLATE_NAME: bar
+      SD_TEMPLATE_NAMESPACE: foo
+      SD_TEMPLATE_VERSION: 1.0.2
+      NODE_ENV: test
+      CI: "true"
+    secrets:
+    - NPM_TOKEN
+  build:
+    image: golang
+    steps:
+    - build: go build
+    environment:
+      SD_TEMPLATE_FULLNAME: baz
+      SD_TEMPLATE_NAME: baz
+      SD_TEMPLATE_NAMESPACE: ""
+      SD_TEMPLATE_VERSION: 1.1.0
+`,
+		},
+		{
+			name: "without template",
+			yaml: `jobs:
+  main:
+    image: golang
+    steps:
+      - test: go test ./...
+`,
+			expected: `jobs:
+  main:
+    image: golang
+    steps:
+    - test: go test ./...
+`,
+		},
+		{
+			name: "step not in the template",
+			yaml: `jobs:
+  main:
+    template: foo/bar
+    steps:
+      - deploy: npm publish
+`,
+			err: "step deploy of job main is not in template foo/bar, only the steps of the template and their pre and post steps can be defined",
+		},
+		{
+			name: "template not found",
+			yaml: `jobs:
+  main:
+    template: foo/qux@2
+`,
+			err: "template foo/qux@2 is not found",
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			actual, err := ExpandTemplates(context.Background(), api, []byte(tt.yaml))
+			if tt.err != "" {
+				assert.Equal(t, tt.err, err.Error())
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, tt.expected, string(actual))
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package screwdriver
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// TokenValidator is the API which checks the user token before it is used by the builds
+type TokenValidator interface {
+	ValidateToken(ctx context.Context, apiURL, token string) error
+}
+
+var _ TokenValidator = (*sdAPI)(nil)
+
+// NewTokenValidator creates a TokenValidator, which is not bound to an API URL and a token unlike New
+func NewTokenValidator(ua string, client *http.Client) TokenValidator {
+	return &sdAPI{
+		HTTPClient: client,
+		UA:         ua,
+	}
+}
+
+// ValidateToken checks that the API at apiURL accepts the user token by exchanging it for a JWT, which the builds do first.
+func (sd *sdAPI) ValidateToken(ctx context.Context, apiURL, token string) error {
+	api := &sdAPI{
+		HTTPClient: sd.HTTPClient,
+		APIURL:     apiURL,
+		UserToken:  token,
+		UA:         sd.UA,
+	}
+
+	fullpath, err := api.makeURL(tokenEndpoint)
+	if err != nil {
+		return fmt.Errorf("failed to make request url: %v", err)
+	}
+
+	query := fullpath.Query()
+	query.Set("api_token", token)
+	fullpath.RawQuery = query.Encode()
+
+	res, err := api.request(ctx, http.MethodGet, fullpath.String(), nil)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %v", err)
+	}
+	defer res.Body.Close()
+
+	switch res.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return fmt.Errorf("token is rejected by %s: StatusCode %d", apiURL, res.StatusCode)
+	default:
+		return fmt.Errorf("failed to validate the token: StatusCode %d", res.StatusCode)
+	}
+}
@@ -0,0 +1,43 @@
+package screwdriver
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateToken(t *testing.T) {
+	testCases := []struct {
+		name     string
+		status   int
+		expected string
+	}{
+		{name: "success", status: http.StatusOK},
+		{name: "unauthorized", status: http.StatusUnauthorized, expected: "token is rejected by <server>: StatusCode 401"},
+		{name: "forbidden", status: http.StatusForbidden, expected: "token is rejected by <server>: StatusCode 403"},
+		{name: "server error", status: http.StatusInternalServerError, expected: "failed to validate the token: StatusCode 500"},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "/v4/auth/token", r.URL.Path)
+				assert.Equal(t, "user-token", r.URL.Query().Get("api_token"))
+				validateHeader(t, "User-Agent", "sd-local/test", r)
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			err := NewTokenValidator("sd-local/test", http.DefaultClient).ValidateToken(context.Background(), server.URL, "user-token")
+			if tt.expected == "" {
+				assert.Nil(t, err)
+			} else {
+				assert.Equal(t, strings.Replace(tt.expected, "<server>", server.URL, 1), err.Error())
+			}
+		})
+	}
+}
@@ -0,0 +1,326 @@
+package screwdriver
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-yaml/yaml"
+)
+
+// The keys of screwdriver.yaml. Other keys are reported as unknown ones, which are typos in most cases.
+var (
+	pipelineKeys = keySet("annotations", "cache", "childPipelines", "jobs", "parameters", "shared", "stages", "subscribe", "template")
+	jobKeys      = keySet("annotations", "blockedBy", "cache", "description", "environment", "freezeWindows", "image", "order",
+		"parameters", "provider", "requires", "secrets", "settings", "sourcePaths", "stage", "steps", "template")
+)
+
+// triggers are the names of the events which the jobs can require with the prefix ~, like ~pr
+var triggers = keySet("commit", "pr", "pr-closed", "release", "subscribe", "tag")
+
+var (
+	syntaxErrorPattern = regexp.MustCompile(`^yaml: line (\d+): (.*)$`)
+	typeErrorPattern   = regexp.MustCompile(`^line (\d+): (.*)$`)
+	keyPattern         = regexp.MustCompile(`^("[^"]*"|'[^']*'|[^\s#"'][^#]*?)\s*:(\s|$)`)
+	blockScalarPattern = regexp.MustCompile(`^[|>][-+0-9]*\s*(#.*)?$`)
+)
+
+func keySet(keys ...string) map[string]bool {
+	set := make(map[string]bool, len(keys))
+	for _, k := range keys {
+		set[k] = true
+	}
+	return set
+}
+
+// Problem is a problem of screwdriver.yaml found by Validate.
+// Column is 0 if the position in the line is unknown.
+type Problem struct {
+	Line    int
+	Column  int
+	Message string
+}
+
+// position is the position of a key in screwdriver.yaml
+type position struct {
+	line   int
+	column int
+}
+
+// document is the lines of screwdriver.yaml and the positions of the keys of its block mappings,
+// because the YAML decoder doesn't tell the positions of the values
+type document struct {
+	lines []string
+	keys  map[string]position
+}
+
+func keyPath(keys ...string) string {
+	return strings.Join(keys, "\x00")
+}
+
+// unquote returns the key without the quotes
+func unquote(key string) string {
+	if strings.HasPrefix(key, `"`) {
+		if s, err := strconv.Unquote(key); err == nil {
+			return s
+		}
+	}
+	if strings.HasPrefix(key, "'") {
+		return strings.Replace(strings.Trim(key, "'"), "''", "'", -1)
+	}
+	return key
+}
+
+func indentOf(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " "))
+}
+
+// newDocument scans the keys of the block mappings in the lines.
+// The keys in the flow mappings like {a: b} are not scanned and the positions of their parents are used instead.
+func newDocument(content string) document {
+	type key struct {
+		indent int
+		name   string
+	}
+
+	d := document{
+		lines: strings.Split(content, "\n"),
+		keys:  make(map[string]position),
+	}
+	var stack []key
+	blockIndent := -1
+	for i, line := range d.lines {
+		trimmed := strings.TrimSpace(line)
+		indent := indentOf(line)
+		// skip the contents of the block scalars like `command: |`
+		if blockIndent >= 0 {
+			if trimmed == "" || indent > blockIndent {
+				continue
+			}
+			blockIndent = -1
+		}
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") || trimmed == "---" {
+			continue
+		}
+
+		// the keys of the mappings in the list items are indented after the dashes
+		content := line[indent:]
+		for strings.HasPrefix(content, "- ") {
+			content = strings.TrimLeft(content[2:], " ")
+			indent = len(line) - len(content)
+		}
+
+		for len(stack) != 0 && stack[len(stack)-1].indent >= indent {
+			stack = stack[:len(stack)-1]
+		}
+
+		m := keyPattern.FindStringSubmatch(content)
+		if m == nil {
+			continue
+		}
+		stack = append(stack, key{indent: indent, name: unquote(m[1])})
+		names := make([]string, 0, len(stack))
+		for _, k := range stack {
+			names = append(names, k.name)
+		}
+		if _, ok := d.keys[keyPath(names...)]; !ok {
+			d.keys[keyPath(names...)] = position{line: i + 1, column: indent + 1}
+		}
+
+		if blockScalarPattern.MatchString(strings.TrimSpace(content[len(m[0]):])) {
+			blockIndent = indent
+		}
+	}
+	return d
+}
+
+// position returns the position of the key, or its nearest parent if it is not scanned
+func (d document) position(keys ...string) position {
+	for i := len(keys); i > 0; i-- {
+		if p, ok := d.keys[keyPath(keys[:i]...)]; ok {
+			return p
+		}
+	}
+	return position{line: 1, column: 1}
+}
+
+// valuePosition returns the position of the value in the lines of the key like `requires: [main]` and `- main`
+func (d document) valuePosition(value string, keys ...string) position {
+	p := d.position(keys...)
+	pattern := regexp.MustCompile(`(^|[\s\[,'"-])` + regexp.QuoteMeta(value) + `($|[\s\],'"#])`)
+	for i := p.line - 1; i < len(d.lines); i++ {
+		line := d.lines[i]
+		if i != p.line-1 && strings.TrimSpace(line) != "" && indentOf(line) < p.column {
+			break
+		}
+		if loc := pattern.FindStringSubmatchIndex(line); loc != nil && (i != p.line-1 || loc[3] > p.column) {
+			return position{line: i + 1, column: loc[3] + 1}
+		}
+	}
+	return p
+}
+
+// problem returns the problem at the position
+func (p position) problem(format string, a ...interface{}) Problem {
+	return Problem{Line: p.line, Column: p.column, Message: fmt.Sprintf(format, a...)}
+}
+
+// lineProblem returns the problem at the line whose column is the first character of it
+func (d document) lineProblem(line int, message string) Problem {
+	column := 0
+	if line >= 1 && line <= len(d.lines) {
+		column = indentOf(d.lines[line-1]) + 1
+	}
+	return Problem{Line: line, Column: column, Message: message}
+}
+
+// unknownKeys returns the problems of the keys of the mapping which are not in the known keys
+func (d document) unknownKeys(mapping map[interface{}]interface{}, known map[string]bool, where string, keys ...string) []Problem {
+	var problems []Problem
+	for k := range mapping {
+		name := fmt.Sprint(k)
+		if !known[name] {
+			problems = append(problems, d.position(append(keys, name)...).problem("unknown key '%s'%s", name, where))
+		}
+	}
+	return problems
+}
+
+// requiredJob returns the job name of the requires, or empty for the triggers like ~commit and the external jobs like ~sd@123:main.
+// The jobs with the prefix ~ are required by OR.
+func requiredJob(require string) string {
+	name := strings.TrimPrefix(require, "~")
+	if strings.ContainsAny(name, ":@") || (strings.HasPrefix(require, "~") && triggers[name]) {
+		return ""
+	}
+	return name
+}
+
+// Validate parses screwdriver.yaml locally and returns the problems of the schema like the unknown keys,
+// the missing required keys and the requires of the jobs which don't exist, ordered by the position.
+// The error is returned only if the file can't be read.
+func Validate(filePath string) ([]Problem, error) {
+	content, err := readScrewdriverYAML(filePath)
+	if err != nil {
+		return nil, err
+	}
+	d := newDocument(content)
+
+	var raw map[string]interface{}
+	if err := yaml.Unmarshal([]byte(content), &raw); err != nil {
+		if m := syntaxErrorPattern.FindStringSubmatch(err.Error()); m != nil {
+			line, _ := strconv.Atoi(m[1])
+			return []Problem{{Line: line, Message: m[2]}}, nil
+		}
+		return []Problem{{Line: 1, Column: 1, Message: "screwdriver.yaml must be a mapping of the keys like jobs"}}, nil
+	}
+
+	var problems []Problem
+	// the types of the values are validated by decoding the jobs like the build does
+	var pipeline struct {
+		Shared Job            `yaml:"shared"`
+		Jobs   map[string]Job `yaml:"jobs"`
+	}
+	if err := yaml.Unmarshal([]byte(content), &pipeline); err != nil {
+		if terr, ok := err.(*yaml.TypeError); ok {
+			for _, e := range terr.Errors {
+				if m := typeErrorPattern.FindStringSubmatch(e); m != nil {
+					line, _ := strconv.Atoi(m[1])
+					problems = append(problems, d.lineProblem(line, m[2]))
+				}
+			}
+		}
+	}
+
+	problems = append(problems, d.unknownKeys(toMapping(raw), pipelineKeys, "")...)
+
+	shared, _ := raw["shared"].(map[interface{}]interface{})
+	problems = append(problems, d.unknownKeys(shared, jobKeys, " in shared", "shared")...)
+
+	jobs, _ := raw["jobs"].(map[interface{}]interface{})
+	// the jobs are defined by the template of the pipeline if it is used
+	if _, ok := raw["jobs"]; !ok && raw["template"] == nil {
+		problems = append(problems, position{line: 1, column: 1}.problem("missing required key 'jobs'"))
+	} else if ok && len(jobs) == 0 {
+		problems = append(problems, d.position("jobs").problem("no jobs in 'jobs'"))
+	}
+
+	for k, v := range jobs {
+		name := fmt.Sprint(k)
+		job, ok := v.(map[interface{}]interface{})
+		if !ok {
+			// the job without any keys is reported as the missing keys
+			job = map[interface{}]interface{}{}
+		}
+		problems = append(problems, d.unknownKeys(job, jobKeys, fmt.Sprintf(" in job '%s'", name), "jobs", name)...)
+
+		if job["template"] == nil && shared["template"] == nil {
+			for _, required := range []string{"image", "steps"} {
+				if job[required] == nil && shared[required] == nil {
+					problems = append(problems, d.position("jobs", name).problem("missing required key '%s' in job '%s'", required, name))
+				}
+			}
+		}
+
+		steps, _ := job["steps"].([]interface{})
+		for _, s := range steps {
+			step, ok := s.(map[interface{}]interface{})
+			if !ok || len(step) == 1 {
+				continue
+			}
+			stepNames := make([]string, 0, len(step))
+			for n := range step {
+				stepNames = append(stepNames, fmt.Sprint(n))
+			}
+			sort.Strings(stepNames)
+			problems = append(problems, d.position("jobs", name, "steps", stepNames[0]).problem("step must be a mapping of the name to the command in job '%s', but got %s", name, strings.Join(stepNames, ", ")))
+		}
+
+		// the requires are read from the mapping because the job isn't decoded if it has a type error
+		for _, r := range requiresOf(job) {
+			required := requiredJob(r)
+			if _, ok := jobs[required]; ok || required == "" {
+				continue
+			}
+			problems = append(problems, d.valuePosition(r, "jobs", name, "requires").problem("job '%s' requires unknown job '%s'", name, required))
+		}
+	}
+
+	sort.SliceStable(problems, func(i, j int) bool {
+		if problems[i].Line != problems[j].Line {
+			return problems[i].Line < problems[j].Line
+		}
+		if problems[i].Column != problems[j].Column {
+			return problems[i].Column < problems[j].Column
+		}
+		return problems[i].Message < problems[j].Message
+	})
+	return problems, nil
+}
+
+// requiresOf returns the names of the requires of the job, which can be a name as well as a list of them
+func requiresOf(job map[interface{}]interface{}) []string {
+	switch r := job["requires"].(type) {
+	case string:
+		return []string{r}
+	case []interface{}:
+		names := make([]string, 0, len(r))
+		for _, v := range r {
+			if name, ok := v.(string); ok {
+				names = append(names, name)
+			}
+		}
+		return names
+	}
+	return nil
+}
+
+func toMapping(m map[string]interface{}) map[interface{}]interface{} {
+	mapping := make(map[interface{}]interface{}, len(m))
+	for k, v := range m {
+		mapping[k] = v
+	}
+	return mapping
+}
@@ -0,0 +1,120 @@
+package screwdriver
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidate(t *testing.T) {
+	cases := []struct {
+		name   string
+		yaml   string
+		expect []Problem
+	}{
+		{
+			name: "success",
+			yaml: `shared:
+  image: node:12
+jobs:
+  main:
+    requires: [~pr, ~commit]
+    steps:
+      - test: |
+          npm test
+          echo "key: value"
+  publish:
+    requires:
+      - ~main
+      - ~sd@123:main
+      - stage@prod:setup
+    steps:
+      - publish: npm publish
+  deploy:
+    template: sd/deploy@1
+    requires: publish
+`,
+		},
+		{
+			name: "success with shared template",
+			yaml: "shared:\n  template: sd/build@1\njobs:\n  main:\n    requires: [~pr]\n",
+		},
+		{
+			name: "success with pipeline template",
+			yaml: "template: sd/pipeline@1\n",
+		},
+		{
+			name:   "missing jobs",
+			yaml:   "shared:\n  image: node:12\n",
+			expect: []Problem{{Line: 1, Column: 1, Message: "missing required key 'jobs'"}},
+		},
+		{
+			name:   "empty jobs",
+			yaml:   "jobs: {}\n",
+			expect: []Problem{{Line: 1, Column: 1, Message: "no jobs in 'jobs'"}},
+		},
+		{
+			name: "missing image and steps",
+			yaml: "jobs:\n  main:\n    requires: [~pr]\n",
+			expect: []Problem{
+				{Line: 2, Column: 3, Message: "missing required key 'image' in job 'main'"},
+				{Line: 2, Column: 3, Message: "missing required key 'steps' in job 'main'"},
+			},
+		},
+		{
+			name:   "syntax error",
+			yaml:   "jobs:\n  main: [\n",
+			expect: []Problem{{Line: 2, Message: "did not find expected node content"}},
+		},
+		{
+			name:   "not a mapping",
+			yaml:   "invalid\n",
+			expect: []Problem{{Line: 1, Column: 1, Message: "screwdriver.yaml must be a mapping of the keys like jobs"}},
+		},
+		{
+			name: "problems",
+			yaml: func() string {
+				b, err := ioutil.ReadFile(filepath.Join(testDir, "screwdriverProblems.yaml"))
+				if err != nil {
+					t.Fatal(err)
+				}
+				return string(b)
+			}(),
+			expect: []Problem{
+				{Line: 3, Column: 3, Message: "unknown key 'enviroment' in shared"},
+				{Line: 7, Column: 5, Message: "unknown key 'imgae' in job 'main'"},
+				{Line: 8, Column: 30, Message: "job 'main' requires unknown job 'tset'"},
+				{Line: 14, Column: 9, Message: "step must be a mapping of the name to the command in job 'main', but got publish, test"},
+				{Line: 20, Column: 9, Message: "job 'deploy' requires unknown job 'missing'"},
+				{Line: 24, Column: 7, Message: "cannot unmarshal !!seq into string"},
+				{Line: 25, Column: 3, Message: "missing required key 'steps' in job 'quoted'"},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			f, err := ioutil.TempFile("", "screwdriver.yaml")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.Remove(f.Name())
+			if _, err := f.WriteString(c.yaml); err != nil {
+				t.Fatal(err)
+			}
+			f.Close()
+
+			problems, err := Validate(f.Name())
+			assert.Nil(t, err)
+			assert.Equal(t, c.expect, problems)
+		})
+	}
+
+	t.Run("failure by missing file", func(t *testing.T) {
+		_, err := Validate(filepath.Join(testDir, "missing.yaml"))
+		assert.Contains(t, err.Error(), "failed to read screwdriver.yaml")
+	})
+}
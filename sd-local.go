@@ -14,6 +14,6 @@ func main() {
 
 	if err := cmd.Execute(); err != nil {
 		logrus.Error(err)
-		os.Exit(1)
+		os.Exit(cmd.ExitCode(err))
 	}
 }
@@ -0,0 +1,158 @@
+package sdcmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+
+	"github.com/screwdriver-cd/sd-local/screwdriver"
+	"github.com/sirupsen/logrus"
+)
+
+// storeVersion is the version of the store API to download the binaries of the commands from
+const storeVersion = "v1"
+
+// formatBinary is the format of the commands whose binary is published to the store.
+// The commands of the other formats like habitat are run by sd-cmd without the binary.
+const formatBinary = "binary"
+
+// execPattern matches the invocations of the commands in the steps like sd-cmd exec foo/bar@1.0.0
+var execPattern = regexp.MustCompile(`sd-cmd\s+exec\s+([A-Za-z0-9_-]+)/([A-Za-z0-9_-]+)@([A-Za-z0-9_.-]+)`)
+
+// exactVersionPattern matches the exact versions which are cached, while the tags and the ranges are resolved every time
+var exactVersionPattern = regexp.MustCompile(`^\d+\.\d+\.\d+$`)
+
+// Ref is the reference to the command of sd-cmd like foo/bar@1.0.0, whose version can be a tag like stable as well
+type Ref struct {
+	Namespace string
+	Name      string
+	Version   string
+}
+
+func (r Ref) String() string {
+	return fmt.Sprintf("%s/%s@%s", r.Namespace, r.Name, r.Version)
+}
+
+// Find returns the commands invoked by `sd-cmd exec` in the steps of the jobs in the order they appear
+func Find(jobs ...screwdriver.Job) []Ref {
+	refs := make([]Ref, 0)
+	found := make(map[Ref]bool)
+	for _, job := range jobs {
+		for _, step := range job.Steps {
+			for _, m := range execPattern.FindAllStringSubmatch(step.Command, -1) {
+				ref := Ref{Namespace: m[1], Name: m[2], Version: m[3]}
+				if !found[ref] {
+					found[ref] = true
+					refs = append(refs, ref)
+				}
+			}
+		}
+	}
+	return refs
+}
+
+// Fetcher fetches the binaries of the commands into the cache directory, which is mounted into the directory
+// sd-cmd looks up the commands in. The directory of a command is like <CacheDir>/<namespace>/<name>/<version>.
+type Fetcher struct {
+	API        screwdriver.CommandAPI
+	StoreURL   string
+	JWT        string
+	HTTPClient *http.Client
+	CacheDir   string
+	// Offline uses only the cached commands without the API and the store
+	Offline bool
+}
+
+// commandDir returns the directory of the binary of the command in the cache
+func (f *Fetcher) commandDir(ref Ref) string {
+	return filepath.Join(f.CacheDir, ref.Namespace, ref.Name, ref.Version)
+}
+
+// cached returns true if the binary of the command is in the cache
+func (f *Fetcher) cached(ref Ref) bool {
+	files, err := ioutil.ReadDir(f.commandDir(ref))
+	return err == nil && len(files) != 0
+}
+
+// Fetch fetches the binary of the command unless it is cached. The command of the tag like stable is fetched every time
+// because the tag may be moved to another version, and the cached one is used offline.
+func (f *Fetcher) Fetch(ctx context.Context, ref Ref) error {
+	if f.cached(ref) && (f.Offline || exactVersionPattern.MatchString(ref.Version)) {
+		logrus.Debugf("Using the cached command %s", ref)
+		return nil
+	}
+	if f.Offline {
+		return fmt.Errorf("command %s is not cached, run the build online once to fetch it", ref)
+	}
+
+	command, err := f.API.Command(ctx, ref.Namespace, ref.Name, ref.Version)
+	if err != nil {
+		return fmt.Errorf("failed to resolve command %s: %v", ref, err)
+	}
+	if command.Format != formatBinary {
+		logrus.Debugf("Command %s is run by sd-cmd in the build container because its format is %s", ref, command.Format)
+		return nil
+	}
+
+	logrus.Infof("Fetching command %s (%s)...", ref, command.Version)
+	return f.download(ctx, ref, command)
+}
+
+// download downloads the binary of the command from the store into the cache
+func (f *Fetcher) download(ctx context.Context, ref Ref, command screwdriver.Command) error {
+	url := fmt.Sprintf("%s/%s/commands/%s/%s/%s", f.StoreURL, storeVersion, command.Namespace, command.Name, command.Version)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to download command %s: %v", ref, err)
+	}
+	req.Header.Add("Authorization", "Bearer "+f.JWT)
+
+	res, err := f.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to download command %s: %v", ref, err)
+	}
+	defer res.Body.Close()
+
+	switch res.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return fmt.Errorf("binary of command %s is not found in the store", ref)
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return fmt.Errorf("not authorized to download command %s from the store: StatusCode %d", ref, res.StatusCode)
+	default:
+		return fmt.Errorf("failed to download command %s: StatusCode %d", ref, res.StatusCode)
+	}
+
+	// the binary is written into the temporary file first not to leave the broken one in the cache
+	dir := f.commandDir(ref)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to cache command %s: %v", ref, err)
+	}
+	tmp, err := ioutil.TempFile(dir, ".download-")
+	if err != nil {
+		return fmt.Errorf("failed to cache command %s: %v", ref, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = io.Copy(tmp, res.Body)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to download command %s: %v", ref, err)
+	}
+	if err := os.Chmod(tmp.Name(), 0755); err != nil {
+		return fmt.Errorf("failed to cache command %s: %v", ref, err)
+	}
+	file := path.Base(command.Binary.File)
+	if command.Binary.File == "" {
+		file = command.Name
+	}
+	return os.Rename(tmp.Name(), filepath.Join(dir, file))
+}
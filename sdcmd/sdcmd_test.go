@@ -0,0 +1,204 @@
+package sdcmd
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/screwdriver-cd/sd-local/screwdriver"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockCommandAPI struct {
+	command screwdriver.Command
+	err     error
+	called  int
+}
+
+func (m *mockCommandAPI) Command(ctx context.Context, namespace, name, version string) (screwdriver.Command, error) {
+	m.called++
+	return m.command, m.err
+}
+
+func newCommand(format string) screwdriver.Command {
+	command := screwdriver.Command{Namespace: "foo", Name: "bar", Version: "1.0.2", Format: format}
+	command.Binary.File = "./bin/bar.sh"
+	return command
+}
+
+func TestFind(t *testing.T) {
+	testCases := []struct {
+		name     string
+		jobs     []screwdriver.Job
+		expected []Ref
+	}{
+		{
+			name: "found",
+			jobs: []screwdriver.Job{
+				{Steps: []screwdriver.Step{
+					{Name: "test", Command: "sd-cmd exec foo/bar@1.0.0 arg && sd-cmd  exec foo/baz@stable"},
+					{Name: "again", Command: "sd-cmd exec foo/bar@1.0.0"},
+				}},
+				{Steps: []screwdriver.Step{
+					{Name: "publish", Command: "sd-cmd exec foo/qux@1"},
+				}},
+			},
+			expected: []Ref{
+				{Namespace: "foo", Name: "bar", Version: "1.0.0"},
+				{Namespace: "foo", Name: "baz", Version: "stable"},
+				{Namespace: "foo", Name: "qux", Version: "1"},
+			},
+		},
+		{
+			name: "not found",
+			jobs: []screwdriver.Job{
+				{Steps: []screwdriver.Step{{Name: "test", Command: "sd-cmd validate -f sd-command.yaml"}}},
+			},
+			expected: []Ref{},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, Find(tt.jobs...))
+		})
+	}
+}
+
+func TestFetch(t *testing.T) {
+	ref := Ref{Namespace: "foo", Name: "bar", Version: "1.0.2"}
+
+	t.Run("success", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/v1/commands/foo/bar/1.0.2", r.URL.Path)
+			assert.Equal(t, "Bearer jwt", r.Header.Get("Authorization"))
+			fmt.Fprint(w, "#!/bin/sh\necho bar\n")
+		}))
+		defer server.Close()
+
+		dir, err := ioutil.TempDir("", "sdcmd")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+
+		api := &mockCommandAPI{command: newCommand("binary")}
+		f := Fetcher{API: api, StoreURL: server.URL, JWT: "jwt", HTTPClient: http.DefaultClient, CacheDir: dir}
+
+		err = f.Fetch(context.Background(), ref)
+		assert.Nil(t, err)
+
+		file := filepath.Join(dir, "foo", "bar", "1.0.2", "bar.sh")
+		content, err := ioutil.ReadFile(file)
+		assert.Nil(t, err)
+		assert.Equal(t, "#!/bin/sh\necho bar\n", string(content))
+		info, err := os.Stat(file)
+		assert.Nil(t, err)
+		assert.Equal(t, os.FileMode(0755), info.Mode().Perm())
+
+		// the exact version is cached
+		err = f.Fetch(context.Background(), ref)
+		assert.Nil(t, err)
+		assert.Equal(t, 1, api.called)
+	})
+
+	t.Run("success with the format other than binary", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "sdcmd")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+
+		f := Fetcher{API: &mockCommandAPI{command: newCommand("habitat")}, CacheDir: dir}
+
+		err = f.Fetch(context.Background(), ref)
+		assert.Nil(t, err)
+		_, err = os.Stat(filepath.Join(dir, "foo"))
+		assert.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("success with the cached tag offline", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "sdcmd")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+
+		cached := filepath.Join(dir, "foo", "bar", "stable")
+		if err := os.MkdirAll(cached, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(cached, "bar.sh"), []byte("echo bar"), 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		api := &mockCommandAPI{err: fmt.Errorf("must not be called")}
+		f := Fetcher{API: api, CacheDir: dir, Offline: true}
+
+		err = f.Fetch(context.Background(), Ref{Namespace: "foo", Name: "bar", Version: "stable"})
+		assert.Nil(t, err)
+		assert.Equal(t, 0, api.called)
+	})
+
+	t.Run("failure offline without the cache", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "sdcmd")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+
+		f := Fetcher{API: &mockCommandAPI{}, CacheDir: dir, Offline: true}
+
+		err = f.Fetch(context.Background(), ref)
+		assert.Equal(t, "command foo/bar@1.0.2 is not cached, run the build online once to fetch it", err.Error())
+	})
+
+	t.Run("failure by resolving the command", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "sdcmd")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+
+		f := Fetcher{API: &mockCommandAPI{err: fmt.Errorf("command foo/bar@1.0.2 is not found")}, CacheDir: dir}
+
+		err = f.Fetch(context.Background(), ref)
+		assert.Equal(t, "failed to resolve command foo/bar@1.0.2: command foo/bar@1.0.2 is not found", err.Error())
+	})
+
+	testCases := []struct {
+		name     string
+		status   int
+		expected string
+	}{
+		{name: "not found in the store", status: http.StatusNotFound, expected: "binary of command foo/bar@1.0.2 is not found in the store"},
+		{name: "forbidden", status: http.StatusForbidden, expected: "not authorized to download command foo/bar@1.0.2 from the store: StatusCode 403"},
+		{name: "server error", status: http.StatusInternalServerError, expected: "failed to download command foo/bar@1.0.2: StatusCode 500"},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			dir, err := ioutil.TempDir("", "sdcmd")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(dir)
+
+			f := Fetcher{API: &mockCommandAPI{command: newCommand("binary")}, StoreURL: server.URL, JWT: "jwt", HTTPClient: http.DefaultClient, CacheDir: dir}
+
+			err = f.Fetch(context.Background(), ref)
+			assert.Equal(t, tt.expected, err.Error())
+			assert.False(t, f.cached(ref))
+		})
+	}
+}